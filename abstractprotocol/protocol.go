@@ -9,6 +9,8 @@ import (
 	"github.com/open-horizon/anax/metering"
 	"github.com/open-horizon/anax/persistence"
 	"github.com/open-horizon/anax/policy"
+	"github.com/open-horizon/anax/tracing"
+	"go.opentelemetry.io/otel/attribute"
 	"net/http"
 )
 
@@ -30,13 +32,16 @@ type ProtocolMessage interface {
 	Protocol() string
 	Version() int
 	AgreementId() string
+	TraceContext() map[string]string
+	SetTraceContext(tc map[string]string)
 }
 
 type BaseProtocolMessage struct {
-	MsgType   string `json:"type"`
-	AProtocol string `json:"protocol"`
-	AVersion  int    `json:"version"`
-	AgreeId   string `json:"agreementId"`
+	MsgType   string            `json:"type"`
+	AProtocol string            `json:"protocol"`
+	AVersion  int               `json:"version"`
+	AgreeId   string            `json:"agreementId"`
+	Trace     map[string]string `json:"traceContext,omitempty"` // The W3C trace context of the sender, when the agreement is being traced.
 }
 
 func (pm *BaseProtocolMessage) IsValid() bool {
@@ -67,6 +72,14 @@ func (pm *BaseProtocolMessage) AgreementId() string {
 	return pm.AgreeId
 }
 
+func (pm *BaseProtocolMessage) TraceContext() map[string]string {
+	return pm.Trace
+}
+
+func (pm *BaseProtocolMessage) SetTraceContext(tc map[string]string) {
+	pm.Trace = tc
+}
+
 // Extract the agreement protocol name from stringified message
 func ExtractProtocol(msg string) (string, error) {
 
@@ -217,6 +230,9 @@ func CreateProposal(p ProtocolHandler,
 	defaultPW string,
 	defaultNoData uint64) (*BaseProposal, error) {
 
+	span := tracing.StartSpan(agreementId, "abstractprotocol.CreateProposal", nil)
+	defer span.End()
+
 	if TCPolicy, err := policy.Create_Terms_And_Conditions(producerPolicy, consumerPolicy, workload, agreementId, defaultPW, defaultNoData, version); err != nil {
 		return nil, errors.New(fmt.Sprintf("Protocol %v initiation received error trying to merge policy %v and %v, error: %v", p.Name(), producerPolicy, consumerPolicy, err))
 	} else {
@@ -272,6 +288,12 @@ func DecideOnProposal(p ProtocolHandler,
 	replyErr := error(nil)
 	reply := NewProposalReply(p.Name(), proposal.Version(), proposal.AgreementId(), myId)
 
+	span := tracing.StartSpan(proposal.AgreementId(), "abstractprotocol.DecideOnProposal", proposal.TraceContext())
+	defer func() {
+		span.SetAttributes(attribute.Bool(tracing.ATTR_PROPOSAL_ACCEPTED, reply.ProposalAccepted()))
+		tracing.EndSpan(span, replyErr)
+	}()
+
 	var termsAndConditions, producerPolicy *policy.Policy
 
 	// Marshal the policies in the proposal into in memory policy objects
@@ -423,7 +445,15 @@ func NotifyMeterReading(p ProtocolHandler,
 // Send a message containing the proposal.
 func SendProtocolMessage(messageTarget interface{},
 	msg interface{},
-	sendMessage func(mt interface{}, pay []byte) error) error {
+	sendMessage func(mt interface{}, pay []byte) error) (err error) {
+
+	// Trace the delivery of the message, which includes encrypting it for the receiver, and pass the trace context to the
+	// receiver in the message so that its spans are part of the same trace.
+	if pm, ok := msg.(ProtocolMessage); ok {
+		span := tracing.StartSpan(pm.AgreementId(), "abstractprotocol.SendProtocolMessage", nil, attribute.String(tracing.ATTR_MESSAGE_TYPE, pm.Type()))
+		defer func() { tracing.EndSpan(span, err) }()
+		pm.SetTraceContext(tracing.TraceContext(span))
+	}
 
 	pay, err := json.Marshal(msg)
 	if err != nil {
@@ -18,7 +18,6 @@ import (
 	"github.com/open-horizon/anax/policy"
 	"github.com/open-horizon/anax/producer"
 	"github.com/open-horizon/anax/worker"
-	bolt "go.etcd.io/bbolt"
 	"net/http"
 	"reflect"
 	"strconv"
@@ -80,7 +79,7 @@ func MarkI18nMessages() {
 // must be safely-constructed!!
 type AgreementWorker struct {
 	worker.BaseWorker        // embedded field
-	db                       *bolt.DB
+	db                       persistence.AgentDatabase
 	devicePattern            string
 	protocols                map[string]bool
 	pm                       *policy.PolicyManager
@@ -92,7 +91,7 @@ type AgreementWorker struct {
 	limitedRetryEC           exchange.ExchangeContext
 }
 
-func NewAgreementWorker(name string, cfg *config.HorizonConfig, db *bolt.DB, pm *policy.PolicyManager) *AgreementWorker {
+func NewAgreementWorker(name string, cfg *config.HorizonConfig, db persistence.AgentDatabase, pm *policy.PolicyManager) *AgreementWorker {
 
 	var ec *worker.BaseExchangeContext
 	var lrec exchange.ExchangeContext
@@ -83,7 +83,7 @@ func NewAgreementBotWorker(name string, cfg *config.HorizonConfig, db persistenc
 		newMessagesToProcess: false,
 		nodeSearch:           NewNodeSearch(),
 		secretProvider:       s,
-		secretUpdateManager:  NewSecretUpdateManager(cfg.AgreementBot.SecretsUpdateCheckInterval, cfg.AgreementBot.SecretsUpdateCheckInterval, cfg.AgreementBot.SecretsUpdateCheckMaxInterval, cfg.AgreementBot.SecretsUpdateCheckIncrement),
+		secretUpdateManager:  NewSecretUpdateManager(cfg.AgreementBot.SecretsUpdateCheckInterval, cfg.AgreementBot.SecretsUpdateCheckInterval, cfg.AgreementBot.SecretsUpdateCheckMaxInterval, cfg.AgreementBot.SecretsUpdateCheckIncrement, cfg.AgreementBot.SecretRollout),
 	}
 
 	patternManager = NewPatternManager()
@@ -1635,6 +1635,13 @@ func (w *AgreementBotWorker) secretsProviderMaintenance() int {
 // This function is called by the secrets update sub worker to learn about secrets that have been updated.
 func (w *AgreementBotWorker) secretsUpdate() int {
 	nextRunWait := w.secretUpdateManager.PollInterval
+
+	// Secrets that change during a staged rollout are rolled out after it.
+	if w.secretUpdateManager.IsRolloutInProgress() {
+		glog.V(5).Infof(AWlogString("secret rollout in progress, skipping the check for updated secrets"))
+		return nextRunWait
+	}
+
 	secretUpdates, err := w.secretUpdateManager.CheckForUpdates(w.secretProvider, w.db)
 	if err != nil {
 		glog.Errorf(AWlogString(err))
@@ -24,7 +24,10 @@ import (
 	"github.com/open-horizon/anax/externalpolicy"
 	"github.com/open-horizon/anax/i18n"
 	"github.com/open-horizon/anax/policy"
+	"github.com/open-horizon/anax/tracing"
 	"github.com/open-horizon/anax/worker"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"golang.org/x/text/message"
 )
 
@@ -364,6 +367,9 @@ func (b *BaseAgreementWorker) InitiateNewAgreement(cph ConsumerProtocolHandler,
 
 	msgPrinter := i18n.GetMessagePrinter()
 
+	// The node is checked before an agreement id is assigned, so the time spent on it is recorded once there is one.
+	searchStart := time.Now()
+
 	// get node policy
 	nodePolicyHandler := exchange.GetHTTPNodePolicyHandler(b)
 	_, nodePolicy, err := compcheck.GetNodePolicy(nodePolicyHandler, wi.Device.Id, msgPrinter)
@@ -379,6 +385,17 @@ func (b *BaseAgreementWorker) InitiateNewAgreement(cph ConsumerProtocolHandler,
 		}
 	}
 
+	// Workloads are only deployed to the node when its maintenance window is open. Search the policy again when it opens.
+	if until, ok := maintenanceWindowDeferral(nodePolicy, time.Now()); !ok || until != 0 {
+		glog.V(3).Infof(BAWlogstring(workerId, fmt.Sprintf("node %v is outside of its maintenance window, not making an agreement for %v", wi.Device.Id, wi.ConsumerPolicy.Header.Name)))
+		if !ok {
+			// The node can never be changed, search again in a week in case its node policy was changed and not seen.
+			until = uint64(time.Now().Add(externalpolicy.MAINTENANCE_WINDOW_HORIZON).Unix())
+		}
+		b.nodeSearch.DeferRetry(wi.ConsumerPolicy.Header.Name, until)
+		return
+	}
+
 	// If a deployment policy is being used, set wi.ProducerPolicy to the node policy
 	if wi.ConsumerPolicy.PatternId == "" {
 		// non pattern case
@@ -415,6 +432,18 @@ func (b *BaseAgreementWorker) InitiateNewAgreement(cph ConsumerProtocolHandler,
 		glog.Infof(BAWlogstring(workerId, fmt.Sprintf("using AgreementId %v", agreementIdString)))
 	}
 
+	// Start tracing the agreement. The trace ends when the agreement is finalized or cancelled, or right here if the
+	// agreement is not proposed to the node.
+	tracing.StartAgreement(agreementIdString, "agbot.Agreement", nil, trace.WithTimestamp(searchStart),
+		trace.WithAttributes(attribute.String(tracing.ATTR_NODE_ID, wi.Device.Id), attribute.String(tracing.ATTR_POLICY_NAME, wi.ConsumerPolicy.Header.Name)))
+	tracing.RecordSpan(agreementIdString, "agbot.Search", searchStart, time.Now())
+	proposed := false
+	defer func() {
+		if !proposed {
+			tracing.EndAgreement(agreementIdString, errors.New("agreement was not proposed to the node"))
+		}
+	}()
+
 	bcType, bcName, bcOrg := (&wi.ProducerPolicy).RequiresKnownBC(cph.Name())
 
 	// Use the blockchain name to choose the handler
@@ -453,6 +482,7 @@ func (b *BaseAgreementWorker) InitiateNewAgreement(cph ConsumerProtocolHandler,
 	found := true        // if the service policy can be found from the businesspol_manager
 	var servicePol *externalpolicy.ExternalPolicy
 	var wlUsage *persistence.WorkloadUsage = nil
+	var required *externalpolicy.NodeResources // the node resources reserved for the containers of the services
 
 	for !foundWorkload {
 
@@ -717,8 +747,21 @@ func (b *BaseAgreementWorker) InitiateNewAgreement(cph ConsumerProtocolHandler,
 			}
 		}
 
+		// Make sure the node has room for the containers of the services. The resources reserved by the other agreements of the
+		// node are checked when the agreement is created.
+		capacity_match := true
+		if policy_match && userInput_match && secrets_match && nodeType == persistence.DEVICE_TYPE_DEVICE {
+			if required, err = compcheck.ServiceResourceRequirements(&topSvcDef, depServices, msgPrinter); err != nil {
+				glog.Warningf(BAWlogstring(workerId, fmt.Sprintf("Error getting the resource requirements of service %v/%v %v %v: %v", workload.Org, workloadDetails.URL, workloadDetails.Version, workloadDetails.Arch, err)))
+				capacity_match = false
+			} else if fits, reason := compcheck.CheckNodeCapacity(nodePolicy.Properties, nil, required, msgPrinter); !fits {
+				glog.Warningf(BAWlogstring(workerId, fmt.Sprintf("Node %v does not have room for service %v/%v %v %v: %v", wi.Device.Id, workload.Org, workloadDetails.URL, workloadDetails.Version, workloadDetails.Arch, reason)))
+				capacity_match = false
+			}
+		}
+
 		// All the error cases have been checked, now decide whether to propose this workload or try another version
-		if !policy_match || !userInput_match || !secrets_match {
+		if !policy_match || !userInput_match || !secrets_match || !capacity_match {
 			if !workload.HasEmptyPriority() {
 				// If this is not the first time through the loop, update the workload usage record, otherwise create it.
 				if lastWorkload != nil {
@@ -811,10 +854,16 @@ func (b *BaseAgreementWorker) InitiateNewAgreement(cph ConsumerProtocolHandler,
 		return
 	}
 
-	// Create pending agreement in database
-	if err := b.db.AgreementAttempt(agreementIdString, wi.Org, wi.Device.Id, nodeType, wi.ConsumerPolicy.Header.Name, bcType, bcName, bcOrg, cph.Name(), wi.ConsumerPolicy.PatternId, svcIds, wi.ConsumerPolicy.NodeH, b.config.AgreementBot.GetProtocolTimeout(nodeMaxHBInterval), b.config.AgreementBot.GetAgreementTimeout(nodeMaxHBInterval)); err != nil {
+	// Create pending agreement in database, with the node resources reserved for the services
+	if reserved, err := b.attemptAgreementWithResources(workerId, cph, agreementIdString, wi, nodePolicy, required, func() error {
+		return b.db.AgreementAttempt(agreementIdString, wi.Org, wi.Device.Id, nodeType, wi.ConsumerPolicy.Header.Name, bcType, bcName, bcOrg, cph.Name(), wi.ConsumerPolicy.PatternId, svcIds, wi.ConsumerPolicy.NodeH, b.config.AgreementBot.GetProtocolTimeout(nodeMaxHBInterval), b.config.AgreementBot.GetAgreementTimeout(nodeMaxHBInterval))
+	}, msgPrinter); err != nil {
 		glog.Errorf(BAWlogstring(workerId, fmt.Sprintf("error persisting agreement attempt: %v", err)))
 
+		// The node does not have room for the services, the policy is searched again when resources are freed on the node
+	} else if !reserved {
+		return
+
 		// Decoding device publicKey to []byte
 	} else if publicKeyBytes, err := base64.StdEncoding.DecodeString(wi.Device.PublicKey); err != nil {
 		glog.Errorf(BAWlogstring(workerId, fmt.Sprintf("error decoding device publicKey for node: %s, %v", wi.Device.Id, err)))
@@ -835,8 +884,12 @@ func (b *BaseAgreementWorker) InitiateNewAgreement(cph ConsumerProtocolHandler,
 		// TODO: Publish error on the message bus
 
 		// Update the agreement in the DB with the proposal and policy
-	} else if err := cph.PersistAgreement(wi, proposal, workerId); err != nil {
-		glog.Errorf(err.Error())
+	} else {
+		countProposal(PROPOSAL_SENT)
+		proposed = true
+		if err := cph.PersistAgreement(wi, proposal, workerId); err != nil {
+			glog.Errorf(err.Error())
+		}
 	}
 
 }
@@ -1008,6 +1061,9 @@ func (b *BaseAgreementWorker) HandleAgreementReply(cph ConsumerProtocolHandler,
 	reply := wi.Reply
 	protocolHandler := cph.AgreementProtocolHandler("", "", "") // Use the generic protocol handler
 
+	span := tracing.StartSpan(reply.AgreementId(), "agbot.HandleReply", reply.TraceContext(), attribute.Bool(tracing.ATTR_PROPOSAL_ACCEPTED, reply.ProposalAccepted()))
+	defer span.End()
+
 	// The reply message is usually deleted before recording on the blockchain. For now assume it will be deleted at the end. Early exit from
 	// this function is NOT allowed.
 	deletedMessage := false
@@ -1062,6 +1118,7 @@ func (b *BaseAgreementWorker) HandleAgreementReply(cph ConsumerProtocolHandler,
 		} else {
 			// Done handling the response successfully
 			ackReplyAsValid = true
+			countProposal(PROPOSAL_ACCEPTED)
 
 			// If we dont have a workload usage record for this device, then we need to create one. If there is already a
 			// workload usage record and workload rollback retry counting is enabled, then check to see if the workload priority
@@ -1160,6 +1217,7 @@ func (b *BaseAgreementWorker) HandleAgreementReply(cph ConsumerProtocolHandler,
 
 	} else {
 		glog.Errorf(BAWlogstring(workerId, fmt.Sprintf("received rejection from producer %v", reply)))
+		countProposal(PROPOSAL_REJECTED)
 
 		b.AddRetry(cph, reply.AgreementId(), workerId)
 
@@ -1295,6 +1353,9 @@ func (b *BaseAgreementWorker) AddRetry(cph ConsumerProtocolHandler, agreementId
 		glog.Warningf(BAWlogstring(workerId, fmt.Sprintf("discarding adding retry process for agreement id %v not in this agbot's database", agreementId)))
 	} else {
 		b.nodeSearch.AddRetry(ag.PolicyName, ag.AgreementCreationTime-b.config.GetAgbotRetryLookBackWindow())
+		if ag.Resources != nil {
+			b.nodeSearch.CapacityFreed(ag.DeviceId)
+		}
 	}
 }
 
@@ -1323,6 +1384,13 @@ func (b *BaseAgreementWorker) CancelAgreement(cph ConsumerProtocolHandler, agree
 		return false
 	}
 
+	tracing.EndAgreement(agreementId, errors.New(fmt.Sprintf("agreement cancelled: %v", cph.GetTerminationReason(reason))))
+
+	// The node resources reserved by the agreement are free, try the policies that are waiting for them
+	if ag.Resources != nil {
+		b.nodeSearch.CapacityFreed(ag.DeviceId)
+	}
+
 	// Update state in exchange
 	if err := DeleteConsumerAgreement(b.config.Collaborators.HTTPClientFactory.NewHTTPClient(nil), b.config.AgreementBot.ExchangeURL, cph.GetExchangeId(), cph.GetExchangeToken(), agreementId); err != nil {
 		glog.Errorf(BAWlogstring(workerId, fmt.Sprintf("error deleting agreement %v in exchange: %v", agreementId, err)))
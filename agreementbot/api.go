@@ -35,6 +35,7 @@ type API struct {
 	shutdownError  string
 	configFile     string
 	secretProvider secrets.AgbotSecrets
+	metricsHandler http.Handler
 }
 
 func NewAPIListener(name string, config *config.HorizonConfig, db persistence.AgbotDatabase, configFile string, s secrets.AgbotSecrets) *API {
@@ -52,6 +53,7 @@ func NewAPIListener(name string, config *config.HorizonConfig, db persistence.Ag
 		em:             events.NewEventStateManager(),
 		configFile:     configFile,
 		secretProvider: s,
+		metricsHandler: newMetricsHandler(db, s),
 	}
 
 	listener.listen(config.AgreementBot.APIListen)
@@ -360,6 +362,7 @@ func (a *API) listen(apiListen string) {
 		router.HandleFunc("/status", a.status).Methods("GET", "OPTIONS")
 		router.HandleFunc("/health", a.health).Methods("GET", "OPTIONS")
 		router.HandleFunc("/status/workers", a.workerstatus).Methods("GET", "OPTIONS")
+		router.HandleFunc("/metrics", a.metrics).Methods("GET", "OPTIONS")
 		router.HandleFunc("/node", a.node).Methods("GET", "DELETE", "OPTIONS")
 		router.HandleFunc("/config", a.config).Methods("GET", "OPTIONS")
 		router.HandleFunc("/cache/servedorg", a.ListServedOrgs).Methods("GET", "OPTIONS")
@@ -374,6 +377,9 @@ func (a *API) listen(apiListen string) {
 		router.HandleFunc("/ha/upgradingwlu/{org}/{group_name}/{policy_name}", a.ha_upgrading_wlu).Methods("GET", "DELETE", "OPTIONS")
 		router.HandleFunc("/ha/upgradingnode", a.ha_upgrading_node).Methods("GET", "DELETE", "OPTIONS")
 		router.HandleFunc("/ha/upgradingnode/{org}/{group_name}", a.ha_upgrading_node).Methods("GET", "DELETE", "OPTIONS")
+		router.HandleFunc("/rollout", a.rollout).Methods("GET", "OPTIONS")
+		router.HandleFunc("/rollout/{org}/{name}", a.rollout).Methods("GET", "OPTIONS")
+		router.HandleFunc("/rollout/{org}/{name}/{action}", a.rollout).Methods("POST", "OPTIONS")
 
 		if err := http.ListenAndServe(apiListen, nocache(router)); err != nil {
 			glog.Fatalf(APIlogString(fmt.Sprintf("failed to start listener on %v, error %v", apiListen, err)))
@@ -687,7 +693,7 @@ func (a *API) health(w http.ResponseWriter, r *http.Request) {
 			glog.Errorf(APIlogString(fmt.Sprintf("Unable to get DB heartbeat, error: %v", err)))
 		}
 		if a.secretProvider != nil {
-			health.LastVaultInteraction = (a.secretProvider).GetLastVaultStatus()
+			health.LastVaultInteraction = (a.secretProvider).GetLastStatus()
 		}
 		info.LiveHealth = health
 
@@ -713,6 +719,19 @@ func (a *API) workerstatus(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// Metrics in the Prometheus text format.
+func (a *API) metrics(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case "GET":
+		a.metricsHandler.ServeHTTP(w, r)
+	case "OPTIONS":
+		w.Header().Set("Allow", "GET, OPTIONS")
+		w.WriteHeader(http.StatusOK)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
 func (a *API) node(w http.ResponseWriter, r *http.Request) {
 
 	resource := "node"
@@ -1166,3 +1185,63 @@ func writeResponse(w http.ResponseWriter, payload interface{}, successStatusCode
 		return
 	}
 }
+
+// List the service version rollouts of the deployment policies, and pause, resume or abort the rollout of a policy.
+func (a *API) rollout(w http.ResponseWriter, r *http.Request) {
+
+	glog.V(5).Infof(APIlogString(fmt.Sprintf("Handling %v on service rollouts.", r.Method)))
+
+	pathVars := mux.Vars(r)
+	orgID := pathVars["org"]
+	name := pathVars["name"]
+	action := pathVars["action"]
+	policyName := fmt.Sprintf("%v/%v", orgID, name)
+
+	switch r.Method {
+	case "GET":
+		if orgID == "" {
+			if rollouts, err := a.db.FindServiceRollouts(); err != nil {
+				glog.Error(APIlogString(fmt.Sprintf("error finding service rollouts, error: %v", err)))
+				http.Error(w, "Internal server error", http.StatusInternalServerError)
+			} else {
+				writeResponse(w, rollouts, http.StatusOK)
+			}
+		} else if rollout, err := a.db.FindSingleServiceRollout(policyName); err != nil {
+			glog.Error(APIlogString(fmt.Sprintf("error finding service rollout of policy %v, error: %v", policyName, err)))
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+		} else if rollout == nil {
+			writeResponse(w, "service rollout not found for the deployment policy.", http.StatusNotFound)
+		} else {
+			writeResponse(w, rollout, http.StatusOK)
+		}
+
+	case "POST":
+		serviceRolloutLock.Lock()
+		defer serviceRolloutLock.Unlock()
+
+		if rollout, err := a.db.FindSingleServiceRollout(policyName); err != nil {
+			glog.Error(APIlogString(fmt.Sprintf("error finding service rollout of policy %v, error: %v", policyName, err)))
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+		} else if rollout == nil {
+			writeResponse(w, "service rollout not found for the deployment policy.", http.StatusNotFound)
+		} else if err := applyServiceRolloutAction(rollout, action, uint64(time.Now().Unix())); err != nil {
+			writeInputErr(w, http.StatusBadRequest, &APIUserInputError{Input: "action", Error: err.Error()})
+		} else if err := a.db.SaveServiceRollout(rollout); err != nil {
+			glog.Error(APIlogString(fmt.Sprintf("error saving service rollout of policy %v, error: %v", policyName, err)))
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+		} else {
+			glog.V(3).Infof(APIlogString(fmt.Sprintf("service rollout of policy %v is now %v", policyName, rollout.State)))
+			writeResponse(w, rollout, http.StatusOK)
+		}
+
+	case "OPTIONS":
+		if action == "" {
+			w.Header().Set("Allow", "GET, OPTIONS")
+		} else {
+			w.Header().Set("Allow", "POST, OPTIONS")
+		}
+		w.WriteHeader(http.StatusOK)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
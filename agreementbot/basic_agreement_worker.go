@@ -11,6 +11,7 @@ import (
 	"github.com/open-horizon/anax/config"
 	"github.com/open-horizon/anax/exchange"
 	"github.com/open-horizon/anax/policy"
+	"github.com/open-horizon/anax/tracing"
 	"github.com/open-horizon/anax/worker"
 	uuid "github.com/satori/go.uuid"
 )
@@ -245,17 +246,26 @@ func (a *BasicAgreementWorker) start(work *PrioritizedWorkQueue) {
 				lock := a.alm.getAgreementLock(wi.Reply.AgreementId())
 				lock.Lock()
 
+				span := tracing.StartSpan(wi.Reply.AgreementId(), "agbot.FinalizeAgreement", nil)
+				var finalizeErr error
+
 				// Update state in the database
 				if ag, err := a.db.AgreementFinalized(wi.Reply.AgreementId(), a.protocolHandler.Name()); err != nil {
 					glog.Errorf(bwlogstring(a.workerID, fmt.Sprintf("error persisting agreement %v finalized: %v", wi.Reply.AgreementId(), err)))
+					finalizeErr = err
 
 					// Update state in exchange
 				} else if pol, err := policy.DemarshalPolicy(ag.Policy); err != nil {
 					glog.Errorf(bwlogstring(a.workerID, fmt.Sprintf("error demarshalling policy from agreement %v, error: %v", wi.Reply.AgreementId(), err)))
+					finalizeErr = err
 				} else if err := a.protocolHandler.RecordConsumerAgreementState(wi.Reply.AgreementId(), pol, ag.Org, "Finalized Agreement", a.workerID); err != nil {
 					glog.Errorf(bwlogstring(a.workerID, fmt.Sprintf("error setting agreement %v finalized state in exchange: %v", wi.Reply.AgreementId(), err)))
+					finalizeErr = err
 				}
 				lock.Unlock()
+
+				tracing.EndSpan(span, finalizeErr)
+				tracing.EndAgreement(wi.Reply.AgreementId(), finalizeErr)
 			}
 
 		} else if workItem.Type() == DATARECEIVEDACK {
@@ -443,6 +453,9 @@ func (a *BasicAgreementWorker) start(work *PrioritizedWorkQueue) {
 
 					// Drop the agreement lock
 					lock.Unlock()
+
+				} else if wi.Reply.IsSecretRevert() {
+					glog.V(3).Infof(bwlogstring(a.workerID, fmt.Sprintf("secret revert accepted %v", wi.Reply.ShortString())))
 				}
 
 			} else {
@@ -463,6 +476,26 @@ func (a *BasicAgreementWorker) start(work *PrioritizedWorkQueue) {
 						}
 					}
 
+				} else if wi.Reply.IsSecretUpdate() {
+					// Log the reject and record it, so that a staged secret rollout that includes this agreement is halted.
+					glog.Errorf(bwlogstring(a.workerID, fmt.Sprintf("agent rejected update %v", wi.Reply.ShortString())))
+
+					lock := a.alm.getAgreementLock(wi.Reply.AgreementId())
+					lock.Lock()
+
+					if agreement, err := a.db.FindSingleAgreementByAgreementId(wi.Reply.AgreementId(), a.protocolHandler.Name(), []persistence.AFilter{}); err != nil {
+						glog.Errorf(bwlogstring(a.workerID, fmt.Sprintf("error querying agreement %v, error: %v", wi.Reply.AgreementId(), err)))
+					} else if agreement != nil {
+						if _, err := a.db.AgreementSecretUpdateNackTime(wi.Reply.AgreementId(), a.protocolHandler.Name(), agreement.LastSecretUpdateTime); err != nil {
+							glog.Errorf(bwlogstring(a.workerID, fmt.Sprintf("unable to save secret update nack time for %s, error: %v", wi.Reply.AgreementId(), err)))
+						}
+					} else {
+						// Agreement must belong to other agbot
+						deleteMessage = false
+					}
+
+					lock.Unlock()
+
 				} else {
 					// Log the reject and then update the state of the system to prevent further updates for this change.
 					glog.Errorf(bwlogstring(a.workerID, fmt.Sprintf("agent rejected update %v", wi.Reply.ShortString())))
@@ -67,6 +67,7 @@ type ConsumerProtocolHandler interface {
 	SetBlockchainWritable(ev *events.AccountFundedMessage)
 	IsBlockchainWritable(typeName string, name string, org string) bool
 	CanCancelNow(agreement *persistence.Agreement) bool
+	CancelAgreement(ag persistence.Agreement, reason string, cph ConsumerProtocolHandler, policyMatches bool)
 	DeferCommand(cmd AgreementWork)
 	GetDeferredCommands() []AgreementWork
 	HandleDeferredCommands()
@@ -307,6 +308,10 @@ func (b *BaseConsumerProtocolHandler) HandlePolicyChanged(cmd *PolicyChangedComm
 
 		stillValidAgs := []string{}
 
+		// The agreements for a previous service version are not cancelled right away when the policy rolls out new
+		// versions in waves, the governance cancels them when their wave starts.
+		rolloutNodes := []persistence.ServiceRolloutNode{}
+
 		if agreements, err := b.db.FindAgreements([]persistence.AFilter{persistence.UnarchivedAFilter(), InProgress()}, cph.Name()); err == nil {
 			for _, ag := range agreements {
 
@@ -340,13 +345,22 @@ func (b *BaseConsumerProtocolHandler) HandlePolicyChanged(cmd *PolicyChangedComm
 						glog.Infof(BCPHlogstring(b.Name(), fmt.Sprintf("for current agreement %v: agStillValid: %v, policyMatches: %v, noNewPriority: %v, clusterNSNotChange: %v", ag.CurrentAgreementId, agStillValid, policyMatches, noNewPriority, clusterNSNotChange)))
 					}
 
-					if !agStillValid {
+					if !agStillValid && eventPol.Rollout != nil && ag.Pattern == "" && policyMatches && !noNewPriority {
+						glog.V(3).Infof(BCPHlogstring(b.Name(), fmt.Sprintf("agreement %v has a policy %v with a new service version, deferring the cancel to the service rollout", ag.CurrentAgreementId, pol.Header.Name)))
+						rolloutNodes = append(rolloutNodes, persistence.ServiceRolloutNode{DeviceId: ag.DeviceId, AgreementId: ag.CurrentAgreementId, Protocol: cph.Name(), State: persistence.ROLLOUT_NODE_PENDING})
+						stillValidAgs = append(stillValidAgs, ag.CurrentAgreementId)
+					} else if !agStillValid {
 						glog.Warningf(BCPHlogstring(b.Name(), fmt.Sprintf("agreement %v has a policy %v that has changed incompatibly. Cancelling agreement: %v", ag.CurrentAgreementId, pol.Header.Name, err)))
 						b.CancelAgreement(ag, TERM_REASON_POLICY_CHANGED, cph, policyMatches)
 					} else {
 						if glog.V(5) {
 							glog.Infof(BCPHlogstring(b.Name(), fmt.Sprintf("current agreement %v is still valid", ag.CurrentAgreementId)))
 						}
+						if ag.UpgradeDeferredUntil != 0 {
+							if _, err := b.db.AgreementUpgradeDeferred(ag.CurrentAgreementId, cph.Name(), 0); err != nil {
+								glog.Errorf(BCPHlogstring(b.Name(), fmt.Sprintf("unable to clear the deferred upgrade of agreement %v, error: %v", ag.CurrentAgreementId, err)))
+							}
+						}
 						stillValidAgs = append(stillValidAgs, ag.CurrentAgreementId)
 					}
 				} else {
@@ -360,6 +374,12 @@ func (b *BaseConsumerProtocolHandler) HandlePolicyChanged(cmd *PolicyChangedComm
 			glog.Errorf(BCPHlogstring(b.Name(), fmt.Sprintf("error searching database: %v", err)))
 		}
 
+		if eventPol.Rollout != nil {
+			if err := addToServiceRollout(b.db, eventPol, rolloutNodes); err != nil {
+				glog.Errorf(BCPHlogstring(b.Name(), fmt.Sprintf("unable to save the service rollout of policy %v, error: %v", eventPol.Header.Name, err)))
+			}
+		}
+
 		AgNotKept := func(validAgs []string) persistence.WUFilter {
 			return func(w persistence.WorkloadUsage) bool { return !cutil.SliceContains(validAgs, w.CurrentAgreementId) }
 		}
@@ -590,6 +610,11 @@ func (b *BaseConsumerProtocolHandler) HandlePolicyDeleted(cmd *PolicyDeletedComm
 				}
 			}
 		}
+
+		// The agreements of the policy are all cancelled, so there is nothing left to roll out.
+		if err := b.db.DeleteServiceRollout(eventPol.Header.Name); err != nil {
+			glog.Errorf(BCPHlogstring(b.Name(), fmt.Sprintf("unable to delete the service rollout of policy %v, error: %v", eventPol.Header.Name, err)))
+		}
 	}
 
 	InProgress := func() persistence.AFilter {
@@ -742,6 +767,12 @@ func (b *BaseConsumerProtocolHandler) CancelAgreement(ag persistence.Agreement,
 	if glog.V(5) {
 		glog.Infof(BCPHlogstring(b.Name(), fmt.Sprintf("Canceling Agreement: %v, reason: %v", ag, reason)))
 	}
+
+	// A service upgrade waits for the node's maintenance window.
+	if reason == TERM_REASON_POLICY_CHANGED && policyMatches && b.deferUpgradeToMaintenanceWindow(ag, cph) {
+		return
+	}
+
 	// Remove any workload usage records (non-HA) or mark for pending upgrade (HA). There might not be a workload usage record
 	// if the consumer policy does not specify the workload priority section.
 	if wlUsage, err := b.db.FindSingleWorkloadUsageByDeviceAndPolicyName(ag.DeviceId, ag.PolicyName); err != nil {
@@ -48,6 +48,12 @@ func (w *AgreementBotWorker) GovernAgreements() int {
 	secretUpdates := w.secretUpdateManager.GetNextUpdateEvent()
 	secretExistsMap := make(map[string]bool)
 
+	// When secret updates are rolled out in waves, the same secret updates are processed by several governance passes.
+	secretRollout := w.secretUpdateManager.StartRollout(secretUpdates, w.db)
+	if secretRollout != nil {
+		secretExistsMap = secretRollout.SecretExists
+	}
+
 	// Look at all agreements across all protocols
 	for _, agp := range policy.AllAgreementProtocols() {
 
@@ -155,6 +161,7 @@ func (w *AgreementBotWorker) GovernAgreements() int {
 					}
 					now := uint64(time.Now().Unix())
 					if ag.AgreementCreationTime+timeout < now {
+						countProposal(PROPOSAL_TIMEOUT)
 						w.nodeSearch.AddRetry(ag.PolicyName, ag.AgreementCreationTime-w.BaseWorker.Manager.Config.GetAgbotRetryLookBackWindow())
 						w.TerminateAgreement(&ag, protocolHandler.GetTerminationCode(TERM_REASON_NO_REPLY))
 					}
@@ -173,7 +180,8 @@ func (w *AgreementBotWorker) GovernAgreements() int {
 					}
 
 					// If there are secret updates for this agreement AND the agreement has not seen these updates yet, then process them for this agreement.
-					if len(updatedSecrets) != 0 && ag.LastSecretUpdateTime < newestUpdateTime {
+					// In a staged rollout, the agreement is only updated when its wave is rolled out.
+					if len(updatedSecrets) != 0 && ag.LastSecretUpdateTime < newestUpdateTime && (secretRollout == nil || w.admitToSecretRollout(secretRollout, &ag, agp)) {
 
 						// Extract the consumer policy from agreement.
 						pol, err := policy.DemarshalPolicy(ag.Policy)
@@ -282,6 +290,9 @@ func (w *AgreementBotWorker) GovernAgreements() int {
 
 						// Send the Update Agreement protocol message
 						protocolHandler.UpdateAgreement(&ag, basicprotocol.MsgUpdateTypeSecret, updatedBindings, protocolHandler)
+						if secretRollout != nil {
+							secretRollout.Sent(ag.CurrentAgreementId, agp, updatedBindings)
+						}
 
 						if _, err := w.db.AgreementSecretUpdateTime(ag.CurrentAgreementId, agp, newestUpdateTime); err != nil {
 							glog.Errorf(logString(fmt.Sprintf("unable to save secret update time for %s, error: %v", ag.CurrentAgreementId, err)))
@@ -317,6 +328,17 @@ func (w *AgreementBotWorker) GovernAgreements() int {
 		}
 	}
 
+	// A staged rollout keeps its secret updates until every wave has been rolled out.
+	if secretRollout != nil {
+		if !w.governSecretRollout(secretRollout) {
+			w.secretUpdateManager.SaveRollout(w.db)
+			w.secretUpdateManager.RequeueUpdateEvent(secretUpdates)
+			secretUpdates = nil
+		} else {
+			w.secretUpdateManager.EndRollout(w.db)
+		}
+	}
+
 	// After processing all agreements, update the agbot's secret manager DB to indicate that all secrets have been processed.
 	if secretUpdates != nil {
 		for _, su := range secretUpdates.Updates {
@@ -335,6 +357,12 @@ func (w *AgreementBotWorker) GovernAgreements() int {
 	// Govern the HA partners by examining workload usage records.
 	w.governHAPartners()
 
+	// Move the service version rollouts to their next wave.
+	w.governServiceRollouts()
+
+	// Upgrade the agreements that were waiting for their node's maintenance window.
+	w.governDeferredUpgrades()
+
 	// Dynamically adjust skips to account for long NH check rates.
 	if w.GovTiming.nhSkip == 0 {
 		w.GovTiming.nhSkip = calculateSkipTime(discoveredNHWaitTime, w.BaseWorker.Manager.Config.AgreementBot.ProcessGovernanceIntervalS)
@@ -0,0 +1,89 @@
+package agreementbot
+
+import (
+	"fmt"
+	"github.com/golang/glog"
+	"github.com/open-horizon/anax/agreementbot/persistence"
+	"github.com/open-horizon/anax/compcheck"
+	"github.com/open-horizon/anax/exchange"
+	"github.com/open-horizon/anax/externalpolicy"
+	"github.com/open-horizon/anax/i18n"
+	"github.com/open-horizon/anax/policy"
+	"time"
+)
+
+// Returns 0 if the node policy allows the node to be changed at the given time. Otherwise it returns the next time that
+// it can be changed, or 0 and false if its maintenance windows never allow it. An invalid schedule never defers anything,
+// the node policy is validated when it is saved so this should not happen.
+func maintenanceWindowDeferral(nodePolicy *policy.Policy, now time.Time) (uint64, bool) {
+	if nodePolicy == nil {
+		return 0, true
+	}
+
+	schedule, err := externalpolicy.GetMaintenanceSchedule(nodePolicy.Properties)
+	if err != nil {
+		glog.Errorf(AWlogString(fmt.Sprintf("ignoring the maintenance windows of node policy %v, error: %v", nodePolicy.Header.Name, err)))
+		return 0, true
+	} else if schedule.Allows(now) {
+		return 0, true
+	} else if next, ok := schedule.NextAllowed(now); ok {
+		return uint64(next.Unix()), true
+	}
+	return 0, false
+}
+
+// Service upgrades that cancel an agreement are deferred until the node's maintenance window is open. Returns true if the
+// cancel was deferred, in which case the governance cancels the agreement when the window opens. The node policy is checked
+// again at that time, so a changed maintenance window is honored.
+func (b *BaseConsumerProtocolHandler) deferUpgradeToMaintenanceWindow(ag persistence.Agreement, cph ConsumerProtocolHandler) bool {
+
+	msgPrinter := i18n.GetMessagePrinter()
+
+	_, nodePolicy, err := compcheck.GetNodePolicy(exchange.GetHTTPNodePolicyHandler(cph), ag.DeviceId, msgPrinter)
+	if err != nil {
+		glog.Errorf(BCPHlogstring(b.Name(), fmt.Sprintf("unable to get the node policy of %v to check its maintenance window, upgrading agreement %v now, error: %v", ag.DeviceId, ag.CurrentAgreementId, err)))
+		return false
+	}
+
+	now := time.Now()
+	until, ok := maintenanceWindowDeferral(nodePolicy, now)
+	if ok && until == 0 {
+		return false
+	} else if !ok {
+		// The node can never be changed, check again in a week in case its node policy was changed and not seen.
+		until = uint64(now.Add(externalpolicy.MAINTENANCE_WINDOW_HORIZON).Unix())
+	}
+
+	glog.V(3).Infof(BCPHlogstring(b.Name(), fmt.Sprintf("node %v is outside of its maintenance window, deferring the upgrade of agreement %v until %v", ag.DeviceId, ag.CurrentAgreementId, time.Unix(int64(until), 0))))
+	if ag.UpgradeDeferredUntil != until {
+		if _, err := b.db.AgreementUpgradeDeferred(ag.CurrentAgreementId, cph.Name(), until); err != nil {
+			glog.Errorf(BCPHlogstring(b.Name(), fmt.Sprintf("unable to save the deferred upgrade of agreement %v, error: %v", ag.CurrentAgreementId, err)))
+		}
+	}
+	return true
+}
+
+// Cancel the agreements whose service upgrade was deferred to a maintenance window that should now be open.
+func (w *AgreementBotWorker) governDeferredUpgrades() {
+
+	now := uint64(time.Now().Unix())
+	DeferredUpgradeDue := func() persistence.AFilter {
+		return func(a persistence.Agreement) bool {
+			return a.UpgradeDeferredUntil != 0 && a.UpgradeDeferredUntil <= now && a.AgreementTimedout == 0
+		}
+	}
+
+	for _, protocol := range policy.AllAgreementProtocols() {
+		agreements, err := w.db.FindAgreements([]persistence.AFilter{persistence.UnarchivedAFilter(), DeferredUpgradeDue()}, protocol)
+		if err != nil {
+			glog.Errorf(AWlogString(fmt.Sprintf("unable to read agreements with deferred upgrades, error: %v", err)))
+			continue
+		}
+
+		cph := w.consumerPH.Get(protocol)
+		for _, ag := range agreements {
+			glog.V(3).Infof(AWlogString(fmt.Sprintf("retrying the deferred upgrade of agreement %v on node %v", ag.CurrentAgreementId, ag.DeviceId)))
+			cph.CancelAgreement(ag, TERM_REASON_POLICY_CHANGED, cph, true)
+		}
+	}
+}
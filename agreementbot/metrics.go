@@ -0,0 +1,158 @@
+package agreementbot
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/golang/glog"
+	"github.com/open-horizon/anax/agreementbot/persistence"
+	"github.com/open-horizon/anax/agreementbot/secrets"
+	"github.com/open-horizon/anax/exchange"
+	"github.com/open-horizon/anax/worker"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// The metrics exposed by the agbot /metrics API, in the Prometheus text format. Metrics about the work done by the
+// agbot are updated as the work happens. Metrics about the state of the agbot (agreements, partitions and the secrets
+// manager) are read from their source when the metrics are scraped.
+
+const (
+	PROPOSAL_SENT     = "sent"
+	PROPOSAL_ACCEPTED = "accepted"
+	PROPOSAL_REJECTED = "rejected"
+	PROPOSAL_TIMEOUT  = "timeout"
+)
+
+var workQueueDepth = prometheus.NewGaugeVec(
+	prometheus.GaugeOpts{
+		Namespace: "horizon",
+		Subsystem: "agbot",
+		Name:      "work_queue_depth",
+		Help:      "Number of work items buffered in the agreement work queues, by priority.",
+	},
+	[]string{"priority"},
+)
+
+var workQueueWait = prometheus.NewHistogramVec(
+	prometheus.HistogramOpts{
+		Namespace: "horizon",
+		Subsystem: "agbot",
+		Name:      "work_queue_wait_seconds",
+		Help:      "Time spent by work items in the agreement work queues before a worker picked them up, by priority.",
+		Buckets:   []float64{0.01, 0.1, 0.5, 1, 5, 10, 30, 60, 300, 900},
+	},
+	[]string{"priority"},
+)
+
+var proposalsTotal = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Namespace: "horizon",
+		Subsystem: "agbot",
+		Name:      "proposals_total",
+		Help:      "Number of agreement proposals by result: sent, accepted or rejected by the node, or timed out waiting for a reply.",
+	},
+	[]string{"result"},
+)
+
+func init() {
+	for _, p := range []string{HIGH_PRIORITY, LOW_PRIORITY} {
+		workQueueDepth.WithLabelValues(p)
+		workQueueWait.WithLabelValues(p)
+	}
+	for _, r := range []string{PROPOSAL_SENT, PROPOSAL_ACCEPTED, PROPOSAL_REJECTED, PROPOSAL_TIMEOUT} {
+		proposalsTotal.WithLabelValues(r)
+	}
+}
+
+func countProposal(result string) {
+	proposalsTotal.WithLabelValues(result).Inc()
+}
+
+// Collects the metrics that are read from the agbot database and the secrets manager on each scrape.
+type agbotStateCollector struct {
+	db             persistence.AgbotDatabase
+	secretProvider secrets.AgbotSecrets
+	agreements     *prometheus.Desc
+	partitionOwner *prometheus.Desc
+	secretsReady   *prometheus.Desc
+	secretsLastOK  *prometheus.Desc
+}
+
+func newAgbotStateCollector(db persistence.AgbotDatabase, s secrets.AgbotSecrets) *agbotStateCollector {
+	return &agbotStateCollector{
+		db:             db,
+		secretProvider: s,
+		agreements: prometheus.NewDesc("horizon_agbot_agreements",
+			"Number of agreements in the database, by partition and state (active or archived).",
+			[]string{"partition", "state"}, nil),
+		partitionOwner: prometheus.NewDesc("horizon_agbot_partition_owner",
+			"Set to 1 for the agbot instance that owns each database partition.",
+			[]string{"partition", "owner"}, nil),
+		secretsReady: prometheus.NewDesc("horizon_agbot_secrets_provider_logged_in",
+			"Set to 1 when the agbot is logged in to the secrets manager.",
+			nil, nil),
+		secretsLastOK: prometheus.NewDesc("horizon_agbot_secrets_provider_last_interaction_timestamp_seconds",
+			"Time of the last successful interaction with the secrets manager, in seconds since the epoch.",
+			nil, nil),
+	}
+}
+
+func (c *agbotStateCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.agreements
+	ch <- c.partitionOwner
+	ch <- c.secretsReady
+	ch <- c.secretsLastOK
+}
+
+// Errors reading the database are logged and the affected metrics are left out of the scrape, so that the rest of
+// the metrics are still available when the database is in trouble.
+func (c *agbotStateCollector) Collect(ch chan<- prometheus.Metric) {
+
+	if partitions, err := c.db.FindPartitions(); err != nil {
+		glog.Errorf(APIlogString(fmt.Sprintf("error finding all partitions for metrics, error: %v", err)))
+	} else {
+		for _, p := range partitions {
+			if owner, err := c.db.GetPartitionOwner(p); err != nil {
+				glog.Errorf(APIlogString(fmt.Sprintf("error finding partition %v owner for metrics, error: %v", p, err)))
+			} else {
+				ch <- prometheus.MustNewConstMetric(c.partitionOwner, prometheus.GaugeValue, 1, p, owner)
+			}
+
+			if active, archived, err := c.db.GetAgreementCount(p); err != nil {
+				glog.Errorf(APIlogString(fmt.Sprintf("error finding agreement count in partition %v for metrics, error: %v", p, err)))
+			} else {
+				ch <- prometheus.MustNewConstMetric(c.agreements, prometheus.GaugeValue, float64(active), p, "active")
+				ch <- prometheus.MustNewConstMetric(c.agreements, prometheus.GaugeValue, float64(archived), p, "archived")
+			}
+		}
+	}
+
+	if c.secretProvider != nil {
+		ready := 0.0
+		if c.secretProvider.IsReady() {
+			ready = 1
+		}
+		ch <- prometheus.MustNewConstMetric(c.secretsReady, prometheus.GaugeValue, ready)
+		ch <- prometheus.MustNewConstMetric(c.secretsLastOK, prometheus.GaugeValue, float64(c.secretProvider.GetLastStatus()))
+	}
+}
+
+// Create the handler for the /metrics API. Each API listener has its own registry, so the agent's metrics are not
+// exposed by the agbot. The Go runtime, process, worker status and exchange request metrics are recorded for the
+// whole process, when the agent and the agbot run in the same process both of them expose these metrics.
+func newMetricsHandler(db persistence.AgbotDatabase, s secrets.AgbotSecrets) http.Handler {
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		exchange.ExchangeRequestDuration,
+		worker.NewWorkerStatusCollector(),
+		workQueueDepth,
+		workQueueWait,
+		proposalsTotal,
+		newAgbotStateCollector(db, s),
+	)
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+}
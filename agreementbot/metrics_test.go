@@ -0,0 +1,102 @@
+//go:build unit
+// +build unit
+
+package agreementbot
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/open-horizon/anax/agreementbot/persistence/bolt"
+	"github.com/open-horizon/anax/agreementbot/secrets"
+	"github.com/open-horizon/anax/config"
+	"github.com/open-horizon/anax/policy"
+)
+
+func Test_metrics(t *testing.T) {
+
+	dir, err := os.MkdirTemp("", "agbot-metrics-")
+	if err != nil {
+		t.Fatalf("unable to create temp dir, error: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	db := new(bolt.AgbotBoltDB)
+	if err := db.Initialize(&config.HorizonConfig{AgreementBot: config.AGConfig{DBPath: dir}}); err != nil {
+		t.Fatalf("unable to initialize agbot database, error: %v", err)
+	}
+	defer db.Close()
+
+	if err := db.AgreementAttempt("agreement1", "myorg", "myorg/node1", "device", "policy1", "", "", "", policy.BasicProtocol, "", []string{}, policy.NodeHealth{}, 0, 0); err != nil {
+		t.Fatalf("unable to create agreement, error: %v", err)
+	}
+
+	countProposal(PROPOSAL_SENT)
+
+	q := NewPrioritizedWorkQueue(uint64(10), 2, 10)
+	wi := NewCancelAgreement("agreement1", policy.BasicProtocol, 100, 0)
+	q.InboundHigh() <- &wi
+	<-q.Receive()
+	q.Close()
+
+	handler := newMetricsHandler(db, nil)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("metrics should return %v, returned %v", http.StatusOK, rec.Code)
+	}
+
+	body, _ := io.ReadAll(rec.Body)
+	metrics := string(body)
+	for _, expected := range []string{
+		`horizon_agbot_agreements{partition="global",state="active"} 1`,
+		`horizon_agbot_agreements{partition="global",state="archived"} 0`,
+		`horizon_agbot_partition_owner{owner="global",partition="global"} 1`,
+		`horizon_agbot_proposals_total{result="sent"}`,
+		`horizon_agbot_proposals_total{result="timeout"}`,
+		`horizon_agbot_work_queue_wait_seconds_count{priority="high"}`,
+		`horizon_agbot_work_queue_depth{priority="low"} 0`,
+	} {
+		if !strings.Contains(metrics, expected) {
+			t.Errorf("metrics should contain %v, metrics are:\n%v", expected, metrics)
+		}
+	}
+
+	if strings.Contains(metrics, "horizon_agbot_secrets_provider_logged_in") {
+		t.Errorf("metrics should not contain the secrets provider status when there is no secrets manager")
+	}
+
+	// The secrets provider metrics come from the configured provider, whichever it is.
+	rec = httptest.NewRecorder()
+	newMetricsHandler(db, &fakeSecretsProvider{ready: true, lastStatus: 1700000000}).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	body, _ = io.ReadAll(rec.Body)
+	metrics = string(body)
+	for _, expected := range []string{
+		`horizon_agbot_secrets_provider_logged_in 1`,
+		`horizon_agbot_secrets_provider_last_interaction_timestamp_seconds 1.7e+09`,
+	} {
+		if !strings.Contains(metrics, expected) {
+			t.Errorf("metrics should contain %v, metrics are:\n%v", expected, metrics)
+		}
+	}
+}
+
+// A secrets provider that only reports its status.
+type fakeSecretsProvider struct {
+	secrets.AgbotSecrets
+	ready      bool
+	lastStatus uint64
+}
+
+func (f *fakeSecretsProvider) IsReady() bool {
+	return f.ready
+}
+
+func (f *fakeSecretsProvider) GetLastStatus() uint64 {
+	return f.lastStatus
+}
@@ -0,0 +1,112 @@
+package agreementbot
+
+import (
+	"errors"
+	"fmt"
+	"github.com/golang/glog"
+	"github.com/open-horizon/anax/agreementbot/persistence"
+	"github.com/open-horizon/anax/compcheck"
+	"github.com/open-horizon/anax/exchange"
+	"github.com/open-horizon/anax/externalpolicy"
+	"github.com/open-horizon/anax/policy"
+	"golang.org/x/text/message"
+	"sync"
+)
+
+// How long to wait before searching a policy again when a node did not have room for its services, in case the resources
+// are freed by an agreement that another agbot made with the node.
+const NODE_CAPACITY_RETRY_S = 600
+
+// Serializes the node resource checks of the agreement workers, so that agreements made with a node at the same time
+// do not reserve the same resources. The lock is local to this agbot process. The agreements of other agbots are counted
+// from the status of the node in the exchange, once the node runs their services.
+var nodeCapacityLock sync.Mutex
+
+// Returns the node resources reserved by the active agreements of the node. The agreements of this agbot are counted from
+// its database, with the resources that were reserved when they were made. The node reports the services of all its
+// agreements in its status in the exchange, so the services of the agreements that other agbots made with the node, or
+// that other instances of this agbot made in the partitions they own, are counted from their service definitions.
+func committedNodeResources(db persistence.AgbotDatabase, deviceId string, getNodeStatus exchange.NodeFullStatusHandler,
+	getServiceDefs exchange.ServiceDefResolverHandler, msgPrinter *message.Printer) (*externalpolicy.NodeResources, error) {
+
+	DevAFilter := func() persistence.AFilter {
+		return func(a persistence.Agreement) bool { return a.DeviceId == deviceId }
+	}
+
+	committed := externalpolicy.NodeResources{}
+	localAgreements := make(map[string]bool)
+	for _, protocol := range policy.AllAgreementProtocols() {
+		agreements, err := db.FindAgreements([]persistence.AFilter{DevAFilter()}, protocol)
+		if err != nil {
+			return nil, err
+		}
+		for _, ag := range agreements {
+			localAgreements[ag.CurrentAgreementId] = true
+			if ag.Archived || ag.AgreementTimedout != 0 || ag.Resources == nil {
+				continue
+			}
+			committed.Add(ag.Resources)
+		}
+	}
+
+	status, err := getNodeStatus(deviceId)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("unable to get the status of node %v, error: %v", deviceId, err))
+	} else if status == nil {
+		return &committed, nil
+	}
+
+	// Only the top level service of an agreement has the agreement id in the status, its dependencies are resolved from
+	// the exchange.
+	for _, svc := range status.Services {
+		if svc.AgreementId == "" || localAgreements[svc.AgreementId] {
+			continue
+		}
+		_, depServices, topSvcDef, _, err := getServiceDefs(svc.ServiceURL, svc.Org, svc.Version, svc.Arch)
+		if err != nil {
+			return nil, errors.New(fmt.Sprintf("unable to get the definition of service %v/%v %v %v of agreement %v, error: %v", svc.Org, svc.ServiceURL, svc.Version, svc.Arch, svc.AgreementId, err))
+		} else if topSvcDef == nil {
+			glog.Warningf(AWlogString(fmt.Sprintf("service %v/%v %v %v of agreement %v on node %v is not in the exchange, its resources are not counted", svc.Org, svc.ServiceURL, svc.Version, svc.Arch, svc.AgreementId, deviceId)))
+			continue
+		}
+		if res, err := compcheck.ServiceResourceRequirements(&compcheck.ServiceDefinition{Org: svc.Org, ServiceDefinition: *topSvcDef}, depServices, msgPrinter); err != nil {
+			return nil, errors.New(fmt.Sprintf("unable to get the resource requirements of service %v/%v %v %v of agreement %v, error: %v", svc.Org, svc.ServiceURL, svc.Version, svc.Arch, svc.AgreementId, err))
+		} else {
+			committed.Add(res)
+		}
+	}
+	return &committed, nil
+}
+
+// Create the pending agreement in the database with the node resources that are reserved for the containers of its services.
+// Returns false if the resources reserved by the other agreements of the node leave no room for them, in which case the
+// policy is searched again when resources are freed on the node.
+func (b *BaseAgreementWorker) attemptAgreementWithResources(workerId string, cph ConsumerProtocolHandler, agreementId string, wi *InitiateAgreement,
+	nodePolicy *policy.Policy, required *externalpolicy.NodeResources, attempt func() error, msgPrinter *message.Printer) (bool, error) {
+
+	// The resources are only checked when the node reports its capacity, they are reserved in any case.
+	if !required.IsZero() && externalpolicy.GetNodeCapacity(nodePolicy.Properties) != nil {
+		nodeCapacityLock.Lock()
+		defer nodeCapacityLock.Unlock()
+
+		if committed, err := committedNodeResources(b.db, wi.Device.Id, exchange.GetHTTPNodeFullStatusHandler(b), exchange.GetHTTPServiceDefResolverHandler(b), msgPrinter); err != nil {
+			return false, errors.New(fmt.Sprintf("unable to read the resources reserved on node %v, error: %v", wi.Device.Id, err))
+		} else if fits, reason := compcheck.CheckNodeCapacity(nodePolicy.Properties, committed, required, msgPrinter); !fits {
+			glog.V(3).Infof(BAWlogstring(workerId, fmt.Sprintf("node %v does not have room for policy %v. %v", wi.Device.Id, wi.ConsumerPolicy.Header.Name, reason)))
+			b.nodeSearch.DeferForCapacity(wi.Device.Id, wi.ConsumerPolicy.Header.Name)
+			return false, nil
+		}
+	}
+
+	if err := attempt(); err != nil {
+		return false, err
+	} else if !required.IsZero() {
+		if _, err := b.db.AgreementResources(agreementId, cph.Name(), required); err != nil {
+			if err := b.db.DeleteAgreement(agreementId, cph.Name()); err != nil {
+				glog.Errorf(BAWlogstring(workerId, fmt.Sprintf("error deleting pending agreement: %v, error %v", agreementId, err)))
+			}
+			return false, errors.New(fmt.Sprintf("unable to save the resources reserved by agreement %v, error: %v", agreementId, err))
+		}
+	}
+	return true, nil
+}
@@ -0,0 +1,90 @@
+//go:build unit
+// +build unit
+
+package agreementbot
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/open-horizon/anax/agreementbot/persistence/bolt"
+	"github.com/open-horizon/anax/config"
+	"github.com/open-horizon/anax/exchange"
+	"github.com/open-horizon/anax/externalpolicy"
+	"github.com/open-horizon/anax/policy"
+)
+
+func Test_committedNodeResources(t *testing.T) {
+
+	dir, err := os.MkdirTemp("", "agbot-capacity-")
+	if err != nil {
+		t.Fatalf("unable to create temp dir, error: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	db := new(bolt.AgbotBoltDB)
+	if err := db.Initialize(&config.HorizonConfig{AgreementBot: config.AGConfig{DBPath: dir}}); err != nil {
+		t.Fatalf("unable to initialize agbot database, error: %v", err)
+	}
+	defer db.Close()
+
+	// agreement1 is made by this agbot and reserves its resources, agreement2 was made by this agbot and has ended
+	for _, agId := range []string{"agreement1", "agreement2"} {
+		if err := db.AgreementAttempt(agId, "myorg", "myorg/node1", "device", "policy1", "", "", "", policy.BasicProtocol, "", []string{}, policy.NodeHealth{}, 0, 0); err != nil {
+			t.Fatalf("unable to create agreement, error: %v", err)
+		} else if _, err := db.AgreementResources(agId, policy.BasicProtocol, &externalpolicy.NodeResources{CPUs: 1, MemoryMb: 512}); err != nil {
+			t.Fatalf("unable to save agreement resources, error: %v", err)
+		}
+	}
+	if _, err := db.ArchiveAgreement("agreement2", policy.BasicProtocol, 0, ""); err != nil {
+		t.Fatalf("unable to archive agreement, error: %v", err)
+	}
+
+	// the node runs the services of both agreements of this agbot, and of agreement3 of another agbot
+	status := &exchange.DeviceStatus{Services: []exchange.WorkloadStatus{
+		{AgreementId: "agreement1", ServiceURL: "svc1", Org: "myorg", Version: "1.0.0", Arch: "amd64"},
+		{AgreementId: "agreement2", ServiceURL: "svc1", Org: "myorg", Version: "1.0.0", Arch: "amd64"},
+		{AgreementId: "agreement3", ServiceURL: "svc3", Org: "myorg", Version: "2.0.0", Arch: "amd64"},
+		{AgreementId: "", ServiceURL: "dep3", Org: "myorg", Version: "1.0.0", Arch: "amd64"},
+	}}
+	getNodeStatus := func(deviceId string) (*exchange.DeviceStatus, error) {
+		return status, nil
+	}
+	resolved := []string{}
+	getServiceDefs := func(wUrl string, wOrg string, wVersion string, wArch string) (*policy.APISpecList, map[string]exchange.ServiceDefinition, *exchange.ServiceDefinition, string, error) {
+		resolved = append(resolved, wUrl)
+		if wUrl != "svc3" {
+			return nil, nil, nil, "", nil
+		}
+		deps := map[string]exchange.ServiceDefinition{
+			"myorg/dep3_1.0.0_amd64": {URL: "dep3", Deployment: `{"services":{"dep3":{"image":"dep3:1.0","max_memory_mb":128,"max_cpus":0.25}}}`},
+		}
+		return nil, deps, &exchange.ServiceDefinition{URL: "svc3", Deployment: `{"services":{"svc3":{"image":"svc3:2.0","max_memory_mb":256,"disk_mb":100}}}`}, "myorg/svc3_2.0.0_amd64", nil
+	}
+
+	committed, err := committedNodeResources(db, "myorg/node1", getNodeStatus, getServiceDefs, nil)
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	} else if *committed != (externalpolicy.NodeResources{CPUs: 1.25, MemoryMb: 896, DiskMb: 100}) {
+		t.Errorf("wrong committed resources: %v", committed)
+	} else if len(resolved) != 1 {
+		t.Errorf("only the service of the agreement of the other agbot should be resolved, resolved %v", resolved)
+	}
+
+	// a node without a status only has the agreements of this agbot
+	status = nil
+	if committed, err := committedNodeResources(db, "myorg/node1", getNodeStatus, getServiceDefs, nil); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	} else if *committed != (externalpolicy.NodeResources{CPUs: 1, MemoryMb: 512}) {
+		t.Errorf("wrong committed resources: %v", committed)
+	}
+
+	// the check fails when the status of the node cannot be read
+	getNodeStatus = func(deviceId string) (*exchange.DeviceStatus, error) {
+		return nil, errors.New("exchange unavailable")
+	}
+	if _, err := committedNodeResources(db, "myorg/node1", getNodeStatus, getServiceDefs, nil); err == nil {
+		t.Errorf("expected an error when the node status cannot be read")
+	}
+}
@@ -41,6 +41,10 @@ type NodeSearch struct {
 	policyOrder          bool            // When true, order policies most recently changed to least recently changed.
 	clearExchangeCache   bool            // When true, the exchange cache will be deleted after a seach is made with devices returned.
 	completedSearches    map[string]bool //Keeps track of the patterns/policies that have been searched to eliminate rescans until all are searched
+
+	deferredLock    sync.Mutex          // The lock that protects the deferredRetries and capacityWaits maps, they are changed on the agreement worker threads.
+	deferredRetries map[string]uint64   // The policies that have to be searched again when a node's maintenance window opens, and when to do it. They are persisted in the agbot's DB.
+	capacityWaits   map[string][]string // The policies that wait for resources on a node to be freed, keyed by node id.
 }
 
 func NewNodeSearch() *NodeSearch {
@@ -55,6 +59,8 @@ func NewNodeSearch() *NodeSearch {
 		rescanNeeded:        false,
 		clearExchangeCache:  false,
 		completedSearches:   make(map[string]bool),
+		deferredRetries:     make(map[string]uint64),
+		capacityWaits:       make(map[string][]string),
 	}
 	return ns
 }
@@ -93,6 +99,19 @@ func (n *NodeSearch) Init(db persistence.AgbotDatabase, pm *policy.PolicyManager
 		glog.Errorf(AWlogString(fmt.Sprintf("unable to dump search session records, error: %v", err)))
 	}
 
+	// Restore the policy searches that were deferred before the restart. The nodes they wait for will not show up in
+	// the node searches again unless they change.
+	if deferred, err := n.db.FindDeferredSearches(); err != nil {
+		glog.Errorf(AWlogString(fmt.Sprintf("unable to read deferred searches, error: %v", err)))
+	} else {
+		n.deferredLock.Lock()
+		defer n.deferredLock.Unlock()
+		for policyName, retryTime := range deferred {
+			glog.V(3).Infof(AWlogString(fmt.Sprintf("restored deferred search of %v until %v", policyName, time.Unix(int64(retryTime), 0))))
+			n.deferredRetries[policyName] = retryTime
+		}
+	}
+
 }
 
 // Indicate that a rescan of all nodes is needed. This function is thread safe.
@@ -151,6 +170,9 @@ func (n *NodeSearch) Scan() {
 		}
 	}
 
+	// If a node was not proposed to because it was outside of its maintenance window, search its policy again once the window opens.
+	n.addDueRetries()
+
 	// Now check to see if a new scan is needed. This function will periodically scan all nodes, to ensure that missed change events are eventually acted on.
 	// If there is no rescan needed but it's been a while since the last full scan, then do a full scan anyway.
 	// A full rescan uses its own changedSince time so that the full rescans overlap each other.
@@ -586,3 +608,58 @@ func (n *NodeSearch) AddRetry(policyName string, changedSince uint64) {
 		glog.Errorf(AWlogString(fmt.Sprintf("unable to update %v search session changed since, error: %v", policyName, err)))
 	}
 }
+
+// Search the given policy again at the given time, because a node could not be proposed to yet, for example until its
+// maintenance window opens. This function is thread safe.
+func (n *NodeSearch) DeferRetry(policyName string, retryTime uint64) {
+	n.deferredLock.Lock()
+	defer n.deferredLock.Unlock()
+	if current, ok := n.deferredRetries[policyName]; !ok || retryTime < current {
+		n.deferredRetries[policyName] = retryTime
+		if err := n.db.SaveDeferredSearch(policyName, retryTime); err != nil {
+			glog.Errorf(AWlogString(fmt.Sprintf("unable to save deferred search of %v, error: %v", policyName, err)))
+		}
+	}
+}
+
+// Search the given policy again when resources are freed on the node, because the resources reserved by the other agreements
+// of the node left no room for the services of the policy. The policy is also searched again after a while, in case the
+// resources are freed by another agbot. This function is thread safe.
+func (n *NodeSearch) DeferForCapacity(deviceId string, policyName string) {
+	n.DeferRetry(policyName, uint64(time.Now().Unix())+NODE_CAPACITY_RETRY_S)
+
+	n.deferredLock.Lock()
+	defer n.deferredLock.Unlock()
+	if !cutil.SliceContains(n.capacityWaits[deviceId], policyName) {
+		n.capacityWaits[deviceId] = append(n.capacityWaits[deviceId], policyName)
+	}
+}
+
+// Retry the policies that wait for resources on the node, because an agreement of the node ended. This function is thread safe.
+func (n *NodeSearch) CapacityFreed(deviceId string) {
+	n.deferredLock.Lock()
+	defer n.deferredLock.Unlock()
+	for _, policyName := range n.capacityWaits[deviceId] {
+		glog.V(3).Infof(AWlogString(fmt.Sprintf("retrying %v for node %v, resources on the node were freed", policyName, deviceId)))
+		n.AddRetry(policyName, 0)
+	}
+	delete(n.capacityWaits, deviceId)
+}
+
+// Retry the deferred policies whose time has come. The nodes might not have changed since the policy was last searched,
+// so the whole policy is searched again.
+func (n *NodeSearch) addDueRetries() {
+	n.deferredLock.Lock()
+	defer n.deferredLock.Unlock()
+	now := uint64(time.Now().Unix())
+	for policyName, retryTime := range n.deferredRetries {
+		if retryTime <= now {
+			glog.V(3).Infof(AWlogString(fmt.Sprintf("retrying deferred search of %v", policyName)))
+			n.AddRetry(policyName, 0)
+			delete(n.deferredRetries, policyName)
+			if err := n.db.DeleteDeferredSearch(policyName); err != nil {
+				glog.Errorf(AWlogString(fmt.Sprintf("unable to delete deferred search of %v, error: %v", policyName, err)))
+			}
+		}
+	}
+}
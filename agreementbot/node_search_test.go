@@ -0,0 +1,51 @@
+//go:build unit
+// +build unit
+
+package agreementbot
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/open-horizon/anax/agreementbot/persistence/bolt"
+	"github.com/open-horizon/anax/config"
+)
+
+func Test_DeferredSearchesPersisted(t *testing.T) {
+
+	dir, err := os.MkdirTemp("", "agbot-nodesearch-")
+	if err != nil {
+		t.Fatalf("unable to create temp dir, error: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	cfg := &config.HorizonConfig{AgreementBot: config.AGConfig{DBPath: dir}}
+	db := new(bolt.AgbotBoltDB)
+	if err := db.Initialize(cfg); err != nil {
+		t.Fatalf("unable to initialize agbot database, error: %v", err)
+	}
+	defer db.Close()
+
+	now := uint64(time.Now().Unix())
+	n := NewNodeSearch()
+	n.Init(db, nil, nil, nil, nil, cfg)
+	n.DeferRetry("myorg/policy1", now+3600)
+	n.DeferRetry("myorg/policy1", now+7200)
+	n.DeferRetry("myorg/policy2", now-1)
+
+	// the deferred searches are restored when the agbot restarts, the earliest time of a policy is kept
+	n = NewNodeSearch()
+	n.Init(db, nil, nil, nil, nil, cfg)
+	if len(n.deferredRetries) != 2 || n.deferredRetries["myorg/policy1"] != now+3600 || n.deferredRetries["myorg/policy2"] != now-1 {
+		t.Errorf("wrong deferred searches restored: %v", n.deferredRetries)
+	}
+
+	// a search that is retried is no longer deferred
+	n.addDueRetries()
+	if deferred, err := db.FindDeferredSearches(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	} else if len(deferred) != 1 || deferred["myorg/policy1"] != now+3600 {
+		t.Errorf("wrong deferred searches saved: %v", deferred)
+	}
+}
@@ -3,6 +3,7 @@ package persistence
 import (
 	"errors"
 	"fmt"
+	"github.com/open-horizon/anax/externalpolicy"
 	"github.com/open-horizon/anax/policy"
 	"time"
 )
@@ -57,10 +58,14 @@ type Agreement struct {
 	ServiceId                      []string `json:"service_id"`                        // All the service ids whose policy is used to make the agreement, used for policy case only
 	ProtocolTimeoutS               uint64   `json:"protocol_timeout_sec"`              // Number of seconds to wait before declaring proposal response is lost
 	AgreementTimeoutS              uint64   `json:"agreement_timeout_sec"`
-	LastSecretUpdateTime           uint64   `json:"last_secret_update_time"`     // The secret update time corresponding to the most recent secret update protocol msg sent for this agreement
-	LastSecretUpdateTimeAck        uint64   `json:"last_secret_update_time_ack"` // Will match the LastSecretUpdateTime when the agreement update ACK is received
+	LastSecretUpdateTime           uint64   `json:"last_secret_update_time"`      // The secret update time corresponding to the most recent secret update protocol msg sent for this agreement
+	LastSecretUpdateTimeAck        uint64   `json:"last_secret_update_time_ack"`  // Will match the LastSecretUpdateTime when the agreement update ACK is received
+	LastSecretUpdateTimeNack       uint64   `json:"last_secret_update_time_nack"` // Will match the LastSecretUpdateTime when the agreement update is rejected
 	LastPolicyUpdateTime           uint64   `json:"last_policy_update_time"`
 	LastPolicyUpdateTimeAck        uint64   `json:"last_policy_update_time_ack"`
+	UpgradeDeferredUntil           uint64   `json:"upgrade_deferred_until"` // When the node's maintenance window allows the agreement to be cancelled for a service upgrade, 0 if no upgrade is waiting
+
+	Resources *externalpolicy.NodeResources `json:"resources,omitempty"` // The node resources reserved for the containers of the services, nil if they reserve nothing
 }
 
 func (a Agreement) String() string {
@@ -107,8 +112,11 @@ func (a Agreement) String() string {
 		"AgreementTimeoutS: %v, "+
 		"LastSecretUpdateTime: %v, "+
 		"LastSecretUpdateTimeAck: %v"+
+		"LastSecretUpdateTimeNack: %v"+
 		"LastPolicyUpdateTime: %v"+
-		"LastPolicyUpdateTimeAck: %v",
+		"LastPolicyUpdateTimeAck: %v, "+
+		"UpgradeDeferredUntil: %v, "+
+		"Resources: %v",
 		a.Archived, a.CurrentAgreementId, a.Org, a.AgreementProtocol, a.AgreementProtocolVersion, a.DeviceId, a.DeviceType,
 		a.AgreementInceptionTime, a.AgreementCreationTime, a.AgreementFinalizedTime,
 		a.AgreementTimedout, a.ProposalSig, a.ProposalHash, a.ConsumerProposalSig, a.PolicyName, a.CounterPartyAddress,
@@ -117,7 +125,8 @@ func (a Agreement) String() string {
 		a.MeteringTokens, a.MeteringPerTimeUnit, a.MeteringNotificationInterval, a.MeteringNotificationSent, a.MeteringNotificationMsgs,
 		a.TerminatedReason, a.TerminatedDescription, a.BlockchainType, a.BlockchainName, a.BlockchainOrg, a.BCUpdateAckTime,
 		a.NHMissingHBInterval, a.NHCheckAgreementStatus, a.Pattern, a.ServiceId, a.ProtocolTimeoutS, a.AgreementTimeoutS,
-		a.LastSecretUpdateTime, a.LastSecretUpdateTimeAck, a.LastPolicyUpdateTime, a.LastPolicyUpdateTimeAck)
+		a.LastSecretUpdateTime, a.LastSecretUpdateTimeAck, a.LastSecretUpdateTimeNack, a.LastPolicyUpdateTime, a.LastPolicyUpdateTimeAck,
+		a.UpgradeDeferredUntil, a.Resources)
 }
 
 // Factory method for agreement w/out persistence safety.
@@ -377,6 +386,17 @@ func AgreementSecretUpdateAckTime(db AgbotDatabase, agreementid string, protocol
 	}
 }
 
+func AgreementSecretUpdateNackTime(db AgbotDatabase, agreementid string, protocol string, secretUpdateNackTime uint64) (*Agreement, error) {
+	if agreement, err := db.SingleAgreementUpdate(agreementid, protocol, func(a Agreement) *Agreement {
+		a.LastSecretUpdateTimeNack = secretUpdateNackTime
+		return &a
+	}); err != nil {
+		return nil, err
+	} else {
+		return agreement, nil
+	}
+}
+
 func AgreementPolicyUpdateTime(db AgbotDatabase, agreementid string, protocol string, policyUpdateTime uint64) (*Agreement, error) {
 	if agreement, err := db.SingleAgreementUpdate(agreementid, protocol, func(a Agreement) *Agreement {
 		a.LastPolicyUpdateTime = policyUpdateTime
@@ -399,6 +419,28 @@ func AgreementPolicyUpdateAckTime(db AgbotDatabase, agreementid string, protocol
 	}
 }
 
+func AgreementUpgradeDeferred(db AgbotDatabase, agreementid string, protocol string, deferredUntil uint64) (*Agreement, error) {
+	if agreement, err := db.SingleAgreementUpdate(agreementid, protocol, func(a Agreement) *Agreement {
+		a.UpgradeDeferredUntil = deferredUntil
+		return &a
+	}); err != nil {
+		return nil, err
+	} else {
+		return agreement, nil
+	}
+}
+
+func AgreementResources(db AgbotDatabase, agreementid string, protocol string, resources *externalpolicy.NodeResources) (*Agreement, error) {
+	if agreement, err := db.SingleAgreementUpdate(agreementid, protocol, func(a Agreement) *Agreement {
+		a.Resources = resources
+		return &a
+	}); err != nil {
+		return nil, err
+	} else {
+		return agreement, nil
+	}
+}
+
 // This code is running in a database transaction. Within the tx, the current record is
 // read and then updated according to the updates within the input update record. It is critical
 // to check for correct data transitions within the tx .
@@ -502,12 +544,19 @@ func ValidateStateTransition(mod *Agreement, update *Agreement) {
 	if mod.LastSecretUpdateTimeAck < update.LastSecretUpdateTimeAck { // Valid transitions must move forward
 		mod.LastSecretUpdateTimeAck = update.LastSecretUpdateTimeAck
 	}
+	if mod.LastSecretUpdateTimeNack < update.LastSecretUpdateTimeNack { // Valid transitions must move forward
+		mod.LastSecretUpdateTimeNack = update.LastSecretUpdateTimeNack
+	}
 	if mod.LastPolicyUpdateTime < update.LastPolicyUpdateTime { // Valid transitions must move forward
 		mod.LastPolicyUpdateTime = update.LastPolicyUpdateTime
 	}
 	if mod.LastPolicyUpdateTimeAck < update.LastPolicyUpdateTimeAck { // Valid transitions must move forward
 		mod.LastPolicyUpdateTimeAck = update.LastPolicyUpdateTimeAck
 	}
+	if mod.Resources == nil { // 1 transition from nil to set
+		mod.Resources = update.Resources
+	}
+	mod.UpgradeDeferredUntil = update.UpgradeDeferredUntil // Cleared when the deferred upgrade is no longer needed
 }
 
 // Filters used by the caller to control what comes back from the database.
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"github.com/golang/glog"
 	"github.com/open-horizon/anax/agreementbot/persistence"
+	"github.com/open-horizon/anax/externalpolicy"
 	"github.com/open-horizon/anax/policy"
 	bolt "go.etcd.io/bbolt"
 )
@@ -145,6 +146,10 @@ func (db *AgbotBoltDB) AgreementSecretUpdateAckTime(agreementid string, protocol
 	return persistence.AgreementSecretUpdateAckTime(db, agreementid, protocol, secretUpdateAckTime)
 }
 
+func (db *AgbotBoltDB) AgreementSecretUpdateNackTime(agreementid string, protocol string, secretUpdateNackTime uint64) (*persistence.Agreement, error) {
+	return persistence.AgreementSecretUpdateNackTime(db, agreementid, protocol, secretUpdateNackTime)
+}
+
 func (db *AgbotBoltDB) AgreementPolicyUpdateTime(agreementid string, protocol string, policyUpdateTime uint64) (*persistence.Agreement, error) {
 	return persistence.AgreementPolicyUpdateTime(db, agreementid, protocol, policyUpdateTime)
 }
@@ -153,6 +158,14 @@ func (db *AgbotBoltDB) AgreementPolicyUpdateAckTime(agreementid string, protocol
 	return persistence.AgreementPolicyUpdateAckTime(db, agreementid, protocol, policyUpdateAckTime)
 }
 
+func (db *AgbotBoltDB) AgreementUpgradeDeferred(agreementid string, protocol string, deferredUntil uint64) (*persistence.Agreement, error) {
+	return persistence.AgreementUpgradeDeferred(db, agreementid, protocol, deferredUntil)
+}
+
+func (db *AgbotBoltDB) AgreementResources(agreementid string, protocol string, resources *externalpolicy.NodeResources) (*persistence.Agreement, error) {
+	return persistence.AgreementResources(db, agreementid, protocol, resources)
+}
+
 // no error on not found, only nil
 func (db *AgbotBoltDB) FindSingleAgreementByAgreementId(agreementid string, protocol string, filters []persistence.AFilter) (*persistence.Agreement, error) {
 	filters = append(filters, persistence.IdAFilter(agreementid))
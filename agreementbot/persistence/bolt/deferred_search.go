@@ -0,0 +1,55 @@
+package bolt
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/golang/glog"
+	bolt "go.etcd.io/bbolt"
+)
+
+const DEFERRED_SEARCH_BUCKET = "deferred_search"
+
+func (db *AgbotBoltDB) FindDeferredSearches() (map[string]uint64, error) {
+	searches := make(map[string]uint64)
+
+	readErr := db.db.View(func(tx *bolt.Tx) error {
+		if b := tx.Bucket([]byte(DEFERRED_SEARCH_BUCKET)); b != nil {
+			return b.ForEach(func(k, v []byte) error {
+				var retryTime uint64
+				if err := json.Unmarshal(v, &retryTime); err != nil {
+					return fmt.Errorf("Failed to deserialize deferred search record for policy %v: %v. Error: %v", string(k), string(v), err)
+				}
+				searches[string(k)] = retryTime
+				return nil
+			})
+		}
+		return nil
+	})
+
+	return searches, readErr
+}
+
+func (db *AgbotBoltDB) SaveDeferredSearch(policyName string, retryTime uint64) error {
+	return db.db.Update(func(tx *bolt.Tx) error {
+		if b, err := tx.CreateBucketIfNotExists([]byte(DEFERRED_SEARCH_BUCKET)); err != nil {
+			return err
+		} else if serialized, err := json.Marshal(retryTime); err != nil {
+			return fmt.Errorf("Failed to serialize deferred search of policy %v. Error: %v", policyName, err)
+		} else if err := b.Put([]byte(policyName), serialized); err != nil {
+			return fmt.Errorf("Failed to write deferred search of policy %v. Error: %v", policyName, err)
+		} else {
+			glog.V(5).Infof("Succeeded saving deferred search of policy %v until %v", policyName, retryTime)
+			return nil
+		}
+	})
+}
+
+func (db *AgbotBoltDB) DeleteDeferredSearch(policyName string) error {
+	return db.db.Update(func(tx *bolt.Tx) error {
+		if b := tx.Bucket([]byte(DEFERRED_SEARCH_BUCKET)); b == nil {
+			return nil
+		} else {
+			return b.Delete([]byte(policyName))
+		}
+	})
+}
@@ -0,0 +1,65 @@
+package bolt
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/golang/glog"
+	"github.com/open-horizon/anax/agreementbot/persistence"
+	bolt "go.etcd.io/bbolt"
+)
+
+const SECRET_ROLLOUT_BUCKET = "secret_rollout"
+
+// There is at most one secret rollout, it is saved under this key.
+const SECRET_ROLLOUT_KEY = "rollout"
+
+func (db *AgbotBoltDB) FindSecretRollout() (*persistence.SecretRollout, error) {
+
+	var rollout *persistence.SecretRollout
+
+	readErr := db.db.View(func(tx *bolt.Tx) error {
+		if b := tx.Bucket([]byte(SECRET_ROLLOUT_BUCKET)); b != nil {
+			v := b.Get([]byte(SECRET_ROLLOUT_KEY))
+			if v == nil {
+				return nil
+			}
+
+			var r persistence.SecretRollout
+			if err := json.Unmarshal(v, &r); err != nil {
+				return fmt.Errorf("Failed to deserialize secret rollout record: %v. Error: %v", string(v), err)
+			}
+			rollout = &r
+		}
+		return nil
+	})
+
+	if readErr != nil {
+		return nil, readErr
+	}
+	return rollout, nil
+}
+
+func (db *AgbotBoltDB) SaveSecretRollout(rollout *persistence.SecretRollout) error {
+	return db.db.Update(func(tx *bolt.Tx) error {
+		if b, err := tx.CreateBucketIfNotExists([]byte(SECRET_ROLLOUT_BUCKET)); err != nil {
+			return err
+		} else if serialized, err := json.Marshal(rollout); err != nil {
+			return fmt.Errorf("Failed to serialize secret rollout record: %v. Error: %v", rollout, err)
+		} else if err := b.Put([]byte(SECRET_ROLLOUT_KEY), serialized); err != nil {
+			return fmt.Errorf("Failed to write secret rollout %v. Error: %v", rollout, err)
+		} else {
+			glog.V(5).Infof("Succeeded saving secret rollout %v", rollout)
+			return nil
+		}
+	})
+}
+
+func (db *AgbotBoltDB) DeleteSecretRollout() error {
+	return db.db.Update(func(tx *bolt.Tx) error {
+		if b := tx.Bucket([]byte(SECRET_ROLLOUT_BUCKET)); b == nil {
+			return nil
+		} else {
+			return b.Delete([]byte(SECRET_ROLLOUT_KEY))
+		}
+	})
+}
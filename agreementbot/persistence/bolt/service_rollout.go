@@ -0,0 +1,82 @@
+package bolt
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/golang/glog"
+	"github.com/open-horizon/anax/agreementbot/persistence"
+	bolt "go.etcd.io/bbolt"
+)
+
+const SERVICE_ROLLOUT_BUCKET = "service_rollout"
+
+func (db *AgbotBoltDB) FindSingleServiceRollout(policyName string) (*persistence.ServiceRollout, error) {
+
+	var rollout *persistence.ServiceRollout
+
+	readErr := db.db.View(func(tx *bolt.Tx) error {
+		if b := tx.Bucket([]byte(SERVICE_ROLLOUT_BUCKET)); b != nil {
+			v := b.Get([]byte(policyName))
+			if v == nil {
+				return nil
+			}
+
+			var r persistence.ServiceRollout
+			if err := json.Unmarshal(v, &r); err != nil {
+				return fmt.Errorf("Failed to deserialize service rollout record: %v. Error: %v", string(v), err)
+			}
+			rollout = &r
+		}
+		return nil
+	})
+
+	if readErr != nil {
+		return nil, readErr
+	}
+	return rollout, nil
+}
+
+func (db *AgbotBoltDB) FindServiceRollouts() ([]persistence.ServiceRollout, error) {
+	rollouts := make([]persistence.ServiceRollout, 0)
+
+	readErr := db.db.View(func(tx *bolt.Tx) error {
+		if b := tx.Bucket([]byte(SERVICE_ROLLOUT_BUCKET)); b != nil {
+			return b.ForEach(func(k, v []byte) error {
+				var r persistence.ServiceRollout
+				if err := json.Unmarshal(v, &r); err != nil {
+					return fmt.Errorf("Failed to deserialize service rollout record: %v. Error: %v", string(v), err)
+				}
+				rollouts = append(rollouts, r)
+				return nil
+			})
+		}
+		return nil
+	})
+
+	return rollouts, readErr
+}
+
+func (db *AgbotBoltDB) SaveServiceRollout(rollout *persistence.ServiceRollout) error {
+	return db.db.Update(func(tx *bolt.Tx) error {
+		if b, err := tx.CreateBucketIfNotExists([]byte(SERVICE_ROLLOUT_BUCKET)); err != nil {
+			return err
+		} else if serialized, err := json.Marshal(rollout); err != nil {
+			return fmt.Errorf("Failed to serialize service rollout record: %v. Error: %v", rollout, err)
+		} else if err := b.Put([]byte(rollout.PolicyName), serialized); err != nil {
+			return fmt.Errorf("Failed to write service rollout for policy %v. Error: %v", rollout.PolicyName, err)
+		} else {
+			glog.V(5).Infof("Succeeded saving service rollout %v", rollout)
+			return nil
+		}
+	})
+}
+
+func (db *AgbotBoltDB) DeleteServiceRollout(policyName string) error {
+	return db.db.Update(func(tx *bolt.Tx) error {
+		if b := tx.Bucket([]byte(SERVICE_ROLLOUT_BUCKET)); b == nil {
+			return nil
+		} else {
+			return b.Delete([]byte(policyName))
+		}
+	})
+}
@@ -2,6 +2,7 @@ package persistence
 
 import (
 	"github.com/open-horizon/anax/config"
+	"github.com/open-horizon/anax/externalpolicy"
 	"github.com/open-horizon/anax/policy"
 )
 
@@ -43,8 +44,11 @@ type AgbotDatabase interface {
 	AgreementTimedout(agreementid string, protocol string) (*Agreement, error)
 	AgreementSecretUpdateTime(agreementid string, protocol string, secretUpdateTime uint64) (*Agreement, error)
 	AgreementSecretUpdateAckTime(agreementid string, protocol string, secretUpdateAckTime uint64) (*Agreement, error)
+	AgreementSecretUpdateNackTime(agreementid string, protocol string, secretUpdateNackTime uint64) (*Agreement, error)
 	AgreementPolicyUpdateTime(agreementid string, protocol string, policyUpdateTime uint64) (*Agreement, error)
 	AgreementPolicyUpdateAckTime(agreementid string, protocol string, policyUpdateAckTime uint64) (*Agreement, error)
+	AgreementUpgradeDeferred(agreementid string, protocol string, deferredUntil uint64) (*Agreement, error)
+	AgreementResources(agreementid string, protocol string, resources *externalpolicy.NodeResources) (*Agreement, error)
 
 	DataNotification(agreementid string, protocol string) (*Agreement, error)
 	DataVerified(agreementid string, protocol string) (*Agreement, error)
@@ -112,4 +116,20 @@ type AgbotDatabase interface {
 	GetHAUpgradingWorkload(org string, haGroupName string, policyName string) (*UpgradingHAGroupWorkload, error)
 	UpdateHAUpgradingWorkloadForGroupAndPolicy(org string, haGroupName string, policyName string, deviceId string) error
 	InsertHAUpgradingWorkloadForGroupAndPolicy(org string, haGroupName string, policyName string, deviceId string) (string, error)
+
+	// Functions related to persistence of service version rollouts.
+	FindSingleServiceRollout(policyName string) (*ServiceRollout, error)
+	FindServiceRollouts() ([]ServiceRollout, error)
+	SaveServiceRollout(rollout *ServiceRollout) error
+	DeleteServiceRollout(policyName string) error
+
+	// Functions related to persistence of the policy searches that wait for a time, such as until a node's maintenance window opens.
+	FindDeferredSearches() (map[string]uint64, error)
+	SaveDeferredSearch(policyName string, retryTime uint64) error
+	DeleteDeferredSearch(policyName string) error
+
+	// Functions related to persistence of the staged secret rollout.
+	FindSecretRollout() (*SecretRollout, error)
+	SaveSecretRollout(rollout *SecretRollout) error
+	DeleteSecretRollout() error
 }
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"github.com/golang/glog"
 	"github.com/open-horizon/anax/agreementbot/persistence"
+	"github.com/open-horizon/anax/externalpolicy"
 	"github.com/open-horizon/anax/policy"
 	"strings"
 )
@@ -420,6 +421,10 @@ func (db *AgbotPostgresqlDB) AgreementSecretUpdateAckTime(agreementid string, pr
 	return persistence.AgreementSecretUpdateAckTime(db, agreementid, protocol, secretUpdateAckTime)
 }
 
+func (db *AgbotPostgresqlDB) AgreementSecretUpdateNackTime(agreementid string, protocol string, secretUpdateNackTime uint64) (*persistence.Agreement, error) {
+	return persistence.AgreementSecretUpdateNackTime(db, agreementid, protocol, secretUpdateNackTime)
+}
+
 func (db *AgbotPostgresqlDB) AgreementPolicyUpdateTime(agreementid string, protocol string, policyUpdateTime uint64) (*persistence.Agreement, error) {
 	return persistence.AgreementPolicyUpdateTime(db, agreementid, protocol, policyUpdateTime)
 }
@@ -428,6 +433,14 @@ func (db *AgbotPostgresqlDB) AgreementPolicyUpdateAckTime(agreementid string, pr
 	return persistence.AgreementPolicyUpdateAckTime(db, agreementid, protocol, policyUpdateAckTime)
 }
 
+func (db *AgbotPostgresqlDB) AgreementUpgradeDeferred(agreementid string, protocol string, deferredUntil uint64) (*persistence.Agreement, error) {
+	return persistence.AgreementUpgradeDeferred(db, agreementid, protocol, deferredUntil)
+}
+
+func (db *AgbotPostgresqlDB) AgreementResources(agreementid string, protocol string, resources *externalpolicy.NodeResources) (*persistence.Agreement, error) {
+	return persistence.AgreementResources(db, agreementid, protocol, resources)
+}
+
 func (db *AgbotPostgresqlDB) DeleteAgreement(agreementid string, protocol string) error {
 	tx, err := db.db.Begin()
 	if err != nil {
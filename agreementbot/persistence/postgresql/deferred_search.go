@@ -0,0 +1,80 @@
+package postgresql
+
+import (
+	"errors"
+	"fmt"
+	"github.com/golang/glog"
+)
+
+// Constants for the SQL statements that are used to manage the policy searches that wait for a time, such as until a
+// node's maintenance window opens. This table is not partitioned like the agreements table, instead each row records
+// the partition that owns it.
+//
+// schema:
+// partition:   The agbot partition that owns the deferred search.
+// policy_name: The fully qualified (org/policy-name) policy to search again.
+// retry_time:  A linux epoch time stamp indicating when to search the policy again.
+// updated:     A timestamp to record last updated time.
+//
+
+const DEFERRED_SEARCH_CREATE_MAIN_TABLE = `CREATE TABLE IF NOT EXISTS deferred_searches (
+	partition text NOT NULL,
+	policy_name text NOT NULL,
+	retry_time bigint NOT NULL,
+	updated timestamp with time zone DEFAULT current_timestamp,
+	PRIMARY KEY (partition, policy_name)
+);`
+
+const ALL_DEFERRED_SEARCH_QUERY = `SELECT policy_name, retry_time FROM deferred_searches WHERE partition = $1;`
+
+const DEFERRED_SEARCH_UPSERT = `INSERT INTO deferred_searches (partition, policy_name, retry_time) VALUES ($1, $2, $3)
+	ON CONFLICT (partition, policy_name) DO UPDATE SET retry_time = EXCLUDED.retry_time, updated = current_timestamp;`
+
+const DEFERRED_SEARCH_DELETE = `DELETE FROM deferred_searches WHERE partition = $1 AND policy_name = $2;`
+
+// Move the deferred searches of another partition into ours. When both partitions defer the same policy, the earlier
+// search is kept.
+const DEFERRED_SEARCH_MOVE = `INSERT INTO deferred_searches (partition, policy_name, retry_time)
+	SELECT $2, policy_name, retry_time FROM deferred_searches WHERE partition = $1
+	ON CONFLICT (partition, policy_name) DO UPDATE SET retry_time = LEAST(deferred_searches.retry_time, EXCLUDED.retry_time), updated = current_timestamp;`
+const DEFERRED_SEARCH_DELETE_PARTITION = `DELETE FROM deferred_searches WHERE partition = $1;`
+
+func (db *AgbotPostgresqlDB) FindDeferredSearches() (map[string]uint64, error) {
+	searches := make(map[string]uint64)
+
+	rows, err := db.db.Query(ALL_DEFERRED_SEARCH_QUERY, db.PrimaryPartition())
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("error querying for deferred searches, error: %v", err))
+	}
+
+	// If the rows object doesnt get closed, memory and connections will grow and/or leak.
+	defer rows.Close()
+	for rows.Next() {
+		var policyName string
+		var retryTime int64
+		if err := rows.Scan(&policyName, &retryTime); err != nil {
+			return nil, errors.New(fmt.Sprintf("error scanning row: %v", err))
+		}
+		searches[policyName] = uint64(retryTime)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, errors.New(fmt.Sprintf("error iterating: %v", err))
+	}
+	return searches, nil
+}
+
+func (db *AgbotPostgresqlDB) SaveDeferredSearch(policyName string, retryTime uint64) error {
+	if _, err := db.db.Exec(DEFERRED_SEARCH_UPSERT, db.PrimaryPartition(), policyName, int64(retryTime)); err != nil {
+		return errors.New(fmt.Sprintf("error saving deferred search of policy %v, error: %v", policyName, err))
+	}
+	glog.V(5).Infof("Succeeded saving deferred search of policy %v until %v", policyName, retryTime)
+	return nil
+}
+
+func (db *AgbotPostgresqlDB) DeleteDeferredSearch(policyName string) error {
+	if _, err := db.db.Exec(DEFERRED_SEARCH_DELETE, db.PrimaryPartition(), policyName); err != nil {
+		return errors.New(fmt.Sprintf("error deleting deferred search of policy %v, error: %v", policyName, err))
+	}
+	return nil
+}
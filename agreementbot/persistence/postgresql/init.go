@@ -116,6 +116,21 @@ func (db *AgbotPostgresqlDB) Initialize(cfg *config.HorizonConfig) error {
 			return fmt.Errorf("unable to create ha workload add if not present function, error: %v", err)
 		}
 
+		// Create the service rollout table. Do not partition it.
+		if _, err := db.db.Exec(SERVICE_ROLLOUT_CREATE_MAIN_TABLE); err != nil {
+			return fmt.Errorf("unable to create service rollout table, error: %v", err)
+		}
+
+		// Create the deferred search table. Do not partition it.
+		if _, err := db.db.Exec(DEFERRED_SEARCH_CREATE_MAIN_TABLE); err != nil {
+			return fmt.Errorf("unable to create deferred search table, error: %v", err)
+		}
+
+		// Create the secret rollout table. Do not partition it.
+		if _, err := db.db.Exec(SECRET_ROLLOUT_CREATE_MAIN_TABLE); err != nil {
+			return fmt.Errorf("unable to create secret rollout table, error: %v", err)
+		}
+
 		glog.V(3).Infof("Postgresql primary partition database tables exist.")
 
 		// Migrate the database tables if necessary. Extract the current schema version from the version table,
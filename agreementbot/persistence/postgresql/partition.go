@@ -266,6 +266,18 @@ func (db *AgbotPostgresqlDB) MovePartition(timeout uint64) (bool, error) {
 			return false, err
 		} else if _, err := tx.Exec(db.GetSecretPartitionMovePolicy(fromPartition, db.PrimaryPartition())); err != nil {
 			return false, err
+		} else if _, err := tx.Exec(SERVICE_ROLLOUT_MOVE, fromPartition, db.PrimaryPartition()); err != nil {
+			return false, err
+		} else if _, err := tx.Exec(SERVICE_ROLLOUT_DELETE_PARTITION, fromPartition); err != nil {
+			return false, err
+		} else if _, err := tx.Exec(DEFERRED_SEARCH_MOVE, fromPartition, db.PrimaryPartition()); err != nil {
+			return false, err
+		} else if _, err := tx.Exec(DEFERRED_SEARCH_DELETE_PARTITION, fromPartition); err != nil {
+			return false, err
+		} else if _, err := tx.Exec(SECRET_ROLLOUT_MOVE, fromPartition, db.PrimaryPartition()); err != nil {
+			return false, err
+		} else if _, err := tx.Exec(SECRET_ROLLOUT_DELETE, fromPartition); err != nil {
+			return false, err
 		} else if _, err := tx.Exec(db.GetAgreementPartitionTableDrop(fromPartition)); err != nil {
 			return false, err
 		} else if _, err := tx.Exec(db.GetWorkloadUsagePartitionTableDrop(fromPartition)); err != nil {
@@ -280,7 +292,7 @@ func (db *AgbotPostgresqlDB) MovePartition(timeout uint64) (bool, error) {
 			if err := tx.Commit(); err != nil {
 				return false, errors.New(fmt.Sprintf("unable to commit transaction for moving agreements, error: %v", err))
 			}
-			glog.V(3).Infof("AgreementBot %v moved agreements, workload usage and secrets from partition %v to %v", db.identity, fromPartition, db.PrimaryPartition())
+			glog.V(3).Infof("AgreementBot %v moved agreements, workload usage, secrets, service rollouts, deferred searches and secret rollouts from partition %v to %v", db.identity, fromPartition, db.PrimaryPartition())
 		}
 	}
 	// We found a partition and moved all the records.
@@ -0,0 +1,73 @@
+package postgresql
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/golang/glog"
+	"github.com/open-horizon/anax/agreementbot/persistence"
+	"strings"
+)
+
+// Constants for the SQL statements that are used to manage staged secret rollouts. This table is not partitioned
+// like the agreements table, instead each row records the partition that owns it. A rollout only covers the agreements
+// in the partition of the agbot that runs it, so there is at most one rollout for each partition.
+//
+// schema:
+// partition:   The agbot partition that owns the rollout.
+// rollout:     The rollout object which is a JSON blob. The blob schema is defined by the SecretRollout struct in the persistence package.
+// updated:     A timestamp to record last updated time.
+//
+
+const SECRET_ROLLOUT_CREATE_MAIN_TABLE = `CREATE TABLE IF NOT EXISTS secret_rollouts (
+	partition text NOT NULL,
+	rollout jsonb NOT NULL,
+	updated timestamp with time zone DEFAULT current_timestamp,
+	PRIMARY KEY (partition)
+);`
+
+const SECRET_ROLLOUT_QUERY = `SELECT rollout FROM secret_rollouts WHERE partition = $1;`
+
+const SECRET_ROLLOUT_UPSERT = `INSERT INTO secret_rollouts (partition, rollout) VALUES ($1, $2)
+	ON CONFLICT (partition) DO UPDATE SET rollout = EXCLUDED.rollout, updated = current_timestamp;`
+
+const SECRET_ROLLOUT_DELETE = `DELETE FROM secret_rollouts WHERE partition = $1;`
+
+// Move the rollout of another partition into ours, unless we have a rollout of our own. A rollout that is dropped does
+// not update the agreements it did not get to, they receive the secret updates when the secrets change again.
+const SECRET_ROLLOUT_MOVE = `UPDATE secret_rollouts SET partition = $2, updated = current_timestamp WHERE partition = $1
+	AND NOT EXISTS (SELECT 1 FROM secret_rollouts WHERE partition = $2);`
+
+func (db *AgbotPostgresqlDB) FindSecretRollout() (*persistence.SecretRollout, error) {
+
+	rBytes := make([]byte, 0, 2048)
+	if err := db.db.QueryRow(SECRET_ROLLOUT_QUERY, db.PrimaryPartition()).Scan(&rBytes); err == sql.ErrNoRows || (err != nil && strings.Contains(err.Error(), "not exist")) {
+		return nil, nil
+	} else if err != nil {
+		return nil, errors.New(fmt.Sprintf("error scanning row for secret rollout, error: %v", err))
+	}
+
+	rollout := new(persistence.SecretRollout)
+	if err := json.Unmarshal(rBytes, rollout); err != nil {
+		return nil, errors.New(fmt.Sprintf("error demarshalling row: %v, error: %v", string(rBytes), err))
+	}
+	return rollout, nil
+}
+
+func (db *AgbotPostgresqlDB) SaveSecretRollout(rollout *persistence.SecretRollout) error {
+	if rBytes, err := json.Marshal(rollout); err != nil {
+		return errors.New(fmt.Sprintf("error marshalling secret rollout %v, error: %v", rollout, err))
+	} else if _, err := db.db.Exec(SECRET_ROLLOUT_UPSERT, db.PrimaryPartition(), rBytes); err != nil {
+		return errors.New(fmt.Sprintf("error saving secret rollout %v, error: %v", rollout, err))
+	}
+	glog.V(5).Infof("Succeeded saving secret rollout %v", rollout)
+	return nil
+}
+
+func (db *AgbotPostgresqlDB) DeleteSecretRollout() error {
+	if _, err := db.db.Exec(SECRET_ROLLOUT_DELETE, db.PrimaryPartition()); err != nil {
+		return errors.New(fmt.Sprintf("error deleting secret rollout, error: %v", err))
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+package postgresql
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/golang/glog"
+	"github.com/open-horizon/anax/agreementbot/persistence"
+	"strings"
+)
+
+// Constants for the SQL statements that are used to manage service version rollouts. This table is not partitioned
+// like the agreements table, instead each row records the partition that owns it. A rollout only covers the agreements
+// in the partition of the agbot that runs it, so each agbot rolls out a new service version to its own agreements.
+//
+// schema:
+// partition:   The agbot partition that owns the rollout.
+// policy_name: The fully qualified (org/policy-name) deployment policy being rolled out.
+// rollout:     The rollout object which is a JSON blob. The blob schema is defined by the ServiceRollout struct in the persistence package.
+// updated:     A timestamp to record last updated time.
+//
+
+const SERVICE_ROLLOUT_CREATE_MAIN_TABLE = `CREATE TABLE IF NOT EXISTS service_rollouts (
+	partition text NOT NULL,
+	policy_name text NOT NULL,
+	rollout jsonb NOT NULL,
+	updated timestamp with time zone DEFAULT current_timestamp,
+	PRIMARY KEY (partition, policy_name)
+);`
+
+const SERVICE_ROLLOUT_QUERY = `SELECT rollout FROM service_rollouts WHERE partition = $1 AND policy_name = $2;`
+const ALL_SERVICE_ROLLOUT_QUERY = `SELECT rollout FROM service_rollouts WHERE partition = $1;`
+
+const SERVICE_ROLLOUT_UPSERT = `INSERT INTO service_rollouts (partition, policy_name, rollout) VALUES ($1, $2, $3)
+	ON CONFLICT (partition, policy_name) DO UPDATE SET rollout = EXCLUDED.rollout, updated = current_timestamp;`
+
+const SERVICE_ROLLOUT_DELETE = `DELETE FROM service_rollouts WHERE partition = $1 AND policy_name = $2;`
+
+// Move the rollouts of another partition into ours. A rollout of a policy that we are also rolling out is dropped, the
+// agreements it did not get to keep their service version until they are re-made.
+const SERVICE_ROLLOUT_MOVE = `UPDATE service_rollouts SET partition = $2, updated = current_timestamp WHERE partition = $1
+	AND policy_name NOT IN (SELECT policy_name FROM service_rollouts WHERE partition = $2);`
+const SERVICE_ROLLOUT_DELETE_PARTITION = `DELETE FROM service_rollouts WHERE partition = $1;`
+
+func (db *AgbotPostgresqlDB) FindSingleServiceRollout(policyName string) (*persistence.ServiceRollout, error) {
+
+	rBytes := make([]byte, 0, 2048)
+	if err := db.db.QueryRow(SERVICE_ROLLOUT_QUERY, db.PrimaryPartition(), policyName).Scan(&rBytes); err == sql.ErrNoRows || (err != nil && strings.Contains(err.Error(), "not exist")) {
+		return nil, nil
+	} else if err != nil {
+		return nil, errors.New(fmt.Sprintf("error scanning row for service rollout of policy %v, error: %v", policyName, err))
+	}
+
+	rollout := new(persistence.ServiceRollout)
+	if err := json.Unmarshal(rBytes, rollout); err != nil {
+		return nil, errors.New(fmt.Sprintf("error demarshalling row: %v, error: %v", string(rBytes), err))
+	}
+	return rollout, nil
+}
+
+func (db *AgbotPostgresqlDB) FindServiceRollouts() ([]persistence.ServiceRollout, error) {
+	rollouts := make([]persistence.ServiceRollout, 0)
+
+	rows, err := db.db.Query(ALL_SERVICE_ROLLOUT_QUERY, db.PrimaryPartition())
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("error querying for service rollouts, error: %v", err))
+	}
+
+	// If the rows object doesnt get closed, memory and connections will grow and/or leak.
+	defer rows.Close()
+	for rows.Next() {
+		rBytes := make([]byte, 0, 2048)
+		var rollout persistence.ServiceRollout
+		if err := rows.Scan(&rBytes); err != nil {
+			return nil, errors.New(fmt.Sprintf("error scanning row: %v", err))
+		} else if err := json.Unmarshal(rBytes, &rollout); err != nil {
+			return nil, errors.New(fmt.Sprintf("error demarshalling row: %v, error: %v", string(rBytes), err))
+		}
+		rollouts = append(rollouts, rollout)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, errors.New(fmt.Sprintf("error iterating: %v", err))
+	}
+	return rollouts, nil
+}
+
+func (db *AgbotPostgresqlDB) SaveServiceRollout(rollout *persistence.ServiceRollout) error {
+	if rBytes, err := json.Marshal(rollout); err != nil {
+		return errors.New(fmt.Sprintf("error marshalling service rollout %v, error: %v", rollout, err))
+	} else if _, err := db.db.Exec(SERVICE_ROLLOUT_UPSERT, db.PrimaryPartition(), rollout.PolicyName, rBytes); err != nil {
+		return errors.New(fmt.Sprintf("error saving service rollout %v, error: %v", rollout, err))
+	}
+	glog.V(5).Infof("Succeeded saving service rollout %v", rollout)
+	return nil
+}
+
+func (db *AgbotPostgresqlDB) DeleteServiceRollout(policyName string) error {
+	if _, err := db.db.Exec(SERVICE_ROLLOUT_DELETE, db.PrimaryPartition(), policyName); err != nil {
+		return errors.New(fmt.Sprintf("error deleting service rollout for policy %v, error: %v", policyName, err))
+	}
+	return nil
+}
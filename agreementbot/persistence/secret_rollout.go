@@ -0,0 +1,49 @@
+package persistence
+
+import (
+	"fmt"
+	"github.com/open-horizon/anax/exchangecommon"
+)
+
+// An agreement that is part of a staged secret rollout.
+type SecretRolloutTarget struct {
+	AgreementId string `json:"agreementId"`
+	Protocol    string `json:"protocol"`
+	DeviceId    string `json:"deviceId"`
+	HAGroup     string `json:"haGroup,omitempty"` // The HA group of the node, only set when the rollout is HA group aware
+}
+
+func (t SecretRolloutTarget) String() string {
+	return fmt.Sprintf("AgreementId: %v, Protocol: %v, DeviceId: %v, HAGroup: %v", t.AgreementId, t.Protocol, t.DeviceId, t.HAGroup)
+}
+
+// The secrets sent to an agreement in a staged secret rollout. The secret values are not kept, only the names that
+// are needed to revert the secrets on the node.
+type SecretRolloutUpdate struct {
+	Protocol string                         `json:"protocol"`
+	Bindings []exchangecommon.SecretBinding `json:"bindings"`
+	SentTime uint64                         `json:"sentTime"`
+}
+
+// The state of the staged rollout of a set of secret updates to the agreements of this agbot. The state is saved after
+// each governance pass, so that a restarted agbot resumes the rollout where it was, including the agreements that
+// acknowledged the secret updates and the ones to revert if the rollout is halted. There is at most one secret rollout.
+type SecretRollout struct {
+	Version       uint64                         `json:"version"` // The newest update time of the secrets being rolled out, which is the version of the secrets
+	Strategy      string                         `json:"strategy"`
+	WavePercent   int                            `json:"wavePercent"`
+	SoakS         int                            `json:"soakS"`
+	Candidates    []SecretRolloutTarget          `json:"candidates"`    // The affected agreements, found before the waves are planned
+	Waves         [][]SecretRolloutTarget        `json:"waves"`         // nil until the waves are planned
+	Wave          int                            `json:"wave"`          // The index of the wave that is being rolled out
+	WaveAckedTime uint64                         `json:"waveAckedTime"` // The time when every agreement in the current wave acknowledged the update
+	Halted        bool                           `json:"halted"`
+	HaltReason    string                         `json:"haltReason,omitempty"`
+	SecretExists  map[string]bool                `json:"secretExists"` // Whether the updated secrets could be read, across all the passes of the rollout
+	Updates       map[string]SecretRolloutUpdate `json:"updates"`      // The secret updates sent to each agreement, by agreement id
+}
+
+func (r SecretRollout) String() string {
+	return fmt.Sprintf("Version: %v, Strategy: %v, WavePercent: %v, SoakS: %v, Candidates: %v, Waves: %v, Wave: %v, WaveAckedTime: %v, Halted: %v, HaltReason: %v, Updates: %v",
+		r.Version, r.Strategy, r.WavePercent, r.SoakS, len(r.Candidates), len(r.Waves), r.Wave, r.WaveAckedTime, r.Halted, r.HaltReason, len(r.Updates))
+}
@@ -0,0 +1,80 @@
+package persistence
+
+import (
+	"fmt"
+	"github.com/open-horizon/anax/policy"
+)
+
+// The states of a service version rollout.
+const (
+	ROLLOUT_STATE_IN_PROGRESS = "in_progress"
+	ROLLOUT_STATE_PAUSED      = "paused"
+	ROLLOUT_STATE_HALTED      = "halted"
+	ROLLOUT_STATE_ABORTED     = "aborted"
+	ROLLOUT_STATE_COMPLETED   = "completed"
+)
+
+// The states of a node in a service version rollout.
+const (
+	ROLLOUT_NODE_PENDING   = "pending"   // the node keeps the previous version until its wave starts
+	ROLLOUT_NODE_UPGRADING = "upgrading" // the agreement for the previous version was cancelled
+	ROLLOUT_NODE_DEFERRED  = "deferred"  // the agreement for the previous version is kept until the node's maintenance window opens
+	ROLLOUT_NODE_HEALTHY   = "healthy"   // the node has a healthy agreement for the new version
+	ROLLOUT_NODE_FAILED    = "failed"
+)
+
+// A node that had an agreement for the previous service version when the rollout started.
+type ServiceRolloutNode struct {
+	DeviceId       string `json:"deviceId"`
+	AgreementId    string `json:"agreementId"` // the agreement for the previous version
+	Protocol       string `json:"protocol"`
+	State          string `json:"state"`
+	UpgradeTime    uint64 `json:"upgradeTime,omitempty"`    // when the agreement for the previous version was cancelled, or when the node's maintenance window opened
+	NewAgreementId string `json:"newAgreementId,omitempty"` // the healthy agreement for the new version
+	Reason         string `json:"reason,omitempty"`
+}
+
+func (n ServiceRolloutNode) String() string {
+	return fmt.Sprintf("DeviceId: %v, AgreementId: %v, Protocol: %v, State: %v, UpgradeTime: %v, NewAgreementId: %v, Reason: %v",
+		n.DeviceId, n.AgreementId, n.Protocol, n.State, n.UpgradeTime, n.NewAgreementId, n.Reason)
+}
+
+// The progressive rollout of a new service version to the nodes that have an agreement for a deployment policy. The
+// nodes are split into waves, and the agreements of a wave are cancelled so that new agreements are made with the
+// new version. There is at most one rollout for each policy.
+type ServiceRollout struct {
+	PolicyName      string                 `json:"policyName"`
+	Version         string                 `json:"version"`  // the service version being rolled out
+	Priority        int                    `json:"priority"` // the priority value of the version, 0 if the policy has no priorities
+	Rollout         policy.RolloutPolicy   `json:"rollout"`
+	State           string                 `json:"state"`
+	Reason          string                 `json:"reason,omitempty"`
+	Waves           [][]ServiceRolloutNode `json:"waves"`
+	Wave            int                    `json:"wave"`            // the index of the current wave
+	WaveStartTime   uint64                 `json:"waveStartTime"`   // when the agreements of the current wave were cancelled, 0 until the wave starts
+	WaveHealthyTime uint64                 `json:"waveHealthyTime"` // when every node in the current wave was healthy or had failed
+	StartTime       uint64                 `json:"startTime"`
+	LastUpdateTime  uint64                 `json:"lastUpdateTime"`
+}
+
+func (r ServiceRollout) String() string {
+	return fmt.Sprintf("PolicyName: %v, Version: %v, Priority: %v, Rollout: {%v}, State: %v, Reason: %v, Waves: %v, Wave: %v, WaveStartTime: %v, WaveHealthyTime: %v, StartTime: %v, LastUpdateTime: %v",
+		r.PolicyName, r.Version, r.Priority, r.Rollout, r.State, r.Reason, len(r.Waves), r.Wave, r.WaveStartTime, r.WaveHealthyTime, r.StartTime, r.LastUpdateTime)
+}
+
+// A rollout is active until it is completed, halted or aborted. A paused rollout is still active.
+func (r ServiceRollout) IsActive() bool {
+	return r.State == ROLLOUT_STATE_IN_PROGRESS || r.State == ROLLOUT_STATE_PAUSED
+}
+
+// Returns the wave index and the node of the rollout for the given device, or -1 if the device is not in the rollout.
+func (r ServiceRollout) FindNode(deviceId string) (int, *ServiceRolloutNode) {
+	for ix, wave := range r.Waves {
+		for jx := range wave {
+			if wave[jx].DeviceId == deviceId {
+				return ix, &r.Waves[ix][jx]
+			}
+		}
+	}
+	return -1, nil
+}
@@ -15,9 +15,11 @@ import (
 type PrioritizedWorkQueue struct {
 	inboundHigh         chan *AgreementWork // This is the high priority inbound channel.
 	workQueueBufferHigh []*AgreementWork    // The internal work queue buffer for the high inbound channel.
+	queuedTimeHigh      []time.Time         // The time each work item in the high buffer was queued.
 
 	inboundLow         chan *AgreementWork // This is the low priority inbound channel.
 	workQueueBufferLow []*AgreementWork    // The internal work queue buffer for the low inbound channel.
+	queuedTimeLow      []time.Time         // The time each work item in the low buffer was queued.
 
 	recv       chan *AgreementWork // This is the channel where workers listen/block for work.
 	bufferLock sync.Mutex          // A lock that protects access to the work queue buffers.
@@ -31,8 +33,10 @@ func NewPrioritizedWorkQueue(bufferSize uint64, statInterval int, maxRecords int
 	n := &PrioritizedWorkQueue{
 		inboundHigh:         make(chan *AgreementWork, bufferSize),
 		workQueueBufferHigh: make([]*AgreementWork, 0, bufferSize*2),
+		queuedTimeHigh:      make([]time.Time, 0, bufferSize*2),
 		inboundLow:          make(chan *AgreementWork, bufferSize),
 		workQueueBufferLow:  make([]*AgreementWork, 0, bufferSize*2),
+		queuedTimeLow:       make([]time.Time, 0, bufferSize*2),
 		recv:                make(chan *AgreementWork),
 		bufferSize:          bufferSize,
 		queueHistory:        NewPrioritizedWorkQueueHistory(statInterval, maxRecords),
@@ -122,12 +126,17 @@ func (n *PrioritizedWorkQueue) RemoveHighPriorityBufferHead() {
 	n.bufferLock.Lock()
 	defer n.bufferLock.Unlock()
 	n.workQueueBufferHigh = n.workQueueBufferHigh[1:]
+	workQueueWait.WithLabelValues(HIGH_PRIORITY).Observe(time.Since(n.queuedTimeHigh[0]).Seconds())
+	n.queuedTimeHigh = n.queuedTimeHigh[1:]
+	workQueueDepth.WithLabelValues(HIGH_PRIORITY).Dec()
 }
 
 func (n *PrioritizedWorkQueue) AddToHighPriorityBuffer(w *AgreementWork) {
 	n.bufferLock.Lock()
 	defer n.bufferLock.Unlock()
 	n.workQueueBufferHigh = append(n.workQueueBufferHigh, w)
+	n.queuedTimeHigh = append(n.queuedTimeHigh, time.Now())
+	workQueueDepth.WithLabelValues(HIGH_PRIORITY).Inc()
 }
 
 func (n *PrioritizedWorkQueue) LowPriorityBufferLen() int {
@@ -146,12 +155,17 @@ func (n *PrioritizedWorkQueue) RemoveLowPriorityBufferHead() {
 	n.bufferLock.Lock()
 	defer n.bufferLock.Unlock()
 	n.workQueueBufferLow = n.workQueueBufferLow[1:]
+	workQueueWait.WithLabelValues(LOW_PRIORITY).Observe(time.Since(n.queuedTimeLow[0]).Seconds())
+	n.queuedTimeLow = n.queuedTimeLow[1:]
+	workQueueDepth.WithLabelValues(LOW_PRIORITY).Dec()
 }
 
 func (n *PrioritizedWorkQueue) AddToLowPriorityBuffer(w *AgreementWork) {
 	n.bufferLock.Lock()
 	defer n.bufferLock.Unlock()
 	n.workQueueBufferLow = append(n.workQueueBufferLow, w)
+	n.queuedTimeLow = append(n.queuedTimeLow, time.Now())
+	workQueueDepth.WithLabelValues(LOW_PRIORITY).Inc()
 }
 
 const HIGH_PRIORITY = "high"
@@ -0,0 +1,297 @@
+package agreementbot
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/open-horizon/anax/agreementbot/persistence"
+	"github.com/open-horizon/anax/basicprotocol"
+	"github.com/open-horizon/anax/config"
+	"github.com/open-horizon/anax/exchangecommon"
+)
+
+// A staged secret rollout updates the agreements affected by a set of secret updates in waves. The first governance
+// pass after the secret updates are detected finds the affected agreements, which are then split into waves. Each
+// following pass sends the secret updates to the agreements in the current wave. The next wave starts when every
+// agreement in the current wave has acknowledged the update and its services have kept running for the soak time.
+// The rollout halts if a node rejects the update or the services of the wave fail, and the agreements that were
+// updated are asked to go back to the previous version of the secrets. The state of the rollout is saved in the
+// database, see persistence.SecretRollout.
+type SecretRollout struct {
+	persistence.SecretRollout
+}
+
+func NewSecretRollout(cfg config.SecretRolloutConfig, version uint64) *SecretRollout {
+	return &SecretRollout{persistence.SecretRollout{
+		Version:      version,
+		Strategy:     cfg.Strategy,
+		WavePercent:  cfg.WavePercent,
+		SoakS:        cfg.SoakS,
+		Candidates:   make([]persistence.SecretRolloutTarget, 0),
+		SecretExists: make(map[string]bool),
+		Updates:      make(map[string]persistence.SecretRolloutUpdate),
+	}}
+}
+
+func (r *SecretRollout) IsPlanned() bool {
+	return r.Waves != nil
+}
+
+// The rollout is done when it is halted or every wave has been rolled out.
+func (r *SecretRollout) IsDone() bool {
+	return r.Halted || (r.IsPlanned() && r.Wave >= len(r.Waves))
+}
+
+// Split the affected agreements into waves.
+func (r *SecretRollout) Plan() {
+	r.Waves = planSecretRolloutWaves(r.Candidates, r.WavePercent, r.Strategy == config.SECRET_ROLLOUT_HAGROUP)
+	r.Wave = 0
+	r.WaveAckedTime = 0
+}
+
+// Returns true if the secret updates can be sent to the agreement now. Before the waves are planned, the agreement
+// is only recorded. An agreement that shows up after the waves are planned is added to the last wave.
+func (r *SecretRollout) Admit(target persistence.SecretRolloutTarget) bool {
+	if r.Halted {
+		return false
+	} else if !r.IsPlanned() {
+		for _, c := range r.Candidates {
+			if c.AgreementId == target.AgreementId {
+				return false
+			}
+		}
+		r.Candidates = append(r.Candidates, target)
+		return false
+	}
+
+	for ix, wave := range r.Waves {
+		for _, t := range wave {
+			if t.AgreementId == target.AgreementId {
+				return ix <= r.Wave
+			}
+		}
+	}
+
+	if len(r.Waves) == 0 || r.Wave >= len(r.Waves) {
+		r.Waves = append(r.Waves, []persistence.SecretRolloutTarget{target})
+	} else {
+		r.Waves[len(r.Waves)-1] = append(r.Waves[len(r.Waves)-1], target)
+	}
+	return len(r.Waves)-1 <= r.Wave
+}
+
+func (r *SecretRollout) CurrentWave() []persistence.SecretRolloutTarget {
+	if !r.IsPlanned() || r.Wave >= len(r.Waves) {
+		return []persistence.SecretRolloutTarget{}
+	}
+	return r.Waves[r.Wave]
+}
+
+// Record the secret updates sent to an agreement.
+func (r *SecretRollout) Sent(agreementId string, protocol string, bindings []exchangecommon.SecretBinding) {
+	names := make([]exchangecommon.SecretBinding, 0, len(bindings))
+	for _, binding := range bindings {
+		nb := binding.MakeCopy()
+		for _, bs := range nb.Secrets {
+			for serviceSecretName := range bs {
+				bs[serviceSecretName] = ""
+			}
+		}
+		names = append(names, nb)
+	}
+	r.Updates[agreementId] = persistence.SecretRolloutUpdate{Protocol: protocol, Bindings: names, SentTime: uint64(time.Now().Unix())}
+}
+
+func (r *SecretRollout) IsSent(agreementId string) bool {
+	_, ok := r.Updates[agreementId]
+	return ok
+}
+
+// Remove an agreement that ended before the secret updates were sent to it.
+func (r *SecretRollout) Remove(agreementId string) {
+	for ix, wave := range r.Waves {
+		for jx, t := range wave {
+			if t.AgreementId == agreementId {
+				r.Waves[ix] = append(wave[:jx], wave[jx+1:]...)
+				return
+			}
+		}
+	}
+}
+
+func (r *SecretRollout) NextWave() {
+	r.Wave += 1
+	r.WaveAckedTime = 0
+}
+
+func (r *SecretRollout) Halt(reason string) {
+	r.Halted = true
+	r.HaltReason = reason
+}
+
+// Split the targets into waves of the given percentage of the targets. When the waves are HA group aware, two members
+// of the same HA group are never in the same wave, so that the group keeps running while one of its members is
+// updated. Waves can then be smaller than the percentage, and there can be more of them.
+func planSecretRolloutWaves(targets []persistence.SecretRolloutTarget, percent int, haAware bool) [][]persistence.SecretRolloutTarget {
+
+	waves := make([][]persistence.SecretRolloutTarget, 0)
+	if len(targets) == 0 {
+		return waves
+	}
+
+	if percent <= 0 || percent > 100 {
+		percent = 100
+	}
+	size := (len(targets)*percent + 99) / 100
+	if size == 0 {
+		size = 1
+	}
+
+	for _, target := range targets {
+		placed := false
+		for ix, wave := range waves {
+			if len(wave) >= size || (haAware && target.HAGroup != "" && waveHasHAGroup(wave, target.HAGroup)) {
+				continue
+			}
+			waves[ix] = append(wave, target)
+			placed = true
+			break
+		}
+		if !placed {
+			waves = append(waves, []persistence.SecretRolloutTarget{target})
+		}
+	}
+
+	return waves
+}
+
+func waveHasHAGroup(wave []persistence.SecretRolloutTarget, haGroup string) bool {
+	for _, t := range wave {
+		if t.HAGroup == haGroup {
+			return true
+		}
+	}
+	return false
+}
+
+// Add an agreement to a staged secret rollout, and return true if the secret updates can be sent to it now.
+func (w *AgreementBotWorker) admitToSecretRollout(r *SecretRollout, ag *persistence.Agreement, protocol string) bool {
+
+	target := persistence.SecretRolloutTarget{AgreementId: ag.CurrentAgreementId, Protocol: protocol, DeviceId: ag.DeviceId}
+
+	if !r.IsPlanned() && r.Strategy == config.SECRET_ROLLOUT_HAGROUP {
+		if dev, err := GetDevice(w.GetHTTPFactory().NewHTTPClient(nil), ag.DeviceId, w.GetExchangeURL(), w.GetExchangeId(), w.GetExchangeToken()); err != nil {
+			glog.Errorf(logString(fmt.Sprintf("error getting device %v for secret rollout, error: %v", ag.DeviceId, err)))
+		} else if dev != nil {
+			target.HAGroup = dev.HAGroup
+		}
+	}
+
+	return r.Admit(target)
+}
+
+// Move a staged secret rollout forward. The waves are planned on the first call, after the affected agreements have
+// been found. Returns true when the rollout is done.
+func (w *AgreementBotWorker) governSecretRollout(r *SecretRollout) bool {
+
+	if !r.IsPlanned() {
+		r.Plan()
+		glog.V(3).Infof(logString(fmt.Sprintf("planned secret rollout %v", r)))
+		return r.IsDone()
+	} else if r.IsDone() {
+		return true
+	}
+
+	now := uint64(time.Now().Unix())
+	acked := true
+	ended := make([]string, 0)
+
+	for _, t := range r.CurrentWave() {
+		ag, err := w.db.FindSingleAgreementByAgreementId(t.AgreementId, t.Protocol, []persistence.AFilter{})
+		if err != nil {
+			glog.Errorf(logString(fmt.Sprintf("unable to read agreement %v for secret rollout, error: %v", t.AgreementId, err)))
+			return false
+		}
+
+		if !r.IsSent(t.AgreementId) {
+			if ag == nil || ag.Archived {
+				// The agreement ended before it was updated, it does not say anything about the secrets.
+				ended = append(ended, t.AgreementId)
+			} else {
+				acked = false
+			}
+			continue
+		}
+
+		if ag == nil || ag.Archived {
+			w.haltSecretRollout(r, fmt.Sprintf("agreement %v ended after the secret update", t.AgreementId))
+			return true
+		} else if ag.LastSecretUpdateTimeNack != 0 && ag.LastSecretUpdateTimeNack >= ag.LastSecretUpdateTime {
+			w.haltSecretRollout(r, fmt.Sprintf("node %v rejected the secret update for agreement %v", t.DeviceId, t.AgreementId))
+			return true
+		} else if ag.LastSecretUpdateTimeAck < ag.LastSecretUpdateTime {
+			acked = false
+			timeout := ag.ProtocolTimeoutS
+			if timeout == 0 {
+				_, timeout = w.SetAgreementTimeouts(*ag, t.Protocol)
+			}
+			if now-r.Updates[t.AgreementId].SentTime > timeout {
+				w.haltSecretRollout(r, fmt.Sprintf("node %v did not acknowledge the secret update for agreement %v", t.DeviceId, t.AgreementId))
+				return true
+			}
+		}
+	}
+
+	for _, agreementId := range ended {
+		r.Remove(agreementId)
+	}
+
+	if !acked {
+		return false
+	} else if r.WaveAckedTime == 0 {
+		glog.V(3).Infof(logString(fmt.Sprintf("secret rollout wave %v of %v acknowledged, soaking for %v seconds", r.Wave+1, len(r.Waves), r.SoakS)))
+		r.WaveAckedTime = now
+	}
+
+	if now-r.WaveAckedTime < uint64(r.SoakS) {
+		return false
+	}
+
+	// The soak time is over, the services of the wave must still be running.
+	for _, t := range r.CurrentWave() {
+		if running, err := w.WorkloadRunningOnDevice(t.DeviceId, t.AgreementId); err != nil {
+			glog.Errorf(logString(fmt.Sprintf("unable to check the services of agreement %v for secret rollout, error: %v", t.AgreementId, err)))
+			return false
+		} else if !running {
+			w.haltSecretRollout(r, fmt.Sprintf("the services of agreement %v on node %v are not running after the secret update", t.AgreementId, t.DeviceId))
+			return true
+		}
+	}
+
+	glog.V(3).Infof(logString(fmt.Sprintf("secret rollout wave %v of %v completed", r.Wave+1, len(r.Waves))))
+	r.NextWave()
+	return r.IsDone()
+}
+
+// Halt a staged secret rollout and ask the nodes that accepted the secret updates to go back to the previous version
+// of the secrets. The nodes that have not been updated keep the previous version. The secrets are not rolled out
+// again until they change in the secrets provider.
+func (w *AgreementBotWorker) haltSecretRollout(r *SecretRollout, reason string) {
+
+	glog.Errorf(logString(fmt.Sprintf("halting secret rollout of version %v: %v", r.Version, reason)))
+	r.Halt(reason)
+
+	for agreementId, update := range r.Updates {
+		if ag, err := w.db.FindSingleAgreementByAgreementId(agreementId, update.Protocol, []persistence.AFilter{persistence.UnarchivedAFilter()}); err != nil {
+			glog.Errorf(logString(fmt.Sprintf("unable to read agreement %v to revert secrets, error: %v", agreementId, err)))
+		} else if ag == nil || ag.LastSecretUpdateTimeAck < ag.LastSecretUpdateTime {
+			// The node did not apply the secret updates, or the agreement is gone.
+			continue
+		} else {
+			glog.V(3).Infof(logString(fmt.Sprintf("reverting secrets %v for agreement %v", update.Bindings, agreementId)))
+			protocolHandler := w.consumerPH.Get(update.Protocol)
+			protocolHandler.UpdateAgreement(ag, basicprotocol.MsgUpdateTypeSecretRevert, update.Bindings, protocolHandler)
+		}
+	}
+}
@@ -0,0 +1,100 @@
+//go:build unit
+// +build unit
+
+package agreementbot
+
+import (
+	"testing"
+
+	"github.com/open-horizon/anax/agreementbot/persistence"
+	"github.com/open-horizon/anax/config"
+	"github.com/open-horizon/anax/exchangecommon"
+	"github.com/stretchr/testify/assert"
+)
+
+func rolloutTargets(haGroups ...string) []persistence.SecretRolloutTarget {
+	targets := make([]persistence.SecretRolloutTarget, 0)
+	for ix, g := range haGroups {
+		targets = append(targets, persistence.SecretRolloutTarget{AgreementId: string(rune('a' + ix)), DeviceId: "org/node" + string(rune('a'+ix)), HAGroup: g})
+	}
+	return targets
+}
+
+func Test_planSecretRolloutWaves(t *testing.T) {
+
+	assert.Equal(t, 0, len(planSecretRolloutWaves(rolloutTargets(), 25, false)))
+
+	// Wave sizes are rounded up.
+	waves := planSecretRolloutWaves(rolloutTargets("", "", "", "", ""), 25, false)
+	assert.Equal(t, 3, len(waves))
+	assert.Equal(t, 2, len(waves[0]))
+	assert.Equal(t, 1, len(waves[2]))
+
+	waves = planSecretRolloutWaves(rolloutTargets("", "", ""), 100, false)
+	assert.Equal(t, 1, len(waves))
+
+	// Members of an HA group are spread across waves.
+	waves = planSecretRolloutWaves(rolloutTargets("g1", "g1", "g2", "g2", ""), 50, true)
+	for _, wave := range waves {
+		groups := make(map[string]bool)
+		for _, target := range wave {
+			if target.HAGroup != "" {
+				assert.False(t, groups[target.HAGroup], "HA group %v is in a wave twice: %v", target.HAGroup, wave)
+				groups[target.HAGroup] = true
+			}
+		}
+	}
+	assert.Equal(t, 2, len(waves))
+
+	// Without HA awareness the group members can share a wave.
+	waves = planSecretRolloutWaves(rolloutTargets("g1", "g1", "g1", "g1"), 50, false)
+	assert.Equal(t, 2, len(waves))
+	waves = planSecretRolloutWaves(rolloutTargets("g1", "g1", "g1", "g1"), 50, true)
+	assert.Equal(t, 4, len(waves))
+}
+
+func Test_SecretRollout_Admit(t *testing.T) {
+
+	r := NewSecretRollout(config.SecretRolloutConfig{Strategy: config.SECRET_ROLLOUT_PERCENTAGE, WavePercent: 50, SoakS: 60}, 100)
+	targets := rolloutTargets("", "", "", "")
+
+	// Nothing is sent before the waves are planned.
+	for _, target := range targets {
+		assert.False(t, r.Admit(target))
+	}
+	assert.False(t, r.Admit(targets[0]))
+	assert.Equal(t, 4, len(r.Candidates))
+
+	r.Plan()
+	assert.Equal(t, 2, len(r.Waves))
+	assert.True(t, r.Admit(targets[0]))
+	assert.True(t, r.Admit(targets[1]))
+	assert.False(t, r.Admit(targets[2]))
+
+	// A new agreement goes in the last wave.
+	late := persistence.SecretRolloutTarget{AgreementId: "late"}
+	assert.False(t, r.Admit(late))
+	assert.Equal(t, 3, len(r.Waves[1]))
+
+	r.Sent(targets[0].AgreementId, "Basic", []exchangecommon.SecretBinding{{ServiceUrl: "svc", Secrets: []exchangecommon.BoundSecret{{"pw": "c2VjcmV0"}}}})
+	assert.True(t, r.IsSent(targets[0].AgreementId))
+	assert.Equal(t, "", r.Updates[targets[0].AgreementId].Bindings[0].Secrets[0]["pw"], "The secret values should not be kept.")
+
+	r.NextWave()
+	assert.False(t, r.IsDone())
+	assert.True(t, r.Admit(late))
+
+	r.Remove("late")
+	assert.Equal(t, 2, len(r.Waves[1]))
+
+	r.NextWave()
+	assert.True(t, r.IsDone())
+
+	// A halted rollout does not send anything more.
+	r = NewSecretRollout(config.SecretRolloutConfig{Strategy: config.SECRET_ROLLOUT_PERCENTAGE, WavePercent: 100}, 100)
+	r.Admit(targets[0])
+	r.Plan()
+	r.Halt("test")
+	assert.False(t, r.Admit(targets[0]))
+	assert.True(t, r.IsDone())
+}
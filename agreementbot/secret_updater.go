@@ -8,6 +8,7 @@ import (
 	"github.com/open-horizon/anax/agreementbot/secrets"
 	"github.com/open-horizon/anax/cli/cliutils"
 	"github.com/open-horizon/anax/compcheck"
+	"github.com/open-horizon/anax/config"
 	"github.com/open-horizon/anax/cutil"
 	"github.com/open-horizon/anax/events"
 	"github.com/open-horizon/anax/exchange"
@@ -22,16 +23,19 @@ type SecretUpdateManager struct {
 	PollMinInterval       int
 	PollMaxInterval       int
 	PollIntervalIncrement int
-	PULock                sync.Mutex // The lock that protects the list of pending secret updates.
+	RolloutConfig         config.SecretRolloutConfig // How secret updates are rolled out to the agreements
+	Rollout               *SecretRollout             // The staged rollout of the secret updates at the front of the pending list, if any
+	PULock                sync.Mutex                 // The lock that protects the list of pending secret updates.
 }
 
-func NewSecretUpdateManager(pollInterval int, pollMinInterval int, pollMaxInterval int, pollIntervalIncrement int) *SecretUpdateManager {
+func NewSecretUpdateManager(pollInterval int, pollMinInterval int, pollMaxInterval int, pollIntervalIncrement int, rolloutConfig config.SecretRolloutConfig) *SecretUpdateManager {
 	sum := &SecretUpdateManager{
 		PendingUpdates:        make([]*events.SecretUpdates, 0),
 		PollInterval:          pollInterval,          // 60s
 		PollMinInterval:       pollMinInterval,       // 60s
 		PollMaxInterval:       pollMaxInterval,       // 300s
 		PollIntervalIncrement: pollIntervalIncrement, // 30s
+		RolloutConfig:         rolloutConfig,
 	}
 	return sum
 }
@@ -85,6 +89,95 @@ func (sm *SecretUpdateManager) SetUpdateEvent(ev *events.SecretUpdates) {
 	sm.PendingUpdates = append(sm.PendingUpdates, ev)
 }
 
+// Put secret updates back at the front of the pending list, because their staged rollout is not done.
+func (sm *SecretUpdateManager) RequeueUpdateEvent(ev *events.SecretUpdates) {
+
+	sm.PULock.Lock()
+	defer sm.PULock.Unlock()
+
+	sm.PendingUpdates = append([]*events.SecretUpdates{ev}, sm.PendingUpdates...)
+}
+
+// Return the staged rollout of the given secret updates, starting it if needed. A rollout of the same secret updates
+// that was saved before the agbot restarted is resumed. Returns nil when secret updates are sent to all the agreements
+// immediately.
+func (sm *SecretUpdateManager) StartRollout(ev *events.SecretUpdates, db persistence.AgbotDatabase) *SecretRollout {
+
+	if ev == nil || !sm.RolloutConfig.IsStaged() {
+		return nil
+	}
+
+	sm.PULock.Lock()
+	defer sm.PULock.Unlock()
+
+	if sm.Rollout == nil {
+		version := int64(0)
+		for _, su := range ev.Updates {
+			if su.SecretUpdateTime > version {
+				version = su.SecretUpdateTime
+			}
+		}
+
+		if saved, err := db.FindSecretRollout(); err != nil {
+			glog.Errorf(smlogString(fmt.Sprintf("unable to read the saved secret rollout, error: %v", err)))
+		} else if saved != nil && saved.Version == uint64(version) {
+			sm.Rollout = &SecretRollout{*saved}
+			if sm.Rollout.SecretExists == nil {
+				sm.Rollout.SecretExists = make(map[string]bool)
+			}
+			if sm.Rollout.Updates == nil {
+				sm.Rollout.Updates = make(map[string]persistence.SecretRolloutUpdate)
+			}
+			glog.V(3).Infof(smlogString(fmt.Sprintf("resuming secret rollout %v", sm.Rollout)))
+			return sm.Rollout
+		} else if saved != nil {
+			// The secrets changed again while the agbot was down, the new secret updates are rolled out instead.
+			glog.Warningf(smlogString(fmt.Sprintf("dropping the saved secret rollout %v, the secrets have changed since", saved)))
+		}
+
+		sm.Rollout = NewSecretRollout(sm.RolloutConfig, uint64(version))
+		glog.V(3).Infof(smlogString(fmt.Sprintf("starting secret rollout %v", sm.Rollout)))
+	}
+	return sm.Rollout
+}
+
+// Save the state of the staged rollout, so that it is resumed if the agbot restarts.
+func (sm *SecretUpdateManager) SaveRollout(db persistence.AgbotDatabase) {
+
+	sm.PULock.Lock()
+	defer sm.PULock.Unlock()
+
+	if sm.Rollout != nil {
+		if err := db.SaveSecretRollout(&sm.Rollout.SecretRollout); err != nil {
+			glog.Errorf(smlogString(fmt.Sprintf("unable to save secret rollout %v, error: %v", sm.Rollout, err)))
+		}
+	}
+}
+
+func (sm *SecretUpdateManager) EndRollout(db persistence.AgbotDatabase) {
+
+	sm.PULock.Lock()
+	defer sm.PULock.Unlock()
+
+	if sm.Rollout != nil {
+		glog.V(3).Infof(smlogString(fmt.Sprintf("ended secret rollout %v", sm.Rollout)))
+	}
+	if err := db.DeleteSecretRollout(); err != nil {
+		glog.Errorf(smlogString(fmt.Sprintf("unable to delete the saved secret rollout, error: %v", err)))
+	}
+	sm.Rollout = nil
+}
+
+// Returns true while a staged secret rollout is in progress. New secret updates are not looked for until the rollout
+// is done.
+func (sm *SecretUpdateManager) IsRolloutInProgress() bool {
+
+	sm.PULock.Lock()
+	defer sm.PULock.Unlock()
+
+	return sm.Rollout != nil
+}
+
 // Examine all the managed secrets in the DB, to see if any of them have been updated in the secret provider.
 func (sm *SecretUpdateManager) CheckForUpdates(secretProvider secrets.AgbotSecrets, db persistence.AgbotDatabase) (*events.SecretUpdates, error) {
 
@@ -5,9 +5,14 @@ package agreementbot
 
 import (
 	//"fmt"
+	"os"
 	"testing"
 
+	"github.com/open-horizon/anax/agreementbot/persistence"
+	"github.com/open-horizon/anax/agreementbot/persistence/bolt"
+	"github.com/open-horizon/anax/config"
 	"github.com/open-horizon/anax/events"
+	"github.com/open-horizon/anax/exchangecommon"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -30,7 +35,7 @@ func Test_SUM_Queue(t *testing.T) {
 	sus.AddSecretUpdate(su2)
 
 	// Now test the secret update manager.
-	sum := NewSecretUpdateManager(60, 60, 300, 30)
+	sum := NewSecretUpdateManager(60, 60, 300, 30, config.SecretRolloutConfig{})
 	sum.SetUpdateEvent(sus)
 
 	assert.True(t, len(sum.PendingUpdates) == 1, "There should be 1 pending update")
@@ -41,3 +46,54 @@ func Test_SUM_Queue(t *testing.T) {
 	assert.True(t, ne.Updates[0].SecretFullName == "mysecret1", "The first secret with an update should be mysecret1")
 
 }
+
+// A staged secret rollout is saved in the database and resumed by a restarted agbot.
+func Test_SUM_Rollout_Resume(t *testing.T) {
+
+	dir, err := os.MkdirTemp("", "agbot-secret-rollout-")
+	if err != nil {
+		t.Fatalf("unable to create temp dir, error: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	db := new(bolt.AgbotBoltDB)
+	if err := db.Initialize(&config.HorizonConfig{AgreementBot: config.AGConfig{DBPath: dir}}); err != nil {
+		t.Fatalf("unable to initialize agbot database, error: %v", err)
+	}
+	defer db.Close()
+
+	sus := events.NewSecretUpdates()
+	sus.AddSecretUpdate(events.NewSecretUpdate("org1", "mysecret1", 100, []string{"p1"}, []string{}, ""))
+	rolloutConfig := config.SecretRolloutConfig{Strategy: config.SECRET_ROLLOUT_PERCENTAGE, WavePercent: 50, SoakS: 60}
+
+	sum := NewSecretUpdateManager(60, 60, 300, 30, rolloutConfig)
+	r := sum.StartRollout(sus, db)
+	assert.NotNil(t, r)
+	r.Admit(persistence.SecretRolloutTarget{AgreementId: "a", Protocol: "Basic", DeviceId: "org1/node1"})
+	r.Admit(persistence.SecretRolloutTarget{AgreementId: "b", Protocol: "Basic", DeviceId: "org1/node2"})
+	r.Plan()
+	r.Sent("a", "Basic", []exchangecommon.SecretBinding{{ServiceUrl: "svc", Secrets: []exchangecommon.BoundSecret{{"pw": "c2VjcmV0"}}}})
+	sum.SaveRollout(db)
+
+	// The restarted agbot finds the same secret updates again and resumes the rollout.
+	sum = NewSecretUpdateManager(60, 60, 300, 30, rolloutConfig)
+	r = sum.StartRollout(sus, db)
+	assert.Equal(t, uint64(100), r.Version)
+	assert.Equal(t, 2, len(r.Waves))
+	assert.True(t, r.IsSent("a"))
+	assert.False(t, r.IsSent("b"))
+	assert.Equal(t, "", r.Updates["a"].Bindings[0].Secrets[0]["pw"], "The secret values should not be saved.")
+
+	// A saved rollout of secrets that have changed since is not resumed.
+	sum = NewSecretUpdateManager(60, 60, 300, 30, rolloutConfig)
+	newer := events.NewSecretUpdates()
+	newer.AddSecretUpdate(events.NewSecretUpdate("org1", "mysecret1", 200, []string{"p1"}, []string{}, ""))
+	r = sum.StartRollout(newer, db)
+	assert.Equal(t, uint64(200), r.Version)
+	assert.False(t, r.IsPlanned())
+
+	sum.EndRollout(db)
+	saved, err := db.FindSecretRollout()
+	assert.Nil(t, err)
+	assert.Nil(t, saved)
+}
@@ -0,0 +1,273 @@
+package file
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/open-horizon/anax/agreementbot/secrets"
+	"github.com/open-horizon/anax/config"
+)
+
+// This function registers an uninitialized agbot secrets implementation with the secrets plugin registry. The plugin's Initialize
+// method is used to configure the object.
+func init() {
+	secrets.Register("file", new(AgbotFileSecrets))
+}
+
+// The encrypted file secrets provider keeps all the secrets in a single file that is encrypted with AES-256-GCM. It is meant
+// for development and for air-gapped installations that cannot run a vault. The file is only read when the agbot starts, so
+// it must not be shared by several agbots.
+type AgbotFileSecrets struct {
+	secrets.StoreSecrets
+	path            string
+	key             []byte
+	lock            sync.RWMutex
+	orgs            map[string]map[string]secrets.StoredSecret // The secrets by org and path, nil until the file is loaded
+	lastInteraction uint64
+}
+
+func (fs *AgbotFileSecrets) String() string {
+	return fmt.Sprintf("Path: %v", fs.path)
+}
+
+// This function is called by the anax main to allow the plugin a chance to initialize itself. The key is read here so that
+// a configuration error is reported when the agbot starts.
+func (fs *AgbotFileSecrets) Initialize(cfg *config.HorizonConfig) error {
+
+	glog.V(1).Infof(filePluginLogString("Initializing the encrypted file as the secrets plugin."))
+
+	fs.InitializeStore(cfg, fs)
+	fs.path = cfg.AgreementBot.FileSecrets.Path
+
+	keyBytes, err := os.ReadFile(filepath.Clean(cfg.AgreementBot.FileSecrets.KeyPath))
+	if err != nil {
+		return errors.New(fmt.Sprintf("unable to read secrets key file %v, error: %v", cfg.AgreementBot.FileSecrets.KeyPath, err))
+	} else if len(keyBytes) < 32 {
+		return errors.New(fmt.Sprintf("secrets key file %v must contain at least 32 bytes", cfg.AgreementBot.FileSecrets.KeyPath))
+	}
+	key := sha256.Sum256(keyBytes)
+	fs.key = key[:]
+
+	glog.V(1).Infof(filePluginLogString("Initialized the encrypted file as the secrets plugin"))
+	return nil
+}
+
+// Load the secrets from the file. The file is created the first time a secret is saved.
+func (fs *AgbotFileSecrets) Login() error {
+
+	glog.V(3).Infof(filePluginLogString(fmt.Sprintf("loading secrets from %v", fs.path)))
+
+	if fs.key == nil {
+		return errors.New("the file secrets plugin was not initialized")
+	}
+
+	orgs, err := fs.readFile()
+	if err != nil {
+		return err
+	}
+
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+	fs.orgs = orgs
+	fs.lastInteraction = uint64(time.Now().Unix())
+
+	glog.V(3).Infof(filePluginLogString("loaded secrets."))
+	return nil
+}
+
+// There is no session to renew, but the file should still be there.
+func (fs *AgbotFileSecrets) Renew() error {
+	if _, err := os.Stat(fs.path); err != nil && !os.IsNotExist(err) {
+		return errors.New(fmt.Sprintf("unable to access secrets file %v, error: %v", fs.path, err))
+	}
+
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+	fs.lastInteraction = uint64(time.Now().Unix())
+	return nil
+}
+
+func (fs *AgbotFileSecrets) IsReady() bool {
+	fs.lock.RLock()
+	defer fs.lock.RUnlock()
+	return fs.orgs != nil
+}
+
+func (fs *AgbotFileSecrets) Close() {
+	glog.V(2).Infof("Closed encrypted file secrets implementation")
+}
+
+func (fs *AgbotFileSecrets) GetLastStatus() uint64 {
+	fs.lock.RLock()
+	defer fs.lock.RUnlock()
+	return fs.lastInteraction
+}
+
+func (fs *AgbotFileSecrets) Get(org string, path string) (*secrets.StoredSecret, error) {
+	fs.lock.RLock()
+	defer fs.lock.RUnlock()
+
+	if s, ok := fs.orgs[org][path]; ok {
+		return &s, nil
+	}
+	return nil, nil
+}
+
+func (fs *AgbotFileSecrets) List(org string) ([]string, error) {
+	fs.lock.RLock()
+	defer fs.lock.RUnlock()
+
+	paths := make([]string, 0, len(fs.orgs[org]))
+	for path := range fs.orgs[org] {
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
+
+func (fs *AgbotFileSecrets) Put(org string, path string, details secrets.SecretDetails) error {
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+
+	now := time.Now().Unix()
+	s, ok := fs.orgs[org][path]
+	if !ok {
+		s.CreationTime = now
+	}
+	s.Details = details
+	s.UpdateTime = now
+
+	orgs := fs.copyOrgs()
+	if orgs[org] == nil {
+		orgs[org] = make(map[string]secrets.StoredSecret)
+	}
+	orgs[org][path] = s
+	return fs.save(orgs)
+}
+
+func (fs *AgbotFileSecrets) Delete(org string, path string) (bool, error) {
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+
+	if _, ok := fs.orgs[org][path]; !ok {
+		return false, nil
+	}
+
+	orgs := fs.copyOrgs()
+	delete(orgs[org], path)
+	if len(orgs[org]) == 0 {
+		delete(orgs, org)
+	}
+	return true, fs.save(orgs)
+}
+
+// Copy the secrets so that the secrets in memory are only changed once the file has been written. The caller must hold the lock.
+func (fs *AgbotFileSecrets) copyOrgs() map[string]map[string]secrets.StoredSecret {
+	orgs := make(map[string]map[string]secrets.StoredSecret, len(fs.orgs))
+	for org, paths := range fs.orgs {
+		orgs[org] = make(map[string]secrets.StoredSecret, len(paths))
+		for path, s := range paths {
+			orgs[org][path] = s
+		}
+	}
+	return orgs
+}
+
+// Write the secrets to the file and make them the secrets in memory. The caller must hold the lock.
+func (fs *AgbotFileSecrets) save(orgs map[string]map[string]secrets.StoredSecret) error {
+	if err := fs.writeFile(orgs); err != nil {
+		return err
+	}
+	fs.orgs = orgs
+	fs.lastInteraction = uint64(time.Now().Unix())
+	return nil
+}
+
+// Read and decrypt the secrets file. A file that does not exist yet has no secrets.
+func (fs *AgbotFileSecrets) readFile() (map[string]map[string]secrets.StoredSecret, error) {
+
+	orgs := make(map[string]map[string]secrets.StoredSecret)
+
+	cipherText, err := os.ReadFile(filepath.Clean(fs.path))
+	if os.IsNotExist(err) {
+		return orgs, nil
+	} else if err != nil {
+		return nil, errors.New(fmt.Sprintf("unable to read secrets file %v, error: %v", fs.path, err))
+	}
+
+	gcm, err := fs.newGCM()
+	if err != nil {
+		return nil, err
+	} else if len(cipherText) < gcm.NonceSize() {
+		return nil, errors.New(fmt.Sprintf("secrets file %v is truncated", fs.path))
+	}
+
+	nonce, sealed := cipherText[:gcm.NonceSize()], cipherText[gcm.NonceSize():]
+	plainText, err := gcm.Open(nil, nonce, sealed, nil)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("unable to decrypt secrets file %v, the key may be wrong, error: %v", fs.path, err))
+	}
+
+	if err := json.Unmarshal(plainText, &orgs); err != nil {
+		return nil, errors.New(fmt.Sprintf("unable to parse secrets file %v, error: %v", fs.path, err))
+	}
+	return orgs, nil
+}
+
+// Encrypt and write the secrets file. The file is replaced in one step so that a crash does not leave a partial file.
+func (fs *AgbotFileSecrets) writeFile(orgs map[string]map[string]secrets.StoredSecret) error {
+
+	plainText, err := json.Marshal(orgs)
+	if err != nil {
+		return errors.New(fmt.Sprintf("unable to serialize secrets, error: %v", err))
+	}
+
+	gcm, err := fs.newGCM()
+	if err != nil {
+		return err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return errors.New(fmt.Sprintf("unable to generate nonce, error: %v", err))
+	}
+	cipherText := gcm.Seal(nonce, nonce, plainText, nil)
+
+	if err := os.MkdirAll(filepath.Dir(fs.path), 0700); err != nil {
+		return errors.New(fmt.Sprintf("unable to create directory for secrets file %v, error: %v", fs.path, err))
+	}
+
+	tmpPath := fs.path + ".tmp"
+	if err := os.WriteFile(tmpPath, cipherText, 0600); err != nil {
+		return errors.New(fmt.Sprintf("unable to write secrets file %v, error: %v", tmpPath, err))
+	} else if err := os.Rename(tmpPath, fs.path); err != nil {
+		return errors.New(fmt.Sprintf("unable to replace secrets file %v, error: %v", fs.path, err))
+	}
+	return nil
+}
+
+func (fs *AgbotFileSecrets) newGCM() (cipher.AEAD, error) {
+	block, err := aes.NewCipher(fs.key)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("unable to create cipher, error: %v", err))
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("unable to create cipher, error: %v", err))
+	}
+	return gcm, nil
+}
+
+var filePluginLogString = func(v interface{}) string {
+	return fmt.Sprintf("File Secrets Plugin: %v", v)
+}
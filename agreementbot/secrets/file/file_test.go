@@ -0,0 +1,100 @@
+//go:build unit
+// +build unit
+
+package file
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/open-horizon/anax/agreementbot/secrets"
+	"github.com/open-horizon/anax/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_FileSecrets(t *testing.T) {
+
+	dir := t.TempDir()
+	keyPath := filepath.Join(dir, "key")
+	assert.Nil(t, os.WriteFile(keyPath, []byte("0123456789abcdef0123456789abcdef"), 0600))
+
+	cfg := &config.HorizonConfig{
+		AgreementBot: config.AGConfig{
+			ExchangeId:    "myorg/agbot",
+			ExchangeToken: "token",
+			FileSecrets:   config.FileSecretsConfig{Path: filepath.Join(dir, "secrets", "secrets.enc"), KeyPath: keyPath},
+		},
+	}
+
+	fs := new(AgbotFileSecrets)
+	assert.Nil(t, fs.Initialize(cfg))
+	assert.False(t, fs.IsReady(), "The provider should not be ready before the secrets are loaded.")
+	assert.Nil(t, fs.Login())
+	assert.True(t, fs.IsReady())
+
+	// Create org, user and node secrets as the agbot.
+	id, tok := cfg.AgreementBot.ExchangeId, cfg.AgreementBot.ExchangeToken
+	assert.Nil(t, fs.CreateOrgSecret(id, tok, "myorg", "db/password", secrets.SecretDetails{Key: "user", Value: "pw1"}))
+	assert.Nil(t, fs.CreateOrgUserSecret(id, tok, "myorg", "user/alice/token", secrets.SecretDetails{Key: "alice", Value: "pw2"}))
+	assert.Nil(t, fs.CreateOrgNodeSecret(id, tok, "myorg", "node/node1/cert", secrets.SecretDetails{Key: "cert", Value: "pw3"}))
+
+	names, err := fs.ListOrgSecrets(id, tok, "myorg", "")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"db/password"}, names, "User and node secrets should not be listed with the org secrets.")
+
+	names, err = fs.ListAllSecrets(id, tok, "myorg", "")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"db/password", "node/node1/cert", "user/alice/token"}, names)
+
+	names, err = fs.ListOrgUserSecrets(id, tok, "myorg", "user/alice")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"token"}, names)
+
+	assert.Nil(t, fs.ListOrgNodeSecret(id, tok, "myorg", "node/node1/cert"))
+	assert.IsType(t, &secrets.NoSecretFound{}, fs.ListOrgNodeSecret(id, tok, "myorg", "node/node2/cert"))
+
+	// The secrets survive a restart of the agbot, and are encrypted in the file.
+	fs = new(AgbotFileSecrets)
+	assert.Nil(t, fs.Initialize(cfg))
+	assert.Nil(t, fs.Login())
+
+	details, err := fs.GetSecretDetails(id, tok, "myorg", "alice", "", "token")
+	assert.Nil(t, err)
+	assert.Equal(t, secrets.SecretDetails{Key: "alice", Value: "pw2"}, details)
+
+	md, err := fs.GetSecretMetadata("myorg", "", "node1", "cert")
+	assert.Nil(t, err)
+	assert.NotZero(t, md.CreationTime)
+	assert.Equal(t, md.CreationTime, md.UpdateTime)
+
+	content, err := os.ReadFile(cfg.AgreementBot.FileSecrets.Path)
+	assert.Nil(t, err)
+	assert.NotContains(t, string(content), "pw1", "The secrets file should be encrypted.")
+
+	// Delete a secret.
+	assert.Nil(t, fs.DeleteOrgSecret(id, tok, "myorg", "db/password"))
+	assert.IsType(t, &secrets.NoSecretFound{}, fs.DeleteOrgSecret(id, tok, "myorg", "db/password"))
+	_, err = fs.GetSecretDetails(id, tok, "myorg", "", "", "db/password")
+	assert.IsType(t, &secrets.NoSecretFound{}, err)
+
+	// The file cannot be read with another key.
+	assert.Nil(t, os.WriteFile(keyPath, []byte("fedcba9876543210fedcba9876543210"), 0600))
+	fs = new(AgbotFileSecrets)
+	assert.Nil(t, fs.Initialize(cfg))
+	assert.NotNil(t, fs.Login())
+	assert.False(t, fs.IsReady())
+}
+
+func Test_CanAccessSecret(t *testing.T) {
+
+	assert.True(t, secrets.CanAccessSecret("myorg", "alice", false, "myorg", "db/password", "GET"), "Users can read org secrets.")
+	assert.False(t, secrets.CanAccessSecret("myorg", "alice", false, "myorg", "db/password", "PUT"), "Only admins can write org secrets.")
+	assert.True(t, secrets.CanAccessSecret("myorg", "admin", true, "myorg", "db/password", "PUT"))
+	assert.False(t, secrets.CanAccessSecret("myorg", "alice", false, "myorg", "node/node1/cert", "DELETE"), "Only admins can write org node secrets.")
+	assert.True(t, secrets.CanAccessSecret("myorg", "alice", false, "myorg", "user/alice/node/node1/cert", "PUT"), "Users can write their own secrets.")
+	assert.False(t, secrets.CanAccessSecret("myorg", "alice", false, "myorg", "user/bob/token", "GET"), "Users cannot read the secrets of other users.")
+	assert.False(t, secrets.CanAccessSecret("myorg", "alice", false, "myorg", "user/alice2/token", "GET"))
+	assert.False(t, secrets.CanAccessSecret("otherorg", "admin", true, "myorg", "db/password", "GET"), "Admins of other orgs cannot access the secrets.")
+	assert.True(t, secrets.CanAccessSecret("root", "hubadmin", true, "myorg", "user/bob/token", "DELETE"))
+}
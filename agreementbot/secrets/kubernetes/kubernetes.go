@@ -0,0 +1,257 @@
+package kubernetes
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/open-horizon/anax/agreementbot/secrets"
+	"github.com/open-horizon/anax/config"
+	v1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubeclient "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// This function registers an uninitialized agbot secrets implementation with the secrets plugin registry. The plugin's Initialize
+// method is used to configure the object.
+func init() {
+	secrets.Register("kubernetes", new(AgbotKubernetesSecrets))
+}
+
+// Each secret is stored in a Kubernetes Secret in the configured namespace. Kubernetes object names cannot hold the org and path
+// of a secret, so the object is named with a hash of them, and the org and path are kept in annotations.
+const SECRET_NAME_PREFIX = "hzn-secret-"
+const LABEL_MANAGED_BY = "app.kubernetes.io/managed-by"
+const LABEL_MANAGED_BY_AGBOT = "open-horizon-agbot"
+const ANNOTATION_ORG = "openhorizon.org/secret-org"
+const ANNOTATION_PATH = "openhorizon.org/secret-path"
+const ANNOTATION_UPDATE_TIME = "openhorizon.org/secret-update-time"
+
+// The keys of the secret details in the data of a Kubernetes Secret.
+const DATA_KEY = "key"
+const DATA_VALUE = "value"
+
+// The namespace of the agbot's pod, when it runs in the cluster.
+const SERVICE_ACCOUNT_NAMESPACE_FILE = "/var/run/secrets/kubernetes.io/serviceaccount/namespace"
+
+// The Kubernetes secrets provider is meant for agbots that run in a cluster. The secrets are readable by anyone that can read
+// Secrets in the namespace, so the namespace should be dedicated to the agbot.
+type AgbotKubernetesSecrets struct {
+	secrets.StoreSecrets
+	client          kubeclient.Interface
+	namespace       string
+	lock            sync.RWMutex
+	ready           bool
+	lastInteraction uint64
+}
+
+func (ks *AgbotKubernetesSecrets) String() string {
+	return fmt.Sprintf("Namespace: %v", ks.namespace)
+}
+
+// This function is called by the anax main to allow the plugin a chance to initialize itself. The cluster is not contacted
+// until Login is called.
+func (ks *AgbotKubernetesSecrets) Initialize(cfg *config.HorizonConfig) error {
+
+	glog.V(1).Infof(kubePluginLogString("Initializing kubernetes as the secrets plugin."))
+
+	ks.InitializeStore(cfg, ks)
+
+	var restConfig *rest.Config
+	var err error
+	if cfg.AgreementBot.KubernetesSecrets.KubeConfigPath != "" {
+		restConfig, err = clientcmd.BuildConfigFromFlags("", cfg.AgreementBot.KubernetesSecrets.KubeConfigPath)
+	} else {
+		restConfig, err = rest.InClusterConfig()
+	}
+	if err != nil {
+		return errors.New(fmt.Sprintf("unable to get the kubernetes cluster config, error: %v", err))
+	}
+
+	client, err := kubeclient.NewForConfig(restConfig)
+	if err != nil {
+		return errors.New(fmt.Sprintf("unable to create the kubernetes client, error: %v", err))
+	}
+	ks.client = client
+
+	ks.namespace = cfg.AgreementBot.KubernetesSecrets.Namespace
+	if ks.namespace == "" {
+		if ns, err := os.ReadFile(SERVICE_ACCOUNT_NAMESPACE_FILE); err != nil {
+			return errors.New(fmt.Sprintf("AgreementBot.KubernetesSecrets.Namespace must be set when the agbot is not running in the cluster, error: %v", err))
+		} else {
+			ks.namespace = strings.TrimSpace(string(ns))
+		}
+	}
+
+	glog.V(1).Infof(kubePluginLogString(fmt.Sprintf("Initialized kubernetes as the secrets plugin, using namespace %v", ks.namespace)))
+	return nil
+}
+
+// Make sure the agbot can read the secrets in the namespace.
+func (ks *AgbotKubernetesSecrets) Login() error {
+
+	glog.V(3).Infof(kubePluginLogString(fmt.Sprintf("checking access to secrets in namespace %v", ks.namespace)))
+
+	if ks.client == nil {
+		return errors.New("the kubernetes secrets plugin was not initialized")
+	}
+
+	if _, err := ks.client.CoreV1().Secrets(ks.namespace).List(context.Background(), metav1.ListOptions{LabelSelector: managedBySelector(), Limit: 1}); err != nil {
+		return errors.New(fmt.Sprintf("agbot unable to list secrets in namespace %v, error: %v", ks.namespace, err))
+	}
+
+	ks.lock.Lock()
+	defer ks.lock.Unlock()
+	ks.ready = true
+	ks.lastInteraction = uint64(time.Now().Unix())
+
+	glog.V(3).Infof(kubePluginLogString("access to secrets verified."))
+	return nil
+}
+
+// There is no token to renew, the service account token is refreshed by the client. Check that the cluster is still reachable.
+func (ks *AgbotKubernetesSecrets) Renew() error {
+	return ks.Login()
+}
+
+func (ks *AgbotKubernetesSecrets) IsReady() bool {
+	ks.lock.RLock()
+	defer ks.lock.RUnlock()
+	return ks.ready
+}
+
+func (ks *AgbotKubernetesSecrets) Close() {
+	glog.V(2).Infof("Closed Kubernetes secrets implementation")
+}
+
+func (ks *AgbotKubernetesSecrets) GetLastStatus() uint64 {
+	ks.lock.RLock()
+	defer ks.lock.RUnlock()
+	return ks.lastInteraction
+}
+
+func (ks *AgbotKubernetesSecrets) Get(org string, path string) (*secrets.StoredSecret, error) {
+
+	ksecret, err := ks.client.CoreV1().Secrets(ks.namespace).Get(context.Background(), secretName(org, path), metav1.GetOptions{})
+	if k8serrors.IsNotFound(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, errors.New(fmt.Sprintf("unable to get secret %v in org %v, error: %v", path, org, err))
+	}
+	ks.touch()
+
+	return &secrets.StoredSecret{
+		Details: secrets.SecretDetails{
+			Key:   string(ksecret.Data[DATA_KEY]),
+			Value: string(ksecret.Data[DATA_VALUE]),
+		},
+		CreationTime: ksecret.CreationTimestamp.Unix(),
+		UpdateTime:   updateTime(ksecret),
+	}, nil
+}
+
+func (ks *AgbotKubernetesSecrets) List(org string) ([]string, error) {
+
+	ksecrets, err := ks.client.CoreV1().Secrets(ks.namespace).List(context.Background(), metav1.ListOptions{LabelSelector: managedBySelector()})
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("unable to list secrets in org %v, error: %v", org, err))
+	}
+	ks.touch()
+
+	paths := make([]string, 0)
+	for _, ksecret := range ksecrets.Items {
+		if ksecret.Annotations[ANNOTATION_ORG] == org {
+			paths = append(paths, ksecret.Annotations[ANNOTATION_PATH])
+		}
+	}
+	return paths, nil
+}
+
+func (ks *AgbotKubernetesSecrets) Put(org string, path string, details secrets.SecretDetails) error {
+
+	name := secretName(org, path)
+	now := strconv.FormatInt(time.Now().Unix(), 10)
+	data := map[string][]byte{DATA_KEY: []byte(details.Key), DATA_VALUE: []byte(details.Value)}
+
+	ksecret, err := ks.client.CoreV1().Secrets(ks.namespace).Get(context.Background(), name, metav1.GetOptions{})
+	if k8serrors.IsNotFound(err) {
+		ksecret = &v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        name,
+				Namespace:   ks.namespace,
+				Labels:      map[string]string{LABEL_MANAGED_BY: LABEL_MANAGED_BY_AGBOT},
+				Annotations: map[string]string{ANNOTATION_ORG: org, ANNOTATION_PATH: path, ANNOTATION_UPDATE_TIME: now},
+			},
+			Type: v1.SecretTypeOpaque,
+			Data: data,
+		}
+		if _, err := ks.client.CoreV1().Secrets(ks.namespace).Create(context.Background(), ksecret, metav1.CreateOptions{}); err != nil {
+			return errors.New(fmt.Sprintf("unable to create secret %v in org %v, error: %v", path, org, err))
+		}
+	} else if err != nil {
+		return errors.New(fmt.Sprintf("unable to get secret %v in org %v, error: %v", path, org, err))
+	} else {
+		if ksecret.Annotations == nil {
+			ksecret.Annotations = make(map[string]string)
+		}
+		ksecret.Annotations[ANNOTATION_UPDATE_TIME] = now
+		ksecret.Data = data
+		if _, err := ks.client.CoreV1().Secrets(ks.namespace).Update(context.Background(), ksecret, metav1.UpdateOptions{}); err != nil {
+			return errors.New(fmt.Sprintf("unable to update secret %v in org %v, error: %v", path, org, err))
+		}
+	}
+	ks.touch()
+	return nil
+}
+
+func (ks *AgbotKubernetesSecrets) Delete(org string, path string) (bool, error) {
+
+	err := ks.client.CoreV1().Secrets(ks.namespace).Delete(context.Background(), secretName(org, path), metav1.DeleteOptions{})
+	if k8serrors.IsNotFound(err) {
+		return false, nil
+	} else if err != nil {
+		return false, errors.New(fmt.Sprintf("unable to delete secret %v in org %v, error: %v", path, org, err))
+	}
+	ks.touch()
+	return true, nil
+}
+
+// Record a successful interaction with the cluster.
+func (ks *AgbotKubernetesSecrets) touch() {
+	ks.lock.Lock()
+	defer ks.lock.Unlock()
+	ks.lastInteraction = uint64(time.Now().Unix())
+}
+
+// Return the name of the Kubernetes Secret that holds the secret at a path in an org.
+func secretName(org string, path string) string {
+	hash := sha256.Sum256([]byte(org + "/" + path))
+	return SECRET_NAME_PREFIX + hex.EncodeToString(hash[:])[:40]
+}
+
+func managedBySelector() string {
+	return fmt.Sprintf("%v=%v", LABEL_MANAGED_BY, LABEL_MANAGED_BY_AGBOT)
+}
+
+// Return the time the secret was last changed, which is the creation time if it has never been updated.
+func updateTime(ksecret *v1.Secret) int64 {
+	if t, err := strconv.ParseInt(ksecret.Annotations[ANNOTATION_UPDATE_TIME], 10, 64); err == nil {
+		return t
+	}
+	return ksecret.CreationTimestamp.Unix()
+}
+
+var kubePluginLogString = func(v interface{}) string {
+	return fmt.Sprintf("Kubernetes Secrets Plugin: %v", v)
+}
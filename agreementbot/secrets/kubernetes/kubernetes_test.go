@@ -0,0 +1,63 @@
+//go:build unit
+// +build unit
+
+package kubernetes
+
+import (
+	"context"
+	"testing"
+
+	"github.com/open-horizon/anax/agreementbot/secrets"
+	"github.com/open-horizon/anax/config"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func Test_KubernetesSecrets(t *testing.T) {
+
+	cfg := &config.HorizonConfig{
+		AgreementBot: config.AGConfig{
+			ExchangeId:    "myorg/agbot",
+			ExchangeToken: "token",
+		},
+	}
+
+	ks := &AgbotKubernetesSecrets{client: fake.NewSimpleClientset(), namespace: "agbot"}
+	ks.InitializeStore(cfg, ks)
+	assert.Nil(t, ks.Login())
+	assert.True(t, ks.IsReady())
+
+	id, tok := cfg.AgreementBot.ExchangeId, cfg.AgreementBot.ExchangeToken
+	assert.Nil(t, ks.CreateUserNodeSecret(id, tok, "myorg", "user/alice/node/node1/cert", secrets.SecretDetails{Key: "cert", Value: "v1"}))
+	assert.Nil(t, ks.CreateOrgSecret(id, tok, "otherorg", "cert", secrets.SecretDetails{Key: "cert", Value: "v2"}))
+
+	// The secret is stored in a labelled Kubernetes Secret in the namespace.
+	ksecret, err := ks.client.CoreV1().Secrets("agbot").Get(context.Background(), secretName("myorg", "user/alice/node/node1/cert"), metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, LABEL_MANAGED_BY_AGBOT, ksecret.Labels[LABEL_MANAGED_BY])
+	assert.Equal(t, "v1", string(ksecret.Data[DATA_VALUE]))
+
+	// Update the secret.
+	assert.Nil(t, ks.CreateUserNodeSecret(id, tok, "myorg", "user/alice/node/node1/cert", secrets.SecretDetails{Key: "cert", Value: "v3"}))
+	details, err := ks.GetSecretDetails(id, tok, "myorg", "alice", "node1", "cert")
+	assert.Nil(t, err)
+	assert.Equal(t, "v3", details.Value)
+
+	_, err = ks.GetSecretMetadata("myorg", "alice", "node1", "cert")
+	assert.Nil(t, err)
+
+	// Only the secrets of the org are listed.
+	names, err := ks.ListUserNodeSecrets(id, tok, "myorg", "node1", "user/alice/node/node1")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"cert"}, names)
+
+	names, err = ks.ListAllSecrets(id, tok, "myorg", "")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"user/alice/node/node1/cert"}, names)
+
+	assert.Nil(t, ks.DeleteUserNodeSecret(id, tok, "myorg", "user/alice/node/node1/cert"))
+	assert.IsType(t, &secrets.NoSecretFound{}, ks.DeleteUserNodeSecret(id, tok, "myorg", "user/alice/node/node1/cert"))
+	_, err = ks.GetSecretMetadata("myorg", "alice", "node1", "cert")
+	assert.IsType(t, &secrets.NoSecretFound{}, err)
+}
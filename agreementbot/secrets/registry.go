@@ -20,15 +20,19 @@ func Register(name string, as AgbotSecrets) {
 	SecretsProviders[name] = as
 }
 
-// Initialize the underlying Agbot Secrets implementation depending on what is configured. If vault is configured, it is used.
-// If nothing is configured, an error is returned.
+// Initialize the underlying Agbot Secrets implementation depending on what is configured. The provider selected in the config
+// is used, or vault if no provider is selected and vault is configured. If nothing is configured, an error is returned.
 func InitSecrets(cfg *config.HorizonConfig) (AgbotSecrets, error) {
 
-	if cfg.IsVaultConfigured() {
-		secretsObj := SecretsProviders["vault"]
-		return secretsObj, secretsObj.Initialize(cfg)
+	name := cfg.GetSecretsProvider()
+	if name == "" {
+		return nil, errors.New(fmt.Sprintf("No secrets provider is configured."))
+	}
 
+	secretsObj, ok := SecretsProviders[name]
+	if !ok {
+		return nil, errors.New(fmt.Sprintf("Secrets provider %v is not available.", name))
 	}
-	return nil, errors.New(fmt.Sprintf("Vault is not configured correctly."))
+	return secretsObj, secretsObj.Initialize(cfg)
 
 }
@@ -17,7 +17,8 @@ type AgbotSecrets interface {
 	Renew() error
 	Close()
 	IsReady() bool
-	GetLastVaultStatus() uint64
+	// The time of the last successful interaction with the secrets manager, in seconds since the epoch.
+	GetLastStatus() uint64
 
 	ListAllSecrets(user, token, org, path string) ([]string, error)
 
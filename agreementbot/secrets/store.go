@@ -0,0 +1,353 @@
+package secrets
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/golang/glog"
+	"github.com/open-horizon/anax/cli/cliutils"
+	"github.com/open-horizon/anax/config"
+	"github.com/open-horizon/anax/cutil"
+	"github.com/open-horizon/anax/exchange"
+)
+
+// Secrets providers other than the vault keep the secrets in a simple store and have no access control of their own. The
+// StoreSecrets object implements the secret management functions of the AgbotSecrets interface on top of such a store, so
+// that the providers only have to implement the store and the lifecycle functions. Secrets are stored by org and by a path
+// within the org that is named the same way as in the vault; user/<user>/<name> for user secrets, node/<node>/<name> for
+// node secrets and user/<user>/node/<node>/<name> for user node secrets.
+//
+// The exchange is used to decide who can access a secret, following the same rules as the vault plugin. The agbot can
+// access all secrets. Users can read the org secrets and the org node secrets of their org, and can read and write their
+// own user secrets. Org admins can read and write all secrets in their org and hub admins can access all secrets.
+
+// A secret and its metadata, as saved in a store.
+type StoredSecret struct {
+	Details      SecretDetails `json:"details"`
+	CreationTime int64         `json:"creationTime"`
+	UpdateTime   int64         `json:"updateTime"`
+}
+
+// The storage behind a secrets provider.
+type SecretStore interface {
+	Get(org string, path string) (*StoredSecret, error) // Returns nil if the secret does not exist
+	List(org string) ([]string, error)                  // Returns the paths of all the secrets in the org
+	Put(org string, path string, details SecretDetails) error
+	Delete(org string, path string) (bool, error) // Returns false if the secret did not exist
+}
+
+type StoreSecrets struct {
+	Store      SecretStore
+	cfg        *config.HorizonConfig
+	httpClient *http.Client
+}
+
+func (ss *StoreSecrets) InitializeStore(cfg *config.HorizonConfig, store SecretStore) {
+	ss.Store = store
+	ss.cfg = cfg
+	if cfg.Collaborators.HTTPClientFactory != nil {
+		ss.httpClient = cfg.Collaborators.HTTPClientFactory.NewHTTPClient(nil)
+	}
+}
+
+// Return the path of a secret within its org.
+func SecretPath(secretUser string, secretNode string, secretName string) string {
+	if secretUser != "" && secretNode != "" {
+		return fmt.Sprintf("user/%s/node/%s/%s", secretUser, secretNode, secretName)
+	} else if secretUser != "" {
+		return fmt.Sprintf("user/%s/%s", secretUser, secretName)
+	} else if secretNode != "" {
+		return fmt.Sprintf("node/%s/%s", secretNode, secretName)
+	}
+	return secretName
+}
+
+// Select the secrets below a path from the paths of all the secrets in an org, in the order they are listed by the vault.
+// When the path is empty, the user and node secrets are only included if allSecrets is true.
+func SecretsUnderPath(paths []string, path string, allSecrets bool) []string {
+	res := make([]string, 0)
+	for _, p := range paths {
+		if path == "" {
+			if allSecrets || (!strings.HasPrefix(p, "user/") && !strings.HasPrefix(p, "node/")) {
+				res = append(res, p)
+			}
+		} else if strings.HasPrefix(p, path+"/") {
+			res = append(res, p)
+		}
+	}
+	sort.Strings(res)
+	return res
+}
+
+func (ss *StoreSecrets) ListOrgSecret(user, token, org, path string) error {
+	return ss.listSecret(user, token, org, path)
+}
+
+func (ss *StoreSecrets) ListOrgUserSecret(user, token, org, path string) error {
+	return ss.listSecret(user, token, org, path)
+}
+
+func (ss *StoreSecrets) ListOrgNodeSecret(user, token, org, path string) error {
+	return ss.listSecret(user, token, org, path)
+}
+
+func (ss *StoreSecrets) ListUserNodeSecret(user, token, org, path string) error {
+	return ss.listSecret(user, token, org, path)
+}
+
+// Check that the secret at a path exists.
+func (ss *StoreSecrets) listSecret(user, token, org, path string) error {
+	glog.V(3).Infof(storeLogString(fmt.Sprintf("list secret %v in org %v as user %v", path, org, user)))
+
+	if _, err := ss.authorize(user, token, org, path, http.MethodGet); err != nil {
+		return err
+	}
+
+	if s, err := ss.Store.Get(org, path); err != nil {
+		return &SecretsProviderUnavailable{ProviderError: err}
+	} else if s == nil {
+		return &NoSecretFound{SecretPath: secretLocation(org, path)}
+	}
+	return nil
+}
+
+func (ss *StoreSecrets) ListAllSecrets(user, token, org, path string) ([]string, error) {
+	return ss.listSecrets(user, token, org, path, true)
+}
+
+func (ss *StoreSecrets) ListOrgSecrets(user, token, org, path string) ([]string, error) {
+	return ss.listSecrets(user, token, org, path, false)
+}
+
+func (ss *StoreSecrets) ListOrgUserSecrets(user, token, org, path string) ([]string, error) {
+	return ss.listTrimmedSecrets(user, token, org, path)
+}
+
+func (ss *StoreSecrets) ListOrgNodeSecrets(user, token, org, node, path string) ([]string, error) {
+	return ss.listTrimmedSecrets(user, token, org, path)
+}
+
+func (ss *StoreSecrets) ListUserNodeSecrets(user, token, org, node, path string) ([]string, error) {
+	return ss.listTrimmedSecrets(user, token, org, path)
+}
+
+// List the secrets below a path, with the path removed from the names.
+func (ss *StoreSecrets) listTrimmedSecrets(user, token, org, path string) ([]string, error) {
+	names, err := ss.listSecrets(user, token, org, path, false)
+	if err != nil {
+		return nil, err
+	}
+
+	secretList := make([]string, 0, len(names))
+	for _, name := range names {
+		secretList = append(secretList, strings.TrimPrefix(name, path+"/"))
+	}
+	return secretList, nil
+}
+
+// List the secrets below a path. The names include the path.
+func (ss *StoreSecrets) listSecrets(user, token, org, path string, allSecrets bool) ([]string, error) {
+	glog.V(3).Infof(storeLogString(fmt.Sprintf("list secrets at %v in org %v as user %v", path, org, user)))
+
+	if _, err := ss.authorize(user, token, org, path, "LIST"); err != nil {
+		return nil, err
+	}
+
+	paths, err := ss.Store.List(org)
+	if err != nil {
+		return nil, &SecretsProviderUnavailable{ProviderError: err}
+	}
+	return SecretsUnderPath(paths, path, allSecrets), nil
+}
+
+func (ss *StoreSecrets) CreateOrgSecret(user, token, org, path string, data SecretDetails) error {
+	return ss.createSecret(user, token, org, path, data)
+}
+
+func (ss *StoreSecrets) CreateOrgUserSecret(user, token, org, path string, data SecretDetails) error {
+	return ss.createSecret(user, token, org, path, data)
+}
+
+func (ss *StoreSecrets) CreateOrgNodeSecret(user, token, org, path string, data SecretDetails) error {
+	return ss.createSecret(user, token, org, path, data)
+}
+
+func (ss *StoreSecrets) CreateUserNodeSecret(user, token, org, path string, data SecretDetails) error {
+	return ss.createSecret(user, token, org, path, data)
+}
+
+// Create or update the secret at a path.
+func (ss *StoreSecrets) createSecret(user, token, org, path string, data SecretDetails) error {
+	glog.V(3).Infof(storeLogString(fmt.Sprintf("create secret %v in org %v as user %v", path, org, user)))
+
+	if _, err := ss.authorize(user, token, org, path, http.MethodPut); err != nil {
+		return err
+	}
+
+	if err := ss.Store.Put(org, path, data); err != nil {
+		return &SecretsProviderUnavailable{ProviderError: err}
+	}
+	return nil
+}
+
+func (ss *StoreSecrets) DeleteOrgSecret(user, token, org, path string) error {
+	return ss.deleteSecret(user, token, org, path)
+}
+
+func (ss *StoreSecrets) DeleteOrgUserSecret(user, token, org, path string) error {
+	return ss.deleteSecret(user, token, org, path)
+}
+
+func (ss *StoreSecrets) DeleteOrgNodeSecret(user, token, org, path string) error {
+	return ss.deleteSecret(user, token, org, path)
+}
+
+func (ss *StoreSecrets) DeleteUserNodeSecret(user, token, org, path string) error {
+	return ss.deleteSecret(user, token, org, path)
+}
+
+// Delete the secret at a path.
+func (ss *StoreSecrets) deleteSecret(user, token, org, path string) error {
+	glog.V(3).Infof(storeLogString(fmt.Sprintf("delete secret %v in org %v as user %v", path, org, user)))
+
+	if _, err := ss.authorize(user, token, org, path, http.MethodDelete); err != nil {
+		return err
+	}
+
+	if found, err := ss.Store.Delete(org, path); err != nil {
+		return &SecretsProviderUnavailable{ProviderError: err}
+	} else if !found {
+		return &NoSecretFound{SecretPath: secretLocation(org, path)}
+	}
+	return nil
+}
+
+func (ss *StoreSecrets) GetSecretDetails(user, token, org, secretUser, secretNode, secretName string) (SecretDetails, error) {
+	glog.V(3).Infof(storeLogString(fmt.Sprintf("extract secret details for %s in org %s as user %s", secretName, org, secretUser)))
+
+	if err := checkSecretInput(org, secretName); err != nil {
+		return SecretDetails{}, err
+	}
+
+	path := SecretPath(secretUser, secretNode, secretName)
+	if _, err := ss.authorize(user, token, org, path, http.MethodGet); err != nil {
+		return SecretDetails{}, err
+	}
+
+	if s, err := ss.Store.Get(org, path); err != nil {
+		return SecretDetails{}, &SecretsProviderUnavailable{ProviderError: err}
+	} else if s == nil {
+		return SecretDetails{}, &NoSecretFound{SecretPath: secretLocation(org, path)}
+	} else {
+		return s.Details, nil
+	}
+}
+
+// Retrieve the metadata for a secret. This is only called by the agbot itself, so there is no access check.
+func (ss *StoreSecrets) GetSecretMetadata(secretOrg, secretUser, secretNode, secretName string) (SecretMetadata, error) {
+	glog.V(3).Infof(storeLogString(fmt.Sprintf("extract secret metadata for %s in org %s as user %s", secretName, secretOrg, secretUser)))
+
+	if err := checkSecretInput(secretOrg, secretName); err != nil {
+		return SecretMetadata{}, err
+	}
+
+	path := SecretPath(secretUser, secretNode, secretName)
+	if s, err := ss.Store.Get(secretOrg, path); err != nil {
+		return SecretMetadata{}, &SecretsProviderUnavailable{ProviderError: err}
+	} else if s == nil {
+		return SecretMetadata{}, &NoSecretFound{SecretPath: secretLocation(secretOrg, path)}
+	} else {
+		return SecretMetadata{CreationTime: s.CreationTime, UpdateTime: s.UpdateTime}, nil
+	}
+}
+
+func checkSecretInput(org string, secretName string) error {
+	if org == "" {
+		return &BadRequest{Response: map[string][]string{"errors": {"Organization name must not be an empty string"}},
+			ResponseCode: http.StatusBadRequest,
+			HttpMethod:   http.MethodGet}
+	} else if secretName == "" {
+		return &BadRequest{Response: map[string][]string{"errors": {"Secret name must not be an empty string"}},
+			ResponseCode: http.StatusBadRequest,
+			HttpMethod:   http.MethodGet}
+	}
+	return nil
+}
+
+func secretLocation(org string, path string) string {
+	return org + cliutils.AddSlash(path)
+}
+
+// Verify the user's credentials with the exchange and check that the user is allowed to access the secret at a path in
+// the org. The method is the http method of the secure API that is accessing the secret. Returns the exchange user.
+func (ss *StoreSecrets) authorize(user, token, org, path, method string) (string, error) {
+
+	// The agbot has access to all the secrets.
+	if user == ss.cfg.AgreementBot.ExchangeId && token == ss.cfg.AgreementBot.ExchangeToken {
+		return user, nil
+	}
+
+	userOrg, userId := cutil.SplitOrgSpecUrl(user)
+	admin, err := ss.isAdmin(user, token, userOrg, userId)
+	if err != nil {
+		return "", err
+	}
+
+	if !CanAccessSecret(userOrg, userId, admin, org, path, method) {
+		return "", &PermissionDenied{HttpMethod: method, SecretPath: secretLocation(org, path), ExchangeUser: user}
+	}
+	return user, nil
+}
+
+// Return true if the user is allowed to access the secret at a path in the org.
+func CanAccessSecret(userOrg, userId string, admin bool, org, path, method string) bool {
+
+	// Hub admins are admins in the root org, they can access all the secrets.
+	if userOrg == "root" && admin {
+		return true
+	} else if userOrg != org {
+		return false
+	} else if admin {
+		return true
+	}
+
+	// Users own the secrets below user/<user>, and cannot see the secrets of other users.
+	if strings.HasPrefix(path, "user/") {
+		return path == "user/"+userId || strings.HasPrefix(path, "user/"+userId+"/")
+	}
+
+	// The org secrets and org node secrets can be read by all the users in the org.
+	return method == http.MethodGet || method == "LIST"
+}
+
+// Authenticate the user with the exchange and return whether the user is an admin.
+func (ss *StoreSecrets) isAdmin(user, token, userOrg, userId string) (bool, error) {
+
+	if userOrg == "" || userId == "" {
+		return false, &Unauthenticated{LoginError: errors.New("the user must be org qualified"), ExchangeUser: user}
+	} else if ss.httpClient == nil {
+		return false, &Unauthenticated{LoginError: errors.New("the exchange is not configured"), ExchangeUser: user}
+	}
+
+	targetURL := fmt.Sprintf("%sorgs/%s/users/%s", ss.cfg.AgreementBot.ExchangeURL, userOrg, userId)
+	var resp interface{}
+	resp = new(exchange.GetUsersResponse)
+	if err, tpErr := exchange.InvokeExchange(ss.httpClient, http.MethodGet, targetURL, user, token, nil, &resp); err != nil {
+		return false, &Unauthenticated{LoginError: err, ExchangeUser: user}
+	} else if tpErr != nil {
+		return false, &SecretsProviderUnavailable{ProviderError: tpErr}
+	}
+
+	users := resp.(*exchange.GetUsersResponse).Users
+	if u, ok := users[user]; ok {
+		return u.Admin, nil
+	}
+	return false, &Unauthenticated{LoginError: errors.New(fmt.Sprintf("user %v not found in the exchange", user)), ExchangeUser: user}
+}
+
+var storeLogString = func(v interface{}) string {
+	return fmt.Sprintf("Secret Store: %v", v)
+}
@@ -130,6 +130,6 @@ func (vs *AgbotVaultSecrets) Close() {
 	glog.V(2).Infof("Closed Vault secrets implementation")
 }
 
-func (vs *AgbotVaultSecrets) GetLastVaultStatus() uint64 {
+func (vs *AgbotVaultSecrets) GetLastStatus() uint64 {
 	return vs.lastVaultInteraction
 }
@@ -38,6 +38,7 @@ import (
 	"github.com/open-horizon/anax/exchangecommon"
 	"github.com/open-horizon/anax/externalpolicy"
 	"github.com/open-horizon/anax/i18n"
+	"github.com/open-horizon/anax/policy"
 	"github.com/open-horizon/anax/worker"
 	"golang.org/x/text/message"
 )
@@ -190,6 +191,7 @@ func (a *SecureAPI) listen() {
 		router.HandleFunc("/deploycheck/userinputcompatible", a.userinput_compatible).Methods("GET", "OPTIONS")
 		router.HandleFunc("/deploycheck/deploycompatible", a.deploy_compatible).Methods("GET", "OPTIONS")
 		router.HandleFunc("/deploycheck/secretbindingcompatible", a.secretbinding_compatible).Methods("GET", "OPTIONS")
+		router.HandleFunc("/deploycheck/dryrun", a.deployment_dryrun).Methods("POST", "OPTIONS")
 		router.HandleFunc("/compatibility/constraints/node/{policyType}", a.policyCompatibleNodeList).Methods("GET", "OPTIONS")
 		router.HandleFunc("/compatibility/patterns/node", a.patternCompatibleNodeList).Methods("GET", "OPTIONS")
 		router.HandleFunc("/org/{org}/secrets/user/{user}", a.userSecrets).Methods("LIST", "OPTIONS")
@@ -426,6 +428,11 @@ func (a *SecureAPI) policy_compatible(w http.ResponseWriter, r *http.Request) {
 	//    type: boolean
 	//    required: false
 	//    description: "Show the input which was used to come up with the result."
+	//  - name: explain
+	//    in: query
+	//    type: boolean
+	//    required: false
+	//    description: "Include the evaluation trace of the deployment and node policy constraints, showing the result of each sub-expression and the property values it was evaluated against."
 	//  - name: payload
 	//    in: body
 	//    schema:
@@ -464,8 +471,11 @@ func (a *SecureAPI) policy_compatible(w http.ResponseWriter, r *http.Request) {
 				// if checkAll is set, then check all the services defined in the deployment policy for compatibility.
 				checkAll := r.URL.Query().Get("checkAll")
 
+				// if explain is set, then include the constraint evaluation traces in the output.
+				explain := r.URL.Query().Get("explain")
+
 				// do policy compatibility check
-				output, err := compcheck.PolicyCompatible(user_ec, input, (checkAll != ""), msgPrinter)
+				output, err := compcheck.PolicyCompatible(user_ec, input, (checkAll != ""), (explain != ""), msgPrinter)
 
 				// nil out the policies in the output if 'long' is not set in the request
 				long := r.URL.Query().Get("long")
@@ -771,6 +781,108 @@ func (a *SecureAPI) deploy_compatible(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+func (a *SecureAPI) deployment_dryrun(w http.ResponseWriter, r *http.Request) {
+
+	switch r.Method {
+	// swagger:operation POST /deploycheck/dryrun deployment_dryrun
+	//
+	// Simulate the deployment of a deployment policy.
+	//
+	// This API runs the agbot's agreement initiation logic for the given deployment policy against the current state of the exchange and the agbot. For each node it reports whether the agbot would propose an agreement and which service version would be chosen, or why it would not. No proposals are sent and no agbot state is changed.
+	//
+	// ---
+	// consumes:
+	//  - application/json
+	// produces:
+	//  - application/json
+	// parameters:
+	//  - name: payload
+	//    in: body
+	//    schema:
+	//     "$ref": "#/definitions/DryRunCheck"
+	//    required: true
+	//    description: "The dryRunCheck object as payload."
+	// responses:
+	//  '200':
+	//    description: "Ok"
+	//    schema:
+	//     "$ref": "#/definitions/DryRunOutput"
+	//  '400':
+	//    description: "Failure - No input found"
+	//    schema:
+	//     type: string
+	//  '401':
+	//    description: "Failure - Failed to authenticate"
+	//    schema:
+	//     type: string
+	//  '500':
+	//    description: "Failure - Error"
+	//    schema:
+	//      type: string
+	case "POST":
+		glog.V(5).Infof(APIlogString(fmt.Sprintf("/deploycheck/dryrun called.")))
+
+		if user_ec, _, msgPrinter, ok := a.processExchangeCred("/deploycheck/dryrun", UserTypeCred, w, r); ok {
+			body, _ := io.ReadAll(r.Body)
+			if len(body) == 0 {
+				glog.Errorf(APIlogString(fmt.Sprintf("No input found.")))
+				writeResponse(w, msgPrinter.Sprintf("No input found."), http.StatusBadRequest)
+			} else if input, err := a.decodeDryRunCheckBody(body, msgPrinter); err != nil {
+				writeResponse(w, err.Error(), http.StatusBadRequest)
+			} else {
+				getCommitted := func(nodeId string) (*externalpolicy.NodeResources, error) {
+					return committedNodeResources(a.db, nodeId, exchange.GetHTTPNodeFullStatusHandler(user_ec), exchange.GetHTTPServiceDefResolverHandler(user_ec), msgPrinter)
+				}
+				output, err := compcheck.DeploymentDryRun(user_ec, "", input, a.dryRunNodeState, getCommitted, msgPrinter)
+				a.writeCompCheckResponse(w, output, err, msgPrinter)
+			}
+		}
+
+	case "OPTIONS":
+		w.Header().Set("Allow", "POST, OPTIONS")
+		w.WriteHeader(http.StatusOK)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// Returns the agreement, service rollout and workload usage state this agbot has for the node and deployment policy. It
+// is only read, the dry run never changes it.
+func (a *SecureAPI) dryRunNodeState(nodeId string, policyName string) (*compcheck.DryRunNodeState, error) {
+
+	state := compcheck.DryRunNodeState{}
+	if r, err := a.db.FindSingleServiceRollout(policyName); err != nil {
+		return nil, fmt.Errorf("unable to read the service rollout of policy %v, error: %v", policyName, err)
+	} else if r != nil && r.IsActive() {
+		if wave, n := r.FindNode(nodeId); n != nil && (n.State == persistence.ROLLOUT_NODE_PENDING || n.State == persistence.ROLLOUT_NODE_DEFERRED) {
+			state.Rollout = &compcheck.DryRunRollout{Version: r.Version, State: r.State, NodeState: n.State, Wave: wave, CurrentWave: r.Wave}
+		}
+	}
+
+	for _, agp := range policy.AllAgreementProtocols() {
+		if ags, err := a.db.FindAgreements([]persistence.AFilter{persistence.UnarchivedAFilter(), persistence.DevPolAFilter(nodeId, policyName)}, agp); err != nil {
+			return nil, fmt.Errorf("unable to read agreements for node %v with policy %v, error: %v", nodeId, policyName, err)
+		} else {
+			for _, ag := range ags {
+				if ag.AgreementTimedout == 0 {
+					state.AgreementId = ag.CurrentAgreementId
+					return &state, nil
+				}
+			}
+		}
+	}
+
+	if wlUsage, err := a.db.FindSingleWorkloadUsageByDeviceAndPolicyName(nodeId, policyName); err != nil {
+		return nil, fmt.Errorf("unable to read workload usage for node %v with policy %v, error: %v", nodeId, policyName, err)
+	} else if wlUsage != nil {
+		state.Priority = wlUsage.Priority
+		state.RetryCount = wlUsage.RetryCount
+		state.FirstTryTime = wlUsage.FirstTryTime
+		state.DisableRetry = wlUsage.DisableRetry
+	}
+	return &state, nil
+}
+
 // This function checks user cred and writes corrsponding response. It also creates a message printer with given language from the http request.
 func (a *SecureAPI) processExchangeCred(resource string, authType string, w http.ResponseWriter, r *http.Request) (exchange.ExchangeContext, string, *message.Printer, bool) {
 	// get message printer with the language passed in from the header
@@ -901,6 +1013,17 @@ func (a *SecureAPI) decodeCompCheckBody(body []byte, msgPrinter *message.Printer
 	}
 }
 
+func (a *SecureAPI) decodeDryRunCheckBody(body []byte, msgPrinter *message.Printer) (*compcheck.DryRunCheck, error) {
+
+	var input compcheck.DryRunCheck
+	if err := json.Unmarshal(body, &input); err != nil {
+		glog.Errorf(APIlogString(fmt.Sprintf("Input body couldn't be deserialized to DryRunCheck object. %v", err)))
+		return nil, fmt.Errorf("%s", msgPrinter.Sprintf("Input body couldn't be deserialized to DryRunCheck object. %v", err))
+	}
+	// verification of the input is done in the compcheck component.
+	return &input, nil
+}
+
 // This function verifies the given exchange user name and password.
 // The user must be in the format of orgId/userId.
 func (a *SecureAPI) authenticateWithExchange(authHeaderTokenValue string, user string, userPasswd string, authType string, msgPrinter *message.Printer) (exchange.ExchangeContext, string, error) {
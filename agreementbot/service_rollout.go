@@ -0,0 +1,445 @@
+package agreementbot
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/open-horizon/anax/agreementbot/persistence"
+	"github.com/open-horizon/anax/policy"
+)
+
+// The actions that can be taken on a service version rollout through the API.
+const (
+	ROLLOUT_ACTION_PAUSE  = "pause"
+	ROLLOUT_ACTION_RESUME = "resume"
+	ROLLOUT_ACTION_ABORT  = "abort"
+)
+
+// The service rollouts are changed by the protocol handlers when a policy changes, by the governance when the waves
+// move forward and by the API when a user pauses, resumes or aborts a rollout. A rollout only covers the agreements in
+// the database partition of this agbot, other agbots that serve the same policy run their own rollouts, they are not
+// coordinated with this one.
+var serviceRolloutLock sync.Mutex
+
+// The health of a node in the current wave of a service rollout.
+type rolloutNodeHealth int
+
+const (
+	rolloutNodeWaiting rolloutNodeHealth = iota // the node does not have a healthy agreement for the new version yet
+	rolloutNodeHealthy
+	rolloutNodeFailed
+	rolloutNodeDeferred // the upgrade of the node is waiting for its maintenance window
+)
+
+// Returns the version and the priority value of the highest priority workload in the policy, which is the version that
+// a rollout moves the nodes to.
+func rolloutVersion(pol *policy.Policy) (string, int) {
+	version, priority := "", 0
+	for _, wl := range pol.Workloads {
+		if version == "" || (wl.Priority.PriorityValue != 0 && (priority == 0 || wl.Priority.PriorityValue < priority)) {
+			version = wl.Version
+			priority = wl.Priority.PriorityValue
+		}
+	}
+	return version, priority
+}
+
+// Create a service rollout for the nodes that have an agreement for a previous version of the policy. The nodes are
+// sorted so that the waves are the same on every agbot restart.
+func NewServiceRollout(pol *policy.Policy, nodes []persistence.ServiceRolloutNode, now uint64) *persistence.ServiceRollout {
+	version, priority := rolloutVersion(pol)
+	r := &persistence.ServiceRollout{
+		PolicyName:     pol.Header.Name,
+		Version:        version,
+		Priority:       priority,
+		Rollout:        *pol.Rollout,
+		State:          persistence.ROLLOUT_STATE_IN_PROGRESS,
+		StartTime:      now,
+		LastUpdateTime: now,
+	}
+
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].DeviceId < nodes[j].DeviceId })
+	r.Waves = planServiceRolloutWaves(nodes, r.Rollout.WaveSize(len(nodes)))
+	return r
+}
+
+// Split the nodes into waves of the given size.
+func planServiceRolloutWaves(nodes []persistence.ServiceRolloutNode, size int) [][]persistence.ServiceRolloutNode {
+	waves := make([][]persistence.ServiceRolloutNode, 0)
+	for start := 0; start < len(nodes); start += size {
+		end := start + size
+		if end > len(nodes) {
+			end = len(nodes)
+		}
+		wave := make([]persistence.ServiceRolloutNode, end-start)
+		copy(wave, nodes[start:end])
+		waves = append(waves, wave)
+	}
+	return waves
+}
+
+// Add nodes to a rollout that has already been planned. A node that is not in the rollout yet goes into the last wave,
+// or into a new wave if the last wave is full or has already started. Returns true if a node was added.
+func mergeServiceRolloutNodes(r *persistence.ServiceRollout, nodes []persistence.ServiceRolloutNode) bool {
+	added := false
+	for _, n := range nodes {
+		if ix, _ := r.FindNode(n.DeviceId); ix >= 0 {
+			continue
+		}
+
+		total := 1
+		for _, wave := range r.Waves {
+			total += len(wave)
+		}
+
+		last := len(r.Waves) - 1
+		if last < 0 || last < r.Wave || (last == r.Wave && r.WaveStartTime != 0) || len(r.Waves[last]) >= r.Rollout.WaveSize(total) {
+			r.Waves = append(r.Waves, []persistence.ServiceRolloutNode{n})
+		} else {
+			r.Waves[last] = append(r.Waves[last], n)
+		}
+		added = true
+	}
+	return added
+}
+
+// Record the agreements that were not cancelled when their policy changed, because the policy rolls out new service
+// versions in waves. A new rollout is started when the highest priority version changes, otherwise the nodes are added
+// to the rollout of that version. A rollout that was halted or aborted keeps the nodes it did not get to on their
+// previous version.
+func addToServiceRollout(db persistence.AgbotDatabase, pol *policy.Policy, nodes []persistence.ServiceRolloutNode) error {
+
+	serviceRolloutLock.Lock()
+	defer serviceRolloutLock.Unlock()
+
+	r, err := db.FindSingleServiceRollout(pol.Header.Name)
+	if err != nil {
+		return err
+	}
+
+	now := uint64(time.Now().Unix())
+	version, _ := rolloutVersion(pol)
+
+	if r == nil || r.Version != version || r.State == persistence.ROLLOUT_STATE_COMPLETED {
+		if len(nodes) == 0 {
+			return nil
+		}
+		r = NewServiceRollout(pol, nodes, now)
+		glog.V(3).Infof(logString(fmt.Sprintf("starting service rollout %v", r)))
+	} else if added := mergeServiceRolloutNodes(r, nodes); !added && r.Rollout.IsSame(*pol.Rollout) {
+		return nil
+	} else {
+		r.Rollout = *pol.Rollout
+		r.LastUpdateTime = now
+	}
+
+	return db.SaveServiceRollout(r)
+}
+
+// Pause, resume or abort a rollout. A paused rollout keeps the current wave as it is. Resuming a rollout gives the
+// nodes of the current wave the full health timeout and bake time again, and the nodes that failed in a halted rollout
+// get another chance to become healthy. An aborted rollout does not move any more nodes to the new version.
+func applyServiceRolloutAction(r *persistence.ServiceRollout, action string, now uint64) error {
+
+	switch action {
+	case ROLLOUT_ACTION_PAUSE:
+		if r.State != persistence.ROLLOUT_STATE_IN_PROGRESS {
+			return errors.New(fmt.Sprintf("the rollout of policy %v is %v, only a rollout in progress can be paused", r.PolicyName, r.State))
+		}
+		r.State = persistence.ROLLOUT_STATE_PAUSED
+
+	case ROLLOUT_ACTION_RESUME:
+		if r.State != persistence.ROLLOUT_STATE_PAUSED && r.State != persistence.ROLLOUT_STATE_HALTED {
+			return errors.New(fmt.Sprintf("the rollout of policy %v is %v, only a paused or halted rollout can be resumed", r.PolicyName, r.State))
+		}
+		if r.State == persistence.ROLLOUT_STATE_HALTED && r.Wave < len(r.Waves) {
+			for ix := range r.Waves[r.Wave] {
+				if n := &r.Waves[r.Wave][ix]; n.State == persistence.ROLLOUT_NODE_FAILED {
+					n.State = persistence.ROLLOUT_NODE_UPGRADING
+					n.NewAgreementId = ""
+					n.Reason = ""
+				}
+			}
+		}
+		if r.WaveStartTime != 0 {
+			r.WaveStartTime = now
+		}
+		r.WaveHealthyTime = 0
+		r.State = persistence.ROLLOUT_STATE_IN_PROGRESS
+		r.Reason = ""
+
+	case ROLLOUT_ACTION_ABORT:
+		if !r.IsActive() && r.State != persistence.ROLLOUT_STATE_HALTED {
+			return errors.New(fmt.Sprintf("the rollout of policy %v is %v, it cannot be aborted", r.PolicyName, r.State))
+		}
+		r.State = persistence.ROLLOUT_STATE_ABORTED
+
+	default:
+		return errors.New(fmt.Sprintf("rollout action %v is not supported, it must be %v, %v or %v", action, ROLLOUT_ACTION_PAUSE, ROLLOUT_ACTION_RESUME, ROLLOUT_ACTION_ABORT))
+	}
+
+	r.LastUpdateTime = now
+	return nil
+}
+
+// Check the nodes of the current wave, which has already started. A node is healthy once it has a finalized agreement
+// for the new version that is not waiting for data verification. A node fails when it does not get there within the
+// health timeout, when it falls back to a lower priority version or when its agreement for the new version ends. The
+// health timeout of a node whose upgrade is waiting for its maintenance window starts when the window opens. When
+// every node is healthy or has failed, the wave bakes for the bake time, and then the services of the healthy nodes
+// must still be running. The rollout is halted when more nodes of the wave fail than the rollout allows. Returns true
+// if the rollout changed.
+func advanceServiceRollout(r *persistence.ServiceRollout, check func(n *persistence.ServiceRolloutNode) (rolloutNodeHealth, string, string), running func(n *persistence.ServiceRolloutNode) bool, now uint64) bool {
+
+	if r.Wave >= len(r.Waves) {
+		r.State = persistence.ROLLOUT_STATE_COMPLETED
+		return true
+	}
+
+	changed := false
+	settled := true
+	wave := r.Waves[r.Wave]
+
+	for ix := range wave {
+		n := &wave[ix]
+		if n.State != persistence.ROLLOUT_NODE_UPGRADING && n.State != persistence.ROLLOUT_NODE_HEALTHY && n.State != persistence.ROLLOUT_NODE_DEFERRED {
+			continue
+		}
+
+		health, agreementId, reason := check(n)
+		if health == rolloutNodeDeferred {
+			if n.State != persistence.ROLLOUT_NODE_DEFERRED {
+				n.State = persistence.ROLLOUT_NODE_DEFERRED
+				changed = true
+			}
+			settled = false
+			continue
+		} else if n.State == persistence.ROLLOUT_NODE_DEFERRED {
+			n.State = persistence.ROLLOUT_NODE_UPGRADING
+			n.UpgradeTime = now
+			changed = true
+		}
+
+		if health == rolloutNodeHealthy && n.State != persistence.ROLLOUT_NODE_HEALTHY {
+			n.State = persistence.ROLLOUT_NODE_HEALTHY
+			n.NewAgreementId = agreementId
+			changed = true
+		} else if health == rolloutNodeFailed {
+			n.State = persistence.ROLLOUT_NODE_FAILED
+			n.Reason = reason
+			changed = true
+		} else if health == rolloutNodeWaiting && n.State == persistence.ROLLOUT_NODE_UPGRADING {
+			start := r.WaveStartTime
+			if n.UpgradeTime > start {
+				start = n.UpgradeTime
+			}
+			if timeout := uint64(r.Rollout.GetHealthTimeoutS()); now-start > timeout {
+				n.State = persistence.ROLLOUT_NODE_FAILED
+				n.Reason = fmt.Sprintf("no healthy agreement for version %v within %v seconds", r.Version, timeout)
+				changed = true
+			} else {
+				settled = false
+			}
+		}
+	}
+
+	if haltServiceRollout(r) {
+		return true
+	} else if !settled {
+		if r.WaveHealthyTime != 0 {
+			r.WaveHealthyTime = 0
+			changed = true
+		}
+		return changed
+	} else if r.WaveHealthyTime == 0 {
+		glog.V(3).Infof(logString(fmt.Sprintf("service rollout of policy %v wave %v of %v settled, baking for %v seconds", r.PolicyName, r.Wave+1, len(r.Waves), r.Rollout.BakeTimeS)))
+		r.WaveHealthyTime = now
+		changed = true
+	}
+
+	if now-r.WaveHealthyTime < uint64(r.Rollout.BakeTimeS) {
+		return changed
+	}
+
+	// The bake time is over, the services of the healthy nodes must still be running.
+	for ix := range wave {
+		if n := &wave[ix]; n.State == persistence.ROLLOUT_NODE_HEALTHY && !running(n) {
+			n.State = persistence.ROLLOUT_NODE_FAILED
+			n.Reason = fmt.Sprintf("the services of agreement %v are not running after the bake time", n.NewAgreementId)
+		}
+	}
+
+	if haltServiceRollout(r) {
+		return true
+	}
+
+	glog.V(3).Infof(logString(fmt.Sprintf("service rollout of policy %v wave %v of %v completed", r.PolicyName, r.Wave+1, len(r.Waves))))
+	r.Wave += 1
+	r.WaveStartTime = 0
+	r.WaveHealthyTime = 0
+	if r.Wave >= len(r.Waves) {
+		glog.V(3).Infof(logString(fmt.Sprintf("service rollout of policy %v to version %v completed", r.PolicyName, r.Version)))
+		r.State = persistence.ROLLOUT_STATE_COMPLETED
+	}
+	return true
+}
+
+// Halt the rollout if more nodes of the current wave have failed than the rollout allows.
+func haltServiceRollout(r *persistence.ServiceRollout) bool {
+	failed := make([]string, 0)
+	for _, n := range r.Waves[r.Wave] {
+		if n.State == persistence.ROLLOUT_NODE_FAILED {
+			failed = append(failed, n.DeviceId)
+		}
+	}
+
+	if len(failed) > r.Rollout.MaxFailures {
+		r.State = persistence.ROLLOUT_STATE_HALTED
+		r.Reason = fmt.Sprintf("%v nodes of wave %v failed, at most %v are allowed: %v", len(failed), r.Wave+1, r.Rollout.MaxFailures, failed)
+		glog.Errorf(logString(fmt.Sprintf("halting service rollout of policy %v to version %v: %v", r.PolicyName, r.Version, r.Reason)))
+		return true
+	}
+	return false
+}
+
+// Move the service rollouts in progress forward.
+func (w *AgreementBotWorker) governServiceRollouts() {
+
+	serviceRolloutLock.Lock()
+	defer serviceRolloutLock.Unlock()
+
+	rollouts, err := w.db.FindServiceRollouts()
+	if err != nil {
+		glog.Errorf(logString(fmt.Sprintf("unable to read service rollouts, error: %v", err)))
+		return
+	}
+
+	now := uint64(time.Now().Unix())
+	for ix := range rollouts {
+		r := &rollouts[ix]
+		if r.State != persistence.ROLLOUT_STATE_IN_PROGRESS {
+			continue
+		}
+
+		changed := false
+		if r.Wave < len(r.Waves) && r.WaveStartTime == 0 {
+			changed = w.startServiceRolloutWave(r, now)
+		} else {
+			changed = advanceServiceRollout(r, w.serviceRolloutNodeHealth(r), w.serviceRolloutNodeRunning, now)
+		}
+
+		if changed {
+			r.LastUpdateTime = now
+			if err := w.db.SaveServiceRollout(r); err != nil {
+				glog.Errorf(logString(fmt.Sprintf("unable to save service rollout %v, error: %v", r, err)))
+			}
+		}
+	}
+}
+
+// Cancel the agreements for the previous version of the nodes in the current wave, so that new agreements are made with
+// the new version. A node whose agreement has already ended is no longer part of the rollout. Returns true if the
+// rollout changed.
+func (w *AgreementBotWorker) startServiceRolloutWave(r *persistence.ServiceRollout, now uint64) bool {
+
+	wave := make([]persistence.ServiceRolloutNode, 0, len(r.Waves[r.Wave]))
+	for _, n := range r.Waves[r.Wave] {
+		if n.State != persistence.ROLLOUT_NODE_PENDING {
+			wave = append(wave, n)
+			continue
+		}
+
+		ag, err := w.db.FindSingleAgreementByAgreementId(n.AgreementId, n.Protocol, []persistence.AFilter{persistence.UnarchivedAFilter()})
+		if err != nil {
+			glog.Errorf(logString(fmt.Sprintf("unable to read agreement %v for service rollout, error: %v", n.AgreementId, err)))
+			return false
+		} else if ag == nil || ag.AgreementTimedout != 0 {
+			glog.V(3).Infof(logString(fmt.Sprintf("agreement %v of node %v ended before its service rollout wave started", n.AgreementId, n.DeviceId)))
+			continue
+		}
+
+		glog.V(3).Infof(logString(fmt.Sprintf("cancelling agreement %v of node %v to roll out version %v of policy %v", n.AgreementId, n.DeviceId, r.Version, r.PolicyName)))
+		protocolHandler := w.consumerPH.Get(n.Protocol)
+		protocolHandler.CancelAgreement(*ag, TERM_REASON_POLICY_CHANGED, protocolHandler, true)
+
+		n.State = persistence.ROLLOUT_NODE_UPGRADING
+		n.UpgradeTime = now
+		wave = append(wave, n)
+	}
+
+	r.Waves[r.Wave] = wave
+	if len(wave) == 0 {
+		r.Wave += 1
+		if r.Wave >= len(r.Waves) {
+			r.State = persistence.ROLLOUT_STATE_COMPLETED
+		}
+	} else {
+		glog.V(3).Infof(logString(fmt.Sprintf("service rollout of policy %v wave %v of %v started with %v nodes", r.PolicyName, r.Wave+1, len(r.Waves), len(wave))))
+		r.WaveStartTime = now
+	}
+	return true
+}
+
+// Returns a function that checks if a node of the rollout has a healthy agreement for the new version.
+func (w *AgreementBotWorker) serviceRolloutNodeHealth(r *persistence.ServiceRollout) func(n *persistence.ServiceRolloutNode) (rolloutNodeHealth, string, string) {
+	return func(n *persistence.ServiceRolloutNode) (rolloutNodeHealth, string, string) {
+
+		agreements, err := w.db.FindAgreements([]persistence.AFilter{persistence.UnarchivedAFilter(), persistence.DevPolAFilter(n.DeviceId, r.PolicyName)}, n.Protocol)
+		if err != nil {
+			glog.Errorf(logString(fmt.Sprintf("unable to read agreements of node %v for service rollout, error: %v", n.DeviceId, err)))
+			return rolloutNodeWaiting, "", ""
+		}
+
+		// The node has at most one agreement for the policy that has not ended, it is either the agreement for the previous
+		// version or the new agreement.
+		var newAg *persistence.Agreement
+		for ix, ag := range agreements {
+			if ag.AgreementTimedout != 0 {
+				continue
+			} else if ag.CurrentAgreementId == n.AgreementId {
+				if ag.UpgradeDeferredUntil != 0 {
+					return rolloutNodeDeferred, "", ""
+				}
+			} else {
+				newAg = &agreements[ix]
+			}
+		}
+
+		if n.NewAgreementId != "" && (newAg == nil || newAg.CurrentAgreementId != n.NewAgreementId) {
+			return rolloutNodeFailed, "", fmt.Sprintf("agreement %v for version %v ended", n.NewAgreementId, r.Version)
+		} else if newAg == nil {
+			return rolloutNodeWaiting, "", ""
+		}
+
+		// The workload usage shows which version the agreement was made with when the policy has priorities.
+		if r.Priority != 0 {
+			if wlu, err := w.db.FindSingleWorkloadUsageByDeviceAndPolicyName(n.DeviceId, r.PolicyName); err != nil {
+				glog.Errorf(logString(fmt.Sprintf("unable to read workload usage of node %v for service rollout, error: %v", n.DeviceId, err)))
+				return rolloutNodeWaiting, "", ""
+			} else if wlu != nil && wlu.CurrentAgreementId == newAg.CurrentAgreementId && wlu.Priority > r.Priority {
+				return rolloutNodeFailed, "", fmt.Sprintf("the node fell back from version %v to a lower priority version", r.Version)
+			}
+		}
+
+		if newAg.AgreementFinalizedTime == 0 {
+			return rolloutNodeWaiting, "", ""
+		} else if newAg.DataVerificationURL != "" && !newAg.DisableDataVerificationChecks && newAg.DataVerifiedTime == 0 {
+			return rolloutNodeWaiting, "", ""
+		}
+		return rolloutNodeHealthy, newAg.CurrentAgreementId, ""
+	}
+}
+
+// Returns true if the services of the new agreement of a node are running, as reported in the node status.
+func (w *AgreementBotWorker) serviceRolloutNodeRunning(n *persistence.ServiceRolloutNode) bool {
+	if running, err := w.WorkloadRunningOnDevice(n.DeviceId, n.NewAgreementId); err != nil {
+		glog.Errorf(logString(fmt.Sprintf("unable to check the services of agreement %v for service rollout, error: %v", n.NewAgreementId, err)))
+		return true
+	} else {
+		return running
+	}
+}
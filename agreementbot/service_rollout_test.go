@@ -0,0 +1,197 @@
+//go:build unit
+// +build unit
+
+package agreementbot
+
+import (
+	"testing"
+
+	"github.com/open-horizon/anax/agreementbot/persistence"
+	"github.com/open-horizon/anax/policy"
+	"github.com/stretchr/testify/assert"
+)
+
+func rolloutNodes(ids ...string) []persistence.ServiceRolloutNode {
+	nodes := make([]persistence.ServiceRolloutNode, 0)
+	for _, id := range ids {
+		nodes = append(nodes, persistence.ServiceRolloutNode{DeviceId: "org/" + id, AgreementId: "ag-" + id, Protocol: "Basic", State: persistence.ROLLOUT_NODE_PENDING})
+	}
+	return nodes
+}
+
+func rolloutPolicy(rollout policy.RolloutPolicy) *policy.Policy {
+	pol := policy.Policy_Factory("org/pol")
+	pol.Workloads = []policy.Workload{
+		{Version: "1.0.0", Priority: policy.WorkloadPriority{PriorityValue: 2}},
+		{Version: "2.0.0", Priority: policy.WorkloadPriority{PriorityValue: 1}},
+	}
+	pol.Rollout = &rollout
+	return pol
+}
+
+func Test_NewServiceRollout(t *testing.T) {
+
+	r := NewServiceRollout(rolloutPolicy(policy.RolloutPolicy{BatchPercent: 40}), rolloutNodes("e", "d", "c", "b", "a"), 100)
+	assert.Equal(t, "2.0.0", r.Version)
+	assert.Equal(t, 1, r.Priority)
+	assert.Equal(t, persistence.ROLLOUT_STATE_IN_PROGRESS, r.State)
+
+	// Wave sizes are rounded up, and the nodes are sorted.
+	assert.Equal(t, 3, len(r.Waves))
+	assert.Equal(t, 2, len(r.Waves[0]))
+	assert.Equal(t, "org/a", r.Waves[0][0].DeviceId)
+	assert.Equal(t, "org/e", r.Waves[2][0].DeviceId)
+
+	r = NewServiceRollout(rolloutPolicy(policy.RolloutPolicy{BatchSize: 10}), rolloutNodes("a", "b"), 100)
+	assert.Equal(t, 1, len(r.Waves))
+}
+
+func Test_mergeServiceRolloutNodes(t *testing.T) {
+
+	r := NewServiceRollout(rolloutPolicy(policy.RolloutPolicy{BatchSize: 2}), rolloutNodes("a", "b", "c"), 100)
+
+	// A known node is not added twice, a new node fills the last wave.
+	assert.True(t, mergeServiceRolloutNodes(r, rolloutNodes("a", "d")))
+	assert.Equal(t, 2, len(r.Waves))
+	assert.Equal(t, "org/d", r.Waves[1][1].DeviceId)
+	assert.False(t, mergeServiceRolloutNodes(r, rolloutNodes("d")))
+
+	// A full wave is not changed.
+	mergeServiceRolloutNodes(r, rolloutNodes("e"))
+	assert.Equal(t, 3, len(r.Waves))
+
+	// Nodes are not added to a wave that has started.
+	r.Wave, r.WaveStartTime = 2, 100
+	mergeServiceRolloutNodes(r, rolloutNodes("f"))
+	assert.Equal(t, 4, len(r.Waves))
+	assert.Equal(t, 1, len(r.Waves[2]))
+}
+
+func Test_applyServiceRolloutAction(t *testing.T) {
+
+	r := NewServiceRollout(rolloutPolicy(policy.RolloutPolicy{BatchSize: 2}), rolloutNodes("a", "b"), 100)
+
+	assert.NotNil(t, applyServiceRolloutAction(r, "restart", 110))
+	assert.NotNil(t, applyServiceRolloutAction(r, ROLLOUT_ACTION_RESUME, 110))
+
+	assert.Nil(t, applyServiceRolloutAction(r, ROLLOUT_ACTION_PAUSE, 110))
+	assert.Equal(t, persistence.ROLLOUT_STATE_PAUSED, r.State)
+	assert.NotNil(t, applyServiceRolloutAction(r, ROLLOUT_ACTION_PAUSE, 110))
+
+	// Resuming a halted rollout retries the failed nodes of the current wave.
+	r.State, r.Reason, r.WaveStartTime = persistence.ROLLOUT_STATE_HALTED, "failed", 100
+	r.Waves[0][0].State = persistence.ROLLOUT_NODE_FAILED
+	r.Waves[0][1].State = persistence.ROLLOUT_NODE_HEALTHY
+	assert.Nil(t, applyServiceRolloutAction(r, ROLLOUT_ACTION_RESUME, 120))
+	assert.Equal(t, persistence.ROLLOUT_STATE_IN_PROGRESS, r.State)
+	assert.Equal(t, "", r.Reason)
+	assert.Equal(t, uint64(120), r.WaveStartTime)
+	assert.Equal(t, persistence.ROLLOUT_NODE_UPGRADING, r.Waves[0][0].State)
+	assert.Equal(t, persistence.ROLLOUT_NODE_HEALTHY, r.Waves[0][1].State)
+
+	assert.Nil(t, applyServiceRolloutAction(r, ROLLOUT_ACTION_ABORT, 130))
+	assert.Equal(t, persistence.ROLLOUT_STATE_ABORTED, r.State)
+	assert.NotNil(t, applyServiceRolloutAction(r, ROLLOUT_ACTION_RESUME, 140))
+	assert.NotNil(t, applyServiceRolloutAction(r, ROLLOUT_ACTION_ABORT, 140))
+}
+
+func Test_advanceServiceRollout(t *testing.T) {
+
+	r := NewServiceRollout(rolloutPolicy(policy.RolloutPolicy{BatchSize: 2, BakeTimeS: 60, MaxFailures: 0, HealthTimeoutS: 300}), rolloutNodes("a", "b", "c"), 100)
+	for ix := range r.Waves[0] {
+		r.Waves[0][ix].State = persistence.ROLLOUT_NODE_UPGRADING
+	}
+	r.WaveStartTime = 100
+
+	health := map[string]rolloutNodeHealth{"org/a": rolloutNodeHealthy, "org/b": rolloutNodeWaiting}
+	check := func(n *persistence.ServiceRolloutNode) (rolloutNodeHealth, string, string) {
+		if health[n.DeviceId] == rolloutNodeFailed {
+			return rolloutNodeFailed, "", "fell back"
+		}
+		return health[n.DeviceId], "new-" + n.DeviceId, ""
+	}
+	running := map[string]bool{"org/a": true, "org/b": true}
+	isRunning := func(n *persistence.ServiceRolloutNode) bool { return running[n.DeviceId] }
+
+	// The wave does not settle until every node is healthy.
+	assert.True(t, advanceServiceRollout(r, check, isRunning, 150))
+	assert.Equal(t, persistence.ROLLOUT_NODE_HEALTHY, r.Waves[0][0].State)
+	assert.Equal(t, "new-org/a", r.Waves[0][0].NewAgreementId)
+	assert.Equal(t, uint64(0), r.WaveHealthyTime)
+
+	// Then it bakes before the next wave.
+	health["org/b"] = rolloutNodeHealthy
+	assert.True(t, advanceServiceRollout(r, check, isRunning, 200))
+	assert.Equal(t, uint64(200), r.WaveHealthyTime)
+	assert.False(t, advanceServiceRollout(r, check, isRunning, 230))
+	assert.Equal(t, 0, r.Wave)
+	assert.True(t, advanceServiceRollout(r, check, isRunning, 260))
+	assert.Equal(t, 1, r.Wave)
+	assert.Equal(t, uint64(0), r.WaveStartTime)
+	assert.Equal(t, persistence.ROLLOUT_STATE_IN_PROGRESS, r.State)
+
+	// A node that does not become healthy within the health timeout halts the rollout.
+	r.Waves[1][0].State = persistence.ROLLOUT_NODE_UPGRADING
+	r.WaveStartTime = 300
+	assert.False(t, advanceServiceRollout(r, check, isRunning, 500))
+	assert.True(t, advanceServiceRollout(r, check, isRunning, 601))
+	assert.Equal(t, persistence.ROLLOUT_NODE_FAILED, r.Waves[1][0].State)
+	assert.Equal(t, persistence.ROLLOUT_STATE_HALTED, r.State)
+	assert.Contains(t, r.Reason, "org/c")
+
+	// After a resume, a node whose service stops during the bake time fails, unless failures are allowed.
+	assert.Nil(t, applyServiceRolloutAction(r, ROLLOUT_ACTION_RESUME, 700))
+	r.Rollout.MaxFailures = 1
+	health["org/c"] = rolloutNodeHealthy
+	running["org/c"] = false
+	assert.True(t, advanceServiceRollout(r, check, isRunning, 710))
+	assert.True(t, advanceServiceRollout(r, check, isRunning, 780))
+	assert.Equal(t, persistence.ROLLOUT_NODE_FAILED, r.Waves[1][0].State)
+	assert.Equal(t, persistence.ROLLOUT_STATE_COMPLETED, r.State)
+	assert.Equal(t, 2, r.Wave)
+}
+
+func Test_advanceServiceRollout_fallback(t *testing.T) {
+
+	r := NewServiceRollout(rolloutPolicy(policy.RolloutPolicy{BatchSize: 1, MaxFailures: 0}), rolloutNodes("a", "b"), 100)
+	r.Waves[0][0].State = persistence.ROLLOUT_NODE_UPGRADING
+	r.WaveStartTime = 100
+
+	check := func(n *persistence.ServiceRolloutNode) (rolloutNodeHealth, string, string) {
+		return rolloutNodeFailed, "", "the node fell back"
+	}
+	assert.True(t, advanceServiceRollout(r, check, func(n *persistence.ServiceRolloutNode) bool { return true }, 110))
+	assert.Equal(t, persistence.ROLLOUT_STATE_HALTED, r.State)
+	assert.Equal(t, "the node fell back", r.Waves[0][0].Reason)
+	assert.Equal(t, persistence.ROLLOUT_NODE_PENDING, r.Waves[1][0].State)
+}
+
+func Test_advanceServiceRollout_deferred(t *testing.T) {
+
+	r := NewServiceRollout(rolloutPolicy(policy.RolloutPolicy{BatchSize: 1, MaxFailures: 0, HealthTimeoutS: 300}), rolloutNodes("a", "b"), 100)
+	r.Waves[0][0].State = persistence.ROLLOUT_NODE_UPGRADING
+	r.Waves[0][0].UpgradeTime = 100
+	r.WaveStartTime = 100
+
+	health := rolloutNodeDeferred
+	check := func(n *persistence.ServiceRolloutNode) (rolloutNodeHealth, string, string) {
+		return health, "new-" + n.DeviceId, ""
+	}
+	isRunning := func(n *persistence.ServiceRolloutNode) bool { return true }
+
+	// A node waiting for its maintenance window does not time out.
+	assert.True(t, advanceServiceRollout(r, check, isRunning, 110))
+	assert.Equal(t, persistence.ROLLOUT_NODE_DEFERRED, r.Waves[0][0].State)
+	assert.False(t, advanceServiceRollout(r, check, isRunning, 5000))
+	assert.Equal(t, persistence.ROLLOUT_STATE_IN_PROGRESS, r.State)
+
+	// The health timeout starts when the window opens.
+	health = rolloutNodeWaiting
+	assert.True(t, advanceServiceRollout(r, check, isRunning, 6000))
+	assert.Equal(t, persistence.ROLLOUT_NODE_UPGRADING, r.Waves[0][0].State)
+	assert.Equal(t, uint64(6000), r.Waves[0][0].UpgradeTime)
+	assert.False(t, advanceServiceRollout(r, check, isRunning, 6200))
+	assert.True(t, advanceServiceRollout(r, check, isRunning, 6301))
+	assert.Equal(t, persistence.ROLLOUT_NODE_FAILED, r.Waves[0][0].State)
+	assert.Equal(t, persistence.ROLLOUT_STATE_HALTED, r.State)
+}
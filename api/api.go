@@ -11,7 +11,6 @@ import (
 	"github.com/open-horizon/anax/persistence"
 	"github.com/open-horizon/anax/policy"
 	"github.com/open-horizon/anax/worker"
-	bolt "go.etcd.io/bbolt"
 	"net/http"
 	"regexp"
 	"sync"
@@ -20,13 +19,14 @@ import (
 type API struct {
 	worker.Manager // embedded field
 	name           string
-	db             *bolt.DB
+	db             persistence.AgentDatabase
 	pm             *policy.PolicyManager
 	em             *events.EventStateManager
 	bcState        map[string]map[string]apicommon.BlockchainState
 	bcStateLock    sync.Mutex
 	shutdownError  string
 	EC             *worker.BaseExchangeContext
+	metricsHandler http.Handler // The /metrics API handler, nil when metrics are not enabled
 }
 
 type BlockchainState struct {
@@ -36,7 +36,7 @@ type BlockchainState struct {
 	servicePort string // the network port of the container
 }
 
-func NewAPIListener(name string, cfg *config.HorizonConfig, db *bolt.DB, pm *policy.PolicyManager) *API {
+func NewAPIListener(name string, cfg *config.HorizonConfig, db persistence.AgentDatabase, pm *policy.PolicyManager) *API {
 	messages := make(chan events.Message)
 
 	listener := &API{
@@ -54,6 +54,10 @@ func NewAPIListener(name string, cfg *config.HorizonConfig, db *bolt.DB, pm *pol
 		EC:          nil,
 	}
 
+	if cfg.Edge.EnableMetrics {
+		listener.metricsHandler = newMetricsHandler(db)
+	}
+
 	// setup the exchange context if the device is set
 	pDevice, err := persistence.FindExchangeDevice(db)
 	if err != nil {
@@ -87,6 +91,11 @@ func (a *API) router(includeStaticRedirects bool) *mux.Router {
 	router.HandleFunc("/status", a.status).Methods("GET", "OPTIONS")
 	router.HandleFunc("/status/workers", a.workerstatus).Methods("GET", "OPTIONS")
 
+	// Metrics in the Prometheus format, when they are enabled
+	if a.metricsHandler != nil {
+		router.HandleFunc("/metrics", a.metrics).Methods("GET", "OPTIONS")
+	}
+
 	// Used by the Registration UI to obtain a random token string
 	router.HandleFunc("/token/random", tokenRandom).Methods("GET", "OPTIONS")
 
@@ -95,6 +104,7 @@ func (a *API) router(includeStaticRedirects bool) *mux.Router {
 	router.HandleFunc("/node/configstate", a.nodeconfigstate).Methods("GET", "HEAD", "PUT", "OPTIONS")
 	router.HandleFunc("/node/policy", a.nodepolicy).Methods("GET", "HEAD", "PUT", "POST", "PATCH", "DELETE", "OPTIONS")
 	router.HandleFunc("/node/userinput", a.nodeuserinput).Methods("GET", "HEAD", "PUT", "POST", "PATCH", "DELETE", "OPTIONS")
+	router.HandleFunc("/node/backup", a.nodebackup).Methods("GET", "PUT", "OPTIONS")
 
 	// Used to get the event logs on this node.
 	// get the eventlogs for current registration.
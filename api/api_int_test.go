@@ -15,7 +15,6 @@ import (
 	"path"
 	"sync"
 	"testing"
-	"time"
 
 	"github.com/adams-sarah/test2doc/test"
 	"github.com/golang/glog"
@@ -27,7 +26,6 @@ import (
 	"github.com/open-horizon/anax/worker"
 	"github.com/open-horizon/rsapss-tool/listkeys"
 	"github.com/stretchr/testify/assert"
-	bolt "go.etcd.io/bbolt"
 )
 
 func handleResp(r *http.Response, expectedStatus int) ([]byte, error) {
@@ -70,13 +68,13 @@ func serial(t *testing.T, attrInput []byte) []byte {
 	return serialized
 }
 
-func setup() (string, *bolt.DB, error) {
+func setup() (string, persistence.AgentDatabase, error) {
 	dir, err := os.MkdirTemp("", "api-attribute-")
 	if err != nil {
 		return "", nil, err
 	}
 
-	db, err := bolt.Open(path.Join(dir, "anax-int.db"), 0600, &bolt.Options{Timeout: 10 * time.Second})
+	db, err := persistence.OpenBoltDatabase(path.Join(dir, "anax-int.db"))
 	if err != nil {
 		return dir, nil, err
 	}
@@ -567,3 +567,44 @@ func (a *API) nodeuserinput(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusMethodNotAllowed)
 	}
 }
+
+func (a *API) nodebackup(w http.ResponseWriter, r *http.Request) {
+
+	resource := "node/backup"
+
+	errorHandler := GetHTTPErrorHandler(w)
+
+	switch r.Method {
+	case "GET":
+		glog.V(5).Infof(apiLogString(fmt.Sprintf("Handling %v on resource %v", r.Method, resource)))
+
+		if errHandled, out := FindNodeBackupForOutput(errorHandler, a.db); !errHandled {
+			writeResponse(w, out, http.StatusOK)
+		}
+
+	case "PUT":
+		glog.V(5).Infof(apiLogString(fmt.Sprintf("Handling %v on resource %v", r.Method, resource)))
+
+		// The body is not logged because it contains the node's token.
+		var restore NodeBackupRestore
+		body, _ := io.ReadAll(r.Body)
+		if err := json.Unmarshal(body, &restore); err != nil {
+			LogDeviceEvent(a.db, persistence.SEVERITY_ERROR,
+				persistence.NewMessageMeta(EL_API_ERR_PARSING_NODE_BACKUP, err.Error()),
+				persistence.EC_API_USER_INPUT_ERROR, nil)
+			errorHandler(NewAPIUserInputError(fmt.Sprintf("Input body could not be deserialized to %v object, error: %v", resource, err), "body"))
+			return
+		}
+
+		if errHandled, out := RestoreNodeBackup(&restore, errorHandler, a.db, a.Config); !errHandled {
+			glog.V(5).Infof(apiLogString(fmt.Sprintf("Handled %v on resource %v", r.Method, resource)))
+			writeResponse(w, out, http.StatusCreated)
+		}
+
+	case "OPTIONS":
+		w.Header().Set("Allow", "GET, PUT, OPTIONS")
+		w.WriteHeader(http.StatusOK)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
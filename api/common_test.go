@@ -4,13 +4,12 @@ import (
 	"fmt"
 	"os"
 	"path"
-	"time"
 
 	"github.com/open-horizon/anax/config"
 	"github.com/open-horizon/anax/exchange"
 	"github.com/open-horizon/anax/exchangecommon"
+	"github.com/open-horizon/anax/persistence"
 	"github.com/open-horizon/anax/policy"
-	bolt "go.etcd.io/bbolt"
 )
 
 // ========================================================================================
@@ -250,13 +249,13 @@ func getDummyDeleteNodePolicyHandler() exchange.DeleteNodePolicyHandler {
 	}
 }
 
-func utsetup() (string, *bolt.DB, error) {
+func utsetup() (string, persistence.AgentDatabase, error) {
 	dir, err := os.MkdirTemp("", "utdb-")
 	if err != nil {
 		return "", nil, err
 	}
 
-	db, err := bolt.Open(path.Join(dir, "anax-int.db"), 0600, &bolt.Options{Timeout: 10 * time.Second})
+	db, err := persistence.OpenBoltDatabase(path.Join(dir, "anax-int.db"))
 	if err != nil {
 		return dir, nil, err
 	}
@@ -63,6 +63,13 @@ const (
 	EL_API_NO_NODE_UI_TO_DEL   = "No node user input to detele"
 	EL_API_DELETED_ALL_NODE_UI = "Deleted all node user input"
 
+	// from path_node_backup.go
+	EL_API_NODE_BACKUP_EXPORTED        = "Exported the state of node %v/%v."
+	EL_API_NODE_BACKUP_RESTORED        = "Restored the state of node %v/%v from the backup created at %v. Restart the agent to use the restored state."
+	EL_API_ERR_NODE_BACKUP_RESTORE     = "Error restoring node backup. %v"
+	EL_API_NODE_BACKUP_SECRETS_DROPPED = "The secret values are not saved in a node backup, so agreements %v that use secrets were not restored. New agreements will be made for them."
+	EL_API_ERR_PARSING_NODE_BACKUP     = "Error parsing input for node backup restore. Input body could not be deserialized as a node backup, error: %v"
+
 	// from path_service_config.go
 	EL_API_START_SVC_CONFIG           = "Start service configuration with user input for %v/%v."
 	EL_API_START_SVC_AUTO_CONFIG      = "Start service auto configuration for %v/%v."
@@ -145,6 +152,13 @@ func MarkI18nMessages() {
 	msgPrinter.Sprintf(EL_API_NO_NODE_UI_TO_DEL)
 	msgPrinter.Sprintf(EL_API_DELETED_ALL_NODE_UI)
 
+	// from path_node_backup.go
+	msgPrinter.Sprintf(EL_API_NODE_BACKUP_EXPORTED)
+	msgPrinter.Sprintf(EL_API_NODE_BACKUP_RESTORED)
+	msgPrinter.Sprintf(EL_API_ERR_NODE_BACKUP_RESTORE)
+	msgPrinter.Sprintf(EL_API_NODE_BACKUP_SECRETS_DROPPED)
+	msgPrinter.Sprintf(EL_API_ERR_PARSING_NODE_BACKUP)
+
 	// from path_service_config.go
 	msgPrinter.Sprintf(EL_API_START_SVC_CONFIG)
 	msgPrinter.Sprintf(EL_API_START_SVC_AUTO_CONFIG)
@@ -0,0 +1,122 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/golang/glog"
+	"github.com/open-horizon/anax/changes"
+	"github.com/open-horizon/anax/exchange"
+	"github.com/open-horizon/anax/imagefetch"
+	"github.com/open-horizon/anax/microservice"
+	"github.com/open-horizon/anax/persistence"
+	"github.com/open-horizon/anax/policy"
+	"github.com/open-horizon/anax/worker"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// The metrics exposed by the agent /metrics API, in the Prometheus text format. The API is only served when the
+// EnableMetrics config option is set. Metrics about the work done by the agent are updated by the workers as the work
+// happens. Metrics about the state of the node (workers, agreements and node management policies) are read from their
+// source when the metrics are scraped.
+
+const (
+	AGREEMENT_STATE_ACTIVE      = "active"
+	AGREEMENT_STATE_TERMINATING = "terminating"
+	AGREEMENT_STATE_ARCHIVED    = "archived"
+)
+
+// Collects the metrics that are read from the agent database on each scrape.
+type agentStateCollector struct {
+	db         persistence.AgentDatabase
+	agreements *prometheus.Desc
+	nmpStatus  *prometheus.Desc
+}
+
+func newAgentStateCollector(db persistence.AgentDatabase) *agentStateCollector {
+	return &agentStateCollector{
+		db: db,
+		agreements: prometheus.NewDesc("horizon_agent_agreements",
+			"Number of agreements on the node, by state (active, terminating or archived).",
+			[]string{"state"}, nil),
+		nmpStatus: prometheus.NewDesc("horizon_agent_nmp_status",
+			"Number of node management policies on the node, by agent upgrade status.",
+			[]string{"status"}, nil),
+	}
+}
+
+func (c *agentStateCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.agreements
+	ch <- c.nmpStatus
+}
+
+// Errors reading the database are logged and the affected metrics are left out of the scrape, so that the rest of
+// the metrics are still available.
+func (c *agentStateCollector) Collect(ch chan<- prometheus.Metric) {
+
+	if agreements, err := persistence.FindEstablishedAgreementsAllProtocols(c.db, policy.AllAgreementProtocols(), []persistence.EAFilter{}); err != nil {
+		glog.Errorf(apiLogString(fmt.Sprintf("unable to read agreements for metrics, error: %v", err)))
+	} else {
+		counts := map[string]int{AGREEMENT_STATE_ACTIVE: 0, AGREEMENT_STATE_TERMINATING: 0, AGREEMENT_STATE_ARCHIVED: 0}
+		for _, ag := range agreements {
+			if ag.Archived {
+				counts[AGREEMENT_STATE_ARCHIVED] += 1
+			} else if ag.AgreementTerminatedTime != 0 {
+				counts[AGREEMENT_STATE_TERMINATING] += 1
+			} else {
+				counts[AGREEMENT_STATE_ACTIVE] += 1
+			}
+		}
+		for state, num := range counts {
+			ch <- prometheus.MustNewConstMetric(c.agreements, prometheus.GaugeValue, float64(num), state)
+		}
+	}
+
+	if statuses, err := persistence.FindAllNMPStatus(c.db); err != nil {
+		glog.Errorf(apiLogString(fmt.Sprintf("unable to read node management policy statuses for metrics, error: %v", err)))
+	} else {
+		counts := make(map[string]int)
+		for _, status := range statuses {
+			if status != nil && status.Status() != "" {
+				counts[status.Status()] += 1
+			}
+		}
+		for status, num := range counts {
+			ch <- prometheus.MustNewConstMetric(c.nmpStatus, prometheus.GaugeValue, float64(num), status)
+		}
+	}
+}
+
+// Create the handler for the /metrics API. The registry is not the Prometheus default registry, so the agbot's
+// metrics are not exposed by the agent. The Go runtime, process, worker status and exchange request metrics are
+// recorded for the whole process, when the agent and an agbot run in the same process both of them expose these metrics.
+func newMetricsHandler(db persistence.AgentDatabase) http.Handler {
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		exchange.ExchangeRequestDuration,
+		worker.NewWorkerStatusCollector(),
+		changes.HeartbeatsTotal,
+		changes.PollIntervalSeconds,
+		microservice.ServiceRestartsTotal,
+		microservice.ServiceRollbacksTotal,
+		imagefetch.ImageFetchDuration,
+		newAgentStateCollector(db),
+	)
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+}
+
+func (a *API) metrics(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case "GET":
+		a.metricsHandler.ServeHTTP(w, r)
+	case "OPTIONS":
+		w.Header().Set("Allow", "GET, OPTIONS")
+		w.WriteHeader(http.StatusOK)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
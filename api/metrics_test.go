@@ -0,0 +1,66 @@
+//go:build unit
+// +build unit
+
+package api
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/open-horizon/anax/exchangecommon"
+	"github.com/open-horizon/anax/persistence"
+)
+
+func Test_metrics(t *testing.T) {
+
+	dir, db, err := utsetup()
+	if err != nil {
+		t.Error(err)
+	}
+	defer cleanTestDir(dir)
+
+	// Agreement 1 is active, agreement 2 is archived and agreement 3 is terminating.
+	sps := []persistence.ServiceSpec{persistence.ServiceSpec{Url: "http://sensor.org", Org: "myorg"}}
+	wi, _ := persistence.NewWorkloadInfo("url", "org", "version", "")
+	for _, id := range []string{"agreementId1", "agreementId2", "agreementId3"} {
+		if _, err := persistence.NewEstablishedAgreement(db, "name1", id, "consumerId", "{}", "Basic", 1, sps, "signature", "address", "bcType", "bcName", "bcOrg", wi, 180); err != nil {
+			t.Errorf("error writing %v: %v", id, err)
+		}
+	}
+	if _, err := persistence.ArchiveEstablishedAgreement(db, "agreementId2", "Basic"); err != nil {
+		t.Errorf("error archiving agreement2: %v", err)
+	} else if _, err := persistence.AgreementStateTerminated(db, "agreementId3", 100, "unit test termination", "Basic"); err != nil {
+		t.Errorf("error terminating agreement3: %v", err)
+	}
+
+	nmpStatus := exchangecommon.NodeManagementPolicyStatus{AgentUpgrade: &exchangecommon.AgentUpgradePolicyStatus{Status: exchangecommon.STATUS_SUCCESSFUL}}
+	if err := persistence.SaveOrUpdateNMPStatus(db, "myorg/nmp1", nmpStatus); err != nil {
+		t.Errorf("error saving node management policy status: %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	a := &API{metricsHandler: newMetricsHandler(db)}
+	a.metrics(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("metrics should return %v, returned %v", http.StatusOK, rec.Code)
+	}
+
+	body, _ := io.ReadAll(rec.Body)
+	metrics := string(body)
+	for _, expected := range []string{
+		`horizon_agent_agreements{state="active"} 1`,
+		`horizon_agent_agreements{state="archived"} 1`,
+		`horizon_agent_agreements{state="terminating"} 1`,
+		`horizon_agent_nmp_status{status="` + exchangecommon.STATUS_SUCCESSFUL + `"} 1`,
+		`horizon_agent_exchange_heartbeats_total{result="failure"} 0`,
+		`# TYPE horizon_agent_exchange_poll_interval_seconds gauge`,
+	} {
+		if !strings.Contains(metrics, expected) {
+			t.Errorf("metrics should contain %v, metrics are:\n%v", expected, metrics)
+		}
+	}
+}
@@ -10,7 +10,6 @@ import (
 	"github.com/golang/glog"
 	"github.com/open-horizon/anax/persistence"
 	"github.com/open-horizon/anax/policy"
-	bolt "go.etcd.io/bbolt"
 )
 
 func attributesContains(given []persistence.Attribute, sp *persistence.ServiceSpec, typeString string) *persistence.Attribute {
@@ -517,7 +516,7 @@ func payloadToAttributes(errorhandler ErrorHandler, body io.Reader, permitPartia
 // serializeAttributeForOutput retrieves attributes by url from the DB and then
 // serializes then as JSON, returning a byte array for convenient writing to an
 // HTTP response.
-func FindAndWrapAttributesForOutput(db *bolt.DB, id string) (map[string][]Attribute, error) {
+func FindAndWrapAttributesForOutput(db persistence.AgentDatabase, id string) (map[string][]Attribute, error) {
 
 	attributes, err := persistence.FindApplicableAttributes(db, "", "")
 	if err != nil {
@@ -5,13 +5,12 @@ import (
 	"github.com/golang/glog"
 	"github.com/open-horizon/anax/eventlog"
 	"github.com/open-horizon/anax/persistence"
-	bolt "go.etcd.io/bbolt"
 	"golang.org/x/text/message"
 	"sort"
 )
 
 // This API returns the event logs saved on the db.
-func FindEventLogsForOutput(db *bolt.DB, all_logs bool, selections map[string][]string, msgPrinter *message.Printer) ([]persistence.EventLog, error) {
+func FindEventLogsForOutput(db persistence.AgentDatabase, all_logs bool, selections map[string][]string, msgPrinter *message.Printer) ([]persistence.EventLog, error) {
 
 	glog.V(5).Infof(apiLogString(fmt.Sprintf("Getting event logs from the db. The selectors are: %v.", selections)))
 
@@ -33,7 +32,7 @@ func FindEventLogsForOutput(db *bolt.DB, all_logs bool, selections map[string][]
 }
 
 // This API deletes the selected event logs saved on the db.
-func DeleteEventLogs(db *bolt.DB, prune bool, selections map[string][]string, msgPrinter *message.Printer) (int, error) {
+func DeleteEventLogs(db persistence.AgentDatabase, prune bool, selections map[string][]string, msgPrinter *message.Printer) (int, error) {
 	s := map[string][]persistence.Selector{}
 	if prune {
 		lastUnreg, err := persistence.GetLastUnregistrationTime(db)
@@ -61,7 +60,7 @@ func DeleteEventLogs(db *bolt.DB, prune bool, selections map[string][]string, ms
 	return count, err
 }
 
-func FindSurfaceLogsForOutput(db *bolt.DB, msgPrinter *message.Printer) ([]persistence.SurfaceError, error) {
+func FindSurfaceLogsForOutput(db persistence.AgentDatabase, msgPrinter *message.Printer) ([]persistence.SurfaceError, error) {
 	outputLogs := make([]persistence.SurfaceError, 0)
 	surfaceLogs, err := persistence.FindSurfaceErrors(db)
 	if err != nil {
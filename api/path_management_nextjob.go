@@ -4,11 +4,10 @@ import (
 	"fmt"
 	"github.com/open-horizon/anax/exchangecommon"
 	"github.com/open-horizon/anax/persistence"
-	bolt "go.etcd.io/bbolt"
 	"time"
 )
 
-func FindManagementNextJobForOutput(jobType, ready string, errorHandler ErrorHandler, db *bolt.DB) (bool, map[string]*exchangecommon.NodeManagementPolicyStatus) {
+func FindManagementNextJobForOutput(jobType, ready string, errorHandler ErrorHandler, db persistence.AgentDatabase) (bool, map[string]*exchangecommon.NodeManagementPolicyStatus) {
 	var err error
 	managementStatuses := make(map[string]*exchangecommon.NodeManagementPolicyStatus, 0)
 	returnStatus := make(map[string]*exchangecommon.NodeManagementPolicyStatus, 0)
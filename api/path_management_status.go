@@ -7,11 +7,10 @@ import (
 	"github.com/open-horizon/anax/exchange"
 	"github.com/open-horizon/anax/exchangecommon"
 	"github.com/open-horizon/anax/persistence"
-	bolt "go.etcd.io/bbolt"
 	"strings"
 )
 
-func FindManagementStatusForOutput(nmpName, orgName string, errorHandler ErrorHandler, db *bolt.DB) (bool, map[string]*exchangecommon.NodeManagementPolicyStatus) {
+func FindManagementStatusForOutput(nmpName, orgName string, errorHandler ErrorHandler, db persistence.AgentDatabase) (bool, map[string]*exchangecommon.NodeManagementPolicyStatus) {
 	var err error
 	managementStatuses := make(map[string]*exchangecommon.NodeManagementPolicyStatus, 0)
 
@@ -55,7 +54,7 @@ func UpdateManagementStatus(nmStatus exchangecommon.NodeManagementPolicyStatus,
 	statusHandler exchange.PutNodeManagementPolicyStatusHandler,
 	getDeviceHandler exchange.DeviceHandler,
 	patchDeviceHandler exchange.PatchDeviceHandler,
-	nmpName string, orgName string, db *bolt.DB) (bool, string) {
+	nmpName string, orgName string, db persistence.AgentDatabase) (bool, string) {
 
 	// Find exchange device in DB
 	pDevice, err := persistence.FindExchangeDevice(db)
@@ -101,7 +100,7 @@ func ResetManagementStatus(nmpName string, orgName string, errorHandler ErrorHan
 	statusHandler exchange.PutNodeManagementPolicyStatusHandler,
 	getDeviceHandler exchange.DeviceHandler,
 	patchDeviceHandler exchange.PatchDeviceHandler,
-	db *bolt.DB) (bool, string) {
+	db persistence.AgentDatabase) (bool, string) {
 
 	nmStatus := new(exchangecommon.NodeManagementPolicyStatus)
 	nmStatus.AgentUpgrade = new(exchangecommon.AgentUpgradePolicyStatus)
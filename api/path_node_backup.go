@@ -0,0 +1,110 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/golang/glog"
+	"github.com/open-horizon/anax/config"
+	"github.com/open-horizon/anax/cutil"
+	"github.com/open-horizon/anax/persistence"
+	"github.com/open-horizon/anax/version"
+	"strings"
+)
+
+// The input to restore a node backup. The backup is signed in its compact JSON form, the signature is verified with
+// the public keys in the agent's trust store. The node id and token are optional, when they are set the restored node
+// uses them instead of the id and token in the backup. This is used when the backup is restored as a different node.
+type NodeBackupRestore struct {
+	Backup    json.RawMessage `json:"backup"`
+	Signature string          `json:"signature"`
+	NodeId    string          `json:"nodeId,omitempty"`
+	Token     string          `json:"token,omitempty"`
+}
+
+func (n NodeBackupRestore) String() string {
+	tokenShadow := "unset"
+	if n.Token != "" {
+		tokenShadow = "set"
+	}
+	return fmt.Sprintf("Backup: %v bytes, Signature: %v, NodeId: %v, Token: <%v>", len(n.Backup), n.Signature, n.NodeId, tokenShadow)
+}
+
+// Export the node's state from the local database. The node must be registered.
+func FindNodeBackupForOutput(errorhandler ErrorHandler, db persistence.AgentDatabase) (bool, *persistence.NodeBackup) {
+
+	pDevice, err := persistence.FindExchangeDevice(db)
+	if err != nil {
+		return errorhandler(NewSystemError(fmt.Sprintf("Unable to read node object, error %v", err))), nil
+	} else if pDevice == nil {
+		return errorhandler(NewNotFoundError("Exchange registration not recorded. Only a registered node can be exported.", "node")), nil
+	}
+
+	backup, err := persistence.ExportNodeBackup(db, version.HORIZON_VERSION)
+	if err != nil {
+		return errorhandler(NewSystemError(fmt.Sprintf("Unable to export node state, error %v", err))), nil
+	}
+
+	LogDeviceEvent(db, persistence.SEVERITY_INFO, persistence.NewMessageMeta(EL_API_NODE_BACKUP_EXPORTED, pDevice.Org, pDevice.Id), persistence.EC_NODE_BACKUP_EXPORTED, pDevice)
+	return false, backup
+}
+
+// Restore a node backup into the local database. The node must not be registered, the backup replaces the node's
+// state. The agent has to be restarted to start using the restored state. The secret values are not in the backup, so
+// the agreements whose services use secrets are not restored, the node makes new agreements for them.
+func RestoreNodeBackup(restore *NodeBackupRestore, errorhandler ErrorHandler, db persistence.AgentDatabase, config *config.HorizonConfig) (bool, *persistence.NodeBackup) {
+
+	if pDevice, err := persistence.FindExchangeDevice(db); err != nil {
+		return errorhandler(NewSystemError(fmt.Sprintf("Unable to read node object, error %v", err))), nil
+	} else if pDevice != nil || Unconfiguring {
+		return errorhandler(NewConflictError("The node is registered. Unregister the node before restoring a backup.")), nil
+	}
+
+	if len(restore.Backup) == 0 {
+		return errorhandler(NewAPIUserInputError("The node backup is missing.", "backup")), nil
+	} else if restore.Signature == "" {
+		return errorhandler(NewAPIUserInputError("The node backup is not signed.", "signature")), nil
+	}
+
+	// The backup was signed in its compact form.
+	compact := new(bytes.Buffer)
+	if err := json.Compact(compact, restore.Backup); err != nil {
+		return errorhandler(NewAPIUserInputError(fmt.Sprintf("The node backup is not valid JSON, error: %v", err), "backup")), nil
+	}
+
+	if pemFiles, err := config.Collaborators.KeyFileNamesFetcher.GetKeyFileNames(config.Edge.PublicKeyPath, config.UserPublicKeyPath()); err != nil {
+		return errorhandler(NewSystemError(fmt.Sprintf("Unable to get the public keys to verify the node backup, error %v", err))), nil
+	} else if verified, keyFile, failed := cutil.InputVerifiedByAnyKey(pemFiles, restore.Signature, compact.Bytes()); !verified {
+		glog.Errorf(apiLogString(fmt.Sprintf("Unable to verify the node backup signature: %v", failed)))
+		LogDeviceEvent(db, persistence.SEVERITY_ERROR, persistence.NewMessageMeta(EL_API_ERR_NODE_BACKUP_RESTORE, "The node backup signature cannot be verified."), persistence.EC_ERROR_NODE_BACKUP_RESTORE, nil)
+		return errorhandler(NewAPIUserInputError("The node backup signature cannot be verified with the public keys in the agent's trust store. Import the public key of the user that exported the backup with hzn key import.", "signature")), nil
+	} else {
+		glog.V(3).Infof(apiLogString(fmt.Sprintf("Node backup signature verified with %v", keyFile)))
+	}
+
+	backup := new(persistence.NodeBackup)
+	if err := json.Unmarshal(compact.Bytes(), backup); err != nil {
+		return errorhandler(NewAPIUserInputError(fmt.Sprintf("The node backup could not be deserialized, error: %v", err), "backup")), nil
+	} else if err := backup.Validate(); err != nil {
+		return errorhandler(NewAPIUserInputError(fmt.Sprintf("The node backup cannot be restored, error: %v", err), "backup")), nil
+	} else if err := backup.RewriteIdentity(restore.NodeId, restore.Token); err != nil {
+		return errorhandler(NewAPIUserInputError(fmt.Sprintf("Unable to change the node identity in the backup, error: %v", err), "backup")), nil
+	}
+
+	droppedAgreements, err := backup.DropAgreementsWithSecrets()
+	if err != nil {
+		return errorhandler(NewAPIUserInputError(fmt.Sprintf("Unable to remove the agreements with secrets from the backup, error: %v", err), "backup")), nil
+	}
+
+	if err := persistence.ImportNodeBackup(db, backup); err != nil {
+		LogDeviceEvent(db, persistence.SEVERITY_ERROR, persistence.NewMessageMeta(EL_API_ERR_NODE_BACKUP_RESTORE, err.Error()), persistence.EC_ERROR_NODE_BACKUP_RESTORE, nil)
+		return errorhandler(NewSystemError(fmt.Sprintf("Unable to restore the node backup, error %v", err))), nil
+	}
+
+	pDevice, _ := persistence.FindExchangeDevice(db)
+	if len(droppedAgreements) != 0 {
+		LogDeviceEvent(db, persistence.SEVERITY_WARN, persistence.NewMessageMeta(EL_API_NODE_BACKUP_SECRETS_DROPPED, strings.Join(droppedAgreements, ", ")), persistence.EC_NODE_BACKUP_RESTORED, pDevice)
+	}
+	LogDeviceEvent(db, persistence.SEVERITY_INFO, persistence.NewMessageMeta(EL_API_NODE_BACKUP_RESTORED, backup.Org, backup.NodeId, backup.Created), persistence.EC_NODE_BACKUP_RESTORED, pDevice)
+	return false, backup
+}
@@ -0,0 +1,176 @@
+//go:build unit
+// +build unit
+
+package api
+
+import (
+	"encoding/json"
+	"github.com/open-horizon/anax/config"
+	"github.com/open-horizon/anax/cutil"
+	"github.com/open-horizon/anax/persistence"
+	"os"
+	"path"
+	"testing"
+)
+
+// Export a registered node and restore it on another host with a new id and token.
+func Test_NodeBackup_export_restore(t *testing.T) {
+
+	dir, db, err := utsetup()
+	if err != nil {
+		t.Error(err)
+	}
+	defer cleanTestDir(dir)
+
+	var myError error
+	errorhandler := GetPassThroughErrorHandler(&myError)
+
+	// The backups are signed with a key whose public key is in the trust store of the agent.
+	cfg, sign := getNodeBackupSigningConfig(t, dir)
+
+	// An unregistered node cannot be exported.
+	if errHandled, backup := FindNodeBackupForOutput(errorhandler, db); !errHandled || backup != nil {
+		t.Errorf("export of an unregistered node should fail, returned %v", backup)
+	} else if _, ok := myError.(*NotFoundError); !ok {
+		t.Errorf("wrong error type returned: %T %v", myError, myError)
+	}
+
+	myError = nil
+	if _, err := persistence.SaveNewExchangeDevice(db, "testid", "testtoken", "testname", "device", "myorg", "", persistence.CONFIGSTATE_CONFIGURED, persistence.SoftwareVersion{persistence.AGENT_VERSION: "1.0.0"}); err != nil {
+		t.Errorf("failed to create persisted device, error %v", err)
+	}
+
+	// Agreement ag1 has a secret, ag2 does not.
+	for _, agId := range []string{"ag1", "ag2"} {
+		if _, err := persistence.NewEstablishedAgreement(db, agId, agId, "consumerId", "{}", "Basic", 1, []persistence.ServiceSpec{}, "signature", "address", "", "", "", &persistence.WorkloadInfo{}, 180); err != nil {
+			t.Errorf("failed to create agreement %v, error %v", agId, err)
+		}
+	}
+	secrets := &persistence.PersistedServiceSecrets{MsInstKey: "inst1", SecretsMap: map[string]*persistence.PersistedServiceSecret{"secret1": {SvcSecretName: "secret1", SvcSecretValue: "secretvalue", AgreementIds: []string{"ag1"}}}}
+	if err := persistence.SaveAllSecretsForService(db, "inst1", secrets); err != nil {
+		t.Errorf("failed to save secrets, error %v", err)
+	}
+
+	errHandled, backup := FindNodeBackupForOutput(errorhandler, db)
+	if errHandled || myError != nil {
+		t.Errorf("unexpected error exporting node: %v", myError)
+	} else if backup.Org != "myorg" || backup.NodeId != "testid" || backup.Version != persistence.NODE_BACKUP_VERSION {
+		t.Errorf("wrong node backup: %v", backup)
+	}
+
+	// The backup cannot be restored on a registered node.
+	if errHandled, _ := RestoreNodeBackup(sign(backup, "", ""), errorhandler, db, cfg); !errHandled {
+		t.Errorf("restore on a registered node should fail")
+	} else if _, ok := myError.(*ConflictError); !ok {
+		t.Errorf("wrong error type returned: %T %v", myError, myError)
+	}
+
+	dir2, db2, err := utsetup()
+	if err != nil {
+		t.Error(err)
+	}
+	defer cleanTestDir(dir2)
+
+	// A backup that is not signed, or that was changed after it was signed, is rejected.
+	myError = nil
+	unsigned := sign(backup, "newid", "newtoken")
+	unsigned.Signature = ""
+	if errHandled, _ := RestoreNodeBackup(unsigned, errorhandler, db2, cfg); !errHandled {
+		t.Errorf("restore of an unsigned backup should fail")
+	} else if _, ok := myError.(*APIUserInputError); !ok {
+		t.Errorf("wrong error type returned: %T %v", myError, myError)
+	}
+
+	myError = nil
+	tampered := sign(backup, "newid", "newtoken")
+	backup.AnaxVersion = "9.9.9"
+	tampered.Backup, _ = json.Marshal(backup)
+	if errHandled, _ := RestoreNodeBackup(tampered, errorhandler, db2, cfg); !errHandled {
+		t.Errorf("restore of a backup with an invalid signature should fail")
+	} else if _, ok := myError.(*APIUserInputError); !ok {
+		t.Errorf("wrong error type returned: %T %v", myError, myError)
+	} else if dev, err := persistence.FindExchangeDevice(db2); err != nil || dev != nil {
+		t.Errorf("a backup with an invalid signature should not be restored: %v, error %v", dev, err)
+	}
+
+	myError = nil
+	if errHandled, _ := RestoreNodeBackup(sign(backup, "newid", "newtoken"), errorhandler, db2, cfg); errHandled {
+		t.Errorf("unexpected error restoring node: %v", myError)
+	} else if dev, err := persistence.FindExchangeDevice(db2); err != nil || dev == nil {
+		t.Errorf("restored node not found, error %v", err)
+	} else if dev.Id != "newid" || dev.Token != "newtoken" || dev.Org != "myorg" || dev.Config.State != persistence.CONFIGSTATE_CONFIGURED {
+		t.Errorf("wrong restored node: %v", dev)
+	}
+
+	// The agreements and secrets belong to the old node id, they are not restored under the new id.
+	if secrets, err := persistence.FindAllSecretsForMS(db2, "inst1"); err != nil || secrets != nil {
+		t.Errorf("secrets should not be restored under a new node id: %v, error %v", secrets, err)
+	} else if ags, err := persistence.FindEstablishedAgreementsAllProtocols(db2, []string{"Basic"}, []persistence.EAFilter{}); err != nil || len(ags) != 0 {
+		t.Errorf("agreements should not be restored under a new node id: %v, error %v", ags, err)
+	}
+
+	dir3, db3, err := utsetup()
+	if err != nil {
+		t.Error(err)
+	}
+	defer cleanTestDir(dir3)
+
+	// Restoring with only a new token keeps the agreements, except the ones with secrets because the secret values are
+	// not in the backup.
+	myError = nil
+	if errHandled, backup = FindNodeBackupForOutput(errorhandler, db); errHandled {
+		t.Errorf("unexpected error exporting node: %v", myError)
+	} else if errHandled, _ := RestoreNodeBackup(sign(backup, "", "newtoken"), errorhandler, db3, cfg); errHandled {
+		t.Errorf("unexpected error restoring node: %v", myError)
+	} else if dev, err := persistence.FindExchangeDevice(db3); err != nil || dev == nil || dev.Id != "testid" || dev.Token != "newtoken" {
+		t.Errorf("wrong restored node: %v, error %v", dev, err)
+	}
+
+	if ags, err := persistence.FindEstablishedAgreementsAllProtocols(db3, []string{"Basic"}, []persistence.EAFilter{}); err != nil || len(ags) != 1 || ags[0].CurrentAgreementId != "ag2" {
+		t.Errorf("only agreement ag2 should be restored: %v, error %v", ags, err)
+	} else if secrets, err := persistence.FindAllSecretsForMS(db3, "inst1"); err != nil || secrets != nil {
+		t.Errorf("secrets without their values should not be restored: %v, error %v", secrets, err)
+	}
+
+	// A backup from a newer agent is rejected.
+	backup.Version = persistence.NODE_BACKUP_VERSION + 1
+	if err := backup.Validate(); err == nil {
+		t.Errorf("restore of an unsupported backup version should fail")
+	}
+}
+
+// Returns an agent config whose trust store has the public key of a new signing key, and a function that signs a
+// backup with the private key the way hzn node export does.
+func getNodeBackupSigningConfig(t *testing.T, dir string) (*config.HorizonConfig, func(*persistence.NodeBackup, string, string) *NodeBackupRestore) {
+
+	key, err := cutil.GenerateSigningKey(cutil.SIGNING_ALGO_ECDSA, 0)
+	if err != nil {
+		t.Fatalf("failed to generate signing key, error %v", err)
+	}
+	pubKeyFile := path.Join(dir, "backup-signer.pem")
+	if pubKey, err := cutil.MarshalPublicKeyPEM(key.Public()); err != nil {
+		t.Fatalf("failed to marshal public key, error %v", err)
+	} else if err := os.WriteFile(pubKeyFile, pubKey, 0600); err != nil {
+		t.Fatalf("failed to write public key, error %v", err)
+	}
+
+	cfg := getBasicConfig()
+	cfg.Collaborators.KeyFileNamesFetcher = &config.KeyFileNamesFetcher{
+		GetKeyFileNames: func(publicKeyPath, userKeyPath string) ([]string, error) {
+			return []string{pubKeyFile}, nil
+		},
+	}
+
+	sign := func(backup *persistence.NodeBackup, nodeId string, token string) *NodeBackupRestore {
+		backupBytes, err := json.Marshal(backup)
+		if err != nil {
+			t.Fatalf("failed to marshal node backup, error %v", err)
+		}
+		signature, err := cutil.SignInput(key, backupBytes)
+		if err != nil {
+			t.Fatalf("failed to sign node backup, error %v", err)
+		}
+		return &NodeBackupRestore{Backup: backupBytes, Signature: signature, NodeId: nodeId, Token: token}
+	}
+	return cfg, sign
+}
@@ -9,11 +9,10 @@ import (
 	"github.com/open-horizon/anax/exchangesync"
 	"github.com/open-horizon/anax/externalpolicy"
 	"github.com/open-horizon/anax/persistence"
-	bolt "go.etcd.io/bbolt"
 )
 
 // Return an empty policy object or the object that's in the local database.
-func FindNodePolicyForOutput(db *bolt.DB) (*exchangecommon.NodePolicy, error) {
+func FindNodePolicyForOutput(db persistence.AgentDatabase) (*exchangecommon.NodePolicy, error) {
 
 	if extPolicy, err := persistence.FindNodePolicy(db); err != nil {
 		return nil, errors.New(fmt.Sprintf("unable to read node policy object, error %v", err))
@@ -29,7 +28,7 @@ func UpdateNodePolicy(nodePolicy *exchangecommon.NodePolicy,
 	errorhandler DeviceErrorHandler,
 	nodeGetPolicyHandler exchange.NodePolicyHandler,
 	nodePutPolicyHandler exchange.PutNodePolicyHandler,
-	db *bolt.DB) (bool, *exchangecommon.NodePolicy, []*events.NodePolicyMessage) {
+	db persistence.AgentDatabase) (bool, *exchangecommon.NodePolicy, []*events.NodePolicyMessage) {
 
 	// Check for the device in the local database. If there are errors, they will be written
 	// to the HTTP response.
@@ -55,7 +54,7 @@ func PatchNodePolicy(attributeName string, patchObject interface{},
 	errorhandler DeviceErrorHandler,
 	nodeGetPolicyHandler exchange.NodePolicyHandler,
 	nodePatchPolicyHandler exchange.PutNodePolicyHandler,
-	db *bolt.DB) (bool, *exchangecommon.NodePolicy, []*events.NodePolicyMessage) {
+	db persistence.AgentDatabase) (bool, *exchangecommon.NodePolicy, []*events.NodePolicyMessage) {
 
 	pDevice, err := persistence.FindExchangeDevice(db)
 	if err != nil {
@@ -76,7 +75,7 @@ func PatchNodePolicy(attributeName string, patchObject interface{},
 }
 
 // Delete the node policy object.
-func DeleteNodePolicy(errorhandler DeviceErrorHandler, db *bolt.DB,
+func DeleteNodePolicy(errorhandler DeviceErrorHandler, db persistence.AgentDatabase,
 	nodeGetPolicyHandler exchange.NodePolicyHandler,
 	nodeDeletePolicyHandler exchange.DeleteNodePolicyHandler) (bool, []*events.NodePolicyMessage) {
 
@@ -12,7 +12,8 @@ import (
 	"testing"
 )
 
-const NUM_BUILT_INS = 7
+const NUM_BUILT_INS = 8
+const CLUSTER_NUM_BUILT_INS = 6
 
 func init() {
 	flag.Set("alsologtostderr", "true")
@@ -169,8 +170,8 @@ func Test_UpdateNodePolicy1(t *testing.T) {
 		t.Errorf("no node policy returned")
 	} else if fnp, err := FindNodePolicyForOutput(db); err != nil {
 		t.Errorf("failed to find node policy in db, error %v", err)
-	} else if len(fnp.Properties) != len(*propList)+NUM_BUILT_INS-1 {
-		t.Errorf("incorrect node policy, there should be %v property defined, found: %v", NUM_BUILT_INS, *fnp)
+	} else if len(fnp.Properties) != len(*propList)+CLUSTER_NUM_BUILT_INS {
+		t.Errorf("incorrect node policy, there should be %v property defined, found: %v", len(*propList)+CLUSTER_NUM_BUILT_INS, *fnp)
 	} else if fnp.Properties[0].Name != propName {
 		t.Errorf("expected property %v, but received %v", propName, fnp.Properties[0].Name)
 	} else if len(msgs) != 1 {
@@ -194,8 +195,8 @@ func Test_UpdateNodePolicy1(t *testing.T) {
 		t.Errorf("no node policy returned")
 	} else if fnp, err := FindNodePolicyForOutput(db); err != nil {
 		t.Errorf("failed to find node policy in db, error %v", err)
-	} else if len(fnp.Properties) != len(*propList)+NUM_BUILT_INS-1 {
-		t.Errorf("incorrect node policy, there should be %v property defined, found: %v", NUM_BUILT_INS, *fnp)
+	} else if len(fnp.Properties) != len(*propList)+CLUSTER_NUM_BUILT_INS {
+		t.Errorf("incorrect node policy, there should be %v property defined, found: %v", len(*propList)+CLUSTER_NUM_BUILT_INS, *fnp)
 	} else if fnp.Properties[0].Name != propName {
 		t.Errorf("expected property %v, but received %v", propName, fnp.Properties[0].Name)
 	} else if len(msgs) != 1 {
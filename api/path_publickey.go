@@ -1,6 +1,9 @@
 package api
 
 import (
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
 	"errors"
 	"fmt"
 	"os"
@@ -11,8 +14,10 @@ import (
 	"github.com/golang/glog"
 
 	"github.com/open-horizon/anax/config"
+	"github.com/open-horizon/anax/cutil"
+	"github.com/open-horizon/anax/imagefetch"
 	"github.com/open-horizon/rsapss-tool/listkeys"
-	"github.com/open-horizon/rsapss-tool/verify"
+	"github.com/open-horizon/rsapss-tool/utility"
 )
 
 func FindPublicKeyForOutput(fileName string, config *config.HorizonConfig) (string, error) {
@@ -57,15 +62,7 @@ func FindPublicKeysForOutput(config *config.HorizonConfig, verbose bool) (map[st
 		var value interface{}
 		if verbose {
 			keyPath := path.Join(pubKeyDir, pf.Name())
-			keyPair, err := listkeys.ReadKeyPair(keyPath)
-
-			if err != nil {
-				glog.Errorf("Error reading user x509 cert from file path: %v. Error: %v", keyPath, err)
-				continue
-			}
-
-			// right now, verbose entails including raw
-			kp, err := keyPair.ToKeyPairSimple(true)
+			kp, err := readKeyPairSimple(keyPath)
 			if err != nil {
 				glog.Errorf("Error reading user x509 cert from file path: %v. Error: %v", keyPath, err)
 				continue
@@ -109,7 +106,7 @@ func UploadPublicKey(filename string,
 	// uploaded file is specified on the HTTP PUT. It does not have to have the same file name used
 	// by the HTTP caller.
 
-	if _, err := verify.ValidKeyOrCert(inBytes); err != nil {
+	if err := validTrustFile(inBytes); err != nil {
 		return errorhandler(NewAPIUserInputError(fmt.Sprintf("provided public key or cert is not valid; error: %v", err), "trusted cert file"))
 	} else if err := os.MkdirAll(targetPath, 0644); err != nil {
 		return errorhandler(NewSystemError(fmt.Sprintf("unable to create trusted cert directory %v, error: %v", targetPath, err)))
@@ -178,7 +175,7 @@ func getPemFiles(homePath string) ([]os.FileInfo, error) {
 			fName := homePath + "/" + fileInfo.Name()
 			if pubKeyData, err := os.ReadFile(fName); err != nil {
 				continue
-			} else if _, err := verify.ValidKeyOrCert(pubKeyData); err != nil {
+			} else if err := validTrustFile(pubKeyData); err != nil {
 				continue
 			} else {
 				res = append(res, fileInfoAsFileInfo)
@@ -187,3 +184,54 @@ func getPemFiles(homePath string) ([]os.FileInfo, error) {
 	}
 	return res, nil
 }
+
+// A trust store file is either an RSA, ECDSA or Ed25519 public key or x509 cert that verifies deployment signatures, or a
+// public key or x509 cert that verifies container image signatures.
+func validTrustFile(data []byte) error {
+	if _, err := cutil.ValidPublicKeyOrCert(data); err == nil {
+		return nil
+	} else if _, _, imgErr := imagefetch.ParseTrustPEM(data); imgErr == nil {
+		return nil
+	} else {
+		return err
+	}
+}
+
+// Read the x509 cert in a trust store file for the verbose output. The certs of RSA keys are read like they always were,
+// along with their private keys. The ECDSA and Ed25519 certs do not include the raw key pair.
+func readKeyPairSimple(keyPath string) (*listkeys.KeyPairSimple, error) {
+	certBytes, err := os.ReadFile(keyPath)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(certBytes)
+	if block == nil {
+		return nil, fmt.Errorf("unable to find PEM block in the provided cert: %v", keyPath)
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, ok := cert.PublicKey.(*rsa.PublicKey); ok {
+		keyPair, err := listkeys.ReadKeyPair(keyPath)
+		if err != nil {
+			return nil, err
+		}
+		// right now, verbose entails including raw
+		return keyPair.ToKeyPairSimple(true)
+	}
+
+	pubKey, err := cutil.MarshalPublicKeyPEM(cert.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+	return &listkeys.KeyPairSimple{
+		Type:           "KeyPairSimple",
+		SerialNumber:   utility.SerialOctet(cert.SerialNumber),
+		SubjectNames:   utility.SimpleSubjectNames(cert.Subject.Names),
+		NotValidBefore: cert.NotBefore,
+		NotValidAfter:  cert.NotAfter,
+		PublicKey:      string(pubKey),
+	}, nil
+}
@@ -8,7 +8,6 @@ import (
 	"github.com/open-horizon/anax/container"
 	"github.com/open-horizon/anax/persistence"
 	"github.com/open-horizon/anax/policy"
-	bolt "go.etcd.io/bbolt"
 	"sort"
 )
 
@@ -17,7 +16,7 @@ import (
 // userInput variable config for each service, running containers for each service,
 // and the state of each service as it is being managed by anax.
 func FindServicesForOutput(pm *policy.PolicyManager,
-	db *bolt.DB,
+	db persistence.AgentDatabase,
 	config *config.HorizonConfig) (*AllServices, error) {
 
 	// Get all the service instances from database.
@@ -8,11 +8,10 @@ import (
 	"github.com/open-horizon/anax/exchange"
 	"github.com/open-horizon/anax/persistence"
 	"github.com/open-horizon/anax/semanticversion"
-	bolt "go.etcd.io/bbolt"
 )
 
 // get the service configuration state for all the registered services.
-func FindServiceConfigStateForOutput(errorhandler ErrorHandler, getServicesConfigState exchange.ServicesConfigStateHandler, db *bolt.DB) (bool, map[string][]exchange.ServiceConfigState) {
+func FindServiceConfigStateForOutput(errorhandler ErrorHandler, getServicesConfigState exchange.ServicesConfigStateHandler, db persistence.AgentDatabase) (bool, map[string][]exchange.ServiceConfigState) {
 
 	// Check for the device in the local database. If there are errors, they will be written
 	// to the HTTP response.
@@ -42,7 +41,7 @@ func ChangeServiceConfigState(service_cs *exchange.ServiceConfigState,
 	errorhandler ErrorHandler,
 	getDevice exchange.DeviceHandler,
 	postDeviceSCS exchange.PostDeviceServicesConfigStateHandler,
-	db *bolt.DB) (bool, []events.ServiceConfigState) {
+	db persistence.AgentDatabase) (bool, []events.ServiceConfigState) {
 
 	// Check for the device in the local database. If there are errors, they will be written
 	// to the HTTP response.
@@ -80,6 +80,10 @@ func NewBAgreementVerifyReply(bp *abstractprotocol.BaseProtocolMessage, exists b
 const MsgUpdateTypeSecret = "basicagreementupdatesecret"
 const MsgUpdateTypePolicyChange = "basicagreementtupdatepolicychange"
 
+// Asks the producer to go back to the previous version of the secrets named in the metadata, after a staged
+// secret rollout was halted.
+const MsgUpdateTypeSecretRevert = "basicagreementupdatesecretrevert"
+
 type BAgreementUpdate struct {
 	*abstractprotocol.BaseProtocolMessage
 	Updatetype string      `json:"updateType"`
@@ -109,6 +113,10 @@ func (b *BAgreementUpdate) IsPolicyChangeUpdate() bool {
 	return b.Updatetype == MsgUpdateTypePolicyChange
 }
 
+func (b *BAgreementUpdate) IsSecretRevert() bool {
+	return b.Updatetype == MsgUpdateTypeSecretRevert
+}
+
 func (b *BAgreementUpdate) UpdateType() string {
 	return b.Updatetype
 }
@@ -148,6 +156,10 @@ func (b *BAgreementUpdateReply) IsPolicyChangeUpdate() bool {
 	return b.Updatetype == MsgUpdateTypePolicyChange
 }
 
+func (b *BAgreementUpdateReply) IsSecretRevert() bool {
+	return b.Updatetype == MsgUpdateTypeSecretRevert
+}
+
 func (b *BAgreementUpdateReply) IsAccepted() bool {
 	return b.Accepted
 }
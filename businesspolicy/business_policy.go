@@ -43,16 +43,18 @@ type ServiceRef struct {
 	ClusterNamespace string           `json:"clusterNamespace,omitempty"` // the namespace ths service will be deployed to.
 	ServiceVersions  []WorkloadChoice `json:"serviceVersions,omitempty"`  // a list of service version for rollback
 	NodeH            NodeHealth       `json:"nodeHealth"`                 // policy for determining when a node's health is violating its agreements
+	Rollout          *RolloutPolicy   `json:"rollout,omitempty"`          // how a new service version is rolled out to the nodes that run the service
 }
 
 func (w ServiceRef) String() string {
-	return fmt.Sprintf("Name: %v, Org: %v, Arch: %v, ClusterNamespace: %v, ServiceVersions: %v, NodeH: %v",
+	return fmt.Sprintf("Name: %v, Org: %v, Arch: %v, ClusterNamespace: %v, ServiceVersions: %v, NodeH: %v, Rollout: %v",
 		w.Name,
 		w.Org,
 		w.Arch,
 		w.ClusterNamespace,
 		w.ServiceVersions,
-		w.NodeH)
+		w.NodeH,
+		w.Rollout)
 }
 
 func (w ServiceRef) Validate() error {
@@ -72,6 +74,10 @@ func (w ServiceRef) Validate() error {
 			}
 		}
 	}
+
+	if w.Rollout != nil {
+		return w.Rollout.Validate()
+	}
 	return nil
 
 }
@@ -115,6 +121,39 @@ func (w WorkloadChoice) String() string {
 		w.Upgrade)
 }
 
+type RolloutPolicy struct {
+	BatchSize      int `json:"batch_size,omitempty"`     // The number of nodes moved to the new version in each wave
+	BatchPercent   int `json:"batch_percent,omitempty"`  // The percentage of the nodes moved to the new version in each wave
+	BakeTimeS      int `json:"bake_time,omitempty"`      // The number of seconds a wave has to stay healthy before the next wave starts
+	MaxFailures    int `json:"max_failures,omitempty"`   // The number of nodes in a wave that can fail before the rollout is halted
+	HealthTimeoutS int `json:"health_timeout,omitempty"` // The number of seconds a node in a wave has to get a healthy agreement for the new version
+}
+
+func (w RolloutPolicy) String() string {
+	return fmt.Sprintf("BatchSize: %v, BatchPercent: %v, BakeTimeS: %v, MaxFailures: %v, HealthTimeoutS: %v",
+		w.BatchSize,
+		w.BatchPercent,
+		w.BakeTimeS,
+		w.MaxFailures,
+		w.HealthTimeoutS)
+}
+
+func (w RolloutPolicy) Validate() error {
+	// get message printer
+	msgPrinter := i18n.GetMessagePrinter()
+
+	if w.BatchSize < 0 || w.BakeTimeS < 0 || w.MaxFailures < 0 || w.HealthTimeoutS < 0 {
+		return fmt.Errorf("%s", msgPrinter.Sprintf("batch_size, bake_time, max_failures and health_timeout in the rollout cannot be negative"))
+	} else if w.BatchPercent < 0 || w.BatchPercent > 100 {
+		return fmt.Errorf("%s", msgPrinter.Sprintf("batch_percent in the rollout must be between 1 and 100"))
+	} else if w.BatchSize != 0 && w.BatchPercent != 0 {
+		return fmt.Errorf("%s", msgPrinter.Sprintf("only one of batch_size and batch_percent can be set in the rollout"))
+	} else if w.BatchSize == 0 && w.BatchPercent == 0 {
+		return fmt.Errorf("%s", msgPrinter.Sprintf("one of batch_size and batch_percent must be set in the rollout"))
+	}
+	return nil
+}
+
 type NodeHealth struct {
 	MissingHBInterval    int `json:"missing_heartbeat_interval,omitempty"` // How long a heartbeat can be missing until it is considered missing (in seconds)
 	CheckAgreementStatus int `json:"check_agreement_status,omitempty"`     // How often to check that the node agreement entry still exists in the exchange (in seconds)
@@ -216,6 +255,9 @@ func (b *BusinessPolicy) GenPolicyFromBusinessPolicy(policyName string) (*policy
 	// node health
 	ConvertNodeHealth(service.NodeH, pol)
 
+	// service version rollout
+	ConvertRollout(service.Rollout, pol)
+
 	pol.MaxAgreements = DEFAULT_MAX_AGREEMENT
 
 	// add default agreement protocol
@@ -253,6 +295,18 @@ func ConvertNodeHealth(nodeh NodeHealth, pol *policy.Policy) {
 	pol.Add_NodeHealth(nh)
 }
 
+func ConvertRollout(rollout *RolloutPolicy, pol *policy.Policy) {
+	if rollout != nil {
+		pol.Rollout = &policy.RolloutPolicy{
+			BatchSize:      rollout.BatchSize,
+			BatchPercent:   rollout.BatchPercent,
+			BakeTimeS:      rollout.BakeTimeS,
+			MaxFailures:    rollout.MaxFailures,
+			HealthTimeoutS: rollout.HealthTimeoutS,
+		}
+	}
+}
+
 func ConvertProperties(properties externalpolicy.PropertyList, pol *policy.Policy) error {
 	for _, p := range properties {
 		if err := pol.Add_Property(&p, false); err != nil {
@@ -321,3 +321,44 @@ func Test_GenPolicyFromBusinessPolicy_Complicated(t *testing.T) {
 		t.Errorf("Second user input variable value for service cpu should be val2 but got %v.", pPolicy.UserInput[0].Inputs[1].Value)
 	}
 }
+
+func Test_Validate_Rollout(t *testing.T) {
+
+	service := ServiceRef{
+		Name:            "cpu",
+		Org:             "mycomp",
+		Arch:            "amd64",
+		ServiceVersions: []WorkloadChoice{{Version: "1.0.0"}},
+	}
+
+	bPolicy := BusinessPolicy{
+		Owner:   "me",
+		Label:   "my business policy",
+		Service: service,
+	}
+
+	for _, test := range []struct {
+		rollout RolloutPolicy
+		err     string
+	}{
+		{RolloutPolicy{}, "one of batch_size and batch_percent must be set"},
+		{RolloutPolicy{BatchSize: 2, BatchPercent: 10}, "only one of batch_size and batch_percent"},
+		{RolloutPolicy{BatchPercent: 101}, "between 1 and 100"},
+		{RolloutPolicy{BatchSize: 2, MaxFailures: -1}, "cannot be negative"},
+		{RolloutPolicy{BatchPercent: 10, BakeTimeS: 600, MaxFailures: 1}, ""},
+	} {
+		rollout := test.rollout
+		bPolicy.Service.Rollout = &rollout
+		if err := bPolicy.Validate(); test.err == "" && err != nil {
+			t.Errorf("Validate should not have returned error for rollout %v but got: %v", rollout, err)
+		} else if test.err != "" && (err == nil || !strings.Contains(err.Error(), test.err)) {
+			t.Errorf("Validate should have returned error %v for rollout %v but got: %v", test.err, rollout, err)
+		}
+	}
+
+	if pPolicy, err := bPolicy.GenPolicyFromBusinessPolicy("mycomp/mypolicy"); err != nil {
+		t.Errorf("GenPolicyFromBusinessPolicy should have not have returned error but got: %v", err)
+	} else if pPolicy.Rollout == nil || pPolicy.Rollout.BatchPercent != 10 || pPolicy.Rollout.BakeTimeS != 600 || pPolicy.Rollout.MaxFailures != 1 {
+		t.Errorf("the rollout was not converted into the policy: %v", pPolicy.Rollout)
+	}
+}
@@ -12,7 +12,6 @@ import (
 	"github.com/open-horizon/anax/policy"
 	"github.com/open-horizon/anax/version"
 	"github.com/open-horizon/anax/worker"
-	bolt "go.etcd.io/bbolt"
 	"strings"
 	"time"
 )
@@ -32,7 +31,7 @@ const (
 
 type ChangesWorker struct {
 	worker.BaseWorker      // embedded field
-	db                     *bolt.DB
+	db                     persistence.AgentDatabase
 	pollInterval           int    // The current change polling interval. This interval will float between Min and Max intervals.
 	pollHBRestoredInterval int    // When the node heartbeat fails, this will be used to store the poll interval to return to once the heartbeat is restored
 	pollMinInterval        int    // The minimum time to wait between polls to the exchange.
@@ -47,7 +46,7 @@ type ChangesWorker struct {
 	noworkDispatch         int64  // The last time the NoWorkHandler was dispatched.
 }
 
-func NewChangesWorker(name string, cfg *config.HorizonConfig, db *bolt.DB) *ChangesWorker {
+func NewChangesWorker(name string, cfg *config.HorizonConfig, db persistence.AgentDatabase) *ChangesWorker {
 
 	var ec *worker.BaseExchangeContext
 	dev, _ := persistence.FindExchangeDevice(db)
@@ -70,6 +69,7 @@ func NewChangesWorker(name string, cfg *config.HorizonConfig, db *bolt.DB) *Chan
 		heartBeatFailed:        false,
 		noworkDispatch:         time.Now().Unix(),
 	}
+	PollIntervalSeconds.Set(float64(worker.pollInterval))
 
 	// Initialize the change state tracking from the local DB.
 	chgState, err := persistence.FindExchangeChangeState(db)
@@ -364,6 +364,7 @@ func (w *ChangesWorker) postProcessChanges(changes *exchange.ExchangeChanges, in
 func (w *ChangesWorker) handleHeartbeatStateAndError(changes *exchange.ExchangeChanges, err error) bool {
 	if err != nil {
 		glog.Errorf(chglog(fmt.Sprintf("heartbeat and change retrieval failed, error %v", err)))
+		HeartbeatsTotal.WithLabelValues(HEARTBEAT_FAILURE).Inc()
 
 		if strings.Contains(err.Error(), "status: 401") {
 			// If the heartbeat fails because the node entry is gone then initiate a full node quiesce.
@@ -393,6 +394,7 @@ func (w *ChangesWorker) handleHeartbeatStateAndError(changes *exchange.ExchangeC
 	} else {
 		// Record the last good heartbeat
 		w.lastHeartbeat = time.Now().Unix()
+		HeartbeatsTotal.WithLabelValues(HEARTBEAT_SUCCESS).Inc()
 
 		if w.pollHBRestoredInterval != 0 {
 			w.updatePollingInterval(UPDATE_TYPE_HB_RESTORED)
@@ -437,6 +439,9 @@ func (w *ChangesWorker) handleHeartbeatStateAndError(changes *exchange.ExchangeC
 
 func (w *ChangesWorker) updatePollingInterval(updateType string) {
 
+	// Whichever way the interval changes, publish the new value.
+	defer func() { PollIntervalSeconds.Set(float64(w.pollInterval)) }()
+
 	if updateType == UPDATE_TYPE_RESET {
 		// set the polling interval to minimal. This is the case where agreement negotiation started when the node needs to
 		// watch the upcoming messages more closely.
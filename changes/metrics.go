@@ -0,0 +1,37 @@
+package changes
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// The metrics of the changes worker, exposed by the agent /metrics API. The node heartbeat is the call to the
+// exchange /changes API, so each poll for changes counts as a heartbeat.
+
+const (
+	HEARTBEAT_SUCCESS = "success"
+	HEARTBEAT_FAILURE = "failure"
+)
+
+var HeartbeatsTotal = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Namespace: "horizon",
+		Subsystem: "agent",
+		Name:      "exchange_heartbeats_total",
+		Help:      "Number of node heartbeats to the exchange, by result.",
+	},
+	[]string{"result"},
+)
+
+var PollIntervalSeconds = prometheus.NewGauge(
+	prometheus.GaugeOpts{
+		Namespace: "horizon",
+		Subsystem: "agent",
+		Name:      "exchange_poll_interval_seconds",
+		Help:      "The current interval between polls to the exchange for changes.",
+	},
+)
+
+func init() {
+	HeartbeatsTotal.WithLabelValues(HEARTBEAT_SUCCESS)
+	HeartbeatsTotal.WithLabelValues(HEARTBEAT_FAILURE)
+}
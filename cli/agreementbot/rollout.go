@@ -0,0 +1,73 @@
+package agreementbot
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/open-horizon/anax/agreementbot/persistence"
+	"github.com/open-horizon/anax/cli/cliutils"
+	"github.com/open-horizon/anax/i18n"
+	"net/http"
+	"os"
+)
+
+// List the service version rollouts of the deployment policies, or the rollout of one policy.
+func RolloutList(org string, name string) {
+	// get message printer
+	msgPrinter := i18n.GetMessagePrinter()
+
+	// set env to call agbot url
+	if err := os.Setenv("HORIZON_URL", cliutils.GetAgbotUrlBase()); err != nil {
+		cliutils.Fatal(cliutils.CLI_GENERAL_ERROR, msgPrinter.Sprintf("unable to set env var 'HORIZON_URL', error %v", err))
+	}
+
+	var output interface{}
+	if org == "" && name == "" {
+		rollouts := make([]persistence.ServiceRollout, 0)
+		cliutils.HorizonGet("rollout", []int{200}, &rollouts, false)
+		output = rollouts
+	} else if org == "" || name == "" {
+		cliutils.Fatal(cliutils.CLI_INPUT_ERROR, msgPrinter.Sprintf("both the organization and the name of the deployment policy must be specified."))
+	} else {
+		var rollout persistence.ServiceRollout
+		if httpCode, _ := cliutils.HorizonGet(fmt.Sprintf("rollout/%v/%v", org, name), []int{200, 404}, &rollout, false); httpCode == 404 {
+			cliutils.Fatal(cliutils.CLI_INPUT_ERROR, msgPrinter.Sprintf("deployment policy %v/%v is not being rolled out by this agbot.", org, name))
+		}
+		output = rollout
+	}
+
+	jsonBytes, err := json.MarshalIndent(output, "", cliutils.JSON_INDENT)
+	if err != nil {
+		cliutils.Fatal(cliutils.JSON_PARSING_ERROR, msgPrinter.Sprintf("failed to marshal 'agbot rollout list' output: %v", err))
+	}
+	fmt.Printf("%s\n", jsonBytes)
+}
+
+// Pause, resume or abort the service version rollout of a deployment policy.
+func RolloutAction(org string, name string, action string) {
+	// get message printer
+	msgPrinter := i18n.GetMessagePrinter()
+
+	// set env to call agbot url
+	if err := os.Setenv("HORIZON_URL", cliutils.GetAgbotUrlBase()); err != nil {
+		cliutils.Fatal(cliutils.CLI_GENERAL_ERROR, msgPrinter.Sprintf("unable to set env var 'HORIZON_URL', error %v", err))
+	}
+
+	httpCode, respBody, _ := cliutils.HorizonPutPost(http.MethodPost, fmt.Sprintf("rollout/%v/%v/%v", org, name, action), []int{200, 400, 404}, nil, true)
+	if httpCode == 404 {
+		cliutils.Fatal(cliutils.CLI_INPUT_ERROR, msgPrinter.Sprintf("deployment policy %v/%v is not being rolled out by this agbot.", org, name))
+	} else if httpCode == 400 {
+		cliutils.Fatal(cliutils.CLI_INPUT_ERROR, msgPrinter.Sprintf("unable to %v the rollout of deployment policy %v/%v: %v", action, org, name, respBody))
+	}
+
+	msgPrinter.Printf("The service rollout of deployment policy %v/%v is now %v.", org, name, rolloutState(respBody))
+	msgPrinter.Println()
+}
+
+// Returns the state from the rollout returned by the agbot.
+func rolloutState(respBody string) string {
+	var rollout persistence.ServiceRollout
+	if err := json.Unmarshal([]byte(respBody), &rollout); err != nil {
+		return "unknown"
+	}
+	return rollout.State
+}
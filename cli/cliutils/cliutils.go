@@ -3,13 +3,11 @@ package cliutils
 import (
 	"bufio"
 	"bytes"
-	"crypto/rand"
-	"crypto/rsa"
+	"crypto"
 	"crypto/tls"
 	"crypto/x509"
 	"encoding/base64"
 	"encoding/json"
-	"encoding/pem"
 	"errors"
 	"fmt"
 	"io"
@@ -31,8 +29,6 @@ import (
 	"github.com/open-horizon/anax/cutil"
 	"github.com/open-horizon/anax/exchange"
 	"github.com/open-horizon/anax/i18n"
-	"github.com/open-horizon/rsapss-tool/sign"
-	"github.com/open-horizon/rsapss-tool/verify"
 	"golang.org/x/text/language"
 )
 
@@ -1729,20 +1725,20 @@ func GetAndVerifyPublicKey(pubKeyFilePath string) string {
 	pubKeyFilePath_tmp := WithDefaultEnvVar(&pubKeyFilePath, "HZN_PUBLIC_KEY_FILE")
 	pubKeyFilePath = VerifySigningKeyInput(*pubKeyFilePath_tmp, true)
 	inBytes := ReadFile(pubKeyFilePath)
-	if _, err := verify.ValidKeyOrCert(inBytes); err != nil {
+	if _, err := cutil.ValidPublicKeyOrCert(inBytes); err != nil {
 		Fatal(CLI_INPUT_ERROR, msgPrinter.Sprintf("provided public key is not valid; error: %v", err))
 	}
 	return pubKeyFilePath
 }
 
-func getPrivateKeyFromFile(keyFile string) *rsa.PrivateKey {
+func getPrivateKeyFromFile(keyFile string) crypto.Signer {
 	msgPrinter := i18n.GetMessagePrinter()
 	msgPrinter.Printf("Checking private key file format ... ")
 	msgPrinter.Println()
 
-	var privKey *rsa.PrivateKey
+	var privKey crypto.Signer
 	var err error
-	if privKey, err = sign.ReadPrivateKey(keyFile); err != nil {
+	if privKey, err = GetSigner(keyFile); err != nil {
 		Fatal(CLI_INPUT_ERROR, msgPrinter.Sprintf("provided private key %v is not valid; error: %v", keyFile, err))
 	}
 
@@ -1778,8 +1774,12 @@ func GetDeprecatedDefaultSigningKeyFile(isPublic bool) (string, error) {
 	}
 }
 
-// Gets default keys if not set, verify key files exist.
+// Gets default keys if not set, verify key files exist. A private key can also be a key URI, which is not a file.
 func VerifySigningKeyInput(keyFile string, isPublic bool) string {
+	if !isPublic && IsKeyURI(keyFile) {
+		return keyFile
+	}
+
 	keyFile = verifySigningKeyInputHelper(keyFile, isPublic, false)
 	if _, err := os.Stat(keyFile); os.IsNotExist(err) {
 		keyFile = verifySigningKeyInputHelper(keyFile, isPublic, true)
@@ -1816,12 +1816,12 @@ func verifySigningKeyInputHelper(keyFile string, isPublic, usingDeprecated bool)
 
 // get default keys if needed and verify them.
 // this function is used by `hzn exchange pattern/service publish
-func GetSigningKeys(privKeyFilePath, pubKeyFilePath string) (*rsa.PrivateKey, []byte, string) {
+func GetSigningKeys(privKeyFilePath, pubKeyFilePath string) (crypto.Signer, []byte, string) {
 
 	var err error
 
 	// Get default private key if -k not specified
-	var privKey *rsa.PrivateKey
+	var privKey crypto.Signer
 	privKeyFilePath_tmp := WithDefaultEnvVar(&privKeyFilePath, "HZN_PRIVATE_KEY_FILE")
 	privKeyFilePath = WithDefaultKeyFile(*privKeyFilePath_tmp, false)
 
@@ -1829,7 +1829,7 @@ func GetSigningKeys(privKeyFilePath, pubKeyFilePath string) (*rsa.PrivateKey, []
 	if privKeyFilePath != "" {
 		privKey = getPrivateKeyFromFile(privKeyFilePath)
 		// otherwise, generate a random key
-	} else if privKey, err = rsa.GenerateKey(rand.Reader, 2048); err != nil {
+	} else if privKey, err = cutil.GenerateSigningKey(cutil.SIGNING_ALGO_RSA, 2048); err != nil {
 		Fatal(CLI_GENERAL_ERROR, i18n.GetMessagePrinter().Sprintf("private key could not be generated; error: %v", err))
 	}
 
@@ -1843,17 +1843,10 @@ func GetSigningKeys(privKeyFilePath, pubKeyFilePath string) (*rsa.PrivateKey, []
 		pubKeyBytes = ReadFile(pubKeyFilePath)
 	} else {
 		// calculate public key from private key
-		pubKeyBytes, err = x509.MarshalPKIXPublicKey(&privKey.PublicKey)
+		pubKeyBytes, err = cutil.MarshalPublicKeyPEM(privKey.Public())
 		if err != nil {
-			Fatal(CLI_GENERAL_ERROR, i18n.GetMessagePrinter().Sprintf("%v. Public key could not be generated."))
-		}
-		// format public key
-		pubEnc := &pem.Block{
-			Type:    "PUBLIC KEY",
-			Headers: nil,
-			Bytes:   pubKeyBytes,
+			Fatal(CLI_GENERAL_ERROR, i18n.GetMessagePrinter().Sprintf("%v. Public key could not be generated.", err))
 		}
-		pubKeyBytes = pem.EncodeToMemory(pubEnc)
 	}
 	return privKey, pubKeyBytes, publicKeyName
 }
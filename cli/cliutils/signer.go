@@ -0,0 +1,269 @@
+package cliutils
+
+import (
+	"bytes"
+	"crypto"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/open-horizon/anax/config"
+	"github.com/open-horizon/anax/cutil"
+	"github.com/open-horizon/anax/i18n"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// The prefixes of the key URIs that select where the publishing commands sign. Anything else is the name of a PEM
+// private key file.
+//
+//	pkcs11:token=mytoken;object=mykey?module-path=/usr/lib/softhsm/libsofthsm2.so&pin-value=1234
+//	https://signer.example.com/v1/keys/mykey
+const (
+	KEY_URI_PKCS11 = "pkcs11:"  // a key in a PKCS #11 token (RFC 7512)
+	KEY_URI_REMOTE = "https://" // a key in a remote signing service
+)
+
+// The env vars that complete the key URIs. The PKCS #11 module and pin are used when the URI does not have them, the
+// token is sent to the remote signing service as a bearer token.
+const (
+	PKCS11_MODULE_ENVVAR = "HZN_PKCS11_MODULE"
+	PKCS11_PIN_ENVVAR    = "HZN_PKCS11_PIN"
+	SIGNER_TOKEN_ENVVAR  = "HZN_SIGNER_TOKEN"
+)
+
+// Returns true if the private key is a key URI rather than a key file.
+func IsKeyURI(key string) bool {
+	return strings.HasPrefix(key, KEY_URI_PKCS11) || strings.HasPrefix(key, KEY_URI_REMOTE)
+}
+
+// Returns the signer of a private key file or key URI. The private key of a PKCS #11 token or a remote signing service
+// never leaves it, only the digests that are signed are sent to it.
+func GetSigner(keyFileOrURI string) (crypto.Signer, error) {
+	if strings.HasPrefix(keyFileOrURI, KEY_URI_PKCS11) {
+		return NewPKCS11Signer(keyFileOrURI)
+	} else if strings.HasPrefix(keyFileOrURI, KEY_URI_REMOTE) {
+		return NewRemoteSigner(keyFileOrURI, os.Getenv(SIGNER_TOKEN_ENVVAR), GetHTTPClient(config.HTTPRequestTimeoutS))
+	}
+	return cutil.ReadSigningKey(keyFileOrURI)
+}
+
+// Sign the input with the private key file or key URI.
+func SignInput(keyFileOrURI string, input []byte) (string, error) {
+	signer, err := GetSigner(keyFileOrURI)
+	if err != nil {
+		return "", err
+	}
+	return cutil.SignInput(signer, input)
+}
+
+// The attributes of a PKCS #11 key URI (RFC 7512) that select the key. The path attributes are separated by ';' and the
+// query attributes by '&'.
+type pkcs11URI struct {
+	Token      string
+	Slot       string
+	Object     string
+	ID         []byte
+	ModulePath string
+	Pin        string
+}
+
+func (u pkcs11URI) String() string {
+	return fmt.Sprintf("Token: %v, Slot: %v, Object: %v, ID: %x, ModulePath: %v", u.Token, u.Slot, u.Object, u.ID, u.ModulePath)
+}
+
+// Parse a PKCS #11 key URI. The module and the pin are taken from the env vars when the URI does not have them.
+func parsePKCS11URI(keyURI string) (*pkcs11URI, error) {
+	msgPrinter := i18n.GetMessagePrinter()
+
+	rest := strings.TrimPrefix(keyURI, KEY_URI_PKCS11)
+	pathPart, queryPart := rest, ""
+	if i := strings.Index(rest, "?"); i >= 0 {
+		pathPart, queryPart = rest[:i], rest[i+1:]
+	}
+
+	u := &pkcs11URI{}
+	parse := func(attrs string, sep string) error {
+		for _, attr := range strings.Split(attrs, sep) {
+			if attr == "" {
+				continue
+			}
+			kv := strings.SplitN(attr, "=", 2)
+			if len(kv) != 2 {
+				return errors.New(msgPrinter.Sprintf("PKCS #11 URI attribute %v is not valid", attr))
+			}
+			value, err := url.PathUnescape(kv[1])
+			if err != nil {
+				return errors.New(msgPrinter.Sprintf("PKCS #11 URI attribute %v is not valid: %v", attr, err))
+			}
+			switch kv[0] {
+			case "token":
+				u.Token = value
+			case "slot-id":
+				u.Slot = value
+			case "object":
+				u.Object = value
+			case "id":
+				u.ID = []byte(value)
+			case "module-path":
+				u.ModulePath = value
+			case "pin-value":
+				u.Pin = value
+			case "pin-source":
+				if pin, err := os.ReadFile(strings.TrimPrefix(value, "file:")); err != nil {
+					return errors.New(msgPrinter.Sprintf("unable to read the PKCS #11 pin from %v: %v", value, err))
+				} else {
+					u.Pin = strings.TrimSpace(string(pin))
+				}
+			}
+		}
+		return nil
+	}
+	if err := parse(pathPart, ";"); err != nil {
+		return nil, err
+	} else if err := parse(queryPart, "&"); err != nil {
+		return nil, err
+	}
+
+	if u.ModulePath == "" {
+		u.ModulePath = os.Getenv(PKCS11_MODULE_ENVVAR)
+	}
+	if u.Pin == "" {
+		u.Pin = os.Getenv(PKCS11_PIN_ENVVAR)
+	}
+
+	if u.ModulePath == "" {
+		return nil, errors.New(msgPrinter.Sprintf("the PKCS #11 module must be set with module-path in the key URI or with %v", PKCS11_MODULE_ENVVAR))
+	} else if u.Object == "" && len(u.ID) == 0 {
+		return nil, errors.New(msgPrinter.Sprintf("the PKCS #11 key URI must have the object or the id of the key"))
+	}
+	return u, nil
+}
+
+// PKCS #11 ECDSA signatures are the concatenated R and S values, they are converted to ASN.1 like the signatures of
+// the other ECDSA signers.
+func ecdsaSignatureToASN1(sig []byte) ([]byte, error) {
+	if len(sig) == 0 || len(sig)%2 != 0 {
+		return nil, errors.New(i18n.GetMessagePrinter().Sprintf("PKCS #11 ECDSA signature is not valid"))
+	}
+	half := len(sig) / 2
+	return asn1.Marshal(struct{ R, S *big.Int }{new(big.Int).SetBytes(sig[:half]), new(big.Int).SetBytes(sig[half:])})
+}
+
+// A signer whose key is held by a remote signing service, such as a KMS. The service has 2 operations:
+//
+//	GET  <key URL>       returns {"publicKey": "<PEM public key>"}
+//	POST <key URL>/sign  with {"digest": "<base64 digest>", "hashAlgorithm": "SHA-256"} returns {"signature": "<base64 signature>"}
+//
+// The signature is in the format of the key's algorithm: RSA-PSS, ASN.1 encoded ECDSA, or Ed25519 over the digest.
+type RemoteSigner struct {
+	keyURL    string
+	token     string
+	client    *http.Client
+	publicKey crypto.PublicKey
+}
+
+type remotePublicKeyResponse struct {
+	PublicKey string `json:"publicKey"`
+}
+
+type remoteSignRequest struct {
+	Digest        string `json:"digest"`
+	HashAlgorithm string `json:"hashAlgorithm"`
+}
+
+type remoteSignResponse struct {
+	Signature string `json:"signature"`
+}
+
+// Create a signer for the key URL of a remote signing service. The public key is read from the service.
+func NewRemoteSigner(keyURL string, token string, client *http.Client) (*RemoteSigner, error) {
+	msgPrinter := i18n.GetMessagePrinter()
+
+	s := &RemoteSigner{keyURL: strings.TrimSuffix(keyURL, "/"), token: token, client: client}
+
+	var resp remotePublicKeyResponse
+	if err := s.call(http.MethodGet, s.keyURL, nil, &resp); err != nil {
+		return nil, err
+	}
+	pubKey, err := cutil.ValidPublicKeyOrCert([]byte(resp.PublicKey))
+	if err != nil {
+		return nil, errors.New(msgPrinter.Sprintf("the public key of remote signing key %v is not valid: %v", keyURL, err))
+	}
+	s.publicKey = pubKey
+	return s, nil
+}
+
+func (s *RemoteSigner) Public() crypto.PublicKey {
+	return s.publicKey
+}
+
+// Sign the digest with the remote key. The hash function of the opts is sent to the service, it is empty for Ed25519
+// keys, which sign the digest as the message. The signature is verified with the public key of the service, so that a
+// signature from another key or over another digest is never returned.
+func (s *RemoteSigner) Sign(_ io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	hashType := crypto.Hash(0)
+	hashAlgorithm := ""
+	if opts != nil && opts.HashFunc() != 0 {
+		hashType = opts.HashFunc()
+		hashAlgorithm = hashType.String()
+	}
+
+	var resp remoteSignResponse
+	if err := s.call(http.MethodPost, s.keyURL+"/sign", remoteSignRequest{Digest: base64.StdEncoding.EncodeToString(digest), HashAlgorithm: hashAlgorithm}, &resp); err != nil {
+		return nil, err
+	}
+	sig, err := base64.StdEncoding.DecodeString(resp.Signature)
+	if err != nil {
+		return nil, errors.New(i18n.GetMessagePrinter().Sprintf("the signature from remote signing key %v is not valid: %v", s.keyURL, err))
+	} else if err := cutil.VerifyDigest(s.publicKey, hashType, digest, sig); err != nil {
+		return nil, errors.New(i18n.GetMessagePrinter().Sprintf("the signature from remote signing key %v does not verify with its public key: %v", s.keyURL, err))
+	}
+	return sig, nil
+}
+
+func (s *RemoteSigner) call(method string, reqURL string, body interface{}, result interface{}) error {
+	msgPrinter := i18n.GetMessagePrinter()
+
+	var reqBody io.Reader
+	if body != nil {
+		if b, err := json.Marshal(body); err != nil {
+			return err
+		} else {
+			reqBody = bytes.NewReader(b)
+		}
+	}
+	req, err := http.NewRequest(method, reqURL, reqBody)
+	if err != nil {
+		return err
+	}
+	req.Header.Add("Accept", "application/json")
+	if body != nil {
+		req.Header.Add("Content-Type", "application/json")
+	}
+	if s.token != "" {
+		req.Header.Add("Authorization", "Bearer "+s.token)
+	}
+
+	Verbose(msgPrinter.Sprintf("%v %v", method, reqURL))
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return errors.New(msgPrinter.Sprintf("unable to reach remote signing key %v: %v", reqURL, err))
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return errors.New(msgPrinter.Sprintf("unable to read the response of remote signing key %v: %v", reqURL, err))
+	} else if resp.StatusCode != http.StatusOK {
+		return errors.New(msgPrinter.Sprintf("remote signing key %v returned HTTP code %v: %v", reqURL, resp.StatusCode, string(respBody)))
+	} else if err := json.Unmarshal(respBody, result); err != nil {
+		return errors.New(msgPrinter.Sprintf("unable to unmarshal the response of remote signing key %v: %v", reqURL, err))
+	}
+	return nil
+}
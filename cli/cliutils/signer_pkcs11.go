@@ -0,0 +1,281 @@
+//go:build cgo
+// +build cgo
+
+package cliutils
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/asn1"
+	"encoding/binary"
+	"errors"
+	"github.com/miekg/pkcs11"
+	"github.com/open-horizon/anax/i18n"
+	"io"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// The Edwards curve key type and mechanism of PKCS #11 v3.0, they are not defined by the pkcs11 package.
+const (
+	CKK_EC_EDWARDS = 0x00000040
+	CKM_EDDSA      = 0x00001057
+)
+
+// A signer whose key is held by a PKCS #11 token, such as an HSM or SoftHSM. RSA, ECDSA and Ed25519 keys are supported.
+type PKCS11Signer struct {
+	ctx       *pkcs11.Ctx
+	session   pkcs11.SessionHandle
+	key       pkcs11.ObjectHandle
+	keyType   uint
+	publicKey crypto.PublicKey
+}
+
+// Create a signer for the key in a PKCS #11 key URI. The module is loaded and a session is opened on the token, which is
+// kept until the CLI exits.
+func NewPKCS11Signer(keyURI string) (*PKCS11Signer, error) {
+	msgPrinter := i18n.GetMessagePrinter()
+
+	u, err := parsePKCS11URI(keyURI)
+	if err != nil {
+		return nil, err
+	}
+	Verbose(msgPrinter.Sprintf("Using PKCS #11 key %v", u))
+
+	ctx := pkcs11.New(u.ModulePath)
+	if ctx == nil {
+		return nil, errors.New(msgPrinter.Sprintf("unable to load PKCS #11 module %v", u.ModulePath))
+	} else if err := ctx.Initialize(); err != nil {
+		ctx.Destroy()
+		return nil, errors.New(msgPrinter.Sprintf("unable to initialize PKCS #11 module %v: %v", u.ModulePath, err))
+	}
+
+	s := &PKCS11Signer{ctx: ctx}
+	if err := s.open(u); err != nil {
+		ctx.Finalize()
+		ctx.Destroy()
+		return nil, err
+	}
+	return s, nil
+}
+
+// Open a session on the token of the URI and find the private key and its public key.
+func (s *PKCS11Signer) open(u *pkcs11URI) error {
+	msgPrinter := i18n.GetMessagePrinter()
+
+	slot, err := s.findSlot(u)
+	if err != nil {
+		return err
+	}
+	if s.session, err = s.ctx.OpenSession(slot, pkcs11.CKF_SERIAL_SESSION); err != nil {
+		return errors.New(msgPrinter.Sprintf("unable to open a session on PKCS #11 token %v: %v", u.Token, err))
+	}
+	if u.Pin != "" {
+		if err := s.ctx.Login(s.session, pkcs11.CKU_USER, u.Pin); err != nil && !errors.Is(err, pkcs11.Error(pkcs11.CKR_USER_ALREADY_LOGGED_IN)) {
+			return errors.New(msgPrinter.Sprintf("unable to log in to PKCS #11 token %v: %v", u.Token, err))
+		}
+	}
+
+	if s.key, err = s.findObject(pkcs11.CKO_PRIVATE_KEY, u); err != nil {
+		return err
+	}
+	attrs, err := s.ctx.GetAttributeValue(s.session, s.key, []*pkcs11.Attribute{pkcs11.NewAttribute(pkcs11.CKA_KEY_TYPE, nil)})
+	if err != nil || len(attrs) != 1 {
+		return errors.New(msgPrinter.Sprintf("unable to read the type of PKCS #11 key %v: %v", u.Object, err))
+	}
+	s.keyType = uint(bytesToUint(attrs[0].Value))
+
+	// The public key is read from the public key object, the private key object does not have to expose it.
+	pubKey, err := s.findObject(pkcs11.CKO_PUBLIC_KEY, u)
+	if err != nil {
+		return err
+	}
+	s.publicKey, err = s.readPublicKey(pubKey)
+	return err
+}
+
+func (s *PKCS11Signer) findSlot(u *pkcs11URI) (uint, error) {
+	msgPrinter := i18n.GetMessagePrinter()
+
+	if u.Slot != "" {
+		if slot, err := strconv.ParseUint(u.Slot, 10, 32); err != nil {
+			return 0, errors.New(msgPrinter.Sprintf("PKCS #11 slot-id %v is not valid: %v", u.Slot, err))
+		} else {
+			return uint(slot), nil
+		}
+	}
+
+	slots, err := s.ctx.GetSlotList(true)
+	if err != nil {
+		return 0, errors.New(msgPrinter.Sprintf("unable to list the PKCS #11 slots: %v", err))
+	}
+	for _, slot := range slots {
+		if info, err := s.ctx.GetTokenInfo(slot); err == nil && (u.Token == "" || strings.TrimSpace(info.Label) == u.Token) {
+			return slot, nil
+		}
+	}
+	return 0, errors.New(msgPrinter.Sprintf("PKCS #11 token %v is not found", u.Token))
+}
+
+func (s *PKCS11Signer) findObject(class uint, u *pkcs11URI) (pkcs11.ObjectHandle, error) {
+	msgPrinter := i18n.GetMessagePrinter()
+
+	template := []*pkcs11.Attribute{pkcs11.NewAttribute(pkcs11.CKA_CLASS, class)}
+	if u.Object != "" {
+		template = append(template, pkcs11.NewAttribute(pkcs11.CKA_LABEL, u.Object))
+	}
+	if len(u.ID) != 0 {
+		template = append(template, pkcs11.NewAttribute(pkcs11.CKA_ID, u.ID))
+	}
+
+	if err := s.ctx.FindObjectsInit(s.session, template); err != nil {
+		return 0, errors.New(msgPrinter.Sprintf("unable to search PKCS #11 token %v: %v", u.Token, err))
+	}
+	objects, _, err := s.ctx.FindObjects(s.session, 2)
+	s.ctx.FindObjectsFinal(s.session)
+	if err != nil {
+		return 0, errors.New(msgPrinter.Sprintf("unable to search PKCS #11 token %v: %v", u.Token, err))
+	} else if len(objects) == 0 {
+		return 0, errors.New(msgPrinter.Sprintf("PKCS #11 key %v is not found in token %v", u.Object, u.Token))
+	} else if len(objects) > 1 {
+		return 0, errors.New(msgPrinter.Sprintf("PKCS #11 key URI matches more than one key in token %v, add the object or the id of the key", u.Token))
+	}
+	return objects[0], nil
+}
+
+// Read the public key of a PKCS #11 public key object.
+func (s *PKCS11Signer) readPublicKey(object pkcs11.ObjectHandle) (crypto.PublicKey, error) {
+	msgPrinter := i18n.GetMessagePrinter()
+
+	switch s.keyType {
+	case pkcs11.CKK_RSA:
+		attrs, err := s.ctx.GetAttributeValue(s.session, object, []*pkcs11.Attribute{
+			pkcs11.NewAttribute(pkcs11.CKA_MODULUS, nil),
+			pkcs11.NewAttribute(pkcs11.CKA_PUBLIC_EXPONENT, nil),
+		})
+		if err != nil || len(attrs) != 2 {
+			return nil, errors.New(msgPrinter.Sprintf("unable to read the PKCS #11 RSA public key: %v", err))
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(attrs[0].Value), E: int(new(big.Int).SetBytes(attrs[1].Value).Int64())}, nil
+
+	case pkcs11.CKK_EC, CKK_EC_EDWARDS:
+		attrs, err := s.ctx.GetAttributeValue(s.session, object, []*pkcs11.Attribute{
+			pkcs11.NewAttribute(pkcs11.CKA_EC_PARAMS, nil),
+			pkcs11.NewAttribute(pkcs11.CKA_EC_POINT, nil),
+		})
+		if err != nil || len(attrs) != 2 {
+			return nil, errors.New(msgPrinter.Sprintf("unable to read the PKCS #11 EC public key: %v", err))
+		}
+		// The point is a DER octet string, some tokens return it without the wrapping.
+		point := attrs[1].Value
+		var unwrapped []byte
+		if rest, err := asn1.Unmarshal(point, &unwrapped); err == nil && len(rest) == 0 {
+			point = unwrapped
+		}
+
+		if s.keyType == CKK_EC_EDWARDS {
+			if len(point) != ed25519.PublicKeySize {
+				return nil, errors.New(msgPrinter.Sprintf("PKCS #11 Edwards curve key is not an Ed25519 key"))
+			}
+			return ed25519.PublicKey(point), nil
+		}
+
+		curve, err := curveFromParams(attrs[0].Value)
+		if err != nil {
+			return nil, err
+		}
+		x, y := elliptic.Unmarshal(curve, point)
+		if x == nil {
+			return nil, errors.New(msgPrinter.Sprintf("PKCS #11 EC public key point is not valid"))
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+
+	default:
+		return nil, errors.New(msgPrinter.Sprintf("PKCS #11 key type %v is not supported", s.keyType))
+	}
+}
+
+func curveFromParams(params []byte) (elliptic.Curve, error) {
+	var oid asn1.ObjectIdentifier
+	if _, err := asn1.Unmarshal(params, &oid); err != nil {
+		return nil, errors.New(i18n.GetMessagePrinter().Sprintf("PKCS #11 EC params are not a named curve: %v", err))
+	}
+	switch {
+	case oid.Equal(asn1.ObjectIdentifier{1, 2, 840, 10045, 3, 1, 7}):
+		return elliptic.P256(), nil
+	case oid.Equal(asn1.ObjectIdentifier{1, 3, 132, 0, 34}):
+		return elliptic.P384(), nil
+	case oid.Equal(asn1.ObjectIdentifier{1, 3, 132, 0, 35}):
+		return elliptic.P521(), nil
+	default:
+		return nil, errors.New(i18n.GetMessagePrinter().Sprintf("PKCS #11 EC curve %v is not supported", oid))
+	}
+}
+
+func (s *PKCS11Signer) Public() crypto.PublicKey {
+	return s.publicKey
+}
+
+// Sign the digest in the token. RSA keys make RSA-PSS signatures with a salt as long as the hash, ECDSA signatures are
+// converted to ASN.1 and Ed25519 keys sign the digest as the message.
+func (s *PKCS11Signer) Sign(_ io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	msgPrinter := i18n.GetMessagePrinter()
+
+	var mechanism *pkcs11.Mechanism
+	switch s.keyType {
+	case pkcs11.CKK_RSA:
+		hashAlg, mgf, err := pssHashParams(opts.HashFunc())
+		if err != nil {
+			return nil, err
+		}
+		mechanism = pkcs11.NewMechanism(pkcs11.CKM_RSA_PKCS_PSS, pkcs11.NewPSSParams(hashAlg, mgf, uint(opts.HashFunc().Size())))
+	case pkcs11.CKK_EC:
+		mechanism = pkcs11.NewMechanism(pkcs11.CKM_ECDSA, nil)
+	default:
+		mechanism = pkcs11.NewMechanism(CKM_EDDSA, nil)
+	}
+
+	if err := s.ctx.SignInit(s.session, []*pkcs11.Mechanism{mechanism}, s.key); err != nil {
+		return nil, errors.New(msgPrinter.Sprintf("unable to sign with PKCS #11 key: %v", err))
+	}
+	sig, err := s.ctx.Sign(s.session, digest)
+	if err != nil {
+		return nil, errors.New(msgPrinter.Sprintf("unable to sign with PKCS #11 key: %v", err))
+	}
+
+	if s.keyType == pkcs11.CKK_EC {
+		return ecdsaSignatureToASN1(sig)
+	}
+	return sig, nil
+}
+
+func pssHashParams(hash crypto.Hash) (uint, uint, error) {
+	switch hash {
+	case crypto.SHA1:
+		return pkcs11.CKM_SHA_1, pkcs11.CKG_MGF1_SHA1, nil
+	case crypto.SHA256:
+		return pkcs11.CKM_SHA256, pkcs11.CKG_MGF1_SHA256, nil
+	case crypto.SHA384:
+		return pkcs11.CKM_SHA384, pkcs11.CKG_MGF1_SHA384, nil
+	case crypto.SHA512:
+		return pkcs11.CKM_SHA512, pkcs11.CKG_MGF1_SHA512, nil
+	default:
+		return 0, 0, errors.New(i18n.GetMessagePrinter().Sprintf("hash %v is not supported for PKCS #11 RSA-PSS signatures", hash))
+	}
+}
+
+// PKCS #11 ulong attributes are in the native byte order and size.
+func bytesToUint(b []byte) uint64 {
+	switch len(b) {
+	case 8:
+		return binary.NativeEndian.Uint64(b)
+	case 4:
+		return uint64(binary.NativeEndian.Uint32(b))
+	default:
+		return 0
+	}
+}
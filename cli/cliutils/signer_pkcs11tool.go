@@ -0,0 +1,175 @@
+//go:build !cgo
+// +build !cgo
+
+package cliutils
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/open-horizon/anax/cutil"
+	"github.com/open-horizon/anax/i18n"
+	"io"
+	"os"
+	"os/exec"
+	"path"
+	"strings"
+)
+
+// The env var that sets the pkcs11-tool command, when it is not in the PATH.
+const PKCS11_TOOL_ENVVAR = "HZN_PKCS11_TOOL"
+
+// The env var that passes the pin to pkcs11-tool, so that it is not on the command line.
+const pkcs11ToolPinEnvVar = "HZN_PKCS11_TOOL_PIN"
+
+// PKCS #11 modules are shared libraries that are loaded with cgo. A hzn that is built without it, like the released hzn,
+// uses the key with the pkcs11-tool command of OpenSC, which loads the module of the key URI.
+type PKCS11ToolSigner struct {
+	tool      string
+	uri       *pkcs11URI
+	publicKey crypto.PublicKey
+}
+
+// Create a signer for the key in a PKCS #11 key URI. The public key is read from the token.
+func NewPKCS11Signer(keyURI string) (*PKCS11ToolSigner, error) {
+	msgPrinter := i18n.GetMessagePrinter()
+
+	u, err := parsePKCS11URI(keyURI)
+	if err != nil {
+		return nil, err
+	}
+	Verbose(msgPrinter.Sprintf("Using PKCS #11 key %v", u))
+
+	tool := os.Getenv(PKCS11_TOOL_ENVVAR)
+	if tool == "" {
+		tool = "pkcs11-tool"
+	}
+	if tool, err = exec.LookPath(tool); err != nil {
+		return nil, errors.New(msgPrinter.Sprintf("PKCS #11 keys are used with the pkcs11-tool command of OpenSC by this hzn, install OpenSC or set %v: %v", PKCS11_TOOL_ENVVAR, err))
+	}
+
+	s := &PKCS11ToolSigner{tool: tool, uri: u}
+	dir, err := os.MkdirTemp("", "hzn-pkcs11-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	pubKeyFile := path.Join(dir, "public.der")
+	if err := s.run("--read-object", "--type", "pubkey", "--output-file", pubKeyFile); err != nil {
+		return nil, errors.New(msgPrinter.Sprintf("unable to read the public key of PKCS #11 key %v: %v", u.Object, err))
+	} else if der, err := os.ReadFile(pubKeyFile); err != nil {
+		return nil, err
+	} else if s.publicKey, err = x509.ParsePKIXPublicKey(der); err != nil {
+		return nil, errors.New(msgPrinter.Sprintf("unable to parse the public key of PKCS #11 key %v: %v", u.Object, err))
+	} else if _, err := cutil.SigningAlgorithm(s.publicKey); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *PKCS11ToolSigner) Public() crypto.PublicKey {
+	return s.publicKey
+}
+
+// Sign the digest in the token. RSA keys make RSA-PSS signatures with a salt as long as the hash, ECDSA signatures are
+// converted to ASN.1 and Ed25519 keys sign the digest as the message. The signature is verified with the public key of
+// the token.
+func (s *PKCS11ToolSigner) Sign(_ io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	msgPrinter := i18n.GetMessagePrinter()
+
+	var args []string
+	switch s.publicKey.(type) {
+	case *rsa.PublicKey:
+		hashName, mgf, err := pkcs11ToolPSSParams(opts.HashFunc())
+		if err != nil {
+			return nil, err
+		}
+		args = []string{"--mechanism", "RSA-PKCS-PSS", "--hash-algorithm", hashName, "--mgf", mgf, "--salt-len", fmt.Sprintf("%v", opts.HashFunc().Size())}
+	case *ecdsa.PublicKey:
+		args = []string{"--mechanism", "ECDSA"}
+	case ed25519.PublicKey:
+		args = []string{"--mechanism", "EDDSA"}
+	}
+
+	dir, err := os.MkdirTemp("", "hzn-pkcs11-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	digestFile, sigFile := path.Join(dir, "digest"), path.Join(dir, "signature")
+	if err := os.WriteFile(digestFile, digest, 0600); err != nil {
+		return nil, err
+	} else if err := s.run(append([]string{"--sign", "--input-file", digestFile, "--output-file", sigFile}, args...)...); err != nil {
+		return nil, errors.New(msgPrinter.Sprintf("unable to sign with PKCS #11 key: %v", err))
+	}
+	sig, err := os.ReadFile(sigFile)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, ok := s.publicKey.(*ecdsa.PublicKey); ok {
+		if sig, err = ecdsaSignatureToASN1(sig); err != nil {
+			return nil, err
+		}
+	}
+	hashType := crypto.Hash(0)
+	if opts != nil {
+		hashType = opts.HashFunc()
+	}
+	if err := cutil.VerifyDigest(s.publicKey, hashType, digest, sig); err != nil {
+		return nil, errors.New(msgPrinter.Sprintf("the signature of PKCS #11 key %v does not verify with its public key: %v", s.uri.Object, err))
+	}
+	return sig, nil
+}
+
+// Run pkcs11-tool on the key of the URI.
+func (s *PKCS11ToolSigner) run(args ...string) error {
+	cmdArgs := []string{"--module", s.uri.ModulePath}
+	if s.uri.Slot != "" {
+		cmdArgs = append(cmdArgs, "--slot", s.uri.Slot)
+	} else if s.uri.Token != "" {
+		cmdArgs = append(cmdArgs, "--token-label", s.uri.Token)
+	}
+	if s.uri.Object != "" {
+		cmdArgs = append(cmdArgs, "--label", s.uri.Object)
+	}
+	if len(s.uri.ID) != 0 {
+		cmdArgs = append(cmdArgs, "--id", hex.EncodeToString(s.uri.ID))
+	}
+	env := os.Environ()
+	if s.uri.Pin != "" {
+		cmdArgs = append(cmdArgs, "--login", "--pin", "env:"+pkcs11ToolPinEnvVar)
+		env = append(env, pkcs11ToolPinEnvVar+"="+s.uri.Pin)
+	}
+	cmd := exec.Command(s.tool, append(cmdArgs, args...)...)
+	cmd.Env = env
+
+	Verbose(i18n.GetMessagePrinter().Sprintf("Running %v", strings.Join(cmd.Args, " ")))
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return errors.New(fmt.Sprintf("%v: %v", err, strings.TrimSpace(string(out))))
+	}
+	return nil
+}
+
+// The names of the hash and the mask generation function of RSA-PSS signatures in pkcs11-tool.
+func pkcs11ToolPSSParams(hash crypto.Hash) (string, string, error) {
+	switch hash {
+	case crypto.SHA1:
+		return "SHA-1", "MGF1-SHA1", nil
+	case crypto.SHA256:
+		return "SHA256", "MGF1-SHA256", nil
+	case crypto.SHA384:
+		return "SHA384", "MGF1-SHA384", nil
+	case crypto.SHA512:
+		return "SHA512", "MGF1-SHA512", nil
+	default:
+		return "", "", errors.New(i18n.GetMessagePrinter().Sprintf("hash %v is not supported for PKCS #11 RSA-PSS signatures", hash))
+	}
+}
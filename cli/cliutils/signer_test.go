@@ -0,0 +1,191 @@
+//go:build unit
+// +build unit
+
+package cliutils
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/open-horizon/anax/cutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_parsePKCS11URI(t *testing.T) {
+	u, err := parsePKCS11URI("pkcs11:token=my%20token;object=signing-key;id=%01%02?module-path=/usr/lib/softhsm/libsofthsm2.so&pin-value=1234")
+	assert.Nil(t, err)
+	assert.Equal(t, "my token", u.Token)
+	assert.Equal(t, "signing-key", u.Object)
+	assert.Equal(t, []byte{1, 2}, u.ID)
+	assert.Equal(t, "/usr/lib/softhsm/libsofthsm2.so", u.ModulePath)
+	assert.Equal(t, "1234", u.Pin)
+
+	// the module and the pin come from the env vars when they are not in the URI
+	pinFile := path.Join(t.TempDir(), "pin")
+	assert.Nil(t, os.WriteFile(pinFile, []byte("5678\n"), 0600))
+	t.Setenv(PKCS11_MODULE_ENVVAR, "/opt/hsm/libhsm.so")
+	t.Setenv(PKCS11_PIN_ENVVAR, "9999")
+	u, err = parsePKCS11URI("pkcs11:token=t;object=k?pin-source=file:" + pinFile)
+	assert.Nil(t, err)
+	assert.Equal(t, "/opt/hsm/libhsm.so", u.ModulePath)
+	assert.Equal(t, "5678", u.Pin)
+
+	u, err = parsePKCS11URI("pkcs11:token=t;object=k")
+	assert.Nil(t, err)
+	assert.Equal(t, "9999", u.Pin)
+
+	// the key must be selected
+	_, err = parsePKCS11URI("pkcs11:token=t")
+	assert.NotNil(t, err)
+	_, err = parsePKCS11URI("pkcs11:token")
+	assert.NotNil(t, err)
+
+	assert.True(t, IsKeyURI("pkcs11:token=t;object=k"))
+	assert.True(t, IsKeyURI("https://signer.example.com/v1/keys/k"))
+	assert.False(t, IsKeyURI("/home/me/.hzn/keys/service.private.key"))
+}
+
+func Test_RemoteSigner(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.Nil(t, err)
+	pubPem, err := cutil.MarshalPublicKeyPEM(&key.PublicKey)
+	assert.Nil(t, err)
+
+	// a signing service with the key
+	signKey := key
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer mytoken" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/keys/k":
+			json.NewEncoder(w).Encode(remotePublicKeyResponse{PublicKey: string(pubPem)})
+		case r.Method == http.MethodPost && r.URL.Path == "/v1/keys/k/sign":
+			var req remoteSignRequest
+			json.NewDecoder(r.Body).Decode(&req)
+			assert.Equal(t, crypto.SHA256.String(), req.HashAlgorithm)
+			digest, _ := base64.StdEncoding.DecodeString(req.Digest)
+			sig, _ := signKey.Sign(rand.Reader, digest, crypto.SHA256)
+			json.NewEncoder(w).Encode(remoteSignResponse{Signature: base64.StdEncoding.EncodeToString(sig)})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	signer, err := NewRemoteSigner(server.URL+"/v1/keys/k", "mytoken", server.Client())
+	assert.Nil(t, err)
+	assert.Equal(t, &key.PublicKey, signer.Public())
+
+	input := []byte(`{"services":{}}`)
+	sig, err := cutil.SignInput(signer, input)
+	assert.Nil(t, err)
+	sigBytes, err := base64.StdEncoding.DecodeString(sig)
+	assert.Nil(t, err)
+	digest := sha256.Sum256(input)
+	assert.Nil(t, cutil.VerifyDigest(&key.PublicKey, crypto.SHA256, digest[:], sigBytes))
+
+	// a signature from another key is rejected
+	signKey, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.Nil(t, err)
+	_, err = cutil.SignInput(signer, input)
+	assert.NotNil(t, err)
+
+	// the service rejects other tokens
+	_, err = NewRemoteSigner(server.URL+"/v1/keys/k", "badtoken", server.Client())
+	assert.NotNil(t, err)
+}
+
+// The paths of the SoftHSM module in the distributions, the module can also be set with HZN_PKCS11_MODULE.
+var softHSMModules = []string{
+	"/usr/lib/softhsm/libsofthsm2.so",
+	"/usr/lib/x86_64-linux-gnu/softhsm/libsofthsm2.so",
+	"/usr/lib/aarch64-linux-gnu/softhsm/libsofthsm2.so",
+	"/usr/lib64/pkcs11/libsofthsm2.so",
+	"/usr/local/lib/softhsm/libsofthsm2.so",
+	"/opt/homebrew/lib/softhsm/libsofthsm2.so",
+}
+
+// Sign with RSA and ECDSA keys in a SoftHSM token. The test is skipped when SoftHSM is not installed, and when hzn is
+// built without cgo and the pkcs11-tool command is not installed.
+func Test_PKCS11Signer(t *testing.T) {
+	module := os.Getenv(PKCS11_MODULE_ENVVAR)
+	for _, m := range softHSMModules {
+		if _, err := os.Stat(m); module == "" && err == nil {
+			module = m
+		}
+	}
+	if module == "" {
+		t.Skip("the SoftHSM module is not installed")
+	} else if _, err := exec.LookPath("softhsm2-util"); err != nil {
+		t.Skip("softhsm2-util is not installed")
+	}
+
+	// a token in a temporary SoftHSM configuration
+	dir := t.TempDir()
+	conf := path.Join(dir, "softhsm2.conf")
+	assert.Nil(t, os.Mkdir(path.Join(dir, "tokens"), 0700))
+	assert.Nil(t, os.WriteFile(conf, []byte("directories.tokendir = "+path.Join(dir, "tokens")+"\nobjectstore.backend = file\n"), 0600))
+	t.Setenv("SOFTHSM2_CONF", conf)
+	softhsm := func(args ...string) {
+		out, err := exec.Command("softhsm2-util", args...).CombinedOutput()
+		assert.Nil(t, err, string(out))
+	}
+	softhsm("--init-token", "--free", "--label", "horizon", "--pin", "1234", "--so-pin", "5678")
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.Nil(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.Nil(t, err)
+	keys := []struct {
+		label string
+		id    string
+		key   crypto.Signer
+	}{{"rsa-key", "01", rsaKey}, {"ecdsa-key", "02", ecKey}}
+
+	input := []byte(`{"services":{}}`)
+	digest := sha256.Sum256(input)
+	for _, k := range keys {
+		der, err := x509.MarshalPKCS8PrivateKey(k.key)
+		assert.Nil(t, err)
+		keyFile := path.Join(dir, k.label+".pem")
+		assert.Nil(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0600))
+		softhsm("--import", keyFile, "--token", "horizon", "--label", k.label, "--id", k.id, "--pin", "1234")
+
+		signer, err := GetSigner("pkcs11:token=horizon;object=" + k.label + "?module-path=" + module + "&pin-value=1234")
+		if err != nil && strings.Contains(err.Error(), "pkcs11-tool") {
+			t.Skip("hzn is built without cgo and pkcs11-tool is not installed")
+		}
+		assert.Nil(t, err)
+		assert.Equal(t, k.key.Public(), signer.Public())
+
+		// the signature verifies with the public key, like the signatures of key files
+		sig, err := cutil.SignInput(signer, input)
+		assert.Nil(t, err)
+		sigBytes, err := base64.StdEncoding.DecodeString(sig)
+		assert.Nil(t, err)
+		assert.Nil(t, cutil.VerifyDigest(k.key.Public(), crypto.SHA256, digest[:], sigBytes), k.label)
+	}
+
+	// the key is not in the token, or the pin is wrong
+	_, err = GetSigner("pkcs11:token=horizon;object=other-key?module-path=" + module + "&pin-value=1234")
+	assert.NotNil(t, err)
+	_, err = GetSigner("pkcs11:token=horizon;object=rsa-key?module-path=" + module + "&pin-value=0000")
+	assert.NotNil(t, err)
+}
@@ -31,7 +31,7 @@ func readServicePolicyFile(filePath string, inputFileStruct *exchangecommon.Serv
 }
 
 // check if the policies are compatible
-func PolicyCompatible(org string, userPw string, nodeIds []string, haGroupName string, nodeArch string, nodeType string, nodeNamespace string, nodeIsNamespaceScoped bool, nodePolFile string, businessPolId string, businessPolFile string, servicePolFile string, svcDefFiles []string, checkAllSvcs bool, showDetail bool) {
+func PolicyCompatible(org string, userPw string, nodeIds []string, haGroupName string, nodeArch string, nodeType string, nodeNamespace string, nodeIsNamespaceScoped bool, nodePolFile string, businessPolId string, businessPolFile string, servicePolFile string, svcDefFiles []string, checkAllSvcs bool, showDetail bool, explain bool) {
 
 	msgPrinter := i18n.GetMessagePrinter()
 
@@ -120,7 +120,7 @@ func PolicyCompatible(org string, userPw string, nodeIds []string, haGroupName s
 
 		// now we can call the real code to check if the policies are compatible.
 		// the policy validation are done wthin the calling function.
-		compOutput, err := compcheck.PolicyCompatible(ec, &policyCheckInput, checkAllSvcs, msgPrinter)
+		compOutput, err := compcheck.PolicyCompatible(ec, &policyCheckInput, checkAllSvcs, explain, msgPrinter)
 		if err != nil {
 			cliutils.Fatal(cliutils.CLI_GENERAL_ERROR, err.Error())
 		} else {
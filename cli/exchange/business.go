@@ -123,6 +123,47 @@ func BusinessAddPolicy(org string, credToUse string, policy string, jsonFilePath
 	}
 }
 
+// BusinessDryRunPolicy asks the agbot to simulate the deployment of a policy and displays the outcome for each node.
+// The policy is read from the json file when one is given, so that a policy can be checked before it is published.
+func BusinessDryRunPolicy(org string, credToUse string, policy string, jsonFilePath string, nodeOrgs []string, nodeIds []string) {
+	cliutils.SetWhetherUsingApiKey(credToUse)
+
+	// get message printer
+	msgPrinter := i18n.GetMessagePrinter()
+
+	if policy == "" && jsonFilePath == "" {
+		cliutils.Fatal(cliutils.CLI_INPUT_ERROR, msgPrinter.Sprintf("Either a deployment policy name or a json file must be specified."))
+	}
+
+	input := compcheck.DryRunCheck{NodeOrgs: nodeOrgs}
+	if policy != "" {
+		polOrg, polName := cliutils.TrimOrg(org, policy)
+		input.BusinessPolId = polOrg + "/" + polName
+	}
+	for _, nodeId := range nodeIds {
+		nodeOrg, nodeName := cliutils.TrimOrg(org, nodeId)
+		input.NodeIds = append(input.NodeIds, nodeOrg+"/"+nodeName)
+	}
+	if len(input.NodeOrgs) == 0 && input.BusinessPolId == "" {
+		input.NodeOrgs = []string{org}
+	}
+
+	if jsonFilePath != "" {
+		newBytes := cliconfig.ReadJsonFileWithLocalConfig(jsonFilePath)
+		var policyFile businesspolicy.BusinessPolicy
+		if err := json.Unmarshal(newBytes, &policyFile); err != nil {
+			cliutils.Fatal(cliutils.JSON_PARSING_ERROR, msgPrinter.Sprintf("failed to unmarshal json input file %s: %v", jsonFilePath, err))
+		} else if err := policyFile.Validate(); err != nil {
+			cliutils.Fatal(cliutils.CLI_INPUT_ERROR, msgPrinter.Sprintf("Incorrect deployment policy format in file %s: %v", jsonFilePath, err))
+		}
+		input.BusinessPolicy = &policyFile
+	}
+
+	var output string
+	cliutils.AgbotPutPost(http.MethodPost, "deploycheck/dryrun", cliutils.OrgAndCreds(org, credToUse), []int{200}, input, &output)
+	fmt.Println(output)
+}
+
 // BusinessUpdatePolicy will replace a single attribute of a business policy in the Horizon Exchange
 func BusinessUpdatePolicy(org string, credToUse string, policyName string, filePath string) {
 
@@ -1,8 +1,7 @@
 package exchange
 
 import (
-	"crypto/rsa"
-	"crypto/sha256"
+	"crypto"
 	"encoding/json"
 	"fmt"
 	"github.com/open-horizon/anax/cli/cliconfig"
@@ -14,8 +13,6 @@ import (
 	"github.com/open-horizon/anax/exchangecommon"
 	"github.com/open-horizon/anax/i18n"
 	"github.com/open-horizon/anax/policy"
-	"github.com/open-horizon/rsapss-tool/sign"
-	"github.com/open-horizon/rsapss-tool/verify"
 	"golang.org/x/text/message"
 	"net/http"
 	"os"
@@ -339,18 +336,13 @@ func PatternPublish(org, userPw, jsonFilePath, keyFilePath, pubKeyFilePath, patN
 					}
 					patInput.Services[i].ServiceVersions[j].DeploymentOverrides = string(deployment)
 					// We know we need to sign the overrides, so make sure a real key file was provided.
-					var privKey *rsa.PrivateKey
+					var privKey crypto.Signer
 					if !keyVerified {
 						privKey, newPubKeyToStore, newPubKeyName = cliutils.GetSigningKeys(keyFilePath, pubKeyFilePath)
 						keyVerified = true
 					}
 
-					hasher := sha256.New()
-					_, err = hasher.Write(deployment)
-					if err != nil {
-						cliutils.Fatal(cliutils.CLI_GENERAL_ERROR, msgPrinter.Sprintf("problem signing the deployment_overrides string: %v", err))
-					}
-					patInput.Services[i].ServiceVersions[j].DeploymentOverridesSignature, err = sign.Sha256HashOfInput(privKey, hasher)
+					patInput.Services[i].ServiceVersions[j].DeploymentOverridesSignature, err = cutil.SignInput(privKey, deployment)
 					if err != nil {
 						cliutils.Fatal(cliutils.CLI_GENERAL_ERROR, msgPrinter.Sprintf("problem signing the deployment_overrides string: %v", err))
 					}
@@ -433,7 +425,7 @@ func PatternVerify(org, userPw, pattern, keyFilePath string) {
 				keyFilePath = cliutils.GetAndVerifyPublicKey(keyFilePath)
 				keyVerified = true
 			}
-			verified, err := verify.Input(keyFilePath, pat.Services[i].ServiceVersions[j].DeploymentOverridesSignature, []byte(pat.Services[i].ServiceVersions[j].DeploymentOverrides))
+			verified, err := cutil.VerifyInput(keyFilePath, pat.Services[i].ServiceVersions[j].DeploymentOverridesSignature, []byte(pat.Services[i].ServiceVersions[j].DeploymentOverrides))
 			if err != nil {
 				cliutils.Fatal(cliutils.CLI_GENERAL_ERROR, msgPrinter.Sprintf("problem verifying deployment_overrides string in service %d, serviceVersion number %d with %s: %v", i+1, j+1, keyFilePath, err))
 			} else if !verified {
@@ -2,7 +2,7 @@ package exchange
 
 import (
 	"bytes"
-	"crypto/rsa"
+	"crypto"
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
@@ -15,10 +15,10 @@ import (
 	"github.com/open-horizon/anax/exchange"
 	"github.com/open-horizon/anax/exchangecommon"
 	"github.com/open-horizon/anax/externalpolicy"
+	_ "github.com/open-horizon/anax/externalpolicy/cel_language"
 	_ "github.com/open-horizon/anax/externalpolicy/text_language"
 	"github.com/open-horizon/anax/i18n"
 	"github.com/open-horizon/anax/persistence"
-	"github.com/open-horizon/rsapss-tool/verify"
 	"net/http"
 	"os"
 	"path/filepath"
@@ -91,14 +91,19 @@ func ServiceList(credOrg, userPw, service string, namesOnly bool, filePath strin
 				if err := json.Unmarshal([]byte(s.ClusterDeployment), &kubeDeploymentConfig); err != nil {
 					cliutils.Fatal(cliutils.JSON_PARSING_ERROR, msgPrinter.Sprintf("failed to unmarshal ClusterDeployment in 'hzn exchange service list' output: %v", err))
 				} else {
-					if len(kubeDeploymentConfig.OperatorYamlArchive) > 100 {
-						// only display 100 charactors for ClusterDeployment.OperatorYamlArchive because it is usually very long
+					if len(kubeDeploymentConfig.OperatorYamlArchive) > 100 || len(kubeDeploymentConfig.ManifestArchive) > 100 {
+						// only display 100 charactors for ClusterDeployment.OperatorYamlArchive or ClusterDeployment.ManifestArchive because it is usually very long
 						if service != "" && !namesOnly {
 							// if user specify a service name and -l is specified, then display all of ClusterDeployment
 							// this will give user a way to examine all of it.
 							continue
 						}
-						kubeDeploymentConfig.OperatorYamlArchive = kubeDeploymentConfig.OperatorYamlArchive[0:100] + "..."
+						if len(kubeDeploymentConfig.OperatorYamlArchive) > 100 {
+							kubeDeploymentConfig.OperatorYamlArchive = kubeDeploymentConfig.OperatorYamlArchive[0:100] + "..."
+						}
+						if len(kubeDeploymentConfig.ManifestArchive) > 100 {
+							kubeDeploymentConfig.ManifestArchive = kubeDeploymentConfig.ManifestArchive[0:100] + "..."
+						}
 						if truncatedKD, err := json.Marshal(&kubeDeploymentConfig); err != nil {
 							cliutils.Fatal(cliutils.JSON_PARSING_ERROR, msgPrinter.Sprintf("failed to marshal truncked ClusterDeployment in 'hzn exchange service list' output: %v", err))
 						} else {
@@ -313,7 +318,7 @@ func SignDeployment(deployment interface{}, deploymentSignature string, baseDir
 	// The deployment field can be json object (map), string (for pre-signed), or nil
 	var newDeployment, newDeploymentSignature, newPubKeyName string
 	var newPubKeyToStore []byte
-	var newPrivKeyToStore *rsa.PrivateKey
+	var newPrivKeyToStore crypto.Signer
 	switch dep := deployment.(type) {
 	case nil:
 		deployment = ""
@@ -388,7 +393,7 @@ func ServiceVerify(org, userPw, service, keyFilePath string) {
 
 	// verify the deployment
 	if svc.Deployment != "" {
-		verified, err := verify.Input(keyFilePath, svc.DeploymentSignature, []byte(svc.Deployment))
+		verified, err := cutil.VerifyInput(keyFilePath, svc.DeploymentSignature, []byte(svc.Deployment))
 		if err != nil {
 			cliutils.Fatal(cliutils.CLI_GENERAL_ERROR, msgPrinter.Sprintf("error verifying deployment string with %s: %v", keyFilePath, err))
 		} else if !verified {
@@ -399,7 +404,7 @@ func ServiceVerify(org, userPw, service, keyFilePath string) {
 	}
 	// verify the cluster deployment
 	if svc.ClusterDeployment != "" {
-		verified, err := verify.Input(keyFilePath, svc.ClusterDeploymentSignature, []byte(svc.ClusterDeployment))
+		verified, err := cutil.VerifyInput(keyFilePath, svc.ClusterDeploymentSignature, []byte(svc.ClusterDeployment))
 		if err != nil {
 			cliutils.Fatal(cliutils.CLI_GENERAL_ERROR, msgPrinter.Sprintf("error verifying cluster deployment string with %s: %v", keyFilePath, err))
 		} else if !verified {
@@ -681,8 +686,8 @@ func SaveOpYamlToFile(sId string, clusterDeployment string, filePath string, for
 	msgPrinter.Println()
 }
 
-// This function gets the operator yaml archive (in .tar.gz format) from the clusterDeployment
-// string from a service
+// This function gets the operator yaml archive, or the manifest archive, (in .tar.gz format) from the
+// clusterDeployment string from a service
 func GetOpYamlArchiveFromClusterDepl(deploymentConfig string) []byte {
 	// get message printer
 	msgPrinter := i18n.GetMessagePrinter()
@@ -695,7 +700,11 @@ func GetOpYamlArchiveFromClusterDepl(deploymentConfig string) []byte {
 	if kd, err := persistence.GetKubeDeployment(deploymentConfig); err != nil {
 		cliutils.Fatal(cliutils.JSON_PARSING_ERROR, msgPrinter.Sprintf("error getting kube deployment configuration: %v", err))
 	} else {
-		archiveData, err := base64.StdEncoding.DecodeString(kd.OperatorYamlArchive)
+		archive := kd.OperatorYamlArchive
+		if kd.IsManifest() {
+			archive = kd.ManifestArchive
+		}
+		archiveData, err := base64.StdEncoding.DecodeString(archive)
 		if err != nil {
 			cliutils.Fatal(cliutils.JSON_PARSING_ERROR, msgPrinter.Sprintf("error decoding the cluster deployment configuration: %v", err))
 		}
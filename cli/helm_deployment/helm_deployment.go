@@ -1,17 +1,16 @@
 package helm_deployment
 
 import (
-	"crypto/rsa"
-	"crypto/sha256"
+	"crypto"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"github.com/open-horizon/anax/cli/cliutils"
 	"github.com/open-horizon/anax/cli/dev"
 	"github.com/open-horizon/anax/cli/plugin_registry"
+	"github.com/open-horizon/anax/cutil"
 	"github.com/open-horizon/anax/helm"
 	"github.com/open-horizon/anax/i18n"
-	"github.com/open-horizon/rsapss-tool/sign"
 	"path/filepath"
 )
 
@@ -26,7 +25,7 @@ func NewHelmDeploymentConfigPlugin() plugin_registry.DeploymentConfigPlugin {
 	return new(HelmDeploymentConfigPlugin)
 }
 
-func (p *HelmDeploymentConfigPlugin) Sign(dep map[string]interface{}, privKey *rsa.PrivateKey, ctx plugin_registry.PluginContext) (bool, string, string, error) {
+func (p *HelmDeploymentConfigPlugin) Sign(dep map[string]interface{}, privKey crypto.Signer, ctx plugin_registry.PluginContext) (bool, string, string, error) {
 
 	// get message printer
 	msgPrinter := i18n.GetMessagePrinter()
@@ -62,12 +61,7 @@ func (p *HelmDeploymentConfigPlugin) Sign(dep map[string]interface{}, privKey *r
 	}
 	depStr := string(deployment)
 
-	hasher := sha256.New()
-	_, err = hasher.Write(deployment)
-	if err != nil {
-		return true, "", "", err
-	}
-	sig, err := sign.Sha256HashOfInput(privKey, hasher)
+	sig, err := cutil.SignInput(privKey, deployment)
 
 	if err != nil {
 		return true, "", "", errors.New(msgPrinter.Sprintf("problem signing deployment string: %v", err))
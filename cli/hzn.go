@@ -27,6 +27,7 @@ import (
 	"github.com/open-horizon/anax/cli/service"
 	"github.com/open-horizon/anax/cli/status"
 	"github.com/open-horizon/anax/cli/sync_service"
+	_ "github.com/open-horizon/anax/cli/systemd_deployment"
 	"github.com/open-horizon/anax/cli/unregister"
 	"github.com/open-horizon/anax/cli/userinput"
 	"github.com/open-horizon/anax/cli/utilcmds"
@@ -160,6 +161,19 @@ Environment Variables:
 	agbotPolicyListCmd := agbotPolicyCmd.Command("list | ls", msgPrinter.Sprintf("List policies this Horizon agreement bot hosts.")).Alias("ls").Alias("list")
 	agbotPolicyOrg := agbotPolicyListCmd.Arg("org", msgPrinter.Sprintf("The organization the policy belongs to.")).String()
 	agbotPolicyName := agbotPolicyListCmd.Arg("name", msgPrinter.Sprintf("The policy name.")).String()
+	agbotRolloutCmd := agbotCmd.Command("rollout | ro", msgPrinter.Sprintf("List or manage the service version rollouts of the deployment policies this Horizon agreement bot hosts.")).Alias("ro").Alias("rollout")
+	agbotRolloutListCmd := agbotRolloutCmd.Command("list | ls", msgPrinter.Sprintf("List the service version rollouts, or the rollout of one deployment policy.")).Alias("ls").Alias("list")
+	agbotRolloutListOrg := agbotRolloutListCmd.Arg("org", msgPrinter.Sprintf("The organization the deployment policy belongs to.")).String()
+	agbotRolloutListName := agbotRolloutListCmd.Arg("name", msgPrinter.Sprintf("The deployment policy name.")).String()
+	agbotRolloutPauseCmd := agbotRolloutCmd.Command("pause", msgPrinter.Sprintf("Pause the service version rollout of a deployment policy. The nodes of the current wave keep the version they have."))
+	agbotRolloutPauseOrg := agbotRolloutPauseCmd.Arg("org", msgPrinter.Sprintf("The organization the deployment policy belongs to.")).Required().String()
+	agbotRolloutPauseName := agbotRolloutPauseCmd.Arg("name", msgPrinter.Sprintf("The deployment policy name.")).Required().String()
+	agbotRolloutResumeCmd := agbotRolloutCmd.Command("resume", msgPrinter.Sprintf("Resume a paused or halted service version rollout of a deployment policy. The failed nodes of a halted wave get another chance to become healthy."))
+	agbotRolloutResumeOrg := agbotRolloutResumeCmd.Arg("org", msgPrinter.Sprintf("The organization the deployment policy belongs to.")).Required().String()
+	agbotRolloutResumeName := agbotRolloutResumeCmd.Arg("name", msgPrinter.Sprintf("The deployment policy name.")).Required().String()
+	agbotRolloutAbortCmd := agbotRolloutCmd.Command("abort", msgPrinter.Sprintf("Abort the service version rollout of a deployment policy. The nodes that were not upgraded yet keep their previous version."))
+	agbotRolloutAbortOrg := agbotRolloutAbortCmd.Arg("org", msgPrinter.Sprintf("The organization the deployment policy belongs to.")).Required().String()
+	agbotRolloutAbortName := agbotRolloutAbortCmd.Arg("name", msgPrinter.Sprintf("The deployment policy name.")).Required().String()
 	agbotStatusCmd := agbotCmd.Command("status", msgPrinter.Sprintf("Display the current horizon internal status for the Horizon agreement bot."))
 	agbotStatusLong := agbotStatusCmd.Flag("long", msgPrinter.Sprintf("Show detailed status")).Short('l').Bool()
 
@@ -212,6 +226,7 @@ Environment Variables:
 	policyCompBPolFile := policyCompCmd.Flag("business-pol", "").Hidden().String()
 	policyCompDepPolFile := policyCompCmd.Flag("deployment-pol", msgPrinter.Sprintf("The JSON input file name containing the Deployment policy. Mutually exclusive with -b.")).Short('B').String()
 	policyCompSPolFile := policyCompCmd.Flag("service-pol", msgPrinter.Sprintf("(optional) The JSON input file name containing the service policy. If omitted, the service policy will be retrieved from the Exchange for the service defined in the deployment policy.")).String()
+	policyCompExplain := policyCompCmd.Flag("explain", msgPrinter.Sprintf("Show the evaluation trace of the deployment and node policy constraints, with the result of each sub-expression and the property values it was evaluated against.")).Bool()
 	policyCompSvcFile := policyCompCmd.Flag("service", msgPrinter.Sprintf("(optional) The JSON input file name containing the service definition. Mutually exclusive with -b. If omitted, the service referenced in the deployment policy is retrieved from the Exchange. This flag can be repeated to specify different versions of the service.")).Strings()
 	secretCompCmd := deploycheckCmd.Command("secretbinding | sb", msgPrinter.Sprintf("Check secret bindings.")).Alias("sb").Alias("secretbinding")
 	secretCompNodeArch := secretCompCmd.Flag("arch", msgPrinter.Sprintf("The architecture of the node. It is required when -n is not specified. If omitted, the service of all the architectures referenced in the deployment policy or pattern will be checked for compatibility.")).Short('a').String()
@@ -341,6 +356,12 @@ Environment Variables:
 	exBusinessAddPolicyPolicy := exBusinessAddPolicyCmd.Arg("policy", msgPrinter.Sprintf("The name of the deployment policy to add or overwrite.")).Required().String()
 	exBusinessAddPolicyJsonFile := exBusinessAddPolicyCmd.Flag("json-file", msgPrinter.Sprintf("The path of a JSON file containing the metadata necessary to create/update the service policy in the Horizon Exchange. Specify -f- to read from stdin.")).Short('f').Required().String()
 	exBusinessAddPolNoConstraint := exBusinessAddPolicyCmd.Flag("no-constraints", msgPrinter.Sprintf("Allow this deployment policy to be published even though it does not have any constraints.")).Bool()
+	exBusinessDryRunCmd := exBusinessCmd.Command("dryrun", msgPrinter.Sprintf("Ask the agbot which nodes would receive an agreement for a deployment policy and which service version would be chosen, without deploying anything. Requires HZN_AGBOT_URL."))
+	exBusinessDryRunIdTok := exBusinessDryRunCmd.Flag("id-token", msgPrinter.Sprintf("The Horizon ID and password of the user.")).Short('n').PlaceHolder("ID:TOK").String()
+	exBusinessDryRunPolicy := exBusinessDryRunCmd.Arg("policy", msgPrinter.Sprintf("The name of the deployment policy in the Horizon Exchange. The agbot state of the nodes is looked up with this name.")).String()
+	exBusinessDryRunJsonFile := exBusinessDryRunCmd.Flag("json-file", msgPrinter.Sprintf("The path of a JSON file containing a deployment policy to check instead of the one in the Horizon Exchange. Specify -f- to read from stdin.")).Short('f').String()
+	exBusinessDryRunNodeOrgs := exBusinessDryRunCmd.Flag("node-org", msgPrinter.Sprintf("The organization of the nodes to check. This flag can be repeated. If not specified, the organization of the deployment policy will be used.")).Strings()
+	exBusinessDryRunNodeIds := exBusinessDryRunCmd.Flag("node-id", msgPrinter.Sprintf("Only check this node. This flag can be repeated.")).Strings()
 	exBusinessListPolicyCmd := exBusinessCmd.Command("listpolicy | ls", msgPrinter.Sprintf("Display the deployment policies from the Horizon Exchange.")).Alias("ls").Alias("listpolicy")
 	exBusinessListPolicyIdTok := exBusinessListPolicyCmd.Flag("id-token", msgPrinter.Sprintf("The Horizon ID and password of the user.")).Short('n').PlaceHolder("ID:TOK").String()
 	exBusinessListPolicyLong := exBusinessListPolicyCmd.Flag("long", msgPrinter.Sprintf("Display detailed output about the deployment policies.")).Short('l').Bool()
@@ -487,7 +508,7 @@ Environment Variables:
 	exPatListKeyKey := exPatternListKeyCmd.Arg("key-name", msgPrinter.Sprintf("The existing key name to see the contents of.")).String()
 	exPatternPublishCmd := exPatternCmd.Command("publish | pub", msgPrinter.Sprintf("Sign and create/update the pattern resource in the Horizon Exchange.")).Alias("pub").Alias("publish")
 	exPatJsonFile := exPatternPublishCmd.Flag("json-file", msgPrinter.Sprintf("The path of a JSON file containing the metadata necessary to create/update the pattern in the Horizon exchange. See %v/pattern.json. Specify -f- to read from stdin.", sample_dir)).Short('f').Required().String()
-	exPatKeyFile := exPatternPublishCmd.Flag("private-key-file", msgPrinter.Sprintf("The path of a private key file to be used to sign the pattern. If not specified, the environment variable HZN_PRIVATE_KEY_FILE will be used. If HZN_PRIVATE_KEY_FILE not specified, ~/.hzn/keys/service.private.key will be used. If none are specified, a random key pair will be generated and the public key will be stored with the pattern.")).Short('k').ExistingFile()
+	exPatKeyFile := exPatternPublishCmd.Flag("private-key-file", msgPrinter.Sprintf("The path of a private key file to be used to sign the pattern. If not specified, the environment variable HZN_PRIVATE_KEY_FILE will be used. If HZN_PRIVATE_KEY_FILE not specified, ~/.hzn/keys/service.private.key will be used. If none are specified, a random key pair will be generated and the public key will be stored with the pattern. It can also be a PKCS #11 key URI (pkcs11:token=...;object=...) or the https:// URL of a key in a remote signing service.")).Short('k').String()
 	exPatPubPubKeyFile := exPatternPublishCmd.Flag("public-key-file", msgPrinter.Sprintf("(DEPRECATED) The path of public key file (that corresponds to the private key) that should be stored with the pattern, to be used by the Horizon Agent to verify the signature. If this flag is not specified, the public key will be calculated from the private key.")).Short('K').ExistingFile()
 	exPatName := exPatternPublishCmd.Flag("pattern-name", msgPrinter.Sprintf("The name to use for this pattern in the Horizon exchange. If not specified, will default to the base name of the file path specified in -f.")).Short('p').String()
 	exPatDelCmd := exPatternCmd.Command("remove | rm", msgPrinter.Sprintf("Remove a pattern resource from the Horizon Exchange.")).Alias("rm").Alias("remove")
@@ -533,7 +554,7 @@ Environment Variables:
 	exServiceNewPolicyCmd := exServiceCmd.Command("newpolicy | newp", msgPrinter.Sprintf("Display an empty service policy template that can be filled in.")).Alias("newp").Alias("newpolicy")
 	exServicePublishCmd := exServiceCmd.Command("publish | pub", msgPrinter.Sprintf("Sign and create/update the service resource in the Horizon Exchange.")).Alias("pub").Alias("publish")
 	exSvcJsonFile := exServicePublishCmd.Flag("json-file", msgPrinter.Sprintf("The path of a JSON file containing the metadata necessary to create/update the service in the Horizon exchange. See %v/service.json and %v/service_cluster.json. Specify -f- to read from stdin.", sample_dir, sample_dir)).Short('f').Required().String()
-	exSvcPrivKeyFile := exServicePublishCmd.Flag("private-key-file", msgPrinter.Sprintf("The path of a private key file to be used to sign the service. If not specified, the environment variable HZN_PRIVATE_KEY_FILE will be used. If HZN_PRIVATE_KEY_FILE not specified, ~/.hzn/keys/service.private.key will be used. If none are specified, a random key pair will be generated and the public key will be stored with the service.")).Short('k').ExistingFile()
+	exSvcPrivKeyFile := exServicePublishCmd.Flag("private-key-file", msgPrinter.Sprintf("The path of a private key file to be used to sign the service. If not specified, the environment variable HZN_PRIVATE_KEY_FILE will be used. If HZN_PRIVATE_KEY_FILE not specified, ~/.hzn/keys/service.private.key will be used. If none are specified, a random key pair will be generated and the public key will be stored with the service. It can also be a PKCS #11 key URI (pkcs11:token=...;object=...) or the https:// URL of a key in a remote signing service.")).Short('k').String()
 	exSvcPubPubKeyFile := exServicePublishCmd.Flag("public-key-file", msgPrinter.Sprintf("(DEPRECATED) The path of public key file (that corresponds to the private key) that should be stored with the service, to be used by the Horizon Agent to verify the signature. If this flag is not specified, the public key will be calculated from the private key.")).Short('K').ExistingFile()
 	exSvcPubDontTouchImage := exServicePublishCmd.Flag("dont-change-image-tag", msgPrinter.Sprintf("The image paths in the deployment field have regular tags and should not be changed to sha256 digest values. The image will not get automatically uploaded to the repository. This should only be used during development when testing new versions often.")).Short('I').Bool()
 	exSvcPubPullImage := exServicePublishCmd.Flag("pull-image", msgPrinter.Sprintf("Use the image from the image repository. It will pull the image from the image repository and overwrite the local image if exists. This flag is mutually exclusive with -I.")).Short('P').Bool()
@@ -622,7 +643,8 @@ Environment Variables:
 	keyCreatePrivKey := keyCreateCmd.Flag("private-key-file", msgPrinter.Sprintf("The full path of the private key file. Mutually exclusive with -d. If not specified, the environment variable HZN_PRIVATE_KEY_FILE will be used. If none of them are set, ~/.hzn/keys/service.private.key is the default.")).Short('k').String()
 	keyCreatePubKey := keyCreateCmd.Flag("pubic-key-file", msgPrinter.Sprintf("The full path of the public key file. Mutually exclusive with -d. If not specified, the environment variable HZN_PUBLIC_KEY_FILE will be used. If none of them are set, ~/.hzn/keys/service.public.pem is the default.")).Short('K').String()
 	keyCreateOverwrite := keyCreateCmd.Flag("overwrite", msgPrinter.Sprintf("Overwrite the existing files. It will skip the 'do you want to overwrite' prompt.")).Short('f').Bool()
-	keyLength := keyCreateCmd.Flag("length", msgPrinter.Sprintf("The length of the key to create.")).Short('l').Default("4096").Int()
+	keyLength := keyCreateCmd.Flag("length", msgPrinter.Sprintf("The length of the key to create. Only used for RSA keys.")).Short('l').Default("4096").Int()
+	keyAlgorithm := keyCreateCmd.Flag("algorithm", msgPrinter.Sprintf("The algorithm of the key to create: rsa, ecdsa (P-256) or ed25519.")).Short('a').Default("rsa").Enum("rsa", "ecdsa", "ed25519")
 	keyDaysValid := keyCreateCmd.Flag("days-valid", msgPrinter.Sprintf("x509 certificate validity (Validity > Not After) expressed in days from the day of generation.")).Default("1461").Int()
 	keyImportFlag := keyCreateCmd.Flag("import", msgPrinter.Sprintf("Automatically import the created public key into the local Horizon agent.")).Short('i').Bool()
 	keyImportCmd := keyCmd.Command("import | imp", msgPrinter.Sprintf("Imports a signing public key into the Horizon agent.")).Alias("imp").Alias("import")
@@ -681,12 +703,25 @@ Environment Variables:
 	mmsObjectPublishSkipIntegrityCheck := mmsObjectPublishCmd.Flag("noIntegrity", msgPrinter.Sprintf("The publish command will not perform a data integrity check on the uploaded object data. It is mutually exclusive with --hashAlgo and --hash")).Bool()
 	mmsObjectPublishDSHashAlgo := mmsObjectPublishCmd.Flag("hashAlgo", msgPrinter.Sprintf("The hash algorithm used to hash the object data before signing it, ensuring data integrity during upload and download. Supported hash algorithms are SHA1 or SHA256, the default is SHA256. It is mutually exclusive with the --noIntegrity flag")).Short('a').String()
 	mmsObjectPublishDSHash := mmsObjectPublishCmd.Flag("hash", msgPrinter.Sprintf("The hash of the object data being uploaded or downloaded. Use this flag if you want to provide the hash instead of allowing the command to automatically calculate the hash. The hash must be generated using either the SHA1 or SHA256 algorithm. The -a flag must be specified if the hash was generated using SHA256. This flag is mutually exclusive with --noIntegrity.")).String()
-	mmsObjectPublishPrivKeyFile := mmsObjectPublishCmd.Flag("private-key-file", msgPrinter.Sprintf("The path of a private key file to be used to sign the object. The corresponding public key will be stored in the MMS to ensure integrity of the object. If not specified, the environment variable HZN_PRIVATE_KEY_FILE will be used to find a private key. If not set, ~/.hzn/keys/service.private.key will be used. If it does not exist, an RSA key pair is generated only for this publish operation and then the private key is discarded.")).Short('k').ExistingFile()
+	mmsObjectPublishPrivKeyFile := mmsObjectPublishCmd.Flag("private-key-file", msgPrinter.Sprintf("The path of a private key file to be used to sign the object. The key must be an RSA key. The corresponding public key will be stored in the MMS to ensure integrity of the object. If not specified, the environment variable HZN_PRIVATE_KEY_FILE will be used to find a private key. If not set, ~/.hzn/keys/service.private.key will be used. If it does not exist, an RSA key pair is generated only for this publish operation and then the private key is discarded. It can also be a PKCS #11 key URI (pkcs11:token=...;object=...) or the https:// URL of a key in a remote signing service.")).Short('k').String()
+	mmsObjectRollbackCmd := mmsObjectCmd.Command("rollback", msgPrinter.Sprintf("Roll back an object in the Horizon Model Management Service to a previous version retained on the nodes, by pinning the nodes to that version. The object must have a destination policy."))
+	mmsObjectRollbackType := mmsObjectRollbackCmd.Flag("type", msgPrinter.Sprintf("The type of the object to roll back.")).Short('t').Required().String()
+	mmsObjectRollbackId := mmsObjectRollbackCmd.Flag("id", msgPrinter.Sprintf("The id of the object to roll back.")).Short('i').Required().String()
+	mmsObjectRollbackVersion := mmsObjectRollbackCmd.Flag("version", msgPrinter.Sprintf("The version of the object to pin the nodes to. If omitted, the nodes are pinned to the version before the current version.")).Short('V').String()
+	mmsObjectRollbackUnpin := mmsObjectRollbackCmd.Flag("unpin", msgPrinter.Sprintf("Unpin the object, so that the nodes use the current version again. It is mutually exclusive with --version.")).Bool()
+	mmsObjectPublishDeltaBase := mmsObjectPublishCmd.Flag("delta-base", msgPrinter.Sprintf("The previous version of the object data. The difference from it to the -f file is also published, in a companion object with the id of the object followed by .delta, and the nodes that retained the previous version reconstruct the data from it before they have received the data. The other nodes use the data. The object must have a destination policy. This flag is mutually exclusive with --hash.")).String()
 	mmsObjectTypesCmd := mmsObjectCmd.Command("types", msgPrinter.Sprintf("Display a list of object types stored in the Horizon Model Management Service."))
 	mmsStatusCmd := mmsCmd.Command("status", msgPrinter.Sprintf("Display the status of the Horizon Model Management Service."))
 
 	nodeCmd := app.Command("node", msgPrinter.Sprintf("List and manage general information about this Horizon edge node."))
 	nodeListCmd := nodeCmd.Command("list | ls", msgPrinter.Sprintf("Display general information about this Horizon edge node.")).Alias("list").Alias("ls")
+	nodeExportCmd := nodeCmd.Command("export", msgPrinter.Sprintf("Export the state of this Horizon edge node into a signed archive file, so that it can be restored on another host with 'hzn node import'. The archive contains the node's registration, policy, user input, agreements, services, node management status and secret names (without secret values). The archive contains the node token, so keep it secure."))
+	nodeExportFile := nodeExportCmd.Flag("file", msgPrinter.Sprintf("The path of the archive file to write. Specify -f- to write to stdout.")).Short('f').Required().String()
+	nodeExportPrivKeyFile := nodeExportCmd.Flag("private-key-file", msgPrinter.Sprintf("The path of a private key file to be used to sign the archive. If not specified, the environment variable HZN_PRIVATE_KEY_FILE will be used. If HZN_PRIVATE_KEY_FILE not specified, ~/.hzn/keys/service.private.key will be used. It can also be a PKCS #11 key URI (pkcs11:token=...;object=...) or the https:// URL of a key in a remote signing service.")).Short('k').String()
+	nodeImportCmd := nodeCmd.Command("import", msgPrinter.Sprintf("Restore the state of a Horizon edge node from an archive file created by 'hzn node export'. The node must be unregistered, and the public key of the archive signature must be in the agent's trust store ('hzn key import'). The agreements whose services use secrets are not restored, because the archive has no secret values. Restart the Horizon agent after the import to use the restored node."))
+	nodeImportFile := nodeImportCmd.Flag("file", msgPrinter.Sprintf("The path of the archive file to restore. Specify -f- to read from stdin.")).Short('f').Required().String()
+	nodeImportPubKeyFile := nodeImportCmd.Flag("public-key-file", msgPrinter.Sprintf("The path of the public key file used to verify the archive signature. If not specified, the environment variable HZN_PUBLIC_KEY_FILE will be used. If HZN_PUBLIC_KEY_FILE not specified, ~/.hzn/keys/service.public.pem will be used.")).Short('K').String()
+	nodeImportIdTok := nodeImportCmd.Flag("node-id-tok", msgPrinter.Sprintf("The Horizon exchange node ID and token to use for the restored node, instead of the ones in the archive. The token is optional. The node must be in the same organization as the node in the archive. When the node ID changes, the agreements in the archive are not restored.")).Short('n').PlaceHolder("ID:TOK").String()
 
 	nodeManagementCmd := app.Command("nodemanagement | nm", msgPrinter.Sprintf("List and manage manifests and agent files for node management.")).Alias("nm").Alias("nodemanagement")
 	nmOrg := nodeManagementCmd.Flag("org", msgPrinter.Sprintf("The Horizon organization ID. If not specified, HZN_ORG_ID will be used as a default.")).Short('o').String()
@@ -699,7 +734,7 @@ Environment Variables:
 	nmManifestAddFile := nmManifestAddCmd.Flag("json-file", msgPrinter.Sprintf("The path of a JSON file containing the manifest data. Specify -f- to read from stdin.")).Short('f').Required().String()
 	nmManifestAddDSHashAlgo := nmManifestAddCmd.Flag("hashAlgo", msgPrinter.Sprintf("The hash algorithm used to hash the manifest data before signing it, ensuring data integrity during upload and download. Supported hash algorithms are SHA1 or SHA256, the default is SHA256. It is mutually exclusive with the --noIntegrity flag")).Short('a').String()
 	nmManifestAddDSHash := nmManifestAddCmd.Flag("hash", msgPrinter.Sprintf("The hash of the manifest data being uploaded or downloaded. Use this flag if you want to provide the hash instead of allowing the command to automatically calculate the hash. The hash must be generated using either the SHA1 or SHA256 algorithm. The -a flag must be specified if the hash was generated using SHA256. This flag is mutually exclusive with --noIntegrity.")).String()
-	nmManifestAddPrivKeyFile := nmManifestAddCmd.Flag("private-key-file", msgPrinter.Sprintf("The path of a private key file to be used to sign the manifest. The corresponding public key will be stored in the MMS to ensure integrity of the manifest. If not specified, the environment variable HZN_PRIVATE_KEY_FILE will be used to find a private key. If not set, ~/.hzn/keys/service.private.key will be used. If it does not exist, an RSA key pair is generated only for this publish operation and then the private key is discarded.")).Short('k').ExistingFile()
+	nmManifestAddPrivKeyFile := nmManifestAddCmd.Flag("private-key-file", msgPrinter.Sprintf("The path of a private key file to be used to sign the manifest. The key must be an RSA key. The corresponding public key will be stored in the MMS to ensure integrity of the manifest. If not specified, the environment variable HZN_PRIVATE_KEY_FILE will be used to find a private key. If not set, ~/.hzn/keys/service.private.key will be used. If it does not exist, an RSA key pair is generated only for this publish operation and then the private key is discarded. It can also be a PKCS #11 key URI (pkcs11:token=...;object=...) or the https:// URL of a key in a remote signing service.")).Short('k').String()
 	nmManifestAddSkipIntegrityCheck := nmManifestAddCmd.Flag("noIntegrity", msgPrinter.Sprintf("The publish command will not perform a data integrity check on the uploaded manifest data. It is mutually exclusive with --hashAlgo and --hash")).Bool()
 	nmManifestListCmd := nmManifestCmd.Command("list | ls", msgPrinter.Sprintf("Display a list of manifest files stored in the management hub.")).Alias("ls").Alias("list")
 	nmManifestListType := nmManifestListCmd.Flag("type", msgPrinter.Sprintf("The type of manifest to list. Valid values include 'agent_upgrade_manifests'.")).Short('t').String()
@@ -803,7 +838,7 @@ Environment Variables:
 	utilConfigConvCmd := utilCmd.Command("configconv | cfg", msgPrinter.Sprintf("Convert the configuration file from JSON format to a shell script.")).Alias("cfg").Alias("configconv")
 	utilConfigConvFile := utilConfigConvCmd.Flag("config-file", msgPrinter.Sprintf("The path of a configuration file to be converted. ")).Short('f').Required().ExistingFile()
 	utilSignCmd := utilCmd.Command("sign", msgPrinter.Sprintf("Sign the text in stdin. The signature is sent to stdout."))
-	utilSignPrivKeyFile := utilSignCmd.Flag("private-key-file", msgPrinter.Sprintf("The path of a private key file to be used to sign the stdin. ")).Short('k').Required().ExistingFile()
+	utilSignPrivKeyFile := utilSignCmd.Flag("private-key-file", msgPrinter.Sprintf("The path of a private key file to be used to sign the stdin. It can also be a PKCS #11 key URI (pkcs11:token=...;object=...) or the https:// URL of a key in a remote signing service.")).Short('k').Required().String()
 	utilVerifyCmd := utilCmd.Command("verify | vf", msgPrinter.Sprintf("Verify that the signature specified via -s is a valid signature for the text in stdin.")).Alias("vf").Alias("verify")
 	utilVerifyPubKeyFile := utilVerifyCmd.Flag("public-key-file", msgPrinter.Sprintf("The path of public key file (that corresponds to the private key that was used to sign) to verify the signature of stdin.")).Short('K').Required().ExistingFile()
 	utilVerifySig := utilVerifyCmd.Flag("signature", msgPrinter.Sprintf("The supposed signature of stdin.")).Short('s').Required().String()
@@ -971,6 +1006,8 @@ Environment Variables:
 			credToUse = cliutils.GetExchangeAuth(*exUserPw, "", false)
 		case "hagroup | hagr member | mb remove | rm":
 			credToUse = cliutils.GetExchangeAuth(*exUserPw, "", false)
+		case "deployment | dep dryrun":
+			credToUse = cliutils.GetExchangeAuth(*exUserPw, *exBusinessDryRunIdTok, false)
 		case "deployment | dep listpolicy | ls":
 			credToUse = cliutils.GetExchangeAuth(*exUserPw, *exBusinessListPolicyIdTok, false)
 		case "deployment | dep updatepolicy | upp":
@@ -1263,6 +1300,8 @@ Environment Variables:
 		exchange.ServiceRemovePolicy(*exOrg, credToUse, *exServiceRemovePolicyService, *exServiceRemovePolicyForce)
 	case exServiceListnode.FullCommand():
 		exchange.ListServiceNodes(*exOrg, *exUserPw, *exServiceListnodeService, *exServiceListnodeNodeOrg)
+	case exBusinessDryRunCmd.FullCommand():
+		exchange.BusinessDryRunPolicy(*exOrg, credToUse, *exBusinessDryRunPolicy, *exBusinessDryRunJsonFile, *exBusinessDryRunNodeOrgs, *exBusinessDryRunNodeIds)
 	case exBusinessListPolicyCmd.FullCommand():
 		exchange.BusinessListPolicy(*exOrg, credToUse, *exBusinessListPolicyPolicy, !*exBusinessListPolicyLong)
 	case exBusinessNewPolicyCmd.FullCommand():
@@ -1284,13 +1323,17 @@ Environment Variables:
 	case keyListCmd.FullCommand():
 		key.List(*keyName, *keyListAll)
 	case keyCreateCmd.FullCommand():
-		key.Create(*keyX509Org, *keyX509CN, *keyOutputDir, *keyLength, *keyDaysValid, *keyImportFlag, *keyCreatePrivKey, *keyCreatePubKey, *keyCreateOverwrite)
+		key.Create(*keyX509Org, *keyX509CN, *keyOutputDir, *keyLength, *keyDaysValid, *keyImportFlag, *keyCreatePrivKey, *keyCreatePubKey, *keyCreateOverwrite, *keyAlgorithm)
 	case keyImportCmd.FullCommand():
 		key.Import(*keyImportPubKeyFile)
 	case keyDelCmd.FullCommand():
 		key.Remove(*keyDelName)
 	case nodeListCmd.FullCommand():
 		node.List()
+	case nodeExportCmd.FullCommand():
+		node.Export(*nodeExportFile, *nodeExportPrivKeyFile)
+	case nodeImportCmd.FullCommand():
+		node.Import(*nodeImportFile, *nodeImportPubKeyFile, *nodeImportIdTok)
 	case policyListCmd.FullCommand():
 		policy.List()
 	case policyNewCmd.FullCommand():
@@ -1302,7 +1345,7 @@ Environment Variables:
 	case policyRemoveCmd.FullCommand():
 		policy.Remove(*policyRemoveForce)
 	case policyCompCmd.FullCommand():
-		deploycheck.PolicyCompatible(*deploycheckOrg, *deploycheckUserPw, *policyCompNodeId, *policyCompHAGroup, *policyCompNodeArch, *policyCompNodeType, *policyCompNodeNs, *policyCompNodeIsNamespaceScoped, *policyCompNodePolFile, *policyCompBPolId, *policyCompBPolFile, *policyCompSPolFile, *policyCompSvcFile, *deploycheckCheckAll, *deploycheckLong)
+		deploycheck.PolicyCompatible(*deploycheckOrg, *deploycheckUserPw, *policyCompNodeId, *policyCompHAGroup, *policyCompNodeArch, *policyCompNodeType, *policyCompNodeNs, *policyCompNodeIsNamespaceScoped, *policyCompNodePolFile, *policyCompBPolId, *policyCompBPolFile, *policyCompSPolFile, *policyCompSvcFile, *deploycheckCheckAll, *deploycheckLong, *policyCompExplain)
 	case userinputCompCmd.FullCommand():
 		deploycheck.UserInputCompatible(*deploycheckOrg, *deploycheckUserPw, *userinputCompNodeId, *userinputCompNodeArch, *userinputCompNodeType, *userinputCompNodeUIFile, *userinputCompBPolId, *userinputCompBPolFile, *userinputCompPatternId, *userinputCompPatternFile, *userinputCompSvcFile, *deploycheckCheckAll, *deploycheckLong)
 	case secretCompCmd.FullCommand():
@@ -1375,6 +1418,14 @@ Environment Variables:
 		agreementbot.List()
 	case agbotPolicyListCmd.FullCommand():
 		agreementbot.PolicyList(*agbotPolicyOrg, *agbotPolicyName)
+	case agbotRolloutListCmd.FullCommand():
+		agreementbot.RolloutList(*agbotRolloutListOrg, *agbotRolloutListName)
+	case agbotRolloutPauseCmd.FullCommand():
+		agreementbot.RolloutAction(*agbotRolloutPauseOrg, *agbotRolloutPauseName, "pause")
+	case agbotRolloutResumeCmd.FullCommand():
+		agreementbot.RolloutAction(*agbotRolloutResumeOrg, *agbotRolloutResumeName, "resume")
+	case agbotRolloutAbortCmd.FullCommand():
+		agreementbot.RolloutAction(*agbotRolloutAbortOrg, *agbotRolloutAbortName, "abort")
 	case utilSignCmd.FullCommand():
 		utilcmds.Sign(*utilSignPrivKeyFile)
 	case utilVerifyCmd.FullCommand():
@@ -1390,9 +1441,11 @@ Environment Variables:
 	case mmsObjectNewCmd.FullCommand():
 		sync_service.ObjectNew(*mmsOrg)
 	case mmsObjectPublishCmd.FullCommand():
-		sync_service.ObjectPublish(*mmsOrg, *mmsUserPw, *mmsObjectPublishType, *mmsObjectPublishId, *mmsObjectPublishPat, *mmsObjectPublishDef, *mmsObjectPublishObj, *mmsObjectPublishNoChunkUpload, *mmsObjectPublishChunkUploadDataSize, *mmsObjectPublishSkipIntegrityCheck, *mmsObjectPublishDSHashAlgo, *mmsObjectPublishDSHash, *mmsObjectPublishPrivKeyFile)
+		sync_service.ObjectPublish(*mmsOrg, *mmsUserPw, *mmsObjectPublishType, *mmsObjectPublishId, *mmsObjectPublishPat, *mmsObjectPublishDef, *mmsObjectPublishObj, *mmsObjectPublishNoChunkUpload, *mmsObjectPublishChunkUploadDataSize, *mmsObjectPublishSkipIntegrityCheck, *mmsObjectPublishDSHashAlgo, *mmsObjectPublishDSHash, *mmsObjectPublishPrivKeyFile, *mmsObjectPublishDeltaBase)
 	case mmsObjectDeleteCmd.FullCommand():
 		sync_service.ObjectDelete(*mmsOrg, *mmsUserPw, *mmsObjectDeleteType, *mmsObjectDeleteId)
+	case mmsObjectRollbackCmd.FullCommand():
+		sync_service.ObjectRollback(*mmsOrg, *mmsUserPw, *mmsObjectRollbackType, *mmsObjectRollbackId, *mmsObjectRollbackVersion, *mmsObjectRollbackUnpin)
 	case mmsObjectDownloadCmd.FullCommand():
 		sync_service.ObjectDownLoad(*mmsOrg, *mmsUserPw, *mmsObjectDownloadType, *mmsObjectDownloadId, *mmsObjectDownloadFile, *mmsObjectDownloadOverwrite, *mmsObjectDownloadSkipIntegrityCheck)
 	case mmsObjectTypesCmd.FullCommand():
@@ -1,15 +1,23 @@
 package key
 
 import (
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
 	"encoding/json"
+	"encoding/pem"
 	"fmt"
 	"github.com/open-horizon/anax/api"
 	"github.com/open-horizon/anax/cli/cliutils"
+	"github.com/open-horizon/anax/cutil"
 	"github.com/open-horizon/anax/i18n"
+	"github.com/open-horizon/rsapss-tool/constants"
 	"github.com/open-horizon/rsapss-tool/generatekeys"
+	"math/big"
 	"net/http"
 	"os"
 	"path/filepath"
+	"regexp"
 	"strings"
 	"time"
 )
@@ -78,16 +86,28 @@ func List(keyName string, listAll bool) {
 }
 
 // Create generates a private/public key pair
-func Create(x509Org, x509CN, outputDir string, keyLength, daysValid int, importKey bool, privKeyFile string, pubKeyFile string, overwrite bool) {
+func Create(x509Org, x509CN, outputDir string, keyLength, daysValid int, importKey bool, privKeyFile string, pubKeyFile string, overwrite bool, algorithm string) {
 	// get message printer
 	msgPrinter := i18n.GetMessagePrinter()
 
+	if algorithm != cutil.SIGNING_ALGO_RSA && algorithm != cutil.SIGNING_ALGO_ECDSA && algorithm != cutil.SIGNING_ALGO_ED25519 {
+		cliutils.Fatal(cliutils.CLI_INPUT_ERROR, msgPrinter.Sprintf("key algorithm %v is not supported, it must be %v, %v or %v", algorithm, cutil.SIGNING_ALGO_RSA, cutil.SIGNING_ALGO_ECDSA, cutil.SIGNING_ALGO_ED25519))
+	}
+
 	// verify input, confirm overwrites, remove existing files, create dirs
 	genDir, privKeyFile, pubKeyFile := verifyAndPrepareKeyCreateInput(outputDir, privKeyFile, pubKeyFile, overwrite)
 
-	msgPrinter.Printf("Creating RSA PSS private and public keys, and an x509 certificate for distribution. This is a CPU-intensive operation and, depending on key length and platform, may take a while. Key generation on an amd64 or ppc64 system using the default key length will complete in less than 1 minute.")
-	msgPrinter.Println()
-	newKeys, err := generatekeys.Write(genDir, keyLength, x509CN, x509Org, time.Now().AddDate(0, 0, daysValid))
+	var newKeys []string
+	var err error
+	if algorithm == cutil.SIGNING_ALGO_RSA {
+		msgPrinter.Printf("Creating RSA PSS private and public keys, and an x509 certificate for distribution. This is a CPU-intensive operation and, depending on key length and platform, may take a while. Key generation on an amd64 or ppc64 system using the default key length will complete in less than 1 minute.")
+		msgPrinter.Println()
+		newKeys, err = generatekeys.Write(genDir, keyLength, x509CN, x509Org, time.Now().AddDate(0, 0, daysValid))
+	} else {
+		msgPrinter.Printf("Creating %v private and public keys, and an x509 certificate for distribution.", algorithm)
+		msgPrinter.Println()
+		newKeys, err = writeKeyPair(genDir, algorithm, x509CN, x509Org, time.Now().AddDate(0, 0, daysValid))
+	}
 	if err != nil {
 		cliutils.Fatal(cliutils.CLI_GENERAL_ERROR, msgPrinter.Sprintf("failed to create a new key pair: %v", err))
 	}
@@ -261,3 +281,66 @@ func confirmOverwrite(privKeyFile string, pubKeyFile string, overwrite bool) {
 		}
 	}
 }
+
+// Write a new ECDSA or Ed25519 private key and a self-signed x509 cert of its public key to the output directory. The files
+// are named like the RSA key pairs, <org>-<cert serial>-private.key and <org>-<cert serial>-public.pem.
+func writeKeyPair(outputDir string, algorithm string, cn string, org string, certNotValidAfter time.Time) ([]string, error) {
+	now := time.Now()
+	notBefore := now.Add(-12 * time.Hour)
+	if certNotValidAfter.Sub(notBefore) > time.Duration(constants.MaxSelfSignedCertExpirationDays)*24*time.Hour {
+		return nil, fmt.Errorf("x509 certificate validity date unacceptable. Please specify a time from request less than %d days away", constants.MaxSelfSignedCertExpirationDays-1)
+	}
+
+	privateKey, err := cutil.GenerateSigningKey(algorithm, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	// the serial is a positive random number of up to 20 octets (cf. rfc5280 4.1.2.2)
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 159))
+	if err != nil {
+		return nil, err
+	}
+	serial.Add(serial, big.NewInt(1))
+
+	name := pkix.Name{
+		CommonName:   cn,
+		Organization: []string{org},
+	}
+	template := x509.Certificate{
+		SerialNumber:          serial,
+		Issuer:                name,
+		Subject:               name,
+		NotBefore:             notBefore,
+		NotAfter:              certNotValidAfter,
+		KeyUsage:              x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+		IsCA:                  false,
+	}
+	certDer, err := x509.CreateCertificate(rand.Reader, &template, &template, privateKey.Public(), privateKey)
+	if err != nil {
+		return nil, err
+	}
+	privPem, err := cutil.MarshalSigningKeyPEM(privateKey)
+	if err != nil {
+		return nil, err
+	}
+
+	orgFilenamePattern := regexp.MustCompile(`[\]\[ ,.!@#$%^&*()<>?/\\{}~]+`)
+	fileOutPrefix := fmt.Sprintf("%s-%x-", orgFilenamePattern.ReplaceAllLiteralString(org, ""), serial)
+
+	certPath := filepath.Join(outputDir, fileOutPrefix+"public.pem")
+	privPath := filepath.Join(outputDir, fileOutPrefix+"private.key")
+	for _, p := range []string{certPath, privPath} {
+		if _, err := os.Stat(p); err == nil {
+			return nil, fmt.Errorf("File already exists: %v", p)
+		}
+	}
+
+	if err := os.WriteFile(certPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certDer}), 0644); err != nil {
+		return nil, err
+	} else if err := os.WriteFile(privPath, privPem, 0600); err != nil {
+		return nil, err
+	}
+	return []string{privPath, certPath}, nil
+}
@@ -1,8 +1,7 @@
 package kube_deployment
 
 import (
-	"crypto/rsa"
-	"crypto/sha256"
+	"crypto"
 	"encoding/base64"
 	"encoding/json"
 	"errors"
@@ -14,8 +13,8 @@ import (
 	"github.com/open-horizon/anax/cli/dev"
 	"github.com/open-horizon/anax/cli/plugin_registry"
 	"github.com/open-horizon/anax/common"
+	"github.com/open-horizon/anax/cutil"
 	"github.com/open-horizon/anax/i18n"
-	"github.com/open-horizon/rsapss-tool/sign"
 )
 
 const KUBE_DEPLOYMENT_CONFIG_TYPE = "cluster"
@@ -31,7 +30,7 @@ func NewKubeDeploymentConfigPlugin() plugin_registry.DeploymentConfigPlugin {
 	return new(KubeDeploymentConfigPlugin)
 }
 
-func (p *KubeDeploymentConfigPlugin) Sign(dep map[string]interface{}, privKey *rsa.PrivateKey, ctx plugin_registry.PluginContext) (bool, string, string, error) {
+func (p *KubeDeploymentConfigPlugin) Sign(dep map[string]interface{}, privKey crypto.Signer, ctx plugin_registry.PluginContext) (bool, string, string, error) {
 
 	// get message printer
 	msgPrinter := i18n.GetMessagePrinter()
@@ -40,11 +39,15 @@ func (p *KubeDeploymentConfigPlugin) Sign(dep map[string]interface{}, privKey *r
 		return owned, "", "", err
 	}
 
-	// Grab the kube operator file from the deployment config. The file might be relative to the
-	// service definition file.
-	operatorFilePath := dep["operatorYamlArchive"].(string)
+	// Grab the kube operator file, or the manifest archive, from the deployment config. The file might be relative
+	// to the service definition file.
+	archiveKey := "operatorYamlArchive"
+	if _, ok := dep["manifestArchive"]; ok {
+		archiveKey = "manifestArchive"
+	}
+	operatorFilePath := dep[archiveKey].(string)
 	if operatorFilePath = filepath.Clean(operatorFilePath); operatorFilePath == "." {
-		return true, "", "", errors.New(msgPrinter.Sprintf("cleaned %v resulted in an empty string.", dep["operatorYamlArchive"].(string)))
+		return true, "", "", errors.New(msgPrinter.Sprintf("cleaned %v resulted in an empty string.", dep[archiveKey].(string)))
 	}
 
 	if currentDir, ok := (ctx.Get("currentDir")).(string); !ok {
@@ -56,16 +59,20 @@ func (p *KubeDeploymentConfigPlugin) Sign(dep map[string]interface{}, privKey *r
 	// Get the base 64 encoding of the kube operator, and put it into the deployment config.
 	b64, err := ConvertFileToB64String(operatorFilePath)
 	if err != nil {
-		return true, "", "", errors.New(msgPrinter.Sprintf("unable to read kube operator %v, error %v", dep["operatorYamlArchive"], err))
+		return true, "", "", errors.New(msgPrinter.Sprintf("unable to read kube operator %v, error %v", dep[archiveKey], err))
 	}
-	dep["operatorYamlArchive"] = b64
+	dep[archiveKey] = b64
 
 	if _, ok := dep["metadata"]; ok {
 		return true, "", "", errors.New(msgPrinter.Sprintf("'metadata' in 'clusterDeployment' should not be set. Remove 'metadata' inside 'clusterDeployment' before publishing service"))
 	}
 
 	md := make(map[string]interface{}, 0)
-	namespaceInOperator, err := common.GetKubeOperatorNamespace(b64)
+	getNamespace := common.GetKubeOperatorNamespace
+	if archiveKey == "manifestArchive" {
+		getNamespace = common.GetManifestNamespace
+	}
+	namespaceInOperator, err := getNamespace(b64)
 	if err != nil {
 		return true, "", "", errors.New(msgPrinter.Sprintf("failed to get namespace from kube operator %v, error %v", operatorFilePath, err))
 	} else if namespaceInOperator != "" {
@@ -98,12 +105,7 @@ func (p *KubeDeploymentConfigPlugin) Sign(dep map[string]interface{}, privKey *r
 	}
 	depStr := string(deployment)
 
-	hasher := sha256.New()
-	_, err = hasher.Write(deployment)
-	if err != nil {
-		return true, "", "", err
-	}
-	sig, err := sign.Sha256HashOfInput(privKey, hasher)
+	sig, err := cutil.SignInput(privKey, deployment)
 
 	if err != nil {
 		return true, "", "", errors.New(msgPrinter.Sprintf("problem signing %v deployment string: %v", KUBE_DEPLOYMENT_CONFIG_TYPE, err))
@@ -141,14 +143,27 @@ func (p *KubeDeploymentConfigPlugin) Validate(dep interface{}, cdep interface{})
 		return false, nil
 	}
 
-	if dc, ok := cdep.(map[string]interface{}); !ok {
+	dc, ok := cdep.(map[string]interface{})
+	if !ok {
 		return false, nil
-	} else if c, ok := dc["operatorYamlArchive"]; !ok {
+	}
+
+	// The archive is either a kube operator, or a bundle of plain manifests or a Kustomize directory.
+	_, hasOperator := dc["operatorYamlArchive"]
+	_, hasManifest := dc["manifestArchive"]
+	archiveKey := "operatorYamlArchive"
+	if !hasOperator && !hasManifest {
 		return false, nil
-	} else if ca, ok := c.(string); !ok {
-		return true, errors.New(msgPrinter.Sprintf("operatorYamlArchive must have a string type value, has %T", c))
+	} else if hasOperator && hasManifest {
+		return true, errors.New(msgPrinter.Sprintf("only one of operatorYamlArchive and manifestArchive can be specified"))
+	} else if hasManifest {
+		archiveKey = "manifestArchive"
+	}
+
+	if ca, ok := dc[archiveKey].(string); !ok {
+		return true, errors.New(msgPrinter.Sprintf("%v must have a string type value, has %T", archiveKey, dc[archiveKey]))
 	} else if len(ca) == 0 {
-		return true, errors.New(msgPrinter.Sprintf("operatorYamlArchive must be non-empty strings"))
+		return true, errors.New(msgPrinter.Sprintf("%v must be non-empty strings", archiveKey))
 	} else {
 		return true, nil
 	}
@@ -1,8 +1,7 @@
 package native_deployment
 
 import (
-	"crypto/rsa"
-	"crypto/sha256"
+	"crypto"
 	"encoding/json"
 	"errors"
 	dockerclient "github.com/fsouza/go-dockerclient"
@@ -15,7 +14,6 @@ import (
 	"github.com/open-horizon/anax/containermessage"
 	"github.com/open-horizon/anax/cutil"
 	"github.com/open-horizon/anax/i18n"
-	"github.com/open-horizon/rsapss-tool/sign"
 )
 
 func init() {
@@ -29,7 +27,7 @@ func NewNativeDeploymentConfigPlugin() plugin_registry.DeploymentConfigPlugin {
 	return new(NativeDeploymentConfigPlugin)
 }
 
-func (p *NativeDeploymentConfigPlugin) Sign(dep map[string]interface{}, privKey *rsa.PrivateKey, ctx plugin_registry.PluginContext) (bool, string, string, error) {
+func (p *NativeDeploymentConfigPlugin) Sign(dep map[string]interface{}, privKey crypto.Signer, ctx plugin_registry.PluginContext) (bool, string, string, error) {
 
 	// get message printer
 	msgPrinter := i18n.GetMessagePrinter()
@@ -70,12 +68,7 @@ func (p *NativeDeploymentConfigPlugin) Sign(dep map[string]interface{}, privKey
 	}
 	depStr := string(deployment)
 
-	hasher := sha256.New()
-	_, err = hasher.Write(deployment)
-	if err != nil {
-		return true, "", "", err
-	}
-	sig, err := sign.Sha256HashOfInput(privKey, hasher)
+	sig, err := cutil.SignInput(privKey, deployment)
 
 	if err != nil {
 		return true, "", "", errors.New(msgPrinter.Sprintf("problem signing deployment string: %v", err))
@@ -173,7 +166,7 @@ func (p *NativeDeploymentConfigPlugin) Validate(dep interface{}, cdep interface{
 }
 
 // This can't be a const because a map literal isn't a const in go
-var VALID_DEPLOYMENT_FIELDS = map[string]int8{"image": 1, "privileged": 1, "cap_add": 1, "environment": 1, "devices": 1, "binds": 1, "specific_ports": 1, "command": 1, "ports": 1, "ephemeral_ports": 1, "tmpfs": 1, "network": 1, "entrypoint": 1, "max_memory_mb": 1, "max_cpus": 1, "log_driver": 1, "secrets": 1, "pid": 1, "user": 1, "sysctls": 1, "ipc": 1}
+var VALID_DEPLOYMENT_FIELDS = map[string]int8{"image": 1, "privileged": 1, "cap_add": 1, "environment": 1, "devices": 1, "binds": 1, "specific_ports": 1, "command": 1, "ports": 1, "ephemeral_ports": 1, "tmpfs": 1, "network": 1, "entrypoint": 1, "max_memory_mb": 1, "max_cpus": 1, "log_driver": 1, "secrets": 1, "pid": 1, "user": 1, "sysctls": 1, "ipc": 1, "healthcheck": 1}
 
 // CheckDeploymentService verifies it has the required 'image' key, and checks for keys we don't recognize.
 // For now it only prints a warning for unrecognized keys, in case we recently added a key to anax and haven't updated hzn yet.
@@ -192,6 +185,18 @@ func CheckDeploymentService(svcName string, depSvc map[string]interface{}) error
 			cliutils.Warning(msgPrinter.Sprintf("service '%s' defined under 'deployment.services' has unrecognized field '%s'. See https://github.com/open-horizon/anax/blob/master/doc/deployment_string.md", svcName, k))
 		}
 
+		// Make sure the health check is something the agent can hand to docker.
+		if k == "healthcheck" {
+			var hc containermessage.HealthCheck
+			if bytes, err := json.Marshal(depSvc[k]); err != nil {
+				return errors.New(msgPrinter.Sprintf("service '%s' defined under 'deployment.services' has a malformed healthcheck value %v, error %v", svcName, depSvc[k], err))
+			} else if err := json.Unmarshal(bytes, &hc); err != nil {
+				return errors.New(msgPrinter.Sprintf("service '%s' defined under 'deployment.services' has a malformed healthcheck value %v, error %v", svcName, string(bytes), err))
+			} else if err := hc.Validate(); err != nil {
+				return errors.New(msgPrinter.Sprintf("service '%s' defined under 'deployment.services' has an invalid healthcheck: %v", svcName, err))
+			}
+		}
+
 		// Check for the use of the default agent API port, which will cause a port conflict at runtime.
 		if k == "ports" {
 			// Marshal and unmarshal the ports deployment config so that we can reuse typed APIs for parsing the host port
@@ -0,0 +1,102 @@
+package node
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/open-horizon/anax/api"
+	"github.com/open-horizon/anax/cli/cliutils"
+	"github.com/open-horizon/anax/cutil"
+	"github.com/open-horizon/anax/i18n"
+	"github.com/open-horizon/anax/persistence"
+	"os"
+	"strings"
+)
+
+// The file written by 'hzn node export'. The backup is signed in its compact JSON form, so that the file can be
+// reformatted without invalidating the signature.
+type NodeBackupArchive struct {
+	Backup    json.RawMessage `json:"backup"`
+	Signature string          `json:"signature"`
+}
+
+// Export the state of the node from the agent into a signed archive file. The archive contains the node's token, so
+// the file is only readable by the user.
+func Export(archiveFile string, privKeyFile string) {
+	msgPrinter := i18n.GetMessagePrinter()
+
+	privKeyFilePath := cliutils.WithDefaultEnvVar(&privKeyFile, "HZN_PRIVATE_KEY_FILE")
+	privKeyFile = cliutils.VerifySigningKeyInput(*privKeyFilePath, false)
+
+	var backup persistence.NodeBackup
+	cliutils.HorizonGet("node/backup", []int{200}, &backup, false)
+
+	backupBytes, err := json.Marshal(backup)
+	if err != nil {
+		cliutils.Fatal(cliutils.JSON_PARSING_ERROR, msgPrinter.Sprintf("failed to marshal node backup: %v", err))
+	}
+
+	signature, err := cliutils.SignInput(privKeyFile, backupBytes)
+	if err != nil {
+		cliutils.Fatal(cliutils.SIGNATURE_INVALID, msgPrinter.Sprintf("failed to sign the node backup with %v: %v", privKeyFile, err))
+	}
+
+	archive := NodeBackupArchive{Backup: backupBytes, Signature: signature}
+	output := cliutils.MarshalIndent(archive, "node export")
+	if archiveFile == "-" {
+		msgPrinter.Println(output)
+		return
+	} else if err := os.WriteFile(archiveFile, []byte(output), 0600); err != nil {
+		cliutils.Fatal(cliutils.FILE_IO_ERROR, msgPrinter.Sprintf("failed to write node backup to %v: %v", archiveFile, err))
+	}
+
+	msgPrinter.Printf("Node %v/%v exported to %v.", backup.Org, backup.NodeId, archiveFile)
+	msgPrinter.Println()
+}
+
+// Verify the signature of a node backup archive and restore it into the agent. The agent verifies the signature again
+// with the public keys in its trust store. The node id and token in the backup are replaced when they are specified.
+func Import(archiveFile string, pubKeyFile string, nodeIdTok string) {
+	msgPrinter := i18n.GetMessagePrinter()
+
+	var archive NodeBackupArchive
+	cliutils.Unmarshal(cliutils.ReadFile(archiveFile), &archive, archiveFile)
+	if len(archive.Backup) == 0 || archive.Signature == "" {
+		cliutils.Fatal(cliutils.CLI_INPUT_ERROR, msgPrinter.Sprintf("%v is not a node backup archive.", archiveFile))
+	}
+
+	// The backup was signed in its compact form.
+	compact := new(bytes.Buffer)
+	if err := json.Compact(compact, archive.Backup); err != nil {
+		cliutils.Fatal(cliutils.JSON_PARSING_ERROR, msgPrinter.Sprintf("failed to read the node backup in %v: %v", archiveFile, err))
+	}
+
+	pubKeyFile = cliutils.GetAndVerifyPublicKey(pubKeyFile)
+	if verified, err := cutil.VerifyInput(pubKeyFile, archive.Signature, compact.Bytes()); err != nil {
+		cliutils.Fatal(cliutils.SIGNATURE_INVALID, msgPrinter.Sprintf("failed to verify the node backup signature with %v: %v", pubKeyFile, err))
+	} else if !verified {
+		cliutils.Fatal(cliutils.SIGNATURE_INVALID, msgPrinter.Sprintf("the node backup signature is not valid for public key %v.", pubKeyFile))
+	}
+
+	var backup persistence.NodeBackup
+	cliutils.Unmarshal(compact.Bytes(), &backup, archiveFile)
+	restore := api.NodeBackupRestore{Backup: compact.Bytes(), Signature: archive.Signature}
+	if nodeIdTok != "" {
+		restore.NodeId, restore.Token = cliutils.SplitIdToken(nodeIdTok)
+		// The node cannot move to another org, so the org prefix is optional.
+		if parts := strings.SplitN(restore.NodeId, "/", 2); len(parts) == 2 {
+			if parts[0] != backup.Org {
+				cliutils.Fatal(cliutils.CLI_INPUT_ERROR, msgPrinter.Sprintf("the node %v is not in the organization %v of the node backup.", restore.NodeId, backup.Org))
+			}
+			restore.NodeId = parts[1]
+		}
+	}
+
+	cliutils.HorizonPutPost("PUT", "node/backup", []int{201}, restore, true)
+
+	nodeId := backup.NodeId
+	if restore.NodeId != "" {
+		nodeId = restore.NodeId
+	}
+	msgPrinter.Printf("Node %v/%v restored from %v. Restart the Horizon agent to use the restored node.", backup.Org, nodeId, archiveFile)
+	msgPrinter.Println()
+}
@@ -1,13 +1,16 @@
 package sync_service
 
 import (
+	"bufio"
 	"bytes"
-	"crypto/rand"
+	"crypto"
 	"crypto/rsa"
+	"crypto/sha256"
 	"crypto/x509"
 	"encoding/base64"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"hash"
 	"io"
@@ -27,7 +30,6 @@ import (
 	"github.com/open-horizon/anax/exchange"
 	"github.com/open-horizon/anax/i18n"
 	"github.com/open-horizon/edge-sync-service/common"
-	"github.com/open-horizon/rsapss-tool/sign"
 )
 
 const BatchSize = 50
@@ -281,6 +283,8 @@ func ObjectNew(org string) {
 		`  },`,
 		`  "expiration": "",          /* ` + msgPrinter.Sprintf("A timestamp/date indicating when the object expires (it is automatically deleted). The timestamp should be provided in RFC3339 format.") + ` */`,
 		`  "version": "",             /* ` + msgPrinter.Sprintf("Arbitrary string value. The value is not semantically interpreted. The Model Management System does not keep multiple version of an object.") + ` */`,
+		`                             /* ` + msgPrinter.Sprintf("Nodes can retain previous versions, set the openhorizon.mms.retainVersions property in destinationPolicy.") + ` */`,
+		`                             /* ` + msgPrinter.Sprintf("Use 'hzn mms object rollback' to pin the nodes to a retained version.") + ` */`,
 		`  "description": "",         /* ` + msgPrinter.Sprintf("An arbitrary description.") + ` */`,
 		`  "activationTime": ""       /* ` + msgPrinter.Sprintf("A timestamp/date as to when this object should automatically be activated. The timestamp should be provided in RFC3339 format.") + ` */`,
 		`}`,
@@ -294,7 +298,7 @@ func ObjectNew(org string) {
 
 // Upload an object to the MMS. The user can provide a copy of the object's metadata in a file, or they can simply provide
 // object id and type.
-func ObjectPublish(org string, userPw string, objType string, objId string, objPattern string, objMetadataFile string, objFile string, noChunkUpload bool, chunkSize int, skipDigitalSig bool, dsHashAlgo string, dsHash string, privKeyFilePath string) {
+func ObjectPublish(org string, userPw string, objType string, objId string, objPattern string, objMetadataFile string, objFile string, noChunkUpload bool, chunkSize int, skipDigitalSig bool, dsHashAlgo string, dsHash string, privKeyFilePath string, deltaBase string) {
 	// get message printer
 	msgPrinter := i18n.GetMessagePrinter()
 
@@ -319,6 +323,10 @@ func ObjectPublish(org string, userPw string, objType string, objId string, objP
 		cliutils.Fatal(cliutils.CLI_INPUT_ERROR, msgPrinter.Sprintf("cannot specify --skipDigitalSig with --hashAlgo"))
 	} else if dsHashAlgo != "" && dsHashAlgo != common.Sha1 && dsHashAlgo != common.Sha256 {
 		cliutils.Fatal(cliutils.CLI_INPUT_ERROR, msgPrinter.Sprintf("invalid value for --hashAlgo, please use SHA1 or SHA256"))
+	} else if deltaBase != "" && objFile == "" {
+		cliutils.Fatal(cliutils.CLI_INPUT_ERROR, msgPrinter.Sprintf("must specify --object with --delta-base"))
+	} else if deltaBase != "" && dsHash != "" {
+		cliutils.Fatal(cliutils.CLI_INPUT_ERROR, msgPrinter.Sprintf("cannot specify --hash with --delta-base"))
 	}
 
 	// If we were given a full metadata file, read it in and use it to create the object. Otherwise, construct a minimal
@@ -336,36 +344,102 @@ func ObjectPublish(org string, userPw string, objType string, objId string, objP
 		objectMeta.DestType = objPattern
 	}
 
+	if deltaBase != "" && objectMeta.DestinationPolicy == nil {
+		cliutils.Fatal(cliutils.CLI_INPUT_ERROR, msgPrinter.Sprintf("object '%s' of type '%s' does not have a destination policy, only objects deployed by policy can be published with a delta", objectMeta.ObjectID, objectMeta.ObjectType))
+	}
+
+	// The delta is published as a companion object, which must not replace an object that is not a delta.
+	deltaExists, isDelta := false, false
+	if objFile != "" {
+		deltaExists, isDelta = findObjectDelta(org, userPw, objectMeta.ObjectType, objectMeta.ObjectID)
+	}
+	if deltaBase != "" && deltaExists && !isDelta {
+		cliutils.Fatal(cliutils.CLI_INPUT_ERROR, msgPrinter.Sprintf("object '%s' of type '%s' already exists and is not a delta, the delta of object '%s' cannot be published", cutil.DeltaObjectID(objectMeta.ObjectID), objectMeta.ObjectType, objectMeta.ObjectID))
+	}
+
+	// The data hash describes the data of the object. It is set again when new data is published with a delta, and is
+	// kept from the object in the MMS when only the metadata is updated, because the data has not changed.
+	if objectMeta.DestinationPolicy != nil {
+		cutil.RemoveObjectDelta(objectMeta.DestinationPolicy)
+	}
+	if objFile == "" {
+		keepObjectDataHash(org, userPw, &objectMeta)
+	}
+
 	// If there is no data to upload, set the metaonly flag to indicate that we are only updating the object's metadata. This ensures
 	// that the MMS (CSS) correctly interpets the PUT.
+	var deltaMeta *common.MetaData
+	deltaFile := ""
 	if objFile == "" {
 		objectMeta.MetaOnly = true
-	} else if !skipDigitalSig {
+	} else {
 
 		hashAlgorithm := common.Sha256
 		if dsHashAlgo == common.Sha1 {
 			hashAlgorithm = common.Sha1
 		}
 
-		msgPrinter.Printf("Digital sign with %s will be performed for data integrity. It will delay the MMS object publish.\n", hashAlgorithm)
-
-		// Create public key. Sign data. Set "hashAlgorithm", "publicKey" and "signature" field
-		file, err := os.Open(objFile)
-		defer cutil.CloseFileLogError(file)
-		if err != nil {
-			cliutils.Fatal(cliutils.CLI_INPUT_ERROR, msgPrinter.Sprintf("unable to open object file %v: %v", objFile, err))
+		// Create the delta from the previous version of the data, if it is smaller than the data.
+		if deltaBase != "" {
+			if deltaMeta, deltaFile = createObjectDelta(&objectMeta, objFile, deltaBase); deltaFile != "" {
+				defer os.Remove(deltaFile)
+			}
 		}
-		if publicKey, signature, err := SignObjData(file, hashAlgorithm, dsHash, privKeyFilePath); err != nil {
-			cliutils.Fatal(cliutils.CLI_GENERAL_ERROR, msgPrinter.Sprintf("failed to digital sign the file %v, Error: %v", objFile, err))
-		} else {
-			objectMeta.HashAlgorithm = hashAlgorithm
-			objectMeta.PublicKey = publicKey
-			objectMeta.Signature = signature
+
+		// The data and its delta are signed with the same key, so that the nodes can verify the data they reconstruct.
+		if !skipDigitalSig {
+			msgPrinter.Printf("Digital sign with %s will be performed for data integrity. It will delay the MMS object publish.\n", hashAlgorithm)
+
+			signer, err := getObjectSigner(privKeyFilePath)
+			if err != nil {
+				cliutils.Fatal(cliutils.CLI_GENERAL_ERROR, msgPrinter.Sprintf("failed to digital sign the file %v, Error: %v", objFile, err))
+			}
+			signObjectFile(&objectMeta, objFile, hashAlgorithm, dsHash, signer)
+			if deltaMeta != nil {
+				signObjectFile(deltaMeta, deltaFile, hashAlgorithm, "", signer)
+			}
+
+			msgPrinter.Printf("Digital sign finished.")
+			msgPrinter.Println()
 		}
+	}
 
-		msgPrinter.Printf("Digital sign finished.")
-		msgPrinter.Println()
+	// The companion object is published first, so that the nodes that have the base of the delta can reconstruct the data
+	// while they receive it. The companion of previous data is deleted when new data is published without a delta.
+	if deltaMeta != nil {
+		publishObject(org, userPw, *deltaMeta, deltaFile, noChunkUpload, chunkSize)
+		addObjectDeltaDestinations(org, userPw, objectMeta.ObjectType, objectMeta.ObjectID)
+	} else if objFile != "" && isDelta {
+		deleteObjectDelta(org, userPw, objectMeta.ObjectType, objectMeta.ObjectID)
+	}
+	publishObject(org, userPw, objectMeta, objFile, noChunkUpload, chunkSize)
+}
+
+// Sign the data in the file with the signer, and set the hash algorithm, public key and signature of the object.
+func signObjectFile(objectMeta *common.MetaData, objFile string, hashAlgorithm string, dsHash string, signer crypto.Signer) {
+	// get message printer
+	msgPrinter := i18n.GetMessagePrinter()
+
+	// Create public key. Sign data. Set "hashAlgorithm", "publicKey" and "signature" field
+	file, err := os.Open(objFile)
+	if err != nil {
+		cliutils.Fatal(cliutils.CLI_INPUT_ERROR, msgPrinter.Sprintf("unable to open object file %v: %v", objFile, err))
+	}
+	defer cutil.CloseFileLogError(file)
+	if publicKey, signature, err := signObjData(file, hashAlgorithm, dsHash, signer); err != nil {
+		cliutils.Fatal(cliutils.CLI_GENERAL_ERROR, msgPrinter.Sprintf("failed to digital sign the file %v, Error: %v", objFile, err))
+	} else {
+		objectMeta.HashAlgorithm = hashAlgorithm
+		objectMeta.PublicKey = publicKey
+		objectMeta.Signature = signature
 	}
+}
+
+// Put the metadata of an object in the MMS, then upload its data from the file, if there is one, and wait for the MMS to
+// be ready to send it.
+func publishObject(org string, userPw string, objectMeta common.MetaData, objFile string, noChunkUpload bool, chunkSize int) {
+	// get message printer
+	msgPrinter := i18n.GetMessagePrinter()
 
 	type ObjectWrapper struct {
 		Meta common.MetaData `json:"meta"`
@@ -457,6 +531,128 @@ func ObjectPublish(org string, userPw string, objType string, objId string, objP
 
 }
 
+// Copy the data hash of the object in the MMS to the new metadata of the object, when only the metadata is updated.
+func keepObjectDataHash(org string, userPw string, objectMeta *common.MetaData) {
+	if objectMeta.DestinationPolicy == nil {
+		return
+	}
+
+	var currentMeta common.MetaData
+	urlPath := path.Join("api/v1/objects/", org, objectMeta.ObjectType, objectMeta.ObjectID)
+	if httpCode := cliutils.ExchangeGet("Model Management Service", cliutils.GetMMSUrl(), urlPath, cliutils.OrgAndCreds(org, userPw), []int{200, 404}, &currentMeta); httpCode == 404 {
+		return
+	} else if dataHash := cutil.GetObjectDataHash(&currentMeta); dataHash != "" {
+		cutil.SetObjectPolicyProperty(objectMeta.DestinationPolicy, cutil.MMS_PROP_DATA_HASH, dataHash, "string")
+	}
+}
+
+// Returns true if there is an object with the ID of the companion object of an object, and true if that object is the
+// companion object that has the delta of the object.
+func findObjectDelta(org string, userPw string, objType string, objId string) (bool, bool) {
+	var deltaMeta common.MetaData
+	urlPath := path.Join("api/v1/objects/", org, objType, cutil.DeltaObjectID(objId))
+	if httpCode := cliutils.ExchangeGet("Model Management Service", cliutils.GetMMSUrl(), urlPath, cliutils.OrgAndCreds(org, userPw), []int{200, 404}, &deltaMeta); httpCode == 404 {
+		return false, false
+	}
+	return true, cutil.IsDeltaObject(&deltaMeta)
+}
+
+// Delete the companion object that has the delta of an object.
+func deleteObjectDelta(org string, userPw string, objType string, objId string) {
+	urlPath := path.Join("api/v1/objects/", org, objType, cutil.DeltaObjectID(objId))
+	cliutils.ExchangeDelete("Model Management Service", cliutils.GetMMSUrl(), urlPath, cliutils.OrgAndCreds(org, userPw), []int{204, 404})
+}
+
+// Add the destinations of an object to its companion object, so that the nodes that receive the new data of the object
+// receive its delta first. The agbot places the companion object on the nodes of its policy, which can be after the
+// nodes have received the new data in full.
+func addObjectDeltaDestinations(org string, userPw string, objType string, objId string) {
+	var objectDests []common.DestinationsStatus
+	urlPath := path.Join("api/v1/objects/", org, objType, objId, "destinations")
+	if httpCode := cliutils.ExchangeGet("Model Management Service", cliutils.GetMMSUrl(), urlPath, cliutils.OrgAndCreds(org, userPw), []int{200, 404}, &objectDests); httpCode == 404 || len(objectDests) == 0 {
+		return
+	}
+
+	postDestsRequest := exchange.PostDestsRequest{Action: common.AddAction, Destinations: make([]string, 0, len(objectDests))}
+	for _, dest := range objectDests {
+		postDestsRequest.Destinations = append(postDestsRequest.Destinations, dest.DestType+":"+dest.DestID)
+	}
+	urlPath = path.Join("api/v1/objects/", org, objType, cutil.DeltaObjectID(objId), "destinations")
+	cliutils.ExchangePutPost("Model Management Service", http.MethodPost, cliutils.GetMMSUrl(), urlPath, cliutils.OrgAndCreds(org, userPw), []int{200, 204}, postDestsRequest, nil)
+}
+
+// Create the delta from the base data to the object data in a temporary file, and set the hash of the data in the
+// object's destination policy. Returns the metadata of the companion object that has the delta, and the name of the
+// delta file, or nil and an empty string if the delta is not smaller than the data, in which case only the data is
+// published.
+func createObjectDelta(objectMeta *common.MetaData, objFile string, deltaBase string) (*common.MetaData, string) {
+	// get message printer
+	msgPrinter := i18n.GetMessagePrinter()
+
+	baseFile, err := os.Open(deltaBase)
+	if err != nil {
+		cliutils.Fatal(cliutils.CLI_INPUT_ERROR, msgPrinter.Sprintf("unable to open delta base file %v: %v", deltaBase, err))
+	}
+	defer cutil.CloseFileLogError(baseFile)
+	file, err := os.Open(objFile)
+	if err != nil {
+		cliutils.Fatal(cliutils.CLI_INPUT_ERROR, msgPrinter.Sprintf("unable to open object file %v: %v", objFile, err))
+	}
+	defer cutil.CloseFileLogError(file)
+
+	deltaFile, err := os.CreateTemp("", "hzn-object-delta-")
+	if err != nil {
+		cliutils.Fatal(cliutils.CLI_GENERAL_ERROR, msgPrinter.Sprintf("unable to create a temporary file for the delta: %v", err))
+	}
+	defer cutil.CloseFileLogError(deltaFile)
+
+	msgPrinter.Printf("Creating the delta from %v to %v...", deltaBase, objFile)
+	msgPrinter.Println()
+
+	// The hashes of the base and of the data are computed while the delta is made.
+	baseHash, dataHash := sha256.New(), sha256.New()
+	deltaWriter := bufio.NewWriter(deltaFile)
+	if err := cutil.WriteDelta(io.TeeReader(baseFile, baseHash), io.TeeReader(file, dataHash), deltaWriter); err != nil {
+		os.Remove(deltaFile.Name())
+		cliutils.Fatal(cliutils.CLI_GENERAL_ERROR, msgPrinter.Sprintf("failed to create the delta from %v to %v: %v", deltaBase, objFile, err))
+	} else if err := deltaWriter.Flush(); err != nil {
+		os.Remove(deltaFile.Name())
+		cliutils.Fatal(cliutils.CLI_GENERAL_ERROR, msgPrinter.Sprintf("failed to create the delta from %v to %v: %v", deltaBase, objFile, err))
+	}
+
+	// The nodes keep the data of an object that has a data hash, so that the next delta can be applied to it.
+	cutil.SetObjectPolicyProperty(objectMeta.DestinationPolicy, cutil.MMS_PROP_DATA_HASH, hex.EncodeToString(dataHash.Sum(nil)), "string")
+
+	deltaInfo, err := deltaFile.Stat()
+	if err != nil {
+		cliutils.Fatal(cliutils.CLI_GENERAL_ERROR, err.Error())
+	}
+	fileInfo, err := file.Stat()
+	if err != nil {
+		cliutils.Fatal(cliutils.CLI_GENERAL_ERROR, err.Error())
+	}
+	if deltaInfo.Size() >= fileInfo.Size() {
+		os.Remove(deltaFile.Name())
+		msgPrinter.Printf("The delta is not smaller than the object data, only the object data is published.")
+		msgPrinter.Println()
+		return nil, ""
+	}
+
+	// The companion object is deployed like the object, with the delta properties in its destination policy.
+	deltaMeta := *objectMeta
+	deltaMeta.ObjectID = cutil.DeltaObjectID(objectMeta.ObjectID)
+	policy := *objectMeta.DestinationPolicy
+	policy.Properties = append([]common.PolicyProperty{}, policy.Properties...)
+	cutil.RemoveObjectDelta(&policy)
+	cutil.SetObjectPolicyProperty(&policy, cutil.MMS_PROP_DELTA_BASE, hex.EncodeToString(baseHash.Sum(nil)), "string")
+	cutil.SetObjectPolicyProperty(&policy, cutil.MMS_PROP_DELTA_HASH, hex.EncodeToString(dataHash.Sum(nil)), "string")
+	deltaMeta.DestinationPolicy = &policy
+
+	msgPrinter.Printf("Publishing a delta of %v bytes of the %v bytes of object data in object %v.", deltaInfo.Size(), fileInfo.Size(), deltaMeta.ObjectID)
+	msgPrinter.Println()
+	return &deltaMeta, deltaFile.Name()
+}
+
 // Delete an object in the MMS.
 func ObjectDelete(org string, userPw string, objType string, objId string) {
 	// get message printer
@@ -491,11 +687,72 @@ func ObjectDelete(org string, userPw string, objType string, objId string) {
 		cliutils.Fatal(cliutils.NOT_FOUND, msgPrinter.Sprintf("object '%s' of type '%s' not found in org %s", objId, objType, org))
 	}
 
+	// The companion object that has the delta of the object is deleted with it.
+	if _, isDelta := findObjectDelta(org, userPw, objType, objId); isDelta {
+		deleteObjectDelta(org, userPw, objType, objId)
+	}
+
 	msgPrinter.Printf("Object %v deleted from org %v in the Model Management Service", objId, org)
 	msgPrinter.Println()
 
 }
 
+// Roll back an object in the MMS to a previous version that the nodes have retained, or unpin it. The object is pinned
+// by setting the pinned version property in its destination policy and updating only its metadata, so that the nodes
+// receive the new policy without receiving the data again.
+func ObjectRollback(org string, userPw string, objType string, objId string, version string, unpin bool) {
+	// get message printer
+	msgPrinter := i18n.GetMessagePrinter()
+
+	if userPw == "" {
+		cliutils.Fatal(cliutils.CLI_INPUT_ERROR, msgPrinter.Sprintf("must specify exchange credentials to access the model management service"))
+	}
+	if unpin && version != "" {
+		cliutils.Fatal(cliutils.CLI_INPUT_ERROR, msgPrinter.Sprintf("--version and --unpin are mutually exclusive"))
+	}
+
+	// Set the API key env var if that's what we're using.
+	cliutils.SetWhetherUsingApiKey(userPw)
+
+	// Get the current metadata of the object.
+	var objectMeta common.MetaData
+	urlPath := path.Join("api/v1/objects/", org, objType, objId)
+	httpCode := cliutils.ExchangeGet("Model Management Service", cliutils.GetMMSUrl(), urlPath, cliutils.OrgAndCreds(org, userPw), []int{200, 404}, &objectMeta)
+	if httpCode == 404 {
+		cliutils.Fatal(cliutils.NOT_FOUND, msgPrinter.Sprintf("object '%s' of type '%s' not found in org %s", objId, objType, org))
+	}
+	if objectMeta.DestinationPolicy == nil {
+		cliutils.Fatal(cliutils.CLI_INPUT_ERROR, msgPrinter.Sprintf("object '%s' of type '%s' does not have a destination policy, only objects deployed by policy can be rolled back", objId, objType))
+	}
+
+	if unpin {
+		if !cutil.RemoveObjectPolicyProperty(objectMeta.DestinationPolicy, cutil.MMS_PROP_PINNED_VERSION) {
+			msgPrinter.Printf("Object %v in org %v is not pinned to a version", objId, org)
+			msgPrinter.Println()
+			return
+		}
+	} else {
+		if version == "" {
+			version = cutil.MMS_PINNED_PREVIOUS
+		}
+		cutil.SetObjectPolicyProperty(objectMeta.DestinationPolicy, cutil.MMS_PROP_PINNED_VERSION, version, "string")
+	}
+
+	// Update only the metadata, the nodes keep the data of the object.
+	objectMeta.MetaOnly = true
+	wrapper := struct {
+		Meta common.MetaData `json:"meta"`
+	}{Meta: objectMeta}
+	cliutils.ExchangePutPost("Model Management Service", http.MethodPut, cliutils.GetMMSUrl(), urlPath, cliutils.OrgAndCreds(org, userPw), []int{204}, wrapper, nil)
+
+	if unpin {
+		msgPrinter.Printf("Object %v in org %v is unpinned, the nodes use the current version %v", objId, org, objectMeta.Version)
+	} else {
+		msgPrinter.Printf("Object %v in org %v is pinned to version %v on the nodes that retained it", objId, org, version)
+	}
+	msgPrinter.Println()
+}
+
 func ObjectTypes(org, userPw string) {
 	// get message printer
 	msgPrinter := i18n.GetMessagePrinter()
@@ -550,13 +807,39 @@ func timeIsValid(timestamp string) (bool, string) {
 	return true, ""
 }
 
+// Sign the hash of the object data with the private key, or with a new RSA key if there is none. Returns the base64
+// encoded public key and signature. The sync service (CSS and ESS) verifies the object data, and only supports RSA
+// keys, so the object data cannot be signed with ECDSA or Ed25519 keys.
 func SignObjData(objData io.Reader, dsHashAlgo string, dsHash string, privKeyFilePath string) (string, string, error) {
+	if privateKey, err := getObjectSigner(privKeyFilePath); err != nil {
+		return "", "", err
+	} else {
+		return signObjData(objData, dsHashAlgo, dsHash, privateKey)
+	}
+}
+
+// Returns the signer of the given private key file, or of the default key file. If there is no key file, an RSA key
+// pair is generated only for this use and then the private key is discarded.
+func getObjectSigner(privKeyFilePath string) (crypto.Signer, error) {
+	// use given key pair, if given, otherwise try to fetch default key file
+	privKeyFilePath_tmp := cliutils.WithDefaultEnvVar(&privKeyFilePath, "HZN_PRIVATE_KEY_FILE")
+	privKeyFilePath = cliutils.WithDefaultKeyFile(*privKeyFilePath_tmp, false)
+	if privKeyFilePath != "" {
+		return cliutils.GetSigner(privKeyFilePath)
+	}
+	// if there is no given private key or defualt value, generate private
+	// and public key pair
+	return cutil.GenerateSigningKey(cutil.SIGNING_ALGO_RSA, 2048)
+}
+
+// Sign the data, or the given hash of the data, with the signer. The base64 encoded public key and signature are
+// returned.
+func signObjData(objData io.Reader, dsHashAlgo string, dsHash string, privateKey crypto.Signer) (string, string, error) {
 	// get message printer
 	msgPrinter := i18n.GetMessagePrinter()
 
 	var fileHash hash.Hash
 	var fileHashSum []byte
-	var privateKey *rsa.PrivateKey
 	var err error
 
 	if dsHash != "" {
@@ -581,24 +864,15 @@ func SignObjData(objData io.Reader, dsHashAlgo string, dsHash string, privKeyFil
 		msgPrinter.Println()
 	}
 
-	// use given key pair, if given, otherwise try to fetch default key file
-	privKeyFilePath_tmp := cliutils.WithDefaultEnvVar(&privKeyFilePath, "HZN_PRIVATE_KEY_FILE")
-	privKeyFilePath = cliutils.WithDefaultKeyFile(*privKeyFilePath_tmp, false)
-	if privKeyFilePath != "" {
-		if privateKey, err = sign.ReadPrivateKey(privKeyFilePath); err != nil {
-			return "", "", err
-		}
-		// if there is no given private key or defualt value, generate private
-		// and public key pair
-	} else if privateKey, err = rsa.GenerateKey(rand.Reader, 2048); err != nil {
-		return "", "", err
+	if _, ok := privateKey.Public().(*rsa.PublicKey); !ok {
+		return "", "", errors.New(msgPrinter.Sprintf("the object data must be signed with an RSA key, the sync service does not verify signatures made with %T keys", privateKey.Public()))
 	}
 
-	if publicKeyBytes, err := x509.MarshalPKIXPublicKey(&privateKey.PublicKey); err != nil {
+	if publicKeyBytes, err := x509.MarshalPKIXPublicKey(privateKey.Public()); err != nil {
 		return "", "", err
 	} else if cryptoHash, err := cutil.GetCryptoHashType(dsHashAlgo); err != nil {
 		return "", "", err
-	} else if signature, err := rsa.SignPSS(rand.Reader, privateKey, cryptoHash, fileHashSum, nil); err != nil {
+	} else if signature, err := cutil.SignDigest(privateKey, cryptoHash, fileHashSum); err != nil {
 		return "", "", err
 	} else {
 		publicKeyString := base64.StdEncoding.EncodeToString(publicKeyBytes)
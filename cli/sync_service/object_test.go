@@ -0,0 +1,51 @@
+//go:build unit
+// +build unit
+
+package sync_service
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"testing"
+
+	"github.com/open-horizon/anax/cutil"
+)
+
+func Test_signObjData(t *testing.T) {
+	data := []byte("the object data")
+
+	// the sync service verifies RSA signatures of the object data
+	signer, err := cutil.GenerateSigningKey(cutil.SIGNING_ALGO_RSA, 2048)
+	if err != nil {
+		t.Fatalf("unable to generate RSA key: %v", err)
+	}
+	publicKey, signature, err := signObjData(bytes.NewReader(data), "SHA256", "", signer)
+	if err != nil {
+		t.Fatalf("unexpected error signing with an RSA key: %v", err)
+	}
+
+	// verify the signature the way the sync service does
+	publicKeyBytes, _ := base64.StdEncoding.DecodeString(publicKey)
+	signatureBytes, _ := base64.StdEncoding.DecodeString(signature)
+	digest := sha256.Sum256(data)
+	if pubKey, err := x509.ParsePKIXPublicKey(publicKeyBytes); err != nil {
+		t.Errorf("unable to parse public key: %v", err)
+	} else if err := rsa.VerifyPSS(pubKey.(*rsa.PublicKey), crypto.SHA256, digest[:], signatureBytes, nil); err != nil {
+		t.Errorf("signature does not verify: %v", err)
+	}
+
+	// it does not verify other signatures, so the data is never signed with other keys
+	for _, algorithm := range []string{cutil.SIGNING_ALGO_ECDSA, cutil.SIGNING_ALGO_ED25519} {
+		signer, err := cutil.GenerateSigningKey(algorithm, 0)
+		if err != nil {
+			t.Fatalf("unable to generate %v key: %v", algorithm, err)
+		}
+		if _, _, err := signObjData(bytes.NewReader(data), "SHA256", "", signer); err == nil {
+			t.Errorf("object data is signed with a %v key", algorithm)
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package systemd_deployment
+
+import (
+	"crypto"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/open-horizon/anax/cli/cliutils"
+	"github.com/open-horizon/anax/cli/dev"
+	"github.com/open-horizon/anax/cli/plugin_registry"
+	"github.com/open-horizon/anax/cutil"
+	"github.com/open-horizon/anax/i18n"
+	"github.com/open-horizon/anax/persistence"
+)
+
+func init() {
+	plugin_registry.Register("systemd", NewSystemdDeploymentConfigPlugin())
+}
+
+type SystemdDeploymentConfigPlugin struct {
+}
+
+func NewSystemdDeploymentConfigPlugin() plugin_registry.DeploymentConfigPlugin {
+	return new(SystemdDeploymentConfigPlugin)
+}
+
+// The package and unit file of a systemd service are MMS objects, so there is nothing to embed in the
+// deployment config. The deployment string is signed as is.
+func (p *SystemdDeploymentConfigPlugin) Sign(dep map[string]interface{}, privKey crypto.Signer, ctx plugin_registry.PluginContext) (bool, string, string, error) {
+
+	// get message printer
+	msgPrinter := i18n.GetMessagePrinter()
+
+	if owned, err := p.Validate(dep, nil); !owned || err != nil {
+		return owned, "", "", err
+	}
+
+	// Stringify and sign the deployment string.
+	deployment, err := json.Marshal(dep)
+	if err != nil {
+		return true, "", "", errors.New(msgPrinter.Sprintf("failed to marshal deployment string %v, error %v", dep, err))
+	}
+	depStr := string(deployment)
+
+	sig, err := cutil.SignInput(privKey, deployment)
+
+	if err != nil {
+		return true, "", "", errors.New(msgPrinter.Sprintf("problem signing deployment string: %v", err))
+	}
+
+	return true, depStr, sig, nil
+}
+
+// A systemd service does not run any container images.
+func (p *SystemdDeploymentConfigPlugin) GetContainerImages(dep interface{}) (bool, []string, error) {
+	owned, err := p.Validate(dep, nil)
+	return owned, []string{}, err
+}
+
+func (p *SystemdDeploymentConfigPlugin) DefaultConfig(imageInfo interface{}) interface{} {
+	return map[string]interface{}{
+		"systemd_unit":   "",
+		"object_type":    "",
+		"package_object": "",
+		"unit_object":    "",
+	}
+}
+
+// Return the default cluster config object, which is nil in this case.
+func (p *SystemdDeploymentConfigPlugin) DefaultClusterConfig() interface{} {
+	return nil
+}
+
+func (p *SystemdDeploymentConfigPlugin) Validate(dep interface{}, cdep interface{}) (bool, error) {
+	// get message printer
+	msgPrinter := i18n.GetMessagePrinter()
+
+	dc, ok := dep.(map[string]interface{})
+	if !ok {
+		return false, nil
+	} else if _, ok := dc["systemd_unit"]; !ok {
+		return false, nil
+	}
+
+	// The object org is optional, it defaults to the org of the service.
+	for _, key := range []string{"systemd_unit", "object_type", "package_object", "unit_object", "object_org"} {
+		v, ok := dc[key]
+		if !ok && key == "object_org" {
+			continue
+		} else if !ok {
+			return true, errors.New(msgPrinter.Sprintf("%v must be specified for a systemd deployment", key))
+		} else if s, ok := v.(string); !ok {
+			return true, errors.New(msgPrinter.Sprintf("%v must have a string type value, has %T", key, v))
+		} else if len(s) == 0 && key != "object_org" {
+			return true, errors.New(msgPrinter.Sprintf("%v must be a non-empty string", key))
+		}
+	}
+
+	if unitName := dc["systemd_unit"].(string); !persistence.IsValidUnitName(unitName) {
+		return true, errors.New(msgPrinter.Sprintf("systemd_unit %v must be a plain unit name made of letters, digits, ':', '_', '.' and '-', without the .service suffix", unitName))
+	}
+
+	return true, nil
+}
+
+func (p *SystemdDeploymentConfigPlugin) StartTest(homeDirectory string, userInputFile string, configFiles []string, configType string, noFSS bool, userCreds string, secretsFiles map[string]string) bool {
+
+	// get message printer
+	msgPrinter := i18n.GetMessagePrinter()
+
+	// Run verification before trying to start anything.
+	dev.ServiceValidate(homeDirectory, userInputFile, configFiles, configType, userCreds)
+
+	// Perform the common execution setup.
+	dir, _, _ := dev.CommonExecutionSetup(homeDirectory, userInputFile, dev.SERVICE_COMMAND, dev.SERVICE_START_COMMAND)
+
+	// Get the service definition, so that we can look at the user input variable definitions.
+	serviceDef, sderr := dev.GetServiceDefinition(dir, dev.SERVICE_DEFINITION_FILE)
+	if sderr != nil {
+		cliutils.Fatal(cliutils.CLI_GENERAL_ERROR, fmt.Sprintf("'%v %v' %v", dev.SERVICE_COMMAND, dev.SERVICE_START_COMMAND, sderr))
+	}
+
+	// Now that we have the service def, we can check if we own the deployment config object.
+	if owned, err := p.Validate(serviceDef.Deployment, nil); !owned || err != nil {
+		return false
+	}
+
+	cliutils.Fatal(cliutils.CLI_GENERAL_ERROR, msgPrinter.Sprintf("'%v %v' not supported for systemd deployments", dev.SERVICE_COMMAND, dev.SERVICE_START_COMMAND))
+
+	// For the compiler
+	return true
+}
+
+func (p *SystemdDeploymentConfigPlugin) StopTest(homeDirectory string) bool {
+
+	// get message printer
+	msgPrinter := i18n.GetMessagePrinter()
+
+	// Perform the common execution setup.
+	dir, _, _ := dev.CommonExecutionSetup(homeDirectory, "", dev.SERVICE_COMMAND, dev.SERVICE_START_COMMAND)
+
+	// Get the service definition, so that we can look at the user input variable definitions.
+	serviceDef, sderr := dev.GetServiceDefinition(dir, dev.SERVICE_DEFINITION_FILE)
+	if sderr != nil {
+		cliutils.Fatal(cliutils.CLI_GENERAL_ERROR, fmt.Sprintf("'%v %v' %v", dev.SERVICE_COMMAND, dev.SERVICE_START_COMMAND, sderr))
+	}
+
+	// Now that we have the service def, we can check if we own the deployment config object.
+	if owned, err := p.Validate(serviceDef.Deployment, nil); !owned || err != nil {
+		return false
+	}
+
+	cliutils.Fatal(cliutils.CLI_GENERAL_ERROR, msgPrinter.Sprintf("'%v %v' not supported for systemd deployments", dev.SERVICE_COMMAND, dev.SERVICE_START_COMMAND))
+
+	// For the compiler
+	return true
+}
@@ -4,15 +4,14 @@ import (
 	"fmt"
 	"github.com/open-horizon/anax/cli/cliconfig"
 	"github.com/open-horizon/anax/cli/cliutils"
+	"github.com/open-horizon/anax/cutil"
 	"github.com/open-horizon/anax/i18n"
-	"github.com/open-horizon/rsapss-tool/sign"
-	"github.com/open-horizon/rsapss-tool/verify"
 	"os"
 )
 
 func Sign(privKeyFilePath string) {
 	stdinBytes := cliutils.ReadStdin()
-	signature, err := sign.Input(privKeyFilePath, stdinBytes)
+	signature, err := cliutils.SignInput(privKeyFilePath, stdinBytes)
 	if err != nil {
 		cliutils.Fatal(cliutils.CLI_GENERAL_ERROR, i18n.GetMessagePrinter().Sprintf("problem signing stdin with %s: %v", privKeyFilePath, err))
 	}
@@ -24,7 +23,7 @@ func Verify(pubKeyFilePath, signature string) {
 	msgPrinter := i18n.GetMessagePrinter()
 
 	stdinBytes := cliutils.ReadStdin()
-	verified, err := verify.Input(pubKeyFilePath, signature, stdinBytes)
+	verified, err := cutil.VerifyInput(pubKeyFilePath, signature, stdinBytes)
 	if err != nil {
 		cliutils.Fatal(cliutils.CLI_GENERAL_ERROR, msgPrinter.Sprintf("problem verifying deployment string with %s: %v", pubKeyFilePath, err))
 	} else if !verified {
@@ -23,7 +23,6 @@ import (
 	"github.com/open-horizon/anax/policy"
 	"github.com/open-horizon/anax/version"
 	"github.com/open-horizon/anax/worker"
-	bolt "go.etcd.io/bbolt"
 	"os"
 	"path"
 	"reflect"
@@ -76,11 +75,11 @@ var cuwlog = func(v interface{}) string {
 
 type ClusterUpgradeWorker struct {
 	worker.BaseWorker
-	db         *bolt.DB
+	db         persistence.AgentDatabase
 	kubeClient *KubeClient
 }
 
-func NewClusterUpgradeWorker(name string, config *config.HorizonConfig, db *bolt.DB) *ClusterUpgradeWorker {
+func NewClusterUpgradeWorker(name string, config *config.HorizonConfig, db persistence.AgentDatabase) *ClusterUpgradeWorker {
 	kubeClient, err := NewKubeClient()
 	if err != nil {
 		glog.Errorf("Failed to instantiate kube Client for cluster upgrad worker: %v", err)
@@ -112,7 +111,7 @@ func (w *ClusterUpgradeWorker) Initialize() bool {
 	return true
 }
 
-func (w *ClusterUpgradeWorker) syncOnInit(db *bolt.DB, kubeClient *KubeClient, baseWorkingDir string) error {
+func (w *ClusterUpgradeWorker) syncOnInit(db persistence.AgentDatabase, kubeClient *KubeClient, baseWorkingDir string) error {
 	glog.Infof(cuwlog("In syncOnInit, now FindInitiatedNMPStatuses"))
 	if statuses, err := persistence.FindInitiatedNMPStatuses(db); err != nil {
 		return fmt.Errorf("failed to find nmp statuses in the local db: %v", err)
@@ -298,7 +297,7 @@ func (w *ClusterUpgradeWorker) Messages() chan events.Message {
 	return w.BaseWorker.Manager.Messages
 }
 
-func getEC(config *config.HorizonConfig, db *bolt.DB) *worker.BaseExchangeContext {
+func getEC(config *config.HorizonConfig, db persistence.AgentDatabase) *worker.BaseExchangeContext {
 	var ec *worker.BaseExchangeContext
 	if dev, _ := persistence.FindExchangeDevice(db); dev != nil {
 		ec = worker.NewExchangeContext(fmt.Sprintf("%v/%v", dev.Org, dev.Id), dev.Token, config.Edge.ExchangeURL, config.GetCSSURL(), config.Edge.AgbotURL, config.Collaborators.HTTPClientFactory)
@@ -20,7 +20,9 @@ import (
 	v1beta1scheme "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/serializer"
+	k8syaml "k8s.io/apimachinery/pkg/util/yaml"
 	"k8s.io/client-go/kubernetes/scheme"
+	"path"
 	"strings"
 )
 
@@ -127,7 +129,8 @@ func ConvertToDeploymentConfig(deployment interface{}, msgPrinter *message.Print
 
 type ClusterDeploymentConfig struct {
 	Metadata            map[string]interface{}             `json:"metadata,omitempty"`
-	OperatorYamlArchive string                             `json:"operatorYamlArchive"`
+	OperatorYamlArchive string                             `json:"operatorYamlArchive,omitempty"`
+	ManifestArchive     string                             `json:"manifestArchive,omitempty"`
 	Secrets             map[string]containermessage.Secret `json:"secrets"`
 	MMSPVC              map[string]interface{}             `json:"mmspvc,omitempty"`
 }
@@ -221,9 +224,15 @@ func GetClusterDeploymentMetadata(clusterDeployment interface{}, inspectOperator
 
 	// inspect the kube operator to get the namespace
 	if inspectOperatorForNS {
-		if tempData, ok := depConfig["operatorYamlArchive"]; ok {
+		getNamespace := GetKubeOperatorNamespace
+		tempData, ok := depConfig["operatorYamlArchive"]
+		if !ok {
+			tempData, ok = depConfig["manifestArchive"]
+			getNamespace = GetManifestNamespace
+		}
+		if ok {
 			if tarData, ok := tempData.(string); ok {
-				if ns, err := GetKubeOperatorNamespace(tarData); err != nil {
+				if ns, err := getNamespace(tarData); err != nil {
 					return nil, fmt.Errorf("%s", msgPrinter.Sprintf("Failed to get the namespace from the Kube operator. %v", err))
 				} else {
 					if metadata == nil {
@@ -247,6 +256,44 @@ func GetKubeOperatorNamespace(tar string) (string, error) {
 	return getK8sNamespaceObjectFromYaml(yamls), nil
 }
 
+// Returns the namespace of a manifest archive. For a Kustomize directory, this is the namespace of the top level
+// kustomization, which is the kustomization that is not a resource of any other kustomization in the archive.
+func GetManifestNamespace(tar string) (string, error) {
+	yamls, err := GetYamlFromTarGz(tar)
+	if err != nil {
+		return "", err
+	}
+
+	type kustomization struct {
+		Namespace string   `json:"namespace,omitempty"`
+		Resources []string `json:"resources,omitempty"`
+		Bases     []string `json:"bases,omitempty"`
+	}
+	kustomizations := map[string]kustomization{}
+	referenced := map[string]bool{}
+	for _, file := range yamls {
+		name := path.Clean(file.Header.Name)
+		if base := path.Base(name); base != "kustomization.yaml" && base != "kustomization.yml" && base != "Kustomization" {
+			continue
+		}
+		k := kustomization{}
+		if err := k8syaml.Unmarshal([]byte(file.Body), &k); err != nil {
+			return "", fmt.Errorf("unable to read kustomization %v: %v", name, err)
+		}
+		kustomizations[path.Dir(name)] = k
+		for _, res := range append(k.Resources, k.Bases...) {
+			referenced[path.Join(path.Dir(name), res)] = true
+		}
+	}
+
+	for dir, k := range kustomizations {
+		if !referenced[dir] && k.Namespace != "" {
+			return k.Namespace, nil
+		}
+	}
+	return getK8sNamespaceObjectFromYaml(yamls), nil
+}
+
 // Intermediate state used for after the objects have been read from the deployment but not converted to k8s objects yet
 type YamlFile struct {
 	Header tar.Header
@@ -12,7 +12,6 @@ import (
 	"github.com/open-horizon/anax/exchangecommon"
 	"github.com/open-horizon/anax/persistence"
 	"github.com/open-horizon/anax/version"
-	bolt "go.etcd.io/bbolt"
 )
 
 // The input data newStatus is either from the status.json file or from the /nodemanagement/status/nmp
@@ -25,7 +24,7 @@ import (
 // 4. remove the working directory if the status is 'successful'
 // nmp_id is "org/nmp_name".
 // It returns true if the status is changed. False if the status stays the same.
-func SetNodeManagementPolicyStatus(db *bolt.DB, pDevice *persistence.ExchangeDevice, nmp_id string,
+func SetNodeManagementPolicyStatus(db persistence.AgentDatabase, pDevice *persistence.ExchangeDevice, nmp_id string,
 	newStatus *exchangecommon.NodeManagementPolicyStatus,
 	dbStatus *exchangecommon.NodeManagementPolicyStatus,
 	putStatusHandler exchange.PutNodeManagementPolicyStatusHandler,
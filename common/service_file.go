@@ -204,6 +204,11 @@ func ValidateService(serviceDefResolverHandler exchange.ServiceDefResolverHandle
 		if requiredServices != nil {
 			for _, reqSvc := range requiredServices {
 
+				// check the readiness condition before going to the exchange
+				if err := reqSvc.Readiness.Validate(); err != nil {
+					return fmt.Errorf("%s", msgPrinter.Sprintf("The readiness for required service %v is not valid. %v", reqSvc, err))
+				}
+
 				// get the service definition for the required service and all of it dependents
 				ver := reqSvc.GetVersionRange()
 				vExp, err := semanticversion.Version_Expression_Factory(ver)
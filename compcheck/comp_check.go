@@ -148,14 +148,24 @@ type CompCheckOutput struct {
 	Compatible bool               `json:"compatible"`
 	Reason     map[string]string  `json:"reason"` // set when not compatible
 	Input      *CompCheckResource `json:"input,omitempty"`
+	// The constraint evaluation traces keyed by service id, set when an explanation is requested.
+	Explanation map[string]*PolicyExplanation `json:"explanation,omitempty"`
 }
 
 func (p *CompCheckOutput) String() string {
-	return fmt.Sprintf("Compatible: %v, Reason: %v, Input: %v",
-		p.Compatible, p.Reason, p.Input)
+	return fmt.Sprintf("Compatible: %v, Reason: %v, Input: %v, Explanation: %v",
+		p.Compatible, p.Reason, p.Input, p.Explanation)
 
 }
 
+// Attach the constraint evaluation traces to the output, an empty map leaves the output unchanged.
+func (p *CompCheckOutput) WithExplanation(explanation map[string]*PolicyExplanation) *CompCheckOutput {
+	if len(explanation) != 0 {
+		p.Explanation = explanation
+	}
+	return p
+}
+
 func NewCompCheckOutput(compatible bool, reason map[string]string, input *CompCheckResource) *CompCheckOutput {
 	return &CompCheckOutput{
 		Compatible: compatible,
@@ -343,7 +353,7 @@ func deployCompatible(getDeviceHandler exchange.DeviceHandler,
 	privOutput := NewCompCheckOutput(true, map[string]string{}, &CompCheckResource{})
 	if useBPol {
 		var err1 error
-		pcOutput, err1 = policyCompatible(getDeviceHandler, nodePolicyHandler, getBusinessPolicies, servicePolicyHandler, getSelectedServices, serviceDefResolverHandler, policyCheckInput, true, msgPrinter)
+		pcOutput, err1 = policyCompatible(getDeviceHandler, nodePolicyHandler, getBusinessPolicies, servicePolicyHandler, getSelectedServices, serviceDefResolverHandler, policyCheckInput, true, false, msgPrinter)
 		if err1 != nil {
 			return nil, err1
 		}
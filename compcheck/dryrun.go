@@ -0,0 +1,374 @@
+package compcheck
+
+import (
+	"fmt"
+	"github.com/golang/glog"
+	"github.com/open-horizon/anax/businesspolicy"
+	"github.com/open-horizon/anax/cutil"
+	"github.com/open-horizon/anax/exchange"
+	"github.com/open-horizon/anax/externalpolicy"
+	"github.com/open-horizon/anax/i18n"
+	"github.com/open-horizon/anax/persistence"
+	"github.com/open-horizon/anax/policy"
+	"golang.org/x/text/message"
+	"sort"
+	"time"
+)
+
+// The outcome of a dry run for a node.
+const (
+	DRYRUN_PROPOSE            = "propose"            // the agbot would send a proposal for the chosen service version
+	DRYRUN_EXISTING_AGREEMENT = "existing_agreement" // the node already has an agreement for the deployment policy
+	DRYRUN_INCOMPATIBLE       = "incompatible"       // none of the service versions can be deployed to the node
+	DRYRUN_NOT_READY          = "not_ready"          // the node is not registered or uses a pattern
+	DRYRUN_SUSPENDED          = "suspended"          // the chosen service version is suspended on the node
+	DRYRUN_DEFERRED           = "deferred"           // the node is outside of its maintenance window, the agbot proposes when it opens
+	DRYRUN_ROLLOUT_WAITING    = "rollout_waiting"    // the node keeps its agreement for the previous service version until its rollout wave starts
+	DRYRUN_NO_CAPACITY        = "no_capacity"        // the resources reserved on the node leave no room for the chosen service version
+	DRYRUN_ERROR              = "error"              // the node could not be evaluated
+)
+
+// The input format for the deployment policy dry run. The deployment policy is given by id, or in full when it has
+// not been published yet. When both are given, the id is used to look up the agbot state for the policy. The nodes
+// in node_orgs are evaluated, the deployment policy org is used when no node org is given. node_ids limits the dry
+// run to the given nodes.
+// swagger:model
+type DryRunCheck struct {
+	BusinessPolId  string                         `json:"business_policy_id,omitempty"`
+	BusinessPolicy *businesspolicy.BusinessPolicy `json:"business_policy,omitempty"`
+	NodeOrgs       []string                       `json:"node_orgs,omitempty"`
+	NodeIds        []string                       `json:"node_ids,omitempty"`
+}
+
+func (p DryRunCheck) String() string {
+	return fmt.Sprintf("BusinessPolId: %v, BusinessPolicy: %v, NodeOrgs: %v, NodeIds: %v", p.BusinessPolId, p.BusinessPolicy, p.NodeOrgs, p.NodeIds)
+}
+
+// The service version that the agbot would choose for a node.
+type DryRunService struct {
+	Org      string `json:"org"`
+	URL      string `json:"url"`
+	Version  string `json:"version"`
+	Arch     string `json:"arch"`
+	Priority int    `json:"priority,omitempty"`
+}
+
+// The place of a node in the service version rollout of a deployment policy. The waves are counted from 0, the same
+// way as in the rollout API.
+type DryRunRollout struct {
+	Version     string `json:"version"`      // the service version being rolled out
+	State       string `json:"state"`        // the state of the rollout
+	NodeState   string `json:"node_state"`   // the state of the node in the rollout
+	Wave        int    `json:"wave"`         // the wave of the node
+	CurrentWave int    `json:"current_wave"` // the wave the rollout is at
+}
+
+// The dry run result for a node.
+type DryRunNodeResult struct {
+	NodeId        string            `json:"node_id"`
+	Outcome       string            `json:"outcome"`
+	Service       *DryRunService    `json:"service,omitempty"`
+	AgreementId   string            `json:"agreement_id,omitempty"`
+	Rollout       *DryRunRollout    `json:"rollout,omitempty"`
+	DeferredUntil uint64            `json:"deferred_until,omitempty"` // when the maintenance window of the node opens
+	Reason        map[string]string `json:"reason,omitempty"`
+}
+
+// The output format for the deployment policy dry run.
+// swagger:model
+type DryRunOutput struct {
+	BusinessPolId string             `json:"business_policy_id"`
+	Nodes         []DryRunNodeResult `json:"nodes"`
+	Summary       map[string]int     `json:"summary"` // the number of nodes for each outcome
+}
+
+func (p *DryRunOutput) String() string {
+	return fmt.Sprintf("BusinessPolId: %v, Nodes: %v, Summary: %v", p.BusinessPolId, p.Nodes, p.Summary)
+}
+
+// The agbot state of a node for a deployment policy. The workload usage fields are the ones the agbot uses to
+// choose the service version, they are zero when the node has no workload usage record.
+type DryRunNodeState struct {
+	AgreementId  string // the id of the agreement the node has for the policy, empty if there is none
+	Priority     int
+	RetryCount   int
+	FirstTryTime uint64
+	DisableRetry bool
+	Rollout      *DryRunRollout // set when the node keeps its agreement until its wave of an active service rollout of the policy is upgraded
+}
+
+// Returns the agbot state of the node for the policy, nil if the agbot has no state for it.
+type DryRunNodeStateHandler func(nodeId string, policyName string) (*DryRunNodeState, error)
+
+// Returns the node resources reserved by the agreements the node has with any agbot.
+type DryRunCommittedResourcesHandler func(nodeId string) (*externalpolicy.NodeResources, error)
+
+// Returns the node resources needed by the containers of a service version and its dependencies.
+type serviceResourcesHandler func(svc *DryRunService) (*externalpolicy.NodeResources, error)
+
+// Runs the full deployment compatibility check for a node.
+type deployCheckHandler func(ccInput *CompCheck) (*CompCheckOutput, error)
+
+// Simulate the agreement initiation of the agbot for a deployment policy against the current exchange state. For each node
+// it reports whether the agbot would propose an agreement and with which service version, without sending proposals or
+// changing the agbot state. The resources reserved on the nodes are only checked when getCommitted is given.
+func DeploymentDryRun(ec exchange.ExchangeContext, agbotUrl string, drInput *DryRunCheck, getNodeState DryRunNodeStateHandler, getCommitted DryRunCommittedResourcesHandler, msgPrinter *message.Printer) (*DryRunOutput, error) {
+
+	getBusinessPolicies := exchange.GetHTTPBusinessPoliciesHandler(ec)
+	getOrgDevices := func(orgId string) (map[string]exchange.Device, error) {
+		return exchange.GetExchangeOrgDevices(ec.GetHTTPFactory(), orgId, ec.GetExchangeId(), ec.GetExchangeToken(), ec.GetExchangeURL())
+	}
+	deployCheck := func(ccInput *CompCheck) (*CompCheckOutput, error) {
+		return DeployCompatible(ec, agbotUrl, ccInput, true, msgPrinter)
+	}
+	getServiceDefs := exchange.GetHTTPServiceDefResolverHandler(ec)
+	getServiceResources := func(svc *DryRunService) (*externalpolicy.NodeResources, error) {
+		_, depServices, topSvcDef, _, err := getServiceDefs(svc.URL, svc.Org, svc.Version, svc.Arch)
+		if err != nil {
+			return nil, err
+		} else if topSvcDef == nil {
+			return nil, fmt.Errorf("%s", msgPrinter.Sprintf("Service %v/%v %v %v is not found in the exchange.", svc.Org, svc.URL, svc.Version, svc.Arch))
+		}
+		return ServiceResourceRequirements(&ServiceDefinition{Org: svc.Org, ServiceDefinition: *topSvcDef}, depServices, msgPrinter)
+	}
+
+	return deploymentDryRun(getBusinessPolicies, getOrgDevices, deployCheck, getNodeState, getCommitted, getServiceResources, drInput, msgPrinter)
+}
+
+// Internal function for DeploymentDryRun
+func deploymentDryRun(getBusinessPolicies exchange.BusinessPoliciesHandler,
+	getOrgDevices func(orgId string) (map[string]exchange.Device, error),
+	deployCheck deployCheckHandler,
+	getNodeState DryRunNodeStateHandler,
+	getCommitted DryRunCommittedResourcesHandler,
+	getServiceResources serviceResourcesHandler,
+	drInput *DryRunCheck, msgPrinter *message.Printer) (*DryRunOutput, error) {
+
+	// get default message printer if nil
+	if msgPrinter == nil {
+		msgPrinter = i18n.GetMessagePrinter()
+	}
+
+	if drInput == nil {
+		return nil, NewCompCheckError(fmt.Errorf("%s", msgPrinter.Sprintf("The DryRunCheck input cannot be null")), COMPCHECK_INPUT_ERROR)
+	} else if drInput.BusinessPolId == "" && drInput.BusinessPolicy == nil {
+		return nil, NewCompCheckError(fmt.Errorf("%s", msgPrinter.Sprintf("Neither deployment policy nor deployment policy id is specified.")), COMPCHECK_INPUT_ERROR)
+	}
+
+	// get the deployment policy once, every node is checked against the same copy
+	bPolicy, pPolicy, err := processBusinessPolicy(getBusinessPolicies, drInput.BusinessPolId, drInput.BusinessPolicy, true, msgPrinter)
+	if err != nil {
+		return nil, err
+	}
+
+	// the agbot state is kept under the exchange id of the deployment policy
+	policyName := drInput.BusinessPolId
+	nodeOrgs := drInput.NodeOrgs
+	if len(nodeOrgs) == 0 {
+		if policyName == "" {
+			return nil, NewCompCheckError(fmt.Errorf("%s", msgPrinter.Sprintf("The node organization must be specified for a deployment policy without an id.")), COMPCHECK_INPUT_ERROR)
+		}
+		nodeOrgs = []string{exchange.GetOrg(policyName)}
+	}
+
+	nodeFilter := map[string]bool{}
+	for _, nodeId := range drInput.NodeIds {
+		nodeFilter[nodeId] = true
+	}
+
+	output := DryRunOutput{BusinessPolId: policyName, Nodes: []DryRunNodeResult{}, Summary: map[string]int{}}
+	for _, org := range nodeOrgs {
+		devices, err := getOrgDevices(org)
+		if err != nil {
+			return nil, NewCompCheckError(fmt.Errorf("%s", msgPrinter.Sprintf("Unable to get the nodes in organization %v, %v", org, err)), COMPCHECK_EXCHANGE_ERROR)
+		}
+
+		for nodeId, dev := range devices {
+			if len(nodeFilter) != 0 && !nodeFilter[nodeId] {
+				continue
+			}
+			result := dryRunNode(nodeId, &dev, bPolicy, pPolicy, policyName, deployCheck, getNodeState, getCommitted, getServiceResources, time.Now(), msgPrinter)
+			glog.V(5).Infof("Dry run of deployment policy %v for node %v: %v", policyName, nodeId, result.Outcome)
+			output.Nodes = append(output.Nodes, *result)
+			output.Summary[result.Outcome]++
+		}
+	}
+
+	sort.Slice(output.Nodes, func(i, j int) bool { return output.Nodes[i].NodeId < output.Nodes[j].NodeId })
+	return &output, nil
+}
+
+// Evaluate one node the same way the agbot does when the node is returned by the policy search. The agbot checks the
+// maintenance window of the node before anything else, the dry run checks it once the service version is chosen so
+// that it can report the version that is proposed when the window opens.
+func dryRunNode(nodeId string, dev *exchange.Device, bPolicy *businesspolicy.BusinessPolicy, pPolicy *policy.Policy, policyName string,
+	deployCheck deployCheckHandler, getNodeState DryRunNodeStateHandler, getCommitted DryRunCommittedResourcesHandler,
+	getServiceResources serviceResourcesHandler, now time.Time, msgPrinter *message.Printer) *DryRunNodeResult {
+
+	result := DryRunNodeResult{NodeId: nodeId}
+	nodeError := func(err error) *DryRunNodeResult {
+		result.Outcome = DRYRUN_ERROR
+		result.Reason = map[string]string{"general": err.Error()}
+		return &result
+	}
+
+	// the policy search only returns nodes that have registered and are not using a pattern
+	if dev.PublicKey == "" {
+		result.Outcome = DRYRUN_NOT_READY
+		result.Reason = map[string]string{"general": msgPrinter.Sprintf("The node is not registered.")}
+		return &result
+	} else if dev.Pattern != "" {
+		result.Outcome = DRYRUN_NOT_READY
+		result.Reason = map[string]string{"general": msgPrinter.Sprintf("The node is registered with pattern %v.", dev.Pattern)}
+		return &result
+	}
+
+	var state *DryRunNodeState
+	if getNodeState != nil && policyName != "" {
+		var err error
+		if state, err = getNodeState(nodeId, policyName); err != nil {
+			return nodeError(err)
+		}
+	}
+
+	// the agbot does not start another agreement while one is in place for the node and policy. A node whose agreement
+	// is for the previous version of a service rollout is upgraded when its wave starts.
+	if state != nil && state.AgreementId != "" {
+		result.Outcome = DRYRUN_EXISTING_AGREEMENT
+		result.AgreementId = state.AgreementId
+		if r := state.Rollout; r != nil {
+			result.Outcome = DRYRUN_ROLLOUT_WAITING
+			result.Rollout = r
+			result.Reason = map[string]string{"general": msgPrinter.Sprintf("The node keeps its agreement for the previous service version, it is %v in wave %v of the rollout of version %v, which is %v at wave %v.", r.NodeState, r.Wave, r.Version, r.State, r.CurrentWave)}
+		}
+		return &result
+	}
+
+	ccOutput, err := deployCheck(&CompCheck{NodeId: nodeId, BusinessPolId: policyName, BusinessPolicy: bPolicy})
+	if err != nil {
+		return nodeError(err)
+	}
+	result.Reason = ccOutput.Reason
+
+	workload := chooseWorkload(pPolicy, state, func(wl *policy.Workload) bool {
+		reason, found := workloadReason(wl, dev.Arch, ccOutput.Reason)
+		return found && reason == msgPrinter.Sprintf("Compatible")
+	})
+	if workload == nil {
+		result.Outcome = DRYRUN_INCOMPATIBLE
+		return &result
+	}
+
+	arch := workload.Arch
+	if arch == "" || arch == "*" {
+		arch = dev.Arch
+	}
+	result.Service = &DryRunService{Org: workload.Org, URL: workload.WorkloadURL, Version: workload.Version, Arch: arch, Priority: workload.Priority.PriorityValue}
+	if found, suspended := exchange.ServiceSuspended(dev.RegisteredServices, workload.WorkloadURL, workload.Org, workload.Version); found && suspended {
+		result.Outcome = DRYRUN_SUSPENDED
+		return &result
+	}
+
+	// the node policy is returned by the compatibility check, there is nothing else to check without one
+	if ccOutput.Input == nil || ccOutput.Input.NodePolicy == nil {
+		result.Outcome = DRYRUN_PROPOSE
+		return &result
+	}
+	nodeProps := ccOutput.Input.NodePolicy.GetDeploymentPolicy().Properties
+
+	if result.Reason == nil {
+		result.Reason = map[string]string{}
+	}
+
+	if deferred, until := maintenanceWindowDeferred(nodeId, nodeProps, now); deferred {
+		result.Outcome = DRYRUN_DEFERRED
+		result.DeferredUntil = until
+		if until == 0 {
+			result.Reason["general"] = msgPrinter.Sprintf("The maintenance windows of the node never open.")
+		} else {
+			result.Reason["general"] = msgPrinter.Sprintf("The node is outside of its maintenance window, it opens at %v.", time.Unix(int64(until), 0).UTC().Format(time.RFC3339))
+		}
+		return &result
+	}
+
+	// the agbot checks the resources reserved by the other agreements of a device node when it creates the agreement
+	if getCommitted != nil && getServiceResources != nil && (dev.NodeType == "" || dev.NodeType == persistence.DEVICE_TYPE_DEVICE) && externalpolicy.GetNodeCapacity(nodeProps) != nil {
+		if required, err := getServiceResources(result.Service); err != nil {
+			return nodeError(err)
+		} else if !required.IsZero() {
+			if committed, err := getCommitted(nodeId); err != nil {
+				return nodeError(err)
+			} else if fits, reason := CheckNodeCapacity(nodeProps, committed, required, msgPrinter); !fits {
+				result.Outcome = DRYRUN_NO_CAPACITY
+				result.Reason["general"] = reason
+				return &result
+			}
+		}
+	}
+
+	result.Outcome = DRYRUN_PROPOSE
+	return &result
+}
+
+// Returns true if the maintenance windows of the node do not allow it to be changed at the given time, and the time
+// that they open, which is 0 when they never do. Like in the agbot, an invalid schedule never defers anything.
+func maintenanceWindowDeferred(nodeId string, nodeProps externalpolicy.PropertyList, now time.Time) (bool, uint64) {
+	schedule, err := externalpolicy.GetMaintenanceSchedule(nodeProps)
+	if err != nil {
+		glog.Errorf("Ignoring the maintenance windows of node %v in the dry run, error: %v", nodeId, err)
+		return false, 0
+	} else if schedule.Allows(now) {
+		return false, 0
+	} else if next, ok := schedule.NextAllowed(now); ok {
+		return true, uint64(next.Unix())
+	}
+	return true, 0
+}
+
+// Choose the workload the way the agbot does, starting from the workload usage state of the node. An incompatible workload
+// has its retries used up so that the next priority is tried, the choice ends when the same workload comes up twice.
+func chooseWorkload(pPolicy *policy.Policy, state *DryRunNodeState, compatible func(wl *policy.Workload) bool) *policy.Workload {
+
+	priority, retryCount, firstTryTime, disableRetry := 0, 0, uint64(0), false
+	if state != nil && (state.Priority != 0 || state.FirstTryTime != 0) {
+		priority, retryCount, firstTryTime, disableRetry = state.Priority, state.RetryCount+1, state.FirstTryTime, state.DisableRetry
+		if disableRetry {
+			retryCount = 0
+		}
+	}
+
+	var lastWorkload *policy.Workload
+	for {
+		workload := pPolicy.NextHighestPriorityWorkload(priority, retryCount, firstTryTime)
+		if workload == nil || (lastWorkload != nil && lastWorkload.IsSame(*workload)) {
+			return nil
+		} else if compatible(workload) {
+			return workload
+		}
+
+		// a single workload has no priority, there is nothing else to try
+		if !workload.HasEmptyPriority() {
+			priority, retryCount, firstTryTime = workload.Priority.PriorityValue, workload.Priority.Retries+2, uint64(time.Now().Unix())
+		}
+		lastWorkload = workload
+	}
+}
+
+// Returns the compatibility reason for the workload. The reason map is keyed by the exchange service id, the arch is
+// the node arch or it is dropped for the workloads that can run on any arch.
+func workloadReason(wl *policy.Workload, nodeArch string, reason map[string]string) (string, bool) {
+	arches := []string{wl.Arch}
+	if wl.Arch == "" || wl.Arch == "*" {
+		arches = []string{nodeArch, "*"}
+	}
+
+	for _, arch := range arches {
+		sId := fmt.Sprintf("%v/%v", wl.Org, cutil.FormExchangeIdForService(wl.WorkloadURL, wl.Version, arch))
+		if r, ok := reason[sId]; ok {
+			return r, true
+		} else if r, ok := reason[cutil.RemoveArchFromServiceId(sId)]; ok && arch == "*" {
+			return r, true
+		}
+	}
+	return "", false
+}
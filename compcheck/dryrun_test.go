@@ -0,0 +1,159 @@
+//go:build unit
+// +build unit
+
+package compcheck
+
+import (
+	"fmt"
+	"github.com/open-horizon/anax/businesspolicy"
+	"github.com/open-horizon/anax/cutil"
+	"github.com/open-horizon/anax/exchange"
+	"github.com/open-horizon/anax/exchangecommon"
+	"github.com/open-horizon/anax/externalpolicy"
+	"testing"
+	"time"
+)
+
+func Test_DeploymentDryRun(t *testing.T) {
+
+	service := businesspolicy.ServiceRef{
+		Name: "weather",
+		Org:  "myorg",
+		Arch: "amd64",
+		ServiceVersions: []businesspolicy.WorkloadChoice{
+			businesspolicy.WorkloadChoice{Version: "2.0.0", Priority: businesspolicy.WorkloadPriority{PriorityValue: 1, Retries: 1, RetryDurationS: 600}},
+			businesspolicy.WorkloadChoice{Version: "1.0.0", Priority: businesspolicy.WorkloadPriority{PriorityValue: 2, Retries: 1, RetryDurationS: 600}},
+		},
+	}
+	bHandler := getBusinessPolicyHandler(service, map[string]string{}, []string{})
+
+	sId := func(version string) string {
+		return fmt.Sprintf("myorg/%v", cutil.FormExchangeIdForService("weather", version, "amd64"))
+	}
+
+	devices := map[string]exchange.Device{
+		"myorg/n1": exchange.Device{PublicKey: "key", Arch: "amd64"},
+		"myorg/n2": exchange.Device{PublicKey: "key", Arch: "amd64"},
+		"myorg/n3": exchange.Device{PublicKey: "key", Arch: "amd64"},
+		"myorg/n4": exchange.Device{Arch: "amd64"},
+		"myorg/n5": exchange.Device{PublicKey: "key", Arch: "amd64", Pattern: "myorg/pat"},
+		"myorg/n6": exchange.Device{PublicKey: "key", Arch: "amd64", RegisteredServices: []exchange.Microservice{exchange.Microservice{Url: "myorg/weather", Version: "2.0.0", ConfigState: exchange.SERVICE_CONFIGSTATE_SUSPENDED}}},
+		"myorg/n7": exchange.Device{PublicKey: "key", Arch: "amd64"},
+		"myorg/n9": exchange.Device{PublicKey: "key", Arch: "amd64"},
+		"myorg/na": exchange.Device{PublicKey: "key", Arch: "amd64"},
+		"myorg/nb": exchange.Device{PublicKey: "key", Arch: "amd64"},
+		"myorg/nc": exchange.Device{PublicKey: "key", Arch: "amd64"},
+	}
+	getOrgDevices := func(orgId string) (map[string]exchange.Device, error) {
+		if orgId != "myorg" {
+			return nil, fmt.Errorf("unexpected org %v", orgId)
+		}
+		return devices, nil
+	}
+
+	// n2 can only run the lower priority version, n3 cannot run any version. n9 is in a blackout period that never ends,
+	// na and nb have room for 2 CPUs and nb has 1.5 of them reserved.
+	nodePolicy := func(props ...externalpolicy.Property) *CompCheckResource {
+		return &CompCheckResource{NodePolicy: &exchangecommon.NodePolicy{ExternalPolicy: externalpolicy.ExternalPolicy{Properties: props}}}
+	}
+	deployCheck := func(ccInput *CompCheck) (*CompCheckOutput, error) {
+		reason := map[string]string{sId("2.0.0"): COMPATIBLE, sId("1.0.0"): COMPATIBLE}
+		switch ccInput.NodeId {
+		case "myorg/n9":
+			return NewCompCheckOutput(true, reason, nodePolicy(*externalpolicy.Property_Factory(externalpolicy.PROP_NODE_BLACKOUT_PERIODS, "Mon-Sun 00:00-24:00"))), nil
+		case "myorg/na", "myorg/nb":
+			return NewCompCheckOutput(true, reason, nodePolicy(*externalpolicy.Property_Factory(externalpolicy.PROP_NODE_CPU, 2))), nil
+		case "myorg/n2":
+			reason[sId("2.0.0")] = "Policy Incompatible: constraint not satisfied"
+		case "myorg/n3":
+			reason = map[string]string{sId("2.0.0"): "User Input Incompatible", sId("1.0.0"): "Policy Incompatible"}
+		case "myorg/n8":
+			return nil, fmt.Errorf("unexpected node")
+		}
+		return NewCompCheckOutput(true, reason, &CompCheckResource{}), nil
+	}
+
+	// n1 has an agreement, n7 has used up the retries of the highest priority version, nc has an agreement for the
+	// previous version of a rollout and waits for its wave.
+	getNodeState := func(nodeId string, policyName string) (*DryRunNodeState, error) {
+		if policyName != "myorg/bp1" {
+			return nil, fmt.Errorf("unexpected policy name %v", policyName)
+		} else if nodeId == "myorg/n1" {
+			return &DryRunNodeState{AgreementId: "ag1"}, nil
+		} else if nodeId == "myorg/nc" {
+			return &DryRunNodeState{AgreementId: "ag2", Rollout: &DryRunRollout{Version: "2.0.0", State: "in_progress", NodeState: "pending", Wave: 2, CurrentWave: 0}}, nil
+		} else if nodeId == "myorg/n7" {
+			return &DryRunNodeState{Priority: 1, RetryCount: 1, FirstTryTime: uint64(time.Now().Unix())}, nil
+		}
+		return nil, nil
+	}
+
+	getCommitted := func(nodeId string) (*externalpolicy.NodeResources, error) {
+		if nodeId == "myorg/nb" {
+			return &externalpolicy.NodeResources{CPUs: 1.5}, nil
+		}
+		return &externalpolicy.NodeResources{}, nil
+	}
+	getServiceResources := func(svc *DryRunService) (*externalpolicy.NodeResources, error) {
+		return &externalpolicy.NodeResources{CPUs: 1}, nil
+	}
+
+	output, err := deploymentDryRun(bHandler, getOrgDevices, deployCheck, getNodeState, getCommitted, getServiceResources, &DryRunCheck{BusinessPolId: "myorg/bp1"}, nil)
+	if err != nil {
+		t.Fatalf("deploymentDryRun should not have returned error but got: %v", err)
+	} else if len(output.Nodes) != len(devices) {
+		t.Fatalf("expected %v nodes in the output but got %v", len(devices), output.Nodes)
+	}
+
+	expected := map[string][2]string{
+		"myorg/n1": {DRYRUN_EXISTING_AGREEMENT, ""},
+		"myorg/n2": {DRYRUN_PROPOSE, "1.0.0"},
+		"myorg/n3": {DRYRUN_INCOMPATIBLE, ""},
+		"myorg/n4": {DRYRUN_NOT_READY, ""},
+		"myorg/n5": {DRYRUN_NOT_READY, ""},
+		"myorg/n6": {DRYRUN_SUSPENDED, "2.0.0"},
+		"myorg/n7": {DRYRUN_PROPOSE, "1.0.0"},
+		"myorg/n9": {DRYRUN_DEFERRED, "2.0.0"},
+		"myorg/na": {DRYRUN_PROPOSE, "2.0.0"},
+		"myorg/nb": {DRYRUN_NO_CAPACITY, "2.0.0"},
+		"myorg/nc": {DRYRUN_ROLLOUT_WAITING, ""},
+	}
+	for _, node := range output.Nodes {
+		version := ""
+		if node.Service != nil {
+			version = node.Service.Version
+		}
+		if exp := expected[node.NodeId]; node.Outcome != exp[0] || version != exp[1] {
+			t.Errorf("node %v: expected outcome %v with version %v but got %v with version %v", node.NodeId, exp[0], exp[1], node.Outcome, version)
+		}
+	}
+	if output.Nodes[0].NodeId != "myorg/n1" || output.Nodes[0].AgreementId != "ag1" {
+		t.Errorf("expected the nodes to be sorted and node myorg/n1 to report agreement ag1 but got %v", output.Nodes[0])
+	} else if output.Summary[DRYRUN_PROPOSE] != 3 || output.Summary[DRYRUN_NOT_READY] != 2 {
+		t.Errorf("wrong summary: %v", output.Summary)
+	}
+	for _, node := range output.Nodes {
+		if node.NodeId == "myorg/nc" && (node.AgreementId != "ag2" || node.Rollout == nil || node.Rollout.Wave != 2) {
+			t.Errorf("expected node myorg/nc to report agreement ag2 in wave 2 of the rollout but got %v", node)
+		} else if node.NodeId == "myorg/n9" && (node.DeferredUntil != 0 || node.Reason["general"] == "") {
+			t.Errorf("expected node myorg/n9 to report that its maintenance windows never open but got %v", node)
+		}
+	}
+
+	// the node filter limits the dry run to the given nodes
+	devices["myorg/n8"] = exchange.Device{PublicKey: "key", Arch: "amd64"}
+	if output, err := deploymentDryRun(bHandler, getOrgDevices, deployCheck, getNodeState, getCommitted, getServiceResources, &DryRunCheck{BusinessPolId: "myorg/bp1", NodeIds: []string{"myorg/n2", "myorg/n8"}}, nil); err != nil {
+		t.Errorf("deploymentDryRun should not have returned error but got: %v", err)
+	} else if len(output.Nodes) != 2 {
+		t.Errorf("expected 2 nodes in the output but got %v", output.Nodes)
+	} else if output.Nodes[1].Outcome != DRYRUN_ERROR {
+		t.Errorf("expected an error outcome for node myorg/n8 but got %v", output.Nodes[1])
+	}
+
+	// a policy that is not published needs the node org
+	if _, err := deploymentDryRun(bHandler, getOrgDevices, deployCheck, getNodeState, getCommitted, getServiceResources, &DryRunCheck{BusinessPolicy: createBusinessPolicy(service, map[string]string{}, []string{})}, nil); err == nil {
+		t.Errorf("deploymentDryRun should have returned error for a policy without an id or node org")
+	} else if _, err := deploymentDryRun(bHandler, getOrgDevices, deployCheck, getNodeState, getCommitted, getServiceResources, &DryRunCheck{}, nil); err == nil {
+		t.Errorf("deploymentDryRun should have returned error for an input without a policy")
+	}
+}
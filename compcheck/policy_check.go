@@ -77,7 +77,9 @@ func (p *PolicyCheck) UnmarshalJSON(b []byte) error {
 //
 //	Edge side: node policy (including the node built-in policy)
 //	Agbot side: business policy + service policy + service built-in properties
-func PolicyCompatible(ec exchange.ExchangeContext, pcInput *PolicyCheck, checkAllSvcs bool, msgPrinter *message.Printer) (*CompCheckOutput, error) {
+// If explain is true, the output includes the evaluation trace of the constraints on both sides for each service that
+// reached the policy compatibility check.
+func PolicyCompatible(ec exchange.ExchangeContext, pcInput *PolicyCheck, checkAllSvcs bool, explain bool, msgPrinter *message.Printer) (*CompCheckOutput, error) {
 
 	getDeviceHandler := exchange.GetHTTPDeviceHandler(ec)
 	nodePolicyHandler := exchange.GetHTTPNodePolicyHandler(ec)
@@ -86,7 +88,7 @@ func PolicyCompatible(ec exchange.ExchangeContext, pcInput *PolicyCheck, checkAl
 	getSelectedServices := exchange.GetHTTPSelectedServicesHandler(ec)
 	getServiceResolvedDef := exchange.GetHTTPServiceDefResolverHandler(ec)
 
-	return policyCompatible(getDeviceHandler, nodePolicyHandler, getBusinessPolicies, servicePolicyHandler, getSelectedServices, getServiceResolvedDef, pcInput, checkAllSvcs, msgPrinter)
+	return policyCompatible(getDeviceHandler, nodePolicyHandler, getBusinessPolicies, servicePolicyHandler, getSelectedServices, getServiceResolvedDef, pcInput, checkAllSvcs, explain, msgPrinter)
 }
 
 // Internal function for PolicyCompatible
@@ -96,7 +98,7 @@ func policyCompatible(getDeviceHandler exchange.DeviceHandler,
 	servicePolicyHandler exchange.ServicePolicyHandler,
 	getSelectedServices exchange.SelectedServicesHandler,
 	getServiceResolvedDef exchange.ServiceDefResolverHandler,
-	pcInput *PolicyCheck, checkAllSvcs bool, msgPrinter *message.Printer) (*CompCheckOutput, error) {
+	pcInput *PolicyCheck, checkAllSvcs bool, explain bool, msgPrinter *message.Printer) (*CompCheckOutput, error) {
 
 	// get default message printer if nil
 	if msgPrinter == nil {
@@ -184,6 +186,19 @@ func policyCompatible(getDeviceHandler exchange.DeviceHandler,
 	msg_incompatible := msgPrinter.Sprintf("Policy Incompatible")
 	msg_compatible := msgPrinter.Sprintf("Compatible")
 
+	// the constraint evaluation traces keyed by service id, only when explain is set
+	explanations := map[string]*PolicyExplanation{}
+	addExplanation := func(sId string, mergedServicePol *externalpolicy.ExternalPolicy) error {
+		if explain {
+			if exp, err := ExplainPolicyCompatibility(nPolicy, bPolicy, mergedServicePol, msgPrinter); err != nil {
+				return err
+			} else {
+				explanations[sId] = exp
+			}
+		}
+		return nil
+	}
+
 	messages := map[string]string{}
 	if resources.NodeType == persistence.DEVICE_TYPE_CLUSTER && resources.NodeNamespaceScoped {
 		// verify clusterNamespace vs openhorizon.kubernetesNamespace in the constraint
@@ -266,6 +281,14 @@ func policyCompatible(getDeviceHandler exchange.DeviceHandler,
 							compatible, reason, _, _, err1 = CheckPolicyCompatiblility(nPolicy, bPolicy, mergedServicePol, resources.NodeArch, msgPrinter)
 							if err1 != nil {
 								return nil, err1
+							} else if err1 = addExplanation(sId, mergedServicePol); err1 != nil {
+								return nil, err1
+							}
+						}
+						if compatible && resources.NodeType == persistence.DEVICE_TYPE_DEVICE {
+							// check that the node has room for the service containers
+							if compatible, reason, err1 = nodeCapacityCompatible(nPolicy, topSvcDef, depSvcDefs, msgPrinter); err1 != nil {
+								return nil, err1
 							}
 						}
 					}
@@ -274,7 +297,7 @@ func policyCompatible(getDeviceHandler exchange.DeviceHandler,
 						if checkAllSvcs {
 							messages[sId] = msg_compatible
 						} else {
-							return NewCompCheckOutput(true, map[string]string{sId: msg_compatible}, resources), nil
+							return NewCompCheckOutput(true, map[string]string{sId: msg_compatible}, resources).WithExplanation(explanations), nil
 						}
 					} else {
 						messages[sId] = fmt.Sprintf("%v: %v", msg_incompatible, reason)
@@ -317,6 +340,14 @@ func policyCompatible(getDeviceHandler exchange.DeviceHandler,
 									compatible, reason, _, _, err = CheckPolicyCompatiblility(nPolicy, bPolicy, mergedServicePol, resources.NodeArch, msgPrinter)
 									if err != nil {
 										return nil, err
+									} else if err = addExplanation(sId, mergedServicePol); err != nil {
+										return nil, err
+									}
+								}
+								if compatible && resources.NodeType == persistence.DEVICE_TYPE_DEVICE {
+									// check that the node has room for the service containers
+									if compatible, reason, err = nodeCapacityCompatible(nPolicy, topSvcDef, depSvcDefs, msgPrinter); err != nil {
+										return nil, err
 									}
 								}
 							}
@@ -325,7 +356,7 @@ func policyCompatible(getDeviceHandler exchange.DeviceHandler,
 								if checkAllSvcs {
 									messages[sId] = msg_compatible
 								} else {
-									return NewCompCheckOutput(true, map[string]string{sId: msg_compatible}, resources), nil
+									return NewCompCheckOutput(true, map[string]string{sId: msg_compatible}, resources).WithExplanation(explanations), nil
 								}
 							} else {
 								messages[sId] = fmt.Sprintf("%v: %v", msg_incompatible, reason)
@@ -375,6 +406,14 @@ func policyCompatible(getDeviceHandler exchange.DeviceHandler,
 						compatible, reason, _, _, err1 = CheckPolicyCompatiblility(nPolicy, bPolicy, mergedServicePol, resources.NodeArch, msgPrinter)
 						if err1 != nil {
 							return nil, err1
+						} else if err1 = addExplanation(sId, mergedServicePol); err1 != nil {
+							return nil, err1
+						}
+					}
+					if compatible && resources.NodeType == persistence.DEVICE_TYPE_DEVICE {
+						// check that the node has room for the service containers
+						if compatible, reason, err1 = nodeCapacityCompatible(nPolicy, topSvcDef, depSvcDefs, msgPrinter); err1 != nil {
+							return nil, err1
 						}
 					}
 				}
@@ -384,7 +423,7 @@ func policyCompatible(getDeviceHandler exchange.DeviceHandler,
 				if checkAllSvcs {
 					messages[sId] = msg_compatible
 				} else {
-					return NewCompCheckOutput(true, map[string]string{sId: msg_compatible}, resources), nil
+					return NewCompCheckOutput(true, map[string]string{sId: msg_compatible}, resources).WithExplanation(explanations), nil
 				}
 			} else {
 				messages[sId] = fmt.Sprintf("%v: %v", msg_incompatible, reason)
@@ -397,7 +436,7 @@ func policyCompatible(getDeviceHandler exchange.DeviceHandler,
 	resources.Service = top_services
 
 	if messages != nil && len(messages) != 0 {
-		return NewCompCheckOutput(overall_compatible, messages, resources), nil
+		return NewCompCheckOutput(overall_compatible, messages, resources).WithExplanation(explanations), nil
 	} else {
 		// If we get here, it means that no workload is found in the bp that matches the required node arch.
 		if resources.NodeArch != "" {
@@ -455,6 +494,43 @@ func CheckPolicyCompatiblility(nodePolicy *policy.Policy, businessPolicy *policy
 	}
 }
 
+// The evaluation traces of the constraints on both sides of a policy compatibility check.
+type PolicyExplanation struct {
+	DeploymentConstraints *externalpolicy.ConstraintTrace `json:"deployment_constraints"` // the deployment and service policy constraints evaluated against the node properties
+	NodeConstraints       *externalpolicy.ConstraintTrace `json:"node_constraints"`       // the node policy constraints evaluated against the deployment and service policy properties
+}
+
+func (p PolicyExplanation) String() string {
+	return fmt.Sprintf("DeploymentConstraints: %v, NodeConstraints: %v", p.DeploymentConstraints, p.NodeConstraints)
+}
+
+// Explain the policy compatibility check done by CheckPolicyCompatiblility. The policies are merged the same way, then
+// the constraints on each side are evaluated against the properties of the other side.
+func ExplainPolicyCompatibility(nodePolicy *policy.Policy, businessPolicy *policy.Policy, mergedServicePolicy *externalpolicy.ExternalPolicy, msgPrinter *message.Printer) (*PolicyExplanation, error) {
+
+	// get default message printer if nil
+	if msgPrinter == nil {
+		msgPrinter = i18n.GetMessagePrinter()
+	}
+
+	if nodePolicy == nil || businessPolicy == nil || mergedServicePolicy == nil {
+		return nil, NewCompCheckError(fmt.Errorf("%s", msgPrinter.Sprintf("Node policy, deployment policy and merged service policy cannot be null.")), COMPCHECK_INPUT_ERROR)
+	}
+
+	mergedConsumerPol, err := MergeFullServicePolicyToBusinessPolicy(businessPolicy, mergedServicePolicy, msgPrinter)
+	if err != nil {
+		return nil, err
+	}
+
+	exp := new(PolicyExplanation)
+	if exp.DeploymentConstraints, err = mergedConsumerPol.Constraints.Explain(nodePolicy.Properties); err != nil {
+		return nil, NewCompCheckError(fmt.Errorf("%s", msgPrinter.Sprintf("Failed to evaluate the deployment policy constraints. %v", err)), COMPCHECK_VALIDATION_ERROR)
+	} else if exp.NodeConstraints, err = nodePolicy.Constraints.Explain(mergedConsumerPol.Properties); err != nil {
+		return nil, NewCompCheckError(fmt.Errorf("%s", msgPrinter.Sprintf("Failed to evaluate the node policy constraints. %v", err)), COMPCHECK_VALIDATION_ERROR)
+	}
+	return exp, nil
+}
+
 // add node arch property to the node policy. node arch can be empty
 func addNodeArchToPolicy(nodePolicy *policy.Policy, nodeArch string, msgPrinter *message.Printer) (*policy.Policy, error) {
 	// get default message printer if nil
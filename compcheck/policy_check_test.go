@@ -263,7 +263,7 @@ func Test_policyCompatible_with_IDs(t *testing.T) {
 		getBusinessPolicyHandler(service, map[string]string{"prop1": "val1", "prop2": "val2"}, []string{"prop3 == val3", "prop4 == \"some value\""}),
 		getServicePolicyHandler(map[string]string{"prop5": "val5", "prop6": "val6"}, []string{"prop4 == \"some value\""}),
 		getSelectedServicesHandler(nil), getServiceDefResolverHandler(),
-		&input, true, msgPrinter); err != nil {
+		&input, true, false, msgPrinter); err != nil {
 		t.Errorf("policyCompatible should have returned nil error but got: %v", err)
 	} else if !compOutput.Compatible {
 		t.Errorf("policyCompatible should have returned compatible but got: %v", compOutput)
@@ -281,7 +281,7 @@ func Test_policyCompatible_with_IDs(t *testing.T) {
 		getBusinessPolicyHandler(service, map[string]string{"prop1": "val1", "prop2": "val2"}, []string{"prop3 == val3", "prop4 == \"some value\""}),
 		getServicePolicyHandler(map[string]string{"prop5": "val5", "prop6": "val6"}, []string{"prop4 == \"some value\""}),
 		getSelectedServicesHandler(nil), getServiceDefResolverHandler(),
-		&input, false, msgPrinter); err != nil {
+		&input, false, false, msgPrinter); err != nil {
 		t.Errorf("policyCompatible should have returned nil error but got: %v", err)
 	} else if !compOutput.Compatible {
 		t.Errorf("policyCompatible should have returned compatible but got: %v", compOutput)
@@ -291,6 +291,24 @@ func Test_policyCompatible_with_IDs(t *testing.T) {
 		t.Errorf("The reason for service %v shoud be %v, but got: %v", sId1, COMPATIBLE, compOutput.Reason[sId1])
 	}
 
+	// if explain is true, it returns the constraint evaluation traces for each service version.
+	if compOutput, err := policyCompatible(getDeviceHandler("amd64"),
+		getNodePolicyHandler(*extPol, *extPol_Deploy, *extPol_Manage),
+		getBusinessPolicyHandler(service, map[string]string{"prop1": "val1", "prop2": "val2"}, []string{"prop3 == val3", "prop4 == \"some value\""}),
+		getServicePolicyHandler(map[string]string{"prop5": "val5", "prop6": "val6"}, []string{"prop4 == \"some value\""}),
+		getSelectedServicesHandler(nil), getServiceDefResolverHandler(),
+		&input, true, true, msgPrinter); err != nil {
+		t.Errorf("policyCompatible should have returned nil error but got: %v", err)
+	} else if len(compOutput.Explanation) != 2 {
+		t.Errorf("policyCompatible should have returned 2 explanations but got : %v", compOutput.Explanation)
+	} else if exp := compOutput.Explanation[sId1]; exp == nil || exp.DeploymentConstraints == nil || exp.NodeConstraints == nil {
+		t.Errorf("The explanation for service %v should have traces for both sides, but got: %v", sId1, exp)
+	} else if !exp.DeploymentConstraints.Result || !exp.NodeConstraints.Result {
+		t.Errorf("The explanation for service %v should show both sides satisfied, but got: %v", sId1, exp)
+	} else if len(exp.DeploymentConstraints.Children) != 2 {
+		t.Errorf("The deployment constraint trace for service %v should have 2 constraints, but got: %v", sId1, exp.DeploymentConstraints)
+	}
+
 	// node arch on the exchange does not agree with the input node arch
 	input_wrong_arch := PolicyCheck{
 		NodeId:         "myorg/mynode",
@@ -305,7 +323,7 @@ func Test_policyCompatible_with_IDs(t *testing.T) {
 		getBusinessPolicyHandler(service, map[string]string{"prop1": "val1", "prop2": "val2"}, []string{"prop3 == val3", "prop4 == \"some value\""}),
 		getServicePolicyHandler(map[string]string{"prop5": "val5", "prop6": "val6"}, []string{"prop4 == \"some value\""}),
 		getSelectedServicesHandler(nil), getServiceDefResolverHandler(),
-		&input_wrong_arch, true, msgPrinter); err == nil {
+		&input_wrong_arch, true, false, msgPrinter); err == nil {
 		t.Errorf("policyCompatible should not have returned nil error.")
 	} else if !strings.Contains(err.Error(), "The input node architecture arm64 does not match") {
 		t.Errorf("policyCompatible should have returned error that contains 'input node architecture arm64 does not match' but got: %v", err)
@@ -325,7 +343,7 @@ func Test_policyCompatible_with_IDs(t *testing.T) {
 		getBusinessPolicyHandler(service, map[string]string{"prop1": "val1", "prop2": "val2"}, []string{"prop3 == val3", "prop4 == \"some value\""}),
 		getServicePolicyHandler(map[string]string{"prop5": "val5", "prop6": "val6"}, []string{"prop4 == \"some value\""}),
 		getSelectedServicesHandler(nil), getServiceDefResolverHandler(),
-		&input2, true, msgPrinter); err != nil {
+		&input2, true, false, msgPrinter); err != nil {
 		t.Errorf("policyCompatible should have returned nil error but got: %v", err)
 	} else if compOutput.Compatible {
 		t.Errorf("policyCompatible should have returned incompatible but got: %v", compOutput)
@@ -374,7 +392,7 @@ func Test_policyCompatible_with_IDs(t *testing.T) {
 		getBusinessPolicyHandler(service2, map[string]string{"prop1": "val1", "prop2": "val2"}, []string{"prop3 == val3", "prop4 == \"some value\""}),
 		getServicePolicyHandler(map[string]string{"prop5": "val5", "prop6": "val6"}, []string{"prop4 == \"some value\""}),
 		getSelectedServicesHandler(services), getServiceDefResolverHandler(),
-		&input3, true, msgPrinter); err != nil {
+		&input3, true, false, msgPrinter); err != nil {
 		t.Errorf("policyCompatible should have returned nil error but got: %v", err)
 	} else if !compOutput.Compatible {
 		t.Errorf("policyCompatible should have returned compatible but got: %v", compOutput)
@@ -431,7 +449,7 @@ func Test_policyCompatible_with_Pols(t *testing.T) {
 		getBusinessPolicyHandler(service, map[string]string{}, []string{}),
 		getServicePolicyHandler(map[string]string{}, []string{}),
 		getSelectedServicesHandler(nil), getServiceDefResolverHandler(),
-		&input0, true, msgPrinter); err != nil {
+		&input0, true, false, msgPrinter); err != nil {
 		t.Errorf("policyCompatible should have returned nil error but got: %v", err)
 	} else if !compOutput.Compatible {
 		t.Errorf("policyCompatible should have returned compatible but got: %v", compOutput)
@@ -452,7 +470,7 @@ func Test_policyCompatible_with_Pols(t *testing.T) {
 		getBusinessPolicyHandler(service, map[string]string{}, []string{}),
 		getServicePolicyHandler(map[string]string{}, []string{}),
 		getSelectedServicesHandler(nil), getServiceDefResolverHandler(),
-		&input0, true, msgPrinter); err != nil {
+		&input0, true, false, msgPrinter); err != nil {
 		t.Errorf("policyCompatible should have returned nil error but got: %v", err)
 	} else if compOutput.Compatible {
 		t.Errorf("policyCompatible should returned compatible but got: %v", compOutput)
@@ -481,7 +499,7 @@ func Test_policyCompatible_with_Pols(t *testing.T) {
 		getBusinessPolicyHandler(service, map[string]string{}, []string{}),
 		getServicePolicyHandler(map[string]string{}, []string{}),
 		getSelectedServicesHandler(nil), getServiceDefResolverHandler(),
-		&input1, true, msgPrinter); err != nil {
+		&input1, true, false, msgPrinter); err != nil {
 		t.Errorf("policyCompatible should have returned nil error but got: %v", err)
 	} else if !compOutput.Compatible {
 		t.Errorf("policyCompatible should have returned compatible but got: %v", compOutput)
@@ -512,7 +530,7 @@ func Test_policyCompatible_with_Pols(t *testing.T) {
 		getBusinessPolicyHandler(service, map[string]string{}, []string{}),
 		getServicePolicyHandler(map[string]string{}, []string{}),
 		getSelectedServicesHandler(nil), getServiceDefResolverHandler(),
-		&input1_1, true, msgPrinter); err != nil {
+		&input1_1, true, false, msgPrinter); err != nil {
 		t.Errorf("policyCompatible should have returned nil error but got: %v", err)
 	} else if compOutput.Compatible {
 		t.Errorf("policyCompatible should returned compatible but got: %v", compOutput)
@@ -541,7 +559,7 @@ func Test_policyCompatible_with_Pols(t *testing.T) {
 		getBusinessPolicyHandler(service, map[string]string{}, []string{}),
 		getServicePolicyHandler(map[string]string{}, []string{}),
 		getSelectedServicesHandler(nil), getServiceDefResolverHandler(),
-		&input2, true, msgPrinter); err != nil {
+		&input2, true, false, msgPrinter); err != nil {
 		t.Errorf("policyCompatible should have returned nil error but got: %v", err)
 	} else if compOutput.Compatible {
 		t.Errorf("policyCompatible should have returned incompatible but got: %v", compOutput)
@@ -589,7 +607,7 @@ func Test_policyCompatible_with_Pols(t *testing.T) {
 		getBusinessPolicyHandler(service2, map[string]string{}, []string{}),
 		getServicePolicyHandler(map[string]string{"prop5": "val5", "prop6": "val6"}, []string{"prop4 == \"some value\""}),
 		getSelectedServicesHandler(services), getServiceDefResolverHandler(),
-		&input3, true, msgPrinter); err != nil {
+		&input3, true, false, msgPrinter); err != nil {
 		t.Errorf("policyCompatible should have returned nil error but got: %v", err)
 	} else if !compOutput.Compatible {
 		t.Errorf("policyCompatible should have returned compatible but got: %v", compOutput)
@@ -618,7 +636,7 @@ func Test_policyCompatible_with_Pols(t *testing.T) {
 		getBusinessPolicyHandler(service2, map[string]string{}, []string{}),
 		getServicePolicyHandler(map[string]string{"prop5": "val5", "prop6": "val6"}, []string{"prop4 == \"some value\""}),
 		getSelectedServicesHandler(services), getServiceDefResolverHandler(),
-		&input4, true, msgPrinter); err != nil {
+		&input4, true, false, msgPrinter); err != nil {
 		t.Errorf("policyCompatible should have returned nil error but got: %v", err)
 	} else if !compOutput.Compatible {
 		t.Errorf("policyCompatible should have returned compatible but got: %v", compOutput)
@@ -666,7 +684,7 @@ func Test_policyCompatible_Error(t *testing.T) {
 		getBusinessPolicyHandler(service, map[string]string{"prop1": "val1", "prop2": "val2"}, []string{"prop3 == val3", "prop4 == \"some value\""}),
 		getServicePolicyHandler(map[string]string{"prop5": "val5", "prop6": "val6"}, []string{"prop4 == \"some value\""}),
 		getSelectedServicesHandler(nil), getServiceDefResolverHandler(),
-		&input, true, msgPrinter); err == nil {
+		&input, true, false, msgPrinter); err == nil {
 		t.Errorf("policyCompatible should not have returned nil")
 	} else if !strings.Contains(err.Error(), "Error trying to query node policy") {
 		t.Errorf("policyCompatible should have returned 'Error trying to query node policy' error but got: %v", err)
@@ -678,7 +696,7 @@ func Test_policyCompatible_Error(t *testing.T) {
 		getBusinessPolicyHandler_Error(),
 		getServicePolicyHandler(map[string]string{"prop5": "val5", "prop6": "val6"}, []string{"prop4 == \"some value\""}),
 		getSelectedServicesHandler(nil), getServiceDefResolverHandler(),
-		&input, true, msgPrinter); err == nil {
+		&input, true, false, msgPrinter); err == nil {
 		t.Errorf("policyCompatible should not have returned nil")
 	} else if !strings.Contains(err.Error(), "Unable to get deployment policy") {
 		t.Errorf("policyCompatible should have returned 'Unable to get deployment policy' error but got: %v", err)
@@ -690,7 +708,7 @@ func Test_policyCompatible_Error(t *testing.T) {
 		getBusinessPolicyHandler(service, map[string]string{"prop1": "val1", "prop2": "val2"}, []string{"prop3 == val3", "prop4 == \"some value\""}),
 		getServicePolicyHandler_Error(),
 		getSelectedServicesHandler(nil), getServiceDefResolverHandler(),
-		&input, true, msgPrinter); err == nil {
+		&input, true, false, msgPrinter); err == nil {
 		t.Errorf("policyCompatible should not have returned nil")
 	} else if !strings.Contains(err.Error(), "Error trying to query service policy") {
 		t.Errorf("policyCompatible should have returned 'Error trying to query service policy' error but got: %v", err)
@@ -718,7 +736,7 @@ func Test_policyCompatible_Error(t *testing.T) {
 		getBusinessPolicyHandler(service2, map[string]string{"prop1": "val1", "prop2": "val2"}, []string{"prop3 == val3", "prop4 == \"some value\""}),
 		getServicePolicyHandler(map[string]string{"prop5": "val5", "prop6": "val6"}, []string{"prop4 == \"some value\""}),
 		getSelectedServicesHandler_Error(), getServiceDefResolverHandler(),
-		&input2, true, msgPrinter); err == nil {
+		&input2, true, false, msgPrinter); err == nil {
 		t.Errorf("policyCompatible should not have returned nil, %v", compOutput)
 	} else if !strings.Contains(err.Error(), "Failed to get services for all archetctures for") {
 		t.Errorf("policyCompatible should have returned 'Failed to get services for all archetctures for' error but got: %v", err)
@@ -739,7 +757,7 @@ func Test_policyCompatible_Error(t *testing.T) {
 		getBusinessPolicyHandler(service, map[string]string{"prop1": "val1", "prop2": "val2"}, []string{"prop3 == val3", "prop4 !&& \"some value\""}),
 		getServicePolicyHandler(map[string]string{"prop5": "val5", "prop6": "val6"}, []string{"prop4 == \"some value\""}),
 		getSelectedServicesHandler(nil), getServiceDefResolverHandler(),
-		&input3, true, msgPrinter); err == nil {
+		&input3, true, false, msgPrinter); err == nil {
 		t.Errorf("policyCompatible should not have returned nil for error")
 	} else if !strings.Contains(err.Error(), "Failed to validate the node policy") {
 		t.Errorf("policyCompatible should have returned 'Failed to validate the node policy' error but got: %v", err)
@@ -758,7 +776,7 @@ func Test_policyCompatible_Error(t *testing.T) {
 		getBusinessPolicyHandler(service, map[string]string{"prop1": "val1", "prop2": "val2"}, []string{"prop3 == val3", "prop4 !&& \"some value\""}),
 		getServicePolicyHandler(map[string]string{"prop5": "val5", "prop6": "val6"}, []string{"prop4 == \"some value\""}),
 		getSelectedServicesHandler(nil), getServiceDefResolverHandler(),
-		&input4, true, msgPrinter); err == nil {
+		&input4, true, false, msgPrinter); err == nil {
 		t.Errorf("policyCompatible should not have returned nil for error")
 	} else if !strings.Contains(err.Error(), "Failed to validate the business policy") {
 		t.Errorf("policyCompatible should have returned 'Failed to validate the business policy' error but got: %v", err)
@@ -777,7 +795,7 @@ func Test_policyCompatible_Error(t *testing.T) {
 		getBusinessPolicyHandler(service, map[string]string{"prop1": "val1", "prop2": "val2"}, []string{"prop3 == val3", "prop4 == \"some value\""}),
 		getServicePolicyHandler(map[string]string{"prop5": "val5", "prop6": "val6"}, []string{"prop4 &&%% \"some value\""}),
 		getSelectedServicesHandler(nil), getServiceDefResolverHandler(),
-		&input5, true, msgPrinter); err == nil {
+		&input5, true, false, msgPrinter); err == nil {
 		t.Errorf("policyCompatible should not have returned nil for error")
 	} else if !strings.Contains(err.Error(), "Failed to validate the service policy") {
 		t.Errorf("policyCompatible should have returned 'Failed to validate the service policy' error but got: %v", err)
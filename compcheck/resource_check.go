@@ -0,0 +1,138 @@
+package compcheck
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/open-horizon/anax/common"
+	"github.com/open-horizon/anax/containermessage"
+	"github.com/open-horizon/anax/exchange"
+	"github.com/open-horizon/anax/externalpolicy"
+	"github.com/open-horizon/anax/i18n"
+	"github.com/open-horizon/anax/policy"
+	"golang.org/x/text/message"
+	"strings"
+)
+
+// Returns the resources that the containers of the top level service and its dependent services need on the node. A container
+// needs the CPUs and memory it is limited to and the disk space it reserves, a container without limits adds nothing.
+func ServiceResourceRequirements(topSvc common.AbstractServiceFile, depServiceDefs map[string]exchange.ServiceDefinition, msgPrinter *message.Printer) (*externalpolicy.NodeResources, error) {
+
+	if msgPrinter == nil {
+		msgPrinter = i18n.GetMessagePrinter()
+	}
+
+	required := externalpolicy.NodeResources{}
+
+	// handle top level service
+	if topSvc != nil {
+		depstring := ""
+		if _, ok := topSvc.GetDeployment().(string); ok {
+			depstring = topSvc.GetDeployment().(string)
+		} else {
+			depByte, err := json.Marshal(topSvc.GetDeployment())
+			if err != nil {
+				return nil, err
+			}
+			depstring = string(depByte)
+		}
+
+		if res, err := DeploymentResourceRequirements(depstring, msgPrinter); err != nil {
+			return nil, err
+		} else {
+			required.Add(res)
+		}
+	}
+
+	// handle dependent services
+	for _, sDef := range depServiceDefs {
+		if res, err := DeploymentResourceRequirements(sDef.GetDeploymentString(), msgPrinter); err != nil {
+			return nil, err
+		} else {
+			required.Add(res)
+		}
+	}
+	return &required, nil
+}
+
+// Returns the resources that the containers in the deployment string need on the node.
+func DeploymentResourceRequirements(deploymentString string, msgPrinter *message.Printer) (*externalpolicy.NodeResources, error) {
+	required := externalpolicy.NodeResources{}
+	if deploymentString == "" || deploymentString == "null" {
+		return &required, nil
+	}
+	if msgPrinter == nil {
+		msgPrinter = i18n.GetMessagePrinter()
+	}
+	deploymentStruct := &containermessage.DeploymentDescription{}
+	err := json.Unmarshal([]byte(deploymentString), deploymentStruct)
+	if err != nil {
+		return nil, NewCompCheckError(fmt.Errorf("%s", msgPrinter.Sprintf("Error unmarshaling deployment string to internal deployment structure: %v", err)), COMPCHECK_CONVERSION_ERROR)
+	}
+	for _, svc := range deploymentStruct.Services {
+		if svc != nil {
+			required.CPUs += float64(svc.MaxCPUs)
+			required.MemoryMb += svc.MaxMemoryMb
+			required.DiskMb += svc.DiskMb
+		}
+	}
+	return &required, nil
+}
+
+// Check if the node has room for the required resources on top of the resources committed to the other services on the
+// node. The committed resources can be nil. A resource is only checked when the node reports its capacity and the service
+// needs some of it. Returns false and the reason if the node does not have room.
+func CheckNodeCapacity(nodeProps externalpolicy.PropertyList, committed *externalpolicy.NodeResources, required *externalpolicy.NodeResources, msgPrinter *message.Printer) (bool, string) {
+
+	if msgPrinter == nil {
+		msgPrinter = i18n.GetMessagePrinter()
+	}
+
+	capacity := externalpolicy.GetNodeCapacity(nodeProps)
+	if capacity == nil || required.IsZero() {
+		return true, ""
+	}
+	used := externalpolicy.NodeResources{}
+	used.Add(committed)
+
+	reasons := []string{}
+	if capacity.CPUs != 0 && required.CPUs != 0 && used.CPUs+required.CPUs > capacity.CPUs {
+		if used.CPUs == 0 {
+			reasons = append(reasons, msgPrinter.Sprintf("The service needs %v CPUs but the node has %v.", required.CPUs, capacity.CPUs))
+		} else {
+			reasons = append(reasons, msgPrinter.Sprintf("The service needs %v CPUs but the node has %v, of which %v are used by other services.", required.CPUs, capacity.CPUs, used.CPUs))
+		}
+	}
+	if capacity.MemoryMb != 0 && required.MemoryMb != 0 && used.MemoryMb+required.MemoryMb > capacity.MemoryMb {
+		if used.MemoryMb == 0 {
+			reasons = append(reasons, msgPrinter.Sprintf("The service needs %v MB of memory but the node has %v MB.", required.MemoryMb, capacity.MemoryMb))
+		} else {
+			reasons = append(reasons, msgPrinter.Sprintf("The service needs %v MB of memory but the node has %v MB, of which %v MB are used by other services.", required.MemoryMb, capacity.MemoryMb, used.MemoryMb))
+		}
+	}
+	if capacity.DiskMb != 0 && required.DiskMb != 0 && used.DiskMb+required.DiskMb > capacity.DiskMb {
+		if used.DiskMb == 0 {
+			reasons = append(reasons, msgPrinter.Sprintf("The service needs %v MB of disk but the node has %v MB.", required.DiskMb, capacity.DiskMb))
+		} else {
+			reasons = append(reasons, msgPrinter.Sprintf("The service needs %v MB of disk but the node has %v MB, of which %v MB are used by other services.", required.DiskMb, capacity.DiskMb, used.DiskMb))
+		}
+	}
+
+	if len(reasons) != 0 {
+		return false, msgPrinter.Sprintf("Insufficient node capacity. %v", strings.Join(reasons, " "))
+	}
+	return true, ""
+}
+
+// Check if a device node has room for the containers of the service. The resources committed to the agreements of the
+// node are only known to the agbots, so only the capacity of the node is checked.
+func nodeCapacityCompatible(nodePolicy *policy.Policy, topSvc common.AbstractServiceFile, depServiceDefs map[string]exchange.ServiceDefinition, msgPrinter *message.Printer) (bool, string, error) {
+	if nodePolicy == nil {
+		return true, "", nil
+	}
+	required, err := ServiceResourceRequirements(topSvc, depServiceDefs, msgPrinter)
+	if err != nil {
+		return false, "", err
+	}
+	compatible, reason := CheckNodeCapacity(nodePolicy.Properties, nil, required, msgPrinter)
+	return compatible, reason, nil
+}
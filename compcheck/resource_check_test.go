@@ -0,0 +1,99 @@
+//go:build unit
+// +build unit
+
+package compcheck
+
+import (
+	"github.com/open-horizon/anax/exchange"
+	"github.com/open-horizon/anax/externalpolicy"
+	"strings"
+	"testing"
+)
+
+// test starts here
+func Test_DeploymentResourceRequirements(t *testing.T) {
+	dep := `{"services":{"svc1":{"image":"svc1:1.0","max_memory_mb":512,"max_cpus":0.5,"disk_mb":1024},"svc2":{"image":"svc2:1.0","max_memory_mb":256}}}`
+	if res, err := DeploymentResourceRequirements(dep, nil); err != nil {
+		t.Errorf("Error getting resource requirements: %v", err)
+	} else if res.CPUs != 0.5 || res.MemoryMb != 768 || res.DiskMb != 1024 {
+		t.Errorf("Wrong resource requirements: %v", res)
+	}
+
+	if res, err := DeploymentResourceRequirements("", nil); err != nil {
+		t.Errorf("Error getting resource requirements of empty deployment: %v", err)
+	} else if !res.IsZero() {
+		t.Errorf("Empty deployment should not need resources, got %v", res)
+	}
+
+	if _, err := DeploymentResourceRequirements("{bad json", nil); err == nil {
+		t.Errorf("Should have returned an error for a bad deployment string")
+	}
+}
+
+func Test_ServiceResourceRequirements(t *testing.T) {
+	depSvcs := map[string]exchange.ServiceDefinition{
+		"mycomp/dep1_1.0.0_amd64": exchange.ServiceDefinition{
+			URL:        "dep1",
+			Version:    "1.0.0",
+			Arch:       "amd64",
+			Deployment: `{"services":{"dep1":{"image":"dep1:1.0","max_memory_mb":128,"max_cpus":0.25}}}`,
+		},
+	}
+	topSvc := &ServiceDefinition{
+		"mycomp",
+		exchange.ServiceDefinition{
+			URL:        "top",
+			Version:    "1.0.0",
+			Arch:       "amd64",
+			Deployment: `{"services":{"top":{"image":"top:1.0","max_memory_mb":512,"max_cpus":1,"disk_mb":100}}}`,
+		},
+	}
+
+	if res, err := ServiceResourceRequirements(topSvc, depSvcs, nil); err != nil {
+		t.Errorf("Error getting resource requirements: %v", err)
+	} else if res.CPUs != 1.25 || res.MemoryMb != 640 || res.DiskMb != 100 {
+		t.Errorf("Wrong resource requirements: %v", res)
+	}
+}
+
+func Test_CheckNodeCapacity(t *testing.T) {
+	props := externalpolicy.PropertyList{
+		*externalpolicy.Property_Factory(externalpolicy.PROP_NODE_CPU, float64(2)),
+		*externalpolicy.Property_Factory(externalpolicy.PROP_NODE_MEMORY, float64(1024)),
+		*externalpolicy.Property_Factory(externalpolicy.PROP_NODE_DISK, float64(2048)),
+	}
+
+	required := &externalpolicy.NodeResources{CPUs: 1, MemoryMb: 512, DiskMb: 1024}
+	if fits, reason := CheckNodeCapacity(props, nil, required, nil); !fits {
+		t.Errorf("Service should fit on an empty node: %v", reason)
+	}
+
+	committed := &externalpolicy.NodeResources{CPUs: 1, MemoryMb: 512}
+	if fits, reason := CheckNodeCapacity(props, committed, required, nil); !fits {
+		t.Errorf("Service should fit on the remaining capacity: %v", reason)
+	}
+
+	committed.MemoryMb = 768
+	if fits, reason := CheckNodeCapacity(props, committed, required, nil); fits {
+		t.Errorf("Service should not fit in the remaining memory")
+	} else if !strings.Contains(reason, "memory") || strings.Contains(reason, "CPUs") {
+		t.Errorf("Wrong reason: %v", reason)
+	}
+
+	required.CPUs = 3
+	if fits, reason := CheckNodeCapacity(props, nil, required, nil); fits {
+		t.Errorf("Service should not fit on a node with fewer CPUs")
+	} else if !strings.Contains(reason, "CPUs") {
+		t.Errorf("Wrong reason: %v", reason)
+	}
+
+	// a node that does not report its capacity is not checked
+	if fits, reason := CheckNodeCapacity(externalpolicy.PropertyList{}, committed, required, nil); !fits {
+		t.Errorf("Service should fit on a node without capacity: %v", reason)
+	}
+
+	// a service without limits fits anywhere
+	if fits, reason := CheckNodeCapacity(props, committed, &externalpolicy.NodeResources{}, nil); !fits {
+		t.Errorf("Service without limits should fit: %v", reason)
+	}
+}
@@ -25,6 +25,7 @@ type HorizonConfig struct {
 	AgreementBot  AGConfig
 	Collaborators Collaborators
 	ArchSynonyms  ArchSynonyms
+	Tracing       TracingConfig // OpenTelemetry tracing of agreement negotiations, for both the agent and the agbot
 }
 
 // This is the configuration options for Edge component flavor of Anax
@@ -32,7 +33,9 @@ type Config struct {
 	ServiceStorage                   string // The base storage directory where the service can write or get the data.
 	APIListen                        string
 	DBPath                           string
-	DockerEndpoint                   string
+	Postgresql                       PostgresqlConfig // The Postgresql config if the agent keeps its state in postgresql instead of the bolt DB in DBPath
+	DockerEndpoint                   string           // The API endpoint of the container runtime, a Docker or Podman socket.
+	ContainerRuntime                 string           // The container runtime for device nodes, docker or podman. The default is detected from the DockerEndpoint.
 	DockerCredFilePath               string
 	DefaultCPUSet                    string
 	DefaultServiceRegistrationRAM    int64
@@ -57,7 +60,9 @@ type Config struct {
 	ExchangeMessagePollMaxInterval   int       // As the runtime increases the ExchangeMessagePollInterval, this value is the maximum that value can attain.
 	ExchangeMessagePollIncrement     int       // The number of seconds to increment the ExchangeMessagePollInterval when its time to increase the poll interval.
 	UserPublicKeyPath                string    // The location to store user keys uploaded through the REST API
+	ImageTrustPolicyPath             string    // The image trust policy file, it selects the keys and certs in the trust store that verify the container image signatures of each org. Images are not verified when it is empty.
 	ReportDeviceStatus               bool      // whether to report the device status to the exchange or not.
+	EnableMetrics                    bool      // whether to serve the agent metrics in the Prometheus format on the /metrics API. The default is false.
 	TrustCertUpdatesFromOrg          bool      // whether to trust the certs provided by the organization on the exchange or not.
 	TrustDockerAuthFromOrg           bool      // whether to turst the docker auths provided by the organization on the exchange or not.
 	ServiceUpgradeCheckIntervalS     int64     // service upgrade check interval in seconds. The default is 300 seconds.
@@ -76,6 +81,8 @@ type Config struct {
 	K8sCRInstallTimeoutS             int64     // The number of seconds to wait for the custom resouce to install successfully before it is considered a failure
 	SecretsManagerFilePath           string    // The filepath for the secrets manager to store secrets in the agent filesystem
 	NodeMgmtWorkDirectory            string    // The filepath for the node management policy updates to use
+	SystemdServicePath               string    // The filepath in which the packages of systemd services are installed
+	HelmUpgradeGracePeriodS          int64     // The number of seconds to keep a Helm release after its agreement ends, so that the agreement of the upgraded service can upgrade it in place. The default is 300, 0 uninstalls the release right away.
 
 	// these Ids could be provided in config or discovered after startup by the system
 	BlockchainAccountId        string
@@ -87,55 +94,59 @@ type AGConfig struct {
 	TxLostDelayTolerationSeconds  int
 	AgreementWorkers              int
 	DBPath                        string
-	Postgresql                    PostgresqlConfig // The Postgresql config if it is being used
-	PartitionStale                uint64           // Number of seconds to wait before declaring a partition to be stale (i.e. the previous owner has unexpectedly terminated).
-	ProtocolTimeoutS              uint64           // Number of seconds to wait before declaring proposal response is lost
-	AgreementTimeoutS             uint64           // Number of seconds to wait before declaring agreement not finalized in blockchain
-	ProtocolTimeoutScaleFactor    float64          // Time to wait before declaring a proposal response is lost. Expressed as a scaling factor of the max heartbeat interval for a given node
-	AgreementTimeoutScaleFactor   float64          // Time to wait before declaring an agreement did not finalize. Expressed as a scaling factor of the max heartbeat interval for a given node
-	NoDataIntervalS               uint64           // default should be 15 mins == 15*60 == 900. Ignored if the policy has data verification disabled.
-	ActiveAgreementsURL           string           // This field is used when policy files indicate they want data verification but they dont specify a URL
-	ActiveAgreementsUser          string           // This is the userid the agbot uses to authenticate to the data verifivcation API
-	ActiveAgreementsPW            string           // This is the password for the ActiveAgreementsUser
-	PolicyPath                    string           // The directory where policy files are kept, default /etc/provider-tremor/policy/
-	NewContractIntervalS          uint64           // default should be 1
-	ProcessGovernanceIntervalS    uint64           // How long the gov sleeps before general gov checks (new payloads, interval payments, etc).
-	IgnoreContractWithAttribs     string           // A comma seperated list of contract attributes. If set, the contracts that contain one or more of the attributes will be ignored. The default is "ethereum_account".
-	ExchangeURL                   string           // The URL of the Horizon exchange. If not configured, the exchange will not be used.
-	ExchangeHeartbeat             int              // Seconds between heartbeats to the exchange
-	ExchangeId                    string           // The id of the agbot, not the userid of the exchange user. Must be org qualified.
-	ExchangeToken                 string           // The agbot's authentication token
-	DVPrefix                      string           // When looking for agreement ids in the data verification API response, look for agreement ids with this prefix.
-	ActiveDeviceTimeoutS          int              // The amount of time a device can go without heartbeating and still be considered active for the purposes of search
-	ExchangeMessageTTL            int              // The number of seconds the exchange will keep this message before automatically deleting it
-	ExchangeMessageTTLScaleFactor float64          // Scale factor for thee time the exchange will keep this ,essage before automatically deleting it. Scaled relativee to the max heeartbeat interval
-	MessageKeyPath                string           // The path to the location of messaging keys
-	MessageKeyCheck               int              // The interval (in seconds) indicating how often the agbot checks its own object in the exchange to ensure that the message key is still available.
-	DefaultWorkloadPW             string           // The default workload password if none is specified in the policy file
-	APIListen                     string           // Host and port for the API to listen on
-	SecureAPIListenHost           string           // The host for the secure API to listen on
-	SecureAPIListenPort           string           // The port for the secure API to listen on
-	SecureAPIServerCert           string           // The path to the certificate file for the secure api
-	SecureAPIServerKey            string           // The path to the server key file for the secure api
-	PurgeArchivedAgreementHours   int              // Number of hours to leave an archived agreement in the database before automatically deleting it
-	CheckUpdatedPolicyS           int              // The number of seconds to wait between checks for an updated policy file. Zero means auto checking is turned off.
-	CSSURL                        string           // The URL used to access the CSS.
-	CSSSSLCert                    string           // The path to the client side SSL certificate for the CSS.
-	MMSGarbageCollectionInterval  int64            // The amount of time to wait between MMS object cache garbage collection scans.
-	AgreementBatchSize            uint64           // The number of nodes that the agbot will process in a batch.
-	AgreementQueueSize            uint64           // The agreement bot work queue max size.
-	MessageQueueScale             float64          // Scaling factor applied to the AgreementQueueSize when determining how deep to keep the queues.
-	QueueHistorySize              int              // The number of statistics records to retain in the prioritized queue history.
-	ErrRescanS                    uint64           // The number of seconds between rescan if error occurs from last rescan
-	FullRescanS                   uint64           // The number of seconds between policy scans when there have been no changes reported by the exchange.
-	MaxExchangeChanges            int              // The maximum number of exchange changes to request on a given call the exchange /changes API.
-	RetryLookBackWindow           uint64           // The time window (in seconds) used by the agbot to look backward in time for node changes when node agreements are retried.
-	PolicySearchOrder             bool             // When true, search policies from most recently changed to least recently changed.
-	Vault                         VaultConfig      // The hashicorp vault config to connect to and fetch secrets from.
-	SecretsUpdateCheckInterval    int              // The number of seconds between checks for updated secrets. Default is 60
-	SecretsUpdateCheckMaxInterval int              // As the runtime increases the SecretsUpdateCheckInterval, this value is the maximum that value can attain.
-	SecretsUpdateCheckIncrement   int              // The number of seconds to increment the SecretsUpdateCheckInterval when its time to increase the poll interval.
-	CSSDestinationBatchSize       int              // The max number of destination updates to send to CSS in a single update.
+	Postgresql                    PostgresqlConfig        // The Postgresql config if it is being used
+	PartitionStale                uint64                  // Number of seconds to wait before declaring a partition to be stale (i.e. the previous owner has unexpectedly terminated).
+	ProtocolTimeoutS              uint64                  // Number of seconds to wait before declaring proposal response is lost
+	AgreementTimeoutS             uint64                  // Number of seconds to wait before declaring agreement not finalized in blockchain
+	ProtocolTimeoutScaleFactor    float64                 // Time to wait before declaring a proposal response is lost. Expressed as a scaling factor of the max heartbeat interval for a given node
+	AgreementTimeoutScaleFactor   float64                 // Time to wait before declaring an agreement did not finalize. Expressed as a scaling factor of the max heartbeat interval for a given node
+	NoDataIntervalS               uint64                  // default should be 15 mins == 15*60 == 900. Ignored if the policy has data verification disabled.
+	ActiveAgreementsURL           string                  // This field is used when policy files indicate they want data verification but they dont specify a URL
+	ActiveAgreementsUser          string                  // This is the userid the agbot uses to authenticate to the data verifivcation API
+	ActiveAgreementsPW            string                  // This is the password for the ActiveAgreementsUser
+	PolicyPath                    string                  // The directory where policy files are kept, default /etc/provider-tremor/policy/
+	NewContractIntervalS          uint64                  // default should be 1
+	ProcessGovernanceIntervalS    uint64                  // How long the gov sleeps before general gov checks (new payloads, interval payments, etc).
+	IgnoreContractWithAttribs     string                  // A comma seperated list of contract attributes. If set, the contracts that contain one or more of the attributes will be ignored. The default is "ethereum_account".
+	ExchangeURL                   string                  // The URL of the Horizon exchange. If not configured, the exchange will not be used.
+	ExchangeHeartbeat             int                     // Seconds between heartbeats to the exchange
+	ExchangeId                    string                  // The id of the agbot, not the userid of the exchange user. Must be org qualified.
+	ExchangeToken                 string                  // The agbot's authentication token
+	DVPrefix                      string                  // When looking for agreement ids in the data verification API response, look for agreement ids with this prefix.
+	ActiveDeviceTimeoutS          int                     // The amount of time a device can go without heartbeating and still be considered active for the purposes of search
+	ExchangeMessageTTL            int                     // The number of seconds the exchange will keep this message before automatically deleting it
+	ExchangeMessageTTLScaleFactor float64                 // Scale factor for thee time the exchange will keep this ,essage before automatically deleting it. Scaled relativee to the max heeartbeat interval
+	MessageKeyPath                string                  // The path to the location of messaging keys
+	MessageKeyCheck               int                     // The interval (in seconds) indicating how often the agbot checks its own object in the exchange to ensure that the message key is still available.
+	DefaultWorkloadPW             string                  // The default workload password if none is specified in the policy file
+	APIListen                     string                  // Host and port for the API to listen on
+	SecureAPIListenHost           string                  // The host for the secure API to listen on
+	SecureAPIListenPort           string                  // The port for the secure API to listen on
+	SecureAPIServerCert           string                  // The path to the certificate file for the secure api
+	SecureAPIServerKey            string                  // The path to the server key file for the secure api
+	PurgeArchivedAgreementHours   int                     // Number of hours to leave an archived agreement in the database before automatically deleting it
+	CheckUpdatedPolicyS           int                     // The number of seconds to wait between checks for an updated policy file. Zero means auto checking is turned off.
+	CSSURL                        string                  // The URL used to access the CSS.
+	CSSSSLCert                    string                  // The path to the client side SSL certificate for the CSS.
+	MMSGarbageCollectionInterval  int64                   // The amount of time to wait between MMS object cache garbage collection scans.
+	AgreementBatchSize            uint64                  // The number of nodes that the agbot will process in a batch.
+	AgreementQueueSize            uint64                  // The agreement bot work queue max size.
+	MessageQueueScale             float64                 // Scaling factor applied to the AgreementQueueSize when determining how deep to keep the queues.
+	QueueHistorySize              int                     // The number of statistics records to retain in the prioritized queue history.
+	ErrRescanS                    uint64                  // The number of seconds between rescan if error occurs from last rescan
+	FullRescanS                   uint64                  // The number of seconds between policy scans when there have been no changes reported by the exchange.
+	MaxExchangeChanges            int                     // The maximum number of exchange changes to request on a given call the exchange /changes API.
+	RetryLookBackWindow           uint64                  // The time window (in seconds) used by the agbot to look backward in time for node changes when node agreements are retried.
+	PolicySearchOrder             bool                    // When true, search policies from most recently changed to least recently changed.
+	SecretsProvider               string                  // The secrets provider, one of vault, file or kubernetes. The default is vault when Vault is configured.
+	Vault                         VaultConfig             // The hashicorp vault config to connect to and fetch secrets from.
+	FileSecrets                   FileSecretsConfig       // The encrypted file config for the file secrets provider.
+	KubernetesSecrets             KubernetesSecretsConfig // The Kubernetes config for the kubernetes secrets provider.
+	SecretRollout                 SecretRolloutConfig     // How updated secrets are rolled out to the nodes with agreements that use them.
+	SecretsUpdateCheckInterval    int                     // The number of seconds between checks for updated secrets. Default is 60
+	SecretsUpdateCheckMaxInterval int                     // As the runtime increases the SecretsUpdateCheckInterval, this value is the maximum that value can attain.
+	SecretsUpdateCheckIncrement   int                     // The number of seconds to increment the SecretsUpdateCheckInterval when its time to increase the poll interval.
+	CSSDestinationBatchSize       int                     // The max number of destination updates to send to CSS in a single update.
 }
 
 // Contains the hashicorp vault configuration used within AGConfig.
@@ -164,6 +175,15 @@ func (c *HorizonConfig) IsBoltDBConfigured() bool {
 	return len(c.AgreementBot.DBPath) != 0
 }
 
+func (c *HorizonConfig) IsEdgeBoltDBConfigured() bool {
+	return len(c.Edge.DBPath) != 0
+}
+
+// The agent uses postgresql only when it is running as an agent, i.e. the DBPath is also set.
+func (c *HorizonConfig) IsEdgePostgresqlConfigured() bool {
+	return c.IsEdgeBoltDBConfigured() && (c.Edge.Postgresql != (PostgresqlConfig{}))
+}
+
 func (c *HorizonConfig) IsPostgresqlConfigured() bool {
 	return (c.AgreementBot.Postgresql != (PostgresqlConfig{})) && (c.GetPartitionStale() != 0)
 }
@@ -189,6 +209,13 @@ func (c *HorizonConfig) GetSecretsManagerFilePath() string {
 	return secPath
 }
 
+func (c *HorizonConfig) GetSystemdServicePath() string {
+	if c.Edge.SystemdServicePath == "" {
+		return path.Join(getDefaultBase(), HZN_SYSTEMD_SERVICE_PATH)
+	}
+	return c.Edge.SystemdServicePath
+}
+
 func (c *HorizonConfig) GetSecretsUpdateCheck() int {
 	return c.AgreementBot.SecretsUpdateCheckInterval
 }
@@ -256,6 +283,10 @@ func (c *HorizonConfig) GetK8sCRInstallTimeouts() int64 {
 	return K8sCRInstallTimeoutS_DEFAULT
 }
 
+func (c *HorizonConfig) GetHelmUpgradeGracePeriod() int64 {
+	return c.Edge.HelmUpgradeGracePeriodS
+}
+
 func (a *AGConfig) GetProtocolTimeout(maxHeartbeatInterval int) uint64 {
 	if a.ProtocolTimeoutS != 0 {
 		return a.ProtocolTimeoutS
@@ -405,6 +436,7 @@ func Read(file string) (*HorizonConfig, error) {
 				ExchangeMessagePollIncrement:   ExchangeMessagePollIncrement_DEFAULT,
 				MaxAgreementPrelaunchTimeM:     EdgeMaxAgreementPrelaunchTimeM_DEFAULT,
 				K8sCRInstallTimeoutS:           K8sCRInstallTimeoutS_DEFAULT,
+				HelmUpgradeGracePeriodS:        HelmUpgradeGracePeriodS_DEFAULT,
 			},
 			AgreementBot: AGConfig{
 				MessageKeyCheck:               AgbotMessageKeyCheck_DEFAULT,
@@ -504,20 +536,38 @@ func Read(file string) (*HorizonConfig, error) {
 			config.AgreementBot.MMSGarbageCollectionInterval = 300
 		}
 
+		if err := config.Tracing.Validate(); err != nil {
+			return nil, err
+		}
+
+		if err := config.validateSecretsProvider(); err != nil {
+			return nil, err
+		}
+
+		if err := config.validateSecretRollout(); err != nil {
+			return nil, err
+		}
+
+		if err := config.validateContainerRuntime(); err != nil {
+			return nil, err
+		}
+
 		// success at last!
 		return &config, nil
 	}
 }
 
 func (c *HorizonConfig) String() string {
-	return fmt.Sprintf("Edge: {%v}, AgreementBot: {%v}, Collaborators: {%v}, ArchSynonyms: {%v}", c.Edge.String(), c.AgreementBot.String(), c.Collaborators.String(), c.ArchSynonyms)
+	return fmt.Sprintf("Edge: {%v}, AgreementBot: {%v}, Collaborators: {%v}, ArchSynonyms: {%v}, Tracing: {%v}", c.Edge.String(), c.AgreementBot.String(), c.Collaborators.String(), c.ArchSynonyms, c.Tracing.String())
 }
 
 func (con *Config) String() string {
 	return fmt.Sprintf("ServiceStorage %v"+
 		", APIListen %v"+
 		", DBPath %v"+
+		", Postgresql: {%v}"+
 		", DockerEndpoint %v"+
+		", ContainerRuntime %v"+
 		", DockerCredFilePath %v"+
 		", DefaultCPUSet %v"+
 		", DefaultServiceRegistrationRAM: %v"+
@@ -540,7 +590,9 @@ func (con *Config) String() string {
 		", ExchangeMessagePollMaxInterval: %v"+
 		", ExchangeMessagePollIncrement: %v"+
 		", UserPublicKeyPath: %v"+
+		", ImageTrustPolicyPath: %v"+
 		", ReportDeviceStatus: %v"+
+		", EnableMetrics: %v"+
 		", TrustCertUpdatesFromOrg: %v"+
 		", TrustDockerAuthFromOrg: %v"+
 		", ServiceUpgradeCheckIntervalS: %v"+
@@ -552,11 +604,11 @@ func (con *Config) String() string {
 		", InitialPollingBuffer: {%v}"+
 		", BlockchainAccountId: %v"+
 		", BlockchainDirectoryAddress %v",
-		con.ServiceStorage, con.APIListen, con.DBPath, con.DockerEndpoint, con.DockerCredFilePath, con.DefaultCPUSet,
+		con.ServiceStorage, con.APIListen, con.DBPath, con.Postgresql.String(), con.DockerEndpoint, con.ContainerRuntime, con.DockerCredFilePath, con.DefaultCPUSet,
 		con.DefaultServiceRegistrationRAM, con.StaticWebContent, con.PublicKeyPath, con.TrustSystemCACerts, con.CACertsPath, con.ExchangeURL, con.AgbotURL,
 		con.DefaultHTTPClientTimeoutS, con.HTTPIdleConnectionTimeout, con.PolicyPath, con.ExchangeHeartbeat, con.AgreementTimeoutS,
 		con.DVPrefix, con.RegistrationDelayS, con.ExchangeMessageTTL, con.ExchangeMessageDynamicPoll, con.ExchangeMessagePollInterval,
-		con.ExchangeMessagePollMaxInterval, con.ExchangeMessagePollIncrement, con.UserPublicKeyPath, con.ReportDeviceStatus,
+		con.ExchangeMessagePollMaxInterval, con.ExchangeMessagePollIncrement, con.UserPublicKeyPath, con.ImageTrustPolicyPath, con.ReportDeviceStatus, con.EnableMetrics,
 		con.TrustCertUpdatesFromOrg, con.TrustDockerAuthFromOrg, con.ServiceUpgradeCheckIntervalS, con.MultipleAnaxInstances,
 		con.DefaultServiceRetryCount, con.DefaultServiceRetryDuration, con.NodeCheckIntervalS, con.FileSyncService.String(),
 		con.InitialPollingBuffer, con.BlockchainAccountId, con.BlockchainDirectoryAddress)
@@ -607,7 +659,11 @@ func (agc *AGConfig) String() string {
 		", MaxExchangeChanges: %v"+
 		", RetryLookBackWindow: %v"+
 		", PolicySearchOrder: %v"+
+		", SecretsProvider: %v"+
 		", Vault: {%v}"+
+		", FileSecrets: {%v}"+
+		", KubernetesSecrets: {%v}"+
+		", SecretRollout: {%v}"+
 		", SecretsUpdateCheckInterval: %v"+
 		", SecretsUpdateCheckMaxInterval: %v"+
 		", SecretsUpdateCheckIncrement: %v",
@@ -619,7 +675,7 @@ func (agc *AGConfig) String() string {
 		agc.SecureAPIListenHost, agc.SecureAPIListenPort, agc.SecureAPIServerCert, agc.SecureAPIServerKey,
 		agc.PurgeArchivedAgreementHours, agc.CheckUpdatedPolicyS, agc.CSSURL, agc.CSSSSLCert, agc.CSSDestinationBatchSize, agc.AgreementBatchSize,
 		agc.AgreementQueueSize, agc.MessageQueueScale, agc.QueueHistorySize, agc.FullRescanS, agc.ErrRescanS, agc.MaxExchangeChanges,
-		agc.RetryLookBackWindow, agc.PolicySearchOrder, agc.Vault, agc.SecretsUpdateCheckInterval, agc.SecretsUpdateCheckMaxInterval, agc.SecretsUpdateCheckIncrement)
+		agc.RetryLookBackWindow, agc.PolicySearchOrder, agc.SecretsProvider, agc.Vault, agc.FileSecrets.String(), agc.KubernetesSecrets.String(), agc.SecretRollout.String(), agc.SecretsUpdateCheckInterval, agc.SecretsUpdateCheckMaxInterval, agc.SecretsUpdateCheckIncrement)
 }
 
 func (c *VaultConfig) String() string {
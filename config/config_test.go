@@ -72,3 +72,30 @@ func Test_enrichFromEnvvars_success(t *testing.T) {
 	}
 
 }
+
+func Test_validateContainerRuntime(t *testing.T) {
+
+	config := HorizonConfig{Edge: Config{DockerEndpoint: "unix:///var/run/docker.sock"}}
+	if err := config.validateContainerRuntime(); err != nil {
+		t.Errorf("unexpected error for the default runtime: %v", err)
+	}
+
+	config = HorizonConfig{Edge: Config{ContainerRuntime: CONTAINER_RUNTIME_PODMAN}}
+	if err := config.validateContainerRuntime(); err != nil {
+		t.Errorf("unexpected error for podman: %v", err)
+	} else if config.Edge.DockerEndpoint != GetPodmanEndpoint() {
+		t.Errorf("DockerEndpoint should default to %v, is %v", GetPodmanEndpoint(), config.Edge.DockerEndpoint)
+	}
+
+	config = HorizonConfig{Edge: Config{ContainerRuntime: CONTAINER_RUNTIME_PODMAN, DockerEndpoint: "unix:///tmp/podman.sock"}}
+	if err := config.validateContainerRuntime(); err != nil {
+		t.Errorf("unexpected error for podman: %v", err)
+	} else if config.Edge.DockerEndpoint != "unix:///tmp/podman.sock" {
+		t.Errorf("DockerEndpoint should not be changed, is %v", config.Edge.DockerEndpoint)
+	}
+
+	config = HorizonConfig{Edge: Config{ContainerRuntime: "containerd"}}
+	if err := config.validateContainerRuntime(); err == nil {
+		t.Errorf("expected an error for an unsupported runtime")
+	}
+}
@@ -45,6 +45,9 @@ const HZN_FSS_STORAGE_PATH = "ess-store"
 // The relative path of authentication credentials used by services to access the sync service. This path should be combined with the HZN_VAR_BASE_DEFAULT.
 const HZN_FSS_AUTH_PATH = "ess-auth"
 
+// The relative path in which the packages of systemd services are installed. This path should be combined with the HZN_VAR_BASE_DEFAULT.
+const HZN_SYSTEMD_SERVICE_PATH = "systemd"
+
 // The name of the file mount that a service uses to find its FSS credential file.
 const HZN_FSS_AUTH_MOUNT = "/" + HZN_FSS_AUTH_PATH
 
@@ -66,6 +69,12 @@ const HZN_FSS_CERT_KEY_FILE = "key.pem"
 // The number of seconds between polls to the CSS for updates.
 const HZN_FSS_POLLING_RATE = 60
 
+// The name of the folder, in the ESS persistence path, where the previous versions of objects are kept.
+const HZN_FSS_VERSIONS_PATH = "versions"
+
+// The number of seconds between checks of the ESS objects for new versions to retain.
+const HZN_FSS_OBJECT_VERSION_CHECK_RATE = 15
+
 // The buffer size of object queue to send notifications
 const HZN_FSS_OBJECT_QUEUE_BUFFER_SIZE = 2
 
@@ -135,6 +144,9 @@ const AgbotProtocolTimeoutScaleFactor_DEFAULT = 2
 // Time to allow a kube agent to attempt to install a custom resource before timing out
 const K8sCRInstallTimeoutS_DEFAULT = 180
 
+// Time to keep a Helm release after its agreement ends, waiting for the agreement of an upgraded version of the service.
+const HelmUpgradeGracePeriodS_DEFAULT = 300
+
 // Time between secret update checks
 const SecretsUpdateCheck_DEFAULT = 60
 
@@ -0,0 +1,44 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// The container runtimes that can be selected with Config.ContainerRuntime for device nodes.
+const CONTAINER_RUNTIME_DOCKER = "docker"
+const CONTAINER_RUNTIME_PODMAN = "podman"
+
+// The sockets of the Podman API service. The rootless socket is under the runtime directory of the user.
+const PODMAN_ROOTFUL_SOCKET = "/run/podman/podman.sock"
+const PODMAN_ROOTLESS_SOCKET = "podman/podman.sock"
+
+// Return the Podman socket for the user that runs the agent.
+func GetPodmanEndpoint() string {
+	if os.Geteuid() != 0 {
+		runtimeDir := os.Getenv("XDG_RUNTIME_DIR")
+		if runtimeDir == "" {
+			runtimeDir = fmt.Sprintf("/run/user/%v", os.Getuid())
+		}
+		return "unix://" + filepath.Join(runtimeDir, PODMAN_ROOTLESS_SOCKET)
+	}
+	return "unix://" + PODMAN_ROOTFUL_SOCKET
+}
+
+// Make sure the container runtime is supported. When Podman is selected without an endpoint, the Podman socket of
+// the user that runs the agent is used.
+func (c *HorizonConfig) validateContainerRuntime() error {
+	switch c.Edge.ContainerRuntime {
+	case "", CONTAINER_RUNTIME_DOCKER:
+		return nil
+	case CONTAINER_RUNTIME_PODMAN:
+		if c.Edge.DockerEndpoint == "" {
+			c.Edge.DockerEndpoint = GetPodmanEndpoint()
+		}
+		return nil
+	default:
+		return errors.New(fmt.Sprintf("Edge.ContainerRuntime %v is not supported, it must be %v or %v", c.Edge.ContainerRuntime, CONTAINER_RUNTIME_DOCKER, CONTAINER_RUNTIME_PODMAN))
+	}
+}
@@ -25,10 +25,12 @@ type FSSConfig struct {
 	MaxDataChunkSize          int    // The data chunksize during internal data transfer between CSS and agent.
 	IsDataChunkEnabled        string // Indicate if chunk data transfer is enabled.
 	UnixSocketFilePermissions string // the permission digit for socket file
+	RetainedObjectVersions    int    // The number of previous versions of each object kept on the node, unless the object's policy sets openhorizon.mms.retainVersions.
+	ObjectVersionCheckRate    uint16 // The number of seconds between checks of the objects in the ESS for new versions to retain.
 }
 
 func (f *FSSConfig) String() string {
-	return fmt.Sprintf("APIListen: %v, APIPort: %v, APIProtocol: %v, PersistencePath: %v, AuthenticationPath: %v, CSSURL: %v, CSSSSLCert: %v, PollingRate: %v, ObjectQueueBufferSize: %v, HTTPESSClientTimeout: %v, HTTPESSObjClientTimeout: %v, IsDataChunkEnabled : %v, MaxDataChunkSize: %v", f.APIListen, f.APIPort, f.APIProtocol, f.PersistencePath, f.AuthenticationPath, f.CSSURL, f.CSSSSLCert, f.PollingRate, f.ObjectQueueBufferSize, f.HTTPESSClientTimeout, f.HTTPESSObjClientTimeout, f.IsDataChunkEnabled, f.MaxDataChunkSize)
+	return fmt.Sprintf("APIListen: %v, APIPort: %v, APIProtocol: %v, PersistencePath: %v, AuthenticationPath: %v, CSSURL: %v, CSSSSLCert: %v, PollingRate: %v, ObjectQueueBufferSize: %v, HTTPESSClientTimeout: %v, HTTPESSObjClientTimeout: %v, IsDataChunkEnabled : %v, MaxDataChunkSize: %v, RetainedObjectVersions: %v, ObjectVersionCheckRate: %v", f.APIListen, f.APIPort, f.APIProtocol, f.PersistencePath, f.AuthenticationPath, f.CSSURL, f.CSSSSLCert, f.PollingRate, f.ObjectQueueBufferSize, f.HTTPESSClientTimeout, f.HTTPESSObjClientTimeout, f.IsDataChunkEnabled, f.MaxDataChunkSize, f.RetainedObjectVersions, f.ObjectVersionCheckRate)
 }
 
 func (c *HorizonConfig) FSSIsUnixProtocol() bool {
@@ -130,6 +132,26 @@ func (c *HorizonConfig) GetESSPollingRate() uint16 {
 	}
 }
 
+// The previous versions of the objects are stored next to the ESS persistence.
+func (c *HorizonConfig) GetFileSyncServiceVersionsPath() string {
+	return path.Join(c.GetFileSyncServiceStoragePath(), HZN_FSS_VERSIONS_PATH)
+}
+
+func (c *HorizonConfig) GetFSSRetainedObjectVersions() int {
+	if c.Edge.FileSyncService.RetainedObjectVersions < 0 {
+		return 0
+	}
+	return c.Edge.FileSyncService.RetainedObjectVersions
+}
+
+func (c *HorizonConfig) GetFSSObjectVersionCheckRate() uint16 {
+	if c.Edge.FileSyncService.ObjectVersionCheckRate == 0 {
+		return HZN_FSS_OBJECT_VERSION_CHECK_RATE
+	} else {
+		return c.Edge.FileSyncService.ObjectVersionCheckRate
+	}
+}
+
 func (c *HorizonConfig) GetFSSObjectQueueSize() uint64 {
 	if c.Edge.FileSyncService.ObjectQueueBufferSize == 0 {
 		return HZN_FSS_OBJECT_QUEUE_BUFFER_SIZE
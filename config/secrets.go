@@ -0,0 +1,114 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+)
+
+// The agbot secrets providers that can be selected with AGConfig.SecretsProvider. The name of each provider is
+// the name that the provider's implementation registers with the agbot secrets provider registry.
+const SECRETS_PROVIDER_VAULT = "vault"
+const SECRETS_PROVIDER_FILE = "file"
+const SECRETS_PROVIDER_KUBERNETES = "kubernetes"
+
+// Contains the configuration of the encrypted file secrets provider used within AGConfig.
+type FileSecretsConfig struct {
+	Path    string // The file in which the secrets are stored, encrypted.
+	KeyPath string // The file containing the key used to encrypt the secrets file. It should hold at least 32 random bytes.
+}
+
+func (c *FileSecretsConfig) String() string {
+	return fmt.Sprintf("Path: %v, KeyPath: %v", c.Path, c.KeyPath)
+}
+
+// Contains the configuration of the Kubernetes Secrets provider used within AGConfig.
+type KubernetesSecretsConfig struct {
+	Namespace      string // The namespace in which the secrets are stored. The default is the namespace of the agbot's pod.
+	KubeConfigPath string // The kube config file used to reach the cluster when the agbot is not running in the cluster.
+}
+
+func (c *KubernetesSecretsConfig) String() string {
+	return fmt.Sprintf("Namespace: %v, KubeConfigPath: %v", c.Namespace, c.KubeConfigPath)
+}
+
+// Return the name of the secrets provider that the agbot should use. Vault is used when it is configured and no
+// provider is selected, so that existing configurations keep working. An empty string means that there is no
+// secrets provider.
+func (c *HorizonConfig) GetSecretsProvider() string {
+	if c.AgreementBot.SecretsProvider != "" {
+		return c.AgreementBot.SecretsProvider
+	} else if c.IsVaultConfigured() {
+		return SECRETS_PROVIDER_VAULT
+	}
+	return ""
+}
+
+// Make sure the selected secrets provider has the configuration it needs.
+func (c *HorizonConfig) validateSecretsProvider() error {
+	switch c.AgreementBot.SecretsProvider {
+	case "", SECRETS_PROVIDER_KUBERNETES:
+		return nil
+	case SECRETS_PROVIDER_VAULT:
+		if !c.IsVaultConfigured() {
+			return errors.New(fmt.Sprintf("AgreementBot.Vault must be set when the secrets provider is %v", SECRETS_PROVIDER_VAULT))
+		}
+		return nil
+	case SECRETS_PROVIDER_FILE:
+		if c.AgreementBot.FileSecrets.Path == "" || c.AgreementBot.FileSecrets.KeyPath == "" {
+			return errors.New(fmt.Sprintf("AgreementBot.FileSecrets.Path and AgreementBot.FileSecrets.KeyPath must be set when the secrets provider is %v", SECRETS_PROVIDER_FILE))
+		}
+		return nil
+	default:
+		return errors.New(fmt.Sprintf("AgreementBot.SecretsProvider %v is not supported, it must be %v, %v or %v", c.AgreementBot.SecretsProvider, SECRETS_PROVIDER_VAULT, SECRETS_PROVIDER_FILE, SECRETS_PROVIDER_KUBERNETES))
+	}
+}
+
+// The strategies that can be used to roll out updated secrets, selected with SecretRolloutConfig.Strategy. The immediate
+// strategy sends the updated secret to every affected agreement as soon as the update is detected. The percentage
+// strategy updates the agreements in waves, and the hagroup strategy does the same but never updates two members of
+// an HA group in the same wave.
+const SECRET_ROLLOUT_IMMEDIATE = "immediate"
+const SECRET_ROLLOUT_PERCENTAGE = "percentage"
+const SECRET_ROLLOUT_HAGROUP = "hagroup"
+
+const SecretRolloutWavePercent_DEFAULT = 25
+const SecretRolloutSoakS_DEFAULT = 300
+
+// Contains the configuration of the staged rollout of updated secrets used within AGConfig.
+type SecretRolloutConfig struct {
+	Strategy    string // One of immediate, percentage or hagroup. The default is immediate.
+	WavePercent int    // The percentage of the affected agreements that are updated in each wave. The default is 25.
+	SoakS       int    // The number of seconds that the services in a wave must keep running before the next wave starts. The default is 300.
+}
+
+func (c *SecretRolloutConfig) String() string {
+	return fmt.Sprintf("Strategy: %v, WavePercent: %v, SoakS: %v", c.Strategy, c.WavePercent, c.SoakS)
+}
+
+// Returns true when updated secrets are rolled out in waves.
+func (c *SecretRolloutConfig) IsStaged() bool {
+	return c.Strategy == SECRET_ROLLOUT_PERCENTAGE || c.Strategy == SECRET_ROLLOUT_HAGROUP
+}
+
+// Make sure the secret rollout strategy is supported, and fill in the defaults of a staged rollout.
+func (c *HorizonConfig) validateSecretRollout() error {
+	ro := &c.AgreementBot.SecretRollout
+	switch ro.Strategy {
+	case "", SECRET_ROLLOUT_IMMEDIATE:
+		return nil
+	case SECRET_ROLLOUT_PERCENTAGE, SECRET_ROLLOUT_HAGROUP:
+		if ro.WavePercent == 0 {
+			ro.WavePercent = SecretRolloutWavePercent_DEFAULT
+		} else if ro.WavePercent < 0 || ro.WavePercent > 100 {
+			return errors.New(fmt.Sprintf("AgreementBot.SecretRollout.WavePercent %v must be between 1 and 100", ro.WavePercent))
+		}
+		if ro.SoakS == 0 {
+			ro.SoakS = SecretRolloutSoakS_DEFAULT
+		} else if ro.SoakS < 0 {
+			return errors.New(fmt.Sprintf("AgreementBot.SecretRollout.SoakS %v must not be negative", ro.SoakS))
+		}
+		return nil
+	default:
+		return errors.New(fmt.Sprintf("AgreementBot.SecretRollout.Strategy %v is not supported, it must be %v, %v or %v", ro.Strategy, SECRET_ROLLOUT_IMMEDIATE, SECRET_ROLLOUT_PERCENTAGE, SECRET_ROLLOUT_HAGROUP))
+	}
+}
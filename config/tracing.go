@@ -0,0 +1,50 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+)
+
+// The span exporters that can be configured for tracing.
+const TRACING_EXPORTER_OTLP = "otlp"
+const TRACING_EXPORTER_FILE = "file"
+
+// The default OTLP/HTTP receiver of a local OpenTelemetry collector.
+const TRACING_OTLP_ENDPOINT_DEFAULT = "localhost:4318"
+
+// Configuration for OpenTelemetry tracing of the agreement negotiation lifecycle, on the agent and the agbot. Tracing is
+// disabled when there is no exporter.
+type TracingConfig struct {
+	Exporter     string // Either "otlp" to send spans to an OpenTelemetry collector or "file" to write them to FilePath. Tracing is disabled when empty.
+	OTLPEndpoint string // The host:port of the collector's OTLP/HTTP receiver. The default is localhost:4318.
+	OTLPInsecure bool   // Send spans to the collector over http instead of https.
+	FilePath     string // The file to which spans are appended, one JSON document per span, when the exporter is "file".
+}
+
+func (t *TracingConfig) String() string {
+	return fmt.Sprintf("Exporter: %v, OTLPEndpoint: %v, OTLPInsecure: %v, FilePath: %v", t.Exporter, t.OTLPEndpoint, t.OTLPInsecure, t.FilePath)
+}
+
+func (t *TracingConfig) IsEnabled() bool {
+	return t.Exporter != ""
+}
+
+// Fill in the defaults and make sure the configured exporter can be used.
+func (t *TracingConfig) Validate() error {
+	switch t.Exporter {
+	case "":
+		return nil
+	case TRACING_EXPORTER_OTLP:
+		if t.OTLPEndpoint == "" {
+			t.OTLPEndpoint = TRACING_OTLP_ENDPOINT_DEFAULT
+		}
+		return nil
+	case TRACING_EXPORTER_FILE:
+		if t.FilePath == "" {
+			return errors.New(fmt.Sprintf("Tracing.FilePath must be set when the tracing exporter is %v", TRACING_EXPORTER_FILE))
+		}
+		return nil
+	default:
+		return errors.New(fmt.Sprintf("Tracing.Exporter %v is not supported, it must be %v or %v", t.Exporter, TRACING_EXPORTER_OTLP, TRACING_EXPORTER_FILE))
+	}
+}
@@ -22,6 +22,7 @@ import (
 	"github.com/open-horizon/anax/cli/cliutils"
 	"github.com/open-horizon/anax/config"
 	"github.com/open-horizon/anax/containermessage"
+	"github.com/open-horizon/anax/containerruntime"
 	"github.com/open-horizon/anax/cutil"
 	"github.com/open-horizon/anax/eventlog"
 	"github.com/open-horizon/anax/events"
@@ -29,21 +30,14 @@ import (
 	"github.com/open-horizon/anax/persistence"
 	"github.com/open-horizon/anax/policy"
 	"github.com/open-horizon/anax/resource"
+	"github.com/open-horizon/anax/tracing"
 	"github.com/open-horizon/anax/worker"
-	bolt "go.etcd.io/bbolt"
 	"golang.org/x/sys/unix"
 )
 
 const LABEL_PREFIX = "openhorizon.anax"
 const IPT_COLONUS_ISOLATED_CHAIN = "OPENHORIZON-ANAX-ISOLATION"
 
-const (
-	API_SERVER_TYPE_DOCKER = "docker"
-	API_SERVER_TYPE_PODMAN = "podman"
-	LOG_DRIVER_SYSLOG      = "syslog"
-	LOG_DRIVER_JOURNALD    = "journald"
-)
-
 // messages for event logs
 const (
 	EL_CONT_DEPLOYCONF_UNSUPPORT_CAP_FOR_WL   = "Deployment config %v contains unsupported capability for a workload"
@@ -61,6 +55,11 @@ const (
 	EL_CONT_TERM_UNABLE_ACCESS_STORAGE_DIR    = "anax terminating. Unable to access service storage direcotry specified in config: %v. %v"
 	EL_CONT_TERM_UNABLE_INIT_IPTABLE_CLIENT   = "anax terminating. Failed to instantiate iptables client. %v"
 	EL_CONT_TERM_UNABLE_INIT_DOCKER_CLIENT    = "anax terminating. Failed to instantiate docker client. %v"
+	EL_CONT_ROOTLESS_NO_ISOLATION             = "The %v container runtime is rootless, the network isolation of services cannot be enforced. Services that require network isolation will not be started."
+	EL_CONT_WAIT_DEPENDENCY_READY             = "Waiting up to %v seconds for dependency service %v/%v to be %v before starting %v. %v"
+	EL_CONT_DEPENDENCY_READY                  = "Dependency services for %v are ready after waiting %v seconds."
+	EL_CONT_DEPENDENCY_NOT_READY_TIMEOUT      = "Dependency service %v/%v did not become %v within %v seconds, unable to start %v. %v"
+	EL_CONT_DEPENDENCY_NEVER_READY            = "Dependency service %v/%v can never be %v, unable to start %v. %v"
 )
 
 // This is does nothing useful at run time.
@@ -86,6 +85,11 @@ func MarkI18nMessages() {
 	msgPrinter.Sprintf(EL_CONT_TERM_UNABLE_ACCESS_STORAGE_DIR)
 	msgPrinter.Sprintf(EL_CONT_TERM_UNABLE_INIT_IPTABLE_CLIENT)
 	msgPrinter.Sprintf(EL_CONT_TERM_UNABLE_INIT_DOCKER_CLIENT)
+	msgPrinter.Sprintf(EL_CONT_ROOTLESS_NO_ISOLATION)
+	msgPrinter.Sprintf(EL_CONT_WAIT_DEPENDENCY_READY)
+	msgPrinter.Sprintf(EL_CONT_DEPENDENCY_READY)
+	msgPrinter.Sprintf(EL_CONT_DEPENDENCY_NOT_READY_TIMEOUT)
+	msgPrinter.Sprintf(EL_CONT_DEPENDENCY_NEVER_READY)
 }
 
 /*
@@ -242,19 +246,8 @@ func (w *ContainerWorker) finalizeDeployment(agreementId string, deployment *con
 			return nil, err
 		}
 
-		// Determine if docker or podman as there are some differences
-		if w.apiServerType == "" {
-			if svType, err := GetServerEnginType(w.client); err != nil {
-				return nil, err
-			} else {
-				w.apiServerType = svType
-			}
-		}
-
-		// Needed in case SELinux is enabled with podman. Podman won't allow the use of the unix domain socket without it
-		if w.apiServerType == API_SERVER_TYPE_PODMAN {
-			service.SecurityOpt = append(service.SecurityOpt, "label=disable")
-		}
+		// Add the security options required by the container runtime, e.g. podman needs SELinux labeling disabled to allow the use of the unix domain socket.
+		service.SecurityOpt = append(service.SecurityOpt, w.client.SecurityOpts()...)
 
 		// If the FSS is using a unix domain socket listener, add a filesystem binding for it.
 		if uds != "" {
@@ -306,13 +299,9 @@ func (w *ContainerWorker) finalizeDeployment(agreementId string, deployment *con
 		var logConfig docker.LogConfig
 
 		// Use -log-driver defined in the deployment string of the service.
-		// If -log-driver is not defined
-		// Use journald log driver by default if podman is running
-		// Otherwise use syslog log driver by default.
-		logDriver := LOG_DRIVER_SYSLOG
-		if w.apiServerType == API_SERVER_TYPE_PODMAN {
-			logDriver = LOG_DRIVER_JOURNALD
-		}
+		// If -log-driver is not defined, use the default log driver of the container runtime,
+		// journald for podman and syslog for docker.
+		logDriver := w.client.DefaultLogDriver()
 		if service.LogDriver != "" {
 			logDriver = service.LogDriver
 		}
@@ -385,6 +374,15 @@ func (w *ContainerWorker) finalizeDeployment(agreementId string, deployment *con
 			serviceConfig.HostConfig.NanoCPUs = int64(service.MaxCPUs * 1000000000)
 		}
 
+		// Pass the health check through to docker so that the container's health state can be governed
+		if service.HealthCheck != nil {
+			if hc, err := service.HealthCheck.DockerHealthConfig(); err != nil {
+				return nil, fmt.Errorf("Invalid healthcheck for service %v: %v", serviceName, err)
+			} else {
+				serviceConfig.Config.Healthcheck = hc
+			}
+		}
+
 		// Mark each container as infrastructure if the deployment description indicates infrastructure
 		if deployment.Infrastructure {
 			serviceConfig.Config.Labels[LABEL_PREFIX+".infrastructure"] = ""
@@ -520,52 +518,27 @@ func (w *ContainerWorker) finalizeDeployment(agreementId string, deployment *con
 	return services, nil
 }
 
-// Check if the client is talking with docker or podman
-// The /version api returns something like:
-//
-//	{
-//	  "Components": [{"Name": "Podman Engine","Version": "3.1.0-dev",...]
-//	  ...
-//	}
-func GetServerEnginType(client *docker.Client) (string, error) {
-	svType := API_SERVER_TYPE_DOCKER
-
-	if client == nil {
-		return svType, fmt.Errorf("Invalid client pointer: nil.")
-	}
-
-	versionInfo, err := client.Version()
-	if err != nil {
-		return svType, fmt.Errorf("Failed to get the container server API version info. %v", err)
-	}
-	glog.V(5).Infof("API version info: %v", versionInfo)
-
-	if versionInfo != nil {
-		for _, info := range *versionInfo {
-			if strings.Contains(strings.ToLower(info), "podman") {
-				glog.V(3).Infof("podman endpoint is detected.")
-				svType = API_SERVER_TYPE_PODMAN
-				break
-			}
-		}
-	}
-
-	return svType, nil
-}
-
 type ContainerWorker struct {
 	worker.BaseWorker // embedded field
-	db                *bolt.DB
-	client            *docker.Client
+	db                persistence.AgentDatabase
+	client            containerruntime.ContainerRuntime
 	iptables          *iptables.IPTables
 	authMgr           *resource.AuthenticationManager
 	secretMgr         *resource.SecretsManager
 	pattern           string
 	isDevInstance     bool
-	apiServerType     string
+	readinessWaits    map[string]int64 // the time each parent started waiting for its dependencies to become ready, keyed by agreement id or service instance key
 }
 
+// Return the go-dockerclient client of the container runtime, for callers that need calls outside of the runtime interface.
 func (cw *ContainerWorker) GetClient() *docker.Client {
+	if cw.client == nil {
+		return nil
+	}
+	return cw.client.DockerClient()
+}
+
+func (cw *ContainerWorker) GetContainerRuntime() containerruntime.ContainerRuntime {
 	return cw.client
 }
 
@@ -583,30 +556,25 @@ func (cw *ContainerWorker) GetSecretsManager() *resource.SecretsManager {
 
 func CreateCLIContainerWorker(config *config.HorizonConfig) (*ContainerWorker, error) {
 	dockerEP := cutil.GetDockerEndpoint()
-	client, derr := docker.NewClient(dockerEP)
-	if derr != nil {
-		return nil, derr
-	}
-
-	svType, err := GetServerEnginType(client)
+	client, err := containerruntime.NewContainerRuntime(dockerEP, config.Edge.ContainerRuntime)
 	if err != nil {
 		return nil, err
 	}
 
 	return &ContainerWorker{
-		BaseWorker:    worker.NewBaseWorker("mock", config, nil),
-		db:            nil,
-		client:        client,
-		iptables:      nil,
-		authMgr:       resource.NewAuthenticationManager(config.GetFileSyncServiceAuthPath()),
-		secretMgr:     resource.NewSecretsManager(config, nil),
-		pattern:       "",
-		isDevInstance: true,
-		apiServerType: svType,
+		BaseWorker:     worker.NewBaseWorker("mock", config, nil),
+		db:             nil,
+		client:         client,
+		iptables:       nil,
+		authMgr:        resource.NewAuthenticationManager(config.GetFileSyncServiceAuthPath()),
+		secretMgr:      resource.NewSecretsManager(config, nil),
+		pattern:        "",
+		isDevInstance:  true,
+		readinessWaits: make(map[string]int64),
 	}, nil
 }
 
-func NewContainerWorker(name string, config *config.HorizonConfig, db *bolt.DB, am *resource.AuthenticationManager, sm *resource.SecretsManager) *ContainerWorker {
+func NewContainerWorker(name string, config *config.HorizonConfig, db persistence.AgentDatabase, am *resource.AuthenticationManager, sm *resource.SecretsManager) *ContainerWorker {
 
 	// do not start this container if the the node is registered and the type is cluster
 	dev, _ := persistence.FindExchangeDevice(db)
@@ -630,20 +598,10 @@ func NewContainerWorker(name string, config *config.HorizonConfig, db *bolt.DB,
 
 	var err error
 	var ipt *iptables.IPTables
-	var client *docker.Client
-
-	ipt, err = iptables.New()
-	if err != nil {
-		glog.Errorf("Failed to instantiate iptables Client: %v", err)
-		eventlog.LogNodeEvent(db, persistence.SEVERITY_FATAL,
-			persistence.NewMessageMeta(EL_CONT_TERM_UNABLE_INIT_IPTABLE_CLIENT, err.Error()),
-			persistence.EC_ERROR_CREATE_IPTABLE_CLIENT,
-			"", "", "", "")
-		panic(fmt.Sprintf("Terminating, unable to instantiate iptables Client. %v", err))
-	}
+	var client containerruntime.ContainerRuntime
 
 	if config.Edge.DockerEndpoint != "" {
-		client, err = docker.NewClient(config.Edge.DockerEndpoint)
+		client, err = containerruntime.NewContainerRuntime(config.Edge.DockerEndpoint, config.Edge.ContainerRuntime)
 		if err != nil {
 			glog.Errorf("Failed to instantiate docker Client: %v", err)
 			eventlog.LogNodeEvent(db, persistence.SEVERITY_FATAL,
@@ -652,6 +610,27 @@ func NewContainerWorker(name string, config *config.HorizonConfig, db *bolt.DB,
 				"", "", "", "")
 			panic(fmt.Sprintf("Terminating, unable to instantiate docker Client. %v", err))
 		}
+		glog.Infof("Using the %v container runtime at %v, rootless: %v", client.Name(), config.Edge.DockerEndpoint, client.IsRootless())
+	}
+
+	// A rootless container runtime places its containers in a user network namespace, the host firewall cannot
+	// isolate them so the iptables client is not used, and the services that require network isolation are refused.
+	if client != nil && client.IsRootless() {
+		glog.Errorf("The %v container runtime is rootless, services that require network isolation will not be started", client.Name())
+		eventlog.LogNodeEvent(db, persistence.SEVERITY_ERROR,
+			persistence.NewMessageMeta(EL_CONT_ROOTLESS_NO_ISOLATION, client.Name()),
+			persistence.EC_ERROR_CREATE_IPTABLE_CLIENT,
+			"", "", "", "")
+	} else {
+		ipt, err = iptables.New()
+		if err != nil {
+			glog.Errorf("Failed to instantiate iptables Client: %v", err)
+			eventlog.LogNodeEvent(db, persistence.SEVERITY_FATAL,
+				persistence.NewMessageMeta(EL_CONT_TERM_UNABLE_INIT_IPTABLE_CLIENT, err.Error()),
+				persistence.EC_ERROR_CREATE_IPTABLE_CLIENT,
+				"", "", "", "")
+			panic(fmt.Sprintf("Terminating, unable to instantiate iptables Client. %v", err))
+		}
 	}
 
 	pattern := ""
@@ -660,14 +639,14 @@ func NewContainerWorker(name string, config *config.HorizonConfig, db *bolt.DB,
 	}
 
 	worker := &ContainerWorker{
-		BaseWorker:    worker.NewBaseWorker(name, config, nil),
-		db:            db,
-		client:        client,
-		iptables:      ipt,
-		authMgr:       am,
-		secretMgr:     sm,
-		pattern:       pattern,
-		apiServerType: "",
+		BaseWorker:     worker.NewBaseWorker(name, config, nil),
+		db:             db,
+		client:         client,
+		iptables:       ipt,
+		authMgr:        am,
+		secretMgr:      sm,
+		pattern:        pattern,
+		readinessWaits: make(map[string]int64),
 	}
 	worker.SetDeferredDelay(15)
 
@@ -771,7 +750,7 @@ func (w *ContainerWorker) NewEvent(incoming events.Message) {
 	return
 }
 
-func MakeBridge(client *docker.Client, name string, infrastructure, sharedPattern, isDev bool) (*docker.Network, error) {
+func MakeBridge(client containerruntime.ContainerClient, name string, infrastructure, sharedPattern, isDev bool) (*docker.Network, error) {
 
 	// Labels on the docker network indicate attributes about the network.
 	labels := make(map[string]string)
@@ -813,7 +792,7 @@ func MakeBridge(client *docker.Client, name string, infrastructure, sharedPatter
 	return bridge, nil
 }
 
-func serviceStart(client *docker.Client,
+func serviceStart(client containerruntime.ContainerClient,
 	agreementId string,
 	serviceName string,
 	shareLabel string,
@@ -860,7 +839,7 @@ func serviceStart(client *docker.Client,
 	logDriverName := serviceConfig.HostConfig.LogConfig.Type
 	err := client.StartContainer(container.ID, nil)
 	if err != nil {
-		if strings.Contains(err.Error(), "logging driver") && (strings.Contains(err.Error(), LOG_DRIVER_SYSLOG) || strings.Contains(err.Error(), LOG_DRIVER_JOURNALD)) {
+		if strings.Contains(err.Error(), "logging driver") && (strings.Contains(err.Error(), containerruntime.LOG_DRIVER_SYSLOG) || strings.Contains(err.Error(), containerruntime.LOG_DRIVER_JOURNALD)) {
 			// prevent infinit loop, just in case
 			if !isFirstTry {
 				return fail(container, serviceName, fmt.Errorf("logging driver is not first try: %v", err))
@@ -899,7 +878,7 @@ func serviceStart(client *docker.Client,
 	return nil
 }
 
-func serviceDestroy(client *docker.Client, agreementId string, containerId string) (bool, error) {
+func serviceDestroy(client containerruntime.ContainerClient, agreementId string, containerId string) (bool, error) {
 	glog.V(3).Infof("Attempting to stop container %v from agreement: %v.", containerId, agreementId)
 	err := client.KillContainer(docker.KillContainerOptions{ID: containerId})
 
@@ -915,7 +894,7 @@ func serviceDestroy(client *docker.Client, agreementId string, containerId strin
 	return true, client.RemoveContainer(docker.RemoveContainerOptions{ID: containerId, RemoveVolumes: true, Force: true})
 }
 
-func existingShared(client *docker.Client, serviceName string, servicePair *servicePair, bridgeName string, shareLabel string) (*docker.Network, *docker.APIContainers, error) {
+func existingShared(client containerruntime.ContainerClient, serviceName string, servicePair *servicePair, bridgeName string, shareLabel string) (*docker.Network, *docker.APIContainers, error) {
 
 	var sBridge docker.Network
 	networks, err := client.ListNetworks()
@@ -1044,7 +1023,7 @@ func generatePermittedString(isolation *containermessage.NetworkIsolation, netwo
 	return fmt.Sprintf("%v%v/%v", permittedString, network.IPAddress, network.IPPrefixLen), nil
 }
 
-func processPostCreate(ipt *iptables.IPTables, client *docker.Client, agreementId string, deployment containermessage.DeploymentDescription, configureRaw []byte, hasSpecifiedEthAccount bool, containers []interface{}, fail func(container *docker.Container, name string, err error) error) error {
+func processPostCreate(ipt *iptables.IPTables, client containerruntime.ContainerClient, agreementId string, deployment containermessage.DeploymentDescription, configureRaw []byte, hasSpecifiedEthAccount bool, containers []interface{}, fail func(container *docker.Container, name string, err error) error) error {
 	// check if any of the service containers require iptables manipulation to limit outbound traffic. If not, skip this step
 	requiresProcessPostCreate := false
 	for _, con := range containers {
@@ -1070,6 +1049,8 @@ func processPostCreate(ipt *iptables.IPTables, client *docker.Client, agreementI
 
 	if !requiresProcessPostCreate {
 		return nil
+	} else if ipt == nil {
+		return fail(nil, "<unknown>", fmt.Errorf("The service requires network isolation, which cannot be enforced because the container runtime is rootless"))
 	}
 
 	if ipt != nil {
@@ -1540,11 +1521,41 @@ func (b *ContainerWorker) CommandHandler(command worker.Command) bool {
 		} else if ms_containers, err := b.findDependencyContainersForService(persistence.NewServiceInstancePathElement(ags[0].RunningWorkload.URL, ags[0].RunningWorkload.Org, ags[0].RunningWorkload.Version), []string{agreementId}, cmd.AgreementLaunchContext.Microservices); err != nil {
 			glog.Errorf("Error checking service containers: %v", err)
 
+			// If a dependency is up but not ready, wait for it until its readiness timeout expires.
+			if notReady, ok := err.(*dependencyNotReadyError); ok {
+				readiness := notReady.Spec.Readiness
+				if first, expired := b.trackDependencyWait(agreementId, notReady); notReady.Never {
+					b.endDependencyWait(agreementId)
+					eventlog.LogAgreementEvent(b.db, persistence.SEVERITY_ERROR,
+						persistence.NewMessageMeta(EL_CONT_DEPENDENCY_NEVER_READY, notReady.Spec.Org, notReady.Spec.SpecRef, readiness.GetCondition(), ags[0].RunningWorkload.URL, notReady.Reason),
+						persistence.EC_DEPENDENT_SERVICE_NOT_READY, ags[0])
+					b.Messages() <- events.NewWorkloadMessage(events.EXECUTION_FAILED, cmd.AgreementLaunchContext.AgreementProtocol, agreementId, nil)
+					return true
+				} else if first {
+					eventlog.LogAgreementEvent(b.db, persistence.SEVERITY_INFO,
+						persistence.NewMessageMeta(EL_CONT_WAIT_DEPENDENCY_READY, readiness.GetTimeout(), notReady.Spec.Org, notReady.Spec.SpecRef, readiness.GetCondition(), ags[0].RunningWorkload.URL, notReady.Reason),
+						persistence.EC_WAIT_DEPENDENT_SERVICE_READY, ags[0])
+				} else if expired {
+					b.endDependencyWait(agreementId)
+					eventlog.LogAgreementEvent(b.db, persistence.SEVERITY_ERROR,
+						persistence.NewMessageMeta(EL_CONT_DEPENDENCY_NOT_READY_TIMEOUT, notReady.Spec.Org, notReady.Spec.SpecRef, readiness.GetCondition(), readiness.GetTimeout(), ags[0].RunningWorkload.URL, notReady.Reason),
+						persistence.EC_DEPENDENT_SERVICE_NOT_READY, ags[0])
+					b.Messages() <- events.NewWorkloadMessage(events.EXECUTION_FAILED, cmd.AgreementLaunchContext.AgreementProtocol, agreementId, nil)
+					return true
+				}
+			}
+
 			// requeue the command
 			b.AddDeferredCommand(cmd)
 			return true
 		} else {
 
+			if waited, ok := b.endDependencyWait(agreementId); ok {
+				eventlog.LogAgreementEvent(b.db, persistence.SEVERITY_INFO,
+					persistence.NewMessageMeta(EL_CONT_DEPENDENCY_READY, ags[0].RunningWorkload.URL, waited),
+					persistence.EC_DEPENDENT_SERVICE_READY, ags[0])
+			}
+
 			// Now that we have a list of containers on which this workload is dependent, we need to get a list of service
 			// network ids to be added to all the workload containers.
 			ms_children_networks := b.GatherAndCreateDependencyNetworks(ms_containers, agreementId)
@@ -1606,7 +1617,11 @@ func (b *ContainerWorker) CommandHandler(command worker.Command) bool {
 
 			// Create the docker configuration and launch the containers.
 			// agreementId is the MSSInstanceKey
-			if deploymentConfig, err := b.ResourcesCreate(agreementId, cmd.AgreementLaunchContext.AgreementProtocol, deploymentDesc, cmd.AgreementLaunchContext.ConfigureRaw, *cmd.AgreementLaunchContext.EnvironmentAdditions, ms_children_networks, serviceIdentity, sVer, agreementId); err != nil {
+			span := tracing.StartSpan(agreementId, "container.StartWorkload", nil)
+			deploymentConfig, err := b.ResourcesCreate(agreementId, cmd.AgreementLaunchContext.AgreementProtocol, deploymentDesc, cmd.AgreementLaunchContext.ConfigureRaw, *cmd.AgreementLaunchContext.EnvironmentAdditions, ms_children_networks, serviceIdentity, sVer, agreementId)
+			tracing.EndSpan(span, err)
+
+			if err != nil {
 				eventlog.LogAgreementEvent(b.db, persistence.SEVERITY_ERROR,
 					persistence.NewMessageMeta(EL_CONT_START_CONTAINER_ERROR, err.Error()),
 					persistence.EC_ERROR_START_CONTAINER,
@@ -1670,11 +1685,45 @@ func (b *ContainerWorker) CommandHandler(command worker.Command) bool {
 			if ms_containers, err := b.findDependencyContainersForService(lc.GetServicePathElement(), lc.AgreementIds, lc.Microservices); err != nil {
 				glog.Errorf("Error checking service containers: %v", err)
 
+				// If a dependency is up but not ready, wait for it until its readiness timeout expires.
+				if notReady, ok := err.(*dependencyNotReadyError); ok {
+					readiness := notReady.Spec.Readiness
+					if first, expired := b.trackDependencyWait(lc.Name, notReady); notReady.Never {
+						b.endDependencyWait(lc.Name)
+						eventlog.LogServiceEvent2(b.db, persistence.SEVERITY_ERROR,
+							persistence.NewMessageMeta(EL_CONT_DEPENDENCY_NEVER_READY, notReady.Spec.Org, notReady.Spec.SpecRef, readiness.GetCondition(), serviceInfo.URL, notReady.Reason),
+							persistence.EC_DEPENDENT_SERVICE_NOT_READY,
+							lc.Name, serviceInfo.URL, serviceInfo.Org, serviceInfo.Version, "", lc.AgreementIds)
+						b.Messages() <- events.NewContainerMessage(events.EXECUTION_FAILED, *lc, "", "")
+						return true
+					} else if first {
+						eventlog.LogServiceEvent2(b.db, persistence.SEVERITY_INFO,
+							persistence.NewMessageMeta(EL_CONT_WAIT_DEPENDENCY_READY, readiness.GetTimeout(), notReady.Spec.Org, notReady.Spec.SpecRef, readiness.GetCondition(), serviceInfo.URL, notReady.Reason),
+							persistence.EC_WAIT_DEPENDENT_SERVICE_READY,
+							lc.Name, serviceInfo.URL, serviceInfo.Org, serviceInfo.Version, "", lc.AgreementIds)
+					} else if expired {
+						b.endDependencyWait(lc.Name)
+						eventlog.LogServiceEvent2(b.db, persistence.SEVERITY_ERROR,
+							persistence.NewMessageMeta(EL_CONT_DEPENDENCY_NOT_READY_TIMEOUT, notReady.Spec.Org, notReady.Spec.SpecRef, readiness.GetCondition(), readiness.GetTimeout(), serviceInfo.URL, notReady.Reason),
+							persistence.EC_DEPENDENT_SERVICE_NOT_READY,
+							lc.Name, serviceInfo.URL, serviceInfo.Org, serviceInfo.Version, "", lc.AgreementIds)
+						b.Messages() <- events.NewContainerMessage(events.EXECUTION_FAILED, *lc, "", "")
+						return true
+					}
+				}
+
 				// Requeue the command
 				b.AddDeferredCommand(cmd)
 				return true
 			} else {
 
+				if waited, ok := b.endDependencyWait(lc.Name); ok {
+					eventlog.LogServiceEvent2(b.db, persistence.SEVERITY_INFO,
+						persistence.NewMessageMeta(EL_CONT_DEPENDENCY_READY, serviceInfo.URL, waited),
+						persistence.EC_DEPENDENT_SERVICE_READY,
+						lc.Name, serviceInfo.URL, serviceInfo.Org, serviceInfo.Version, "", lc.AgreementIds)
+				}
+
 				// Now that we have a list of containers on which this service is dependent, we need to get a list of network ids
 				// for the dependencies so that all of this service's containers can be added to the dependency networks.
 				ms_children_networks = b.GatherAndCreateDependencyNetworks(ms_containers, lc.Name)
@@ -1820,12 +1869,18 @@ func (b *ContainerWorker) CommandHandler(command worker.Command) bool {
 			nd := cmd.Deployment.(*persistence.NativeDeploymentConfig)
 			serviceNames := persistence.ServiceConfigNames(&nd.Services)
 
+			unhealthy := false
 			report := func(container *docker.APIContainers, agreementId string) error {
 
 				for _, name := range serviceNames {
 					if container.Labels[LABEL_PREFIX+".service_name"] == name && container.State == "running" {
-						cMatches = append(cMatches, *container)
-						glog.V(4).Infof("Matching container instance for agreement %v: %v", agreementId, container)
+						if isContainerUnhealthy(container) {
+							glog.Errorf("Workload container %v for agreement %v is running but failing its health check: %v", name, agreementId, container.Status)
+							unhealthy = true
+						} else {
+							cMatches = append(cMatches, *container)
+							glog.V(4).Infof("Matching container instance for agreement %v: %v", agreementId, container)
+						}
 					}
 				}
 				return nil
@@ -1835,6 +1890,9 @@ func (b *ContainerWorker) CommandHandler(command worker.Command) bool {
 
 			if len(serviceNames) == len(cMatches) {
 				glog.V(3).Infof("Found expected count of running containers for agreement %v: %v", cmd.AgreementId, len(cMatches))
+			} else if unhealthy {
+				// ask governer to cancel the agreement
+				b.Messages() <- events.NewWorkloadMessage(events.EXECUTION_UNHEALTHY, cmd.AgreementProtocol, cmd.AgreementId, cmd.Deployment)
 			} else {
 				glog.Errorf("Insufficient running containers found for agreement %v. Found: %v", cmd.AgreementId, cMatches)
 
@@ -1858,6 +1916,7 @@ func (b *ContainerWorker) CommandHandler(command worker.Command) bool {
 			glog.Infof("ContainerWorker received shutdown command w/ current agreement id: %v. Shutting down resources", cmd.CurrentAgreementId)
 			glog.V(5).Infof("Shutdown command for agreement id %v: %v", cmd.CurrentAgreementId, cmd)
 			agreements = append(agreements, cmd.CurrentAgreementId)
+			b.endDependencyWait(cmd.CurrentAgreementId)
 		}
 
 		if err := b.ResourcesRemove(agreements); err != nil {
@@ -1892,12 +1951,16 @@ func (b *ContainerWorker) CommandHandler(command worker.Command) bool {
 			glog.Errorf("Error retrieving service contianers for %v, error: %v", cmd.MsInstKey, err)
 		} else if serviceNames != nil && len(serviceNames) > 0 {
 
+			unhealthy := false
 			report := func(container *docker.APIContainers, instance_key string) error {
 
 				for _, name := range serviceNames {
 					if container.Labels[LABEL_PREFIX+".service_name"] == name {
 						if container.State != "running" {
 							glog.Errorf("Service container for %v is not in the running state.", instance_key)
+						} else if isContainerUnhealthy(container) {
+							glog.Errorf("Service container %v for %v is running but failing its health check: %v", name, instance_key, container.Status)
+							unhealthy = true
 						} else {
 							cMatches = append(cMatches, *container)
 							glog.V(4).Infof("Matching container instance for service instance %v: %v", instance_key, container)
@@ -1915,9 +1978,13 @@ func (b *ContainerWorker) CommandHandler(command worker.Command) bool {
 				glog.Errorf("Insufficient running containers found for service instance %v. Found: %v", cmd.MsInstKey, cMatches)
 
 				// ask governer to record it into the db
+				eventId := events.EXECUTION_FAILED
+				if unhealthy {
+					eventId = events.EXECUTION_UNHEALTHY
+				}
 				cc := events.NewContainerConfig("", "", "", "", "", "", "", nil)
 				ll := events.NewContainerLaunchContext(cc, nil, events.BlockchainConfig{}, cmd.MsInstKey, msinst.AssociatedAgreements, []events.MicroserviceSpec{}, []persistence.ServiceInstancePathElement{}, false)
-				b.Messages() <- events.NewContainerMessage(events.EXECUTION_FAILED, *ll, "", "")
+				b.Messages() <- events.NewContainerMessage(eventId, *ll, "", "")
 			}
 		}
 	case *ShutdownMicroserviceCommand:
@@ -1927,6 +1994,7 @@ func (b *ContainerWorker) CommandHandler(command worker.Command) bool {
 		if cmd.MsInstKey != "" {
 			glog.Infof("ContainerWorker received shutdown command for service %v. Shutting down resources", cmd.MsInstKey)
 			agreements = append(agreements, cmd.MsInstKey)
+			b.endDependencyWait(cmd.MsInstKey)
 		}
 
 		if err := b.ResourcesRemove(agreements); err != nil {
@@ -2152,7 +2220,9 @@ func (b *ContainerWorker) syncupResources() {
 
 		// Fourth, run through IP routing table rules, looking for rules that are leftover from old agreements. Be aware that there
 		// could be other non-Horizon rules on this host, so we have to be careful to NOT terminate them.
-		if exists, err := b.iptables.Exists("filter", IPT_COLONUS_ISOLATED_CHAIN, "-j", "RETURN"); err != nil {
+		if b.iptables == nil {
+			glog.V(5).Infof("ContainerWorker skipping the isolation rules check, the container runtime is rootless.")
+		} else if exists, err := b.iptables.Exists("filter", IPT_COLONUS_ISOLATED_CHAIN, "-j", "RETURN"); err != nil {
 			fail(fmt.Sprintf("ContainerWorker unable to interrogate iptables on host. Error: %v", err))
 		} else if !exists {
 			glog.V(3).Infof(fmt.Sprintf("ContainerWorker primary redirect rule missing from %v chain.", IPT_COLONUS_ISOLATED_CHAIN))
@@ -2729,9 +2799,14 @@ func (b *ContainerWorker) findDependencyContainersForService(parent *persistence
 						if _, ok := container.Labels[LABEL_PREFIX+".infrastructure"]; ok {
 							cname := container.Names[0]
 							if cname == "/"+ms_instance.GetKey()+"-"+serviceName {
-								// check if the container is up and running
+								// check if the container is up and running, and ready if the parent has a readiness condition for it
 								if container.State != "running" {
+									if api_spec.Readiness != nil {
+										return nil, &dependencyNotReadyError{Spec: api_spec, Container: serviceName, Reason: fmt.Sprintf("container state is %v", container.State)}
+									}
 									return nil, fmt.Errorf("The service container %v is not up and running. %v", serviceName, err)
+								} else if ready, reason := isContainerReady(&container, api_spec.Readiness, b.GetClient()); !ready {
+									return nil, &dependencyNotReadyError{Spec: api_spec, Container: serviceName, Reason: reason, Never: !canBecomeReady(&container, api_spec.Readiness)}
 								} else {
 									glog.V(5).Infof("Found running service container %v for service %v", container, api_spec)
 									ms_containers = append(ms_containers, container)
@@ -2998,7 +3073,7 @@ func (b *ContainerWorker) ConnectContainerToNetwork(network *docker.Network, con
 		Links:     nil,
 		NetworkID: network.ID,
 	}
-	err := b.GetClient().ConnectNetwork(network.ID, docker.NetworkConnectionOptions{
+	err := b.client.ConnectNetwork(network.ID, docker.NetworkConnectionOptions{
 		Container:      containerId,
 		EndpointConfig: &epc,
 		Force:          true,
@@ -3011,7 +3086,7 @@ func (b *ContainerWorker) ConnectContainerToNetwork(network *docker.Network, con
 }
 
 // Delete the docker volumes that are created by anax
-func DeleteLeftoverDockerVolumes(db *bolt.DB, config *config.HorizonConfig) error {
+func DeleteLeftoverDockerVolumes(db persistence.AgentDatabase, config *config.HorizonConfig) error {
 	glog.V(3).Infof("Cleaning up leftover docker volumes created by anax.")
 
 	if config.Edge.DockerEndpoint == "" {
@@ -3028,7 +3103,7 @@ func DeleteLeftoverDockerVolumes(db *bolt.DB, config *config.HorizonConfig) erro
 		return nil
 	}
 
-	if client, err := docker.NewClient(config.Edge.DockerEndpoint); err != nil {
+	if client, err := containerruntime.NewContainerRuntime(config.Edge.DockerEndpoint, config.Edge.ContainerRuntime); err != nil {
 		return fmt.Errorf("Failed to instantiate docker Client: %v", err)
 	} else {
 		// check existing docker volumes
@@ -3075,6 +3150,13 @@ func DeleteLeftoverDockerVolumes(db *bolt.DB, config *config.HorizonConfig) erro
 	return nil
 }
 
+// isContainerUnhealthy returns true if the health check of the container is failing. Docker appends the health state
+// to the status of containers that have a health check, e.g. "Up 5 minutes (unhealthy)". A container is only reported
+// unhealthy once its start period is over and the configured number of retries have failed.
+func isContainerUnhealthy(container *docker.APIContainers) bool {
+	return strings.HasSuffix(container.Status, "(unhealthy)")
+}
+
 // serviceAndWorkerTypeMatches returns true if the container type matches the ContainerWorker instance type
 // (for dev and non-dev containers)
 func serviceAndWorkerTypeMatches(isDevInstance bool, container *docker.APIContainers) bool {
@@ -25,7 +25,6 @@ import (
 	"github.com/open-horizon/anax/containermessage"
 	"github.com/open-horizon/anax/events"
 	"github.com/open-horizon/anax/persistence"
-	bolt "go.etcd.io/bbolt"
 )
 
 func contentFromTar(fname string, in *bytes.Buffer) (*bytes.Buffer, error) {
@@ -73,7 +72,7 @@ func tConfig() *config.HorizonConfig {
 	}
 }
 
-func tWorker(config *config.HorizonConfig, db *bolt.DB) *ContainerWorker {
+func tWorker(config *config.HorizonConfig, db persistence.AgentDatabase) *ContainerWorker {
 	cw := NewContainerWorker("cworker", config, db)
 	cw.inAgbot = true
 	return cw
@@ -223,7 +222,7 @@ func pickImage() string {
 	}
 }
 
-func commonPatterned(t *testing.T, db *bolt.DB, agreementId string, tFn func(worker *ContainerWorker, env map[string]string, agreementId string), deployment string) {
+func commonPatterned(t *testing.T, db persistence.AgentDatabase, agreementId string, tFn func(worker *ContainerWorker, env map[string]string, agreementId string), deployment string) {
 
 	// used to name stuff for easy teardown
 	namePrefix := "container-int-test"
@@ -386,7 +385,7 @@ func Test_resourcesCreate_patterned(t *testing.T) {
 					return fmt.Errorf("RAM not set correctly")
 				}
 
-				if !tConnectivity(t, worker.client, container) {
+				if !tConnectivity(t, worker.GetClient(), container) {
 					return fmt.Errorf("container connectivity test failed for %v", container.Names)
 				}
 			}
@@ -539,7 +538,7 @@ func Test_resourcesCreate_shared(t *testing.T) {
 
 			if conAg == p2AgreementId || conAg == p1AgreementId {
 
-				connectivity := tConnectivity(t, worker.client, &con)
+				connectivity := tConnectivity(t, worker.GetClient(), &con)
 
 				// D isn't supposed to have connectivity
 				if con.Labels[container.LABEL_PREFIX+".service_name"] == "container-int-test-someServiceD" {
@@ -568,13 +567,13 @@ func Test_resourcesCreate_shared(t *testing.T) {
 	}
 }
 
-func setup() (string, *bolt.DB, error) {
+func setup() (string, persistence.AgentDatabase, error) {
 	dir, err := os.TempDir("", "container-")
 	if err != nil {
 		return "", nil, err
 	}
 
-	db, err := bolt.Open(path.Join(dir, "anax-int.db"), 0600, &bolt.Options{Timeout: 10 * time.Second})
+	db, err := persistence.OpenBoltDatabase(path.Join(dir, "anax-int.db"))
 	if err != nil {
 		return dir, nil, err
 	}
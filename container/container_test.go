@@ -7,6 +7,8 @@ import (
 	"encoding/json"
 	docker "github.com/fsouza/go-dockerclient"
 	"github.com/open-horizon/anax/containermessage"
+	"github.com/open-horizon/anax/exchangecommon"
+	"net"
 	"os"
 	"testing"
 )
@@ -293,3 +295,67 @@ func createTmpDir() error {
 	}
 	return nil
 }
+
+func Test_isContainerUnhealthy(t *testing.T) {
+	statuses := map[string]bool{
+		"Up 5 minutes":                     false,
+		"Up 5 minutes (healthy)":           false,
+		"Up 10 seconds (health: starting)": false,
+		"Up 5 minutes (unhealthy)":         true,
+		"Exited (1) 2 minutes ago":         false,
+	}
+
+	for status, expected := range statuses {
+		c := docker.APIContainers{State: "running", Status: status}
+		if isContainerUnhealthy(&c) != expected {
+			t.Errorf("Container with status %v should have returned unhealthy %v", status, expected)
+		}
+	}
+}
+
+func Test_isContainerReady(t *testing.T) {
+	healthy := &exchangecommon.DependencyReadiness{Condition: exchangecommon.READINESS_HEALTHY}
+	statuses := map[string]bool{
+		"Up 5 minutes":                     false,
+		"Up 5 minutes (healthy)":           true,
+		"Up 10 seconds (health: starting)": false,
+		"Up 5 minutes (unhealthy)":         false,
+	}
+
+	for status, expected := range statuses {
+		c := docker.APIContainers{State: "running", Status: status}
+		if ready, reason := isContainerReady(&c, healthy, nil); ready != expected {
+			t.Errorf("Container with status %v should have returned ready %v, reason: %v", status, expected, reason)
+		}
+	}
+
+	// Only a container without a healthcheck can never become healthy.
+	for status, expected := range map[string]bool{"Up 5 minutes": false, "Up 10 seconds (health: starting)": true, "Up 5 minutes (unhealthy)": true} {
+		c := docker.APIContainers{State: "running", Status: status}
+		if canBecomeReady(&c, healthy) != expected {
+			t.Errorf("Container with status %v should have returned can become ready %v", status, expected)
+		} else if !canBecomeReady(&c, nil) {
+			t.Errorf("Container with status %v can always become running", status)
+		}
+	}
+
+	// Without a readiness condition, a running container is ready.
+	c := docker.APIContainers{State: "running", Status: "Up 5 minutes (health: starting)"}
+	if ready, _ := isContainerReady(&c, nil, nil); !ready {
+		t.Errorf("Running container should be ready when there is no readiness condition")
+	}
+
+	// A container on the host network is probed on the loopback address.
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("unable to listen, error %v", err)
+	}
+	tcp := &exchangecommon.DependencyReadiness{Condition: exchangecommon.READINESS_TCP, Port: listener.Addr().(*net.TCPAddr).Port}
+	if ready, reason := isContainerReady(&c, tcp, nil); !ready {
+		t.Errorf("Container listening on port %v should be ready, reason: %v", tcp.Port, reason)
+	}
+	listener.Close()
+	if ready, _ := isContainerReady(&c, tcp, nil); ready {
+		t.Errorf("Container not listening on port %v should not be ready", tcp.Port)
+	}
+}
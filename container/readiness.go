@@ -0,0 +1,147 @@
+package container
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	docker "github.com/fsouza/go-dockerclient"
+	"github.com/golang/glog"
+	"github.com/open-horizon/anax/events"
+	"github.com/open-horizon/anax/exchangecommon"
+)
+
+// The time allowed for a single tcp readiness probe of a dependency container.
+const READINESS_DIAL_TIMEOUT = 2 * time.Second
+
+// The tcp readiness probe that is run inside a dependency container, with the port as $0, when the agent cannot reach
+// the container's network. It uses nc or the bash /dev/tcp device, and exits 127 when the container has neither.
+const READINESS_EXEC_PROBE = `if command -v nc >/dev/null 2>&1; then nc -z -w 2 127.0.0.1 "$0"; ` +
+	`elif command -v bash >/dev/null 2>&1; then bash -c "exec 3<>/dev/tcp/127.0.0.1/$0"; else exit 127; fi`
+
+// This error is returned when the containers of a dependency exist, but they do not (yet) meet the readiness
+// condition declared for the dependency in the parent's requiredServices. Never is set when the container can not
+// meet the condition at all, so there is no point in waiting for it.
+type dependencyNotReadyError struct {
+	Spec      events.MicroserviceSpec
+	Container string
+	Reason    string
+	Never     bool
+}
+
+func (e *dependencyNotReadyError) Error() string {
+	return fmt.Sprintf("The service container %v for %v/%v is not %v: %v", e.Container, e.Spec.Org, e.Spec.SpecRef, e.Spec.Readiness.GetCondition(), e.Reason)
+}
+
+// Check a running dependency container against the readiness condition. The reason is returned when the container is not ready.
+// The client is used to probe the container from inside its network namespace, it can be nil.
+func isContainerReady(container *docker.APIContainers, readiness *exchangecommon.DependencyReadiness, client *docker.Client) (bool, string) {
+	switch readiness.GetCondition() {
+	case exchangecommon.READINESS_HEALTHY:
+		if strings.HasSuffix(container.Status, "(healthy)") {
+			return true, ""
+		} else if !strings.Contains(container.Status, "health") {
+			return false, "the container does not define a healthcheck"
+		}
+		return false, fmt.Sprintf("container status is %v", container.Status)
+
+	case exchangecommon.READINESS_TCP:
+		port := strconv.Itoa(readiness.Port)
+		addrs := []string{}
+		for _, network := range container.Networks.Networks {
+			if network.IPAddress != "" {
+				addrs = append(addrs, net.JoinHostPort(network.IPAddress, port))
+			}
+		}
+		// Containers on the host network do not have an address of their own.
+		if len(addrs) == 0 {
+			addrs = append(addrs, net.JoinHostPort("127.0.0.1", port))
+		}
+
+		var lastErr error
+		for _, addr := range addrs {
+			if conn, err := net.DialTimeout("tcp", addr, READINESS_DIAL_TIMEOUT); err != nil {
+				lastErr = err
+			} else {
+				conn.Close()
+				return true, ""
+			}
+		}
+
+		// When the agent runs in a container (anax-in-container) it is not on the networks of the service containers,
+		// and the connection times out instead of being refused. The probe is then run inside the container.
+		if client != nil && !errors.Is(lastErr, syscall.ECONNREFUSED) {
+			return probeContainerPort(client, container.ID, readiness.Port)
+		}
+		return false, fmt.Sprintf("unable to connect to port %v, error: %v", port, lastErr)
+	}
+
+	// The default condition is that the container is running, which the caller has already checked.
+	return true, ""
+}
+
+// Returns false if the running dependency container can never meet the readiness condition. The healthy condition needs a
+// healthcheck, which is declared in the deployment of the dependency or in its image. The image is only known once the
+// container runs, so this is not checked when the service is published.
+func canBecomeReady(container *docker.APIContainers, readiness *exchangecommon.DependencyReadiness) bool {
+	return readiness.GetCondition() != exchangecommon.READINESS_HEALTHY || strings.Contains(container.Status, "health")
+}
+
+// Remember when a parent started waiting for its dependencies to become ready. The first return value is true if this is
+// the first time the parent is waiting, the second is true if the parent has waited longer than the readiness timeout.
+func (b *ContainerWorker) trackDependencyWait(parentKey string, notReady *dependencyNotReadyError) (bool, bool) {
+	now := time.Now().Unix()
+	start, waiting := b.readinessWaits[parentKey]
+	if !waiting {
+		b.readinessWaits[parentKey] = now
+		return true, false
+	}
+
+	timeout := int64(notReady.Spec.Readiness.GetTimeout())
+	glog.V(5).Infof("ContainerWorker %v has waited %v of %v seconds for dependency %v/%v to be ready.", parentKey, now-start, timeout, notReady.Spec.Org, notReady.Spec.SpecRef)
+	return false, now-start > timeout
+}
+
+// Stop tracking the dependency wait for a parent. Returns the number of seconds the parent waited and whether it waited at all.
+func (b *ContainerWorker) endDependencyWait(parentKey string) (int64, bool) {
+	if start, waiting := b.readinessWaits[parentKey]; waiting {
+		delete(b.readinessWaits, parentKey)
+		return time.Now().Unix() - start, true
+	}
+	return 0, false
+}
+
+// Run the tcp readiness probe in the network namespace of the container, by executing it in the container.
+func probeContainerPort(client *docker.Client, containerID string, port int) (bool, string) {
+	exec, err := client.CreateExec(docker.CreateExecOptions{
+		Container:    containerID,
+		Cmd:          []string{"sh", "-c", READINESS_EXEC_PROBE, strconv.Itoa(port)},
+		AttachStdout: true,
+		AttachStderr: true,
+	})
+	if err != nil {
+		return false, fmt.Sprintf("unable to reach port %v from the agent, and unable to probe it in the container, error: %v", port, err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*READINESS_DIAL_TIMEOUT)
+	defer cancel()
+	var output bytes.Buffer
+	if err := client.StartExec(exec.ID, docker.StartExecOptions{OutputStream: &output, ErrorStream: &output, Context: ctx}); err != nil {
+		return false, fmt.Sprintf("unable to reach port %v from the agent, and unable to probe it in the container, error: %v", port, err)
+	}
+
+	if inspect, err := client.InspectExec(exec.ID); err != nil {
+		return false, fmt.Sprintf("unable to get the result of the probe of port %v in the container, error: %v", port, err)
+	} else if inspect.ExitCode == 127 {
+		return false, fmt.Sprintf("unable to reach port %v from the agent, and the container has no sh with nc or bash to probe it", port)
+	} else if inspect.ExitCode != 0 {
+		return false, fmt.Sprintf("unable to connect to port %v in the container: %v", port, strings.TrimSpace(output.String()))
+	}
+	return true, ""
+}
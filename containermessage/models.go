@@ -8,6 +8,7 @@ import (
 	docker "github.com/fsouza/go-dockerclient"
 	"reflect"
 	"strings"
+	"time"
 )
 
 /*
@@ -36,6 +37,15 @@ import (
  *           "HostIP": "0.0.0.0"
  *         }
  *       ],
+ *       "healthcheck": {
+ *         "http": {
+ *           "port": 6414,
+ *           "path": "/health"
+ *         },
+ *         "interval": 30,
+ *         "retries": 3,
+ *         "start_period": 60
+ *       },
  *     	 "secrets": {
  *       	"cloudsqlservice": {
  *          	"description": "The token for cloud SQL service."
@@ -170,6 +180,7 @@ type Service struct {
 	Entrypoint       []string             `json:"entrypoint,omitempty"`
 	MaxMemoryMb      int64                `json:"max_memory_mb,omitempty"`
 	MaxCPUs          float32              `json:"max_cpus,omitempty"`
+	DiskMb           int64                `json:"disk_mb,omitempty"`    // The disk space the container needs, it is reserved on the node by the agbot and not enforced
 	LogDriver        string               `json:"log_driver,omitempty"` // Docker's log-driver. Syslog will be used as default driver
 	Secrets          map[string]Secret    `json:"secrets"`
 	SecurityOpt      []string             `json:"security_opt,omitempty"` // Related to SELinux security for podman
@@ -177,6 +188,7 @@ type Service struct {
 	User             string               `json:"user,omitempty"`         // The linux user ID (UID format) in which the container should run, see docker run -user
 	Sysctls          map[string]string    `json:"sysctls,omitempty"`      // The namespaced kernel parameters (sysctls) for this container, see docker run --sysctls
 	Ipc              string               `json:"ipc,omitempty"`          // The ipc mode for this container, see docker run --ipc
+	HealthCheck      *HealthCheck         `json:"healthcheck,omitempty"`  // How the container runtime should probe the container for health, see docker run --health-cmd
 }
 
 func (s *Service) AddFilesystemBinding(bind string) {
@@ -253,6 +265,101 @@ func (s *Service) AddSpecificPortBinding(b docker.PortBinding) {
 	s.Ports = append(s.Ports, b)
 }
 
+// The health check definition for a service container. Exactly one of Exec, HTTP or TCP must be specified. The check
+// is handed to the container runtime as a docker health check, so the HTTP and TCP probes are run from inside the
+// container and require wget (or curl) and nc respectively to be present in the image. All times are in seconds, zero
+// means the docker default is used.
+type HealthCheck struct {
+	Exec        []string   `json:"exec,omitempty"`         // Command run inside the container, a zero exit code means healthy
+	HTTP        *HTTPProbe `json:"http,omitempty"`         // HTTP GET against a port within the container, a 2xx or 3xx response means healthy
+	TCP         *TCPProbe  `json:"tcp,omitempty"`          // TCP connect to a port within the container
+	Interval    int        `json:"interval,omitempty"`     // Seconds between probes
+	Timeout     int        `json:"timeout,omitempty"`      // Seconds before a single probe is considered hung
+	Retries     int        `json:"retries,omitempty"`      // Consecutive failures needed before the container is marked unhealthy
+	StartPeriod int        `json:"start_period,omitempty"` // Seconds the container is given to initialize before failed probes are counted
+}
+
+type HTTPProbe struct {
+	Port int    `json:"port"`
+	Path string `json:"path,omitempty"`
+}
+
+type TCPProbe struct {
+	Port int `json:"port"`
+}
+
+func (h HealthCheck) String() string {
+	return fmt.Sprintf("Exec: %v, HTTP: %v, TCP: %v, Interval: %v, Timeout: %v, Retries: %v, StartPeriod: %v",
+		h.Exec, h.HTTP, h.TCP, h.Interval, h.Timeout, h.Retries, h.StartPeriod)
+}
+
+func (p *HTTPProbe) String() string {
+	return fmt.Sprintf("Port: %v, Path: %v", p.Port, p.Path)
+}
+
+func (p *TCPProbe) String() string {
+	return fmt.Sprintf("Port: %v", p.Port)
+}
+
+// Verify that the health check is well formed.
+func (h *HealthCheck) Validate() error {
+	probes := 0
+	if len(h.Exec) != 0 {
+		probes++
+	}
+	if h.HTTP != nil {
+		probes++
+		if h.HTTP.Port <= 0 || h.HTTP.Port > 65535 {
+			return fmt.Errorf("healthcheck http port %v is not a valid port number", h.HTTP.Port)
+		} else if strings.ContainsAny(h.HTTP.Path, " '\"`$;&|") {
+			return fmt.Errorf("healthcheck http path %v contains characters that are not allowed", h.HTTP.Path)
+		}
+	}
+	if h.TCP != nil {
+		probes++
+		if h.TCP.Port <= 0 || h.TCP.Port > 65535 {
+			return fmt.Errorf("healthcheck tcp port %v is not a valid port number", h.TCP.Port)
+		}
+	}
+
+	if probes != 1 {
+		return errors.New("healthcheck must specify exactly one of exec, http or tcp")
+	} else if h.Interval < 0 || h.Timeout < 0 || h.Retries < 0 || h.StartPeriod < 0 {
+		return errors.New("healthcheck interval, timeout, retries and start_period must not be negative")
+	}
+	return nil
+}
+
+// Convert the health check into the docker representation. The HTTP and TCP probes are converted into shell commands
+// that run inside the container.
+func (h *HealthCheck) DockerHealthConfig() (*docker.HealthConfig, error) {
+	if err := h.Validate(); err != nil {
+		return nil, err
+	}
+
+	hc := &docker.HealthConfig{
+		Interval:    time.Duration(h.Interval) * time.Second,
+		Timeout:     time.Duration(h.Timeout) * time.Second,
+		Retries:     h.Retries,
+		StartPeriod: time.Duration(h.StartPeriod) * time.Second,
+	}
+
+	if len(h.Exec) != 0 {
+		hc.Test = append([]string{"CMD"}, h.Exec...)
+	} else if h.HTTP != nil {
+		path := h.HTTP.Path
+		if !strings.HasPrefix(path, "/") {
+			path = "/" + path
+		}
+		url := fmt.Sprintf("http://127.0.0.1:%v%v", h.HTTP.Port, path)
+		hc.Test = []string{"CMD-SHELL", fmt.Sprintf("wget -q -O /dev/null '%v' || curl -fsS -o /dev/null '%v' || exit 1", url, url)}
+	} else {
+		hc.Test = []string{"CMD-SHELL", fmt.Sprintf("nc -z 127.0.0.1 %v || exit 1", h.TCP.Port)}
+	}
+
+	return hc, nil
+}
+
 type Port struct {
 	LocalhostOnly   bool   `json:"localhost_only,omitempty"`
 	PortAndProtocol string `json:"port_and_protocol"`
@@ -4,8 +4,10 @@
 package containermessage
 
 import (
+	"encoding/json"
 	docker "github.com/fsouza/go-dockerclient"
 	"testing"
+	"time"
 )
 
 func Test_HasSpecificPortBinding(t *testing.T) {
@@ -179,3 +181,81 @@ func Test_AddSpecificPortBinding(t *testing.T) {
 		t.Errorf("Service should have 2 specific port bindings but not.")
 	}
 }
+
+func Test_HealthCheck_Validate(t *testing.T) {
+	hc := HealthCheck{}
+	if err := hc.Validate(); err == nil {
+		t.Errorf("HealthCheck %v without a probe should not be valid.", hc)
+	}
+
+	hc = HealthCheck{Exec: []string{"/bin/check"}, TCP: &TCPProbe{Port: 5432}}
+	if err := hc.Validate(); err == nil {
+		t.Errorf("HealthCheck %v with 2 probes should not be valid.", hc)
+	}
+
+	hc = HealthCheck{HTTP: &HTTPProbe{Port: 70000}}
+	if err := hc.Validate(); err == nil {
+		t.Errorf("HealthCheck %v with an invalid port should not be valid.", hc)
+	}
+
+	hc = HealthCheck{HTTP: &HTTPProbe{Port: 8080, Path: "/health; rm -rf /"}}
+	if err := hc.Validate(); err == nil {
+		t.Errorf("HealthCheck %v with shell characters in the path should not be valid.", hc)
+	}
+
+	hc = HealthCheck{TCP: &TCPProbe{Port: 5432}, Retries: -1}
+	if err := hc.Validate(); err == nil {
+		t.Errorf("HealthCheck %v with negative retries should not be valid.", hc)
+	}
+
+	hc = HealthCheck{TCP: &TCPProbe{Port: 5432}, Interval: 10, Retries: 3}
+	if err := hc.Validate(); err != nil {
+		t.Errorf("HealthCheck %v should be valid, error: %v", hc, err)
+	}
+}
+
+func Test_HealthCheck_DockerHealthConfig(t *testing.T) {
+	depStr := `{"services":{"db":{"image":"postgres","healthcheck":{"exec":["pg_isready","-U","postgres"],"interval":10,"timeout":2,"retries":5,"start_period":30}}}}`
+
+	dd, err := GetNativeDeployment(depStr)
+	if err != nil {
+		t.Fatalf("Error unmarshalling deployment %v: %v", depStr, err)
+	} else if dd.Services["db"].HealthCheck == nil {
+		t.Fatalf("HealthCheck should have been unmarshalled from %v", depStr)
+	}
+
+	hc, err := dd.Services["db"].HealthCheck.DockerHealthConfig()
+	if err != nil {
+		t.Errorf("Unexpected error converting health check: %v", err)
+	} else if len(hc.Test) != 4 || hc.Test[0] != "CMD" || hc.Test[1] != "pg_isready" {
+		t.Errorf("Unexpected test command %v", hc.Test)
+	} else if hc.Interval != 10*time.Second || hc.Timeout != 2*time.Second || hc.StartPeriod != 30*time.Second || hc.Retries != 5 {
+		t.Errorf("Unexpected health config timings %v", hc)
+	}
+
+	httpCheck := HealthCheck{HTTP: &HTTPProbe{Port: 8080, Path: "health"}}
+	if hc, err := httpCheck.DockerHealthConfig(); err != nil {
+		t.Errorf("Unexpected error converting health check: %v", err)
+	} else if len(hc.Test) != 2 || hc.Test[0] != "CMD-SHELL" || hc.Test[1] != "wget -q -O /dev/null 'http://127.0.0.1:8080/health' || curl -fsS -o /dev/null 'http://127.0.0.1:8080/health' || exit 1" {
+		t.Errorf("Unexpected test command %v", hc.Test)
+	} else if hc.Interval != 0 || hc.Retries != 0 {
+		t.Errorf("Unspecified timings should use the docker defaults, got %v", hc)
+	}
+
+	tcpCheck := HealthCheck{TCP: &TCPProbe{Port: 5432}}
+	if hc, err := tcpCheck.DockerHealthConfig(); err != nil {
+		t.Errorf("Unexpected error converting health check: %v", err)
+	} else if len(hc.Test) != 2 || hc.Test[1] != "nc -z 127.0.0.1 5432 || exit 1" {
+		t.Errorf("Unexpected test command %v", hc.Test)
+	}
+
+	// A service without a health check should not add one to the serialized deployment.
+	serv := Service{Image: "an image"}
+	if b, err := json.Marshal(serv); err != nil {
+		t.Errorf("Error marshalling service: %v", err)
+	} else if m := make(map[string]interface{}); json.Unmarshal(b, &m) != nil {
+		t.Errorf("Error unmarshalling service %v", string(b))
+	} else if _, ok := m["healthcheck"]; ok {
+		t.Errorf("Service without a health check should omit the field, got %v", string(b))
+	}
+}
@@ -0,0 +1,31 @@
+package containerruntime
+
+import (
+	docker "github.com/fsouza/go-dockerclient"
+)
+
+// The Docker engine runtime. The container API calls go straight to the go-dockerclient client.
+type dockerRuntime struct {
+	*docker.Client
+	rootless bool
+}
+
+func (d *dockerRuntime) Name() string {
+	return RUNTIME_DOCKER
+}
+
+func (d *dockerRuntime) IsRootless() bool {
+	return d.rootless
+}
+
+func (d *dockerRuntime) DefaultLogDriver() string {
+	return LOG_DRIVER_SYSLOG
+}
+
+func (d *dockerRuntime) SecurityOpts() []string {
+	return []string{}
+}
+
+func (d *dockerRuntime) DockerClient() *docker.Client {
+	return d.Client
+}
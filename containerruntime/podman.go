@@ -0,0 +1,21 @@
+package containerruntime
+
+// The Podman runtime, driven through the Docker compatible API of the Podman socket. Rootful and rootless Podman are
+// both supported, the socket of the runtime is set with Edge.DockerEndpoint.
+type podmanRuntime struct {
+	dockerRuntime
+}
+
+func (p *podmanRuntime) Name() string {
+	return RUNTIME_PODMAN
+}
+
+// Podman does not ship a syslog log driver, the journal is used instead.
+func (p *podmanRuntime) DefaultLogDriver() string {
+	return LOG_DRIVER_JOURNALD
+}
+
+// Needed in case SELinux is enabled with podman. Podman won't allow the use of the unix domain socket without it.
+func (p *podmanRuntime) SecurityOpts() []string {
+	return []string{"label=disable"}
+}
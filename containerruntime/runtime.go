@@ -0,0 +1,141 @@
+package containerruntime
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	docker "github.com/fsouza/go-dockerclient"
+	"github.com/golang/glog"
+	"github.com/open-horizon/anax/config"
+)
+
+// The container runtimes that can run services on a device node.
+const (
+	RUNTIME_DOCKER = config.CONTAINER_RUNTIME_DOCKER
+	RUNTIME_PODMAN = config.CONTAINER_RUNTIME_PODMAN
+)
+
+// The default log drivers of the runtimes.
+const (
+	LOG_DRIVER_SYSLOG   = "syslog"
+	LOG_DRIVER_JOURNALD = "journald"
+)
+
+// The container API calls that the agent makes to run services on a device node. The go-dockerclient types are used
+// for containers, networks, volumes and images, they are understood by Docker and by the Docker compatible API of Podman.
+// The go-dockerclient *docker.Client implements this interface.
+type ContainerClient interface {
+	Version() (*docker.Env, error)
+
+	// Network bridges
+	CreateNetwork(opts docker.CreateNetworkOptions) (*docker.Network, error)
+	ListNetworks() ([]docker.Network, error)
+	FilteredListNetworks(opts docker.NetworkFilterOpts) ([]docker.Network, error)
+	NetworkInfo(id string) (*docker.Network, error)
+	ConnectNetwork(id string, opts docker.NetworkConnectionOptions) error
+	DisconnectNetwork(id string, opts docker.NetworkConnectionOptions) error
+	RemoveNetwork(id string) error
+
+	// Service containers
+	CreateContainer(opts docker.CreateContainerOptions) (*docker.Container, error)
+	StartContainer(id string, hostConfig *docker.HostConfig) error
+	KillContainer(opts docker.KillContainerOptions) error
+	RemoveContainer(opts docker.RemoveContainerOptions) error
+	InspectContainer(id string) (*docker.Container, error)
+	ListContainers(opts docker.ListContainersOptions) ([]docker.APIContainers, error)
+
+	// Volumes
+	CreateVolume(opts docker.CreateVolumeOptions) (*docker.Volume, error)
+	ListVolumes(opts docker.ListVolumesOptions) ([]docker.Volume, error)
+	RemoveVolume(name string) error
+
+	// Images
+	InspectImage(name string) (*docker.Image, error)
+	PullImage(opts docker.PullImageOptions, auth docker.AuthConfiguration) error
+	ListImages(opts docker.ListImagesOptions) ([]docker.APIImages, error)
+}
+
+// A container runtime that the agent deploys services with. The ContainerWorker, the image fetcher and the status
+// reporting use this interface, so that the runtime of the node can be selected in the configuration.
+type ContainerRuntime interface {
+	ContainerClient
+	Name() string                 // The name of the runtime, one of the RUNTIME_* constants.
+	IsRootless() bool             // The runtime runs containers without root privileges, so host firewall rules cannot be applied to them.
+	DefaultLogDriver() string     // The log driver used for service containers that do not specify one.
+	SecurityOpts() []string       // The security options added to every service container.
+	DockerClient() *docker.Client // The underlying go-dockerclient client, for callers that need calls outside of ContainerClient.
+}
+
+// Create the container runtime for the given API endpoint. When the runtime name is empty, the runtime is detected from
+// the version information returned by the endpoint. Only runtimes that serve the Docker API are supported, there is no
+// containerd backend.
+func NewContainerRuntime(endpoint string, runtimeName string) (ContainerRuntime, error) {
+	client, err := docker.NewClient(endpoint)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("unable to create a client for container runtime endpoint %v, error: %v", endpoint, err))
+	}
+
+	if runtimeName == "" {
+		if runtimeName, err = DetectRuntime(client); err != nil {
+			return nil, err
+		}
+	}
+
+	switch runtimeName {
+	case RUNTIME_DOCKER:
+		return &dockerRuntime{Client: client, rootless: isRootless(client)}, nil
+	case RUNTIME_PODMAN:
+		return &podmanRuntime{dockerRuntime{Client: client, rootless: isRootless(client)}}, nil
+	default:
+		return nil, errors.New(fmt.Sprintf("container runtime %v is not supported", runtimeName))
+	}
+}
+
+// Determine if the endpoint is served by Docker or Podman. Podman reports itself in the components of the version info:
+//
+//	{
+//	  "Components": [{"Name": "Podman Engine","Version": "3.1.0-dev",...]
+//	  ...
+//	}
+func DetectRuntime(client ContainerClient) (string, error) {
+	if client == nil {
+		return RUNTIME_DOCKER, fmt.Errorf("Invalid client pointer: nil.")
+	}
+
+	versionInfo, err := client.Version()
+	if err != nil {
+		return RUNTIME_DOCKER, fmt.Errorf("Failed to get the container server API version info. %v", err)
+	}
+	glog.V(5).Infof("API version info: %v", versionInfo)
+
+	if versionInfo != nil {
+		for _, info := range *versionInfo {
+			if strings.Contains(strings.ToLower(info), "podman") {
+				glog.V(3).Infof("podman endpoint is detected.")
+				return RUNTIME_PODMAN, nil
+			}
+		}
+	}
+
+	return RUNTIME_DOCKER, nil
+}
+
+// Both Docker and Podman list "name=rootless" in the security options of the system info when they run without root.
+func isRootless(client *docker.Client) bool {
+	info, err := client.Info()
+	if err != nil {
+		glog.Warningf("Unable to get the container runtime system info, assuming it is not rootless. Error: %v", err)
+		return false
+	}
+	return hasRootlessOption(info.SecurityOptions)
+}
+
+func hasRootlessOption(securityOptions []string) bool {
+	for _, opt := range securityOptions {
+		if strings.Contains(opt, "rootless") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,71 @@
+//go:build unit
+// +build unit
+
+package containerruntime
+
+import (
+	"errors"
+	"testing"
+
+	docker "github.com/fsouza/go-dockerclient"
+	"github.com/stretchr/testify/assert"
+)
+
+// A container client that only answers the version call.
+type versionClient struct {
+	ContainerClient
+	env *docker.Env
+	err error
+}
+
+func (v *versionClient) Version() (*docker.Env, error) {
+	return v.env, v.err
+}
+
+func Test_DetectRuntime(t *testing.T) {
+
+	_, err := DetectRuntime(nil)
+	assert.NotNil(t, err)
+
+	_, err = DetectRuntime(&versionClient{err: errors.New("connection refused")})
+	assert.NotNil(t, err)
+
+	name, err := DetectRuntime(&versionClient{env: &docker.Env{"Version=20.10.7", "ApiVersion=1.41"}})
+	assert.Nil(t, err)
+	assert.Equal(t, RUNTIME_DOCKER, name)
+
+	name, err = DetectRuntime(&versionClient{env: &docker.Env{`Components=[{"Name":"Podman Engine","Version":"4.4.1"}]`, "ApiVersion=1.41"}})
+	assert.Nil(t, err)
+	assert.Equal(t, RUNTIME_PODMAN, name)
+}
+
+func Test_NewContainerRuntime(t *testing.T) {
+
+	// The endpoint is not contacted when the runtime is selected, the rootless check falls back to rootful.
+	rt, err := NewContainerRuntime("unix:///tmp/anax-no-such.sock", RUNTIME_PODMAN)
+	assert.Nil(t, err)
+	assert.Equal(t, RUNTIME_PODMAN, rt.Name())
+	assert.Equal(t, LOG_DRIVER_JOURNALD, rt.DefaultLogDriver())
+	assert.Equal(t, []string{"label=disable"}, rt.SecurityOpts())
+	assert.False(t, rt.IsRootless())
+	assert.NotNil(t, rt.DockerClient())
+
+	rt, err = NewContainerRuntime("unix:///tmp/anax-no-such.sock", RUNTIME_DOCKER)
+	assert.Nil(t, err)
+	assert.Equal(t, RUNTIME_DOCKER, rt.Name())
+	assert.Equal(t, LOG_DRIVER_SYSLOG, rt.DefaultLogDriver())
+	assert.Equal(t, 0, len(rt.SecurityOpts()))
+
+	_, err = NewContainerRuntime("unix:///tmp/anax-no-such.sock", "containerd")
+	assert.NotNil(t, err)
+
+	// Detection needs the endpoint.
+	_, err = NewContainerRuntime("unix:///tmp/anax-no-such.sock", "")
+	assert.NotNil(t, err)
+}
+
+func Test_hasRootlessOption(t *testing.T) {
+	assert.False(t, hasRootlessOption(nil))
+	assert.False(t, hasRootlessOption([]string{"name=seccomp,profile=default"}))
+	assert.True(t, hasRootlessOption([]string{"name=seccomp,profile=default", "name=rootless"}))
+}
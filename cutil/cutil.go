@@ -20,6 +20,7 @@ import (
 	"runtime"
 	"strconv"
 	"strings"
+	"syscall"
 	"time"
 
 	"github.com/golang/glog"
@@ -742,6 +743,28 @@ func GetMemInfo(meminfo_file string) (uint64, uint64, error) {
 	}
 }
 
+// Returns the total and available disk space in MB of the file system that holds the container images. If the path is
+// empty, the docker or podman storage directory is used, or the root file system if neither exists.
+func GetDiskInfo(path string) (uint64, uint64, error) {
+	if path == "" {
+		path = "/"
+		for _, dir := range []string{"/var/lib/docker", "/var/lib/containers"} {
+			if _, err := os.Stat(dir); err == nil {
+				path = dir
+				break
+			}
+		}
+	}
+
+	var fs syscall.Statfs_t
+	if err := syscall.Statfs(path, &fs); err != nil {
+		return 0, 0, fmt.Errorf("Failed to get the file system information for %v. %v", path, err)
+	}
+	total_disk := (uint64(fs.Blocks) * uint64(fs.Bsize)) >> 20
+	avail_disk := (uint64(fs.Bavail) * uint64(fs.Bsize)) >> 20
+	return total_disk, avail_disk, nil
+}
+
 // Converts the given number (in string) to mega bytes. The unit can be MB, KB, GB, or B.
 func ConvertToMB(value string, unit string) (uint64, error) {
 	if s, err := strconv.ParseUint(value, 10, 64); err != nil {
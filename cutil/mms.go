@@ -2,7 +2,6 @@ package cutil
 
 import (
 	"crypto"
-	"crypto/rsa"
 	"crypto/sha1"
 	"crypto/sha256"
 	"crypto/x509"
@@ -49,10 +48,9 @@ func VerifyDataSig(dataReader io.Reader, publicKey string, signature string, has
 
 			// verify datahash
 			dataHashSum := dataHash.Sum(nil)
-			pubKeyToUse := pubKey.(*rsa.PublicKey)
 			if cryptoHashType, err := GetCryptoHashType(hashAlgo); err != nil {
 				return false, err
-			} else if err = rsa.VerifyPSS(pubKeyToUse, cryptoHashType, dataHashSum, signatureBytes, nil); err != nil {
+			} else if err = VerifyDigest(pubKey, cryptoHashType, dataHashSum, signatureBytes); err != nil {
 				return false, err
 			}
 
@@ -103,10 +101,9 @@ func VerifyDataSigInFile(fileNameToVerify string, publicKey string, signature st
 
 			// verify datahash
 			dataHashSum := dataHash.Sum(nil)
-			pubKeyToUse := pubKey.(*rsa.PublicKey)
 			if cryptoHashType, err := GetCryptoHashType(hashAlgo); err != nil {
 				return false, err
-			} else if err = rsa.VerifyPSS(pubKeyToUse, cryptoHashType, dataHashSum, signatureBytes, nil); err != nil {
+			} else if err = VerifyDigest(pubKey, cryptoHashType, dataHashSum, signatureBytes); err != nil {
 				return false, err
 			}
 
@@ -0,0 +1,368 @@
+package cutil
+
+import (
+	"bufio"
+	"compress/gzip"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"math"
+
+	"github.com/open-horizon/edge-sync-service/common"
+)
+
+// The destination policy properties of an MMS object that is published with a delta from its previous version. The
+// object keeps its full data, and dataHash is the SHA256 hash of the data. The delta is published as a companion
+// object, with the ID of the object followed by MMS_DELTA_OBJECT_SUFFIX, whose data is the delta. The delta is applied
+// to the version of the data whose SHA256 hash is the deltaBase of the companion, and the SHA256 hash of the result is
+// its deltaHash, which is the dataHash of the object it was made for.
+const (
+	MMS_PROP_DATA_HASH  = "openhorizon.mms.dataHash"
+	MMS_PROP_DELTA_BASE = "openhorizon.mms.deltaBase"
+	MMS_PROP_DELTA_HASH = "openhorizon.mms.deltaHash"
+)
+
+const MMS_DELTA_OBJECT_SUFFIX = ".delta"
+
+// The delta format is the magic string followed by a gzip stream of operations. A copy operation copies a range of the
+// base data, an insert operation inserts the bytes that follow it. Offsets and lengths are uvarints.
+const (
+	deltaMagic     = "HZNDELTA1"
+	deltaBlockSize = 4096
+	deltaMaxInsert = 1 << 20
+	deltaOpCopy    = 'C'
+	deltaOpInsert  = 'I'
+	deltaOpEnd     = 'E'
+)
+
+// Returns the ID of the companion object that has the delta of an object.
+func DeltaObjectID(objectID string) string {
+	return objectID + MMS_DELTA_OBJECT_SUFFIX
+}
+
+// Returns true if the object is the companion object of another object, and its data is a delta.
+func IsDeltaObject(meta *common.MetaData) bool {
+	base, _ := GetObjectPolicyProperty(meta, MMS_PROP_DELTA_BASE).(string)
+	return base != ""
+}
+
+// Returns the hash of the data of an object published with a delta, or an empty string for other objects.
+func GetObjectDataHash(meta *common.MetaData) string {
+	dataHash, _ := GetObjectPolicyProperty(meta, MMS_PROP_DATA_HASH).(string)
+	return dataHash
+}
+
+// Returns the hash of the base data, and the hash of the data reconstructed from the delta of a companion object.
+func GetObjectDelta(meta *common.MetaData) (string, string) {
+	base, _ := GetObjectPolicyProperty(meta, MMS_PROP_DELTA_BASE).(string)
+	dataHash, _ := GetObjectPolicyProperty(meta, MMS_PROP_DELTA_HASH).(string)
+	return base, dataHash
+}
+
+// Remove the data hash and the delta properties from a destination policy.
+func RemoveObjectDelta(policy *common.Policy) {
+	RemoveObjectPolicyProperty(policy, MMS_PROP_DATA_HASH)
+	RemoveObjectPolicyProperty(policy, MMS_PROP_DELTA_BASE)
+	RemoveObjectPolicyProperty(policy, MMS_PROP_DELTA_HASH)
+}
+
+// Verify the data of an object that was reconstructed from the delta of its companion object. The SHA256 hash of the
+// data must be the data hash in the object's policy and, when the object is signed, the object's signature must verify
+// the data.
+func VerifyObjectDeltaData(meta *common.MetaData, data io.Reader) error {
+	dataHash := GetObjectDataHash(meta)
+	if dataHash == "" {
+		return errors.New(fmt.Sprintf("the object does not have the %v property", MMS_PROP_DATA_HASH))
+	}
+
+	sum := sha256.New()
+	writers := []io.Writer{sum}
+	var digest hash.Hash
+	if meta.PublicKey != "" {
+		var err error
+		if digest, err = GetHash(meta.HashAlgorithm); err != nil {
+			return err
+		}
+		writers = append(writers, digest)
+	}
+	if _, err := io.Copy(io.MultiWriter(writers...), data); err != nil {
+		return err
+	}
+
+	if hex.EncodeToString(sum.Sum(nil)) != dataHash {
+		return errors.New("the hash of the data reconstructed from the delta does not match the object")
+	} else if digest == nil {
+		return nil
+	}
+
+	if publicKeyBytes, err := base64.StdEncoding.DecodeString(meta.PublicKey); err != nil {
+		return err
+	} else if signatureBytes, err := base64.StdEncoding.DecodeString(meta.Signature); err != nil {
+		return err
+	} else if pubKey, err := x509.ParsePKIXPublicKey(publicKeyBytes); err != nil {
+		return err
+	} else if cryptoHashType, err := GetCryptoHashType(meta.HashAlgorithm); err != nil {
+		return err
+	} else if err := VerifyDigest(pubKey, cryptoHashType, digest.Sum(nil), signatureBytes); err != nil {
+		return errors.New(fmt.Sprintf("the signature of the data reconstructed from the delta is not valid, error %v", err))
+	}
+	return nil
+}
+
+// A block of the base data, found by the weak checksum of the block and confirmed by its SHA256 hash.
+type deltaBlock struct {
+	offset int64
+	strong [sha256.Size]byte
+}
+
+// The rsync rolling checksum of a window, split in its two sums so that it can be rolled one byte at a time.
+func deltaChecksum(window []byte) (uint32, uint32) {
+	var s1, s2 uint32
+	for i, c := range window {
+		s1 += uint32(c)
+		s2 += uint32(len(window)-i) * uint32(c)
+	}
+	return s1, s2
+}
+
+func deltaWeak(s1 uint32, s2 uint32) uint32 {
+	return s1&0xffff | s2<<16
+}
+
+// Write the delta that turns the base data into the target data. The base is indexed in blocks, and the target is
+// scanned with a rolling checksum so that the blocks of the base are found at any offset in the target.
+func WriteDelta(base io.Reader, target io.Reader, out io.Writer) error {
+	blocks := make(map[uint32][]deltaBlock)
+	block := make([]byte, deltaBlockSize)
+	for offset := int64(0); ; offset += deltaBlockSize {
+		if _, err := io.ReadFull(base, block); err == io.EOF || err == io.ErrUnexpectedEOF {
+			break
+		} else if err != nil {
+			return err
+		}
+		weak, strong := deltaWeak(deltaChecksum(block)), sha256.Sum256(block)
+		found := false
+		for _, b := range blocks[weak] {
+			if b.strong == strong {
+				found = true
+				break
+			}
+		}
+		if !found {
+			blocks[weak] = append(blocks[weak], deltaBlock{offset: offset, strong: strong})
+		}
+	}
+
+	if _, err := io.WriteString(out, deltaMagic); err != nil {
+		return err
+	}
+	zw := gzip.NewWriter(out)
+	w := &deltaWriter{w: bufio.NewWriter(zw)}
+
+	// The window is a ring of the last block of the target, the oldest byte is at head.
+	src := bufio.NewReader(target)
+	ring := make([]byte, deltaBlockSize)
+	match := func(s1 uint32, s2 uint32, head int) (int64, bool) {
+		candidates := blocks[deltaWeak(s1, s2)]
+		if len(candidates) == 0 {
+			return 0, false
+		}
+		h := sha256.New()
+		h.Write(ring[head:])
+		h.Write(ring[:head])
+		var strong [sha256.Size]byte
+		copy(strong[:], h.Sum(nil))
+		for _, b := range candidates {
+			if b.strong == strong {
+				return b.offset, true
+			}
+		}
+		return 0, false
+	}
+
+	for eof := false; !eof; {
+		n, err := io.ReadFull(src, ring)
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			if err := w.insert(ring[:n]); err != nil {
+				return err
+			}
+			break
+		} else if err != nil {
+			return err
+		}
+
+		head := 0
+		s1, s2 := deltaChecksum(ring)
+		for {
+			if offset, ok := match(s1, s2, head); ok {
+				if err := w.copy(offset, deltaBlockSize); err != nil {
+					return err
+				}
+				break
+			}
+
+			c, err := src.ReadByte()
+			if err == io.EOF {
+				if err := w.insert(ring[head:]); err != nil {
+					return err
+				} else if err := w.insert(ring[:head]); err != nil {
+					return err
+				}
+				eof = true
+				break
+			} else if err != nil {
+				return err
+			}
+
+			old := ring[head]
+			if err := w.insert([]byte{old}); err != nil {
+				return err
+			}
+			ring[head] = c
+			head = (head + 1) % deltaBlockSize
+			s1 = s1 - uint32(old) + uint32(c)
+			s2 = s2 - deltaBlockSize*uint32(old) + s1
+		}
+	}
+
+	if err := w.close(); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// Writes the delta operations. Adjacent copies are merged, and inserted bytes are buffered, so that there is only one
+// pending operation at a time.
+type deltaWriter struct {
+	w          *bufio.Writer
+	copyOffset int64
+	copyLength int64
+	inserted   []byte
+}
+
+func (d *deltaWriter) copy(offset int64, length int64) error {
+	if err := d.flushInsert(); err != nil {
+		return err
+	}
+	if d.copyLength > 0 && d.copyOffset+d.copyLength == offset {
+		d.copyLength += length
+		return nil
+	}
+	if err := d.flushCopy(); err != nil {
+		return err
+	}
+	d.copyOffset, d.copyLength = offset, length
+	return nil
+}
+
+func (d *deltaWriter) insert(data []byte) error {
+	if len(data) == 0 {
+		return nil
+	} else if err := d.flushCopy(); err != nil {
+		return err
+	}
+	d.inserted = append(d.inserted, data...)
+	if len(d.inserted) >= deltaMaxInsert {
+		return d.flushInsert()
+	}
+	return nil
+}
+
+func (d *deltaWriter) flushCopy() error {
+	if d.copyLength == 0 {
+		return nil
+	}
+	op := binary.AppendUvarint([]byte{deltaOpCopy}, uint64(d.copyOffset))
+	op = binary.AppendUvarint(op, uint64(d.copyLength))
+	d.copyLength = 0
+	_, err := d.w.Write(op)
+	return err
+}
+
+func (d *deltaWriter) flushInsert() error {
+	if len(d.inserted) == 0 {
+		return nil
+	}
+	op := binary.AppendUvarint([]byte{deltaOpInsert}, uint64(len(d.inserted)))
+	if _, err := d.w.Write(op); err != nil {
+		return err
+	} else if _, err := d.w.Write(d.inserted); err != nil {
+		return err
+	}
+	d.inserted = d.inserted[:0]
+	return nil
+}
+
+func (d *deltaWriter) close() error {
+	if err := d.flushCopy(); err != nil {
+		return err
+	} else if err := d.flushInsert(); err != nil {
+		return err
+	} else if err := d.w.WriteByte(deltaOpEnd); err != nil {
+		return err
+	}
+	return d.w.Flush()
+}
+
+// Apply a delta written by WriteDelta to the base data, writing the target data to out.
+func ApplyDelta(base io.ReaderAt, delta io.Reader, out io.Writer) error {
+	magic := make([]byte, len(deltaMagic))
+	if _, err := io.ReadFull(delta, magic); err != nil || string(magic) != deltaMagic {
+		return errors.New("the data is not a delta")
+	}
+	zr, err := gzip.NewReader(delta)
+	if err != nil {
+		return err
+	}
+	defer zr.Close()
+	r := bufio.NewReader(zr)
+
+	for {
+		op, err := r.ReadByte()
+		if err != nil {
+			return errors.New(fmt.Sprintf("the delta is truncated, error %v", err))
+		}
+		switch op {
+		case deltaOpCopy:
+			offset, err := binary.ReadUvarint(r)
+			if err != nil {
+				return errors.New(fmt.Sprintf("the delta is truncated, error %v", err))
+			}
+			length, err := binary.ReadUvarint(r)
+			if err != nil {
+				return errors.New(fmt.Sprintf("the delta is truncated, error %v", err))
+			} else if offset > math.MaxInt64 || length > math.MaxInt64-offset {
+				return errors.New(fmt.Sprintf("the delta copies an invalid range %v+%v of the base", offset, length))
+			}
+			if n, err := io.Copy(out, io.NewSectionReader(base, int64(offset), int64(length))); err != nil {
+				return err
+			} else if n != int64(length) {
+				return errors.New(fmt.Sprintf("the delta copies the range %v+%v beyond the end of the base", offset, length))
+			}
+		case deltaOpInsert:
+			length, err := binary.ReadUvarint(r)
+			if err != nil {
+				return errors.New(fmt.Sprintf("the delta is truncated, error %v", err))
+			} else if length > math.MaxInt64 {
+				return errors.New(fmt.Sprintf("the delta inserts an invalid length %v", length))
+			}
+			if _, err := io.CopyN(out, r, int64(length)); err != nil {
+				return errors.New(fmt.Sprintf("the delta is truncated, error %v", err))
+			}
+		case deltaOpEnd:
+			// Read the end of the gzip stream, so that its checksum is verified.
+			if _, err := io.Copy(io.Discard, r); err != nil {
+				return errors.New(fmt.Sprintf("the delta is truncated, error %v", err))
+			}
+			return nil
+		default:
+			return errors.New(fmt.Sprintf("the delta has an invalid operation %v", op))
+		}
+	}
+}
@@ -0,0 +1,126 @@
+//go:build unit
+// +build unit
+
+package cutil
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	mrand "math/rand"
+	"testing"
+
+	"github.com/open-horizon/edge-sync-service/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func testDelta(t *testing.T, base []byte, target []byte) []byte {
+	var delta bytes.Buffer
+	assert.Nil(t, WriteDelta(bytes.NewReader(base), bytes.NewReader(target), &delta))
+
+	var result bytes.Buffer
+	assert.Nil(t, ApplyDelta(bytes.NewReader(base), bytes.NewReader(delta.Bytes()), &result))
+	assert.True(t, bytes.Equal(target, result.Bytes()))
+	return delta.Bytes()
+}
+
+func Test_WriteDelta(t *testing.T) {
+	rnd := mrand.New(mrand.NewSource(1))
+	base := make([]byte, 1<<20+123)
+	rnd.Read(base)
+
+	// a few bytes changed, some inserted and some removed
+	target := append([]byte{}, base[:100000]...)
+	target = append(target, []byte("inserted data")...)
+	target = append(target, base[100000:500000]...)
+	target = append(target, base[510000:]...)
+	target[700000] ^= 0xff
+	delta := testDelta(t, base, target)
+	assert.Less(t, len(delta), 3*deltaBlockSize)
+
+	// no change
+	delta = testDelta(t, base, base)
+	assert.Less(t, len(delta), 300)
+
+	// nothing in common, and data smaller than a block
+	other := make([]byte, 300000)
+	rnd.Read(other)
+	testDelta(t, base, other)
+	testDelta(t, base, []byte("small"))
+	testDelta(t, nil, other)
+	testDelta(t, base, nil)
+
+	// repeated blocks
+	zeros := make([]byte, 10*deltaBlockSize)
+	testDelta(t, zeros, append(append([]byte{1}, zeros...), 2))
+}
+
+func Test_ApplyDelta_errors(t *testing.T) {
+	base := make([]byte, 2*deltaBlockSize)
+	mrand.New(mrand.NewSource(1)).Read(base)
+	var delta bytes.Buffer
+	assert.Nil(t, WriteDelta(bytes.NewReader(base), bytes.NewReader(base), &delta))
+
+	var result bytes.Buffer
+	assert.NotNil(t, ApplyDelta(bytes.NewReader(base), bytes.NewReader([]byte("not a delta")), &result))
+	assert.NotNil(t, ApplyDelta(bytes.NewReader(base), bytes.NewReader(delta.Bytes()[:delta.Len()-10]), &result))
+
+	// the base is not the one the delta was made from
+	assert.NotNil(t, ApplyDelta(bytes.NewReader(base[:deltaBlockSize]), bytes.NewReader(delta.Bytes()), &result))
+}
+
+func Test_VerifyObjectDeltaData(t *testing.T) {
+	data := []byte("the reconstructed data")
+	sum := sha256.Sum256(data)
+
+	meta := &common.MetaData{ObjectID: "model", DestinationPolicy: &common.Policy{}}
+	assert.NotNil(t, VerifyObjectDeltaData(meta, bytes.NewReader(data)))
+	SetObjectPolicyProperty(meta.DestinationPolicy, MMS_PROP_DATA_HASH, hex.EncodeToString(sum[:]), "string")
+	assert.False(t, IsDeltaObject(meta))
+	assert.Equal(t, hex.EncodeToString(sum[:]), GetObjectDataHash(meta))
+	assert.Nil(t, VerifyObjectDeltaData(meta, bytes.NewReader(data)))
+	assert.NotNil(t, VerifyObjectDeltaData(meta, bytes.NewReader([]byte("other data"))))
+
+	// a signed object must have the signature of the data
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.Nil(t, err)
+	pubKey, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	assert.Nil(t, err)
+	meta.PublicKey = base64.StdEncoding.EncodeToString(pubKey)
+	meta.HashAlgorithm = common.Sha256
+	assert.NotNil(t, VerifyObjectDeltaData(meta, bytes.NewReader(data)))
+
+	sig, err := SignDigest(key, crypto.SHA256, sum[:])
+	assert.Nil(t, err)
+	meta.Signature = base64.StdEncoding.EncodeToString(sig)
+	assert.Nil(t, VerifyObjectDeltaData(meta, bytes.NewReader(data)))
+
+	other := sha256.Sum256([]byte("other data"))
+	sig, err = SignDigest(key, crypto.SHA256, other[:])
+	assert.Nil(t, err)
+	meta.Signature = base64.StdEncoding.EncodeToString(sig)
+	assert.NotNil(t, VerifyObjectDeltaData(meta, bytes.NewReader(data)))
+
+	// the companion object has the delta properties
+	delta := &common.MetaData{ObjectID: DeltaObjectID(meta.ObjectID), DestinationPolicy: &common.Policy{}}
+	SetObjectPolicyProperty(delta.DestinationPolicy, MMS_PROP_DELTA_BASE, "abc", "string")
+	SetObjectPolicyProperty(delta.DestinationPolicy, MMS_PROP_DELTA_HASH, hex.EncodeToString(sum[:]), "string")
+	assert.True(t, IsDeltaObject(delta))
+	assert.Equal(t, "model.delta", delta.ObjectID)
+	baseHash, dataHash := GetObjectDelta(delta)
+	assert.Equal(t, "abc", baseHash)
+	assert.Equal(t, GetObjectDataHash(meta), dataHash)
+
+	RemoveObjectDelta(meta.DestinationPolicy)
+	RemoveObjectDelta(delta.DestinationPolicy)
+	assert.False(t, IsDeltaObject(delta))
+	assert.Equal(t, "", GetObjectDataHash(meta))
+	assert.Equal(t, 0, len(meta.DestinationPolicy.Properties))
+	assert.Equal(t, 0, len(delta.DestinationPolicy.Properties))
+}
@@ -0,0 +1,125 @@
+package cutil
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/open-horizon/edge-sync-service/common"
+)
+
+// The destination policy properties of an MMS object that control its lifecycle on the nodes. The agent keeps the
+// number of previous versions of the object's data in retainVersions, and serves the pinned version to the services
+// in place of the current version. The pinned version is the version field of a previous version, or "previous" for
+// the version before the current one.
+const (
+	MMS_PROP_RETAIN_VERSIONS = "openhorizon.mms.retainVersions"
+	MMS_PROP_PINNED_VERSION  = "openhorizon.mms.pinnedVersion"
+	MMS_PINNED_PREVIOUS      = "previous"
+)
+
+// Returns the value of a destination policy property of the object, nil if the object does not have it.
+func GetObjectPolicyProperty(meta *common.MetaData, name string) interface{} {
+	if meta == nil || meta.DestinationPolicy == nil {
+		return nil
+	}
+	for _, prop := range meta.DestinationPolicy.Properties {
+		if prop.Name == name {
+			return prop.Value
+		}
+	}
+	return nil
+}
+
+// Set a destination policy property, replacing the property with the same name.
+func SetObjectPolicyProperty(policy *common.Policy, name string, value interface{}, propType string) {
+	for i, prop := range policy.Properties {
+		if prop.Name == name {
+			policy.Properties[i] = common.PolicyProperty{Name: name, Value: value, Type: propType}
+			return
+		}
+	}
+	policy.Properties = append(policy.Properties, common.PolicyProperty{Name: name, Value: value, Type: propType})
+}
+
+// Remove a destination policy property. Returns false if the policy does not have it.
+func RemoveObjectPolicyProperty(policy *common.Policy, name string) bool {
+	for i, prop := range policy.Properties {
+		if prop.Name == name {
+			policy.Properties = append(policy.Properties[:i], policy.Properties[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// Returns the number of previous versions of the object to retain, or the default if the object does not set it.
+func GetObjectRetainVersions(meta *common.MetaData, defaultVersions int) (int, error) {
+	var versions int
+	switch v := GetObjectPolicyProperty(meta, MMS_PROP_RETAIN_VERSIONS).(type) {
+	case nil:
+		return defaultVersions, nil
+	case float64:
+		versions = int(v)
+	case int:
+		versions = v
+	case string:
+		var err error
+		if versions, err = strconv.Atoi(v); err != nil {
+			return defaultVersions, errors.New(fmt.Sprintf("%v %v is not an integer", MMS_PROP_RETAIN_VERSIONS, v))
+		}
+	default:
+		return defaultVersions, errors.New(fmt.Sprintf("%v %v is not an integer", MMS_PROP_RETAIN_VERSIONS, v))
+	}
+	if versions < 0 {
+		return defaultVersions, errors.New(fmt.Sprintf("%v %v is negative", MMS_PROP_RETAIN_VERSIONS, versions))
+	}
+	return versions, nil
+}
+
+// Returns the pinned version of the object, an empty string if it is not pinned.
+func GetObjectPinnedVersion(meta *common.MetaData) string {
+	if v, ok := GetObjectPolicyProperty(meta, MMS_PROP_PINNED_VERSION).(string); ok {
+		return v
+	}
+	return ""
+}
+
+// Returns true if the object has an expiration time that has passed. The CSS removes expired objects, but the ESS does
+// not remove the objects it has received, so the agent uses this to stop serving them.
+func ObjectExpired(meta *common.MetaData, now time.Time) bool {
+	if meta == nil || meta.Expiration == "" {
+		return false
+	}
+	expiration, err := time.Parse(time.RFC3339, meta.Expiration)
+	return err == nil && !now.Before(expiration)
+}
+
+// Returns true if the service can use the object, because the service is in the object's destination policy with a
+// version in the range of the policy. The service is <org>/<version>/<name>, like the identity that the ESS
+// authenticates, an empty service (not a service with a version) can use every object. This is the check that the
+// ESS makes before it gives an object to a service.
+func ServiceCanUseObject(meta *common.MetaData, service string) bool {
+	if service == "" || meta == nil || meta.DestinationPolicy == nil || len(meta.DestinationPolicy.Services) == 0 {
+		return true
+	}
+	parts := strings.SplitN(service, "/", 3)
+	if len(parts) < 3 {
+		return false
+	}
+	serviceVersion, err := common.ParseSemVer(parts[1])
+	if err != nil {
+		return false
+	}
+	for _, s := range meta.DestinationPolicy.Services {
+		if s.OrgID != parts[0] || s.ServiceName != parts[2] {
+			continue
+		}
+		if versionRange, err := common.ParseSemVerRange(s.Version); err == nil && versionRange.IsInRange(serviceVersion) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,59 @@
+//go:build unit
+// +build unit
+
+package cutil
+
+import (
+	"testing"
+	"time"
+
+	"github.com/open-horizon/edge-sync-service/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_GetObjectRetainVersions(t *testing.T) {
+	meta := &common.MetaData{DestinationPolicy: &common.Policy{}}
+	versions, err := GetObjectRetainVersions(meta, 1)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, versions)
+
+	// JSON numbers, ints and strings are all accepted
+	for _, value := range []interface{}{float64(3), 3, "3"} {
+		SetObjectPolicyProperty(meta.DestinationPolicy, MMS_PROP_RETAIN_VERSIONS, value, "int")
+		versions, err = GetObjectRetainVersions(meta, 1)
+		assert.Nil(t, err)
+		assert.Equal(t, 3, versions)
+	}
+	assert.Equal(t, 1, len(meta.DestinationPolicy.Properties))
+
+	for _, value := range []interface{}{"three", -1, true} {
+		SetObjectPolicyProperty(meta.DestinationPolicy, MMS_PROP_RETAIN_VERSIONS, value, "int")
+		versions, err = GetObjectRetainVersions(meta, 1)
+		assert.NotNil(t, err)
+		assert.Equal(t, 1, versions)
+	}
+
+	assert.True(t, RemoveObjectPolicyProperty(meta.DestinationPolicy, MMS_PROP_RETAIN_VERSIONS))
+	assert.False(t, RemoveObjectPolicyProperty(meta.DestinationPolicy, MMS_PROP_RETAIN_VERSIONS))
+	assert.Equal(t, "", GetObjectPinnedVersion(meta))
+}
+
+func Test_ObjectExpired(t *testing.T) {
+	now := time.Now()
+	assert.False(t, ObjectExpired(&common.MetaData{}, now))
+	assert.False(t, ObjectExpired(&common.MetaData{Expiration: now.Add(time.Hour).Format(time.RFC3339)}, now))
+	assert.True(t, ObjectExpired(&common.MetaData{Expiration: now.Add(-time.Hour).Format(time.RFC3339)}, now))
+	assert.False(t, ObjectExpired(&common.MetaData{Expiration: "tomorrow"}, now))
+}
+
+func Test_ServiceCanUseObject(t *testing.T) {
+	meta := &common.MetaData{DestinationPolicy: &common.Policy{
+		Services: []common.ServiceID{{OrgID: "myorg", ServiceName: "detect", Arch: "*", Version: "[1.0.0,2.0.0)"}},
+	}}
+	assert.True(t, ServiceCanUseObject(meta, "myorg/1.5.0/detect"))
+	assert.False(t, ServiceCanUseObject(meta, "myorg/2.0.0/detect"))
+	assert.False(t, ServiceCanUseObject(meta, "otherorg/1.5.0/detect"))
+	assert.False(t, ServiceCanUseObject(meta, "myorg/detect"))
+	assert.True(t, ServiceCanUseObject(meta, ""))
+	assert.True(t, ServiceCanUseObject(&common.MetaData{}, "myorg/2.0.0/detect"))
+}
@@ -0,0 +1,280 @@
+package cutil
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"github.com/open-horizon/rsapss-tool/verify"
+	"math/big"
+	"os"
+	"time"
+)
+
+// The algorithms of the keys that sign deployment strings and MMS objects. RSA keys make RSA-PSS signatures, ECDSA keys
+// make ASN.1 encoded ECDSA signatures and Ed25519 keys make Ed25519 signatures. Every algorithm signs the digest of the
+// data, which is SHA256 for deployment strings and the hash algorithm of the object for MMS objects, so that large
+// objects can be signed and verified as a stream.
+const (
+	SIGNING_ALGO_RSA     = "rsa"
+	SIGNING_ALGO_ECDSA   = "ecdsa"
+	SIGNING_ALGO_ED25519 = "ed25519"
+)
+
+// Returns the signing algorithm of a public key, an error if the key is not supported. The ECDSA curves are P-256, P-384
+// and P-521.
+func SigningAlgorithm(pubKey crypto.PublicKey) (string, error) {
+	switch k := pubKey.(type) {
+	case *rsa.PublicKey:
+		return SIGNING_ALGO_RSA, nil
+	case *ecdsa.PublicKey:
+		switch k.Curve {
+		case elliptic.P256(), elliptic.P384(), elliptic.P521():
+			return SIGNING_ALGO_ECDSA, nil
+		default:
+			return "", errors.New(fmt.Sprintf("ECDSA curve %v is not supported", k.Curve.Params().Name))
+		}
+	case ed25519.PublicKey:
+		return SIGNING_ALGO_ED25519, nil
+	default:
+		return "", errors.New(fmt.Sprintf("public key type %T is not supported", pubKey))
+	}
+}
+
+// Parse a PEM encoded private key. RSA keys in PKCS #1 (RSA PRIVATE KEY), ECDSA keys in SEC 1 (EC PRIVATE KEY) and RSA,
+// ECDSA and Ed25519 keys in PKCS #8 (PRIVATE KEY) are supported.
+func ParseSigningKey(data []byte) (crypto.Signer, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("unable to find PEM block in the provided private key")
+	}
+
+	var key interface{}
+	var err error
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		key, err = x509.ParseECPrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	default:
+		return nil, errors.New(fmt.Sprintf("PEM block type %v is not a supported private key", block.Type))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, errors.New(fmt.Sprintf("private key type %T is not supported", key))
+	} else if _, err := SigningAlgorithm(signer.Public()); err != nil {
+		return nil, err
+	}
+	return signer, nil
+}
+
+// Read a PEM encoded private key file.
+func ReadSigningKey(keyFile string) (crypto.Signer, error) {
+	data, err := os.ReadFile(keyFile)
+	if err != nil {
+		return nil, err
+	}
+	signer, err := ParseSigningKey(data)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("%v: %v", keyFile, err))
+	}
+	return signer, nil
+}
+
+// Generate a private key with the signing algorithm. The length is the RSA key length, ECDSA keys are P-256.
+func GenerateSigningKey(algorithm string, length int) (crypto.Signer, error) {
+	switch algorithm {
+	case SIGNING_ALGO_RSA, "":
+		return rsa.GenerateKey(rand.Reader, length)
+	case SIGNING_ALGO_ECDSA:
+		return ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	case SIGNING_ALGO_ED25519:
+		_, key, err := ed25519.GenerateKey(rand.Reader)
+		return key, err
+	default:
+		return nil, errors.New(fmt.Sprintf("signing algorithm %v is not supported, it must be %v, %v or %v", algorithm, SIGNING_ALGO_RSA, SIGNING_ALGO_ECDSA, SIGNING_ALGO_ED25519))
+	}
+}
+
+// Returns the PEM encoding of a private key. RSA keys are PKCS #1 so that older tools can read them, the others are PKCS #8.
+func MarshalSigningKeyPEM(key crypto.Signer) ([]byte, error) {
+	if rsaKey, ok := key.(*rsa.PrivateKey); ok {
+		return pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(rsaKey)}), nil
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), nil
+}
+
+// Returns the PEM encoding of a public key.
+func MarshalPublicKeyPEM(pubKey crypto.PublicKey) ([]byte, error) {
+	der, err := x509.MarshalPKIXPublicKey(pubKey)
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), nil
+}
+
+// Sign the digest of some data, which was made with the hash type. The signer can be a key in memory or in a hardware
+// security module.
+func SignDigest(signer crypto.Signer, hashType crypto.Hash, digest []byte) ([]byte, error) {
+	algorithm, err := SigningAlgorithm(signer.Public())
+	if err != nil {
+		return nil, err
+	}
+
+	switch algorithm {
+	case SIGNING_ALGO_RSA:
+		return signer.Sign(rand.Reader, digest, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthAuto, Hash: hashType})
+	case SIGNING_ALGO_ECDSA:
+		return signer.Sign(rand.Reader, digest, hashType)
+	default:
+		// Ed25519 signs the digest as the message.
+		return signer.Sign(rand.Reader, digest, crypto.Hash(0))
+	}
+}
+
+// Verify the signature of the digest of some data, which was made with the hash type.
+func VerifyDigest(pubKey crypto.PublicKey, hashType crypto.Hash, digest []byte, signature []byte) error {
+	switch k := pubKey.(type) {
+	case *rsa.PublicKey:
+		return rsa.VerifyPSS(k, hashType, digest, signature, nil)
+	case *ecdsa.PublicKey:
+		if !ecdsa.VerifyASN1(k, digest, signature) {
+			return errors.New("ECDSA signature verification failed")
+		}
+	case ed25519.PublicKey:
+		if !ed25519.Verify(k, digest, signature) {
+			return errors.New("Ed25519 signature verification failed")
+		}
+	default:
+		return errors.New(fmt.Sprintf("public key type %T is not supported", pubKey))
+	}
+	return nil
+}
+
+// Sign the input with the private key. The base64 encoded signature of the SHA256 digest of the input is returned.
+func SignInput(signer crypto.Signer, input []byte) (string, error) {
+	digest := sha256.Sum256(input)
+	signature, err := SignDigest(signer, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(signature), nil
+}
+
+// Returns the public key of a PEM encoded public key or x509 cert, with the algorithm detected from the key. An error is
+// returned if the key or cert cannot verify signatures. RSA keys and certs are checked like they always were, the certs
+// must be self-signed. ECDSA and Ed25519 certs can be issued by a PKI, the cert is trusted as it is and its issuers are not
+// verified.
+func ValidPublicKeyOrCert(keyOrCert []byte) (crypto.PublicKey, error) {
+	block, _ := pem.Decode(keyOrCert)
+	if block == nil {
+		return nil, errors.New("unable to find PEM block in the provided public key or cert")
+	}
+
+	var pubKey crypto.PublicKey
+	if cert, err := x509.ParseCertificate(block.Bytes); err == nil {
+		if _, ok := cert.PublicKey.(*rsa.PublicKey); ok {
+			if rsaKey, err := verify.ValidKeyOrCert(keyOrCert); err != nil {
+				return nil, err
+			} else {
+				return rsaKey, nil
+			}
+		} else if err := checkSigningCert(cert, time.Now()); err != nil {
+			return nil, err
+		}
+		pubKey = cert.PublicKey
+	} else if pubKey, err = x509.ParsePKIXPublicKey(block.Bytes); err != nil {
+		return nil, errors.New(fmt.Sprintf("unable to parse provided file as a public key or cert, error: %v", err))
+	}
+
+	if _, err := SigningAlgorithm(pubKey); err != nil {
+		return nil, err
+	}
+	return pubKey, nil
+}
+
+func checkSigningCert(cert *x509.Certificate, now time.Time) error {
+	if now.Before(cert.NotBefore) {
+		return errors.New(fmt.Sprintf("certificate invalid; current time %v before valid NotBefore time: %v", now, cert.NotBefore))
+	} else if now.After(cert.NotAfter) {
+		return errors.New(fmt.Sprintf("certificate invalid; current time %v after valid NotAfter time: %v", now, cert.NotAfter))
+	} else if cert.SerialNumber.Cmp(big.NewInt(0)) < 1 {
+		return errors.New(fmt.Sprintf("certificate invalid; serial number not positive: %v", cert.SerialNumber))
+	} else if cert.IsCA {
+		return errors.New("certificate invalid; cert is a CA which is not supported")
+	} else if cert.KeyUsage != 0 && cert.KeyUsage&x509.KeyUsageDigitalSignature == 0 {
+		return errors.New("certificate invalid; KeyUsageDigitalSignature use type is required")
+	}
+	return nil
+}
+
+// Verify the signature of the input with the public key or cert in the file. It returns false without an error if the
+// signature was not made by the key, and an error if the key or the signature cannot be read.
+func VerifyInput(keyOrCertFile string, signature string, input []byte) (bool, error) {
+	signatureBytes, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return false, errors.New(fmt.Sprintf("unable to base64 decode signature %v, error: %v", signature, err))
+	}
+	pubKey, err := readPublicKeyOrCert(keyOrCertFile)
+	if err != nil {
+		return false, err
+	}
+	digest := sha256.Sum256(input)
+	return VerifyDigest(pubKey, crypto.SHA256, digest[:], signatureBytes) == nil, nil
+}
+
+// Verify the signature of the input with the public keys or certs in the files. It returns true and the name of the file
+// that verified the signature, or false and the errors keyed by file name. The errors that are not about a file are
+// keyed by verify.COMMON_ERROR.
+func InputVerifiedByAnyKey(keyOrCertFiles []string, signature string, input []byte) (bool, string, map[string]error) {
+	failed := make(map[string]error)
+
+	if len(keyOrCertFiles) == 0 {
+		failed[verify.COMMON_ERROR] = errors.New("no certificate or public key files provided; input not verified")
+		return false, "", failed
+	}
+
+	signatureBytes, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		failed[verify.COMMON_ERROR] = errors.New(fmt.Sprintf("unable to base64 decode signature %v, error: %v", signature, err))
+		return false, "", failed
+	}
+
+	digest := sha256.Sum256(input)
+	for _, keyOrCertFile := range keyOrCertFiles {
+		if pubKey, err := readPublicKeyOrCert(keyOrCertFile); err != nil {
+			failed[keyOrCertFile] = err
+		} else if err := VerifyDigest(pubKey, crypto.SHA256, digest[:], signatureBytes); err != nil {
+			failed[keyOrCertFile] = errors.New(fmt.Sprintf("unable to verify signature using pubkey file %v, error: %v", keyOrCertFile, err))
+		} else {
+			return true, keyOrCertFile, nil
+		}
+	}
+	return false, "", failed
+}
+
+func readPublicKeyOrCert(keyOrCertFile string) (crypto.PublicKey, error) {
+	data, err := os.ReadFile(keyOrCertFile)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("unable to read key file %v, error: %v", keyOrCertFile, err))
+	}
+	return ValidPublicKeyOrCert(data)
+}
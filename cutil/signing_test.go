@@ -0,0 +1,146 @@
+//go:build unit
+// +build unit
+
+package cutil
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"github.com/open-horizon/edge-sync-service/common"
+	"github.com/stretchr/testify/assert"
+	"math/big"
+	"os"
+	"path"
+	"testing"
+	"time"
+)
+
+func Test_SignAndVerifyInput(t *testing.T) {
+	dir := t.TempDir()
+	input := []byte(`{"services":{"cpu":{"image":"openhorizon/cpu:1.0.0"}}}`)
+
+	for _, algorithm := range []string{SIGNING_ALGO_RSA, SIGNING_ALGO_ECDSA, SIGNING_ALGO_ED25519} {
+		signer, err := GenerateSigningKey(algorithm, 2048)
+		assert.Nil(t, err, algorithm)
+
+		// the private key is read back from its PEM file
+		privPem, err := MarshalSigningKeyPEM(signer)
+		assert.Nil(t, err, algorithm)
+		privFile := path.Join(dir, algorithm+"-private.key")
+		assert.Nil(t, os.WriteFile(privFile, privPem, 0600))
+
+		readSigner, err := ReadSigningKey(privFile)
+		assert.Nil(t, err, algorithm)
+		sig, err := SignInput(readSigner, input)
+		assert.Nil(t, err, algorithm)
+
+		pubPem, err := MarshalPublicKeyPEM(signer.Public())
+		assert.Nil(t, err, algorithm)
+		pubFile := path.Join(dir, algorithm+"-public.pem")
+		assert.Nil(t, os.WriteFile(pubFile, pubPem, 0644))
+
+		verified, err := VerifyInput(pubFile, sig, input)
+		assert.Nil(t, err, algorithm)
+		assert.True(t, verified, algorithm)
+
+		verified, err = VerifyInput(pubFile, sig, []byte("something else"))
+		assert.Nil(t, err, algorithm)
+		assert.False(t, verified, algorithm)
+	}
+
+	// the signature is verified by the key that made it
+	verified, fn, failed := InputVerifiedByAnyKey([]string{path.Join(dir, "rsa-public.pem"), path.Join(dir, "ed25519-public.pem")}, mustSign(t, path.Join(dir, "ed25519-private.key"), input), input)
+	assert.True(t, verified)
+	assert.Equal(t, path.Join(dir, "ed25519-public.pem"), fn)
+	assert.Nil(t, failed)
+
+	verified, _, failed = InputVerifiedByAnyKey([]string{path.Join(dir, "rsa-public.pem"), path.Join(dir, "ed25519-public.pem")}, mustSign(t, path.Join(dir, "ecdsa-private.key"), input), input)
+	assert.False(t, verified)
+	assert.Equal(t, 2, len(failed))
+}
+
+func Test_ValidPublicKeyOrCert(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.Nil(t, err)
+
+	// a cert issued by a CA is accepted for ECDSA keys
+	ca := &x509.Certificate{SerialNumber: big.NewInt(1), Subject: pkix.Name{CommonName: "ca"}, NotBefore: time.Now().Add(-time.Hour), NotAfter: time.Now().Add(time.Hour), IsCA: true, BasicConstraintsValid: true, KeyUsage: x509.KeyUsageCertSign}
+	leaf := &x509.Certificate{SerialNumber: big.NewInt(2), Subject: pkix.Name{CommonName: "signer"}, NotBefore: time.Now().Add(-time.Hour), NotAfter: time.Now().Add(time.Hour), KeyUsage: x509.KeyUsageDigitalSignature}
+	der, err := x509.CreateCertificate(rand.Reader, leaf, ca, &key.PublicKey, key)
+	assert.Nil(t, err)
+	pubKey, err := ValidPublicKeyOrCert(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+	assert.Nil(t, err)
+	assert.Equal(t, &key.PublicKey, pubKey)
+
+	// a CA cert and an expired cert are not
+	der, err = x509.CreateCertificate(rand.Reader, ca, ca, &key.PublicKey, key)
+	assert.Nil(t, err)
+	_, err = ValidPublicKeyOrCert(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+	assert.NotNil(t, err)
+
+	leaf.NotAfter = time.Now().Add(-time.Minute)
+	der, err = x509.CreateCertificate(rand.Reader, leaf, ca, &key.PublicKey, key)
+	assert.Nil(t, err)
+	_, err = ValidPublicKeyOrCert(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+	assert.NotNil(t, err)
+
+	// P-224 keys are not supported
+	p224, err := ecdsa.GenerateKey(elliptic.P224(), rand.Reader)
+	assert.Nil(t, err)
+	der, err = x509.MarshalPKIXPublicKey(&p224.PublicKey)
+	assert.Nil(t, err)
+	_, err = ValidPublicKeyOrCert(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+	assert.NotNil(t, err)
+}
+
+func Test_ParseSigningKey_SEC1(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.Nil(t, err)
+	der, err := x509.MarshalECPrivateKey(key)
+	assert.Nil(t, err)
+
+	signer, err := ParseSigningKey(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der}))
+	assert.Nil(t, err)
+	assert.Equal(t, &key.PublicKey, signer.Public())
+
+	_, err = ParseSigningKey([]byte("not a key"))
+	assert.NotNil(t, err)
+}
+
+func Test_VerifyDataSigInFile(t *testing.T) {
+	dir := t.TempDir()
+	data := []byte("model data")
+	dataFile := path.Join(dir, "object.tmp")
+	assert.Nil(t, os.WriteFile(dataFile, data, 0600))
+
+	for _, algorithm := range []string{SIGNING_ALGO_RSA, SIGNING_ALGO_ECDSA, SIGNING_ALGO_ED25519} {
+		signer, err := GenerateSigningKey(algorithm, 2048)
+		assert.Nil(t, err, algorithm)
+
+		digest := sha1.Sum(data)
+		sig, err := SignDigest(signer, crypto.SHA1, digest[:])
+		assert.Nil(t, err, algorithm)
+		pubDer, err := x509.MarshalPKIXPublicKey(signer.Public())
+		assert.Nil(t, err, algorithm)
+
+		assert.Nil(t, os.WriteFile(dataFile, data, 0600))
+		verified, err := VerifyDataSigInFile(dataFile, base64.StdEncoding.EncodeToString(pubDer), base64.StdEncoding.EncodeToString(sig), common.Sha1, path.Join(dir, "object"))
+		assert.Nil(t, err, algorithm)
+		assert.True(t, verified, algorithm)
+	}
+}
+
+func mustSign(t *testing.T, keyFile string, input []byte) string {
+	signer, err := ReadSigningKey(keyFile)
+	assert.Nil(t, err)
+	sig, err := SignInput(signer, input)
+	assert.Nil(t, err)
+	return sig
+}
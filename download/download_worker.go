@@ -18,7 +18,6 @@ import (
 	"github.com/open-horizon/anax/persistence"
 	"github.com/open-horizon/anax/semanticversion"
 	"github.com/open-horizon/anax/worker"
-	bolt "go.etcd.io/bbolt"
 )
 
 const (
@@ -43,10 +42,10 @@ const (
 
 type DownloadWorker struct {
 	worker.BaseWorker
-	db *bolt.DB
+	db persistence.AgentDatabase
 }
 
-func NewDownloadWorker(name string, config *config.HorizonConfig, db *bolt.DB) *DownloadWorker {
+func NewDownloadWorker(name string, config *config.HorizonConfig, db persistence.AgentDatabase) *DownloadWorker {
 	ec := getEC(config, db)
 
 	worker := &DownloadWorker{
@@ -564,7 +563,7 @@ func checkForLatestKeywords(manifest *exchangecommon.UpgradeManifest) *exchangec
 	return &latestVers
 }
 
-func getEC(config *config.HorizonConfig, db *bolt.DB) *worker.BaseExchangeContext {
+func getEC(config *config.HorizonConfig, db persistence.AgentDatabase) *worker.BaseExchangeContext {
 	var ec *worker.BaseExchangeContext
 	if dev, _ := persistence.FindExchangeDevice(db); dev != nil {
 		ec = worker.NewExchangeContext(fmt.Sprintf("%v/%v", dev.Org, dev.Id), dev.Token, config.Edge.ExchangeURL, config.GetCSSURL(), config.Edge.AgbotURL, config.Collaborators.HTTPClientFactory)
@@ -16,7 +16,6 @@ import (
 	"github.com/open-horizon/anax/exchangecommon"
 	"github.com/open-horizon/anax/externalpolicy"
 	"github.com/open-horizon/anax/persistence"
-	bolt "go.etcd.io/bbolt"
 )
 
 func Test_ResolveUpgradeVersions(t *testing.T) {
@@ -315,13 +314,13 @@ func Test_formAgentUpgradePackageNames(t *testing.T) {
 	}
 }
 
-func setupDB() (string, *bolt.DB, error) {
+func setupDB() (string, persistence.AgentDatabase, error) {
 	dir, err := os.MkdirTemp("", "container-")
 	if err != nil {
 		return "", nil, err
 	}
 
-	db, err := bolt.Open(path.Join(dir, "anax-int.db"), 0600, &bolt.Options{Timeout: 10 * time.Second})
+	db, err := persistence.OpenBoltDatabase(path.Join(dir, "anax-int.db"))
 	if err != nil {
 		return dir, nil, err
 	}
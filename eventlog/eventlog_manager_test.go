@@ -8,11 +8,9 @@ import (
 	"os"
 	"path"
 	"testing"
-	"time"
 
 	"github.com/open-horizon/anax/persistence"
 	"github.com/stretchr/testify/assert"
-	bolt "go.etcd.io/bbolt"
 )
 
 func init() {
@@ -505,13 +503,13 @@ func Test_LogExchangeEvent(t *testing.T) {
 
 }
 
-func utsetup() (string, *bolt.DB, error) {
+func utsetup() (string, persistence.AgentDatabase, error) {
 	dir, err := os.MkdirTemp("", "utdb-")
 	if err != nil {
 		return "", nil, err
 	}
 
-	db, err := bolt.Open(path.Join(dir, "anax-int.db"), 0600, &bolt.Options{Timeout: 10 * time.Second})
+	db, err := persistence.OpenBoltDatabase(path.Join(dir, "anax-int.db"))
 	if err != nil {
 		return dir, nil, err
 	}
@@ -50,6 +50,7 @@ const (
 	// container-related
 	EXECUTION_FAILED            EventId = "EXECUTION_FAILED"
 	EXECUTION_BEGUN             EventId = "EXECUTION_BEGUN"
+	EXECUTION_UNHEALTHY         EventId = "EXECUTION_UNHEALTHY"
 	WORKLOAD_DESTROYED          EventId = "WORKLOAD_DESTROYED"
 	CONTAINER_STOPPING          EventId = "CONTAINER_STOPPING"
 	CONTAINER_DESTROYED         EventId = "CONTAINER_DESTROYED"
@@ -159,10 +160,11 @@ type LaunchContext interface {
 }
 
 type MicroserviceSpec struct {
-	SpecRef string
-	Org     string
-	Version string
-	MsdefId string
+	SpecRef   string
+	Org       string
+	Version   string
+	MsdefId   string
+	Readiness *exchangecommon.DependencyReadiness // the condition the service must meet before its parent is started
 }
 
 type AgreementLaunchContext struct {
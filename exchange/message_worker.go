@@ -10,7 +10,6 @@ import (
 	"github.com/open-horizon/anax/events"
 	"github.com/open-horizon/anax/persistence"
 	"github.com/open-horizon/anax/worker"
-	bolt "go.etcd.io/bbolt"
 	"strconv"
 	"time"
 )
@@ -34,11 +33,11 @@ type GetDeviceMessageResponse struct {
 
 type ExchangeMessageWorker struct {
 	worker.BaseWorker // embedded field
-	db                *bolt.DB
+	db                persistence.AgentDatabase
 	config            *config.HorizonConfig
 }
 
-func NewExchangeMessageWorker(name string, cfg *config.HorizonConfig, db *bolt.DB) *ExchangeMessageWorker {
+func NewExchangeMessageWorker(name string, cfg *config.HorizonConfig, db persistence.AgentDatabase) *ExchangeMessageWorker {
 
 	var ec *worker.BaseExchangeContext
 	if dev, _ := persistence.FindExchangeDevice(db); dev != nil {
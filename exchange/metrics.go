@@ -0,0 +1,62 @@
+package exchange
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// The latency of the calls to the exchange, by HTTP method, exchange resource and HTTP status code. Calls that did not
+// get a response from the exchange have the code "error". Both the agent and the agbot expose this metric on their
+// /metrics API.
+var ExchangeRequestDuration = prometheus.NewHistogramVec(
+	prometheus.HistogramOpts{
+		Namespace: "horizon",
+		Subsystem: "exchange",
+		Name:      "request_duration_seconds",
+		Help:      "Latency of the calls to the exchange, by method, resource and HTTP status code.",
+		Buckets:   []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30},
+	},
+	[]string{"method", "resource", "code"},
+)
+
+const EXCHANGE_REQUEST_ERROR_CODE = "error"
+
+// Record the result of a call to the exchange.
+func observeExchangeRequest(method string, urlPath string, httpResp *http.Response, err error, elapsed time.Duration) {
+	code := EXCHANGE_REQUEST_ERROR_CODE
+	if err == nil && httpResp != nil {
+		code = strconv.Itoa(httpResp.StatusCode)
+	}
+	ExchangeRequestDuration.WithLabelValues(method, exchangeResource(urlPath), code).Observe(elapsed.Seconds())
+}
+
+// Return the type of exchange resource in a URL path, e.g. nodes for /v1/orgs/myorg/nodes/mynode/agreements. The
+// resource names are a small, fixed set so they can be used as a metric label, unlike the full path.
+func exchangeResource(urlPath string) string {
+	segments := make([]string, 0, 8)
+	for _, s := range strings.Split(urlPath, "/") {
+		if s != "" {
+			segments = append(segments, s)
+		}
+	}
+
+	for ix, s := range segments {
+		if s == "orgs" {
+			// The resource follows the org id. A path that ends at the org is the org itself.
+			if ix+2 < len(segments) {
+				return segments[ix+2]
+			}
+			return "orgs"
+		}
+	}
+
+	// Paths outside of an org, e.g. /v1/admin/version, are identified by their last segment.
+	if len(segments) > 0 {
+		return segments[len(segments)-1]
+	}
+	return ""
+}
@@ -0,0 +1,26 @@
+//go:build unit
+// +build unit
+
+package exchange
+
+import (
+	"testing"
+)
+
+func Test_exchangeResource(t *testing.T) {
+
+	tests := map[string]string{
+		"/v1/orgs/myorg/nodes/mynode/agreements": "nodes",
+		"/api/v1/orgs/myorg/business/policies":   "business",
+		"/v1/orgs/myorg":                         "orgs",
+		"/v1/orgs":                               "orgs",
+		"/v1/admin/version":                      "version",
+		"":                                       "",
+	}
+
+	for path, expected := range tests {
+		if r := exchangeResource(path); r != expected {
+			t.Errorf("resource for %v should be %v, was %v", path, expected, r)
+		}
+	}
+}
@@ -131,7 +131,9 @@ func InvokeExchange(httpClient *http.Client, method string, urlPath string, user
 		}
 
 		// If the exchange is down, this call will return an error.
+		start := time.Now()
 		httpResp, err := httpClient.Do(req)
+		observeExchangeRequest(method, urlObj.Path, httpResp, err, time.Since(start))
 		if httpResp != nil && httpResp.Body != nil {
 			defer httpResp.Body.Close()
 		}
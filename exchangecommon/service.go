@@ -20,15 +20,77 @@ const SERVICE_SHARING_MODE_MULTIPLE = "multiple"
 
 // This object is used to refer to a specific service that is a dependency for the referencing service.
 type ServiceDependency struct {
-	URL          string `json:"url"`
-	Org          string `json:"org"`
-	Version      string `json:"version,omitempty"`
-	VersionRange string `json:"versionRange"`
-	Arch         string `json:"arch"`
+	URL          string               `json:"url"`
+	Org          string               `json:"org"`
+	Version      string               `json:"version,omitempty"`
+	VersionRange string               `json:"versionRange"`
+	Arch         string               `json:"arch"`
+	Readiness    *DependencyReadiness `json:"readiness,omitempty"`
 }
 
 func (sd ServiceDependency) String() string {
-	return fmt.Sprintf("{URL: %v, Org: %v, Version: %v, VersionRange: %v, Arch: %v}", sd.URL, sd.Org, sd.Version, sd.VersionRange, sd.Arch)
+	if sd.Readiness == nil {
+		return fmt.Sprintf("{URL: %v, Org: %v, Version: %v, VersionRange: %v, Arch: %v}", sd.URL, sd.Org, sd.Version, sd.VersionRange, sd.Arch)
+	}
+	return fmt.Sprintf("{URL: %v, Org: %v, Version: %v, VersionRange: %v, Arch: %v, Readiness: %v}", sd.URL, sd.Org, sd.Version, sd.VersionRange, sd.Arch, sd.Readiness)
+}
+
+// dependency readiness conditions
+const READINESS_RUNNING = "running" // the dependency's containers are running, this is the default
+const READINESS_HEALTHY = "healthy" // the dependency's containers are passing their health check
+const READINESS_TCP = "tcp"         // the dependency's containers are accepting connections on a port
+
+// the number of seconds a parent service waits for a dependency to become ready when no timeout is specified
+const DEFAULT_READINESS_TIMEOUT = 600
+
+// The condition a dependent service has to meet before the service that requires it is started.
+type DependencyReadiness struct {
+	Condition string `json:"condition"`
+	Port      int    `json:"port,omitempty"`    // the container port checked by the tcp condition
+	Timeout   int    `json:"timeout,omitempty"` // seconds to wait for the condition before the parent fails to start
+}
+
+func (r *DependencyReadiness) String() string {
+	if r == nil {
+		return "<nil>"
+	}
+	return fmt.Sprintf("{Condition: %v, Port: %v, Timeout: %v}", r.Condition, r.Port, r.Timeout)
+}
+
+func (r *DependencyReadiness) GetCondition() string {
+	if r == nil || r.Condition == "" {
+		return READINESS_RUNNING
+	}
+	return r.Condition
+}
+
+func (r *DependencyReadiness) GetTimeout() int {
+	if r == nil || r.Timeout == 0 {
+		return DEFAULT_READINESS_TIMEOUT
+	}
+	return r.Timeout
+}
+
+func (r *DependencyReadiness) Validate() error {
+	if r == nil {
+		return nil
+	}
+	switch r.GetCondition() {
+	case READINESS_RUNNING, READINESS_HEALTHY:
+		if r.Port != 0 {
+			return fmt.Errorf("port is only supported for the %v readiness condition", READINESS_TCP)
+		}
+	case READINESS_TCP:
+		if r.Port <= 0 || r.Port > 65535 {
+			return fmt.Errorf("port %v is not a valid port number for the %v readiness condition", r.Port, READINESS_TCP)
+		}
+	default:
+		return fmt.Errorf("readiness condition %v is not supported, it must be one of %v, %v or %v", r.Condition, READINESS_RUNNING, READINESS_HEALTHY, READINESS_TCP)
+	}
+	if r.Timeout < 0 {
+		return fmt.Errorf("readiness timeout must not be negative")
+	}
+	return nil
 }
 
 func (sd ServiceDependency) GetVersionRange() string {
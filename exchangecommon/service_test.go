@@ -0,0 +1,52 @@
+//go:build unit
+// +build unit
+
+package exchangecommon
+
+import (
+	"testing"
+)
+
+func Test_DependencyReadiness_Validate(t *testing.T) {
+	valid := []*DependencyReadiness{
+		nil,
+		&DependencyReadiness{},
+		&DependencyReadiness{Condition: READINESS_RUNNING},
+		&DependencyReadiness{Condition: READINESS_HEALTHY, Timeout: 120},
+		&DependencyReadiness{Condition: READINESS_TCP, Port: 8080},
+	}
+	for _, r := range valid {
+		if err := r.Validate(); err != nil {
+			t.Errorf("readiness %v should be valid, error: %v", r, err)
+		}
+	}
+
+	invalid := []*DependencyReadiness{
+		&DependencyReadiness{Condition: "ready"},
+		&DependencyReadiness{Condition: READINESS_TCP},
+		&DependencyReadiness{Condition: READINESS_TCP, Port: 70000},
+		&DependencyReadiness{Condition: READINESS_HEALTHY, Port: 8080},
+		&DependencyReadiness{Condition: READINESS_RUNNING, Timeout: -1},
+	}
+	for _, r := range invalid {
+		if err := r.Validate(); err == nil {
+			t.Errorf("readiness %v should be invalid", r)
+		}
+	}
+}
+
+func Test_DependencyReadiness_Defaults(t *testing.T) {
+	var r *DependencyReadiness
+	if r.GetCondition() != READINESS_RUNNING {
+		t.Errorf("nil readiness should default to %v, was %v", READINESS_RUNNING, r.GetCondition())
+	} else if r.GetTimeout() != DEFAULT_READINESS_TIMEOUT {
+		t.Errorf("nil readiness should default to a timeout of %v, was %v", DEFAULT_READINESS_TIMEOUT, r.GetTimeout())
+	}
+
+	r = &DependencyReadiness{Condition: READINESS_HEALTHY, Timeout: 30}
+	if r.GetCondition() != READINESS_HEALTHY {
+		t.Errorf("readiness condition should be %v, was %v", READINESS_HEALTHY, r.GetCondition())
+	} else if r.GetTimeout() != 30 {
+		t.Errorf("readiness timeout should be 30, was %v", r.GetTimeout())
+	}
+}
@@ -9,7 +9,6 @@ import (
 	"github.com/open-horizon/anax/exchangecommon"
 	"github.com/open-horizon/anax/persistence"
 	"github.com/open-horizon/anax/version"
-	bolt "go.etcd.io/bbolt"
 	"os"
 	"sync"
 )
@@ -36,7 +35,7 @@ func SetExchangeNode(device *exchange.Device) {
 }
 
 // Get the node from the exchange and save it
-func SyncNodeWithExchange(db *bolt.DB, pDevice *persistence.ExchangeDevice, getDevice exchange.DeviceHandler) (*exchange.Device, error) {
+func SyncNodeWithExchange(db persistence.AgentDatabase, pDevice *persistence.ExchangeDevice, getDevice exchange.DeviceHandler) (*exchange.Device, error) {
 
 	glog.V(4).Infof("Checking the node changes.")
 
@@ -60,7 +59,7 @@ func SyncNodeWithExchange(db *bolt.DB, pDevice *persistence.ExchangeDevice, getD
 }
 
 // Used one time when the local node is first registered
-func NodeInitalSetup(db *bolt.DB, getDevice exchange.DeviceHandler, patchDevice exchange.PatchDeviceHandler) error {
+func NodeInitalSetup(db persistence.AgentDatabase, getDevice exchange.DeviceHandler, patchDevice exchange.PatchDeviceHandler) error {
 
 	// get the node
 	pDevice, err := persistence.FindExchangeDevice(db)
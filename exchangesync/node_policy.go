@@ -12,7 +12,6 @@ import (
 	"github.com/open-horizon/anax/exchangecommon"
 	"github.com/open-horizon/anax/externalpolicy"
 	"github.com/open-horizon/anax/persistence"
-	bolt "go.etcd.io/bbolt"
 )
 
 var nodePolicyUpdateLock sync.Mutex //The lock that protects the nodePolicyLastUpdated value
@@ -20,7 +19,7 @@ var nodePolicyUpdateLock sync.Mutex //The lock that protects the nodePolicyLastU
 // Check the node policy changes on the exchange and update the local copy with the changes.
 // It returns the comparision result code that is defined in externalpolicy/ExternalPolicy.go
 // (EP_COMPARE_*) for deployment and management. It also returns the latest node policy.
-func SyncNodePolicyWithExchange(db *bolt.DB, pDevice *persistence.ExchangeDevice, getExchangeNodePolicy exchange.NodePolicyHandler, putExchangeNodePolicy exchange.PutNodePolicyHandler) (int, int, *exchangecommon.NodePolicy, error) {
+func SyncNodePolicyWithExchange(db persistence.AgentDatabase, pDevice *persistence.ExchangeDevice, getExchangeNodePolicy exchange.NodePolicyHandler, putExchangeNodePolicy exchange.PutNodePolicyHandler) (int, int, *exchangecommon.NodePolicy, error) {
 
 	glog.V(4).Infof("Checking the node policy changes.")
 
@@ -90,7 +89,7 @@ func SyncNodePolicyWithExchange(db *bolt.DB, pDevice *persistence.ExchangeDevice
 // This function retrieves the node's policy from the exchange, adds the node built-in properties if needed. Then it saves the new
 // node policy to the exchange again and then returns the new node policy. If the exchange node policy already has the built-in properties,
 // it just returns the one from the exchange.
-func GetProcessedExchangeNodePolicy(pDevice *persistence.ExchangeDevice, getExchangeNodePolicy exchange.NodePolicyHandler, putExchangeNodePolicy exchange.PutNodePolicyHandler, db *bolt.DB) (*exchange.ExchangeNodePolicy, error) {
+func GetProcessedExchangeNodePolicy(pDevice *persistence.ExchangeDevice, getExchangeNodePolicy exchange.NodePolicyHandler, putExchangeNodePolicy exchange.PutNodePolicyHandler, db persistence.AgentDatabase) (*exchange.ExchangeNodePolicy, error) {
 	// get the node policy from the exchange
 	exchangeNodePolicy, err := getExchangeNodePolicy(fmt.Sprintf("%v/%v", pDevice.Org, pDevice.Id))
 	if err != nil {
@@ -191,7 +190,7 @@ func GetProcessedExchangeNodePolicy(pDevice *persistence.ExchangeDevice, getExch
 }
 
 // Sets the default node policy on local db and the exchange
-func SetDefaultNodePolicy(config *config.HorizonConfig, pDevice *persistence.ExchangeDevice, db *bolt.DB,
+func SetDefaultNodePolicy(config *config.HorizonConfig, pDevice *persistence.ExchangeDevice, db persistence.AgentDatabase,
 	getExchangeNodePolicy exchange.NodePolicyHandler,
 	putExchangeNodePolicy exchange.PutNodePolicyHandler) (*exchangecommon.NodePolicy, error) {
 
@@ -277,7 +276,7 @@ func SetDefaultNodePolicy(config *config.HorizonConfig, pDevice *persistence.Exc
 
 // If the both local and exchange node policy are not created, use the default.
 // Otherwise, update the local node policy with the one from the exchange.
-func NodePolicyInitalSetup(db *bolt.DB, config *config.HorizonConfig,
+func NodePolicyInitalSetup(db persistence.AgentDatabase, config *config.HorizonConfig,
 	getExchangeNodePolicy exchange.NodePolicyHandler,
 	putExchangeNodePolicy exchange.PutNodePolicyHandler) (*exchangecommon.NodePolicy, error) {
 
@@ -321,7 +320,7 @@ func NodePolicyInitalSetup(db *bolt.DB, config *config.HorizonConfig,
 
 // check if the node policy has been changed from last sync.
 // It returns the latest node policy on the exchange.
-func ExchangeNodePolicyChanged(pDevice *persistence.ExchangeDevice, db *bolt.DB, getExchangeNodePolicy exchange.NodePolicyHandler) (bool, *exchangecommon.NodePolicy, error) {
+func ExchangeNodePolicyChanged(pDevice *persistence.ExchangeDevice, db persistence.AgentDatabase, getExchangeNodePolicy exchange.NodePolicyHandler) (bool, *exchangecommon.NodePolicy, error) {
 
 	// get the node policy from the exchange
 	exchangeNodePolicy, err := getExchangeNodePolicy(fmt.Sprintf("%v/%v", pDevice.Org, pDevice.Id))
@@ -353,7 +352,7 @@ func ExchangeNodePolicyChanged(pDevice *persistence.ExchangeDevice, db *bolt.DB,
 }
 
 // Delete the node policy from local db and the exchange
-func DeleteNodePolicy(pDevice *persistence.ExchangeDevice, db *bolt.DB,
+func DeleteNodePolicy(pDevice *persistence.ExchangeDevice, db persistence.AgentDatabase,
 	getExchangeNodePolicy exchange.NodePolicyHandler,
 	deleteExchangeNodePolicy exchange.DeleteNodePolicyHandler) error {
 
@@ -388,7 +387,7 @@ func DeleteNodePolicy(pDevice *persistence.ExchangeDevice, db *bolt.DB,
 // Update (create new or replace old) node policy on local db and the exchange.
 // It returns the comparision result code that is defined in externalpolicy/ExternalPolicy.go
 // (EP_COMPARE_*) for deployment and management. It also returns the latest node policy.
-func UpdateNodePolicy(pDevice *persistence.ExchangeDevice, db *bolt.DB, nodePolicy *exchangecommon.NodePolicy,
+func UpdateNodePolicy(pDevice *persistence.ExchangeDevice, db persistence.AgentDatabase, nodePolicy *exchangecommon.NodePolicy,
 	nodeGetPolicyHandler exchange.NodePolicyHandler,
 	nodePutPolicyHandler exchange.PutNodePolicyHandler) (int, int, error) {
 
@@ -440,7 +439,7 @@ func UpdateNodePolicy(pDevice *persistence.ExchangeDevice, db *bolt.DB, nodePoli
 
 // It returns the comparision result code that is defined in externalpolicy/ExternalPolicy.go
 // (EP_COMPARE_*) for deployment and management. It also returns the latest node policy.
-func PatchNodePolicy(pDevice *persistence.ExchangeDevice, db *bolt.DB,
+func PatchNodePolicy(pDevice *persistence.ExchangeDevice, db persistence.AgentDatabase,
 	attributeName string, patchObject interface{},
 	nodeGetPolicyHandler exchange.NodePolicyHandler,
 	nodePutPolicyHandler exchange.PutNodePolicyHandler) (int, int, *exchangecommon.NodePolicy, error) {
@@ -9,7 +9,6 @@ import (
 	"path"
 	"strings"
 	"testing"
-	"time"
 
 	"github.com/open-horizon/anax/config"
 	"github.com/open-horizon/anax/exchange"
@@ -17,12 +16,11 @@ import (
 	"github.com/open-horizon/anax/externalpolicy"
 	_ "github.com/open-horizon/anax/externalpolicy/text_language"
 	"github.com/open-horizon/anax/persistence"
-	bolt "go.etcd.io/bbolt"
 )
 
 var ExchangeNodePolicy *exchange.ExchangeNodePolicy
 
-const NUM_BUILT_INS = 7
+const NUM_BUILT_INS = 8
 const CLUSTER_NUM_BUILT_INS = 6
 
 // Verify that a Node Policy Object can be created and saved the first time.
@@ -326,13 +324,13 @@ func getDummyDeleteNodePolicyHandler() exchange.DeleteNodePolicyHandler {
 	}
 }
 
-func utsetup() (string, *bolt.DB, error) {
+func utsetup() (string, persistence.AgentDatabase, error) {
 	dir, err := os.MkdirTemp("", "utdb-")
 	if err != nil {
 		return "", nil, err
 	}
 
-	db, err := bolt.Open(path.Join(dir, "anax-int.db"), 0600, &bolt.Options{Timeout: 10 * time.Second})
+	db, err := persistence.OpenBoltDatabase(path.Join(dir, "anax-int.db"))
 	if err != nil {
 		return dir, nil, err
 	}
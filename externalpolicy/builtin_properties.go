@@ -25,6 +25,7 @@ const (
 	PROP_NODE_K8S_NAMESPACE_SCOPED = "openhorizon.kubernetesNamespaceScoped" // Boolean field indicating whter the cluster agent is namespace-scoped
 	PROP_NODE_OS                   = "openhorizon.operatingSystem"           // The operating system the agent is installed on. For containerized agents, this is the host os
 	PROP_NODE_CONTAINERIZED        = "openhorizon.containerized"             // Boolean field indicating whether the agent is running in a container
+	PROP_NODE_DISK                 = "openhorizon.disk"                      // The amount of disk space in MBs for the containers
 
 	// for install type
 	OS_CLUSTER   = "cluster"
@@ -51,7 +52,7 @@ const MAX_MEMEORY = 1048576                            // the unit is MB. This i
 const DEFAULT_NODE_K8S_NAMESPACE = "openhorizon-agent" // the default cluster name space for cluster type. The default for device type is an emptry string.
 
 func ListReadOnlyProperties() []string {
-	return []string{PROP_NODE_CPU, PROP_NODE_ARCH, PROP_NODE_MEMORY, PROP_NODE_HARDWAREID, PROP_NODE_K8S_VERSION, PROP_NODE_K8S_NAMESPACE, PROP_NODE_K8S_NAMESPACE_SCOPED, PROP_NODE_OS, PROP_NODE_CONTAINERIZED}
+	return []string{PROP_NODE_CPU, PROP_NODE_ARCH, PROP_NODE_MEMORY, PROP_NODE_HARDWAREID, PROP_NODE_K8S_VERSION, PROP_NODE_K8S_NAMESPACE, PROP_NODE_K8S_NAMESPACE_SCOPED, PROP_NODE_OS, PROP_NODE_CONTAINERIZED, PROP_NODE_DISK}
 }
 
 // returns a map of all the built-in properties used by the given node type
@@ -59,7 +60,7 @@ func ListReadOnlyProperties() []string {
 func NodeBuiltInPropMap(nodeType string) map[string]string {
 	if nodeType == "device" {
 		return map[string]string{PROP_NODE_CPU: "2.23.4", PROP_NODE_MEMORY: "2.23.4", PROP_NODE_ARCH: "2.23.4", PROP_NODE_HARDWAREID: "2.24.5", PROP_NODE_PRIVILEGED: "2.24.10",
-			PROP_NODE_OS: "2.30.0", PROP_NODE_CONTAINERIZED: "2.30.0"}
+			PROP_NODE_OS: "2.30.0", PROP_NODE_CONTAINERIZED: "2.30.0", PROP_NODE_DISK: "2.32.0"}
 	} else if nodeType == "cluster" {
 		return map[string]string{PROP_NODE_K8S_NAMESPACE_SCOPED: "2.31.0", PROP_NODE_K8S_NAMESPACE: "2.31.0", PROP_NODE_K8S_VERSION: "2.26.4", PROP_NODE_CPU: "2.23.4", PROP_NODE_MEMORY: "2.23.4", PROP_NODE_ARCH: "2.23.4", PROP_NODE_PRIVILEGED: "2.24.10"}
 	}
@@ -142,6 +143,12 @@ func createDeviceNodeBuiltInPolicy(availableMem bool, omitGenHwId bool, existing
 		avail_mem = 0
 	}
 
+	total_disk, _, err := cutil.GetDiskInfo("")
+	if err != nil {
+		glog.V(2).Infof("Failed to get disk info for the local node. Proceeding with default value. %v", err)
+		total_disk = 0
+	}
+
 	privileged := false
 	if existingPolicy != nil && existingPolicy.Properties.HasProperty(PROP_NODE_PRIVILEGED) {
 		privProp, _ := existingPolicy.Properties.GetProperty(PROP_NODE_PRIVILEGED)
@@ -211,6 +218,7 @@ func createDeviceNodeBuiltInPolicy(availableMem bool, omitGenHwId bool, existing
 	} else {
 		nodeBuiltInReadOnlyProps.Add_Property(Property_Factory(PROP_NODE_MEMORY, float64(total_mem)), false)
 	}
+	nodeBuiltInReadOnlyProps.Add_Property(Property_Factory(PROP_NODE_DISK, float64(total_disk)), false)
 
 	buitInPolReadOnly := ExternalPolicy{
 		Properties:  *nodeBuiltInReadOnlyProps,
@@ -253,7 +261,8 @@ func IsNodeBuiltinPropertyName(propName string) bool {
 		propName == PROP_NODE_K8S_NAMESPACE ||
 		propName == PROP_NODE_K8S_NAMESPACE_SCOPED ||
 		propName == PROP_NODE_OS ||
-		propName == PROP_NODE_CONTAINERIZED {
+		propName == PROP_NODE_CONTAINERIZED ||
+		propName == PROP_NODE_DISK {
 		return true
 	} else {
 		return false
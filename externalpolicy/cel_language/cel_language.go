@@ -0,0 +1,173 @@
+package cel_language
+
+import (
+	"errors"
+	"fmt"
+	"github.com/google/cel-go/cel"
+	"github.com/open-horizon/anax/externalpolicy/plugin_registry"
+	"github.com/open-horizon/anax/i18n"
+	"sort"
+	"strings"
+)
+
+// Constraints written in the Common Expression Language start with this prefix, which is how this plugin
+// recognizes the constraints it owns, e.g. cel: node.memory >= 2048 && node.location.startsWith("eu-")
+const CEL_PREFIX = "cel:"
+
+func init() {
+	plugin_registry.Register("cel", NewCELConstraintLanguagePlugin())
+}
+
+type CELConstraintLanguagePlugin struct {
+}
+
+func NewCELConstraintLanguagePlugin() plugin_registry.ConstraintLanguagePlugin {
+	return new(CELConstraintLanguagePlugin)
+}
+
+// The variable names through which a constraint refers to the counterparty's properties. Deployment and model policies
+// usually refer to the node, node policies usually refer to the service, but both names refer to the same properties.
+var PROPERTY_VARIABLES = []string{"node", "service"}
+
+// The CEL environment shared by all constraints. The only variables are the counterparty's properties, as a map
+// from property name to value, and only the standard CEL functions are available.
+var celEnv = newCELEnv()
+
+func newCELEnv() *cel.Env {
+	opts := make([]cel.EnvOption, 0, len(PROPERTY_VARIABLES))
+	for _, v := range PROPERTY_VARIABLES {
+		opts = append(opts, cel.Variable(v, cel.MapType(cel.StringType, cel.DynType)))
+	}
+	env, err := cel.NewEnv(opts...)
+	if err != nil {
+		panic(fmt.Sprintf("unable to create the CEL environment, error: %v", err))
+	}
+	return env
+}
+
+// Parse and type check a CEL expression, which has to produce a bool.
+func compileCEL(expr string) (*cel.Ast, error) {
+	ast, iss := celEnv.Compile(expr)
+	if iss.Err() != nil {
+		return nil, iss.Err()
+	} else if t := ast.OutputType(); !t.IsExactType(cel.BoolType) && !t.IsExactType(cel.DynType) {
+		return nil, fmt.Errorf("the expression produces a %v, not a bool", t)
+	}
+	return ast, nil
+}
+
+// Returns the properties as a CEL map. A property whose name contains dots is also nested under each part of its
+// name, so that openhorizon.cpu can be referenced as node.openhorizon.cpu, unless a property already has that name.
+func propertyMap(props map[string]interface{}) map[string]interface{} {
+	m := make(map[string]interface{}, len(props))
+	names := make([]string, 0, len(props))
+	for name, value := range props {
+		m[name] = value
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		parts := strings.Split(name, ".")
+		parent := m
+		for _, part := range parts[:len(parts)-1] {
+			child, ok := parent[part].(map[string]interface{})
+			if !ok {
+				if _, exists := parent[part]; exists {
+					parent = nil
+					break
+				}
+				child = make(map[string]interface{})
+				parent[part] = child
+			}
+			parent = child
+		}
+		if last := parts[len(parts)-1]; parent != nil {
+			if _, exists := parent[last]; !exists {
+				parent[last] = props[name]
+			}
+		}
+	}
+	return m
+}
+
+// Returns true if the constraint is written in CEL.
+func IsCELConstraint(constraint string) bool {
+	return strings.HasPrefix(strings.TrimSpace(constraint), CEL_PREFIX)
+}
+
+// Returns the CEL expression in the constraint, without the prefix.
+func stripPrefix(constraint string) string {
+	return strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(constraint), CEL_PREFIX))
+}
+
+// The plugin owns the constraints if all of them are written in CEL. A constraint list that mixes CEL with another
+// language is not owned by any single plugin, ConstraintExpression validates each of its constraints with the plugin
+// that owns the constraint's language.
+func (p *CELConstraintLanguagePlugin) Validate(dconstraints interface{}) (bool, []string, error) {
+
+	// get message printer because this function is called by CLI
+	msgPrinter := i18n.GetMessagePrinter()
+
+	constraints, ok := dconstraints.([]string)
+	if !ok {
+		return false, []string{}, errors.New(msgPrinter.Sprintf("The constraint expression: %v is type %T, but is expected to be an array of strings", dconstraints, dconstraints))
+	}
+
+	if len(constraints) == 0 {
+		return false, nil, nil
+	}
+	for _, constraint := range constraints {
+		if !IsCELConstraint(constraint) {
+			return false, nil, nil
+		}
+	}
+
+	validConstraints := make([]string, 0, len(constraints))
+	for _, constraint := range constraints {
+		if expr := stripPrefix(constraint); expr == "" {
+			return true, nil, errors.New(msgPrinter.Sprintf("The CEL constraint expression %v is empty.", constraint))
+		} else if _, err := compileCEL(expr); err != nil {
+			return true, nil, errors.New(msgPrinter.Sprintf("Error parsing CEL constraint expression %v. Error was: %v", constraint, err))
+		}
+		validConstraints = append(validConstraints, constraint)
+	}
+
+	return true, validConstraints, nil
+}
+
+// CEL expressions are evaluated as a whole by Evaluate, they cannot be broken down into property expressions.
+func (p *CELConstraintLanguagePlugin) GetNextExpression(expression string) (string, string, error) {
+	return "", expression, fmt.Errorf("CEL constraint expressions cannot be converted to property expressions: %v", expression)
+}
+
+func (p *CELConstraintLanguagePlugin) GetNextOperator(expression string) (string, string, error) {
+	return "", expression, fmt.Errorf("CEL constraint expressions cannot be converted to property expressions: %v", expression)
+}
+
+// Evaluate a CEL constraint against the counterparty's properties. The constraint is satisfied only if the
+// expression evaluates to true; an error (such as a reference to a missing property) means it is not satisfied.
+func (p *CELConstraintLanguagePlugin) Evaluate(constraint string, props map[string]interface{}) (bool, error) {
+	ast, err := compileCEL(stripPrefix(constraint))
+	if err != nil {
+		return false, fmt.Errorf("error parsing CEL constraint expression %v, error: %v", constraint, err)
+	}
+	prg, err := celEnv.Program(ast)
+	if err != nil {
+		return false, fmt.Errorf("error parsing CEL constraint expression %v, error: %v", constraint, err)
+	}
+
+	properties := propertyMap(props)
+	vars := make(map[string]interface{}, len(PROPERTY_VARIABLES))
+	for _, v := range PROPERTY_VARIABLES {
+		vars[v] = properties
+	}
+
+	result, _, err := prg.Eval(vars)
+	if err != nil {
+		return false, fmt.Errorf("error evaluating CEL constraint expression %v, error: %v", constraint, err)
+	} else if b, ok := result.Value().(bool); !ok {
+		return false, fmt.Errorf("CEL constraint expression %v evaluated to %v, which is a %v, not a bool", constraint, result.Value(), result.Type().TypeName())
+	} else {
+		return b, nil
+	}
+}
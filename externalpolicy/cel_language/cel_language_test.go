@@ -0,0 +1,128 @@
+//go:build unit
+// +build unit
+
+package cel_language
+
+import (
+	"testing"
+)
+
+func Test_Validate_Succeed(t *testing.T) {
+	celPlugin := NewCELConstraintLanguagePlugin()
+	constraintStrings := []string{
+		"cel: node.memory >= 2048 && node.location.startsWith(\"eu-\")",
+		"cel:node.openhorizon.cpu * 2 > 3 || !has(node.gpu)",
+		"cel: node.purpose in ['test', 'prod'] ? node.memory > 1024 : false",
+		"cel: node['openhorizon.arch'].matches('^(amd64|arm64)$') && size(node.name) <= 0x20",
+	}
+
+	if owned, validated, err := celPlugin.Validate(interface{}(constraintStrings)); !owned {
+		t.Errorf("Should own the constraints but did not, err: %v", err)
+	} else if err != nil {
+		t.Errorf("Should validate without err, but returned err: %v", err)
+	} else if len(validated) != len(constraintStrings) {
+		t.Errorf("Should return %v validated constraints, returned %v", len(constraintStrings), validated)
+	}
+}
+
+func Test_Validate_NotOwned(t *testing.T) {
+	celPlugin := NewCELConstraintLanguagePlugin()
+	if owned, _, err := celPlugin.Validate(interface{}([]string{"memory >= 2048 && location == eu"})); owned {
+		t.Errorf("Should not own text constraints")
+	} else if err != nil {
+		t.Errorf("Should not return an error for text constraints, returned err: %v", err)
+	}
+
+	// a list that mixes languages is validated one constraint at a time by ConstraintExpression
+	if owned, _, err := celPlugin.Validate(interface{}([]string{"cel: node.memory > 1", "memory > 1"})); owned {
+		t.Errorf("Should not own constraints that mix CEL and text")
+	} else if err != nil {
+		t.Errorf("Should not return an error for mixed constraints, returned err: %v", err)
+	}
+
+	if owned, _, err := celPlugin.Validate(interface{}("cel: node.memory > 1")); owned || err == nil {
+		t.Errorf("Should not own a constraint that is not an array of strings")
+	}
+}
+
+func Test_Validate_Failed(t *testing.T) {
+	celPlugin := NewCELConstraintLanguagePlugin()
+	invalid := [][]string{
+		[]string{"cel: node.memory >= "},
+		[]string{"cel: memory >= 2048"},
+		[]string{"cel: node.location.trim() == 'eu'"},
+		[]string{"cel: (node.memory > 1"},
+		[]string{"cel: node.name == 'abc"},
+		[]string{"cel:"},
+		[]string{"cel: node.memory + 'MB'"},
+	}
+
+	for _, constraints := range invalid {
+		if owned, _, err := celPlugin.Validate(interface{}(constraints)); !owned {
+			t.Errorf("Should own %v", constraints)
+		} else if err == nil {
+			t.Errorf("Validation of %v should fail but did not", constraints)
+		}
+	}
+}
+
+func Test_Evaluate(t *testing.T) {
+	celPlugin := NewCELConstraintLanguagePlugin().(*CELConstraintLanguagePlugin)
+	props := map[string]interface{}{
+		"memory":           float64(4096),
+		"location":         "eu-west",
+		"openhorizon.cpu":  2,
+		"openhorizon.arch": "arm64",
+		"certifications":   []string{"USDA", "Organic"},
+		"secure":           true,
+	}
+
+	satisfied := []string{
+		"cel: node.memory >= 2048 && node.location.startsWith(\"eu-\")",
+		"cel: service.memory / 2.0 == 2048.0",
+		"cel: node.openhorizon.cpu + 1 == 3",
+		"cel: node['openhorizon.arch'] == 'arm64'",
+		"cel: 'Organic' in node.certifications && size(node.certifications) == 2",
+		"cel: node.location.matches('^eu-(west|east)$')",
+		"cel: node.secure && !has(node.gpu)",
+		"cel: node.gpu > 0 || node.memory > 1024",
+		"cel: 'location' in node",
+		"cel: node.location.endsWith('west') ? node.memory > 0 : false",
+		"cel: string(node.openhorizon.cpu) == '2' && int('7') % 4 == 3 && double(node.openhorizon.cpu) == 2.0",
+	}
+	for _, c := range satisfied {
+		if ok, err := celPlugin.Evaluate(c, props); err != nil {
+			t.Errorf("Evaluating %v returned err: %v", c, err)
+		} else if !ok {
+			t.Errorf("%v should be satisfied", c)
+		}
+	}
+
+	notSatisfied := []string{
+		"cel: node.memory < 2048",
+		"cel: node.location.startsWith('us-')",
+		"cel: node.gpu > 0 && node.memory < 1024",
+		"cel: 'Kosher' in node.certifications",
+	}
+	for _, c := range notSatisfied {
+		if ok, err := celPlugin.Evaluate(c, props); err != nil {
+			t.Errorf("Evaluating %v returned err: %v", c, err)
+		} else if ok {
+			t.Errorf("%v should not be satisfied", c)
+		}
+	}
+
+	errors := []string{
+		"cel: node.gpu > 0",
+		"cel: node.gpu > 0 && node.memory > 1024",
+		"cel: node.memory + 'MB' == '4096MB'",
+		"cel: node.memory / 2 == 2048",
+		"cel: node.location > 2",
+		"cel: 1 / 0 == 1",
+	}
+	for _, c := range errors {
+		if ok, err := celPlugin.Evaluate(c, props); err == nil {
+			t.Errorf("Evaluating %v should return an error, returned %v", c, ok)
+		}
+	}
+}
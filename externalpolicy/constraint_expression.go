@@ -1,6 +1,7 @@
 package externalpolicy
 
 import (
+	"errors"
 	"fmt"
 	"github.com/open-horizon/anax/externalpolicy/plugin_registry"
 	"strings"
@@ -9,8 +10,18 @@ import (
 // This type implements all the ConstraintLanguage Plug-in methods and delegates to the plug-in system."
 type ConstraintExpression []string
 
+// Each constraint is validated by the plugin that owns its language, so a constraint expression can contain
+// constraints written in different languages.
 func (c *ConstraintExpression) Validate() ([]string, error) {
-	return plugin_registry.ConstraintLanguagePlugins.ValidatedByOne((*c).GetStrings())
+	validated := make([]string, 0, len(*c))
+	for _, constraint := range *c {
+		if v, err := plugin_registry.ConstraintLanguagePlugins.ValidatedByOne([]string{constraint}); err != nil {
+			return nil, err
+		} else {
+			validated = append(validated, v...)
+		}
+	}
+	return validated, nil
 }
 
 func (c *ConstraintExpression) GetLanguageHandler() (plugin_registry.ConstraintLanguagePlugin, error) {
@@ -88,6 +99,11 @@ func (c ConstraintExpression) IsSame(other ConstraintExpression) bool {
 	return true
 }
 
+// Get the language handler for a single constraint in the expression.
+func getConstraintLanguageHandler(constraint string) (plugin_registry.ConstraintLanguagePlugin, error) {
+	return plugin_registry.ConstraintLanguagePlugins.GetLanguageHandlerByOne([]string{constraint})
+}
+
 // This function is used to determine if an input set of properties and values will satisfy
 // the ConstraintExpression expression.
 func (self *ConstraintExpression) IsSatisfiedBy(props []Property) error {
@@ -96,8 +112,25 @@ func (self *ConstraintExpression) IsSatisfiedBy(props []Property) error {
 		return nil
 	}
 
+	// Constraints in a language that evaluates its own expressions are checked directly, the rest are converted
+	// to a RequiredProperty.
+	propConstraints := Constraint_Factory()
+	for _, constraint := range *self {
+		if handler, err := getConstraintLanguageHandler(constraint); err != nil {
+			return fmt.Errorf("unable to obtain policy constraint language handler, error %v", err)
+		} else if evaluator, ok := handler.(plugin_registry.ConstraintEvaluatorPlugin); ok {
+			if satisfied, err := evaluator.Evaluate(constraint, propertiesAsMap(props)); err != nil {
+				return err
+			} else if !satisfied {
+				return errors.New(fmt.Sprintf("The required constraint '%v' is not satisfied by the available properties %v", constraint, displayProperties(&props)))
+			}
+		} else {
+			propConstraints.Add_Constraint(constraint)
+		}
+	}
+
 	// convert it to RequiredProperty and then check
-	if rp, err := RequiredPropertyFromConstraint(self); err != nil {
+	if rp, err := RequiredPropertyFromConstraint(propConstraints); err != nil {
 		return err
 	} else if rp != nil {
 		return rp.IsSatisfiedBy(props)
@@ -106,6 +139,24 @@ func (self *ConstraintExpression) IsSatisfiedBy(props []Property) error {
 	}
 }
 
+// Convert a list of properties into a map of property values keyed by property name, for constraint languages that
+// evaluate their own expressions. Lists of strings are split into their elements.
+func propertiesAsMap(props []Property) map[string]interface{} {
+	propMap := make(map[string]interface{}, len(props))
+	for _, p := range props {
+		if s, ok := p.Value.(string); ok && p.Type == LIST_TYPE {
+			list := make([]string, 0)
+			for _, elem := range strings.Split(s, ",") {
+				list = append(list, strings.TrimSpace(elem))
+			}
+			propMap[p.Name] = list
+		} else {
+			propMap[p.Name] = p.Value
+		}
+	}
+	return propMap
+}
+
 func (self *ConstraintExpression) GetStrings() []string {
 	return ([]string(*self))
 }
@@ -130,13 +181,12 @@ func RequiredPropertyFromConstraint(extConstraint *ConstraintExpression) (*Requi
 	}
 
 	for _, remainder := range *extConstraint {
-		remainder := strings.Replace(remainder, "\a", " ", -1)
-
 		// Get a handle to the specific language handler we will be using.
-		handler, err = extConstraint.GetLanguageHandler()
+		handler, err = getConstraintLanguageHandler(remainder)
 		if err != nil {
 			return nil, fmt.Errorf("unable to obtain policy constraint language handler, error %v", err)
 		}
+		remainder := strings.Replace(remainder, "\a", " ", -1)
 
 		// Create a new Required Property structure and initialize it with a top level OR followed by a top level AND. This will allow us
 		// to drop expressions into the structure as they come in through the GetNextExpression function.
@@ -282,13 +332,15 @@ func GetParseConstraintWithName(extConstraint *ConstraintExpression, constraintN
 	allPropArray := make([]interface{}, 0)
 
 	for _, remainder := range *extConstraint {
-		remainder := strings.Replace(remainder, "\a", " ", -1)
-
 		// Get a handle to the specific language handler we will be using.
-		handler, err = extConstraint.GetLanguageHandler()
+		handler, err = getConstraintLanguageHandler(remainder)
 		if err != nil {
 			return nil, fmt.Errorf("unable to obtain policy constraint language handler, error %v", err)
+		} else if _, ok := handler.(plugin_registry.ConstraintEvaluatorPlugin); ok {
+			// Constraints that are evaluated as a whole do not contain named property expressions.
+			continue
 		}
+		remainder := strings.Replace(remainder, "\a", " ", -1)
 
 		// Create a new Required Property structure and initialize it with a top level OR followed by a top level AND. This will allow us
 		// to drop expressions into the structure as they come in through the GetNextExpression function.
@@ -4,6 +4,7 @@
 package externalpolicy
 
 import (
+	_ "github.com/open-horizon/anax/externalpolicy/cel_language"
 	_ "github.com/open-horizon/anax/externalpolicy/text_language"
 	"testing"
 )
@@ -337,3 +338,125 @@ func Test_MergeWith(t *testing.T) {
 		t.Errorf("Error: constraints %v should have 4 elements but got %v", ce1, len(*ce1))
 	}
 }
+
+func Test_CEL_IsSatisfiedBy(t *testing.T) {
+	ce := new(ConstraintExpression)
+	(*ce) = append((*ce),
+		"cel: node.memory >= 2048 && node.location.startsWith(\"eu-\")",
+		"cel: 'Organic' in node.certification",
+		"purpose == network-testing")
+	prop_list := `[{"name":"memory", "value":4096},{"name":"location", "value":"eu-west"},{"name":"certification", "value":"USDA, Organic", "type":"list of strings"},{"name":"purpose", "value":"network-testing"}]`
+	props := create_property_list(prop_list, t)
+	if _, err := ce.Validate(); err != nil {
+		t.Errorf("Error: mixed constraint expression should be valid: %v", err)
+	} else if err := ce.IsSatisfiedBy(*props); err != nil {
+		t.Errorf("Error: constraint expression should be satisfied: %v", err)
+	}
+
+	prop_list = `[{"name":"memory", "value":1024},{"name":"location", "value":"eu-west"},{"name":"certification", "value":"USDA, Organic", "type":"list of strings"},{"name":"purpose", "value":"network-testing"}]`
+	props = create_property_list(prop_list, t)
+	if err := ce.IsSatisfiedBy(*props); err == nil {
+		t.Errorf("Error: constraint expression should not be satisfied by %v", props)
+	}
+
+	prop_list = `[{"name":"memory", "value":4096},{"name":"location", "value":"eu-west"},{"name":"certification", "value":"USDA, Organic", "type":"list of strings"},{"name":"purpose", "value":"production"}]`
+	props = create_property_list(prop_list, t)
+	if err := ce.IsSatisfiedBy(*props); err == nil {
+		t.Errorf("Error: constraint expression should not be satisfied by %v", props)
+	}
+}
+
+func Test_CEL_Validate_Mixed(t *testing.T) {
+	invalid := []ConstraintExpression{
+		ConstraintExpression{"cel: node.memory >= ", "purpose == network-testing"},
+		ConstraintExpression{"cel: node.memory >= 2048", "purpose == "},
+		ConstraintExpression{"purpose == network-testing", "cel: memory >= 2048"},
+	}
+	for _, ce := range invalid {
+		if _, err := ce.Validate(); err == nil {
+			t.Errorf("Error: constraint expression %v should not be valid", ce)
+		}
+	}
+
+	ce := ConstraintExpression{"purpose == network-testing", "cel: node.memory >= 2048", "location == eu-west"}
+	if validated, err := ce.Validate(); err != nil {
+		t.Errorf("Error: mixed constraint expression should be valid: %v", err)
+	} else if len(validated) != 3 {
+		t.Errorf("Error: expected 3 validated constraints, got %v", validated)
+	}
+}
+
+func Test_Explain(t *testing.T) {
+	ce := new(ConstraintExpression)
+	(*ce) = append((*ce),
+		"prop == true && (prop2 == value2 || prop3 == value3)",
+		"cel: node.memory >= 2048",
+		"prop4 >= 4")
+	prop_list := `[{"name":"prop", "value":true},{"name":"prop2", "value":"other"},{"name":"prop3", "value":"value3"},{"name":"memory", "value":1024}]`
+	props := create_property_list(prop_list, t)
+
+	trace, err := ce.Explain(*props)
+	if err != nil {
+		t.Fatalf("Error: unable to explain the constraint expression: %v", err)
+	} else if trace.Result {
+		t.Errorf("Error: the constraint expression should not be satisfied, trace: %v", trace)
+	} else if len(trace.Children) != 3 {
+		t.Fatalf("Error: there should be a trace for each of the 3 constraints, trace: %v", trace)
+	}
+
+	// prop == true && (prop2 == value2 || prop3 == value3)
+	first := trace.Children[0]
+	if !first.Result || first.Op != OP_AND || len(first.Children) != 2 {
+		t.Errorf("Error: the first constraint should be a satisfied and with 2 children, trace: %v", first)
+	} else if or := first.Children[1]; !or.Result || or.Op != OP_OR || len(or.Children) != 2 {
+		t.Errorf("Error: the second clause of the first constraint should be a satisfied or with 2 children, trace: %v", or)
+	} else if or.Children[0].Result || or.Children[0].Actual != "other" || or.Children[0].Property != "prop2" {
+		t.Errorf("Error: prop2 == value2 should fail with the actual value other, trace: %v", or.Children[0])
+	} else if !or.Children[1].Result {
+		t.Errorf("Error: prop3 == value3 should be satisfied, trace: %v", or.Children[1])
+	}
+
+	// cel: node.memory >= 2048
+	if second := trace.Children[1]; second.Result || second.Expression != "cel: node.memory >= 2048" {
+		t.Errorf("Error: the CEL constraint should not be satisfied, trace: %v", second)
+	}
+
+	// prop4 >= 4
+	if third := trace.Children[2]; third.Result || third.Actual != nil || third.Reason == "" {
+		t.Errorf("Error: prop4 >= 4 should fail because prop4 is not defined, trace: %v", third)
+	}
+
+	// The result of the trace agrees with IsSatisfiedBy.
+	(*props)[3].Value = float64(4096)
+	(*props) = append((*props), *Property_Factory("prop4", float64(5)))
+	if trace, err := ce.Explain(*props); err != nil {
+		t.Errorf("Error: unable to explain the constraint expression: %v", err)
+	} else if !trace.Result {
+		t.Errorf("Error: the constraint expression should be satisfied, trace: %v", trace)
+	} else if err := ce.IsSatisfiedBy(*props); err != nil {
+		t.Errorf("Error: the constraint expression should be satisfied: %v", err)
+	}
+}
+
+func Test_Version_And_Numeric_Range_IsSatisfiedBy(t *testing.T) {
+	prop_list := `[{"name":"firmware", "value":"1.5.0", "type":"version"},{"name":"agentVersion", "value":"2.31.0-1"},{"name":"mem", "value":2048}]`
+	props := create_property_list(prop_list, t)
+
+	for _, c := range []string{"firmware in [1.2, 2.0)", "firmware in [1.5.0-beta,INFINITY)", "firmware >= 1.2.0", "firmware < 1.10.0", "firmware == 1.5", "firmware != 1.4.9", "agentVersion > 2.30.0", "mem in [1024,4096)"} {
+		ce := ConstraintExpression{c}
+		if _, err := ce.Validate(); err != nil {
+			t.Errorf("Error: constraint %v should be valid: %v", c, err)
+		} else if err := ce.IsSatisfiedBy(*props); err != nil {
+			t.Errorf("Error: constraint %v should be satisfied: %v", c, err)
+		}
+	}
+
+	for _, c := range []string{"firmware in [1.5.1,2.0)", "firmware < 1.5.0", "firmware > 1.10.0", "firmware != 1.5", "agentVersion < 2.4.0", "mem in [4096,INFINITY)", "mem in (1024,2048)"} {
+		ce := ConstraintExpression{c}
+		if _, err := ce.Validate(); err != nil {
+			t.Errorf("Error: constraint %v should be valid: %v", c, err)
+		} else if err := ce.IsSatisfiedBy(*props); err == nil {
+			t.Errorf("Error: constraint %v should not be satisfied by %v", c, props)
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package externalpolicy
+
+import (
+	"fmt"
+	"github.com/open-horizon/anax/externalpolicy/plugin_registry"
+	"strings"
+)
+
+// The evaluation trace of a constraint expression against a list of properties. Each node in the tree is a
+// sub-expression of the constraints; control operators (and, or) have children, property expressions are leaves.
+// Every node has the result of evaluating it, so the clause that caused a constraint to fail can be found by
+// following the false results down the tree.
+type ConstraintTrace struct {
+	Expression string             `json:"expression"`         // the sub-expression, in the constraint language syntax
+	Op         string             `json:"op,omitempty"`       // the control operator (and, or) or the comparison operator of a property expression
+	Property   string             `json:"property,omitempty"` // the property name in a property expression
+	Value      interface{}        `json:"value,omitempty"`    // the value the property is compared with in a property expression
+	Actual     interface{}        `json:"actual,omitempty"`   // the value of the property found in the properties, omitted if the property is not defined
+	Result     bool               `json:"result"`
+	Reason     string             `json:"reason,omitempty"` // set when the expression could not be evaluated normally, e.g. the property is not defined
+	Children   []*ConstraintTrace `json:"children,omitempty"`
+}
+
+func (t ConstraintTrace) String() string {
+	return fmt.Sprintf("Expression: %v, Result: %v, Actual: %v, Reason: %v, Children: %v", t.Expression, t.Result, t.Actual, t.Reason, t.Children)
+}
+
+// Evaluate the constraint expression against the input properties and return the full evaluation trace. Unlike
+// IsSatisfiedBy, evaluation does not stop at the first failure, so that every sub-expression has a result. The root
+// of the trace ANDs together each constraint in the expression.
+func (self *ConstraintExpression) Explain(props []Property) (*ConstraintTrace, error) {
+	root := &ConstraintTrace{Expression: strings.Join(*self, " && "), Op: OP_AND, Result: true, Children: []*ConstraintTrace{}}
+
+	for _, constraint := range *self {
+		handler, err := getConstraintLanguageHandler(constraint)
+		if err != nil {
+			return nil, fmt.Errorf("unable to obtain policy constraint language handler, error %v", err)
+		}
+
+		var trace *ConstraintTrace
+		if evaluator, ok := handler.(plugin_registry.ConstraintEvaluatorPlugin); ok {
+			// The expression is evaluated as a whole, so it is a leaf in the trace.
+			trace = &ConstraintTrace{Expression: constraint}
+			if satisfied, err := evaluator.Evaluate(constraint, propertiesAsMap(props)); err != nil {
+				trace.Reason = err.Error()
+			} else {
+				trace.Result = satisfied
+			}
+		} else if rp, err := RequiredPropertyFromConstraint(&ConstraintExpression{constraint}); err != nil {
+			return nil, err
+		} else {
+			topMap := make(map[string]interface{})
+			for k := range *rp {
+				topMap[k] = (*rp)[k]
+			}
+			trace = rp.explain(&topMap, props)
+			trace.Expression = constraint
+		}
+
+		root.Children = append(root.Children, trace)
+		root.Result = root.Result && trace.Result
+	}
+
+	return root, nil
+}
+
+// Build the trace of a RequiredProperty control operator. Control operators with a single element are replaced by
+// the trace of that element, because they do not change the result.
+func (self *RequiredProperty) explain(cop *map[string]interface{}, props []Property) *ConstraintTrace {
+	controlOp := getControlOperator(cop)
+	propArray, _ := (*cop)[controlOp].([]interface{})
+
+	children := make([]*ConstraintTrace, 0, len(propArray))
+	for _, p := range propArray {
+		if prop := isPropertyExpression(p); prop != nil {
+			children = append(children, explainPropertyExpression(prop, props))
+		} else if subOp := isControlOp(p); subOp != nil {
+			children = append(children, self.explain(subOp, props))
+		} else {
+			children = append(children, &ConstraintTrace{Expression: fmt.Sprintf("%v", p), Reason: "neither a property expression nor a control operator"})
+		}
+	}
+
+	if len(children) == 1 {
+		return children[0]
+	}
+
+	trace := &ConstraintTrace{Op: controlOp, Result: controlOp == OP_AND, Children: children}
+	exprs := make([]string, 0, len(children))
+	for _, c := range children {
+		if controlOp == OP_OR {
+			trace.Result = trace.Result || c.Result
+		} else {
+			trace.Result = trace.Result && c.Result
+		}
+		if len(c.Children) != 0 {
+			exprs = append(exprs, fmt.Sprintf("(%v)", c.Expression))
+		} else {
+			exprs = append(exprs, c.Expression)
+		}
+	}
+
+	if controlOp == OP_OR {
+		trace.Expression = strings.Join(exprs, " || ")
+	} else {
+		trace.Expression = strings.Join(exprs, " && ")
+	}
+	return trace
+}
+
+// Build the trace of a single property expression, including the value of the property if there is one.
+func explainPropertyExpression(prop *PropertyExpression, props []Property) *ConstraintTrace {
+	trace := &ConstraintTrace{
+		Expression: fmt.Sprintf("%v %v %v", prop.Name, prop.Op, prop.Value),
+		Op:         prop.Op,
+		Property:   prop.Name,
+		Value:      prop.Value,
+		Result:     propertyInArray(prop, &props),
+	}
+
+	found := false
+	for _, p := range props {
+		if p.Name == prop.Name {
+			trace.Actual = p.Value
+			found = true
+			break
+		}
+	}
+	if !found {
+		trace.Reason = fmt.Sprintf("property %v is not defined", prop.Name)
+	}
+	return trace
+}
@@ -418,6 +418,9 @@ func propertyInArray(propexp *PropertyExpression, props *[]Property) bool {
 			continue
 		} else {
 			if isFloat64(p.Value) {
+				if propexp.Op == isin {
+					return numberInRange(p.Value.(float64), propexp.Value)
+				}
 				var propexpFloat float64
 				if isFloat64(propexp.Value) {
 					propexpFloat = propexp.Value.(float64)
@@ -462,7 +465,9 @@ func propertyInArray(propexp *PropertyExpression, props *[]Property) bool {
 			} else if isString(p.Value) && isString(propexp.Value) {
 				pValue := removeSpaces(removeQuotes(p.Value.(string)))
 				propexpValue := removeSpaces(removeQuotes(propexp.Value.(string)))
-				if _, ok := stringOperators()[propexp.Op]; !ok {
+				if isVersionComparison(&p, pValue, propexp.Op, propexpValue) {
+					return compareVersion(pValue, propexp.Op, propexpValue)
+				} else if _, ok := stringOperators()[propexp.Op]; !ok {
 					return false
 				} else if propexp.Op == notequalto {
 					if p.Type == LIST_TYPE {
@@ -500,6 +505,51 @@ func removeQuotes(value string) string {
 	return value
 }
 
+// Versions are compared semantically when the property is a version, or when the constraint orders the property
+// value against a full version (e.g. firmware >= 1.2.0). Other string properties keep plain string equality.
+func isVersionComparison(p *Property, pValue string, op string, propexpValue string) bool {
+	if op == isin || !semanticversion.IsVersionString(pValue) || !semanticversion.IsVersionString(propexpValue) || propexpValue == semanticversion.INF {
+		return false
+	} else if p.Type == VERSION_TYPE {
+		return true
+	} else if _, ok := stringOperators()[op]; ok {
+		return false
+	}
+	return strings.Count(strings.Split(propexpValue, "-")[0], ".") == 2
+}
+
+func compareVersion(pValue string, op string, propexpValue string) bool {
+	c, err := semanticversion.CompareVersions(pValue, propexpValue)
+	if err != nil {
+		return false
+	}
+	switch op {
+	case lessthan:
+		return c < 0
+	case greaterthan:
+		return c > 0
+	case lessthaneq:
+		return c <= 0
+	case greaterthaneq:
+		return c >= 0
+	case notequalto:
+		return c != 0
+	default:
+		return c == 0
+	}
+}
+
+// Return true if the number is within the numeric range, e.g. [1024,4096).
+func numberInRange(value float64, numRange interface{}) bool {
+	if !isString(numRange) {
+		return false
+	} else if nr, err := semanticversion.Numeric_Range_Factory(removeSpaces(numRange.(string))); err != nil {
+		return false
+	} else {
+		return nr.Contains(value)
+	}
+}
+
 func containsVersion(versRange string, version string) bool {
 	vers, err := semanticversion.Version_Expression_Factory(version)
 	if err != nil {
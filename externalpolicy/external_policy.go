@@ -83,6 +83,11 @@ func (e *ExternalPolicy) ValidateAndNormalize() error {
 		}
 	}
 
+	// make sure the maintenance windows and blackout periods can be parsed
+	if _, err := GetMaintenanceSchedule(e.Properties); err != nil {
+		return err
+	}
+
 	// Validate the Constraints expression by invoking the plugins.
 	if e != nil && len(e.Constraints) != 0 {
 		_, err := e.Constraints.Validate()
@@ -0,0 +1,535 @@
+package externalpolicy
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/open-horizon/anax/i18n"
+)
+
+// A node declares when its workloads can be deployed, restarted and upgraded with these properties in its node policy.
+// Each property is a string with one or more windows separated by ';'. A window is either a time range:
+//
+//	[days] HH:MM-HH:MM [timezone]
+//
+// where days is a comma separated list of day names or day ranges (e.g. Mon-Fri,Sun) and every day is used when it is
+// omitted, or a cron expression followed by the duration of the window:
+//
+//	minute hour day-of-month month day-of-week duration [timezone]
+//
+// where the window opens every time the cron expression matches. A time range that ends before it starts ends on the
+// next day. The timezone is an IANA timezone name, UTC is used when it is omitted.
+//
+// Outside of the maintenance windows, and inside any blackout period, the agbot does not propose new agreements to the
+// node and does not cancel agreements to upgrade their services, and the agent does not upgrade dependent services.
+const (
+	PROP_NODE_MAINTENANCE_WINDOWS = "openhorizon.maintenanceWindows"
+	PROP_NODE_BLACKOUT_PERIODS    = "openhorizon.blackoutPeriods"
+)
+
+// How far ahead to look for the next time that a node can be changed. Time ranges, and cron expressions that match on
+// every day of the month and in every month, repeat every week, so a node with only these windows that is not allowed to
+// be changed at all in this time never will be unless its policy changes.
+const MAINTENANCE_WINDOW_HORIZON = 8 * 24 * time.Hour
+
+// How far ahead to look when a cron expression restricts the day of the month or the month. Such an expression can match
+// as rarely as once in 8 years, on February 29.
+const MAINTENANCE_WINDOW_CALENDAR_HORIZON = (8*366 + 8) * 24 * time.Hour
+
+// The most windows and blackout periods that are stepped through to find the next time that a node can be changed.
+const maintenanceWindowMaxSteps = 100000
+
+// The longest a cron maintenance window can be.
+const MAINTENANCE_WINDOW_MAX_DURATION = 7 * 24 * time.Hour
+
+var dayNames = map[string]int{"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6}
+var monthNames = map[string]int{"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6, "jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12}
+
+// A maintenance window or a blackout period.
+type MaintenanceWindow struct {
+	Spec     string
+	location *time.Location
+
+	// time range windows
+	days  [7]bool
+	start int // minutes after midnight
+	end   int
+
+	// cron windows
+	cron     *cronSpec
+	duration time.Duration
+}
+
+func (w MaintenanceWindow) String() string {
+	return w.Spec
+}
+
+// A time when a window opens and when it closes.
+type windowInterval struct {
+	open  time.Time
+	close time.Time
+}
+
+// Parse one maintenance window or blackout period.
+func ParseMaintenanceWindow(spec string) (*MaintenanceWindow, error) {
+	msgPrinter := i18n.GetMessagePrinter()
+
+	fields := strings.Fields(spec)
+	w := &MaintenanceWindow{Spec: strings.Join(fields, " "), location: time.UTC}
+	if len(fields) == 0 {
+		return nil, errors.New(msgPrinter.Sprintf("the maintenance window is empty"))
+	}
+
+	// The time range form has a HH:MM-HH:MM field, the cron form has at least 6 fields.
+	rangeIx := -1
+	for ix, f := range fields {
+		if strings.Contains(f, ":") && strings.Contains(f, "-") {
+			rangeIx = ix
+			break
+		}
+	}
+
+	var tz []string
+	if rangeIx >= 0 {
+		if rangeIx > 1 || len(fields) > rangeIx+2 {
+			return nil, errors.New(msgPrinter.Sprintf("maintenance window %v must be [days] HH:MM-HH:MM [timezone]", spec))
+		}
+		if rangeIx == 1 {
+			if err := parseDays(fields[0], &w.days); err != nil {
+				return nil, errors.New(msgPrinter.Sprintf("maintenance window %v has invalid days: %v", spec, err))
+			}
+		} else {
+			for d := range w.days {
+				w.days[d] = true
+			}
+		}
+		times := strings.SplitN(fields[rangeIx], "-", 2)
+		var err error
+		if w.start, err = parseClock(times[0], false); err != nil {
+			return nil, errors.New(msgPrinter.Sprintf("maintenance window %v has an invalid start time: %v", spec, err))
+		} else if w.end, err = parseClock(times[1], true); err != nil {
+			return nil, errors.New(msgPrinter.Sprintf("maintenance window %v has an invalid end time: %v", spec, err))
+		} else if w.start == w.end {
+			return nil, errors.New(msgPrinter.Sprintf("maintenance window %v starts and ends at the same time", spec))
+		}
+		tz = fields[rangeIx+1:]
+	} else {
+		if len(fields) < 6 || len(fields) > 7 {
+			return nil, errors.New(msgPrinter.Sprintf("maintenance window %v must be [days] HH:MM-HH:MM [timezone] or a cron expression followed by a duration and an optional timezone", spec))
+		}
+		var err error
+		if w.cron, err = parseCron(fields[0:5]); err != nil {
+			return nil, errors.New(msgPrinter.Sprintf("maintenance window %v has an invalid cron expression: %v", spec, err))
+		} else if w.duration, err = time.ParseDuration(fields[5]); err != nil || w.duration < time.Minute || w.duration > MAINTENANCE_WINDOW_MAX_DURATION {
+			return nil, errors.New(msgPrinter.Sprintf("maintenance window %v has an invalid duration %v, it must be between 1m and 168h", spec, fields[5]))
+		}
+		tz = fields[6:]
+	}
+
+	if len(tz) != 0 {
+		if loc, err := time.LoadLocation(tz[0]); err != nil {
+			return nil, errors.New(msgPrinter.Sprintf("maintenance window %v has an invalid timezone: %v", spec, err))
+		} else {
+			w.location = loc
+		}
+	}
+	return w, nil
+}
+
+// Returns the times that the window is open which overlap the given time range.
+func (w MaintenanceWindow) intervals(from time.Time, to time.Time) []windowInterval {
+	intervals := make([]windowInterval, 0)
+
+	if w.cron != nil {
+		// Start from the earliest time that could open a window that is still open at the start of the range.
+		t, ok := w.cron.next(from.Add(-w.duration).In(w.location), to)
+		for ; ok; t, ok = w.cron.next(t.Add(time.Minute), to) {
+			if close := t.Add(w.duration); close.After(from) {
+				intervals = append(intervals, windowInterval{open: t, close: close})
+			}
+		}
+		return intervals
+	}
+
+	length := w.end - w.start
+	if length <= 0 {
+		length += 24 * 60
+	}
+
+	// A window that started on the day before the range can still be open.
+	local := from.In(w.location)
+	day := time.Date(local.Year(), local.Month(), local.Day()-1, 0, 0, 0, 0, w.location)
+	for ; day.Before(to); day = day.AddDate(0, 0, 1) {
+		if w.days[int(day.Weekday())] {
+			open := day.Add(time.Duration(w.start) * time.Minute)
+			close := open.Add(time.Duration(length) * time.Minute)
+			if close.After(from) && open.Before(to) {
+				intervals = append(intervals, windowInterval{open: open, close: close})
+			}
+		}
+	}
+	return intervals
+}
+
+// Returns true if the window is open at the given time.
+func (w MaintenanceWindow) IsOpen(t time.Time) bool {
+	_, open := w.closesAt(t)
+	return open
+}
+
+// Returns when the window closes, if it is open at the given time. When the window opened more than once and is still
+// open, the latest close is returned.
+func (w MaintenanceWindow) closesAt(t time.Time) (time.Time, bool) {
+	close, open := time.Time{}, false
+	for _, i := range w.intervals(t, t.Add(time.Minute)) {
+		if !t.Before(i.open) && t.Before(i.close) && i.close.After(close) {
+			close, open = i.close, true
+		}
+	}
+	return close, open
+}
+
+// Returns the next time after the given time, and before the limit, that the window opens.
+func (w MaintenanceWindow) nextOpen(t time.Time, limit time.Time) (time.Time, bool) {
+	if w.cron != nil {
+		return w.cron.next(t.Add(time.Minute).Truncate(time.Minute).In(w.location), limit)
+	}
+
+	// A time range opens at least once a week.
+	to := t.Add(MAINTENANCE_WINDOW_HORIZON)
+	if to.After(limit) {
+		to = limit
+	}
+	for _, i := range w.intervals(t, to) {
+		if i.open.After(t) && i.open.Before(limit) {
+			return i.open, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// Returns true if the window can go more than a week without opening, because it is a cron expression that restricts the
+// day of the month or the month.
+func (w MaintenanceWindow) isCalendar() bool {
+	if w.cron == nil {
+		return false
+	}
+	for d := 1; d <= 31; d++ {
+		if !w.cron.daysOfMonth[d] {
+			return true
+		}
+	}
+	for m := 1; m <= 12; m++ {
+		if !w.cron.months[m] {
+			return true
+		}
+	}
+	return false
+}
+
+// The maintenance windows and blackout periods of a node.
+type MaintenanceSchedule struct {
+	Windows   []MaintenanceWindow
+	Blackouts []MaintenanceWindow
+}
+
+func (s MaintenanceSchedule) String() string {
+	return fmt.Sprintf("Windows: %v, Blackouts: %v", s.Windows, s.Blackouts)
+}
+
+// Returns the maintenance windows and blackout periods declared in the given properties, or nil if there are none.
+func GetMaintenanceSchedule(props PropertyList) (*MaintenanceSchedule, error) {
+	schedule := new(MaintenanceSchedule)
+	var err error
+	if schedule.Windows, err = parseWindowProperty(props, PROP_NODE_MAINTENANCE_WINDOWS); err != nil {
+		return nil, err
+	} else if schedule.Blackouts, err = parseWindowProperty(props, PROP_NODE_BLACKOUT_PERIODS); err != nil {
+		return nil, err
+	} else if len(schedule.Windows) == 0 && len(schedule.Blackouts) == 0 {
+		return nil, nil
+	}
+	return schedule, nil
+}
+
+func parseWindowProperty(props PropertyList, name string) ([]MaintenanceWindow, error) {
+	windows := make([]MaintenanceWindow, 0)
+	if !props.HasProperty(name) {
+		return windows, nil
+	}
+
+	msgPrinter := i18n.GetMessagePrinter()
+	prop, err := props.GetProperty(name)
+	if err != nil {
+		return nil, err
+	}
+	value, ok := prop.Value.(string)
+	if !ok {
+		return nil, errors.New(msgPrinter.Sprintf("Property %s must be a string of windows separated by ';'.", name))
+	}
+
+	for _, spec := range strings.Split(value, ";") {
+		if strings.TrimSpace(spec) == "" {
+			continue
+		}
+		if w, err := ParseMaintenanceWindow(spec); err != nil {
+			return nil, errors.New(msgPrinter.Sprintf("Property %s is not valid: %v", name, err))
+		} else {
+			windows = append(windows, *w)
+		}
+	}
+	return windows, nil
+}
+
+// Returns true if the node can be changed at the given time, which is when it is in one of its maintenance windows, or
+// it does not declare any, and it is not in a blackout period.
+func (s *MaintenanceSchedule) Allows(t time.Time) bool {
+	if s == nil {
+		return true
+	}
+	for _, b := range s.Blackouts {
+		if b.IsOpen(t) {
+			return false
+		}
+	}
+	if len(s.Windows) == 0 {
+		return true
+	}
+	for _, w := range s.Windows {
+		if w.IsOpen(t) {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns the next time, at or after the given time, that the node can be changed. The second return value is false if
+// there is no such time. The schedule is searched for as long as it takes to repeat, a week, or 8 years when a cron
+// expression restricts the day of the month or the month.
+func (s *MaintenanceSchedule) NextAllowed(t time.Time) (time.Time, bool) {
+	if s.Allows(t) {
+		return t, true
+	}
+
+	horizon := MAINTENANCE_WINDOW_HORIZON
+	for _, w := range append(append([]MaintenanceWindow{}, s.Windows...), s.Blackouts...) {
+		if w.isCalendar() {
+			horizon = MAINTENANCE_WINDOW_CALENDAR_HORIZON
+		}
+	}
+	limit := t.Add(horizon)
+
+	// The node can only become changeable when a maintenance window opens or a blackout period closes. Outside of the
+	// windows, skip to the next window that opens, and inside of the blackout periods, skip to when they all close.
+	for c, steps := t, 0; c.Before(limit) && steps < maintenanceWindowMaxSteps; steps++ {
+		if next, ok := s.nextWindow(c, limit); !ok {
+			return time.Time{}, false
+		} else if next.After(c) {
+			c = next
+		} else if until, blocked := s.blackoutUntil(c); blocked {
+			c = until
+		} else {
+			return c, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// Returns the given time if a maintenance window is open at that time or there are none, otherwise the next time before
+// the limit that a window opens.
+func (s *MaintenanceSchedule) nextWindow(t time.Time, limit time.Time) (time.Time, bool) {
+	if len(s.Windows) == 0 {
+		return t, true
+	}
+	next, found := limit, false
+	for _, w := range s.Windows {
+		if w.IsOpen(t) {
+			return t, true
+		} else if open, ok := w.nextOpen(t, limit); ok && open.Before(next) {
+			next, found = open, true
+		}
+	}
+	return next, found
+}
+
+// Returns when all the blackout periods that are in effect at the given time end, if there are any.
+func (s *MaintenanceSchedule) blackoutUntil(t time.Time) (time.Time, bool) {
+	until, blocked := t, false
+	for _, b := range s.Blackouts {
+		if close, open := b.closesAt(t); open && close.After(until) {
+			until, blocked = close, true
+		}
+	}
+	return until, blocked
+}
+
+// Parse HH:MM. 24:00 is allowed as the end of a day.
+func parseClock(s string, end bool) (int, error) {
+	parts := strings.Split(s, ":")
+	if len(parts) != 2 {
+		return 0, fmt.Errorf("%v is not HH:MM", s)
+	}
+	h, err1 := strconv.Atoi(parts[0])
+	m, err2 := strconv.Atoi(parts[1])
+	if err1 != nil || err2 != nil || h < 0 || m < 0 || m > 59 || h > 24 || (h == 24 && (m != 0 || !end)) {
+		return 0, fmt.Errorf("%v is not HH:MM", s)
+	}
+	return h*60 + m, nil
+}
+
+// Parse a comma separated list of day names and day ranges.
+func parseDays(s string, days *[7]bool) error {
+	for _, item := range strings.Split(strings.ToLower(s), ",") {
+		bounds := strings.SplitN(item, "-", 2)
+		first, ok := dayNames[bounds[0]]
+		if !ok {
+			return fmt.Errorf("%v is not a day", bounds[0])
+		}
+		last := first
+		if len(bounds) == 2 {
+			if last, ok = dayNames[bounds[1]]; !ok {
+				return fmt.Errorf("%v is not a day", bounds[1])
+			}
+		}
+		for d := first; ; d = (d + 1) % 7 {
+			days[d] = true
+			if d == last {
+				break
+			}
+		}
+	}
+	return nil
+}
+
+// A 5 field cron expression. When both the day of month and the day of week are restricted, a time matches if either
+// of them does, the same as cron.
+type cronSpec struct {
+	minutes     [60]bool
+	hours       [24]bool
+	daysOfMonth [32]bool
+	months      [13]bool
+	daysOfWeek  [7]bool
+	anyDOM      bool
+	anyDOW      bool
+}
+
+func parseCron(fields []string) (*cronSpec, error) {
+	c := new(cronSpec)
+	var err error
+	if err = parseCronField(fields[0], 0, 59, nil, c.minutes[:]); err != nil {
+		return nil, err
+	} else if err = parseCronField(fields[1], 0, 23, nil, c.hours[:]); err != nil {
+		return nil, err
+	} else if err = parseCronField(fields[2], 1, 31, nil, c.daysOfMonth[:]); err != nil {
+		return nil, err
+	} else if err = parseCronField(fields[3], 1, 12, monthNames, c.months[:]); err != nil {
+		return nil, err
+	}
+
+	// Sunday can be 0 or 7.
+	dow := make([]bool, 8)
+	if err = parseCronField(fields[4], 0, 7, dayNames, dow); err != nil {
+		return nil, err
+	}
+	copy(c.daysOfWeek[:], dow[0:7])
+	c.daysOfWeek[0] = c.daysOfWeek[0] || dow[7]
+
+	c.anyDOM = strings.HasPrefix(fields[2], "*")
+	c.anyDOW = strings.HasPrefix(fields[4], "*")
+	return c, nil
+}
+
+// Parse one cron field, a comma separated list of *, values or ranges, each with an optional /step.
+func parseCronField(field string, min int, max int, names map[string]int, set []bool) error {
+	value := func(s string) (int, error) {
+		if v, ok := names[strings.ToLower(s)]; ok {
+			return v, nil
+		} else if v, err := strconv.Atoi(s); err != nil || v < min || v > max {
+			return 0, fmt.Errorf("%v must be between %v and %v", s, min, max)
+		} else {
+			return v, nil
+		}
+	}
+
+	for _, item := range strings.Split(field, ",") {
+		step := 1
+		if parts := strings.SplitN(item, "/", 2); len(parts) == 2 {
+			var err error
+			if step, err = strconv.Atoi(parts[1]); err != nil || step <= 0 {
+				return fmt.Errorf("%v has an invalid step", item)
+			}
+			item = parts[0]
+		}
+
+		first, last := min, max
+		if item != "*" {
+			bounds := strings.SplitN(item, "-", 2)
+			var err error
+			if first, err = value(bounds[0]); err != nil {
+				return err
+			}
+			last = first
+			if len(bounds) == 2 {
+				if last, err = value(bounds[1]); err != nil {
+					return err
+				} else if last < first {
+					return fmt.Errorf("%v is not a valid range", item)
+				}
+			} else if step != 1 {
+				last = max
+			}
+		}
+		for v := first; v <= last; v += step {
+			set[v] = true
+		}
+	}
+	return nil
+}
+
+func (c *cronSpec) matches(t time.Time) bool {
+	return c.minutes[t.Minute()] && c.hours[t.Hour()] && c.matchesDay(t)
+}
+
+func (c *cronSpec) matchesDay(t time.Time) bool {
+	if !c.months[int(t.Month())] {
+		return false
+	}
+	dom, dow := c.daysOfMonth[t.Day()], c.daysOfWeek[int(t.Weekday())]
+	if c.anyDOM || c.anyDOW {
+		return dom && dow
+	}
+	return dom || dow
+}
+
+// Returns the first time at or after the given time, in its location and rounded up to the minute, that the expression
+// matches. The second return value is false if it does not match before the limit. The days that do not match are
+// skipped, so that an expression that matches rarely is found quickly.
+func (c *cronSpec) next(t time.Time, limit time.Time) (time.Time, bool) {
+	if r := t.Truncate(time.Minute); r.Before(t) {
+		t = r.Add(time.Minute)
+	}
+	for day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location()); day.Before(limit); day = day.AddDate(0, 0, 1) {
+		if !c.matchesDay(day) {
+			continue
+		}
+		for h := 0; h < 24; h++ {
+			if !c.hours[h] {
+				continue
+			}
+			for m := 0; m < 60; m++ {
+				if !c.minutes[m] {
+					continue
+				}
+				at := time.Date(day.Year(), day.Month(), day.Day(), h, m, 0, 0, t.Location())
+				if !at.Before(limit) {
+					return time.Time{}, false
+				} else if !at.Before(t) && c.matches(at) {
+					return at, true
+				}
+			}
+		}
+	}
+	return time.Time{}, false
+}
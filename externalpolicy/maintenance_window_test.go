@@ -0,0 +1,194 @@
+//go:build unit
+// +build unit
+
+package externalpolicy
+
+import (
+	"testing"
+	"time"
+)
+
+func Test_ParseMaintenanceWindow(t *testing.T) {
+	valid := []string{
+		"02:00-04:00",
+		"Mon-Fri 22:00-06:00",
+		"sat,sun 00:00-24:00 America/New_York",
+		"Fri-Mon 01:30-02:30 UTC",
+		"0 2 * * * 2h",
+		"*/30 1-3 * * mon-fri 15m Europe/Berlin",
+		"0 0 1 jan,jul * 24h",
+	}
+	for _, spec := range valid {
+		if _, err := ParseMaintenanceWindow(spec); err != nil {
+			t.Errorf("window %v should be valid, error: %v", spec, err)
+		}
+	}
+
+	invalid := []string{
+		"",
+		"02:00-02:00",
+		"25:00-04:00",
+		"24:00-04:00",
+		"02:60-04:00",
+		"Mon-Xyz 02:00-04:00",
+		"Mon 02:00-04:00 UTC extra",
+		"02:00-04:00 Not/AZone",
+		"0 2 * * *",
+		"0 2 * * * 0s",
+		"0 2 * * * 200h",
+		"60 2 * * * 1h",
+		"0 2 * * 8 1h",
+		"0 2 5-1 * * 1h",
+	}
+	for _, spec := range invalid {
+		if _, err := ParseMaintenanceWindow(spec); err == nil {
+			t.Errorf("window %v should not be valid", spec)
+		}
+	}
+}
+
+func Test_MaintenanceWindow_IsOpen(t *testing.T) {
+	// 2024-01-05 is a Friday.
+	at := func(day int, hour int, min int) time.Time {
+		return time.Date(2024, time.January, day, hour, min, 0, 0, time.UTC)
+	}
+
+	w, _ := ParseMaintenanceWindow("Mon-Fri 22:00-06:00")
+	if !w.IsOpen(at(5, 23, 0)) {
+		t.Errorf("window %v should be open on Friday night", w)
+	} else if !w.IsOpen(at(6, 5, 59)) {
+		t.Errorf("window %v should still be open early Saturday", w)
+	} else if w.IsOpen(at(6, 6, 0)) {
+		t.Errorf("window %v should be closed at 06:00 on Saturday", w)
+	} else if w.IsOpen(at(6, 23, 0)) {
+		t.Errorf("window %v should be closed on Saturday night", w)
+	} else if w.IsOpen(at(5, 12, 0)) {
+		t.Errorf("window %v should be closed at noon", w)
+	}
+
+	w, _ = ParseMaintenanceWindow("Sat 00:00-24:00 America/New_York")
+	if w.IsOpen(at(6, 3, 0)) {
+		t.Errorf("window %v should be closed, it is still Friday in New York", w)
+	} else if !w.IsOpen(at(7, 3, 0)) {
+		t.Errorf("window %v should be open, it is still Saturday in New York", w)
+	}
+
+	w, _ = ParseMaintenanceWindow("30 23 * * fri 90m")
+	if !w.IsOpen(at(5, 23, 30)) {
+		t.Errorf("window %v should be open when it starts", w)
+	} else if !w.IsOpen(at(6, 0, 59)) {
+		t.Errorf("window %v should be open past midnight", w)
+	} else if w.IsOpen(at(6, 1, 0)) {
+		t.Errorf("window %v should be closed after 90 minutes", w)
+	} else if w.IsOpen(at(4, 23, 45)) {
+		t.Errorf("window %v should be closed on Thursday", w)
+	}
+
+	// The day of month or the day of week can match when both are restricted.
+	w, _ = ParseMaintenanceWindow("0 12 1 * mon 1h")
+	if !w.IsOpen(at(1, 12, 30)) {
+		t.Errorf("window %v should be open on the first", w)
+	} else if !w.IsOpen(at(8, 12, 30)) {
+		t.Errorf("window %v should be open on a Monday", w)
+	} else if w.IsOpen(at(9, 12, 30)) {
+		t.Errorf("window %v should be closed on a Tuesday", w)
+	}
+}
+
+func Test_MaintenanceSchedule(t *testing.T) {
+	at := func(day int, hour int, min int) time.Time {
+		return time.Date(2024, time.January, day, hour, min, 0, 0, time.UTC)
+	}
+
+	props := PropertyList{}
+	if s, err := GetMaintenanceSchedule(props); err != nil || s != nil {
+		t.Errorf("there should be no schedule, returned %v, error: %v", s, err)
+	} else if !s.Allows(at(5, 12, 0)) {
+		t.Errorf("a node without a schedule can always be changed")
+	}
+
+	props.Add_Property(Property_Factory(PROP_NODE_MAINTENANCE_WINDOWS, float64(5)), false)
+	if _, err := GetMaintenanceSchedule(props); err == nil {
+		t.Errorf("a number should not be a valid maintenance window")
+	}
+
+	props = PropertyList{}
+	props.Add_Property(Property_Factory(PROP_NODE_MAINTENANCE_WINDOWS, "Mon-Fri 22:00-06:00; Sat,Sun 00:00-24:00"), false)
+	props.Add_Property(Property_Factory(PROP_NODE_BLACKOUT_PERIODS, "Fri 23:00-24:00"), false)
+	s, err := GetMaintenanceSchedule(props)
+	if err != nil || s == nil {
+		t.Fatalf("the schedule should be valid, error: %v", err)
+	} else if len(s.Windows) != 2 || len(s.Blackouts) != 1 {
+		t.Fatalf("the schedule should have 2 windows and 1 blackout, it is %v", s)
+	}
+
+	if !s.Allows(at(5, 22, 30)) {
+		t.Errorf("the node should be changeable before the blackout")
+	} else if s.Allows(at(5, 23, 30)) {
+		t.Errorf("the node should not be changeable in the blackout")
+	} else if !s.Allows(at(6, 12, 0)) {
+		t.Errorf("the node should be changeable on Saturday")
+	} else if s.Allows(at(8, 12, 0)) {
+		t.Errorf("the node should not be changeable on Monday at noon")
+	}
+
+	if next, ok := s.NextAllowed(at(5, 23, 30)); !ok || !next.Equal(at(6, 0, 0)) {
+		t.Errorf("the node should be changeable at the end of the blackout, returned %v", next)
+	} else if next, ok := s.NextAllowed(at(8, 12, 0)); !ok || !next.Equal(at(8, 22, 0)) {
+		t.Errorf("the node should be changeable when the Monday window opens, returned %v", next)
+	} else if next, ok := s.NextAllowed(at(8, 23, 0)); !ok || !next.Equal(at(8, 23, 0)) {
+		t.Errorf("the node should be changeable now, returned %v", next)
+	}
+
+	// A blackout that covers every window means the node can never be changed.
+	props = PropertyList{}
+	props.Add_Property(Property_Factory(PROP_NODE_MAINTENANCE_WINDOWS, "Sat 02:00-04:00"), false)
+	props.Add_Property(Property_Factory(PROP_NODE_BLACKOUT_PERIODS, "00:00-24:00"), false)
+	if s, err = GetMaintenanceSchedule(props); err != nil {
+		t.Fatalf("the schedule should be valid, error: %v", err)
+	} else if _, ok := s.NextAllowed(at(5, 12, 0)); ok {
+		t.Errorf("the node should never be changeable")
+	}
+
+	// Windows that restrict the day of the month or the month can open more than a week later.
+	props = PropertyList{}
+	props.Add_Property(Property_Factory(PROP_NODE_MAINTENANCE_WINDOWS, "0 2 1 * * 2h"), false)
+	props.Add_Property(Property_Factory(PROP_NODE_BLACKOUT_PERIODS, "* * * * * 1m"), false)
+	if s, err = GetMaintenanceSchedule(props); err != nil {
+		t.Fatalf("the schedule should be valid, error: %v", err)
+	} else if _, ok := s.NextAllowed(at(5, 12, 0)); ok {
+		t.Errorf("the node should never be changeable, every minute is blacked out")
+	}
+
+	props = PropertyList{}
+	props.Add_Property(Property_Factory(PROP_NODE_MAINTENANCE_WINDOWS, "0 2 1 * * 2h; 0 0 29 feb * 24h"), false)
+	props.Add_Property(Property_Factory(PROP_NODE_BLACKOUT_PERIODS, "0 0 1 2 * 24h"), false)
+	if s, err = GetMaintenanceSchedule(props); err != nil {
+		t.Fatalf("the schedule should be valid, error: %v", err)
+	} else if next, ok := s.NextAllowed(at(5, 12, 0)); !ok || !next.Equal(time.Date(2024, time.January, 29, 0, 0, 0, 0, time.UTC).AddDate(0, 1, 0)) {
+		t.Errorf("the node should be changeable on February 29, the window on February 1 is blacked out, returned %v", next)
+	} else if next, ok := s.NextAllowed(time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC)); !ok || !next.Equal(time.Date(2024, time.April, 1, 2, 0, 0, 0, time.UTC)) {
+		t.Errorf("the node should be changeable on April 1, returned %v", next)
+	}
+
+	props = PropertyList{}
+	props.Add_Property(Property_Factory(PROP_NODE_MAINTENANCE_WINDOWS, "0 0 29 feb * 24h"), false)
+	if s, err = GetMaintenanceSchedule(props); err != nil {
+		t.Fatalf("the schedule should be valid, error: %v", err)
+	} else if next, ok := s.NextAllowed(time.Date(2096, time.March, 1, 0, 0, 0, 0, time.UTC)); !ok || !next.Equal(time.Date(2104, time.February, 29, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("the node should be changeable on the next February 29, returned %v", next)
+	}
+}
+
+func Test_ValidateAndNormalize_MaintenanceWindows(t *testing.T) {
+	pol := &ExternalPolicy{}
+	pol.Properties.Add_Property(Property_Factory(PROP_NODE_MAINTENANCE_WINDOWS, "Mon 02:00-04:00"), false)
+	if err := pol.ValidateAndNormalize(); err != nil {
+		t.Errorf("the policy should be valid, error: %v", err)
+	}
+
+	pol.Properties.Add_Property(Property_Factory(PROP_NODE_BLACKOUT_PERIODS, "someday"), false)
+	if err := pol.ValidateAndNormalize(); err == nil {
+		t.Errorf("the policy should not be valid")
+	}
+}
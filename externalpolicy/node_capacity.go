@@ -0,0 +1,60 @@
+package externalpolicy
+
+import (
+	"fmt"
+)
+
+// The CPU, memory and disk of a node. It is used both for the capacity that a node reports in its built-in properties and
+// for the resources that the containers of a service reserve on the node. A zero value means unknown or not reserved.
+type NodeResources struct {
+	CPUs     float64 `json:"cpus,omitempty"`
+	MemoryMb int64   `json:"memory_mb,omitempty"`
+	DiskMb   int64   `json:"disk_mb,omitempty"`
+}
+
+func (r NodeResources) String() string {
+	return fmt.Sprintf("CPUs: %v, MemoryMb: %v, DiskMb: %v", r.CPUs, r.MemoryMb, r.DiskMb)
+}
+
+func (r *NodeResources) IsZero() bool {
+	return r == nil || (r.CPUs == 0 && r.MemoryMb == 0 && r.DiskMb == 0)
+}
+
+// Add the given resources to this one.
+func (r *NodeResources) Add(other *NodeResources) {
+	if other != nil {
+		r.CPUs += other.CPUs
+		r.MemoryMb += other.MemoryMb
+		r.DiskMb += other.DiskMb
+	}
+}
+
+// Returns the capacity of the node from its built-in properties, nil if the node does not report any.
+func GetNodeCapacity(props PropertyList) *NodeResources {
+	capacity := NodeResources{
+		CPUs:     numericProperty(props, PROP_NODE_CPU),
+		MemoryMb: int64(numericProperty(props, PROP_NODE_MEMORY)),
+		DiskMb:   int64(numericProperty(props, PROP_NODE_DISK)),
+	}
+	if capacity.IsZero() {
+		return nil
+	}
+	return &capacity
+}
+
+// Returns the value of a numeric property, 0 if the property is not set or is not a number.
+func numericProperty(props PropertyList, name string) float64 {
+	if prop, err := props.GetProperty(name); err != nil {
+		return 0
+	} else {
+		switch v := prop.Value.(type) {
+		case float64:
+			return v
+		case int:
+			return float64(v)
+		case int64:
+			return float64(v)
+		}
+	}
+	return 0
+}
@@ -3,6 +3,7 @@ package plugin_registry
 import (
 	"errors"
 	"fmt"
+	"sort"
 )
 
 // Each constraint language plugin implements this interface.
@@ -12,6 +13,14 @@ type ConstraintLanguagePlugin interface {
 	GetNextOperator(expression string) (string, string, error)
 }
 
+// Constraint language plugins whose expressions cannot be broken down into property expressions (see
+// GetNextExpression) also implement this interface, so that their constraints can be evaluated directly
+// against the counterparty's properties, keyed by property name.
+type ConstraintEvaluatorPlugin interface {
+	ConstraintLanguagePlugin
+	Evaluate(constraint string, props map[string]interface{}) (bool, error)
+}
+
 // Global constraint language registry.
 type ConstraintLanguageRegistry map[string]ConstraintLanguagePlugin
 
@@ -25,9 +34,11 @@ func Register(name string, p ConstraintLanguagePlugin) {
 // Ask each plugin to attempt to validate the input constraint language. Plugins are called
 // until one of them claims ownership of the constraint language field. If no error is
 // returned, then one of the plugins has validated the constraint expression.
+// Plugins are called in the order of their names, so that the result does not depend on map iteration order.
 func (d ConstraintLanguageRegistry) ValidatedByOne(constraints interface{}) ([]string, error) {
 	errs := ""
-	for _, p := range d {
+	for _, name := range d.names() {
+		p := d[name]
 		if owned, constraints, err := p.Validate(constraints); owned {
 			return constraints, err
 		} else if err != nil {
@@ -42,7 +53,8 @@ func (d ConstraintLanguageRegistry) ValidatedByOne(constraints interface{}) ([]s
 // until one of them claims ownership of the constraint expression. If no error is
 // returned, then one of the plugins has claimed ownership.
 func (d ConstraintLanguageRegistry) GetLanguageHandlerByOne(constraints interface{}) (ConstraintLanguagePlugin, error) {
-	for _, p := range d {
+	for _, name := range d.names() {
+		p := d[name]
 		if owned, _, err := p.Validate(constraints); owned {
 			return p, err
 		}
@@ -51,6 +63,16 @@ func (d ConstraintLanguageRegistry) GetLanguageHandlerByOne(constraints interfac
 	return nil, errors.New(fmt.Sprintf("constraint language %v is not supported", constraints))
 }
 
+// Returns the names of the registered plugins in sorted order.
+func (d ConstraintLanguageRegistry) names() []string {
+	names := make([]string, 0, len(d))
+	for name := range d {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
 // Utility methods that can be used by other parts of the system to ask the global registry about plugins.
 func (d ConstraintLanguageRegistry) HasPlugin(name string) bool {
 	if _, ok := d[name]; ok {
@@ -0,0 +1,44 @@
+//go:build unit
+// +build unit
+
+package plugin_registry
+
+import (
+	"testing"
+)
+
+// A plugin that claims every constraint expression.
+type greedyPlugin struct {
+	name string
+}
+
+func (p *greedyPlugin) Validate(constraints interface{}) (bool, []string, error) {
+	return true, []string{p.name}, nil
+}
+
+func (p *greedyPlugin) GetNextExpression(expression string) (string, string, error) {
+	return "", expression, nil
+}
+
+func (p *greedyPlugin) GetNextOperator(expression string) (string, string, error) {
+	return "", expression, nil
+}
+
+func Test_ValidatedByOne_Deterministic(t *testing.T) {
+	registry := ConstraintLanguageRegistry{}
+	for _, name := range []string{"zeta", "beta", "alpha", "gamma"} {
+		registry[name] = &greedyPlugin{name: name}
+	}
+
+	for i := 0; i < 20; i++ {
+		if validated, err := registry.ValidatedByOne([]string{"a == b"}); err != nil {
+			t.Errorf("Error: validation failed: %v", err)
+		} else if validated[0] != "alpha" {
+			t.Errorf("Error: plugin %v validated the constraint, expected alpha", validated[0])
+		} else if p, err := registry.GetLanguageHandlerByOne([]string{"a == b"}); err != nil {
+			t.Errorf("Error: unable to get the language handler: %v", err)
+		} else if p.(*greedyPlugin).name != "alpha" {
+			t.Errorf("Error: plugin %v claimed the constraint, expected alpha", p.(*greedyPlugin).name)
+		}
+	}
+}
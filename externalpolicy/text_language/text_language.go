@@ -145,7 +145,12 @@ func (p *TextConstraintLanguagePlugin) GetNextExpression(expression string) (str
 		if err = validOpValuePair(name, op, opType, val, valType, def); err != nil {
 			return "", expression, err
 		}
-		return fmt.Sprintf("%v\a%v\a%v", name, strings.TrimSpace(op), strings.TrimSpace(val)), strings.Replace(expression, fmt.Sprintf(("%v%v%v"), name, op, val), "", 1), nil
+		// Whitespace is allowed inside a range literal, but not in the range value that is evaluated.
+		rangeVal := strings.TrimSpace(val)
+		if valType == def["VersRange"] {
+			rangeVal = strings.Join(strings.Fields(rangeVal), "")
+		}
+		return fmt.Sprintf("%v\a%v\a%v", name, strings.TrimSpace(op), rangeVal), strings.Replace(expression, fmt.Sprintf(("%v%v%v"), name, op, val), "", 1), nil
 	}
 	if nextRune == def["OpenParen"] || nextRune == def["CloseParen"] {
 		return "", expression, nil
@@ -193,6 +198,8 @@ func (p *TextConstraintLanguagePlugin) GetNextOperator(expression string) (strin
 // 4. for string types, a quoted string, inside which is a list of comma separated strings provide acceptable values
 // 5. string values that contain spaces must be quoted
 // 6. for the version type, supported values are a single version or a range of versions in the semantic version format (the same as used for service verions). The == operator implies that the value is a single version. The 'in' operator treats the value as a version range. As with service versions, the version 1.0.0 when treated as a version range is equivalent to the explicit range [1.0.0,INFINITY).
+// 7. for the version type, the numerical comparison operators <, >, <=, >= compare a single version semantically, e.g. firmware >= 1.2.0
+// 8. for numeric types, the 'in' operator accepts a range in the same syntax as a version range, e.g. memory in [1024,4096)
 
 // This function checks that the operator is valid for the specified value and validates version ranges with the semanticversion Factory function
 // Returns a property expression struct with numerical values as float64
@@ -209,19 +216,22 @@ func validOpValuePair(name string, op string, opType rune, val interface{}, valT
 		}
 	}
 	if lexMap["OpComp"] == opType {
-		if _, err := strconv.ParseFloat(val.(string), 64); err != nil {
+		if _, err := strconv.ParseFloat(val.(string), 64); err != nil && !(lexMap["Vers"] == valType && semanticversion.IsVersionString(strings.TrimSpace(val.(string)))) {
 			return fmt.Errorf("Cannot use numerical comparison operator %s with value %v.", op, val)
 		}
 	}
 	if lexMap["OpIn"] == opType {
 		if lexMap["ListStr"] != valType && lexMap["QuoteStr"] != valType && lexMap["VersRange"] != valType && lexMap["Vers"] != valType {
-			return fmt.Errorf("The 'in' operator can only be used for types version and list of strings")
+			return fmt.Errorf("The 'in' operator can only be used for types version and list of strings, and for numeric ranges")
 		}
 		if lexMap["VersRange"] == valType {
-			// Using the factory function to validate version ranges
-			_, err = semanticversion.Version_Expression_Factory(val.(string))
+			// Using the factory function to validate version ranges, a range that is not a version range must be a numeric range
+			rangeVal := strings.Join(strings.Fields(val.(string)), "")
+			_, err = semanticversion.Version_Expression_Factory(rangeVal)
 			if err != nil {
-				return err
+				if _, nerr := semanticversion.Numeric_Range_Factory(rangeVal); nerr != nil {
+					return fmt.Errorf("The value %v is neither a version range nor a numeric range. Version range error: %v, numeric range error: %v", val, err, nerr)
+				}
 			}
 		}
 	}
@@ -254,7 +264,8 @@ func getLexer() lexer.Definition {
 		OpIn =  {whitespace} "in" {whitespace} .
 	  OpEq =  {whitespace}  ( "!=" | "="["="] )  {whitespace} .
 
-	  VersRange = {whitespace}  ( "(" | "[" )  vers {whitespace}  "," {whitespace}  (vers | "INFINITY")  ("]" | ")").
+	  VersRange = {whitespace}  ( "(" | "[" ) {whitespace} bound {whitespace}  "," {whitespace}  (bound | "INFINITY") {whitespace} ("]" | ")").
+	  bound = ["-"] digit {digit} {"." digit {digit}} ["-" alphanumeric {alphanumeric | "." | "-"}] .
 		Vers = {whitespace}  vers .
 	  Num = {whitespace} ["-"] digit {digit} ["." {digit}] .
 	  whitespace = "\n" | "\r" | "\t" | " " .
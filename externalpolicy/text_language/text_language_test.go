@@ -4,6 +4,7 @@
 package text_language
 
 import (
+	"strings"
 	"testing"
 )
 
@@ -367,3 +368,37 @@ func Test_GetNextExpression_Succeed(t *testing.T) {
 	}
 
 }
+
+func Test_Validate_Version_And_Numeric_Ranges(t *testing.T) {
+	textConstraintLanguagePlugin := NewTextConstraintLanguagePlugin()
+
+	// version ranges with whitespace, short versions and pre-release bounds, version comparisons and numeric ranges.
+	ce := []string{"firmware in [1.2.0, 2.0.0)", "firmware in [ 1.2,2.0 )", "firmware in [1.2.0-beta.1,INFINITY)", "firmware >= 1.2.0 && firmware < 2.0.0", "mem in [1024,4096)", "temp in (-10.5, 40]"}
+	if validated, _, err := textConstraintLanguagePlugin.Validate(interface{}(ce)); validated == false {
+		t.Errorf("Validation failed but should not, err: %v", err)
+	} else if err != nil {
+		t.Errorf("Validation succeeded but also returned an error: %v", err)
+	}
+
+	for _, c := range []string{"firmware in [1.2.0,2.0.0", "firmware == [1.2.0,2.0.0)", "mem < [1024,4096)"} {
+		if _, _, err := textConstraintLanguagePlugin.Validate(interface{}([]string{c})); err == nil {
+			t.Errorf("Validation of %v should have failed", c)
+		}
+	}
+
+	// the errors mention numeric ranges as well as versions.
+	for _, c := range []string{"mem in 1024", "mem in 1.5"} {
+		if _, _, err := textConstraintLanguagePlugin.Validate(interface{}([]string{c})); err == nil {
+			t.Errorf("Validation of %v should have failed", c)
+		} else if !strings.Contains(err.Error(), "numeric range") {
+			t.Errorf("The error for %v should mention numeric ranges: %v", c, err)
+		}
+	}
+
+	// whitespace is removed from the range in the property expression.
+	if exp, _, err := textConstraintLanguagePlugin.GetNextExpression("firmware in [1.2.0, 2.0.0)"); err != nil {
+		t.Errorf("Error parsing constraint expression with GetNextExpression: %v", err)
+	} else if exp != "firmware\ain\a[1.2.0,2.0.0)" {
+		t.Errorf("Wrong expression returned by GetNextExpression: %v", exp)
+	}
+}
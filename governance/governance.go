@@ -9,6 +9,7 @@ import (
 	"github.com/open-horizon/anax/basicprotocol"
 	"github.com/open-horizon/anax/cache"
 	"github.com/open-horizon/anax/config"
+	"github.com/open-horizon/anax/containerruntime"
 	"github.com/open-horizon/anax/cutil"
 	"github.com/open-horizon/anax/eventlog"
 	"github.com/open-horizon/anax/events"
@@ -21,8 +22,8 @@ import (
 	"github.com/open-horizon/anax/policy"
 	"github.com/open-horizon/anax/producer"
 	"github.com/open-horizon/anax/semanticversion"
+	"github.com/open-horizon/anax/tracing"
 	"github.com/open-horizon/anax/worker"
-	bolt "go.etcd.io/bbolt"
 	"net/http"
 	"strconv"
 	"strings"
@@ -47,7 +48,7 @@ const EXCHANGE_ERRORS = "ExchangeErrors"
 
 type GovernanceWorker struct {
 	worker.BaseWorker // embedded field
-	db                *bolt.DB
+	db                persistence.AgentDatabase
 	devicePattern     string
 	deviceType        string
 	pm                *policy.PolicyManager
@@ -59,9 +60,11 @@ type GovernanceWorker struct {
 	exchErrors        cache.Cache
 	noworkDispatch    int64 // The last time the NoWorkHandler was dispatched.
 	essCleanedUp      bool
+	upgradeRetryTime  int64                             // When to check again for service upgrades that are waiting for the node's maintenance window, 0 if there are none.
+	containerClient   containerruntime.ContainerRuntime // The client of the container runtime that the status report lists the containers with, created on the first report.
 }
 
-func NewGovernanceWorker(name string, cfg *config.HorizonConfig, db *bolt.DB, pm *policy.PolicyManager) *GovernanceWorker {
+func NewGovernanceWorker(name string, cfg *config.HorizonConfig, db persistence.AgentDatabase, pm *policy.PolicyManager) *GovernanceWorker {
 
 	var ec *worker.BaseExchangeContext
 	var lrec exchange.ExchangeContext
@@ -135,6 +138,20 @@ func (w *GovernanceWorker) NewEvent(incoming events.Message) {
 		case events.EXECUTION_FAILED:
 			cmd := w.NewCleanupExecutionCommand(msg.AgreementProtocol, msg.AgreementId, w.producerPH[msg.AgreementProtocol].GetTerminationCode(producer.TERM_REASON_CONTAINER_FAILURE), msg.Deployment)
 			w.Commands <- cmd
+		case events.EXECUTION_UNHEALTHY:
+			// The containers are up but not answering their health check, this is treated the same as a container failure.
+			if ags, err := persistence.FindEstablishedAgreements(w.db, msg.AgreementProtocol, []persistence.EAFilter{persistence.UnarchivedEAFilter(), persistence.IdEAFilter(msg.AgreementId)}); err != nil {
+				glog.Errorf(logString(fmt.Sprintf("unable to retrieve agreement %v from database, error %v", msg.AgreementId, err)))
+			} else if len(ags) == 1 {
+				eventlog.LogAgreementEvent(
+					w.db,
+					persistence.SEVERITY_ERROR,
+					persistence.NewMessageMeta(EL_GOV_WL_CONTAINER_UNHEALTHY, ags[0].RunningWorkload.Org, ags[0].RunningWorkload.URL),
+					persistence.EC_CONTAINER_UNHEALTHY,
+					ags[0])
+			}
+			cmd := w.NewCleanupExecutionCommand(msg.AgreementProtocol, msg.AgreementId, w.producerPH[msg.AgreementProtocol].GetTerminationCode(producer.TERM_REASON_CONTAINER_FAILURE), msg.Deployment)
+			w.Commands <- cmd
 		case events.IMAGE_LOAD_FAILED:
 			cmd := w.NewCleanupExecutionCommand(msg.AgreementProtocol, msg.AgreementId, w.producerPH[msg.AgreementProtocol].GetTerminationCode(producer.TERM_REASON_WL_IMAGE_LOAD_FAILURE), msg.Deployment)
 			w.Commands <- cmd
@@ -190,12 +207,21 @@ func (w *GovernanceWorker) NewEvent(incoming events.Message) {
 					if msg.Error != nil {
 						errDetails = msg.Error.Error()
 					}
-					eventlog.LogAgreementEvent(
-						w.db,
-						persistence.SEVERITY_ERROR,
-						persistence.NewMessageMeta(EL_GOV_ERR_LOADING_IMG, ags[0].RunningWorkload.Org, ags[0].RunningWorkload.URL, errDetails),
-						persistence.EC_ERROR_IMAGE_LOADE,
-						ags[0])
+					if msg.Event().Id == events.IMAGE_SIG_VERIF_ERROR {
+						eventlog.LogAgreementEvent(
+							w.db,
+							persistence.SEVERITY_ERROR,
+							persistence.NewMessageMeta(EL_GOV_ERR_IMG_UNTRUSTED, ags[0].RunningWorkload.Org, ags[0].RunningWorkload.URL, errDetails),
+							persistence.EC_ERROR_IMAGE_UNTRUSTED,
+							ags[0])
+					} else {
+						eventlog.LogAgreementEvent(
+							w.db,
+							persistence.SEVERITY_ERROR,
+							persistence.NewMessageMeta(EL_GOV_ERR_LOADING_IMG, ags[0].RunningWorkload.Org, ags[0].RunningWorkload.URL, errDetails),
+							persistence.EC_ERROR_IMAGE_LOADE,
+							ags[0])
+					}
 					cmd := w.NewCleanupExecutionCommand(lc.AgreementProtocol, lc.AgreementId, reason, nil)
 					w.Commands <- cmd
 				}
@@ -210,6 +236,19 @@ func (w *GovernanceWorker) NewEvent(incoming events.Message) {
 					persistence.NewMessageMeta(EL_GOV_IMAGE_LOADED_FOR_SVC, serviceInfo.Org, serviceInfo.URL),
 					persistence.EC_IMAGE_LOADED,
 					"", serviceInfo.URL, "", serviceInfo.Version, "", lc.AgreementIds)
+			} else if msg.Event().Id == events.IMAGE_SIG_VERIF_ERROR {
+				var errDetails = "unknown error"
+				if msg.Error != nil {
+					errDetails = msg.Error.Error()
+				}
+				eventlog.LogServiceEvent2(
+					w.db,
+					persistence.SEVERITY_ERROR,
+					persistence.NewMessageMeta(EL_GOV_ERR_IMG_UNTRUSTED_SVC, serviceInfo.Org, serviceInfo.URL, errDetails),
+					persistence.EC_ERROR_IMAGE_UNTRUSTED,
+					"", serviceInfo.URL, "", serviceInfo.Version, "", lc.AgreementIds)
+				cmd := w.NewUpdateMicroserviceCommand(lc.Name, false, microservice.MS_IMAGE_FETCH_FAILED, microservice.DecodeReasonCode(microservice.MS_IMAGE_FETCH_FAILED))
+				w.Commands <- cmd
 			} else {
 				eventlog.LogServiceEvent2(
 					w.db,
@@ -282,6 +321,9 @@ func (w *GovernanceWorker) NewEvent(incoming events.Message) {
 			case events.EXECUTION_FAILED:
 				cmd := w.NewUpdateMicroserviceCommand(msg.LaunchContext.Name, false, microservice.MS_EXEC_FAILED, microservice.DecodeReasonCode(microservice.MS_EXEC_FAILED))
 				w.Commands <- cmd
+			case events.EXECUTION_UNHEALTHY:
+				cmd := w.NewUpdateMicroserviceCommand(msg.LaunchContext.Name, false, microservice.MS_HEALTH_CHECK_FAILED, microservice.DecodeReasonCode(microservice.MS_HEALTH_CHECK_FAILED))
+				w.Commands <- cmd
 			case events.IMAGE_LOAD_FAILED:
 				cmd := w.NewUpdateMicroserviceCommand(msg.LaunchContext.Name, false, microservice.MS_IMAGE_LOAD_FAILED, microservice.DecodeReasonCode(microservice.MS_IMAGE_LOAD_FAILED))
 				w.Commands <- cmd
@@ -652,6 +694,8 @@ func (w *GovernanceWorker) reportBlockchains() int {
 // same agreement id.
 func (w *GovernanceWorker) cancelAgreement(agreementId string, agreementProtocol string, reason uint, desc string) {
 
+	tracing.EndAgreement(agreementId, errors.New(fmt.Sprintf("agreement cancelled: %v", desc)))
+
 	var ag *persistence.EstablishedAgreement
 
 	// This function could be called the first time an agreement is cancelled and it could be called from
@@ -801,6 +845,12 @@ func (w *GovernanceWorker) Initialize() bool {
 		w.UpdateRegisteredServicesWithAgreement()
 	}
 
+	// The service upgrades that were waiting for the node's maintenance window before the agent restarted are not
+	// remembered, so check for service upgrades again when the worker starts.
+	if w.hasMaintenanceSchedule() {
+		w.upgradeRetryTime = time.Now().Unix()
+	}
+
 	return true
 
 }
@@ -825,6 +875,9 @@ func (w *GovernanceWorker) CommandHandler(command worker.Command) bool {
 		if ag, err := persistence.AgreementStateExecutionStarted(w.db, cmd.AgreementId, cmd.AgreementProtocol); err != nil {
 			glog.Errorf(logString(fmt.Sprintf("Failed to update local contract record to start governing Agreement: %v. Error: %v", cmd.AgreementId, err)))
 		} else {
+			// The workload is running, which is the end of the agreement negotiation on the node.
+			tracing.EndAgreement(cmd.AgreementId, nil)
+
 			eventlog.LogAgreementEvent(
 				w.db,
 				persistence.SEVERITY_INFO,
@@ -1376,8 +1429,15 @@ func (w *GovernanceWorker) CommandHandler(command worker.Command) bool {
 							persistence.EC_COMPLETE_DEPENDENT_SERVICE,
 							*msinst)
 					} else {
+						if cmd.ExecutionFailureCode == microservice.MS_HEALTH_CHECK_FAILED {
+							eventlog.LogServiceEvent(w.db, persistence.SEVERITY_ERROR,
+								persistence.NewMessageMeta(EL_GOV_SVC_CONTAINER_UNHEALTHY, cutil.FormOrgSpecUrl(msinst.SpecRef, msinst.Org)),
+								persistence.EC_CONTAINER_UNHEALTHY,
+								*msinst)
+						}
 						if msinst.CleanupStartTime == 0 { // if this is not part of the ms instance cleanup process
 							// this is the case where agreement are made but microservice containers are failed
+							// or are running but failing their health check
 							w.handleMicroserviceExecFailure(msdef, cmd.MsInstKey)
 						}
 					}
@@ -1489,6 +1549,12 @@ func (w *GovernanceWorker) NoWorkHandler() {
 		w.governAgreements()
 	}
 
+	// Retry the service upgrades that were waiting for the node's maintenance window.
+	if !w.IsWorkerShuttingDown() && w.upgradeRetryTime != 0 && time.Now().Unix() >= w.upgradeRetryTime {
+		w.upgradeRetryTime = 0
+		w.governMicroserviceVersions()
+	}
+
 	// When all subworkers are down, start the shutdown process.
 	if w.IsWorkerShuttingDown() && w.ShuttingDownCmd != nil {
 		if w.AreAllSubworkersTerminated() && w.essCleanedUp {
@@ -1507,7 +1573,10 @@ func (w *GovernanceWorker) NoWorkHandler() {
 }
 
 // This function encapsulates finalization of an agreement for re-use
-func (w *GovernanceWorker) finalizeAgreement(agreement persistence.EstablishedAgreement, protocolHandler abstractprotocol.ProtocolHandler) error {
+func (w *GovernanceWorker) finalizeAgreement(agreement persistence.EstablishedAgreement, protocolHandler abstractprotocol.ProtocolHandler) (finalizeErr error) {
+
+	span := tracing.StartSpan(agreement.CurrentAgreementId, "governance.FinalizeAgreement", nil)
+	defer func() { tracing.EndSpan(span, finalizeErr) }()
 
 	// The reply ack might have been lost or mishandled. Since we are now seeing evidence on the blockchain that the agreement
 	// was created by the agbot, we will assume we should have gotten a positive reply ack.
@@ -1543,7 +1612,12 @@ func (w *GovernanceWorker) finalizeAgreement(agreement persistence.EstablishedAg
 	return nil
 }
 
-func (w *GovernanceWorker) RecordReply(proposal abstractprotocol.Proposal, protocol string) error {
+func (w *GovernanceWorker) RecordReply(proposal abstractprotocol.Proposal, protocol string) (recordErr error) {
+
+	// The workload is launched from here, so the time it takes to fetch the images and start the containers is in the
+	// spans of the image fetch and container workers that follow.
+	span := tracing.StartSpan(proposal.AgreementId(), "governance.RecordReply", nil)
+	defer func() { tracing.EndSpan(span, recordErr) }()
 
 	// Update the agreement state in the database and in the exchange.
 	if ag, err := persistence.AgreementStateAccepted(w.db, proposal.AgreementId(), protocol); err != nil {
@@ -1728,7 +1802,7 @@ func (w *GovernanceWorker) processDependencies(dependencyPath []persistence.Serv
 			return ms_specs, fmt.Errorf("%s", logString(fmt.Sprintf("failed to get or create service definition for dependent service for agreement %v. %v", agreementId, err)))
 		}
 
-		msspec := events.MicroserviceSpec{SpecRef: msdef.SpecRef, Org: msdef.Org, Version: msdef.Version, MsdefId: msdef.Id}
+		msspec := events.MicroserviceSpec{SpecRef: msdef.SpecRef, Org: msdef.Org, Version: msdef.Version, MsdefId: msdef.Id, Readiness: sDep.Readiness}
 		ms_specs = append(ms_specs, msspec)
 
 		// Recursively work down the dependency tree, starting leaf node dependencies first and then start their parents.
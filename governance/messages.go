@@ -35,12 +35,15 @@ const (
 	EL_GOV_IMAGE_LOADED_FOR_SVC    = "Image loaded for service %v/%v."
 	EL_GOV_ERR_LOADING_IMG         = "Error loading image for %v/%v. Reason: %v"
 	EL_GOV_ERR_LOADING_IMG_FOR_SVC = "Error loading image for service %v/%v."
+	EL_GOV_ERR_IMG_UNTRUSTED       = "Image for %v/%v is not trusted. Reason: %v"
+	EL_GOV_ERR_IMG_UNTRUSTED_SVC   = "Image for service %v/%v is not trusted. Reason: %v"
 
 	// agreement
 	EL_GOV_START_TERM_AG_WITH_REASON    = "Start terminating agreement for %v. Termination reason: %v"
 	EL_GOV_AG_REACHED                   = "Agreement reached for service %v. The agreement id is %v."
 	EL_GOV_AG_NOT_VALID                 = "Agreement for %v no longer valid on the agbot. Node will cancel it."
 	EL_GOV_WL_CONTAINER_UP              = "Workload service containers for %v/%v are up and running."
+	EL_GOV_WL_CONTAINER_UNHEALTHY       = "Workload service containers for %v/%v are running but failing their health check."
 	EL_GOV_COMPLETE_TERM_AG_WITH_REASON = "Complete terminating agreement for %v. Termination reason: %v"
 	EL_GOV_ERR_DEL_AG_IN_EXCH           = "Error deleting agreement for %v in exchange: %v. Will retry."
 	EL_GOV_ERR_AG_VERIFICATION          = "Encountered error for AgreementVerification for %v with agbot, error %v"
@@ -61,6 +64,7 @@ const (
 	EL_GOV_START_WORKLOAD_SVC             = "Start workload service for %v/%v."
 	EL_GOV_WORKLOAD_DESTROYED             = "Workload destroyed for %v"
 	EL_GOV_SVC_CONTAINER_STARTED          = "Service containers for %v started."
+	EL_GOV_SVC_CONTAINER_UNHEALTHY        = "Service containers for %v are running but failing their health check."
 	EL_GOV_COMPLETE_CLEANUP_SVC           = "Complete cleaning up the service instance %v."
 	EL_GOV_START_DEPENDENT_SVC            = "Start dependent services for %v/%v."
 	EL_GOV_ERR_START_DEPENDENT_SVC        = "Encountered error starting dependen services for %v/%v. %v"
@@ -147,12 +151,15 @@ func MarkI18nMessages() {
 	msgPrinter.Sprintf(EL_GOV_IMAGE_LOADED_FOR_SVC)
 	msgPrinter.Sprintf(EL_GOV_ERR_LOADING_IMG)
 	msgPrinter.Sprintf(EL_GOV_ERR_LOADING_IMG_FOR_SVC)
+	msgPrinter.Sprintf(EL_GOV_ERR_IMG_UNTRUSTED)
+	msgPrinter.Sprintf(EL_GOV_ERR_IMG_UNTRUSTED_SVC)
 
 	// agreement
 	msgPrinter.Sprintf(EL_GOV_START_TERM_AG_WITH_REASON)
 	msgPrinter.Sprintf(EL_GOV_AG_REACHED)
 	msgPrinter.Sprintf(EL_GOV_AG_NOT_VALID)
 	msgPrinter.Sprintf(EL_GOV_WL_CONTAINER_UP)
+	msgPrinter.Sprintf(EL_GOV_WL_CONTAINER_UNHEALTHY)
 	msgPrinter.Sprintf(EL_GOV_COMPLETE_TERM_AG_WITH_REASON)
 	msgPrinter.Sprintf(EL_GOV_ERR_DEL_AG_IN_EXCH)
 	msgPrinter.Sprintf(EL_GOV_ERR_AG_VERIFICATION)
@@ -173,6 +180,7 @@ func MarkI18nMessages() {
 	msgPrinter.Sprintf(EL_GOV_START_WORKLOAD_SVC)
 	msgPrinter.Sprintf(EL_GOV_WORKLOAD_DESTROYED)
 	msgPrinter.Sprintf(EL_GOV_SVC_CONTAINER_STARTED)
+	msgPrinter.Sprintf(EL_GOV_SVC_CONTAINER_UNHEALTHY)
 	msgPrinter.Sprintf(EL_GOV_COMPLETE_CLEANUP_SVC)
 	msgPrinter.Sprintf(EL_GOV_START_DEPENDENT_SVC)
 	msgPrinter.Sprintf(EL_GOV_ERR_START_DEPENDENT_SVC)
@@ -10,6 +10,7 @@ import (
 	"github.com/open-horizon/anax/events"
 	"github.com/open-horizon/anax/exchange"
 	"github.com/open-horizon/anax/exchangecommon"
+	"github.com/open-horizon/anax/externalpolicy"
 	"github.com/open-horizon/anax/microservice"
 	"github.com/open-horizon/anax/persistence"
 	"github.com/open-horizon/anax/policy"
@@ -194,7 +195,7 @@ func (w *GovernanceWorker) StartMicroservice(ms_key string, agreementId string,
 					return nil, fmt.Errorf("%s", logString(fmt.Sprintf("failed to get or create service definition for for %v/%v: %v", rs.Org, rs.URL, err)))
 				} else {
 					// Assume the first msdef is the one we want.
-					msspec := events.MicroserviceSpec{SpecRef: rs.URL, Org: rs.Org, Version: msdef_dep.Version, MsdefId: msdef_dep.Id}
+					msspec := events.MicroserviceSpec{SpecRef: rs.URL, Org: rs.Org, Version: msdef_dep.Version, MsdefId: msdef_dep.Id, Readiness: rs.Readiness}
 					ms_specs = append(ms_specs, msspec)
 				}
 			}
@@ -507,7 +508,11 @@ func (w *GovernanceWorker) RetryMicroservice(msi *persistence.MicroserviceInstan
 }
 
 // Get the next highest microservice version and rollback to it. Tryer even lower version if it fails
-func (w *GovernanceWorker) RollbackMicroservice(msdef *persistence.MicroserviceDefinition) error {
+func (w *GovernanceWorker) RollbackMicroservice(msdef *persistence.MicroserviceDefinition) (rollbackErr error) {
+	defer func(failed *persistence.MicroserviceDefinition) {
+		microservice.CountServiceRollback(failed, rollbackErr)
+	}(msdef)
+
 	for true {
 		// get next lower version
 		if new_msdef, err := microservice.GetRollbackMicroserviceDef(exchange.GetHTTPServiceResolverHandler(w), msdef, w.db); err != nil {
@@ -804,6 +809,7 @@ func (w *GovernanceWorker) handleMicroserviceExecFailure(msdef *persistence.Micr
 			persistence.EC_START_RETRY_DEPENDENT_SERVICE,
 			msinst_key, msdef.SpecRef, msdef.Org, msdef.Version, msdef.Arch, []string{})
 
+		microservice.CountServiceRestart(msdef)
 		if err := w.RetryMicroservice(msi); err != nil {
 			eventlog.LogServiceEvent2(w.db, persistence.SEVERITY_ERROR,
 				persistence.NewMessageMeta(EL_GOV_FAILED_SVC_RETRY, strconv.Itoa(int(current_retry)), msdef.SpecRef, msdef.Version),
@@ -853,6 +859,11 @@ func (w *GovernanceWorker) handleMicroserviceUpgrade(msdef_id string) {
 			glog.Errorf(logString(fmt.Sprintf("Error finding the new service definition to upgrade to for %v/%v version %v. %v", msdef.Org, msdef.SpecRef, msdef.Version, err)))
 		} else if new_msdef == nil {
 			glog.V(5).Infof(logString(fmt.Sprintf("No changes for service definition %v/%v, no need to upgrade.", msdef.Org, msdef.SpecRef)))
+		} else if retryTime, deferred := w.serviceUpgradeDeferral(time.Now()); deferred {
+			glog.V(3).Infof(logString(fmt.Sprintf("node is outside of its maintenance window, deferring the upgrade of service %v/%v from version %v to %v until %v", msdef.Org, msdef.SpecRef, msdef.Version, new_msdef.Version, time.Unix(retryTime, 0))))
+			if w.upgradeRetryTime == 0 || retryTime < w.upgradeRetryTime {
+				w.upgradeRetryTime = retryTime
+			}
 		} else {
 			eventlog.LogServiceEvent2(w.db, persistence.SEVERITY_INFO,
 				persistence.NewMessageMeta(EL_GOV_START_UPGRADE, msdef.Org, msdef.SpecRef, msdef.Version, new_msdef.Version),
@@ -888,6 +899,44 @@ func (w *GovernanceWorker) handleMicroserviceUpgrade(msdef_id string) {
 	}
 }
 
+// Returns true if the node policy declares maintenance windows or blackout periods.
+func (w *GovernanceWorker) hasMaintenanceSchedule() bool {
+	if nodePol, err := persistence.FindNodePolicy(w.db); err != nil {
+		glog.Errorf(logString(fmt.Sprintf("unable to read node policy from the local database to check its maintenance window. %v", err)))
+		return false
+	} else if nodePol == nil {
+		return false
+	} else if schedule, err := externalpolicy.GetMaintenanceSchedule(nodePol.GetDeploymentPolicy().Properties); err != nil || schedule == nil {
+		return false
+	}
+	return true
+}
+
+// Returns true if the node's maintenance windows do not allow its services to be upgraded at the given time, and when to
+// check again.
+func (w *GovernanceWorker) serviceUpgradeDeferral(now time.Time) (int64, bool) {
+	nodePol, err := persistence.FindNodePolicy(w.db)
+	if err != nil {
+		glog.Errorf(logString(fmt.Sprintf("unable to read node policy from the local database to check its maintenance window. %v", err)))
+		return 0, false
+	} else if nodePol == nil {
+		return 0, false
+	}
+
+	schedule, err := externalpolicy.GetMaintenanceSchedule(nodePol.GetDeploymentPolicy().Properties)
+	if err != nil {
+		glog.Errorf(logString(fmt.Sprintf("ignoring the maintenance windows of the node policy. %v", err)))
+		return 0, false
+	} else if schedule.Allows(now) {
+		return 0, false
+	} else if next, ok := schedule.NextAllowed(now); ok {
+		return next.Unix(), true
+	}
+
+	// The node can never be changed, check again in a week in case its node policy was changed and not seen.
+	return now.Add(externalpolicy.MAINTENANCE_WINDOW_HORIZON).Unix(), true
+}
+
 // For the given suspended services, cancel all the related agreements and hence remove all the related containers.
 func (w *GovernanceWorker) handleServiceSuspended(service_cs []events.ServiceConfigState) error {
 	if service_cs == nil || len(service_cs) == 0 {
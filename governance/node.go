@@ -15,7 +15,6 @@ import (
 	"github.com/open-horizon/anax/persistence"
 	"github.com/open-horizon/anax/policy"
 	"github.com/open-horizon/anax/producer"
-	bolt "go.etcd.io/bbolt"
 	"time"
 )
 
@@ -330,7 +329,7 @@ func ValidateNewPattern(nodeType string, new_pattern string,
 	getPatterns exchange.PatternHandler,
 	serviceResolver exchange.ServiceResolverHandler,
 	getService exchange.ServiceHandler,
-	db *bolt.DB,
+	db persistence.AgentDatabase,
 	config *config.HorizonConfig) error {
 
 	glog.V(3).Infof(logString(fmt.Sprintf("Validating new pattern %v", new_pattern)))
@@ -445,7 +444,7 @@ func ValidateNewPattern(nodeType string, new_pattern string,
 // This function validats if there is enough user input for the given service.
 // The given mergedUserInput is the merged user input from node and pattern.
 // The user input from attribute will be added to it before checking.
-func ValidateUserInput(sdef *exchange.ServiceDefinition, serviceOrg string, mergedUserInput []policy.UserInput, db *bolt.DB) error {
+func ValidateUserInput(sdef *exchange.ServiceDefinition, serviceOrg string, mergedUserInput []policy.UserInput, db persistence.AgentDatabase) error {
 	glog.V(5).Infof(logString(fmt.Sprintf("Start validating userinput for service %v/%v", serviceOrg, sdef.URL)))
 
 	if !sdef.NeedsUserInput() {
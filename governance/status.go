@@ -7,6 +7,7 @@ import (
 	"github.com/golang/glog"
 	"github.com/open-horizon/anax/container"
 	"github.com/open-horizon/anax/containermessage"
+	"github.com/open-horizon/anax/containerruntime"
 	"github.com/open-horizon/anax/cutil"
 	"github.com/open-horizon/anax/events"
 	"github.com/open-horizon/anax/exchange"
@@ -15,6 +16,7 @@ import (
 	"github.com/open-horizon/anax/kube_operator"
 	"github.com/open-horizon/anax/persistence"
 	"github.com/open-horizon/anax/policy"
+	"github.com/open-horizon/anax/systemd"
 	"reflect"
 	"time"
 )
@@ -44,10 +46,16 @@ func (w *GovernanceWorker) reportDeviceStatus(cfgStates []events.ServiceConfigSt
 	// get docker containers
 	containers := make([]docker.APIContainers, 0)
 	if w.deviceType == persistence.DEVICE_TYPE_DEVICE {
-		if client, err := docker.NewClient(w.Config.Edge.DockerEndpoint); err != nil {
-			glog.Errorf(logString(fmt.Sprintf("Failed to instantiate docker Client: %v", err)))
-		} else {
-			containers, err = client.ListContainers(docker.ListContainersOptions{})
+		if w.containerClient == nil {
+			if client, err := containerruntime.NewContainerRuntime(w.Config.Edge.DockerEndpoint, w.Config.Edge.ContainerRuntime); err != nil {
+				glog.Errorf(logString(fmt.Sprintf("Failed to instantiate docker Client: %v", err)))
+			} else {
+				w.containerClient = client
+			}
+		}
+		if w.containerClient != nil {
+			var err error
+			containers, err = w.containerClient.ListContainers(docker.ListContainersOptions{})
 			if err != nil {
 				glog.Errorf(logString(fmt.Sprintf("Unable to get list of running containers: %v", err)))
 			}
@@ -289,7 +297,8 @@ func GetContainerStatus(deployment string, key string, infrastructure bool, cont
 
 		hc := helm.NewHelmClient()
 		releaseState := "Not Running"
-		if rs, err := hc.Status(hdc.ReleaseName); err != nil {
+		rs, err := hc.Status(hdc.ReleaseName)
+		if err != nil {
 			releaseState = fmt.Sprintf("Unknown, error: %v", err)
 		} else {
 			releaseState = rs.Status
@@ -299,6 +308,32 @@ func GetContainerStatus(deployment string, key string, infrastructure bool, cont
 		}
 		container_status.State = releaseState
 		status = append(status, container_status)
+
+		// Add the status of each resource in the release.
+		if rs != nil {
+			for _, res := range rs.Resources {
+				var resource_status exchange.ContainerStatus
+				resource_status.Name = fmt.Sprintf("Helm release: %v, %v: %v", hdc.ReleaseName, res.Kind, res.Name)
+				resource_status.Image = rs.ChartName
+				resource_status.Created = container_status.Created
+				resource_status.State = res.Status
+				status = append(status, resource_status)
+			}
+		}
+	} else if sdc, err := persistence.GetSystemdDeployment(deployment); err == nil {
+		var container_status exchange.ContainerStatus
+		container_status.Name = fmt.Sprintf("Systemd unit: %v", sdc.UnitName)
+		container_status.Image = sdc.PackageObject
+
+		unitState := "Not Running"
+		if us, err := systemd.NewSystemdClient().Status(sdc.UnitName); err != nil {
+			unitState = fmt.Sprintf("Unknown, error: %v", err)
+		} else {
+			unitState = fmt.Sprintf("%v (%v)", us.ActiveState, us.SubState)
+			container_status.Created = int64(us.StartTime)
+		}
+		container_status.State = unitState
+		status = append(status, container_status)
 	} else if kdc, err := persistence.GetKubeDeployment(deployment); err == nil {
 		var container_status exchange.ContainerStatus
 
@@ -307,7 +342,13 @@ func GetContainerStatus(deployment string, key string, infrastructure bool, cont
 			status = append(status, container_status)
 		} else {
 			// TODO-L
-			if kubeStatus, err := kc.Status(kdc.OperatorYamlArchive, kdc.Metadata, key, reqClusterNamespace); err != nil {
+			var kubeStatus []kube_operator.ContainerStatus
+			if kdc.IsManifest() {
+				kubeStatus, err = kc.ManifestStatus(kdc.ManifestArchive, key, reqClusterNamespace)
+			} else {
+				kubeStatus, err = kc.Status(kdc.OperatorYamlArchive, kdc.Metadata, key, reqClusterNamespace)
+			}
+			if err != nil {
 				container_status.State = fmt.Sprintf("Unknown, error: %v", err)
 				status = append(status, container_status)
 			} else {
@@ -329,7 +370,7 @@ func GetContainerStatus(deployment string, key string, infrastructure bool, cont
 // GetOperatorStatus will check if the given deployment is for a kube operator and return the operator defined status if it is
 // Will return nil for the interface and no error if the deployment is not for a kube operator
 func GetOperatorStatus(deployment string, agId string, reqNamespace string) (interface{}, error) {
-	if kd, err := persistence.GetKubeDeployment(deployment); err == nil {
+	if kd, err := persistence.GetKubeDeployment(deployment); err == nil && !kd.IsManifest() {
 		client, err := kube_operator.NewKubeClient()
 		if err != nil {
 			return nil, fmt.Errorf("%s", logString(fmt.Sprintf("Error retrieving operator status from cluster, error: %v", err)))
@@ -2,33 +2,60 @@ package helm
 
 import (
 	"encoding/base64"
+	"encoding/json"
 	"fmt"
 	"os"
+	"strings"
 
 	"github.com/golang/glog"
+	"github.com/open-horizon/anax/config"
 	"github.com/open-horizon/anax/cutil"
 )
 
 // Status object returned by our Helm client.
 type ReleaseStatus struct {
-	Name      string // Release name
-	Revision  string // Revision of the release
-	Updated   string // The date of last change
-	Status    string // The release's status
-	ChartName string // The name of the chart
-	Namespace string // The k8s namespace that the chart was deployed into
+	Name      string           // Release name
+	Revision  string           // Revision of the release
+	Updated   string           // The date of last change
+	Status    string           // The release's status
+	ChartName string           // The name of the chart
+	Namespace string           // The k8s namespace that the chart was deployed into
+	Resources []ResourceStatus // The status of each of the k8s resources created by the release
 }
 
-// The Helm Client interface that we use, regardless of how its implemented under the covers.
+// One entry in the history of a release.
+type ReleaseRevision struct {
+	Revision    int    `json:"revision"`
+	Updated     string `json:"updated"`
+	Status      string `json:"status"`
+	Chart       string `json:"chart"`
+	AppVersion  string `json:"app_version"`
+	Description string `json:"description"`
+}
+
+// The status of a k8s resource created by a release.
+type ResourceStatus struct {
+	Kind      string // The k8s kind of the resource
+	Name      string // The name of the resource
+	Namespace string // The namespace of the resource, empty for cluster scoped resources
+	Ready     bool   // True when the resource is available
+	Status    string // A short description of the state of the resource
+}
+
+// The Helm Client interface that we use, regardless of how its implemented under the covers. The values
+// override the values packaged in the chart, they can be nil.
 type HelmClient interface {
-	Install(b64Package string, releaseName string) error
+	Install(b64Package string, releaseName string, values map[string]interface{}) error
+	Upgrade(b64Package string, releaseName string, values map[string]interface{}) error
+	Rollback(releaseName string, revision int) error
 	UnInstall(releaseName string) error
 	Status(releaseName string) (*ReleaseStatus, error)
+	History(releaseName string) ([]ReleaseRevision, error)
 	ReleaseTimeFormat() string
 }
 
 func NewHelmClient() HelmClient {
-	return NewCliClient()
+	return NewSDKClient()
 }
 
 // ========================================================================================
@@ -49,7 +76,7 @@ func ConvertB64StringToFile(b64Package string) (string, error) {
 		if err != nil {
 			return "", err
 		}
-		glog.V(5).Infof(clilogString(fmt.Sprintf("Wrote %v bytes to temp Helm package to file: %v", num, f.Name())))
+		glog.V(5).Infof(sdklogString(fmt.Sprintf("Wrote %v bytes to temp Helm package to file: %v", num, f.Name())))
 		return f.Name(), nil
 	}
 }
@@ -70,3 +97,91 @@ func ConvertFileToB64String(filePath string) (string, error) {
 		return b64String, nil
 	}
 }
+
+// Convert the user input variables of a service into Helm values. The platform variables (HZN_*) are not
+// chart values, so they are skipped. User input values are strings, values that are valid JSON (numbers,
+// booleans and lists) are converted so that the chart templates get the type they expect.
+func UserInputValues(envAdds map[string]string) map[string]interface{} {
+	values := make(map[string]interface{})
+	for name, value := range envAdds {
+		if strings.HasPrefix(name, config.ENVVAR_PREFIX) {
+			continue
+		}
+		var v interface{}
+		if err := json.Unmarshal([]byte(value), &v); err != nil {
+			values[name] = value
+		} else if _, ok := v.(map[string]interface{}); ok {
+			values[name] = value
+		} else {
+			values[name] = v
+		}
+	}
+	return values
+}
+
+// Returns true when an operation on the release did not complete, Helm does not run another operation on the release
+// until it is rolled back.
+func IsPendingStatus(status string) bool {
+	return strings.HasPrefix(strings.ToUpper(status), "PENDING")
+}
+
+// Returns the last revision of the history that was deployed, before the latest revision. A revision that was
+// replaced by an upgrade is superseded. Returns 0 when there is no such revision.
+func LastDeployedRevision(history []ReleaseRevision) int {
+	for ix := len(history) - 2; ix >= 0; ix-- {
+		if status := strings.ToLower(history[ix].Status); status == "deployed" || status == "superseded" {
+			return history[ix].Revision
+		}
+	}
+	return 0
+}
+
+// Get the status of a k8s resource from its unstructured form. Workload resources are ready when all of their
+// replicas are ready, all other resources are ready when they exist.
+func GetResourceStatus(obj map[string]interface{}) ResourceStatus {
+	rs := ResourceStatus{Ready: true, Status: "Created"}
+	rs.Kind, _ = obj["kind"].(string)
+	if md, ok := obj["metadata"].(map[string]interface{}); ok {
+		rs.Name, _ = md["name"].(string)
+		rs.Namespace, _ = md["namespace"].(string)
+	}
+
+	spec, _ := obj["spec"].(map[string]interface{})
+	status, _ := obj["status"].(map[string]interface{})
+
+	switch rs.Kind {
+	case "Deployment", "StatefulSet", "ReplicaSet":
+		desired := int64(1)
+		if r, ok := spec["replicas"].(float64); ok {
+			desired = int64(r)
+		}
+		ready := int64(0)
+		if r, ok := status["readyReplicas"].(float64); ok {
+			ready = int64(r)
+		}
+		rs.Ready = ready >= desired
+		rs.Status = fmt.Sprintf("%v/%v ready", ready, desired)
+	case "DaemonSet":
+		desired, _ := status["desiredNumberScheduled"].(float64)
+		ready, _ := status["numberReady"].(float64)
+		rs.Ready = ready >= desired
+		rs.Status = fmt.Sprintf("%v/%v ready", int64(ready), int64(desired))
+	case "Pod":
+		phase, _ := status["phase"].(string)
+		rs.Ready = phase == "Running" || phase == "Succeeded"
+		rs.Status = phase
+	case "Job":
+		succeeded, _ := status["succeeded"].(float64)
+		failed, _ := status["failed"].(float64)
+		rs.Ready = succeeded > 0
+		if failed > 0 && succeeded == 0 {
+			rs.Status = "Failed"
+		} else if succeeded > 0 {
+			rs.Status = "Complete"
+		} else {
+			rs.Status = "Running"
+		}
+	}
+
+	return rs
+}
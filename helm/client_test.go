@@ -8,6 +8,15 @@ import (
 	"flag"
 	"os"
 	"testing"
+	"time"
+
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/release"
+	helmtime "helm.sh/helm/v3/pkg/time"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 )
 
 func init() {
@@ -36,3 +45,120 @@ func Test_DecodeAndCreate(t *testing.T) {
 	}
 
 }
+
+func Test_UserInputValues(t *testing.T) {
+
+	envAdds := map[string]string{
+		"HZN_AGREEMENTID": "ag1",
+		"replicaCount":    "3",
+		"debug":           "true",
+		"name":            "hello",
+		"ports":           "[80,443]",
+		"config":          "{\"a\":1}",
+	}
+
+	values := UserInputValues(envAdds)
+	if _, ok := values["HZN_AGREEMENTID"]; ok {
+		t.Errorf("platform variables should not be values: %v", values)
+	} else if values["replicaCount"] != float64(3) {
+		t.Errorf("replicaCount should be a number: %v", values["replicaCount"])
+	} else if values["debug"] != true {
+		t.Errorf("debug should be a boolean: %v", values["debug"])
+	} else if values["name"] != "hello" {
+		t.Errorf("name should be a string: %v", values["name"])
+	} else if ports, ok := values["ports"].([]interface{}); !ok || len(ports) != 2 {
+		t.Errorf("ports should be a list: %v", values["ports"])
+	} else if values["config"] != "{\"a\":1}" {
+		t.Errorf("config should be a string: %v", values["config"])
+	}
+
+}
+
+func Test_convertReleaseStatus(t *testing.T) {
+
+	replicas := int32(2)
+	deployed, _ := helmtime.Parse(time.RFC3339Nano, "2023-01-02T15:04:05.123456789Z")
+	rel := &release.Release{
+		Name:      "hello",
+		Version:   3,
+		Namespace: "default",
+		Chart:     &chart.Chart{Metadata: &chart.Metadata{Name: "hello", Version: "1.0.0", AppVersion: "2.0"}},
+		Info: &release.Info{
+			LastDeployed: deployed,
+			Status:       release.StatusDeployed,
+			Description:  "Upgrade complete",
+			Resources: map[string][]runtime.Object{
+				"v1/Pod(related)": {&corev1.Pod{TypeMeta: metav1.TypeMeta{Kind: "Pod"}, ObjectMeta: metav1.ObjectMeta{Name: "hello-abc", Namespace: "default"}, Status: corev1.PodStatus{Phase: corev1.PodPending}}},
+				"v1/Deployment":   {&appsv1.Deployment{TypeMeta: metav1.TypeMeta{Kind: "Deployment"}, ObjectMeta: metav1.ObjectMeta{Name: "hello", Namespace: "default"}, Spec: appsv1.DeploymentSpec{Replicas: &replicas}, Status: appsv1.DeploymentStatus{ReadyReplicas: 2}}},
+				"v1/ConfigMap":    {&corev1.ConfigMap{TypeMeta: metav1.TypeMeta{Kind: "ConfigMap"}, ObjectMeta: metav1.ObjectMeta{Name: "hello-config", Namespace: "default"}}},
+			},
+		},
+	}
+
+	status, err := convertReleaseStatus(rel)
+	if err != nil {
+		t.Fatalf("unexpected error converting %v, error: %v", rel, err)
+	}
+
+	if status.Status != DEPLOYED || status.Revision != "3" || status.ChartName != "hello-1.0.0" || status.Updated != "2023-01-02T15:04:05.123456789Z" {
+		t.Errorf("unexpected status %v", status)
+	} else if len(status.Resources) != 3 {
+		t.Errorf("expected 3 resources, got %v", status.Resources)
+	} else if rs := status.Resources[0]; rs.Kind != "ConfigMap" || !rs.Ready {
+		t.Errorf("unexpected resource status %v", rs)
+	} else if rs := status.Resources[1]; rs.Kind != "Deployment" || !rs.Ready || rs.Status != "2/2 ready" {
+		t.Errorf("unexpected resource status %v", rs)
+	} else if rs := status.Resources[2]; rs.Kind != "Pod" || rs.Ready || rs.Status != "Pending" {
+		t.Errorf("unexpected resource status %v", rs)
+	}
+
+	rev := convertReleaseRevision(rel)
+	if rev.Revision != 3 || rev.Status != "deployed" || rev.Chart != "hello-1.0.0" || rev.AppVersion != "2.0" || rev.Description != "Upgrade complete" {
+		t.Errorf("unexpected release revision %v", rev)
+	}
+
+}
+
+func Test_GetResourceStatus(t *testing.T) {
+
+	// A deployment with the default number of replicas, none ready.
+	rs := GetResourceStatus(map[string]interface{}{"kind": "Deployment", "metadata": map[string]interface{}{"name": "d"}})
+	if rs.Ready || rs.Status != "0/1 ready" {
+		t.Errorf("unexpected resource status %v", rs)
+	}
+
+	rs = GetResourceStatus(map[string]interface{}{"kind": "Job", "status": map[string]interface{}{"failed": float64(1)}})
+	if rs.Ready || rs.Status != "Failed" {
+		t.Errorf("unexpected resource status %v", rs)
+	}
+
+	rs = GetResourceStatus(map[string]interface{}{"kind": "DaemonSet", "status": map[string]interface{}{"desiredNumberScheduled": float64(2), "numberReady": float64(2)}})
+	if !rs.Ready {
+		t.Errorf("unexpected resource status %v", rs)
+	}
+
+}
+
+func Test_LastDeployedRevision(t *testing.T) {
+
+	// The agent stopped during the upgrade to revision 3, the release is rolled back to revision 2.
+	history := []ReleaseRevision{
+		{Revision: 1, Status: release.StatusSuperseded.String()},
+		{Revision: 2, Status: release.StatusSuperseded.String()},
+		{Revision: 3, Status: release.StatusPendingUpgrade.String()},
+	}
+	if !IsPendingStatus("PENDING-UPGRADE") || IsPendingStatus(DEPLOYED) {
+		t.Errorf("unexpected pending status")
+	} else if rev := LastDeployedRevision(history); rev != 2 {
+		t.Errorf("expected revision 2, got %v", rev)
+	}
+
+	// A failed revision is skipped, a release that was never deployed has no revision to roll back to.
+	history[1].Status = release.StatusFailed.String()
+	if rev := LastDeployedRevision(history); rev != 1 {
+		t.Errorf("expected revision 1, got %v", rev)
+	} else if rev := LastDeployedRevision(history[2:]); rev != 0 {
+		t.Errorf("expected no revision, got %v", rev)
+	}
+
+}
@@ -62,3 +62,15 @@ func NewMaintenanceCommand(protocol string, agreementId string, deployment persi
 		Deployment:        deployment,
 	}
 }
+
+// Uninstall all of the releases that are waiting for an upgrade.
+type FlushCommand struct {
+}
+
+func (f FlushCommand) ShortString() string {
+	return fmt.Sprintf("FlushCommand")
+}
+
+func NewFlushCommand() *FlushCommand {
+	return &FlushCommand{}
+}
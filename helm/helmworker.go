@@ -8,15 +8,15 @@ import (
 	"github.com/open-horizon/anax/events"
 	"github.com/open-horizon/anax/persistence"
 	"github.com/open-horizon/anax/worker"
-	bolt "go.etcd.io/bbolt"
+	"time"
 )
 
 type HelmWorker struct {
 	worker.BaseWorker // embedded field
-	db                *bolt.DB
+	db                persistence.AgentDatabase
 }
 
-func NewHelmWorker(name string, config *config.HorizonConfig, db *bolt.DB) *HelmWorker {
+func NewHelmWorker(name string, config *config.HorizonConfig, db persistence.AgentDatabase) *HelmWorker {
 
 	worker := &HelmWorker{
 		BaseWorker: worker.NewBaseWorker(name, config, nil),
@@ -24,7 +24,7 @@ func NewHelmWorker(name string, config *config.HorizonConfig, db *bolt.DB) *Helm
 	}
 
 	glog.Info(hpwlog(fmt.Sprintf("Starting Helm worker")))
-	worker.Start(worker, 0)
+	worker.Start(worker, 15)
 	return worker
 }
 
@@ -63,6 +63,8 @@ func (w *HelmWorker) NewEvent(incoming events.Message) {
 		msg, _ := incoming.(*events.NodeShutdownCompleteMessage)
 		switch msg.Event().Id {
 		case events.UNCONFIGURE_COMPLETE:
+			// The node is going away, there won't be an upgrade.
+			w.Commands <- NewFlushCommand()
 			w.Commands <- worker.NewTerminateCommand("shutdown")
 		}
 
@@ -114,9 +116,21 @@ func (w *HelmWorker) CommandHandler(command worker.Command) bool {
 		if !ok {
 			glog.Warningf(hpwlog(fmt.Sprintf("ignoring non-Helm deployment: %v", cmd.Deployment)))
 			return true
-		} else if err := w.uninstallHelmPackage(hdc); err != nil {
-			// Since we have a Helm deployment package, uninstall it.
-			glog.Errorf(hpwlog(fmt.Sprintf("failed to uninstall helm package after agreement cancellation: %v", err)))
+		} else if rel, err := persistence.FindHelmRelease(w.db, hdc.ReleaseName); err != nil {
+			glog.Errorf(hpwlog(fmt.Sprintf("unable to read Helm release %v from the database, not uninstalling it, error: %v", hdc.ReleaseName, err)))
+		} else if rel != nil && rel.AgreementId != "" && rel.AgreementId != cmd.CurrentAgreementId {
+			// The release has already been upgraded by another agreement.
+			glog.V(3).Infof(hpwlog(fmt.Sprintf("Helm release %v is owned by agreement %v, not uninstalling it for agreement %v", hdc.ReleaseName, rel.AgreementId, cmd.CurrentAgreementId)))
+		} else if grace := w.Config.GetHelmUpgradeGracePeriod(); grace > 0 {
+			// Keep the release running for a while, the agreement might have ended because the service is being upgraded.
+			glog.V(3).Infof(hpwlog(fmt.Sprintf("Helm release %v will be uninstalled in %v seconds unless it is upgraded", hdc.ReleaseName, grace)))
+			pending := &persistence.HelmRelease{ReleaseName: hdc.ReleaseName, UninstallDeadline: time.Now().Unix() + grace}
+			if err := persistence.SaveHelmRelease(w.db, pending); err != nil {
+				glog.Errorf(hpwlog(fmt.Sprintf("unable to save Helm release %v, uninstalling it now, error: %v", hdc.ReleaseName, err)))
+				w.uninstallRelease(hdc.ReleaseName)
+			}
+		} else {
+			w.uninstallRelease(hdc.ReleaseName)
 		}
 
 		w.Messages() <- events.NewWorkloadMessage(events.WORKLOAD_DESTROYED, cmd.AgreementProtocol, cmd.CurrentAgreementId, hdc)
@@ -135,6 +149,9 @@ func (w *HelmWorker) CommandHandler(command worker.Command) bool {
 			w.Messages() <- events.NewWorkloadMessage(events.EXECUTION_FAILED, cmd.AgreementProtocol, cmd.AgreementId, hdc)
 		}
 
+	case *FlushCommand:
+		w.uninstallPending(true)
+
 	default:
 		return false
 	}
@@ -142,6 +159,36 @@ func (w *HelmWorker) CommandHandler(command worker.Command) bool {
 
 }
 
+func (w *HelmWorker) NoWorkHandler() {
+	w.uninstallPending(false)
+}
+
+// Uninstall the releases whose grace period has expired, or all of them when forced. The releases are kept in the
+// database, so the releases whose grace period expired while the agent was down are uninstalled when it restarts.
+func (w *HelmWorker) uninstallPending(force bool) {
+	releases, err := persistence.FindHelmReleases(w.db)
+	if err != nil {
+		glog.Errorf(hpwlog(fmt.Sprintf("unable to read Helm releases from the database, error: %v", err)))
+		return
+	}
+	now := time.Now().Unix()
+	for _, rel := range releases {
+		if rel.IsPendingUninstall() && (force || rel.UninstallDeadline <= now) {
+			w.uninstallRelease(rel.ReleaseName)
+		}
+	}
+}
+
+// Forget the release and uninstall it. The release is not retried when it cannot be uninstalled.
+func (w *HelmWorker) uninstallRelease(releaseName string) {
+	if err := persistence.DeleteHelmRelease(w.db, releaseName); err != nil {
+		glog.Errorf(hpwlog(fmt.Sprintf("unable to delete Helm release %v from the database, error: %v", releaseName, err)))
+	}
+	if err := w.uninstallHelmPackage(releaseName); err != nil {
+		glog.Errorf(hpwlog(fmt.Sprintf("failed to uninstall helm package after agreement cancellation: %v", err)))
+	}
+}
+
 func (w *HelmWorker) getLaunchContext(launchContext interface{}) *events.AgreementLaunchContext {
 	switch launchContext.(type) {
 	case *events.AgreementLaunchContext:
@@ -157,26 +204,64 @@ func (w *HelmWorker) processHelmPackage(launchContext *events.AgreementLaunchCon
 
 	// TODO: Verify signature
 
+	// The user input variables of the service override the values in the chart.
+	values := map[string]interface{}{}
+	if launchContext.EnvironmentAdditions != nil {
+		values = UserInputValues(*launchContext.EnvironmentAdditions)
+	}
+
+	// A release that is still installed belongs to a previous version of the service, upgrade it in place so
+	// that the service keeps running.
 	c := NewHelmClient()
-	if err := c.Install(hd.ChartArchive, hd.ReleaseName); err != nil {
+	if rs, err := c.Status(hd.ReleaseName); err == nil {
+		if IsPendingStatus(rs.Status) {
+			// The agent stopped during an upgrade of the release, Helm refuses to upgrade it until it is rolled back.
+			if err := recoverRelease(c, hd.ReleaseName); err != nil {
+				return errors.New(fmt.Sprintf("unable to upgrade Helm release %v in status %v, error: %v", hd, rs.Status, err))
+			}
+		}
+		if err := c.Upgrade(hd.ChartArchive, hd.ReleaseName, values); err != nil {
+			return errors.New(fmt.Sprintf("unable to upgrade Helm release %v, error: %v", hd, err))
+		}
+		glog.V(5).Infof(hpwlog(fmt.Sprintf("completed upgrade of Helm Deployment release %v", hd.ReleaseName)))
+	} else if err := c.Install(hd.ChartArchive, hd.ReleaseName, values); err != nil {
 		return errors.New(fmt.Sprintf("unable to install Helm package %v, error: %v", hd, err))
+	} else {
+		glog.V(5).Infof(hpwlog(fmt.Sprintf("completed install of Helm Deployment release %v", hd.ReleaseName)))
 	}
 
-	glog.V(5).Infof(hpwlog(fmt.Sprintf("completed install of Helm Deployment release %v", hd.ReleaseName)))
+	// The agreement owns the release, it is no longer waiting to be uninstalled.
+	if err := persistence.SaveHelmRelease(w.db, &persistence.HelmRelease{ReleaseName: hd.ReleaseName, AgreementId: launchContext.AgreementId}); err != nil {
+		return errors.New(fmt.Sprintf("unable to save Helm release %v, error: %v", hd.ReleaseName, err))
+	}
 
 	return nil
 }
 
-func (w *HelmWorker) uninstallHelmPackage(hd *persistence.HelmDeploymentConfig) error {
+// Roll the release back to the last revision that was deployed.
+func recoverRelease(c HelmClient, releaseName string) error {
+	history, err := c.History(releaseName)
+	if err != nil {
+		return err
+	}
+	revision := LastDeployedRevision(history)
+	if revision == 0 {
+		return errors.New(fmt.Sprintf("Helm release %v has no deployed revision to roll back to", releaseName))
+	}
+	glog.V(3).Infof(hpwlog(fmt.Sprintf("rolling back Helm release %v to revision %v", releaseName, revision)))
+	return c.Rollback(releaseName, revision)
+}
+
+func (w *HelmWorker) uninstallHelmPackage(releaseName string) error {
 
-	glog.V(5).Infof(hpwlog(fmt.Sprintf("begin uninstall of Helm Deployment release %v", hd.ReleaseName)))
+	glog.V(5).Infof(hpwlog(fmt.Sprintf("begin uninstall of Helm Deployment release %v", releaseName)))
 
 	c := NewHelmClient()
-	if err := c.UnInstall(hd.ReleaseName); err != nil {
-		return errors.New(fmt.Sprintf("unable to uninstall Helm package %v, error: %v", hd, err))
+	if err := c.UnInstall(releaseName); err != nil {
+		return errors.New(fmt.Sprintf("unable to uninstall Helm release %v, error: %v", releaseName, err))
 	}
 
-	glog.V(5).Infof(hpwlog(fmt.Sprintf("completed uninstall of Helm Deployment release %v", hd.ReleaseName)))
+	glog.V(5).Infof(hpwlog(fmt.Sprintf("completed uninstall of Helm Deployment release %v", releaseName)))
 
 	return nil
 }
@@ -193,6 +278,12 @@ func (w *HelmWorker) releaseStatus(hd *persistence.HelmDeploymentConfig, desired
 		return errors.New(fmt.Sprintf("Helm release %v is not in desiredStatus %v, in %v", hd.ReleaseName, desiredStatus, status))
 	}
 
+	for _, rs := range status.Resources {
+		if !rs.Ready {
+			glog.Warningf(hpwlog(fmt.Sprintf("Helm release %v resource %v %v is not ready: %v", hd.ReleaseName, rs.Kind, rs.Name, rs.Status)))
+		}
+	}
+
 	glog.V(5).Infof(hpwlog(fmt.Sprintf("completed listing Helm Deployment release %v, status %v", hd.ReleaseName, status)))
 
 	return nil
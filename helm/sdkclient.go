@@ -0,0 +1,244 @@
+package helm
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang/glog"
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/cli"
+	"helm.sh/helm/v3/pkg/release"
+)
+
+// This client implements our abstract helm client interface, using the Helm 3 Go SDK. The cluster, namespace and
+// storage driver are configured from the same environment variables (KUBECONFIG, HELM_NAMESPACE, HELM_DRIVER) as the
+// Helm CLI.
+
+type SDKClient struct {
+}
+
+// The time to wait for the resources of a release to become ready, the same as the default of the Helm CLI.
+const RELEASE_TIMEOUT = 5 * time.Minute
+const DEPLOYED = "DEPLOYED"
+
+func NewSDKClient() *SDKClient {
+	return new(SDKClient)
+}
+
+func (c *SDKClient) Install(b64Package string, releaseName string, values map[string]interface{}) error {
+	cfg, settings, err := newConfiguration()
+	if err != nil {
+		return errors.New(fmt.Sprintf("error installing Helm package: %v", err))
+	}
+	chrt, err := loadChart(b64Package)
+	if err != nil {
+		return errors.New(fmt.Sprintf("error installing Helm package: %v", err))
+	}
+
+	install := action.NewInstall(cfg)
+	install.ReleaseName = releaseName
+	install.Namespace = settings.Namespace()
+	install.Wait = true
+	install.Timeout = RELEASE_TIMEOUT
+	if _, err := install.Run(chrt, values); err != nil {
+		return errors.New(fmt.Sprintf("error installing Helm package: %v", err))
+	}
+	return nil
+}
+
+// Upgrade the release in place. The upgrade is atomic, if it fails the release is rolled back to the revision that
+// was deployed before the upgrade, so that the previous version of the service keeps running.
+func (c *SDKClient) Upgrade(b64Package string, releaseName string, values map[string]interface{}) error {
+	cfg, settings, err := newConfiguration()
+	if err != nil {
+		return errors.New(fmt.Sprintf("error upgrading Helm release %v: %v", releaseName, err))
+	}
+	chrt, err := loadChart(b64Package)
+	if err != nil {
+		return errors.New(fmt.Sprintf("error upgrading Helm release %v: %v", releaseName, err))
+	}
+
+	upgrade := action.NewUpgrade(cfg)
+	upgrade.Namespace = settings.Namespace()
+	upgrade.Atomic = true
+	upgrade.Timeout = RELEASE_TIMEOUT
+	if _, err := upgrade.Run(releaseName, chrt, values); err != nil {
+		return errors.New(fmt.Sprintf("error upgrading Helm release %v: %v", releaseName, err))
+	}
+	return nil
+}
+
+func (c *SDKClient) Rollback(releaseName string, revision int) error {
+	cfg, _, err := newConfiguration()
+	if err != nil {
+		return errors.New(fmt.Sprintf("error rolling back Helm release: %v", err))
+	}
+
+	rollback := action.NewRollback(cfg)
+	rollback.Version = revision
+	rollback.Wait = true
+	rollback.Timeout = RELEASE_TIMEOUT
+	if err := rollback.Run(releaseName); err != nil {
+		return errors.New(fmt.Sprintf("error rolling back Helm release: %v", err))
+	}
+	return nil
+}
+
+func (c *SDKClient) UnInstall(releaseName string) error {
+	cfg, _, err := newConfiguration()
+	if err != nil {
+		return errors.New(fmt.Sprintf("error uninstalling Helm package: %v", err))
+	}
+
+	uninstall := action.NewUninstall(cfg)
+	uninstall.Timeout = RELEASE_TIMEOUT
+	if _, err := uninstall.Run(releaseName); err != nil {
+		return errors.New(fmt.Sprintf("error uninstalling Helm package: %v", err))
+	}
+	return nil
+}
+
+func (c *SDKClient) Status(releaseName string) (*ReleaseStatus, error) {
+	cfg, _, err := newConfiguration()
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("error getting Helm release status: %v", err))
+	}
+
+	status := action.NewStatus(cfg)
+	status.ShowResources = true
+	rel, err := status.Run(releaseName)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("error getting Helm release status: %v", err))
+	}
+	return convertReleaseStatus(rel)
+}
+
+func (c *SDKClient) History(releaseName string) ([]ReleaseRevision, error) {
+	cfg, _, err := newConfiguration()
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("error getting Helm release history: %v", err))
+	}
+
+	rels, err := action.NewHistory(cfg).Run(releaseName)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("error getting Helm release history: %v", err))
+	}
+
+	history := make([]ReleaseRevision, 0, len(rels))
+	for _, rel := range rels {
+		history = append(history, convertReleaseRevision(rel))
+	}
+	sort.Slice(history, func(i, j int) bool { return history[i].Revision < history[j].Revision })
+	return history, nil
+}
+
+// Helm time format. Golang requires the format string to be in reference to the specific time as shown.
+// This is so that the formatter and parser can figure out what goes where in the string.
+const HelmReleaseStatusTimeFormat = time.RFC3339Nano
+
+func (c *SDKClient) ReleaseTimeFormat() string {
+	return HelmReleaseStatusTimeFormat
+}
+
+// Create the Helm action configuration, for the cluster and namespace configured in the environment.
+func newConfiguration() (*action.Configuration, *cli.EnvSettings, error) {
+	settings := cli.New()
+	cfg := new(action.Configuration)
+	debug := func(format string, v ...interface{}) {
+		glog.V(5).Infof(sdklogString(fmt.Sprintf(format, v...)))
+	}
+	if err := cfg.Init(settings.RESTClientGetter(), settings.Namespace(), os.Getenv("HELM_DRIVER"), debug); err != nil {
+		return nil, nil, err
+	}
+	return cfg, settings, nil
+}
+
+// Load the chart from the base 64 encoded chart archive.
+func loadChart(b64Package string) (*chart.Chart, error) {
+	archive, err := base64.StdEncoding.DecodeString(b64Package)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("error decoding Helm package: %v", err))
+	}
+	chrt, err := loader.LoadArchive(bytes.NewReader(archive))
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("error loading Helm package: %v", err))
+	}
+	glog.V(5).Infof(sdklogString(fmt.Sprintf("Loaded Helm chart %v version %v", chrt.Name(), chrt.Metadata.Version)))
+	return chrt, nil
+}
+
+func chartName(rel *release.Release) string {
+	if rel.Chart == nil || rel.Chart.Metadata == nil {
+		return ""
+	}
+	return fmt.Sprintf("%v-%v", rel.Chart.Metadata.Name, rel.Chart.Metadata.Version)
+}
+
+// Convert a release into our status object.
+func convertReleaseStatus(rel *release.Release) (*ReleaseStatus, error) {
+
+	status := &ReleaseStatus{
+		Name:      rel.Name,
+		Revision:  strconv.Itoa(rel.Version),
+		ChartName: chartName(rel),
+		Namespace: rel.Namespace,
+		Resources: make([]ResourceStatus, 0, 5),
+	}
+	if rel.Info == nil {
+		return status, nil
+	}
+	status.Updated = rel.Info.LastDeployed.Format(HelmReleaseStatusTimeFormat)
+	status.Status = strings.ToUpper(rel.Info.Status.String())
+
+	// The resources are grouped by kind, sort the kinds so that the status is reported in a stable order.
+	kinds := make([]string, 0, len(rel.Info.Resources))
+	for kind := range rel.Info.Resources {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	for _, kind := range kinds {
+		for _, obj := range rel.Info.Resources[kind] {
+			// Convert the object to its unstructured form, the same form as the JSON output of helm status.
+			u := make(map[string]interface{})
+			if oBytes, err := json.Marshal(obj); err != nil {
+				return nil, errors.New(fmt.Sprintf("error marshalling Helm release %v resource %v, error: %v", rel.Name, kind, err))
+			} else if err := json.Unmarshal(oBytes, &u); err != nil {
+				return nil, errors.New(fmt.Sprintf("error unmarshalling Helm release %v resource %v, error: %v", rel.Name, kind, err))
+			}
+			status.Resources = append(status.Resources, GetResourceStatus(u))
+		}
+	}
+
+	return status, nil
+}
+
+// Convert a release into an entry of the release history.
+func convertReleaseRevision(rel *release.Release) ReleaseRevision {
+	rev := ReleaseRevision{
+		Revision: rel.Version,
+		Chart:    chartName(rel),
+	}
+	if rel.Chart != nil && rel.Chart.Metadata != nil {
+		rev.AppVersion = rel.Chart.Metadata.AppVersion
+	}
+	if rel.Info != nil {
+		rev.Updated = rel.Info.LastDeployed.Format(HelmReleaseStatusTimeFormat)
+		rev.Status = rel.Info.Status.String()
+		rev.Description = rel.Info.Description
+	}
+	return rev
+}
+
+var sdklogString = func(v interface{}) string {
+	return fmt.Sprintf("Helm SDKClient: %v", v)
+}
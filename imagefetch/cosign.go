@@ -0,0 +1,372 @@
+package imagefetch
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"github.com/golang/glog"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	protobundle "github.com/sigstore/protobuf-specs/gen/pb-go/bundle/v1"
+	protocommon "github.com/sigstore/protobuf-specs/gen/pb-go/common/v1"
+	protorekor "github.com/sigstore/protobuf-specs/gen/pb-go/rekor/v1"
+	"github.com/sigstore/sigstore-go/pkg/bundle"
+	"github.com/sigstore/sigstore-go/pkg/root"
+	"github.com/sigstore/sigstore-go/pkg/verify"
+	"github.com/sigstore/sigstore/pkg/signature"
+	"io"
+	"strings"
+	"time"
+)
+
+// The annotations of the layers of a cosign signature image.
+const (
+	COSIGN_SIGNATURE_ANNOTATION   = "dev.cosignproject.cosign/signature"
+	COSIGN_CERTIFICATE_ANNOTATION = "dev.sigstore.cosign/certificate"
+	COSIGN_CHAIN_ANNOTATION       = "dev.sigstore.cosign/chain"
+	COSIGN_BUNDLE_ANNOTATION      = "dev.sigstore.cosign/bundle"
+)
+
+const COSIGN_SIGNATURE_TYPE = "cosign container image signature"
+
+// The media type of the sigstore bundles that the cosign signatures are converted to. Version 0.1 holds a cert chain and
+// a transparency log entry with only its signed entry timestamp, which is what a cosign signature image has.
+const COSIGN_SIGSTORE_BUNDLE_MEDIA_TYPE = "application/vnd.dev.sigstore.bundle+json;version=0.1"
+
+// The simple signing payload that a cosign signature signs.
+type cosignPayload struct {
+	Critical struct {
+		Identity struct {
+			DockerReference string `json:"docker-reference"`
+		} `json:"identity"`
+		Image struct {
+			DockerManifestDigest string `json:"docker-manifest-digest"`
+		} `json:"image"`
+		Type string `json:"type"`
+	} `json:"critical"`
+}
+
+// The transparency log entry bundle of a cosign signature. The signed entry timestamp is the log's signature over the
+// canonical JSON of the payload, so that the entry can be verified offline.
+type cosignBundle struct {
+	SignedEntryTimestamp string              `json:"SignedEntryTimestamp"`
+	Payload              cosignBundlePayload `json:"Payload"`
+}
+
+// The fields are in the canonical JSON order.
+type cosignBundlePayload struct {
+	Body           string `json:"body"`
+	IntegratedTime int64  `json:"integratedTime"`
+	LogID          string `json:"logID"`
+	LogIndex       int64  `json:"logIndex"`
+}
+
+// The kind of the log entry in the body of a bundle, it is the entry of a signature over the hash of the payload.
+type rekorEntryKind struct {
+	Kind       string `json:"kind"`
+	APIVersion string `json:"apiVersion"`
+}
+
+// Verify that the image digest has a cosign signature from one of the trusted keys, or from a signing cert issued by one
+// of the trusted roots to one of the trusted identities. The signatures are stored in the image repository under the
+// sha256-<digest>.sig tag. The verification is done offline, the time of a keyless signature comes from its transparency
+// log bundle.
+func verifyCosign(repo name.Repository, digest string, trust *OrgImageTrust, material *trustMaterial, options []remote.Option) error {
+
+	sigTag := repo.Tag(strings.Replace(digest, ":", "-", 1) + ".sig")
+	sigImage, err := remote.Image(sigTag, options...)
+	if err != nil {
+		return errors.New(fmt.Sprintf("unable to get cosign signatures %v, error: %v", sigTag, err))
+	}
+
+	manifest, err := sigImage.Manifest()
+	if err != nil {
+		return errors.New(fmt.Sprintf("unable to read cosign signature manifest %v, error: %v", sigTag, err))
+	}
+
+	reasons := []string{}
+	for _, layerDesc := range manifest.Layers {
+		layer, err := sigImage.LayerByDigest(layerDesc.Digest)
+		if err != nil {
+			reasons = append(reasons, fmt.Sprintf("unable to get signature layer %v: %v", layerDesc.Digest, err))
+			continue
+		}
+		payload, err := readLayer(layer.Compressed)
+		if err != nil {
+			reasons = append(reasons, fmt.Sprintf("unable to read signature layer %v: %v", layerDesc.Digest, err))
+			continue
+		}
+
+		if err := verifyCosignSignature(digest, payload, layerDesc.Annotations, trust, material); err != nil {
+			reasons = append(reasons, err.Error())
+		} else {
+			glog.V(3).Infof("Verified cosign signature %v of image %v@%v", layerDesc.Digest, repo, digest)
+			return nil
+		}
+	}
+
+	if len(reasons) == 0 {
+		return errors.New(fmt.Sprintf("cosign signature manifest %v has no signatures", sigTag))
+	}
+	return errors.New(fmt.Sprintf("no trusted cosign signature: %v", strings.Join(reasons, "; ")))
+}
+
+func readLayer(open func() (io.ReadCloser, error)) ([]byte, error) {
+	rc, err := open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return io.ReadAll(rc)
+}
+
+// Verify a single cosign signature of the image digest. The signature is converted to a sigstore bundle and verified
+// with sigstore-go.
+func verifyCosignSignature(digest string, payload []byte, annotations map[string]string, trust *OrgImageTrust, material *trustMaterial) error {
+
+	var p cosignPayload
+	if err := json.Unmarshal(payload, &p); err != nil {
+		return errors.New(fmt.Sprintf("signature payload is not valid: %v", err))
+	} else if p.Critical.Type != COSIGN_SIGNATURE_TYPE {
+		return errors.New(fmt.Sprintf("signature payload type %v is not supported", p.Critical.Type))
+	} else if p.Critical.Image.DockerManifestDigest != digest {
+		return errors.New(fmt.Sprintf("signature is for digest %v", p.Critical.Image.DockerManifestDigest))
+	}
+
+	sig, err := base64.StdEncoding.DecodeString(annotations[COSIGN_SIGNATURE_ANNOTATION])
+	if err != nil || len(sig) == 0 {
+		return errors.New("signature annotation is missing or not valid")
+	}
+	payloadHash := sha256.Sum256(payload)
+	pb := &protobundle.Bundle{
+		MediaType:            COSIGN_SIGSTORE_BUNDLE_MEDIA_TYPE,
+		VerificationMaterial: &protobundle.VerificationMaterial{},
+		Content: &protobundle.Bundle_MessageSignature{MessageSignature: &protocommon.MessageSignature{
+			MessageDigest: &protocommon.HashOutput{Algorithm: protocommon.HashAlgorithm_SHA2_256, Digest: payloadHash[:]},
+			Signature:     sig,
+		}},
+	}
+
+	// The log entry bundle is required when the policy has transparency log keys, and for keyless signatures so that the
+	// signing cert can be verified at the time of signing.
+	var rekorRoot *root.TrustedRoot
+	if len(material.rekorKeys) != 0 {
+		bundleJson, ok := annotations[COSIGN_BUNDLE_ANNOTATION]
+		if !ok {
+			return errors.New("signature does not have a transparency log bundle")
+		}
+		entry, err := cosignTlogEntry(bundleJson)
+		if err != nil {
+			return err
+		}
+		pb.VerificationMaterial.TlogEntries = []*protorekor.TransparencyLogEntry{entry}
+		if rekorRoot, err = newSigstoreRoot(nil, nil, material.rekorKeys); err != nil {
+			return err
+		}
+	}
+
+	if certPem, ok := annotations[COSIGN_CERTIFICATE_ANNOTATION]; ok && certPem != "" {
+		// keyless signature, the signing cert must chain to a trusted root and be issued to a trusted identity
+		if rekorRoot == nil {
+			return errors.New("keyless signature cannot be verified without a transparency log bundle, rekorKeys must be specified")
+		}
+		return verifyCosignKeyless(pb, payload, certPem, annotations[COSIGN_CHAIN_ANNOTATION], trust, material)
+	}
+	return verifyCosignKey(pb, payload, rekorRoot, material)
+}
+
+// Verify a keyless signature bundle. The time of the signature is the time of its transparency log entry.
+func verifyCosignKeyless(pb *protobundle.Bundle, payload []byte, certPem string, chainPem string, trust *OrgImageTrust, material *trustMaterial) error {
+	certs := parsePemCerts([]byte(certPem))
+	if len(certs) == 0 {
+		return errors.New("signing certificate annotation is not valid")
+	}
+	chain := parsePemCerts([]byte(chainPem))
+
+	rawCerts := []*protocommon.X509Certificate{}
+	for _, cert := range append([]*x509.Certificate{certs[0]}, chain...) {
+		rawCerts = append(rawCerts, &protocommon.X509Certificate{RawBytes: cert.Raw})
+	}
+	pb.VerificationMaterial.Content = &protobundle.VerificationMaterial_X509CertificateChain{
+		X509CertificateChain: &protocommon.X509CertificateChain{Certificates: rawCerts},
+	}
+
+	trustedRoot, err := newSigstoreRoot(material.roots, chain, material.rekorKeys)
+	if err != nil {
+		return err
+	}
+	policy := []verify.PolicyOption{}
+	for _, id := range trust.Identities {
+		// An identity without an issuer matches any issuer, sigstore-go requires an issuer criteria.
+		issuerRegExp, subjectRegExp := "", ""
+		if id.Issuer == "" {
+			issuerRegExp = ".*"
+		}
+		if id.SubjectRegExp != "" {
+			subjectRegExp = anchorRegExp(id.SubjectRegExp)
+		}
+		certId, err := verify.NewShortCertificateIdentity(id.Issuer, issuerRegExp, id.Subject, subjectRegExp)
+		if err != nil {
+			return errors.New(fmt.Sprintf("identity %v is not valid: %v", id, err))
+		}
+		policy = append(policy, verify.WithCertificateIdentity(certId))
+	}
+	if len(policy) == 0 {
+		return errors.New("keyless signature cannot be verified without identities")
+	}
+
+	return verifySigstoreBundle(pb, payload, trustedRoot, policy, verify.WithTransparencyLog(1), verify.WithIntegratedTimestamps(1))
+}
+
+// Verify a signature bundle made with a key, the key must be one of the trusted keys. A bundle has the hint of a single
+// key, so it is verified with each key in turn.
+func verifyCosignKey(pb *protobundle.Bundle, payload []byte, rekorRoot *root.TrustedRoot, material *trustMaterial) error {
+	options := []verify.VerifierOption{verify.WithNoObserverTimestamps()}
+	if rekorRoot != nil {
+		options = []verify.VerifierOption{verify.WithTransparencyLog(1), verify.WithIntegratedTimestamps(1)}
+	}
+
+	var lastErr error
+	for i, key := range material.keys {
+		verifiers, err := keyVerifiers(key)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		for j, verifier := range verifiers {
+			hint := fmt.Sprintf("key%v-%v", i, j)
+			pb.VerificationMaterial.Content = &protobundle.VerificationMaterial_PublicKey{PublicKey: &protocommon.PublicKeyIdentifier{Hint: hint}}
+			trusted := root.TrustedMaterialCollection{root.NewTrustedPublicKeyMaterialFromMapping(map[string]*root.ExpiringKey{
+				hint: root.NewExpiringKey(verifier, time.Time{}, time.Time{}),
+			})}
+			if rekorRoot != nil {
+				trusted = append(trusted, rekorRoot)
+			}
+			if lastErr = verifySigstoreBundle(pb, payload, trusted, []verify.PolicyOption{verify.WithKey()}, options...); lastErr == nil {
+				return nil
+			}
+		}
+	}
+	if lastErr != nil {
+		return errors.New(fmt.Sprintf("signature is not from a trusted key: %v", lastErr))
+	}
+	return errors.New("signature is not from a trusted key")
+}
+
+func verifySigstoreBundle(pb *protobundle.Bundle, payload []byte, trusted root.TrustedMaterial, policy []verify.PolicyOption, options ...verify.VerifierOption) error {
+	b, err := bundle.NewBundle(pb)
+	if err != nil {
+		return errors.New(fmt.Sprintf("signature cannot be converted to a sigstore bundle: %v", err))
+	}
+	verifier, err := verify.NewVerifier(trusted, options...)
+	if err != nil {
+		return err
+	}
+	if _, err := verifier.Verify(b, verify.NewPolicy(verify.WithArtifact(bytes.NewReader(payload)), policy...)); err != nil {
+		return errors.New(fmt.Sprintf("signature verification failed: %v", err))
+	}
+	return nil
+}
+
+// Returns the verifiers of cosign signatures made with the key. ECDSA and RSA signatures are over the SHA-256 digest of
+// the payload, RSA signatures are PKCS #1 v1.5 or PSS.
+func keyVerifiers(key crypto.PublicKey) ([]signature.Verifier, error) {
+	switch k := key.(type) {
+	case *ecdsa.PublicKey:
+		v, err := signature.LoadECDSAVerifier(k, crypto.SHA256)
+		return []signature.Verifier{v}, err
+	case *rsa.PublicKey:
+		v1, err := signature.LoadRSAPKCS1v15Verifier(k, crypto.SHA256)
+		if err != nil {
+			return nil, err
+		}
+		v2, err := signature.LoadRSAPSSVerifier(k, crypto.SHA256, nil)
+		return []signature.Verifier{v1, v2}, err
+	case ed25519.PublicKey:
+		v, err := signature.LoadED25519Verifier(k)
+		return []signature.Verifier{v}, err
+	default:
+		return nil, errors.New(fmt.Sprintf("public key type %T is not supported", key))
+	}
+}
+
+// Returns the sigstore trusted root of the trusted roots and transparency log keys. The intermediates are the chain
+// certs of a signature, they are verified up to one of the roots.
+func newSigstoreRoot(roots []*x509.Certificate, intermediates []*x509.Certificate, rekorKeys []crypto.PublicKey) (*root.TrustedRoot, error) {
+	cas := []root.CertificateAuthority{}
+	for _, cert := range roots {
+		cas = append(cas, &root.FulcioCertificateAuthority{Root: cert, Intermediates: intermediates})
+	}
+
+	logs := map[string]*root.TransparencyLog{}
+	for _, key := range rekorKeys {
+		der, err := x509.MarshalPKIXPublicKey(key)
+		if err != nil {
+			return nil, errors.New(fmt.Sprintf("transparency log key is not valid: %v", err))
+		}
+		logID := sha256.Sum256(der)
+		logs[hex.EncodeToString(logID[:])] = &root.TransparencyLog{
+			ID:                  logID[:],
+			PublicKey:           key,
+			HashFunc:            crypto.SHA256,
+			SignatureHashFunc:   crypto.SHA256,
+			ValidityPeriodStart: time.Unix(0, 0),
+		}
+	}
+	return root.NewTrustedRoot(root.TrustedRootMediaType01, cas, nil, nil, logs)
+}
+
+// Returns the transparency log entry of a cosign bundle annotation.
+func cosignTlogEntry(bundleJson string) (*protorekor.TransparencyLogEntry, error) {
+	var cb cosignBundle
+	if err := json.Unmarshal([]byte(bundleJson), &cb); err != nil {
+		return nil, errors.New(fmt.Sprintf("transparency log bundle is not valid: %v", err))
+	}
+	set, err := base64.StdEncoding.DecodeString(cb.SignedEntryTimestamp)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("transparency log signed entry timestamp is not valid: %v", err))
+	}
+	logID, err := hex.DecodeString(cb.Payload.LogID)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("transparency log ID is not valid: %v", err))
+	}
+	body, err := base64.StdEncoding.DecodeString(cb.Payload.Body)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("transparency log entry is not valid: %v", err))
+	}
+	var kind rekorEntryKind
+	if err := json.Unmarshal(body, &kind); err != nil {
+		return nil, errors.New(fmt.Sprintf("transparency log entry is not valid: %v", err))
+	}
+
+	return &protorekor.TransparencyLogEntry{
+		LogIndex:          cb.Payload.LogIndex,
+		LogId:             &protocommon.LogId{KeyId: logID},
+		KindVersion:       &protorekor.KindVersion{Kind: kind.Kind, Version: kind.APIVersion},
+		IntegratedTime:    cb.Payload.IntegratedTime,
+		InclusionPromise:  &protorekor.InclusionPromise{SignedEntryTimestamp: set},
+		CanonicalizedBody: body,
+	}, nil
+}
+
+func parsePemCerts(data []byte) []*x509.Certificate {
+	certs := []*x509.Certificate{}
+	for block, rest := pem.Decode(data); block != nil; block, rest = pem.Decode(rest) {
+		if block.Type == "CERTIFICATE" {
+			if cert, err := x509.ParseCertificate(block.Bytes); err == nil {
+				certs = append(certs, cert)
+			}
+		}
+	}
+	return certs
+}
@@ -2,25 +2,28 @@ package imagefetch
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	docker "github.com/fsouza/go-dockerclient"
 	"github.com/golang/glog"
 	"github.com/open-horizon/anax/config"
 	"github.com/open-horizon/anax/containermessage"
+	"github.com/open-horizon/anax/containerruntime"
 	"github.com/open-horizon/anax/events"
 	"github.com/open-horizon/anax/persistence"
+	"github.com/open-horizon/anax/tracing"
 	"github.com/open-horizon/anax/worker"
-	bolt "go.etcd.io/bbolt"
 	"strings"
+	"time"
 )
 
 type ImageFetchWorker struct {
 	worker.BaseWorker // embedded field
-	db                *bolt.DB
-	client            *docker.Client
+	db                persistence.AgentDatabase
+	client            containerruntime.ContainerRuntime
 }
 
-func NewImageFetchWorker(name string, config *config.HorizonConfig, db *bolt.DB) *ImageFetchWorker {
+func NewImageFetchWorker(name string, config *config.HorizonConfig, db persistence.AgentDatabase) *ImageFetchWorker {
 
 	// do not start this container if the the node is registered and the type is cluster
 	dev, _ := persistence.FindExchangeDevice(db)
@@ -28,16 +31,21 @@ func NewImageFetchWorker(name string, config *config.HorizonConfig, db *bolt.DB)
 		return nil
 	}
 
-	var client *docker.Client
+	var client containerruntime.ContainerRuntime
 	var err error
 	if config.Edge.DockerEndpoint != "" {
-		client, err = docker.NewClient(config.Edge.DockerEndpoint)
+		client, err = containerruntime.NewContainerRuntime(config.Edge.DockerEndpoint, config.Edge.ContainerRuntime)
 		if err != nil {
 			glog.Errorf("Failed to instantiate docker Client: %v", err)
 			panic("Unable to instantiate docker Client")
 		}
 	}
 
+	// the policy is read again for every fetch, so that a bad policy file can be fixed without restarting the agent
+	if _, err := LoadImageTrustPolicy(config.Edge.ImageTrustPolicyPath); err != nil {
+		glog.Errorf("The image trust policy is not valid, images of the orgs that are verified will fail to load: %v", err)
+	}
+
 	worker := &ImageFetchWorker{
 		BaseWorker: worker.NewBaseWorker(name, config, nil),
 		db:         db,
@@ -140,7 +148,7 @@ func AppendDockerAuth(dockerAuths map[string][]docker.AuthConfiguration, auth do
 }
 
 // append the auth attribute to the given auth maps
-func authAttributes(db *bolt.DB, dockerAuthConfigurations map[string][]docker.AuthConfiguration) error {
+func authAttributes(db persistence.AgentDatabase, dockerAuthConfigurations map[string][]docker.AuthConfiguration) error {
 
 	// assemble credentials from attributes
 	attributes, err := persistence.FindApplicableAttributes(db, "", "")
@@ -192,7 +200,8 @@ func processDeployment(cfg *config.HorizonConfig, containerConfig events.Contain
 	return pemFiles, &deploymentDesc, nil
 }
 
-func processFetch(cfg *config.HorizonConfig, client *docker.Client, db *bolt.DB, deploymentDesc *containermessage.DeploymentDescription, imageDockerAuths []events.ImageDockerAuth) error {
+// Fetch the images of the deployment and verify their signatures with the image trust policy of the org that owns the service.
+func processFetch(cfg *config.HorizonConfig, client containerruntime.ContainerClient, db persistence.AgentDatabase, deploymentDesc *containermessage.DeploymentDescription, imageDockerAuths []events.ImageDockerAuth, org string) error {
 	if client == nil {
 		return fmt.Errorf("Docker client is nil. Please make sure DockerEndpoint is set in the configuration file.")
 	}
@@ -211,17 +220,28 @@ func processFetch(cfg *config.HorizonConfig, client *docker.Client, db *bolt.DB,
 		glog.Errorf("Failed to fetch authentication facts from the attributes before processing packages and / or Docker pulls: %v. Continuing anyway", err)
 	}
 
-	return fetchImage(cfg, client, db, deploymentDesc, dockerAuthConfigurations)
+	if err := fetchImage(cfg, client, db, deploymentDesc, dockerAuthConfigurations); err != nil {
+		return err
+	}
+	return verifyImages(cfg, client, deploymentDesc, dockerAuthConfigurations, org)
 }
 
-func fetchImage(cfg *config.HorizonConfig, client *docker.Client, db *bolt.DB, deploymentDesc *containermessage.DeploymentDescription, dockerAuthConfigurations map[string][]docker.AuthConfiguration) error {
+func fetchImage(cfg *config.HorizonConfig, client containerruntime.ContainerClient, db persistence.AgentDatabase, deploymentDesc *containermessage.DeploymentDescription, dockerAuthConfigurations map[string][]docker.AuthConfiguration) error {
 
 	skipCheckFn := SkipCheckFn(client)
 	// using Docker pull (newer option, uses docker client to pull images from repos in image names in deployment description)
 	// Note: we don't want to make this a fallback option, it's a potential security vector
 	glog.V(3).Infof("Using Docker pull mechanism to retrieve and load Docker images into local registry")
 
+	start := time.Now()
 	fetchErr := pullImageFromRepos(cfg.Edge, dockerAuthConfigurations, client, &skipCheckFn, deploymentDesc)
+
+	result := "success"
+	if fetchErr != nil {
+		result = "failure"
+	}
+	ImageFetchDuration.WithLabelValues(result).Observe(time.Since(start).Seconds())
+
 	return fetchErr
 }
 
@@ -234,7 +254,7 @@ func fetchImage(cfg *config.HorizonConfig, client *docker.Client, db *bolt.DB, d
 // 2) from the dockerAuthConfigurations
 // 3) from the config.DockerCredFilePath file.
 // 4) from /root/.docker/config.json if 3) is not set.
-func ProcessImageFetch(cfg *config.HorizonConfig, client *docker.Client, containerConfig *events.ContainerConfig, dockerAuthConfigurations map[string][]docker.AuthConfiguration) error {
+func ProcessImageFetch(cfg *config.HorizonConfig, client containerruntime.ContainerClient, containerConfig *events.ContainerConfig, dockerAuthConfigurations map[string][]docker.AuthConfiguration) error {
 
 	dockerAuthNew := make(map[string][]docker.AuthConfiguration, 0)
 
@@ -294,9 +314,22 @@ func (b *ImageFetchWorker) CommandHandler(command worker.Command) bool {
 				return true
 			}
 
-			if fetchErr := processFetch(b.Config, b.client, b.db, deploymentDesc, lc.ContainerConfig().ImageDockerAuths); fetchErr != nil {
+			// Image fetches for agreements are part of the agreement's trace. The span of a service launch does not
+			// record anything.
+			agreementId := ""
+			if alc, ok := cmd.LaunchContext.(*events.AgreementLaunchContext); ok {
+				agreementId = alc.AgreementId
+			}
+			span := tracing.StartSpan(agreementId, "imagefetch.FetchImages", nil)
+			fetchErr := processFetch(b.Config, b.client, b.db, deploymentDesc, lc.ContainerConfig().ImageDockerAuths, launchContextOrg(b.db, cmd.LaunchContext))
+			tracing.EndSpan(span, fetchErr)
+
+			if fetchErr != nil {
 				var id events.EventId
-				if strings.Contains(fetchErr.Error(), "Auth error") {
+				var verifErr ImageVerificationError
+				if errors.As(fetchErr, &verifErr) {
+					id = events.IMAGE_SIG_VERIF_ERROR
+				} else if strings.Contains(fetchErr.Error(), "Auth error") {
 					id = events.IMAGE_FETCH_AUTH_ERROR
 				} else {
 					id = events.IMAGE_FETCH_ERROR
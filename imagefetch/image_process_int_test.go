@@ -24,7 +24,6 @@ import (
 	"github.com/open-horizon/anax/events"
 	"github.com/open-horizon/anax/persistence"
 	"github.com/open-horizon/rsapss-tool/sign"
-	bolt "go.etcd.io/bbolt"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -156,13 +155,13 @@ func tConfig(t *testing.T, dir string) *config.HorizonConfig {
 	return &cfg
 }
 
-func setup(t *testing.T) (string, *bolt.DB, error) {
+func setup(t *testing.T) (string, persistence.AgentDatabase, error) {
 	dir, err := os.TempDir("", "container-")
 	if err != nil {
 		return "", nil, err
 	}
 
-	db, err := bolt.Open(path.Join(dir, "anax-int.db"), 0600, &bolt.Options{Timeout: 10 * time.Second})
+	db, err := persistence.OpenBoltDatabase(path.Join(dir, "anax-int.db"))
 	if err != nil {
 		return dir, nil, err
 	}
@@ -223,7 +222,7 @@ func setup(t *testing.T) (string, *bolt.DB, error) {
 	return dir, db, nil
 }
 
-func tWorker(config *config.HorizonConfig, db *bolt.DB) *ImageFetchWorker {
+func tWorker(config *config.HorizonConfig, db persistence.AgentDatabase) *ImageFetchWorker {
 	tw := NewImageFetchWorker("tworker", config, db)
 	return tw
 }
@@ -252,7 +251,7 @@ func tCleanup(t *testing.T, worker *ImageFetchWorker, images []string) {
 
 	t.Logf("Cleaning up: %v", images)
 	for _, image := range images {
-		err := worker.client.RemoveImage(image)
+		err := worker.client.DockerClient().RemoveImage(image)
 
 		if err != nil {
 			t.Errorf("ERROR: cleanup failed for docker image, it was supposed to be there and perhaps wasn't. Expected to find: %v", image)
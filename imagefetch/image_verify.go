@@ -0,0 +1,167 @@
+package imagefetch
+
+import (
+	"errors"
+	"fmt"
+	docker "github.com/fsouza/go-dockerclient"
+	"github.com/golang/glog"
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/open-horizon/anax/config"
+	"github.com/open-horizon/anax/containermessage"
+	"github.com/open-horizon/anax/containerruntime"
+	"github.com/open-horizon/anax/cutil"
+	"github.com/open-horizon/anax/events"
+	"github.com/open-horizon/anax/persistence"
+	"strings"
+)
+
+// The error returned when a container image does not have a trusted signature. It fails the agreement or the service
+// that the image belongs to.
+type ImageVerificationError struct {
+	Image  string
+	Reason string
+}
+
+func (e ImageVerificationError) Error() string {
+	return fmt.Sprintf("image %v is not trusted: %v", e.Image, e.Reason)
+}
+
+// Verify the signatures of the container images in the deployment with the image trust policy of the org that owns the
+// service. The images must have been pulled, the signature of the digest that was pulled is verified. The signatures are
+// fetched from the image registries with the same auths as the images.
+func verifyImages(cfg *config.HorizonConfig, client containerruntime.ContainerClient, deploymentDesc *containermessage.DeploymentDescription, authConfigs map[string][]docker.AuthConfiguration, org string) error {
+
+	policy, err := LoadImageTrustPolicy(cfg.Edge.ImageTrustPolicyPath)
+	if err != nil {
+		return ImageVerificationError{Image: "*", Reason: err.Error()}
+	}
+
+	// The images of a service whose org is not known cannot be allowed in by the trust of another org.
+	if org == "" && policy.EnforcesAnyOrg() {
+		return ImageVerificationError{Image: "*", Reason: "the org of the service is not known and the image trust policy enforces the signatures of some orgs"}
+	}
+
+	trust := policy.ForOrg(org)
+	if trust == nil {
+		glog.V(5).Infof("Images of org %v are not verified", org)
+		return nil
+	}
+
+	material, err := readTrustMaterial(cfg.UserPublicKeyPath(), trust)
+	if err != nil {
+		return ImageVerificationError{Image: "*", Reason: err.Error()}
+	}
+
+	for serviceName, service := range deploymentDesc.Services {
+		if service == nil {
+			continue
+		}
+		if err := verifyImage(client, service.Image, authConfigs, trust, material); err != nil {
+			if trust.IsAudit() {
+				glog.Warningf("Image %v for service %v of org %v is not trusted, starting it anyway because verification is %v: %v", service.Image, serviceName, org, IMAGE_VERIFY_AUDIT, err)
+				continue
+			}
+			glog.Errorf("Image %v for service %v of org %v is not trusted: %v", service.Image, serviceName, org, err)
+			return ImageVerificationError{Image: service.Image, Reason: err.Error()}
+		}
+		glog.V(3).Infof("Image %v for service %v of org %v is trusted", service.Image, serviceName, org)
+	}
+	return nil
+}
+
+// Verify the signatures of the digest of a pulled image. The image is trusted if it has a signature in one of the allowed
+// formats that verifies.
+func verifyImage(client containerruntime.ContainerClient, image string, authConfigs map[string][]docker.AuthConfiguration, trust *OrgImageTrust, material *trustMaterial) error {
+
+	ref, err := name.ParseReference(image)
+	if err != nil {
+		return errors.New(fmt.Sprintf("unable to parse image name, error: %v", err))
+	}
+	digest, err := pulledImageDigest(client, image, ref)
+	if err != nil {
+		return err
+	}
+
+	domain, _, _, _ := cutil.ParseDockerImagePath(image)
+	authenticator, err := registryAuth(ref.Context().Digest(digest), authsForDomain(authConfigs, domain))
+	if err != nil {
+		return err
+	}
+
+	reasons := []string{}
+	if trust.AllowsFormat(SIG_FORMAT_COSIGN) {
+		if err := verifyCosign(ref.Context(), digest, trust, material, []remote.Option{remote.WithAuth(authenticator)}); err == nil {
+			return nil
+		} else {
+			reasons = append(reasons, err.Error())
+		}
+	}
+	if trust.AllowsFormat(SIG_FORMAT_NOTATION) {
+		if err := verifyNotation(ref.Context(), digest, trust, material, authenticator); err == nil {
+			return nil
+		} else {
+			reasons = append(reasons, err.Error())
+		}
+	}
+	return errors.New(strings.Join(reasons, ". "))
+}
+
+// Returns the repo digest of the pulled image, which is the digest that was signed.
+func pulledImageDigest(client containerruntime.ContainerClient, image string, ref name.Reference) (string, error) {
+	if d, ok := ref.(name.Digest); ok {
+		return d.DigestStr(), nil
+	}
+
+	if client == nil {
+		return "", errors.New("container client is nil")
+	}
+	imageInfo, err := client.InspectImage(image)
+	if err != nil {
+		return "", errors.New(fmt.Sprintf("unable to inspect the pulled image, error: %v", err))
+	}
+	for _, repoDigest := range imageInfo.RepoDigests {
+		if d, err := name.NewDigest(repoDigest); err == nil && d.Context().Name() == ref.Context().Name() {
+			return d.DigestStr(), nil
+		}
+	}
+	return "", errors.New("the pulled image does not have a digest from its repository")
+}
+
+// Returns the first auth that can read the image. The auths are tried in order, followed by anonymous access.
+func registryAuth(ref name.Digest, auths []docker.AuthConfiguration) (authn.Authenticator, error) {
+	authenticators := []authn.Authenticator{}
+	for _, auth := range auths {
+		authenticators = append(authenticators, authn.FromConfig(authn.AuthConfig{Username: auth.Username, Password: auth.Password}))
+	}
+	authenticators = append(authenticators, authn.Anonymous)
+
+	var err error
+	for _, a := range authenticators {
+		if _, err = remote.Head(ref, remote.WithAuth(a)); err == nil {
+			return a, nil
+		}
+	}
+	return nil, errors.New(fmt.Sprintf("unable to read %v from its registry, error: %v", ref, err))
+}
+
+// Returns the org of the service that the launch context deploys, it selects the image trust of the images.
+func launchContextOrg(db persistence.AgentDatabase, launchContext interface{}) string {
+	switch lc := launchContext.(type) {
+	case *events.AgreementLaunchContext:
+		if db == nil {
+			return ""
+		}
+		if ags, err := persistence.FindEstablishedAgreements(db, lc.AgreementProtocol, []persistence.EAFilter{persistence.UnarchivedEAFilter(), persistence.IdEAFilter(lc.AgreementId)}); err != nil {
+			glog.Errorf("Unable to retrieve agreement %v from database, error %v", lc.AgreementId, err)
+		} else if len(ags) == 1 {
+			return ags[0].RunningWorkload.Org
+		}
+	case *events.ContainerLaunchContext:
+		if len(lc.ServicePath) != 0 {
+			return lc.ServicePath[len(lc.ServicePath)-1].Org
+		}
+	}
+	return ""
+}
@@ -0,0 +1,382 @@
+//go:build unit
+// +build unit
+
+package imagefetch
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/static"
+	"github.com/notaryproject/notation-go"
+	"github.com/notaryproject/notation-go/signer"
+	"github.com/open-horizon/anax/config"
+	"github.com/open-horizon/anax/containermessage"
+	"github.com/sigstore/sigstore-go/pkg/fulcio/certificate"
+)
+
+func Test_ImageTrustPolicy(t *testing.T) {
+	dir := t.TempDir()
+
+	policyFile := path.Join(dir, "policy.json")
+	os.WriteFile(policyFile, []byte(`{"orgs": {"org1": {"keys": ["k.pem"]}, "*": {"verification": "audit", "roots": ["r.pem"], "identities": [{"subject": "CN=signer"}]}, "org3": {"verification": "skip"}}}`), 0600)
+	if policy, err := LoadImageTrustPolicy(policyFile); err != nil {
+		t.Errorf("Error loading policy: %v", err)
+	} else if trust := policy.ForOrg("org1"); trust == nil || trust.IsAudit() || trust.Keys[0] != "k.pem" {
+		t.Errorf("Wrong trust for org1: %v", trust)
+	} else if trust := policy.ForOrg("org2"); trust == nil || !trust.IsAudit() {
+		t.Errorf("Wrong trust for org2: %v", trust)
+	} else if trust := policy.ForOrg("org3"); trust != nil {
+		t.Errorf("org3 should not be verified: %v", trust)
+	}
+
+	os.WriteFile(policyFile, []byte(`{"orgs": {"org1": {"signatureFormats": ["gpg"], "keys": ["k.pem"]}}}`), 0600)
+	if _, err := LoadImageTrustPolicy(policyFile); err == nil {
+		t.Errorf("Unsupported signature format should be rejected")
+	}
+
+	os.WriteFile(policyFile, []byte(`{"orgs": {"org1": {"verification": "enforce"}}}`), 0600)
+	if _, err := LoadImageTrustPolicy(policyFile); err == nil {
+		t.Errorf("Policy without keys or roots should be rejected")
+	}
+
+	os.WriteFile(policyFile, []byte(`{"orgs": {"org1": {"roots": ["r.pem"]}}}`), 0600)
+	if _, err := LoadImageTrustPolicy(policyFile); err == nil {
+		t.Errorf("Policy with roots and without identities should be rejected")
+	}
+
+	os.WriteFile(policyFile, []byte(`{"orgs": {"org1": {"roots": ["r.pem"], "identities": [{"issuer": "https://issuer"}]}}}`), 0600)
+	if _, err := LoadImageTrustPolicy(policyFile); err == nil {
+		t.Errorf("Identity without a subject should be rejected")
+	}
+
+	if policy, err := LoadImageTrustPolicy(""); err != nil || policy.ForOrg("org1") != nil || policy.EnforcesAnyOrg() {
+		t.Errorf("No policy file should verify nothing, error: %v", err)
+	}
+
+	// The images of a service without an org fail when any org is enforced.
+	cfg := &config.HorizonConfig{Edge: config.Config{ImageTrustPolicyPath: policyFile}}
+	deployment := &containermessage.DeploymentDescription{Services: map[string]*containermessage.Service{"svc": {Image: "registry.example.com/svc:1.0"}}}
+	os.WriteFile(policyFile, []byte(`{"orgs": {"org1": {"keys": ["k.pem"]}, "*": {"verification": "skip"}}}`), 0600)
+	if err := verifyImages(cfg, nil, deployment, nil, ""); err == nil {
+		t.Errorf("Images of a service without an org should fail when an org is enforced")
+	}
+	os.WriteFile(policyFile, []byte(`{"orgs": {"org1": {"verification": "audit", "keys": ["k.pem"]}, "*": {"verification": "skip"}}}`), 0600)
+	if err := verifyImages(cfg, nil, deployment, nil, ""); err != nil {
+		t.Errorf("Images of a service without an org should not fail when no org is enforced, error: %v", err)
+	}
+
+	trust := OrgImageTrust{Identities: []SignerIdentity{{Issuer: "https://issuer", SubjectRegExp: "https://github.com/myorg/.*"}}}
+	if !trust.MatchesIdentity([]string{"https://github.com/myorg/repo/.github/workflows/build.yml@refs/heads/main"}, "https://issuer") {
+		t.Errorf("Identity should match")
+	} else if trust.MatchesIdentity([]string{"https://github.com/other/repo"}, "https://issuer") {
+		t.Errorf("Identity with other subject should not match")
+	} else if trust.MatchesIdentity([]string{"https://github.com/myorg/repo"}, "https://other") {
+		t.Errorf("Identity with other issuer should not match")
+	} else if trust.MatchesIdentity([]string{"https://evil.com/?https://github.com/myorg/repo"}, "https://issuer") {
+		t.Errorf("Subject regexp should match the whole subject")
+	}
+
+	if (&OrgImageTrust{}).MatchesIdentity([]string{"CN=signer"}, "CN=root") {
+		t.Errorf("No identity should match when there are none")
+	}
+}
+
+func Test_verifyImage_cosign_key(t *testing.T) {
+	reg := newTestRegistry(t)
+	image, digest := pushRandomImage(t, reg, "org/svc:1.0")
+
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	otherKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	payload := cosignTestPayload(digest)
+	pushCosignSignature(t, image, digest, payload, map[string]string{COSIGN_SIGNATURE_ANNOTATION: signASN1(t, key, payload)})
+
+	trust := &OrgImageTrust{Keys: []string{"k.pem"}}
+	if err := verifyImage(nil, image.String(), nil, trust, &trustMaterial{keys: []crypto.PublicKey{&key.PublicKey}}); err != nil {
+		t.Errorf("Image signed with the trusted key should verify: %v", err)
+	}
+	if err := verifyImage(nil, image.String(), nil, trust, &trustMaterial{keys: []crypto.PublicKey{&otherKey.PublicKey}}); err == nil {
+		t.Errorf("Image signed with another key should not verify")
+	} else if !strings.Contains(err.Error(), "not from a trusted key") {
+		t.Errorf("Wrong error: %v", err)
+	}
+
+	// an image without signatures
+	unsigned, _ := pushRandomImage(t, reg, "org/unsigned:1.0")
+	if err := verifyImage(nil, unsigned.String(), nil, trust, &trustMaterial{keys: []crypto.PublicKey{&key.PublicKey}}); err == nil {
+		t.Errorf("Unsigned image should not verify")
+	}
+
+	// with transparency log keys the signature must have a log entry bundle
+	rekorKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err := verifyImage(nil, image.String(), nil, trust, &trustMaterial{keys: []crypto.PublicKey{&key.PublicKey}, rekorKeys: []crypto.PublicKey{&rekorKey.PublicKey}}); err == nil {
+		t.Errorf("Signature without a transparency log bundle should not verify")
+	}
+	logged, loggedDigest := pushRandomImage(t, reg, "org/logged:1.0")
+	loggedPayload := cosignTestPayload(loggedDigest)
+	loggedSig := signASN1(t, key, loggedPayload)
+	pushCosignSignature(t, logged, loggedDigest, loggedPayload, map[string]string{
+		COSIGN_SIGNATURE_ANNOTATION: loggedSig,
+		COSIGN_BUNDLE_ANNOTATION:    cosignTestBundle(t, rekorKey, loggedPayload, loggedSig, testPublicKeyPem(t, &key.PublicKey), time.Now().Unix()),
+	})
+	if err := verifyImage(nil, logged.String(), nil, trust, &trustMaterial{keys: []crypto.PublicKey{&key.PublicKey}, rekorKeys: []crypto.PublicKey{&rekorKey.PublicKey}}); err != nil {
+		t.Errorf("Signature with a transparency log bundle should verify: %v", err)
+	}
+
+	// the signature of another digest is not accepted
+	otherPayload := cosignTestPayload("sha256:" + strings.Repeat("0", 64))
+	other, otherDigest := pushRandomImage(t, reg, "org/other:1.0")
+	pushCosignSignature(t, other, otherDigest, otherPayload, map[string]string{COSIGN_SIGNATURE_ANNOTATION: signASN1(t, key, otherPayload)})
+	if err := verifyImage(nil, other.String(), nil, trust, &trustMaterial{keys: []crypto.PublicKey{&key.PublicKey}}); err == nil {
+		t.Errorf("Signature of another digest should not verify")
+	}
+}
+
+func Test_verifyImage_cosign_keyless(t *testing.T) {
+	reg := newTestRegistry(t)
+	image, digest := pushRandomImage(t, reg, "org/svc:2.0")
+
+	rootKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	root := newTestCert(t, rootKey, nil, nil, true, "root", nil, "")
+	signKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	signCert := newTestCert(t, signKey, root, rootKey, false, "", []string{"dev@myorg.com"}, "https://accounts.example.com")
+	rekorKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+
+	payload := cosignTestPayload(digest)
+	sig := signASN1(t, signKey, payload)
+	certPem := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: signCert.Raw}))
+	annotations := map[string]string{
+		COSIGN_SIGNATURE_ANNOTATION:   sig,
+		COSIGN_CERTIFICATE_ANNOTATION: certPem,
+		COSIGN_BUNDLE_ANNOTATION:      cosignTestBundle(t, rekorKey, payload, sig, certPem, time.Now().Unix()),
+	}
+	pushCosignSignature(t, image, digest, payload, annotations)
+
+	roots := []*x509.Certificate{root}
+	material := &trustMaterial{roots: roots, rekorKeys: []crypto.PublicKey{&rekorKey.PublicKey}}
+
+	trust := &OrgImageTrust{SignatureFormats: []string{SIG_FORMAT_COSIGN}, Roots: []string{"r.pem"}, Identities: []SignerIdentity{{Issuer: "https://accounts.example.com", Subject: "dev@myorg.com"}}}
+	if err := verifyImage(nil, image.String(), nil, trust, material); err != nil {
+		t.Errorf("Keyless signature should verify: %v", err)
+	}
+
+	trust.Identities[0].Subject = "other@myorg.com"
+	if err := verifyImage(nil, image.String(), nil, trust, material); err == nil {
+		t.Errorf("Keyless signature of another identity should not verify")
+	}
+
+	// the subject regexp must match the whole subject, and an identity without an issuer matches any issuer
+	trust.Identities[0] = SignerIdentity{SubjectRegExp: "dev@myorg"}
+	if err := verifyImage(nil, image.String(), nil, trust, material); err == nil {
+		t.Errorf("Keyless signature with a partly matching subject should not verify")
+	}
+	trust.Identities[0] = SignerIdentity{SubjectRegExp: ".*@myorg.com"}
+	if err := verifyImage(nil, image.String(), nil, trust, material); err != nil {
+		t.Errorf("Keyless signature with a matching subject should verify: %v", err)
+	}
+
+	// no signing cert is trusted without identities
+	trust.Identities = nil
+	if err := verifyImage(nil, image.String(), nil, trust, material); err == nil {
+		t.Errorf("Keyless signature without identities should not verify")
+	}
+	trust.Identities = []SignerIdentity{{Issuer: "https://accounts.example.com"}}
+
+	// without the transparency log key the signing time is not known
+	trust.Identities[0].Subject = "dev@myorg.com"
+	if err := verifyImage(nil, image.String(), nil, trust, &trustMaterial{roots: roots}); err == nil {
+		t.Errorf("Keyless signature without a transparency log key should not verify")
+	}
+
+	// a bundle signed by another log
+	otherRekorKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err := verifyImage(nil, image.String(), nil, trust, &trustMaterial{roots: roots, rekorKeys: []crypto.PublicKey{&otherRekorKey.PublicKey}}); err == nil {
+		t.Errorf("Bundle from an untrusted log should not verify")
+	}
+}
+
+func Test_verifyImage_notation(t *testing.T) {
+	reg := newTestRegistry(t)
+	image, _ := pushRandomImage(t, reg, "org/svc:3.0")
+
+	rootKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	root := newTestCert(t, rootKey, nil, nil, true, "root", nil, "")
+	signKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	signCert := newTestCert(t, signKey, root, rootKey, false, "signer", nil, "")
+	pushNotationSignature(t, image, signKey, []*x509.Certificate{signCert, root})
+
+	material := &trustMaterial{roots: []*x509.Certificate{root}}
+
+	trust := &OrgImageTrust{SignatureFormats: []string{SIG_FORMAT_NOTATION}, Roots: []string{"r.pem"}, Identities: []SignerIdentity{{Subject: "CN=signer"}}}
+	if err := verifyImage(nil, image.String(), nil, trust, material); err != nil {
+		t.Errorf("Notation signature should verify: %v", err)
+	}
+
+	trust.Identities[0].Subject = "CN=other"
+	if err := verifyImage(nil, image.String(), nil, trust, material); err == nil {
+		t.Errorf("Notation signature of another identity should not verify")
+	}
+	trust.Identities[0].Subject = "CN=signer"
+
+	otherRootKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	otherRoot := newTestCert(t, otherRootKey, nil, nil, true, "other root", nil, "")
+	if err := verifyImage(nil, image.String(), nil, trust, &trustMaterial{roots: []*x509.Certificate{otherRoot}}); err == nil {
+		t.Errorf("Notation signature from an untrusted root should not verify")
+	}
+
+	// the image is not signed with cosign
+	trust.SignatureFormats = []string{SIG_FORMAT_COSIGN}
+	if err := verifyImage(nil, image.String(), nil, trust, material); err == nil {
+		t.Errorf("Image without cosign signature should not verify")
+	}
+}
+
+func newTestRegistry(t *testing.T) string {
+	server := httptest.NewServer(registry.New(registry.WithReferrersSupport(true)))
+	t.Cleanup(server.Close)
+	u, _ := url.Parse(server.URL)
+	return u.Host
+}
+
+// Push a random image and returns its digest reference and digest.
+func pushRandomImage(t *testing.T, reg string, repoTag string) (name.Digest, string) {
+	img, err := random.Image(256, 1)
+	if err != nil {
+		t.Fatalf("Error creating image: %v", err)
+	}
+	tag, _ := name.NewTag(fmt.Sprintf("%v/%v", reg, repoTag))
+	if err := remote.Write(tag, img); err != nil {
+		t.Fatalf("Error pushing image: %v", err)
+	}
+	digest, _ := img.Digest()
+	return tag.Context().Digest(digest.String()), digest.String()
+}
+
+func cosignTestPayload(digest string) []byte {
+	return []byte(fmt.Sprintf(`{"critical":{"identity":{"docker-reference":"svc"},"image":{"docker-manifest-digest":"%v"},"type":"%v"},"optional":null}`, digest, COSIGN_SIGNATURE_TYPE))
+}
+
+func signASN1(t *testing.T, key *ecdsa.PrivateKey, data []byte) string {
+	digest := sha256.Sum256(data)
+	sig, err := ecdsa.SignASN1(rand.Reader, key, digest[:])
+	if err != nil {
+		t.Fatalf("Error signing: %v", err)
+	}
+	return base64.StdEncoding.EncodeToString(sig)
+}
+
+func pushCosignSignature(t *testing.T, image name.Digest, digest string, payload []byte, annotations map[string]string) {
+	sigImage, err := mutate.Append(empty.Image, mutate.Addendum{
+		Layer:       static.NewLayer(payload, "application/vnd.dev.cosign.simplesigning.v1+json"),
+		Annotations: annotations,
+	})
+	if err != nil {
+		t.Fatalf("Error creating signature image: %v", err)
+	}
+	if err := remote.Write(image.Context().Tag(strings.Replace(digest, ":", "-", 1)+".sig"), sigImage); err != nil {
+		t.Fatalf("Error pushing signature: %v", err)
+	}
+}
+
+// Returns the bundle of the hashedrekord log entry of a signature, the verifier is the PEM of the signing key or cert.
+func cosignTestBundle(t *testing.T, rekorKey *ecdsa.PrivateKey, payload []byte, sig string, verifierPem string, integratedTime int64) string {
+	payloadHash := sha256.Sum256(payload)
+	body := fmt.Sprintf(`{"apiVersion":"0.0.1","kind":"hashedrekord","spec":{"data":{"hash":{"algorithm":"sha256","value":"%v"}},"signature":{"content":"%v","publicKey":{"content":"%v"}}}}`,
+		hex.EncodeToString(payloadHash[:]), sig, base64.StdEncoding.EncodeToString([]byte(verifierPem)))
+	rekorDer, _ := x509.MarshalPKIXPublicKey(&rekorKey.PublicKey)
+	logID := sha256.Sum256(rekorDer)
+	bundle := cosignBundle{Payload: cosignBundlePayload{
+		Body:           base64.StdEncoding.EncodeToString([]byte(body)),
+		IntegratedTime: integratedTime,
+		LogID:          hex.EncodeToString(logID[:]),
+		LogIndex:       1,
+	}}
+	canonical, _ := json.Marshal(bundle.Payload)
+	bundle.SignedEntryTimestamp = signASN1(t, rekorKey, canonical)
+	bundleJson, _ := json.Marshal(bundle)
+	return string(bundleJson)
+}
+
+func newTestCert(t *testing.T, key *ecdsa.PrivateKey, parent *x509.Certificate, parentKey *ecdsa.PrivateKey, isCA bool, cn string, emails []string, issuer string) *x509.Certificate {
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: cn},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		EmailAddresses:        emails,
+		BasicConstraintsValid: true,
+		IsCA:                  isCA,
+	}
+	if isCA {
+		template.KeyUsage = x509.KeyUsageCertSign
+	} else {
+		template.KeyUsage = x509.KeyUsageDigitalSignature
+		template.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning}
+	}
+	if issuer != "" {
+		value, _ := asn1.MarshalWithParams(issuer, "utf8")
+		template.ExtraExtensions = []pkix.Extension{{Id: certificate.OIDIssuerV2, Value: value}}
+	}
+	if parent == nil {
+		parent, parentKey = template, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	if err != nil {
+		t.Fatalf("Error creating cert: %v", err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	return cert
+}
+
+func testPublicKeyPem(t *testing.T, key crypto.PublicKey) string {
+	der, err := x509.MarshalPKIXPublicKey(key)
+	if err != nil {
+		t.Fatalf("Error encoding key: %v", err)
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+}
+
+// Sign the image with notation and push the signature to its registry.
+func pushNotationSignature(t *testing.T, image name.Digest, key *ecdsa.PrivateKey, certChain []*x509.Certificate) {
+	sigRepo, err := notationRepository(image.Context(), authn.Anonymous)
+	if err != nil {
+		t.Fatalf("Error creating repository: %v", err)
+	}
+	sigSigner, err := signer.NewGenericSigner(key, certChain)
+	if err != nil {
+		t.Fatalf("Error creating signer: %v", err)
+	}
+	if _, err := notation.Sign(context.Background(), sigSigner, sigRepo, notation.SignOptions{
+		ArtifactReference: image.String(),
+		SignerSignOptions: notation.SignerSignOptions{SignatureMediaType: "application/jose+json"},
+	}); err != nil {
+		t.Fatalf("Error signing image: %v", err)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/golang/glog"
 	"github.com/open-horizon/anax/config"
 	"github.com/open-horizon/anax/containermessage"
+	"github.com/open-horizon/anax/containerruntime"
 	"github.com/open-horizon/anax/cutil"
 	"os"
 	"strings"
@@ -67,7 +68,7 @@ func authDockerFile(config config.Config, authConfigs map[string][]docker.AuthCo
 	return nil
 }
 
-func pullImageFromRepos(config config.Config, authConfigs map[string][]docker.AuthConfiguration, client *docker.Client, skipPartFetchFn *func(repotag string) (bool, error), deploymentDesc *containermessage.DeploymentDescription) error {
+func pullImageFromRepos(config config.Config, authConfigs map[string][]docker.AuthConfiguration, client containerruntime.ContainerClient, skipPartFetchFn *func(repotag string) (bool, error), deploymentDesc *containermessage.DeploymentDescription) error {
 
 	// append docker auth from docker file
 	authDockerFile(config, authConfigs)
@@ -123,14 +124,7 @@ func pullImageFromRepos(config config.Config, authConfigs map[string][]docker.Au
 		}
 
 		// get all the auths for this domain or repo.
-		auth_array := []docker.AuthConfiguration{}
-		for k, _ := range authConfigs {
-			// for "docker.io" repo, the repo string in ~/.docker/config.json is something like:
-			// "https://index.docker.io/v1/"
-			if k == domain || (domain == "docker.io" && strings.Contains(k, domain)) {
-				auth_array = append(auth_array, authConfigs[k]...)
-			}
-		}
+		auth_array := authsForDomain(authConfigs, domain)
 
 		// try auths one at a time
 		var err error
@@ -160,8 +154,25 @@ func pullImageFromRepos(config config.Config, authConfigs map[string][]docker.Au
 	return nil
 }
 
+// Returns the auths for the domain of an image, docker.io is used when the domain is empty.
+func authsForDomain(authConfigs map[string][]docker.AuthConfiguration, domain string) []docker.AuthConfiguration {
+	if domain == "" {
+		domain = "docker.io"
+	}
+
+	auth_array := []docker.AuthConfiguration{}
+	for k, _ := range authConfigs {
+		// for "docker.io" repo, the repo string in ~/.docker/config.json is something like:
+		// "https://index.docker.io/v1/"
+		if k == domain || (domain == "docker.io" && strings.Contains(k, domain)) {
+			auth_array = append(auth_array, authConfigs[k]...)
+		}
+	}
+	return auth_array
+}
+
 // This function try maxPullAttempts times to pull the image from the repo. It exits out imediately if there is auth error.
-func pullSingleImageFromRepo(client *docker.Client, opts docker.PullImageOptions, auth docker.AuthConfiguration) error {
+func pullSingleImageFromRepo(client containerruntime.ContainerClient, opts docker.PullImageOptions, auth docker.AuthConfiguration) error {
 	glog.V(5).Infof("Pulling image %v with auth name %v.", opts, auth.Username)
 
 	var pullAttempts int
@@ -204,7 +215,7 @@ func pullSingleImageFromRepo(client *docker.Client, opts docker.PullImageOptions
 	return nil
 }
 
-func listImages(client *docker.Client) ([]docker.APIImages, error) {
+func listImages(client containerruntime.ContainerClient) ([]docker.APIImages, error) {
 
 	if images, err := client.ListImages(docker.ListImagesOptions{
 		All: true,
@@ -216,7 +227,7 @@ func listImages(client *docker.Client) ([]docker.APIImages, error) {
 }
 
 // TODO: user needs to use image IDs instead of repotags to avoid overwriting or otherwise mistaken handling because of name collisions
-func SkipCheckFn(client *docker.Client) func(repotag string) (bool, error) {
+func SkipCheckFn(client containerruntime.ContainerClient) func(repotag string) (bool, error) {
 
 	return func(repotag string) (bool, error) {
 		repotagParts := strings.Split(repotag, ":")
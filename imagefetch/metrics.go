@@ -0,0 +1,17 @@
+package imagefetch
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// The time taken to fetch the images of a deployment, by result. It is exposed by the agent /metrics API.
+var ImageFetchDuration = prometheus.NewHistogramVec(
+	prometheus.HistogramOpts{
+		Namespace: "horizon",
+		Subsystem: "agent",
+		Name:      "image_fetch_duration_seconds",
+		Help:      "Time taken to fetch the container images of a service deployment, by result.",
+		Buckets:   []float64{1, 5, 15, 30, 60, 120, 300, 600, 1200, 3600},
+	},
+	[]string{"result"},
+)
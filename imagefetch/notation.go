@@ -0,0 +1,147 @@
+package imagefetch
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"github.com/golang/glog"
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/notaryproject/notation-go"
+	"github.com/notaryproject/notation-go/registry"
+	"github.com/notaryproject/notation-go/verifier"
+	"github.com/notaryproject/notation-go/verifier/trustpolicy"
+	"github.com/notaryproject/notation-go/verifier/truststore"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/registry/remote"
+	"oras.land/oras-go/v2/registry/remote/auth"
+	"strings"
+)
+
+// The name of the notation trust policy and of its trust store, which holds the roots of the org's image trust.
+const NOTATION_TRUST_STORE = "imagetrust"
+
+// The trust store of the notation verifier, it has the trusted roots as its only CA store.
+type notationTrustStore struct {
+	roots []*x509.Certificate
+}
+
+func (s *notationTrustStore) GetCertificates(ctx context.Context, storeType truststore.Type, namedStore string) ([]*x509.Certificate, error) {
+	if storeType != truststore.TypeCA || namedStore != NOTATION_TRUST_STORE {
+		return nil, errors.New(fmt.Sprintf("trust store %v:%v does not exist", storeType, namedStore))
+	}
+	return s.roots, nil
+}
+
+// Verify that the image digest has a notation signature from a signing cert that chains to one of the trusted roots and
+// is issued to one of the trusted identities. The signatures are verified with notation-go, at the current time and
+// without checking revocation, so that nothing but the registry is contacted.
+func verifyNotation(repo name.Repository, digest string, trust *OrgImageTrust, material *trustMaterial, authenticator authn.Authenticator) error {
+
+	if len(material.roots) == 0 {
+		return errors.New("notation signatures cannot be verified without roots")
+	}
+
+	sigRepo, err := notationRepository(repo, authenticator)
+	if err != nil {
+		return err
+	}
+	sigVerifier, err := verifier.New(&trustpolicy.Document{
+		Version: "1.0",
+		TrustPolicies: []trustpolicy.TrustPolicy{{
+			Name:           NOTATION_TRUST_STORE,
+			RegistryScopes: []string{"*"},
+			SignatureVerification: trustpolicy.SignatureVerification{
+				VerificationLevel: trustpolicy.LevelStrict.Name,
+				Override:          map[trustpolicy.ValidationType]trustpolicy.ValidationAction{trustpolicy.TypeRevocation: trustpolicy.ActionSkip},
+			},
+			TrustStores:       []string{string(truststore.TypeCA) + ":" + NOTATION_TRUST_STORE},
+			TrustedIdentities: []string{"*"},
+		}},
+	}, &notationTrustStore{roots: material.roots}, nil)
+	if err != nil {
+		return errors.New(fmt.Sprintf("unable to create the notation verifier, error: %v", err))
+	}
+
+	ctx := context.Background()
+	artifactRef := repo.Digest(digest).String()
+	targetDesc, err := sigRepo.Resolve(ctx, digest)
+	if err != nil {
+		return errors.New(fmt.Sprintf("unable to resolve %v, error: %v", artifactRef, err))
+	}
+
+	reasons := []string{}
+	verified := false
+	err = sigRepo.ListSignatures(ctx, targetDesc, func(signatures []ocispec.Descriptor) error {
+		for _, sigDesc := range signatures {
+			if err := verifyNotationSignature(ctx, sigRepo, sigVerifier, targetDesc, sigDesc, artifactRef, trust); err != nil {
+				reasons = append(reasons, fmt.Sprintf("signature %v: %v", sigDesc.Digest, err))
+			} else {
+				glog.V(3).Infof("Verified notation signature %v of image %v", sigDesc.Digest, artifactRef)
+				verified = true
+				return errStopListing
+			}
+		}
+		return nil
+	})
+	if verified {
+		return nil
+	} else if err != nil {
+		return errors.New(fmt.Sprintf("unable to list the notation signatures of %v, error: %v", artifactRef, err))
+	} else if len(reasons) == 0 {
+		return errors.New(fmt.Sprintf("%v has no notation signatures", artifactRef))
+	}
+	return errors.New(fmt.Sprintf("no trusted notation signature: %v", strings.Join(reasons, "; ")))
+}
+
+// Returned by the signature listing function to stop listing once a signature is trusted.
+var errStopListing = errors.New("stop listing signatures")
+
+// Verify a single notation signature of the target, and that its signing cert is issued to a trusted identity.
+func verifyNotationSignature(ctx context.Context, sigRepo registry.Repository, sigVerifier notation.Verifier, targetDesc ocispec.Descriptor, sigDesc ocispec.Descriptor, artifactRef string, trust *OrgImageTrust) error {
+	sigBlob, blobDesc, err := sigRepo.FetchSignatureBlob(ctx, sigDesc)
+	if err != nil {
+		return errors.New(fmt.Sprintf("unable to fetch the signature envelope, error: %v", err))
+	}
+	outcome, err := sigVerifier.Verify(ctx, targetDesc, sigBlob, notation.VerifierVerifyOptions{
+		ArtifactReference:  artifactRef,
+		SignatureMediaType: blobDesc.MediaType,
+	})
+	if err != nil {
+		return err
+	} else if outcome.EnvelopeContent == nil || len(outcome.EnvelopeContent.SignerInfo.CertificateChain) == 0 {
+		return errors.New("signature does not have a certificate chain")
+	}
+
+	cert := outcome.EnvelopeContent.SignerInfo.CertificateChain[0]
+	subjects := append([]string{cert.Subject.String()}, cert.EmailAddresses...)
+	if !trust.MatchesIdentity(subjects, cert.Issuer.String()) {
+		return errors.New(fmt.Sprintf("signing certificate %v issued by %v is not trusted", cert.Subject, cert.Issuer))
+	}
+	return nil
+}
+
+// Returns the notation repository of the image repository, it reads the registry with the authenticator that can read
+// the image.
+func notationRepository(repo name.Repository, authenticator authn.Authenticator) (registry.Repository, error) {
+	orasRepo, err := remote.NewRepository(repo.Name())
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("unable to access repository %v, error: %v", repo, err))
+	}
+	orasRepo.PlainHTTP = repo.Registry.Scheme() == "http"
+
+	authConfig, err := authenticator.Authorization()
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("unable to get the credentials of repository %v, error: %v", repo, err))
+	}
+	orasRepo.Client = &auth.Client{
+		Credential: auth.StaticCredential(repo.RegistryStr(), auth.Credential{
+			Username:     authConfig.Username,
+			Password:     authConfig.Password,
+			RefreshToken: authConfig.IdentityToken,
+			AccessToken:  authConfig.RegistryToken,
+		}),
+	}
+	return registry.NewRepository(orasRepo), nil
+}
@@ -0,0 +1,316 @@
+package imagefetch
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"regexp"
+)
+
+// How the container images of an org are verified.
+const (
+	IMAGE_VERIFY_ENFORCE = "enforce" // An image without a trusted signature fails the agreement or service. This is the default.
+	IMAGE_VERIFY_AUDIT   = "audit"   // An image without a trusted signature is logged and started anyway.
+	IMAGE_VERIFY_SKIP    = "skip"    // The images are not verified.
+)
+
+// The image signature formats.
+const (
+	SIG_FORMAT_COSIGN   = "cosign"
+	SIG_FORMAT_NOTATION = "notation"
+)
+
+// The org whose entry applies to the orgs that are not listed in the image trust policy.
+const IMAGE_TRUST_ANY_ORG = "*"
+
+// The image trust policy of the agent, read from the file in the Edge.ImageTrustPolicyPath configuration. It has an entry
+// for each org whose service images are verified, the images of an org without an entry are not verified.
+//
+//	{
+//	  "orgs": {
+//	    "myorg": {
+//	      "signatureFormats": ["cosign"],
+//	      "keys": ["myorg-cosign.pem"]
+//	    },
+//	    "*": {
+//	      "verification": "audit",
+//	      "roots": ["fulcio-root.pem"],
+//	      "rekorKeys": ["rekor.pem"],
+//	      "identities": [{"issuer": "https://token.actions.githubusercontent.com", "subjectRegExp": "https://github.com/myorg/.*"}]
+//	    }
+//	  }
+//	}
+type ImageTrustPolicy struct {
+	Orgs map[string]OrgImageTrust `json:"orgs"`
+}
+
+// The image trust of an org. The files are the names of the files in the agent's trust store, they are managed with the
+// /trust API.
+type OrgImageTrust struct {
+	Verification     string           `json:"verification,omitempty"`     // enforce, audit or skip, the default is enforce
+	SignatureFormats []string         `json:"signatureFormats,omitempty"` // cosign and/or notation, the default is both
+	Keys             []string         `json:"keys,omitempty"`             // public keys or certs that verify cosign signatures made with a key
+	Roots            []string         `json:"roots,omitempty"`            // CA certs that issue the signing certs of keyless cosign signatures and notation signatures
+	Identities       []SignerIdentity `json:"identities,omitempty"`       // the identities allowed in signing certs, required with roots
+	RekorKeys        []string         `json:"rekorKeys,omitempty"`        // transparency log public keys, when set a cosign signature must have a log entry bundle signed by one of them
+}
+
+// An identity in a signing cert. For a keyless cosign signature the subject is the email or URI of the cert and the issuer
+// is the OIDC issuer that the cert was issued for. For a notation signature they are the subject and issuer distinguished
+// names of the signing cert, such as "CN=signer,O=myorg". An identity must have a subject or a subjectRegExp, an empty
+// issuer matches any issuer. The subjectRegExp must match the whole subject.
+type SignerIdentity struct {
+	Issuer        string `json:"issuer,omitempty"`
+	Subject       string `json:"subject,omitempty"`
+	SubjectRegExp string `json:"subjectRegExp,omitempty"`
+}
+
+func (p ImageTrustPolicy) String() string {
+	return fmt.Sprintf("Orgs: %v", p.Orgs)
+}
+
+func (o OrgImageTrust) String() string {
+	return fmt.Sprintf("Verification: %v, SignatureFormats: %v, Keys: %v, Roots: %v, Identities: %v, RekorKeys: %v",
+		o.Verification, o.SignatureFormats, o.Keys, o.Roots, o.Identities, o.RekorKeys)
+}
+
+// Read the image trust policy file. Returns nil if the file name is empty, in which case no image is verified.
+func LoadImageTrustPolicy(fileName string) (*ImageTrustPolicy, error) {
+	if fileName == "" {
+		return nil, nil
+	}
+
+	bytes, err := os.ReadFile(fileName)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("unable to read image trust policy file %v, error: %v", fileName, err))
+	}
+
+	policy := new(ImageTrustPolicy)
+	if err := json.Unmarshal(bytes, policy); err != nil {
+		return nil, errors.New(fmt.Sprintf("unable to unmarshal image trust policy file %v, error: %v", fileName, err))
+	}
+
+	for org, trust := range policy.Orgs {
+		if err := trust.Validate(); err != nil {
+			return nil, errors.New(fmt.Sprintf("image trust policy file %v has an invalid entry for org %v, error: %v", fileName, org, err))
+		}
+	}
+	return policy, nil
+}
+
+// Returns the image trust of the org, nil if the images of the org are not verified.
+func (p *ImageTrustPolicy) ForOrg(org string) *OrgImageTrust {
+	if p == nil {
+		return nil
+	}
+	trust, ok := p.Orgs[org]
+	if !ok {
+		if trust, ok = p.Orgs[IMAGE_TRUST_ANY_ORG]; !ok {
+			return nil
+		}
+	}
+	if trust.Verification == IMAGE_VERIFY_SKIP {
+		return nil
+	}
+	return &trust
+}
+
+// Returns true if the images of any org must have a trusted signature.
+func (p *ImageTrustPolicy) EnforcesAnyOrg() bool {
+	if p == nil {
+		return false
+	}
+	for _, trust := range p.Orgs {
+		if trust.Verification == "" || trust.Verification == IMAGE_VERIFY_ENFORCE {
+			return true
+		}
+	}
+	return false
+}
+
+func (o *OrgImageTrust) Validate() error {
+	switch o.Verification {
+	case "", IMAGE_VERIFY_ENFORCE, IMAGE_VERIFY_AUDIT, IMAGE_VERIFY_SKIP:
+	default:
+		return errors.New(fmt.Sprintf("verification %v is not supported, it must be %v, %v or %v", o.Verification, IMAGE_VERIFY_ENFORCE, IMAGE_VERIFY_AUDIT, IMAGE_VERIFY_SKIP))
+	}
+	for _, format := range o.SignatureFormats {
+		if format != SIG_FORMAT_COSIGN && format != SIG_FORMAT_NOTATION {
+			return errors.New(fmt.Sprintf("signature format %v is not supported, it must be %v or %v", format, SIG_FORMAT_COSIGN, SIG_FORMAT_NOTATION))
+		}
+	}
+	if o.Verification != IMAGE_VERIFY_SKIP && len(o.Keys) == 0 && len(o.Roots) == 0 {
+		return errors.New("keys or roots must be specified")
+	}
+	if o.AllowsFormat(SIG_FORMAT_NOTATION) && !o.AllowsFormat(SIG_FORMAT_COSIGN) && len(o.Roots) == 0 {
+		return errors.New("notation signatures are verified with roots, roots must be specified")
+	}
+	// Any signing cert issued by the roots would be trusted without identities, including the certs that a public CA
+	// such as Fulcio issues to anyone.
+	if len(o.Roots) != 0 && len(o.Identities) == 0 {
+		return errors.New("identities must be specified with roots")
+	}
+	for _, id := range o.Identities {
+		if id.Subject == "" && id.SubjectRegExp == "" {
+			return errors.New("an identity must have a subject or a subjectRegExp")
+		} else if id.SubjectRegExp != "" {
+			if _, err := regexp.Compile(anchorRegExp(id.SubjectRegExp)); err != nil {
+				return errors.New(fmt.Sprintf("subjectRegExp %v is not valid, error: %v", id.SubjectRegExp, err))
+			}
+		}
+	}
+	return nil
+}
+
+// Returns the regular expression anchored to match the whole string.
+func anchorRegExp(re string) string {
+	return "^(?:" + re + ")$"
+}
+
+func (o *OrgImageTrust) AllowsFormat(format string) bool {
+	if len(o.SignatureFormats) == 0 {
+		return true
+	}
+	for _, f := range o.SignatureFormats {
+		if f == format {
+			return true
+		}
+	}
+	return false
+}
+
+func (o *OrgImageTrust) IsAudit() bool {
+	return o.Verification == IMAGE_VERIFY_AUDIT
+}
+
+// Returns true if the subject and issuer match one of the identities. No identity matches when there are none.
+func (o *OrgImageTrust) MatchesIdentity(subjects []string, issuer string) bool {
+	for _, id := range o.Identities {
+		if id.Issuer != "" && id.Issuer != issuer {
+			continue
+		}
+		for _, subject := range subjects {
+			if id.Subject != "" && id.Subject != subject {
+				continue
+			}
+			if id.SubjectRegExp != "" {
+				if matched, err := regexp.MatchString(anchorRegExp(id.SubjectRegExp), subject); err != nil || !matched {
+					continue
+				}
+			}
+			return true
+		}
+	}
+	return false
+}
+
+// The keys and certs of an org's image trust, read from the agent's trust store.
+type trustMaterial struct {
+	keys      []crypto.PublicKey
+	roots     []*x509.Certificate
+	rekorKeys []crypto.PublicKey
+}
+
+// Read the keys and certs that the image trust refers to from the trust store directory.
+func readTrustMaterial(trustDir string, trust *OrgImageTrust) (*trustMaterial, error) {
+	material := &trustMaterial{}
+
+	for _, fileName := range trust.Keys {
+		if keys, certs, err := readTrustFile(trustDir, fileName); err != nil {
+			return nil, err
+		} else {
+			material.keys = append(material.keys, keys...)
+			for _, cert := range certs {
+				material.keys = append(material.keys, cert.PublicKey)
+			}
+		}
+	}
+
+	for _, fileName := range trust.Roots {
+		if _, certs, err := readTrustFile(trustDir, fileName); err != nil {
+			return nil, err
+		} else if len(certs) == 0 {
+			return nil, errors.New(fmt.Sprintf("trusted root file %v does not contain a certificate", fileName))
+		} else {
+			material.roots = append(material.roots, certs...)
+		}
+	}
+
+	for _, fileName := range trust.RekorKeys {
+		if keys, certs, err := readTrustFile(trustDir, fileName); err != nil {
+			return nil, err
+		} else {
+			material.rekorKeys = append(material.rekorKeys, keys...)
+			for _, cert := range certs {
+				material.rekorKeys = append(material.rekorKeys, cert.PublicKey)
+			}
+		}
+	}
+	return material, nil
+}
+
+func readTrustFile(trustDir string, fileName string) ([]crypto.PublicKey, []*x509.Certificate, error) {
+	bytes, err := os.ReadFile(path.Join(trustDir, path.Base(fileName)))
+	if err != nil {
+		return nil, nil, errors.New(fmt.Sprintf("unable to read trusted cert file %v, error: %v", fileName, err))
+	}
+	keys, certs, err := ParseTrustPEM(bytes)
+	if err != nil {
+		return nil, nil, errors.New(fmt.Sprintf("trusted cert file %v is not valid, error: %v", fileName, err))
+	}
+	return keys, certs, nil
+}
+
+// Parse the public keys and x509 certs in the PEM data of a trust store file. The RSA, ECDSA and Ed25519 keys that sign
+// container images are supported.
+func ParseTrustPEM(data []byte) ([]crypto.PublicKey, []*x509.Certificate, error) {
+	keys := []crypto.PublicKey{}
+	certs := []*x509.Certificate{}
+
+	for block, rest := pem.Decode(data); block != nil; block, rest = pem.Decode(rest) {
+		switch block.Type {
+		case "PUBLIC KEY":
+			if key, err := x509.ParsePKIXPublicKey(block.Bytes); err != nil {
+				return nil, nil, err
+			} else if err := checkKeyType(key); err != nil {
+				return nil, nil, err
+			} else {
+				keys = append(keys, key)
+			}
+		case "RSA PUBLIC KEY":
+			if key, err := x509.ParsePKCS1PublicKey(block.Bytes); err != nil {
+				return nil, nil, err
+			} else {
+				keys = append(keys, key)
+			}
+		case "CERTIFICATE":
+			if cert, err := x509.ParseCertificate(block.Bytes); err != nil {
+				return nil, nil, err
+			} else {
+				certs = append(certs, cert)
+			}
+		}
+	}
+
+	if len(keys) == 0 && len(certs) == 0 {
+		return nil, nil, errors.New("no PEM encoded public key or certificate found")
+	}
+	return keys, certs, nil
+}
+
+func checkKeyType(key crypto.PublicKey) error {
+	switch key.(type) {
+	case *rsa.PublicKey, *ecdsa.PublicKey, ed25519.PublicKey:
+		return nil
+	default:
+		return errors.New(fmt.Sprintf("public key type %T is not supported", key))
+	}
+}
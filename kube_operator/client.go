@@ -161,20 +161,8 @@ func (c KubeClient) Install(tar string, metadata map[string]interface{}, mmsPVCC
 
 	// get and check namespace
 	namespace := getFinalNamespace(reqNamespace, opNamespace)
-	nodeNamespace := cutil.GetClusterNamespace()
-	nodeIsNamespaceScope := cutil.IsNamespaceScoped()
-	if namespace != nodeNamespace && nodeIsNamespaceScope {
-		return fmt.Errorf("Service failed to start for agreement %v. Could not deploy service into namespace %v because the agent's namespace is namespace scoped, and it restricts all services to the agent namespace %v", agId, namespace, nodeNamespace)
-	} else if namespace != nodeNamespace {
-		// create network policies to allow traffic between the node and service
-		ingress := networkingv1.NetworkPolicyIngressRule{From: []networkingv1.NetworkPolicyPeer{networkingv1.NetworkPolicyPeer{NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"kubernetes.io/metadata.name": namespace}}}}}
-		egress := networkingv1.NetworkPolicyEgressRule{To: []networkingv1.NetworkPolicyPeer{networkingv1.NetworkPolicyPeer{NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"kubernetes.io/metadata.name": namespace}}}}}
-		spec := networkingv1.NetworkPolicySpec{PodSelector: metav1.LabelSelector{}, Ingress: []networkingv1.NetworkPolicyIngressRule{ingress}, Egress: []networkingv1.NetworkPolicyEgressRule{egress}, PolicyTypes: []networkingv1.PolicyType{"Ingress", "Egress"}}
-		netPol := networkingv1.NetworkPolicy{ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("%s-networkPolicy", agId), Namespace: nodeNamespace}, Spec: spec}
-		_, err := c.Client.NetworkingV1().NetworkPolicies(nodeNamespace).Create(context.Background(), &netPol, metav1.CreateOptions{})
-		if err != nil {
-			glog.Errorf(kwlog(fmt.Sprintf("Error creating network policy: %v. Continuing installation.", err)))
-		}
+	if err := c.createNetworkPolicy(agId, namespace); err != nil {
+		return err
 	}
 
 	// If the namespace was specified in the deployment then create the namespace object so it can be created
@@ -235,12 +223,8 @@ func (c KubeClient) Uninstall(tar string, metadata map[string]interface{}, agId
 	if _, ok := apiObjMap[K8S_NAMESPACE_TYPE]; !ok && namespace != nodeNamespace && !nodeIsNamespaceScope {
 		nsObj := corev1.Namespace{TypeMeta: metav1.TypeMeta{Kind: "Namespace"}, ObjectMeta: metav1.ObjectMeta{Name: namespace}}
 		apiObjMap[K8S_NAMESPACE_TYPE] = []APIObjectInterface{NamespaceCoreV1{NamespaceObject: &nsObj}}
-	} else if namespace != nodeNamespace {
-		// delete the network policy that allows traffic between the node and service
-		err := c.Client.NetworkingV1().NetworkPolicies(nodeNamespace).Delete(context.Background(), fmt.Sprintf("%s-networkPolicy", agId), metav1.DeleteOptions{})
-		if err != nil {
-			glog.Errorf(kwlog(fmt.Sprintf("Error deleting network policy: %v", err)))
-		}
+	} else {
+		c.deleteNetworkPolicy(agId, namespace)
 	}
 
 	baseK8sComponents := getBaseK8sKinds()
@@ -344,6 +328,38 @@ func (c KubeClient) Update(tar string, metadata map[string]interface{}, agId str
 	return nil
 }
 
+// Check that the service can be deployed to the namespace, and if the namespace is not the namespace of the agent,
+// create the network policy that allows traffic between the node and the service.
+func (c KubeClient) createNetworkPolicy(agId string, namespace string) error {
+	nodeNamespace := cutil.GetClusterNamespace()
+	nodeIsNamespaceScope := cutil.IsNamespaceScoped()
+	if namespace != nodeNamespace && nodeIsNamespaceScope {
+		return fmt.Errorf("Service failed to start for agreement %v. Could not deploy service into namespace %v because the agent's namespace is namespace scoped, and it restricts all services to the agent namespace %v", agId, namespace, nodeNamespace)
+	} else if namespace != nodeNamespace {
+		// create network policies to allow traffic between the node and service
+		ingress := networkingv1.NetworkPolicyIngressRule{From: []networkingv1.NetworkPolicyPeer{networkingv1.NetworkPolicyPeer{NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"kubernetes.io/metadata.name": namespace}}}}}
+		egress := networkingv1.NetworkPolicyEgressRule{To: []networkingv1.NetworkPolicyPeer{networkingv1.NetworkPolicyPeer{NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"kubernetes.io/metadata.name": namespace}}}}}
+		spec := networkingv1.NetworkPolicySpec{PodSelector: metav1.LabelSelector{}, Ingress: []networkingv1.NetworkPolicyIngressRule{ingress}, Egress: []networkingv1.NetworkPolicyEgressRule{egress}, PolicyTypes: []networkingv1.PolicyType{"Ingress", "Egress"}}
+		netPol := networkingv1.NetworkPolicy{ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("%s-networkPolicy", agId), Namespace: nodeNamespace}, Spec: spec}
+		_, err := c.Client.NetworkingV1().NetworkPolicies(nodeNamespace).Create(context.Background(), &netPol, metav1.CreateOptions{})
+		if err != nil {
+			glog.Errorf(kwlog(fmt.Sprintf("Error creating network policy: %v. Continuing installation.", err)))
+		}
+	}
+	return nil
+}
+
+// Delete the network policy that allows traffic between the node and the service.
+func (c KubeClient) deleteNetworkPolicy(agId string, namespace string) {
+	nodeNamespace := cutil.GetClusterNamespace()
+	if namespace != nodeNamespace {
+		err := c.Client.NetworkingV1().NetworkPolicies(nodeNamespace).Delete(context.Background(), fmt.Sprintf("%s-networkPolicy", agId), metav1.DeleteOptions{})
+		if err != nil {
+			glog.Errorf(kwlog(fmt.Sprintf("Error deleting network policy: %v", err)))
+		}
+	}
+}
+
 // processDeployment takes the deployment string and converts it to a map with the k8s objects, the namespace to be used, and an error if one occurs
 func ProcessDeployment(tar string, metadata map[string]interface{}, mmsPVCConfig map[string]interface{}, envVars map[string]string, fssAuthFilePath string, fssCertFilePath string, secretsMap map[string]string, agId string, crInstallTimeout int64) (map[string][]APIObjectInterface, string, error) {
 	// Read the yaml files from the commpressed tar files
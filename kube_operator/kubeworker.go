@@ -9,19 +9,18 @@ import (
 	"github.com/open-horizon/anax/persistence"
 	"github.com/open-horizon/anax/resource"
 	"github.com/open-horizon/anax/worker"
-	bolt "go.etcd.io/bbolt"
 	"path"
 )
 
 type KubeWorker struct {
 	worker.BaseWorker
 	config    *config.HorizonConfig
-	db        *bolt.DB
+	db        persistence.AgentDatabase
 	authMgr   *resource.AuthenticationManager
 	secretMgr *resource.SecretsManager
 }
 
-func NewKubeWorker(name string, config *config.HorizonConfig, db *bolt.DB, am *resource.AuthenticationManager, sm *resource.SecretsManager) *KubeWorker {
+func NewKubeWorker(name string, config *config.HorizonConfig, db persistence.AgentDatabase, am *resource.AuthenticationManager, sm *resource.SecretsManager) *KubeWorker {
 	worker := &KubeWorker{
 		BaseWorker: worker.NewBaseWorker(name, config, nil),
 		config:     config,
@@ -220,7 +219,11 @@ func (w *KubeWorker) processKubeOperator(lc *events.AgreementLaunchContext, kd *
 
 		fssAuthFilePath := path.Join(w.GetAuthenticationManager().GetCredentialPath(lc.AgreementId), config.HZN_FSS_AUTH_FILE) // /var/horizon/ess-auth/<agreementId>/auth.json
 		fssCertFilePath := path.Join(w.config.GetESSSSLClientCertPath(), config.HZN_FSS_CERT_FILE)                             // /var/horizon/ess-auth/SSL/cert/cert.pem
-		err = client.Install(kd.OperatorYamlArchive, kd.Metadata, kd.MMSPVC, *(lc.EnvironmentAdditions), fssAuthFilePath, fssCertFilePath, secretsMap, lc.AgreementId, lc.Configure.ClusterNamespace, crInstallTimeout)
+		if kd.IsManifest() {
+			err = client.InstallManifest(kd.ManifestArchive, *(lc.EnvironmentAdditions), fssAuthFilePath, fssCertFilePath, secretsMap, lc.AgreementId, lc.Configure.ClusterNamespace)
+		} else {
+			err = client.Install(kd.OperatorYamlArchive, kd.Metadata, kd.MMSPVC, *(lc.EnvironmentAdditions), fssAuthFilePath, fssCertFilePath, secretsMap, lc.AgreementId, lc.Configure.ClusterNamespace, crInstallTimeout)
+		}
 		if err != nil {
 			return err
 		}
@@ -235,7 +238,11 @@ func (w *KubeWorker) uninstallKubeOperator(kd *persistence.KubeDeploymentConfig,
 	if err != nil {
 		return err
 	}
-	err = client.Uninstall(kd.OperatorYamlArchive, kd.Metadata, agId, reqNamespace)
+	if kd.IsManifest() {
+		err = client.UninstallManifest(kd.ManifestArchive, agId, reqNamespace)
+	} else {
+		err = client.Uninstall(kd.OperatorYamlArchive, kd.Metadata, agId, reqNamespace)
+	}
 	if err != nil {
 		return err
 	}
@@ -249,7 +256,12 @@ func (w *KubeWorker) operatorStatus(kd *persistence.KubeDeploymentConfig, intend
 	if err != nil {
 		return err
 	}
-	opStatus, err := client.Status(kd.OperatorYamlArchive, kd.Metadata, agId, reqnamespace)
+	var opStatus []ContainerStatus
+	if kd.IsManifest() {
+		opStatus, err = client.ManifestStatus(kd.ManifestArchive, agId, reqnamespace)
+	} else {
+		opStatus, err = client.Status(kd.OperatorYamlArchive, kd.Metadata, agId, reqnamespace)
+	}
 	if err != nil {
 		return err
 	}
@@ -275,6 +287,8 @@ func (w *KubeWorker) updateKubeOperatorSecrets(kd *persistence.KubeDeploymentCon
 
 	if updatedSecretsMap, err := w.GetSecretManager().ProcessServiceSecretUpdatesForCluster(agId, kd, updatedSecrets); err != nil {
 		return err
+	} else if kd.IsManifest() {
+		return client.UpdateManifestSecrets(kd.ManifestArchive, agId, reqnamespace, updatedSecretsMap)
 	} else if err = client.Update(kd.OperatorYamlArchive, kd.Metadata, agId, reqnamespace, map[string]string{}, updatedSecretsMap); err != nil {
 		return err
 	}
@@ -0,0 +1,292 @@
+package kube_operator
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	utiljson "k8s.io/apimachinery/pkg/util/json"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"sigs.k8s.io/kustomize/api/krusty"
+	"sigs.k8s.io/kustomize/api/types"
+	"sigs.k8s.io/kustomize/kyaml/filesys"
+)
+
+// The agent builds Kustomize directories with the kustomize library, in memory, so a kustomization is built the same
+// way kustomize build would build it. Only the files in the manifest archive can be used, remote resources are
+// rejected, and plugins and Helm charts are disabled.
+
+// The names of a kustomization file, in the order kustomize looks for them.
+var kustomizationFileNames = []string{"kustomization.yaml", "kustomization.yml", "Kustomization"}
+
+// Kinds that are not namespaced, the namespace of the service is not set on them.
+var clusterScopedKinds = []string{"Namespace", "ClusterRole", "ClusterRoleBinding", "CustomResourceDefinition", "PersistentVolume", "StorageClass", "PriorityClass", "IngressClass", "RuntimeClass", "MutatingWebhookConfiguration", "ValidatingWebhookConfiguration", "APIService"}
+
+func isClusterScopedKind(kind string) bool {
+	for _, k := range clusterScopedKinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns the kustomizations in the archive, keyed by their directory.
+func readKustomizations(files map[string]string) (map[string]*types.Kustomization, error) {
+	kustomizations := map[string]*types.Kustomization{}
+	for name, content := range files {
+		for _, kName := range kustomizationFileNames {
+			if path.Base(name) != kName {
+				continue
+			}
+			k := new(types.Kustomization)
+			if err := utilyaml.Unmarshal([]byte(content), k); err != nil {
+				return nil, fmt.Errorf("unable to read kustomization %v: %v", name, err)
+			}
+			k.FixKustomization()
+			kustomizations[path.Dir(name)] = k
+		}
+	}
+	return kustomizations, nil
+}
+
+// Returns the directory of the top level kustomization in the bundle, and false if the bundle is not a Kustomize
+// directory. The top level kustomization is the one that is not a resource of any other kustomization in the bundle,
+// there must be exactly one.
+func findKustomizationRoot(files map[string]string) (string, bool, error) {
+	kustomizations, err := readKustomizations(files)
+	if err != nil {
+		return "", true, err
+	} else if len(kustomizations) == 0 {
+		return "", false, nil
+	}
+
+	referenced := map[string]bool{}
+	for dir, k := range kustomizations {
+		for _, res := range append(k.Resources, k.Components...) {
+			referenced[path.Join(dir, res)] = true
+		}
+	}
+
+	roots := []string{}
+	for dir := range kustomizations {
+		if !referenced[dir] {
+			roots = append(roots, dir)
+		}
+	}
+	sort.Strings(roots)
+	if len(roots) == 0 {
+		return "", true, fmt.Errorf("the kustomizations in the manifest archive include each other")
+	} else if len(roots) > 1 {
+		return "", true, fmt.Errorf("the manifest archive has more than one top level kustomization: %v", strings.Join(roots, ", "))
+	}
+	return roots[0], true, nil
+}
+
+// Returns true if kustomize would fetch the file or directory from a URL or a git repository, rather than load it
+// from the archive.
+func isRemoteReference(ref string) bool {
+	lower := strings.ToLower(ref)
+	return strings.Contains(lower, "://") || strings.HasPrefix(lower, "git::") || strings.HasPrefix(lower, "github.com") || strings.Contains(lower, "@")
+}
+
+// Returns the files and directories that the kustomization loads. Inline patches and plugin configurations span
+// more than one line and are not references.
+func kustomizationReferences(k *types.Kustomization) []string {
+	refs := []string{}
+	refs = append(refs, k.Resources...)
+	refs = append(refs, k.Components...)
+	refs = append(refs, k.Crds...)
+	refs = append(refs, k.Configurations...)
+	refs = append(refs, k.Generators...)
+	refs = append(refs, k.Transformers...)
+	refs = append(refs, k.Validators...)
+	for _, p := range k.PatchesStrategicMerge {
+		refs = append(refs, string(p))
+	}
+	for _, p := range k.Patches {
+		refs = append(refs, p.Path)
+	}
+	for _, r := range k.Replacements {
+		refs = append(refs, r.Path)
+	}
+	refs = append(refs, k.OpenAPI["path"])
+	kvSources := func(kv types.KvPairSources) {
+		for _, f := range kv.FileSources {
+			if i := strings.Index(f, "="); i != -1 {
+				f = f[i+1:]
+			}
+			refs = append(refs, f)
+		}
+		refs = append(refs, kv.EnvSources...)
+	}
+	for _, g := range k.ConfigMapGenerator {
+		kvSources(g.KvPairSources)
+	}
+	for _, g := range k.SecretGenerator {
+		kvSources(g.KvPairSources)
+	}
+
+	result := []string{}
+	for _, ref := range refs {
+		if ref != "" && !strings.Contains(ref, "\n") {
+			result = append(result, ref)
+		}
+	}
+	return result
+}
+
+// Build the kustomization in the directory with kustomize. Returns the objects and the namespace of the kustomization.
+func buildKustomization(files map[string]string, dir string) ([]*unstructured.Unstructured, string, error) {
+
+	kustomizations, err := readKustomizations(files)
+	if err != nil {
+		return nil, "", err
+	}
+	for kDir, k := range kustomizations {
+		if len(k.HelmCharts) != 0 || len(k.HelmChartInflationGenerator) != 0 {
+			return nil, "", fmt.Errorf("Helm charts in kustomization %v are not supported", kDir)
+		}
+		for _, ref := range kustomizationReferences(k) {
+			if isRemoteReference(ref) {
+				return nil, "", fmt.Errorf("remote resource %v in kustomization %v is not supported", ref, kDir)
+			}
+		}
+	}
+
+	fSys := filesys.MakeFsInMemory()
+	for name, content := range files {
+		if err := fSys.WriteFile(path.Join("/", name), []byte(content)); err != nil {
+			return nil, "", fmt.Errorf("unable to load manifest archive file %v: %v", name, err)
+		}
+	}
+
+	resMap, err := krusty.MakeKustomizer(krusty.MakeDefaultOptions()).Run(fSys, path.Join("/", dir))
+	if err != nil {
+		return nil, "", fmt.Errorf("unable to build kustomization %v: %v", dir, err)
+	}
+	out, err := resMap.AsYaml()
+	if err != nil {
+		return nil, "", fmt.Errorf("unable to build kustomization %v: %v", dir, err)
+	}
+	objs, err := decodeManifests(string(out))
+	if err != nil {
+		return nil, "", fmt.Errorf("unable to read the output of kustomization %v: %v", dir, err)
+	}
+
+	namespace := ""
+	if k, ok := kustomizations[dir]; ok {
+		namespace = k.Namespace
+	}
+	return objs, namespace, nil
+}
+
+// Decode the objects in a manifest file, which can hold more than one yaml document.
+func decodeManifests(content string) ([]*unstructured.Unstructured, error) {
+	objs := []*unstructured.Unstructured{}
+	decoder := utilyaml.NewYAMLOrJSONDecoder(bytes.NewReader([]byte(content)), 4096)
+	for {
+		// The apimachinery JSON decoder keeps integers as int64, like the k8s client does.
+		raw := json.RawMessage{}
+		obj := map[string]interface{}{}
+		if err := decoder.Decode(&raw); err == io.EOF {
+			return objs, nil
+		} else if err != nil {
+			return nil, err
+		} else if err := utiljson.Unmarshal(raw, &obj); err != nil {
+			return nil, err
+		} else if len(obj) == 0 {
+			// empty document
+			continue
+		}
+
+		u := &unstructured.Unstructured{Object: obj}
+		if u.GetKind() == "" || u.GetAPIVersion() == "" {
+			return nil, fmt.Errorf("object %v does not have a kind and an apiVersion", u.GetName())
+		} else if u.GetKind() == "List" {
+			list, err := u.ToList()
+			if err != nil {
+				return nil, err
+			}
+			for i := range list.Items {
+				objs = append(objs, &list.Items[i])
+			}
+		} else {
+			objs = append(objs, u)
+		}
+	}
+}
+
+// Add labels to the pod template of a workload.
+func addPodTemplateLabels(obj *unstructured.Unstructured, newLabels map[string]string) {
+	if templatePath := podTemplatePath(obj.GetKind()); templatePath != nil {
+		labelsPath := append(templatePath, "metadata", "labels")
+		labels, _, _ := unstructured.NestedStringMap(obj.Object, labelsPath...)
+		if labels == nil {
+			labels = map[string]string{}
+		}
+		for key, value := range newLabels {
+			labels[key] = value
+		}
+		unstructured.SetNestedStringMap(obj.Object, labels, labelsPath...)
+	}
+}
+
+// Returns the path of the pod template in a workload, or nil if the kind does not have one.
+func podTemplatePath(kind string) []string {
+	switch kind {
+	case "Deployment", "StatefulSet", "DaemonSet", "ReplicaSet", "Job":
+		return []string{"spec", "template"}
+	case "CronJob":
+		return []string{"spec", "jobTemplate", "spec", "template"}
+	}
+	return nil
+}
+
+// Returns the pod spec of a pod or a workload, or nil if the object does not have one.
+func podSpec(obj *unstructured.Unstructured) map[string]interface{} {
+	var specPath []string
+	if obj.GetKind() == "Pod" {
+		specPath = []string{"spec"}
+	} else if templatePath := podTemplatePath(obj.GetKind()); templatePath != nil {
+		specPath = append(templatePath, "spec")
+	} else {
+		return nil
+	}
+
+	var spec interface{} = obj.Object
+	for _, field := range specPath {
+		if m, ok := spec.(map[string]interface{}); !ok {
+			return nil
+		} else {
+			spec = m[field]
+		}
+	}
+	if m, ok := spec.(map[string]interface{}); ok {
+		return m
+	}
+	return nil
+}
+
+// Returns the containers and init containers of a pod or a workload. The containers are returned by reference
+// so that they can be changed.
+func podContainers(obj *unstructured.Unstructured) []map[string]interface{} {
+	containers := []map[string]interface{}{}
+	if spec := podSpec(obj); spec != nil {
+		for _, field := range []string{"initContainers", "containers"} {
+			if list, ok := spec[field].([]interface{}); ok {
+				for _, c := range list {
+					if container, ok := c.(map[string]interface{}); ok {
+						containers = append(containers, container)
+					}
+				}
+			}
+		}
+	}
+	return containers
+}
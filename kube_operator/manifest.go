@@ -0,0 +1,502 @@
+package kube_operator
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/open-horizon/anax/config"
+	"github.com/open-horizon/anax/cutil"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery/cached/memory"
+	dynamic "k8s.io/client-go/dynamic"
+	"k8s.io/client-go/restmapper"
+)
+
+// A manifest deployment is a bundle of plain k8s manifests, or a Kustomize directory, that the agent applies to the
+// cluster itself with server side apply. Every object applied for an agreement carries the owner label, so that the
+// objects can be found and pruned when the agreement ends.
+const (
+	// The value of the owner label is a hash of the agreement id, label values are limited to 63 characters.
+	MANIFEST_OWNER_LABEL = "openhorizon.org/agreement"
+	// The full agreement id, as an annotation.
+	MANIFEST_AGREEMENT_ANNOTATION = "openhorizon.org/agreement-id"
+	// The field manager of the server side apply requests.
+	MANIFEST_FIELD_MANAGER = "openhorizon-agent"
+
+	ESS_AUTH_VOLUME_NAME = "ess-auth-vol"
+	ESS_CERT_VOLUME_NAME = "ess-cert-vol"
+
+	// How long to wait for the API server to serve the kinds of a custom resource definition that was just applied.
+	MANIFEST_DISCOVERY_TIMEOUT_S = 60
+)
+
+// The order in which the kinds of a manifest bundle are applied. Kinds that are not in the list are applied last, in
+// the order they appear in the bundle. The objects are removed in the reverse order.
+var manifestKindOrder = []string{"Namespace", "CustomResourceDefinition", "PriorityClass", "StorageClass", "ServiceAccount", "ClusterRole", "ClusterRoleBinding", "Role", "RoleBinding", "ConfigMap", "Secret", "PersistentVolume", "PersistentVolumeClaim", "Service", "DaemonSet", "Deployment", "StatefulSet", "ReplicaSet", "Pod", "Job", "CronJob"}
+
+func manifestKindRank(kind string) int {
+	for i, k := range manifestKindOrder {
+		if k == kind {
+			return i
+		}
+	}
+	return len(manifestKindOrder)
+}
+
+// Returns the value of the owner label for the agreement.
+func ManifestOwnerLabelValue(agId string) string {
+	return cutil.GetHashFromString(agId)
+}
+
+// Read the files in the manifest archive, keyed by their path in the archive.
+func readManifestArchive(archive string) (map[string]string, error) {
+	yamls, err := getYamlFromTarGz(archive)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read manifest archive: %v", err)
+	}
+
+	files := map[string]string{}
+	for _, y := range yamls {
+		name := path.Clean(y.Header.Name)
+		if path.IsAbs(name) || name == ".." || strings.HasPrefix(name, "../") {
+			return nil, fmt.Errorf("file %v is outside of the manifest archive", y.Header.Name)
+		}
+		files[name] = y.Body
+	}
+	return files, nil
+}
+
+// BuildManifests returns the objects in the manifest archive, and the namespace that the manifests ask for. If the
+// archive has a kustomization file, the objects are the output of the kustomization, otherwise they are the objects
+// in all the yaml and json files of the archive.
+func BuildManifests(archive string) ([]*unstructured.Unstructured, string, error) {
+	files, err := readManifestArchive(archive)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var objs []*unstructured.Unstructured
+	namespace := ""
+	if root, found, err := findKustomizationRoot(files); err != nil {
+		return nil, "", err
+	} else if found {
+		if objs, namespace, err = buildKustomization(files, root); err != nil {
+			return nil, "", err
+		}
+	} else {
+		names := []string{}
+		for name := range files {
+			if ext := path.Ext(name); ext == ".yaml" || ext == ".yml" || ext == ".json" {
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if fileObjs, err := decodeManifests(files[name]); err != nil {
+				return nil, "", fmt.Errorf("unable to read manifest %v: %v", name, err)
+			} else {
+				objs = append(objs, fileObjs...)
+			}
+		}
+	}
+
+	if len(objs) == 0 {
+		return nil, "", fmt.Errorf("the manifest archive does not contain any k8s objects")
+	}
+	for _, obj := range objs {
+		if obj.GetName() == "" {
+			return nil, "", fmt.Errorf("object of kind %v does not have a name", obj.GetKind())
+		}
+		if namespace == "" && obj.GetKind() == K8S_NAMESPACE_TYPE {
+			namespace = obj.GetName()
+		}
+	}
+
+	return objs, namespace, nil
+}
+
+// The agreement specific names of the objects that the agent creates for the service.
+type manifestOverlay struct {
+	agId          string
+	namespace     string
+	configMapName string
+	secretsName   string
+	essAuthName   string
+	essCertName   string
+}
+
+// Apply the agreement overlay to the objects of the bundle. Every object gets the owner label, namespaced objects are
+// moved to the namespace of the service, and the containers of every workload get the env vars, service secrets and
+// ESS credentials of the agreement. Namespace objects other than the namespace of the service are dropped, as is the
+// namespace of the agent, which the service must never own.
+func applyManifestOverlay(objs []*unstructured.Unstructured, overlay manifestOverlay) []*unstructured.Unstructured {
+	ownerLabel := map[string]string{MANIFEST_OWNER_LABEL: ManifestOwnerLabelValue(overlay.agId)}
+
+	result := []*unstructured.Unstructured{}
+	for _, obj := range objs {
+		if obj.GetKind() == K8S_NAMESPACE_TYPE && (obj.GetName() != overlay.namespace || obj.GetName() == cutil.GetClusterNamespace()) {
+			glog.Warningf(kwlog(fmt.Sprintf("Namespace %v in the manifests is ignored. Service will be deployed to '%v'.", obj.GetName(), overlay.namespace)))
+			continue
+		}
+
+		labels := obj.GetLabels()
+		if labels == nil {
+			labels = map[string]string{}
+		}
+		labels[MANIFEST_OWNER_LABEL] = ownerLabel[MANIFEST_OWNER_LABEL]
+		obj.SetLabels(labels)
+
+		annotations := obj.GetAnnotations()
+		if annotations == nil {
+			annotations = map[string]string{}
+		}
+		annotations[MANIFEST_AGREEMENT_ANNOTATION] = overlay.agId
+		obj.SetAnnotations(annotations)
+
+		if !isClusterScopedKind(obj.GetKind()) {
+			if obj.GetNamespace() != "" && obj.GetNamespace() != overlay.namespace {
+				glog.Warningf(kwlog(fmt.Sprintf("Embedded namespace '%v' in %v %v is ignored. Service will be deployed to '%v'.", obj.GetNamespace(), obj.GetKind(), obj.GetName(), overlay.namespace)))
+			}
+			obj.SetNamespace(overlay.namespace)
+		}
+
+		if spec := podSpec(obj); spec != nil {
+			addPodTemplateLabels(obj, ownerLabel)
+			addOverlayToPodSpec(obj, spec, overlay)
+		}
+
+		result = append(result, obj)
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		return manifestKindRank(result[i].GetKind()) < manifestKindRank(result[j].GetKind())
+	})
+	return result
+}
+
+// Add the env var config map, the service secrets and the ESS credentials of the agreement to every container of the
+// pod spec.
+func addOverlayToPodSpec(obj *unstructured.Unstructured, spec map[string]interface{}, overlay manifestOverlay) {
+	volumes, _ := spec["volumes"].([]interface{})
+	mounts := []interface{}{}
+	addVolume := func(volumeName string, secretName string, mountPath string) {
+		volumes = append(volumes, map[string]interface{}{"name": volumeName, "secret": map[string]interface{}{"secretName": secretName}})
+		mounts = append(mounts, map[string]interface{}{"name": volumeName, "mountPath": mountPath, "readOnly": true})
+	}
+	if overlay.secretsName != "" {
+		addVolume(SECRETS_VOLUME_NAME, overlay.secretsName, config.HZN_SECRETS_MOUNT)
+	}
+	if overlay.essAuthName != "" {
+		addVolume(ESS_AUTH_VOLUME_NAME, overlay.essAuthName, config.HZN_FSS_AUTH_MOUNT)
+	}
+	if overlay.essCertName != "" {
+		addVolume(ESS_CERT_VOLUME_NAME, overlay.essCertName, config.HZN_FSS_CERT_MOUNT)
+	}
+	if len(mounts) != 0 {
+		spec["volumes"] = volumes
+	}
+
+	for _, container := range podContainers(obj) {
+		if overlay.configMapName != "" {
+			envFrom, _ := container["envFrom"].([]interface{})
+			container["envFrom"] = append(envFrom, map[string]interface{}{"configMapRef": map[string]interface{}{"name": overlay.configMapName}})
+		}
+		if len(mounts) != 0 {
+			volumeMounts, _ := container["volumeMounts"].([]interface{})
+			container["volumeMounts"] = append(volumeMounts, mounts...)
+		}
+	}
+}
+
+// Returns a mapper from the kinds of the objects to the API resources that the cluster serves.
+func (c KubeClient) newRESTMapper() *restmapper.DeferredDiscoveryRESTMapper {
+	return restmapper.NewDeferredDiscoveryRESTMapper(memory.NewMemCacheClient(c.Client.Discovery()))
+}
+
+// Returns the resource client for the kind of the object. A kind that was just added to the cluster by a custom
+// resource definition is not served until the definition is established, so a kind that is not found is looked up
+// again until the timeout expires.
+func (c KubeClient) resourceFor(mapper *restmapper.DeferredDiscoveryRESTMapper, gvk schema.GroupVersionKind, namespace string, timeout time.Duration) (dynamic.ResourceInterface, *meta.RESTMapping, error) {
+	start := time.Now()
+	for {
+		mapping, err := mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+		if err != nil && meta.IsNoMatchError(err) && time.Since(start) < timeout {
+			mapper.Reset()
+			time.Sleep(2 * time.Second)
+			continue
+		} else if err != nil {
+			return nil, nil, fmt.Errorf("unable to find the API resource for %v: %v", gvk.String(), err)
+		}
+
+		if mapping.Scope.Name() == meta.RESTScopeNameNamespace {
+			return c.DynClient.Resource(mapping.Resource).Namespace(namespace), mapping, nil
+		}
+		return c.DynClient.Resource(mapping.Resource), mapping, nil
+	}
+}
+
+// Returns true if the object in the cluster was applied for the agreement. The owner label is a hash of the agreement
+// id, the agreement id annotation must match too.
+func manifestObjectOwned(obj *unstructured.Unstructured, agId string) bool {
+	return obj.GetLabels()[MANIFEST_OWNER_LABEL] == ManifestOwnerLabelValue(agId) && obj.GetAnnotations()[MANIFEST_AGREEMENT_ANNOTATION] == agId
+}
+
+// InstallManifest applies the objects in the manifest archive to the cluster, with the agreement overlay.
+func (c KubeClient) InstallManifest(archive string, envVars map[string]string, fssAuthFilePath string, fssCertFilePath string, secretsMap map[string]string, agId string, reqNamespace string) error {
+
+	objs, manifestNamespace, err := BuildManifests(archive)
+	if err != nil {
+		return err
+	}
+
+	namespace := getFinalNamespace(reqNamespace, manifestNamespace)
+	if err := c.createNetworkPolicy(agId, namespace); err != nil {
+		return err
+	}
+
+	// Create the namespace of the service if it does not exist yet, labelled so that it is removed with the service.
+	nodeNamespace := cutil.GetClusterNamespace()
+	if namespace != nodeNamespace {
+		if _, err := c.Client.CoreV1().Namespaces().Get(context.Background(), namespace, metav1.GetOptions{}); err != nil && errors.IsNotFound(err) {
+			nsObj := corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: namespace, Labels: map[string]string{MANIFEST_OWNER_LABEL: ManifestOwnerLabelValue(agId)}, Annotations: map[string]string{MANIFEST_AGREEMENT_ANNOTATION: agId}}}
+			if _, err := c.Client.CoreV1().Namespaces().Create(context.Background(), &nsObj, metav1.CreateOptions{}); err != nil && !errors.IsAlreadyExists(err) {
+				return fmt.Errorf("%s", kwlog(fmt.Sprintf("Error creating namespace %v: %v", namespace, err)))
+			}
+		} else if err != nil {
+			return fmt.Errorf("%s", kwlog(fmt.Sprintf("Error getting namespace %v: %v", namespace, err)))
+		}
+	}
+
+	overlay := manifestOverlay{agId: agId, namespace: namespace}
+
+	cutil.SetESSEnvVarsForClusterAgent(envVars, config.ENVVAR_PREFIX, agId)
+	if overlay.configMapName, err = c.CreateConfigMap(envVars, agId, namespace); err != nil {
+		return err
+	}
+	if fssAuthFilePath != "" {
+		if overlay.essAuthName, err = c.CreateESSAuthSecrets(fssAuthFilePath, agId, namespace); err != nil {
+			return err
+		}
+	}
+	if fssCertFilePath != "" {
+		if overlay.essCertName, err = c.CreateESSCertSecrets(fssCertFilePath, agId, namespace); err != nil {
+			return err
+		}
+	}
+	if len(secretsMap) > 0 {
+		// secretsMap is a map, key is the secret name, value is the base64 encoded string.
+		if decodedSecrets, err := decodeServiceSecret(secretsMap); err != nil {
+			return err
+		} else if overlay.secretsName, err = c.CreateK8SSecrets(decodedSecrets, agId, namespace); err != nil {
+			return err
+		}
+	}
+
+	mapper := c.newRESTMapper()
+	for _, obj := range applyManifestOverlay(objs, overlay) {
+		resClient, mapping, err := c.resourceFor(mapper, obj.GroupVersionKind(), namespace, MANIFEST_DISCOVERY_TIMEOUT_S*time.Second)
+		if err != nil {
+			return err
+		}
+		if mapping.Scope.Name() != meta.RESTScopeNameNamespace {
+			obj.SetNamespace("")
+		}
+
+		// The agent never takes over an object that it did not apply for the agreement, such as an object of another
+		// agreement or of the cluster administrator. A namespace that already exists is used as it is.
+		if current, err := resClient.Get(context.Background(), obj.GetName(), metav1.GetOptions{}); err != nil && !errors.IsNotFound(err) {
+			return fmt.Errorf("%s", kwlog(fmt.Sprintf("Error getting %v %v: %v", obj.GetKind(), obj.GetName(), err)))
+		} else if err == nil && !manifestObjectOwned(current, agId) {
+			if obj.GetKind() == K8S_NAMESPACE_TYPE {
+				glog.Infof(kwlog(fmt.Sprintf("namespace %v already exists, it is not applied for agreement %v", obj.GetName(), agId)))
+				continue
+			}
+			return fmt.Errorf("%s", kwlog(fmt.Sprintf("Error applying %v %v: the object already exists and is not owned by agreement %v", obj.GetKind(), obj.GetName(), agId)))
+		}
+
+		// The apply is not forced, so a field that another field manager, such as kubectl, set on an object of the
+		// agreement fails the apply with a conflict instead of being taken over.
+		if _, err := resClient.Apply(context.Background(), obj.GetName(), obj, metav1.ApplyOptions{FieldManager: MANIFEST_FIELD_MANAGER}); err != nil {
+			return fmt.Errorf("%s", kwlog(fmt.Sprintf("Error applying %v %v: %v", obj.GetKind(), obj.GetName(), err)))
+		}
+		glog.Infof(kwlog(fmt.Sprintf("successfully applied %v %v", obj.GetKind(), obj.GetName())))
+	}
+
+	glog.V(3).Infof(kwlog(fmt.Sprintf("all manifest objects applied for agreement %v", agId)))
+	return nil
+}
+
+// UninstallManifest removes the objects that were applied for the agreement. The objects are found by the owner label,
+// for each of the kinds in the manifest archive, so objects that are no longer in the archive are removed too.
+func (c KubeClient) UninstallManifest(archive string, agId string, reqNamespace string) error {
+
+	objs, manifestNamespace, err := BuildManifests(archive)
+	if err != nil {
+		return err
+	}
+	namespace := getFinalNamespace(reqNamespace, manifestNamespace)
+
+	// The kinds to prune, in the reverse order of install. The namespace is removed last, if the agent created it.
+	kinds := []schema.GroupVersionKind{}
+	seen := map[schema.GroupVersionKind]bool{}
+	for _, obj := range applyManifestOverlay(objs, manifestOverlay{agId: agId, namespace: namespace}) {
+		if gvk := obj.GroupVersionKind(); !seen[gvk] && gvk.Kind != K8S_NAMESPACE_TYPE {
+			seen[gvk] = true
+			kinds = append([]schema.GroupVersionKind{gvk}, kinds...)
+		}
+	}
+	kinds = append(kinds, corev1.SchemeGroupVersion.WithKind(K8S_NAMESPACE_TYPE))
+
+	selector := fmt.Sprintf("%v=%v", MANIFEST_OWNER_LABEL, ManifestOwnerLabelValue(agId))
+	propagation := metav1.DeletePropagationBackground
+	mapper := c.newRESTMapper()
+	for _, gvk := range kinds {
+		if gvk.Kind == K8S_NAMESPACE_TYPE {
+			c.DeleteConfigMap(agId, namespace)
+			c.DeleteESSAuthSecrets(agId, namespace)
+			c.DeleteESSCertSecrets(agId, namespace)
+			c.DeleteK8SSecrets(agId, namespace)
+			c.deleteNetworkPolicy(agId, namespace)
+		}
+
+		resClient, _, err := c.resourceFor(mapper, gvk, namespace, 0)
+		if err != nil {
+			glog.Errorf(kwlog(fmt.Sprintf("unable to remove objects of kind %v: %v", gvk.Kind, err)))
+			continue
+		}
+		list, err := resClient.List(context.Background(), metav1.ListOptions{LabelSelector: selector})
+		if err != nil {
+			glog.Errorf(kwlog(fmt.Sprintf("unable to list objects of kind %v: %v", gvk.Kind, err)))
+			continue
+		}
+		for _, item := range list.Items {
+			if gvk.Kind == K8S_NAMESPACE_TYPE && item.GetName() == cutil.GetClusterNamespace() {
+				continue
+			} else if !manifestObjectOwned(&item, agId) {
+				glog.Warningf(kwlog(fmt.Sprintf("%v %v has the owner label of agreement %v but is not owned by it, it is not removed", gvk.Kind, item.GetName(), agId)))
+				continue
+			}
+			glog.Infof(kwlog(fmt.Sprintf("attempting to uninstall %v %v", gvk.Kind, item.GetName())))
+			if err := resClient.Delete(context.Background(), item.GetName(), metav1.DeleteOptions{PropagationPolicy: &propagation}); err != nil && !errors.IsNotFound(err) {
+				glog.Errorf(kwlog(fmt.Sprintf("unable to delete %v %v: %v", gvk.Kind, item.GetName(), err)))
+			}
+		}
+	}
+
+	glog.V(3).Infof(kwlog(fmt.Sprintf("Completed removal of all manifest objects of agreement %v from the cluster.", agId)))
+	return nil
+}
+
+// ManifestStatus returns the rollout status of the workloads in the manifest archive. A workload is Running when all
+// of its replicas are updated and ready.
+func (c KubeClient) ManifestStatus(archive string, agId string, reqNamespace string) ([]ContainerStatus, error) {
+
+	objs, manifestNamespace, err := BuildManifests(archive)
+	if err != nil {
+		return nil, err
+	}
+	namespace := getFinalNamespace(reqNamespace, manifestNamespace)
+
+	statuses := []ContainerStatus{}
+	mapper := c.newRESTMapper()
+	for _, obj := range objs {
+		if kind := obj.GetKind(); kind != "Deployment" && kind != "StatefulSet" && kind != "DaemonSet" {
+			continue
+		}
+
+		resClient, _, err := c.resourceFor(mapper, obj.GroupVersionKind(), namespace, 0)
+		if err != nil {
+			return nil, err
+		}
+		status := ContainerStatus{Name: fmt.Sprintf("%v/%v", obj.GetKind(), obj.GetName())}
+		if containers := podContainers(obj); len(containers) != 0 {
+			status.Image, _ = containers[len(containers)-1]["image"].(string)
+		}
+
+		if current, err := resClient.Get(context.Background(), obj.GetName(), metav1.GetOptions{}); err != nil && errors.IsNotFound(err) {
+			status.State = "Missing"
+		} else if err != nil {
+			return nil, err
+		} else {
+			status.CreatedTime = current.GetCreationTimestamp().Unix()
+			status.State = rolloutState(current)
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+// Returns Running if the rollout of the workload is complete, otherwise the progress of the rollout.
+func rolloutState(obj *unstructured.Unstructured) string {
+	var desired, updated, ready int64
+	generation := obj.GetGeneration()
+	observed, _, _ := unstructured.NestedInt64(obj.Object, "status", "observedGeneration")
+
+	if obj.GetKind() == "DaemonSet" {
+		desired, _, _ = unstructured.NestedInt64(obj.Object, "status", "desiredNumberScheduled")
+		updated, _, _ = unstructured.NestedInt64(obj.Object, "status", "updatedNumberScheduled")
+		ready, _, _ = unstructured.NestedInt64(obj.Object, "status", "numberReady")
+	} else {
+		var found bool
+		if desired, found, _ = unstructured.NestedInt64(obj.Object, "spec", "replicas"); !found {
+			desired = 1
+		}
+		updated, _, _ = unstructured.NestedInt64(obj.Object, "status", "updatedReplicas")
+		ready, _, _ = unstructured.NestedInt64(obj.Object, "status", "readyReplicas")
+	}
+
+	if observed >= generation && updated >= desired && ready >= desired {
+		return "Running"
+	}
+	return fmt.Sprintf("Progressing (%v/%v ready)", ready, desired)
+}
+
+// UpdateManifestSecrets updates the values of the service secrets of the agreement. The secrets are mounted in the
+// containers, so the services see the new values without being restarted.
+func (c KubeClient) UpdateManifestSecrets(archive string, agId string, reqNamespace string, updatedSecretsMap map[string]string) error {
+
+	_, manifestNamespace, err := BuildManifests(archive)
+	if err != nil {
+		return err
+	}
+	namespace := getFinalNamespace(reqNamespace, manifestNamespace)
+
+	if len(updatedSecretsMap) == 0 {
+		glog.V(3).Infof(kwlog(fmt.Sprintf("No updated service secrets for agreement %v in namespace %v, skip updating", agId, namespace)))
+		return nil
+	}
+
+	secretsName := fmt.Sprintf("%s-%s", HZN_SERVICE_SECRETS, agId)
+	k8sSecretObject, err := c.Client.CoreV1().Secrets(namespace).Get(context.Background(), secretsName, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+
+	// The values in the secrets map are base64 encoded.
+	decodedSecrets, err := decodeServiceSecret(updatedSecretsMap)
+	if err != nil {
+		return err
+	}
+	if k8sSecretObject.Data == nil {
+		k8sSecretObject.Data = map[string][]byte{}
+	}
+	for name, value := range decodedSecrets {
+		k8sSecretObject.Data[name] = []byte(value)
+	}
+	if _, err := c.Client.CoreV1().Secrets(namespace).Update(context.Background(), k8sSecretObject, metav1.UpdateOptions{}); err != nil {
+		return err
+	}
+
+	glog.V(3).Infof(kwlog(fmt.Sprintf("Successfully update the service secrets in namespace %v", namespace)))
+	return nil
+}
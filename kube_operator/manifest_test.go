@@ -0,0 +1,257 @@
+//go:build unit
+// +build unit
+
+package kube_operator
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/open-horizon/anax/config"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+const testDeployment = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  selector:
+    matchLabels:
+      app: web
+  template:
+    metadata:
+      labels:
+        app: web
+    spec:
+      containers:
+      - name: web
+        image: nginx:1.25
+`
+
+const testService = `apiVersion: v1
+kind: Service
+metadata:
+  name: web
+spec:
+  selector:
+    app: web
+  ports:
+  - port: 80
+`
+
+// Returns a base64 encoded tar.gz archive of the files.
+func makeArchive(t *testing.T, files map[string]string) string {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for name, content := range files {
+		hdr := &tar.Header{Name: name, Mode: 0644, Size: int64(len(content)), Typeflag: tar.TypeReg}
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatalf("unable to write archive header, error: %v", err)
+		} else if _, err := tw.Write([]byte(content)); err != nil {
+			t.Fatalf("unable to write archive entry, error: %v", err)
+		}
+	}
+	tw.Close()
+	gz.Close()
+	return base64.StdEncoding.EncodeToString(buf.Bytes())
+}
+
+func findObject(objs []*unstructured.Unstructured, kind string, name string) *unstructured.Unstructured {
+	for _, obj := range objs {
+		if obj.GetKind() == kind && obj.GetName() == name {
+			return obj
+		}
+	}
+	return nil
+}
+
+func Test_BuildManifests_plain(t *testing.T) {
+
+	archive := makeArchive(t, map[string]string{
+		"app/web.yaml":  testDeployment + "---\n" + testService,
+		"app/ns.yaml":   "apiVersion: v1\nkind: Namespace\nmetadata:\n  name: web-ns\n",
+		"app/README.md": "not a manifest",
+	})
+
+	objs, namespace, err := BuildManifests(archive)
+	if err != nil {
+		t.Fatalf("unexpected error building manifests: %v", err)
+	} else if len(objs) != 3 {
+		t.Errorf("expected 3 objects, got %v", len(objs))
+	} else if namespace != "web-ns" {
+		t.Errorf("expected namespace web-ns, got %v", namespace)
+	}
+}
+
+func Test_BuildManifests_kustomize(t *testing.T) {
+
+	archive := makeArchive(t, map[string]string{
+		"base/kustomization.yaml": "resources:\n- deployment.yaml\n- service.yaml\n",
+		"base/deployment.yaml":    testDeployment,
+		"base/service.yaml":       testService,
+		"overlays/prod/kustomization.yaml": `resources:
+- ../../base
+namespace: prod
+commonLabels:
+  tier: frontend
+images:
+- name: nginx
+  newTag: "1.26"
+patches:
+- path: replicas.yaml
+configMapGenerator:
+- name: web-config
+  literals:
+  - mode=prod
+`,
+		"overlays/prod/replicas.yaml": "apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: web\nspec:\n  replicas: 3\n",
+	})
+
+	objs, namespace, err := BuildManifests(archive)
+	if err != nil {
+		t.Fatalf("unexpected error building kustomization: %v", err)
+	} else if namespace != "prod" {
+		t.Errorf("expected namespace prod, got %v", namespace)
+	} else if len(objs) != 3 {
+		t.Fatalf("expected 3 objects, got %v", len(objs))
+	}
+
+	// Generated config maps get a hash suffix.
+	for _, obj := range objs {
+		if obj.GetKind() == "ConfigMap" && (!strings.HasPrefix(obj.GetName(), "web-config-") || obj.GetNamespace() != "prod") {
+			t.Errorf("unexpected generated config map %v in namespace %v", obj.GetName(), obj.GetNamespace())
+		}
+	}
+
+	dep := findObject(objs, "Deployment", "web")
+	if dep == nil {
+		t.Fatalf("deployment not found in %v", objs)
+	} else if dep.GetNamespace() != "prod" {
+		t.Errorf("expected namespace prod, got %v", dep.GetNamespace())
+	} else if replicas, _, _ := unstructured.NestedInt64(dep.Object, "spec", "replicas"); replicas != 3 {
+		t.Errorf("patch was not applied, replicas is %v", replicas)
+	} else if image := podContainers(dep)[0]["image"]; image != "nginx:1.26" {
+		t.Errorf("image was not replaced, image is %v", image)
+	} else if selector, _, _ := unstructured.NestedStringMap(dep.Object, "spec", "selector", "matchLabels"); selector["tier"] != "frontend" || selector["app"] != "web" {
+		t.Errorf("common labels were not added to the selector: %v", selector)
+	}
+
+	// The patch must keep the containers of the base, a strategic merge patch merges lists by key.
+	if len(podContainers(dep)) != 1 {
+		t.Errorf("expected 1 container, got %v", podContainers(dep))
+	}
+
+	svc := findObject(objs, "Service", "web")
+	if selector, _, _ := unstructured.NestedStringMap(svc.Object, "spec", "selector"); selector["tier"] != "frontend" {
+		t.Errorf("common labels were not added to the service selector: %v", selector)
+	}
+}
+
+func Test_BuildManifests_errors(t *testing.T) {
+
+	tests := map[string]map[string]string{
+		"helm chart":        {"kustomization.yaml": "helmCharts:\n- name: web\n  repo: https://example.com/charts\n"},
+		"remote resource":   {"kustomization.yaml": "resources:\n- https://example.com/web.yaml\n"},
+		"remote repository": {"kustomization.yaml": "resources:\n- github.com/example/web/deploy?ref=v1\n"},
+		"remote patch":      {"kustomization.yaml": "resources:\n- web.yaml\npatches:\n- path: https://example.com/patch.yaml\n", "web.yaml": testDeployment},
+		"invalid patch":     {"kustomization.yaml": "resources:\n- web.yaml\npatches:\n- path: missing.yaml\n", "web.yaml": testDeployment},
+		"missing resource":  {"kustomization.yaml": "resources:\n- web.yaml\n"},
+		"two roots":         {"a/kustomization.yaml": "resources: []\n", "b/kustomization.yaml": "resources: []\n"},
+		"outside archive":   {"../web.yaml": testDeployment},
+		"unnamed object":    {"web.yaml": "apiVersion: v1\nkind: ConfigMap\nmetadata: {}\n"},
+		"no objects":        {"README.md": "nothing"},
+	}
+
+	for name, files := range tests {
+		if _, _, err := BuildManifests(makeArchive(t, files)); err == nil {
+			t.Errorf("%v: expected an error", name)
+		}
+	}
+}
+
+func Test_applyManifestOverlay(t *testing.T) {
+
+	archive := makeArchive(t, map[string]string{
+		"web.yaml": testService + "---\n" + testDeployment + "---\napiVersion: v1\nkind: Namespace\nmetadata:\n  name: other\n",
+	})
+	objs, _, err := BuildManifests(archive)
+	if err != nil {
+		t.Fatalf("unexpected error building manifests: %v", err)
+	}
+
+	overlay := manifestOverlay{agId: "ag1", namespace: "svc-ns", configMapName: "hzn-env-vars-ag1", secretsName: "hzn-service-secrets-ag1"}
+	objs = applyManifestOverlay(objs, overlay)
+
+	if len(objs) != 2 {
+		t.Fatalf("expected the other namespace to be dropped, got %v objects", len(objs))
+	} else if objs[0].GetKind() != "Service" || objs[1].GetKind() != "Deployment" {
+		t.Errorf("objects are not in install order: %v %v", objs[0].GetKind(), objs[1].GetKind())
+	}
+
+	for _, obj := range objs {
+		if obj.GetLabels()[MANIFEST_OWNER_LABEL] != ManifestOwnerLabelValue("ag1") {
+			t.Errorf("%v does not have the owner label: %v", obj.GetKind(), obj.GetLabels())
+		} else if obj.GetAnnotations()[MANIFEST_AGREEMENT_ANNOTATION] != "ag1" {
+			t.Errorf("%v does not have the agreement annotation: %v", obj.GetKind(), obj.GetAnnotations())
+		} else if obj.GetNamespace() != "svc-ns" {
+			t.Errorf("%v is in namespace %v", obj.GetKind(), obj.GetNamespace())
+		}
+	}
+
+	dep := objs[1]
+	if labels, _, _ := unstructured.NestedStringMap(dep.Object, "spec", "template", "metadata", "labels"); labels[MANIFEST_OWNER_LABEL] == "" {
+		t.Errorf("pod template does not have the owner label: %v", labels)
+	}
+	container := podContainers(dep)[0]
+	if envFrom, ok := container["envFrom"].([]interface{}); !ok || len(envFrom) != 1 {
+		t.Errorf("container does not get the env vars config map: %v", container)
+	} else if mounts, ok := container["volumeMounts"].([]interface{}); !ok || len(mounts) != 1 {
+		t.Errorf("container does not mount the service secrets: %v", container)
+	} else if mounts[0].(map[string]interface{})["mountPath"] != config.HZN_SECRETS_MOUNT {
+		t.Errorf("service secrets are mounted at %v", mounts[0])
+	}
+
+	// the objects applied for the agreement are owned by it, objects of other agreements or of the cluster are not
+	if !manifestObjectOwned(dep, "ag1") {
+		t.Errorf("deployment is not owned by its agreement: %v", dep.GetLabels())
+	} else if manifestObjectOwned(dep, "ag2") {
+		t.Errorf("deployment is owned by another agreement: %v", dep.GetLabels())
+	}
+	dep.SetAnnotations(nil)
+	if manifestObjectOwned(dep, "ag1") {
+		t.Errorf("deployment without the agreement annotation is owned by the agreement")
+	}
+}
+
+func Test_rolloutState(t *testing.T) {
+
+	dep := &unstructured.Unstructured{Object: map[string]interface{}{
+		"kind":     "Deployment",
+		"metadata": map[string]interface{}{"name": "web", "generation": int64(2)},
+		"spec":     map[string]interface{}{"replicas": int64(2)},
+		"status":   map[string]interface{}{"observedGeneration": int64(2), "updatedReplicas": int64(2), "readyReplicas": int64(1)},
+	}}
+	if state := rolloutState(dep); state != "Progressing (1/2 ready)" {
+		t.Errorf("unexpected state %v", state)
+	}
+
+	unstructured.SetNestedField(dep.Object, int64(2), "status", "readyReplicas")
+	if state := rolloutState(dep); state != "Running" {
+		t.Errorf("unexpected state %v", state)
+	}
+
+	ds := &unstructured.Unstructured{Object: map[string]interface{}{
+		"kind":     "DaemonSet",
+		"metadata": map[string]interface{}{"name": "agent"},
+		"status":   map[string]interface{}{"desiredNumberScheduled": int64(3), "updatedNumberScheduled": int64(3), "numberReady": int64(3)},
+	}}
+	if state := rolloutState(ds); state != "Running" {
+		t.Errorf("unexpected state %v", state)
+	}
+}
@@ -10,6 +10,8 @@ import (
 	_ "github.com/open-horizon/anax/agreementbot/persistence/bolt"
 	_ "github.com/open-horizon/anax/agreementbot/persistence/postgresql"
 	agbotSecretsImpl "github.com/open-horizon/anax/agreementbot/secrets"
+	_ "github.com/open-horizon/anax/agreementbot/secrets/file"
+	_ "github.com/open-horizon/anax/agreementbot/secrets/kubernetes"
 	_ "github.com/open-horizon/anax/agreementbot/secrets/vault"
 	"github.com/open-horizon/anax/api"
 	"github.com/open-horizon/anax/changes"
@@ -18,6 +20,7 @@ import (
 	"github.com/open-horizon/anax/container"
 	"github.com/open-horizon/anax/download"
 	"github.com/open-horizon/anax/exchange"
+	_ "github.com/open-horizon/anax/externalpolicy/cel_language"
 	_ "github.com/open-horizon/anax/externalpolicy/text_language"
 	"github.com/open-horizon/anax/governance"
 	"github.com/open-horizon/anax/i18n"
@@ -26,17 +29,18 @@ import (
 	"github.com/open-horizon/anax/kube_operator"
 	"github.com/open-horizon/anax/nodemanagement"
 	"github.com/open-horizon/anax/persistence"
+	_ "github.com/open-horizon/anax/persistence/postgresql"
 	"github.com/open-horizon/anax/policy"
 	"github.com/open-horizon/anax/resource"
+	"github.com/open-horizon/anax/systemd"
+	"github.com/open-horizon/anax/tracing"
+	"github.com/open-horizon/anax/version"
 	"github.com/open-horizon/anax/worker"
-	bolt "go.etcd.io/bbolt"
 	"os"
 	"os/signal"
-	"path"
 	"runtime"
 	"runtime/pprof"
 	"syscall"
-	"time"
 )
 
 // The core of anax is an event handling system that distributes events to workers, where the workers
@@ -72,18 +76,15 @@ func main() {
 	// eventlog messages.
 	i18n.InitMessagePrinter(true)
 
-	// open edge DB if necessary
-	var db *bolt.DB
-	if len(cfg.Edge.DBPath) != 0 {
-		if err := os.MkdirAll(cfg.Edge.DBPath, 0700); err != nil {
-			panic(err)
-		}
+	// Tracing is optional, anax keeps running without it when the exporter cannot be set up.
+	if err := tracing.Init(&cfg.Tracing, version.HORIZON_VERSION); err != nil {
+		glog.Errorf("Unable to initialize tracing, continuing without it: %v", err)
+	}
 
-		edgeDB, err := bolt.Open(path.Join(cfg.Edge.DBPath, "anax.db"), 0600, &bolt.Options{Timeout: 10 * time.Second})
-		if err != nil {
-			panic(err)
-		}
-		db = edgeDB
+	// open edge DB if necessary
+	db, err := persistence.InitDatabase(cfg)
+	if err != nil {
+		panic(err)
 	}
 
 	// open Agreement Bot DB if necessary
@@ -117,17 +118,8 @@ func main() {
 		glog.Infof("Closing up shop.")
 
 		pprof.StopCPUProfile()
-		if db != nil {
-			db.Close()
-			// remove the local db
-			dbFile := path.Join(cfg.Edge.DBPath, "anax.db")
-			glog.Infof("Removing local db file %v.", dbFile)
-			if persistence.GetRemoveDatabaseOnExit() {
-				if err := os.Remove(dbFile); err != nil {
-					glog.Infof("Error Removing local db file %v, error %v", dbFile, err)
-				}
-			}
-		}
+		tracing.Shutdown()
+		closeEdgeDB(db)
 		if agbotDB != nil {
 			agbotDB.Close()
 		}
@@ -188,6 +180,9 @@ func main() {
 		if imageWorker := imagefetch.NewImageFetchWorker("ImageFetch", cfg, db); imageWorker != nil {
 			workers.Add(imageWorker)
 		}
+		if systemdWorker := systemd.NewSystemdWorker("Systemd", cfg, db, secretm); systemdWorker != nil {
+			workers.Add(systemdWorker)
+		}
 		workers.Add(kube_operator.NewKubeWorker("Kube", cfg, db, authm, secretm))
 		workers.Add(resource.NewResourceWorker("Resource", cfg, db, authm))
 		workers.Add(changes.NewChangesWorker("ExchangeChanges", cfg, db))
@@ -203,18 +198,8 @@ func main() {
 	// Get into the event processing loop until anax shuts itself down.
 	workers.ProcessEventMessages()
 
-	if db != nil {
-		db.Close()
-
-		// remove the local db
-		dbFile := path.Join(cfg.Edge.DBPath, "anax.db")
-		glog.Infof("Removing local db file %v.", dbFile)
-		if persistence.GetRemoveDatabaseOnExit() {
-			if err := os.Remove(dbFile); err != nil {
-				glog.Infof("Error Removing local db file %v, error %v", dbFile, err)
-			}
-		}
-	}
+	tracing.Shutdown()
+	closeEdgeDB(db)
 
 	if agbotDB != nil {
 		agbotDB.Close()
@@ -222,3 +207,17 @@ func main() {
 
 	glog.Info("Main process terminating")
 }
+
+// Close the edge DB, removing its content if the node was unregistered.
+func closeEdgeDB(db persistence.AgentDatabase) {
+	if db == nil {
+		return
+	} else if persistence.GetRemoveDatabaseOnExit() {
+		glog.Infof("Removing local db.")
+		if err := db.Remove(); err != nil {
+			glog.Infof("Error Removing local db, error %v", err)
+		}
+	} else {
+		db.Close()
+	}
+}
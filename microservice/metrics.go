@@ -0,0 +1,43 @@
+package microservice
+
+import (
+	"github.com/open-horizon/anax/cutil"
+	"github.com/open-horizon/anax/persistence"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// The metrics about the service instances that have failed on the node, exposed by the agent /metrics API. The
+// governance worker restarts a failed service instance until it runs out of retries, then it rolls the service back
+// to a lower version.
+
+var ServiceRestartsTotal = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Namespace: "horizon",
+		Subsystem: "agent",
+		Name:      "service_restarts_total",
+		Help:      "Number of times a failed service instance was restarted, by service.",
+	},
+	[]string{"service"},
+)
+
+var ServiceRollbacksTotal = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Namespace: "horizon",
+		Subsystem: "agent",
+		Name:      "service_rollbacks_total",
+		Help:      "Number of times a failed service was rolled back to a lower version, by service and result.",
+	},
+	[]string{"service", "result"},
+)
+
+func CountServiceRestart(msdef *persistence.MicroserviceDefinition) {
+	ServiceRestartsTotal.WithLabelValues(cutil.FormOrgSpecUrl(msdef.SpecRef, msdef.Org)).Inc()
+}
+
+func CountServiceRollback(msdef *persistence.MicroserviceDefinition, err error) {
+	result := "success"
+	if err != nil {
+		result = "failure"
+	}
+	ServiceRollbacksTotal.WithLabelValues(cutil.FormOrgSpecUrl(msdef.SpecRef, msdef.Org), result).Inc()
+}
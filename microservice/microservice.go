@@ -13,7 +13,6 @@ import (
 	"github.com/open-horizon/anax/persistence"
 	"github.com/open-horizon/anax/policy"
 	"github.com/open-horizon/anax/semanticversion"
-	bolt "go.etcd.io/bbolt"
 	"golang.org/x/crypto/sha3"
 	"strconv"
 	"strings"
@@ -33,6 +32,7 @@ const MS_DELETED_BY_UPGRADE_PROCESS = 205
 const MS_DELETED_FOR_AG_ENDED = 206
 const MS_IMAGE_FETCH_FAILED = 207
 const MS_DELETED_BY_DOWNGRADE_PROCESS = 208
+const MS_HEALTH_CHECK_FAILED = 209
 
 func DecodeReasonCode(code uint64) string {
 	// microservice termiated deccription
@@ -46,6 +46,7 @@ func DecodeReasonCode(code uint64) string {
 		MS_DELETED_BY_DOWNGRADE_PROCESS: "Deleted by downgrading process",
 		MS_DELETED_FOR_AG_ENDED:         "Deleted for agreement ended",
 		MS_IMAGE_FETCH_FAILED:           "Image fetching failed",
+		MS_HEALTH_CHECK_FAILED:          "Health check failed",
 	}
 
 	if reasonString, ok := codeMeanings[code]; !ok {
@@ -129,14 +130,14 @@ func ConvertServiceToPersistent(es *exchange.ServiceDefinition, org string) (*pe
 func ConvertRequiredServicesToExchange(m *persistence.MicroserviceDefinition) *[]exchangecommon.ServiceDependency {
 	reqServs := make([]exchangecommon.ServiceDependency, 0)
 	for _, rs := range m.RequiredServices {
-		sd := exchangecommon.ServiceDependency{URL: rs.URL, Org: rs.Org, Version: rs.Version, Arch: rs.Arch}
+		sd := exchangecommon.ServiceDependency{URL: rs.URL, Org: rs.Org, Version: rs.Version, Arch: rs.Arch, Readiness: rs.Readiness}
 		reqServs = append(reqServs, sd)
 	}
 	return &reqServs
 }
 
 // check if the given msdef is eligible for a upgrade
-func MicroserviceReadyForUpgrade(msdef *persistence.MicroserviceDefinition, db *bolt.DB) bool {
+func MicroserviceReadyForUpgrade(msdef *persistence.MicroserviceDefinition, db persistence.AgentDatabase) bool {
 	glog.V(5).Infof("Check if service %v/%v is available for a upgrade.", msdef.Org, msdef.SpecRef)
 
 	if msdef.Archived {
@@ -194,7 +195,7 @@ func MicroserviceReadyForUpgrade(msdef *persistence.MicroserviceDefinition, db *
 // This function gets the msdef with highest version within defined version range from the exchange and
 // compare the version and content with the current msdef and decide if it needs to upgrade.
 // It returns the new msdef if the old one needs to be upgraded, otherwide return nil.
-func GetUpgradeMicroserviceDef(getService exchange.ServiceResolverHandler, msdef *persistence.MicroserviceDefinition, db *bolt.DB) (*persistence.MicroserviceDefinition, error) {
+func GetUpgradeMicroserviceDef(getService exchange.ServiceResolverHandler, msdef *persistence.MicroserviceDefinition, db persistence.AgentDatabase) (*persistence.MicroserviceDefinition, error) {
 	glog.V(3).Infof("Get new service def for upgrading service %v/%v version %v key %v", msdef.Org, msdef.SpecRef, msdef.Version, msdef.Id)
 
 	// convert the sensor version to a version expression
@@ -239,7 +240,7 @@ func GetUpgradeMicroserviceDef(getService exchange.ServiceResolverHandler, msdef
 }
 
 // Get a msdef with a lower version compared to the given msdef version and return the new microservice def.
-func GetRollbackMicroserviceDef(getService exchange.ServiceResolverHandler, msdef *persistence.MicroserviceDefinition, db *bolt.DB) (*persistence.MicroserviceDefinition, error) {
+func GetRollbackMicroserviceDef(getService exchange.ServiceResolverHandler, msdef *persistence.MicroserviceDefinition, db persistence.AgentDatabase) (*persistence.MicroserviceDefinition, error) {
 	glog.V(3).Infof("Get next highest service def for rolling back service %v/%v version %v key %v", msdef.Org, msdef.SpecRef, msdef.Version, msdef.Id)
 
 	// convert the sensor version to a version expression
@@ -299,7 +300,7 @@ func RemoveMicroservicePolicy(spec_ref string, org string, version string, msdef
 }
 
 // Generate a new policy file for given ms and then register the microservice in the exchange.
-func GenMicroservicePolicy(msdef *persistence.MicroserviceDefinition, policyPath string, db *bolt.DB, e chan events.Message, deviceOrg string, pattern string) error {
+func GenMicroservicePolicy(msdef *persistence.MicroserviceDefinition, policyPath string, db persistence.AgentDatabase, e chan events.Message, deviceOrg string, pattern string) error {
 	glog.V(3).Infof("Generate policy for the given service %v/%v version %v key %v", msdef.Org, msdef.SpecRef, msdef.Version, msdef.Id)
 
 	var serviceAgreementProtocols []interface{}
@@ -402,7 +403,7 @@ func GenMicroservicePolicy(msdef *persistence.MicroserviceDefinition, policyPath
 func UnregisterMicroserviceExchange(getExchangeDevice exchange.DeviceHandler,
 	patchExchangeDevice exchange.PatchDeviceHandler,
 	spec_ref string, org string, version string,
-	device_id string, device_token string, db *bolt.DB) error {
+	device_id string, device_token string, db persistence.AgentDatabase) error {
 
 	glog.V(3).Infof("Unregister service %v/%v from exchange for %v.", org, spec_ref, device_id)
 
@@ -442,7 +443,7 @@ func UnregisterMicroserviceExchange(getExchangeDevice exchange.DeviceHandler,
 // If exactVersion is false, the service_version is treated as a version range,
 // the microservice definiton within the range will be returned if found instances. If not found,
 // the microservice definition for the highest version within the range will be returned.
-func FindOrCreateMicroserviceDef(db *bolt.DB, service_name string, service_org string, service_version string, service_arch string,
+func FindOrCreateMicroserviceDef(db persistence.AgentDatabase, service_name string, service_org string, service_version string, service_arch string,
 	exactVersion bool, forPattern bool, getService exchange.ServiceHandler) (*persistence.MicroserviceDefinition, error) {
 	glog.V(5).Infof("Find or create MicroserviceDefinition object for %v/%v version %v", service_org, service_name, service_version)
 
@@ -542,7 +543,7 @@ func FindOrCreateMicroserviceDef(db *bolt.DB, service_name string, service_org s
 
 // Create and save the MicroserviceDefiniton for given service. The service_version is a version range.
 // Please make sure there is no MicroserviceDefinition for this service before calling this function.
-func CreateMicroserviceDef(db *bolt.DB, service_name string, service_org string, service_version string, service_arch string,
+func CreateMicroserviceDef(db persistence.AgentDatabase, service_name string, service_org string, service_version string, service_arch string,
 	getService exchange.ServiceHandler) (*persistence.MicroserviceDefinition, error) {
 	glog.V(3).Infof("Create service definition for local db for %v/%v version range %v.", service_org, service_name, service_version)
 
@@ -572,7 +573,7 @@ func CreateMicroserviceDef(db *bolt.DB, service_name string, service_org string,
 // for top level service when agreement is formated. The version is not a version range.
 // upgradeVerExpr is a full semantic version expression, representing upgrade or downgrade version range.
 // Please make sure there is no MicroserviceDefinition for this service before calling this function.
-func CreateMicroserviceDefWithServiceDef(db *bolt.DB, sdef *exchange.ServiceDefinition, sId string, upgradeVerExpr string) (*persistence.MicroserviceDefinition, error) {
+func CreateMicroserviceDefWithServiceDef(db persistence.AgentDatabase, sdef *exchange.ServiceDefinition, sId string, upgradeVerExpr string) (*persistence.MicroserviceDefinition, error) {
 	glog.V(3).Infof("Create service definition in local db for %v", sId) // sId: e2edev@somecomp.com/k8s-service-embedded-ns_1.0.0_amd64
 
 	// Convert the service definition to a persistent format so that it can be saved to the db.
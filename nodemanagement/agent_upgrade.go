@@ -12,7 +12,6 @@ import (
 	"github.com/open-horizon/anax/persistence"
 	"github.com/open-horizon/anax/semanticversion"
 	"github.com/open-horizon/anax/version"
-	bolt "go.etcd.io/bbolt"
 	"os"
 	"path"
 	"sort"
@@ -184,7 +183,7 @@ func (n *NodeManagementWorker) CollectStatus(workingFolderPath string, policyNam
 
 // Check if the current agent versions are up to date for software, cert and config according to
 // the specification of the nmp. The NMP must have at least one 'latest' as the version string.
-func IsAgentUpToDate(status *exchangecommon.NodeManagementPolicyStatus, exchAFVs *exchangecommon.AgentFileVersions, db *bolt.DB) (bool, error) {
+func IsAgentUpToDate(status *exchangecommon.NodeManagementPolicyStatus, exchAFVs *exchangecommon.AgentFileVersions, db persistence.AgentDatabase) (bool, error) {
 	// get local device info
 	dev, err := persistence.FindExchangeDevice(db)
 	if err != nil || dev == nil {
@@ -12,7 +12,6 @@ import (
 	"github.com/open-horizon/anax/externalpolicy"
 	"github.com/open-horizon/anax/persistence"
 	"github.com/open-horizon/anax/worker"
-	bolt "go.etcd.io/bbolt"
 	"strings"
 	"sync"
 )
@@ -21,10 +20,10 @@ var statusUpdateLock sync.Mutex
 
 type NodeManagementWorker struct {
 	worker.BaseWorker
-	db *bolt.DB
+	db persistence.AgentDatabase
 }
 
-func NewNodeManagementWorker(name string, config *config.HorizonConfig, db *bolt.DB) *NodeManagementWorker {
+func NewNodeManagementWorker(name string, config *config.HorizonConfig, db persistence.AgentDatabase) *NodeManagementWorker {
 	ec := getEC(config, db)
 
 	worker := &NodeManagementWorker{
@@ -155,7 +154,7 @@ func (w *NodeManagementWorker) checkNMPTimeToRun() int {
 	return 60
 }
 
-func getEC(config *config.HorizonConfig, db *bolt.DB) *worker.BaseExchangeContext {
+func getEC(config *config.HorizonConfig, db persistence.AgentDatabase) *worker.BaseExchangeContext {
 	var ec *worker.BaseExchangeContext
 	if dev, _ := persistence.FindExchangeDevice(db); dev != nil {
 		ec = worker.NewExchangeContext(fmt.Sprintf("%v/%v", dev.Org, dev.Id), dev.Token, config.Edge.ExchangeURL, config.GetCSSURL(), config.Edge.AgbotURL, config.Collaborators.HTTPClientFactory)
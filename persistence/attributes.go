@@ -7,7 +7,6 @@ import (
 	"github.com/open-horizon/anax/cutil"
 	"github.com/open-horizon/anax/externalpolicy"
 	"github.com/satori/go.uuid"
-	bolt "go.etcd.io/bbolt"
 	"reflect"
 	"strconv"
 	"strings"
@@ -234,11 +233,11 @@ func HydrateConcreteAttribute(v []byte) (Attribute, error) {
 }
 
 // FindAttributeByKey is used to fetch a single attribute by its primary key
-func FindAttributeByKey(db *bolt.DB, id string) (*Attribute, error) {
+func FindAttributeByKey(db AgentDatabase, id string) (*Attribute, error) {
 	var attr Attribute
-	var bucket *bolt.Bucket
+	var bucket Bucket
 
-	readErr := db.View(func(tx *bolt.Tx) error {
+	readErr := db.View(func(tx Tx) error {
 		bucket = tx.Bucket([]byte(ATTRIBUTES))
 		if bucket != nil {
 
@@ -274,11 +273,11 @@ func FindAttributeByKey(db *bolt.DB, id string) (*Attribute, error) {
 // For an attribute, if the a.ServiceSpecs is empty, it will be included.
 // Otherwise, if an element in the attrubute's ServiceSpecs array equals to ServiceSpec{serviceUrl, org}
 // the attribute will be included.
-func FindApplicableAttributes(db *bolt.DB, serviceUrl string, org string) ([]Attribute, error) {
+func FindApplicableAttributes(db AgentDatabase, serviceUrl string, org string) ([]Attribute, error) {
 
 	filteredAttrs := []Attribute{}
 
-	return filteredAttrs, db.View(func(tx *bolt.Tx) error {
+	return filteredAttrs, db.View(func(tx Tx) error {
 		bucket := tx.Bucket([]byte(ATTRIBUTES))
 
 		if bucket == nil {
@@ -387,7 +386,7 @@ func AttributesToEnvvarMap(attributes []Attribute, envvars map[string]string, pr
 	return envvars, nil
 }
 
-func FindConflictingAttributes(db *bolt.DB, attribute *Attribute) (*Attribute, error) {
+func FindConflictingAttributes(db AgentDatabase, attribute *Attribute) (*Attribute, error) {
 	var err error
 	var common []Attribute
 	serviceSpecs := GetAttributeServiceSpecs(attribute)
@@ -435,7 +434,7 @@ type ConflictingAttributeFound struct {
 func (e ConflictingAttributeFound) Error() string { return e.msg }
 
 // N.B. It's the caller's responsibility to ensure the attr.ServiceSpecs are deduplicated; use the ServiceSpecs.AddServiceSpec() function to keep the slice clean
-func SaveOrUpdateAttribute(db *bolt.DB, attr Attribute, id string, permitPartialOverwrite bool) (*Attribute, error) {
+func SaveOrUpdateAttribute(db AgentDatabase, attr Attribute, id string, permitPartialOverwrite bool) (*Attribute, error) {
 	var ret *Attribute
 
 	if id == "" {
@@ -490,7 +489,7 @@ func SaveOrUpdateAttribute(db *bolt.DB, attr Attribute, id string, permitPartial
 		(*ret).GetMeta().Publishable = &pT
 	}
 
-	writeErr := db.Update(func(tx *bolt.Tx) error {
+	writeErr := db.Update(func(tx Tx) error {
 		bucket, err := tx.CreateBucketIfNotExists([]byte(ATTRIBUTES))
 		if err != nil {
 			return err
@@ -505,7 +504,7 @@ func SaveOrUpdateAttribute(db *bolt.DB, attr Attribute, id string, permitPartial
 	return ret, writeErr
 }
 
-func DeleteAttribute(db *bolt.DB, id string) (*Attribute, error) {
+func DeleteAttribute(db AgentDatabase, id string) (*Attribute, error) {
 
 	existing, err := FindAttributeByKey(db, id)
 	if err != nil {
@@ -516,7 +515,7 @@ func DeleteAttribute(db *bolt.DB, id string) (*Attribute, error) {
 		return nil, nil
 	}
 
-	delError := db.Update(func(tx *bolt.Tx) error {
+	delError := db.Update(func(tx Tx) error {
 		bucket, err := tx.CreateBucketIfNotExists([]byte(ATTRIBUTES))
 		if err != nil {
 			return err
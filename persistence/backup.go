@@ -0,0 +1,373 @@
+package persistence
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/golang/glog"
+	"github.com/open-horizon/anax/policy"
+	"sort"
+	"time"
+)
+
+// The version of the node backup format. The version is incremented when the content of the backup changes in a
+// way that an older agent cannot restore.
+const NODE_BACKUP_VERSION = 1
+
+// The buckets that are saved in a node backup. These buckets contain the node's registration, configuration and
+// the state of its agreements and services. Buckets that only cache state from the exchange (hashes and last
+// updated times) are not saved, so that the restored node re-synchronizes itself with the exchange.
+var NodeBackupBuckets = append([]string{
+	DEVICES,
+	NODE_EXCH_PATTERN,
+	NODE_POLICY,
+	NODE_USERINPUT,
+	ATTRIBUTES,
+	MICROSERVICE_DEFINITIONS,
+	MICROSERVICE_INSTANCES,
+	NODE_MANAGEMENT_POLICY,
+	NODE_MANAGEMENT_STATUS,
+	SECRETS,
+	AGREEMENT_SECRETS,
+}, agreementBuckets()...)
+
+// The buckets in a node backup that hold the node's agreements and the state of the services started for them. The
+// agreements were made with the node's id, so these buckets are not restored when the node id is changed. The
+// restored node makes new agreements under its new id.
+var NodeAgreementBuckets = append([]string{
+	MICROSERVICE_INSTANCES,
+	SECRETS,
+	AGREEMENT_SECRETS,
+}, agreementBuckets()...)
+
+// The established agreements are kept in a bucket for each agreement protocol.
+func agreementBuckets() []string {
+	buckets := make([]string, 0)
+	for _, protocol := range policy.AllAgreementProtocols() {
+		buckets = append(buckets, E_AGREEMENTS+"-"+protocol)
+	}
+	return buckets
+}
+
+// A single key/value pair in a bucket. Keys and values are byte arrays, they are base64 encoded in the JSON form.
+type NodeBackupEntry struct {
+	Key   []byte `json:"key"`
+	Value []byte `json:"value"`
+}
+
+// The content of the agent database, in a form that can be restored on another host.
+type NodeBackup struct {
+	Version     int                          `json:"version"`
+	AnaxVersion string                       `json:"anaxVersion"`
+	Created     uint64                       `json:"created"`
+	Org         string                       `json:"organization"`
+	NodeId      string                       `json:"nodeId"`
+	Buckets     map[string][]NodeBackupEntry `json:"buckets"`
+}
+
+func (b NodeBackup) String() string {
+	counts := make(map[string]int, len(b.Buckets))
+	for name, entries := range b.Buckets {
+		counts[name] = len(entries)
+	}
+	return fmt.Sprintf("Version: %v, AnaxVersion: %v, Created: %v, Org: %v, NodeId: %v, Buckets: %v", b.Version, b.AnaxVersion, b.Created, b.Org, b.NodeId, counts)
+}
+
+// Secret values are not saved in a backup, only the secret metadata. The agent receives the secret values again
+// from the agbot.
+var nodeBackupFilters = map[string]func([]byte) ([]byte, error){
+	SECRETS: func(value []byte) ([]byte, error) {
+		var secrets PersistedServiceSecrets
+		if err := json.Unmarshal(value, &secrets); err != nil {
+			return nil, err
+		}
+		for _, secret := range secrets.SecretsMap {
+			if secret != nil {
+				secret.SvcSecretValue = ""
+				secret.SvcSecretPreviousValue = ""
+			}
+		}
+		return json.Marshal(secrets)
+	},
+	AGREEMENT_SECRETS: func(value []byte) ([]byte, error) {
+		var secrets []PersistedServiceSecret
+		if err := json.Unmarshal(value, &secrets); err != nil {
+			return nil, err
+		}
+		for ix := range secrets {
+			secrets[ix].SvcSecretValue = ""
+			secrets[ix].SvcSecretPreviousValue = ""
+		}
+		return json.Marshal(secrets)
+	},
+}
+
+// Save the content of the node backup buckets. All the buckets are read in the same transaction, so the backup is
+// consistent.
+func ExportNodeBackup(db AgentDatabase, anaxVersion string) (*NodeBackup, error) {
+
+	backup := &NodeBackup{
+		Version:     NODE_BACKUP_VERSION,
+		AnaxVersion: anaxVersion,
+		Created:     uint64(time.Now().Unix()),
+		Buckets:     make(map[string][]NodeBackupEntry),
+	}
+
+	readErr := db.View(func(tx Tx) error {
+		for _, name := range NodeBackupBuckets {
+			b := tx.Bucket([]byte(name))
+			if b == nil {
+				continue
+			}
+
+			entries := make([]NodeBackupEntry, 0)
+			if err := b.ForEach(func(k, v []byte) error {
+				value := v
+				if filter, ok := nodeBackupFilters[name]; ok {
+					var err error
+					if value, err = filter(v); err != nil {
+						return fmt.Errorf("unable to filter %v in bucket %v, error: %v", string(k), name, err)
+					}
+				}
+				// The key and value are only valid during the transaction, so they are copied.
+				entries = append(entries, NodeBackupEntry{Key: append([]byte{}, k...), Value: append([]byte{}, value...)})
+				return nil
+			}); err != nil {
+				return err
+			}
+			backup.Buckets[name] = entries
+		}
+		return nil
+	})
+
+	if readErr != nil {
+		return nil, readErr
+	}
+
+	if dev, err := backup.exchangeDevice(); err != nil {
+		return nil, err
+	} else if dev != nil {
+		backup.Org = dev.Org
+		backup.NodeId = dev.Id
+	}
+
+	glog.V(3).Infof("Exported node backup %v", backup)
+	return backup, nil
+}
+
+// Return the node object in the backup, or nil if there is none.
+func (b *NodeBackup) exchangeDevice() (*ExchangeDevice, error) {
+	for _, entry := range b.Buckets[DEVICES] {
+		if string(entry.Key) == DEVICES {
+			var dev ExchangeDevice
+			if err := json.Unmarshal(entry.Value, &dev); err != nil {
+				return nil, fmt.Errorf("unable to demarshal node object in backup, error: %v", err)
+			}
+			return &dev, nil
+		}
+	}
+	return nil, nil
+}
+
+// Change the id and token of the node in the backup, so that the backup can be restored as a different node. The
+// id and token are only changed when they are not empty. When the id changes, the agreements and everything tied to
+// them are removed from the backup.
+func (b *NodeBackup) RewriteIdentity(nodeId string, token string) error {
+	if nodeId == "" && token == "" {
+		return nil
+	}
+
+	for ix, entry := range b.Buckets[DEVICES] {
+		if string(entry.Key) != DEVICES {
+			continue
+		}
+
+		var dev ExchangeDevice
+		if err := json.Unmarshal(entry.Value, &dev); err != nil {
+			return fmt.Errorf("unable to demarshal node object in backup, error: %v", err)
+		}
+		if nodeId != "" && nodeId != dev.Id {
+			dev.Id = nodeId
+			b.NodeId = nodeId
+			for _, name := range NodeAgreementBuckets {
+				delete(b.Buckets, name)
+			}
+		}
+		if token != "" {
+			dev.Token = token
+			dev.TokenValid = true
+			dev.TokenLastValidTime = uint64(time.Now().Unix())
+		}
+
+		if serial, err := json.Marshal(dev); err != nil {
+			return fmt.Errorf("unable to serialize node object %v, error: %v", dev, err)
+		} else {
+			b.Buckets[DEVICES][ix].Value = serial
+		}
+		return nil
+	}
+	return errors.New("the backup does not contain a node object")
+}
+
+// Remove the agreements that have secrets from the backup, together with the service instances started for them and
+// their secrets. The secret values are not saved in a backup, so the services of these agreements cannot be restarted
+// with their secrets. The restored node makes new agreements instead, and the agbot sends the secret values with them.
+// Returns the ids of the agreements that were removed.
+func (b *NodeBackup) DropAgreementsWithSecrets() ([]string, error) {
+
+	dropped := make(map[string]bool)
+	for _, entry := range b.Buckets[AGREEMENT_SECRETS] {
+		var secrets []PersistedServiceSecret
+		if err := json.Unmarshal(entry.Value, &secrets); err != nil {
+			return nil, fmt.Errorf("unable to demarshal the secrets of agreement %v in backup, error: %v", string(entry.Key), err)
+		} else if len(secrets) != 0 {
+			dropped[string(entry.Key)] = true
+		}
+	}
+
+	secretInstances := make(map[string]bool)
+	for _, entry := range b.Buckets[SECRETS] {
+		var secrets PersistedServiceSecrets
+		if err := json.Unmarshal(entry.Value, &secrets); err != nil {
+			return nil, fmt.Errorf("unable to demarshal the secrets of service instance %v in backup, error: %v", string(entry.Key), err)
+		}
+		for _, secret := range secrets.SecretsMap {
+			if secret != nil {
+				secretInstances[string(entry.Key)] = true
+				for _, agId := range secret.AgreementIds {
+					dropped[agId] = true
+				}
+			}
+		}
+	}
+
+	instances := make([]MicroserviceInstance, len(b.Buckets[MICROSERVICE_INSTANCES]))
+	for ix, entry := range b.Buckets[MICROSERVICE_INSTANCES] {
+		if err := json.Unmarshal(entry.Value, &instances[ix]); err != nil {
+			return nil, fmt.Errorf("unable to demarshal service instance %v in backup, error: %v", string(entry.Key), err)
+		} else if secretInstances[string(entry.Key)] {
+			for _, agId := range instances[ix].AssociatedAgreements {
+				dropped[agId] = true
+			}
+		}
+	}
+
+	if len(dropped) == 0 {
+		return nil, nil
+	}
+
+	// A service instance is removed when it has secrets or when all of its agreements are removed, the other instances
+	// are only detached from the removed agreements.
+	keptInstances := make([]NodeBackupEntry, 0)
+	for ix, entry := range b.Buckets[MICROSERVICE_INSTANCES] {
+		kept := make([]string, 0)
+		for _, agId := range instances[ix].AssociatedAgreements {
+			if !dropped[agId] {
+				kept = append(kept, agId)
+			}
+		}
+		if secretInstances[string(entry.Key)] || (len(instances[ix].AssociatedAgreements) != 0 && len(kept) == 0) {
+			secretInstances[string(entry.Key)] = true
+			continue
+		} else if len(kept) != len(instances[ix].AssociatedAgreements) {
+			instances[ix].AssociatedAgreements = kept
+			if serial, err := json.Marshal(instances[ix]); err != nil {
+				return nil, fmt.Errorf("unable to serialize service instance %v, error: %v", string(entry.Key), err)
+			} else {
+				entry.Value = serial
+			}
+		}
+		keptInstances = append(keptInstances, entry)
+	}
+	if _, ok := b.Buckets[MICROSERVICE_INSTANCES]; ok {
+		b.Buckets[MICROSERVICE_INSTANCES] = keptInstances
+	}
+
+	removeEntries := func(name string, remove map[string]bool) {
+		if entries, ok := b.Buckets[name]; ok {
+			keptEntries := make([]NodeBackupEntry, 0, len(entries))
+			for _, entry := range entries {
+				if !remove[string(entry.Key)] {
+					keptEntries = append(keptEntries, entry)
+				}
+			}
+			b.Buckets[name] = keptEntries
+		}
+	}
+	for _, name := range agreementBuckets() {
+		removeEntries(name, dropped)
+	}
+	removeEntries(AGREEMENT_SECRETS, dropped)
+	removeEntries(SECRETS, secretInstances)
+
+	agIds := make([]string, 0, len(dropped))
+	for agId := range dropped {
+		agIds = append(agIds, agId)
+	}
+	sort.Strings(agIds)
+	return agIds, nil
+}
+
+// Check that the backup can be restored by this agent.
+func (b *NodeBackup) Validate() error {
+	if b.Version <= 0 || b.Version > NODE_BACKUP_VERSION {
+		return fmt.Errorf("the backup version %v is not supported, the highest supported version is %v", b.Version, NODE_BACKUP_VERSION)
+	}
+
+	backupBuckets := make(map[string]bool, len(NodeBackupBuckets))
+	for _, name := range NodeBackupBuckets {
+		backupBuckets[name] = true
+	}
+	for name := range b.Buckets {
+		if !backupBuckets[name] {
+			return fmt.Errorf("the backup contains bucket %v, which cannot be restored", name)
+		}
+	}
+
+	if dev, err := b.exchangeDevice(); err != nil {
+		return err
+	} else if dev == nil {
+		return errors.New("the backup does not contain a node object")
+	}
+	return nil
+}
+
+// Replace the content of the node backup buckets with the content of the backup. The buckets are replaced in a
+// single transaction, so either the whole backup is restored or nothing is changed.
+func ImportNodeBackup(db AgentDatabase, backup *NodeBackup) error {
+
+	if err := backup.Validate(); err != nil {
+		return err
+	}
+
+	writeErr := db.Update(func(tx Tx) error {
+		for _, name := range NodeBackupBuckets {
+			if b := tx.Bucket([]byte(name)); b != nil {
+				if err := tx.DeleteBucket([]byte(name)); err != nil {
+					return fmt.Errorf("unable to delete bucket %v, error: %v", name, err)
+				}
+			}
+
+			entries, ok := backup.Buckets[name]
+			if !ok {
+				continue
+			}
+
+			b, err := tx.CreateBucketIfNotExists([]byte(name))
+			if err != nil {
+				return fmt.Errorf("unable to create bucket %v, error: %v", name, err)
+			}
+			for _, entry := range entries {
+				if err := b.Put(entry.Key, entry.Value); err != nil {
+					return fmt.Errorf("unable to restore %v in bucket %v, error: %v", string(entry.Key), name, err)
+				}
+			}
+		}
+		return nil
+	})
+
+	if writeErr == nil {
+		glog.V(3).Infof("Imported node backup %v", backup)
+	}
+	return writeErr
+}
@@ -0,0 +1,104 @@
+package persistence
+
+import (
+	"errors"
+	"fmt"
+	"github.com/golang/glog"
+	"github.com/open-horizon/anax/config"
+	bolt "go.etcd.io/bbolt"
+	"os"
+	"path"
+	"time"
+)
+
+// The name of the bolt DB file in the agent's DBPath.
+const BOLTDB_DATABASE_NAME = "anax.db"
+
+func init() {
+	Register("bolt", new(AgentBoltDB))
+}
+
+// The bolt DB implementation of the agent database. This is the default database used by the agent.
+type AgentBoltDB struct {
+	db *bolt.DB
+}
+
+func (db *AgentBoltDB) String() string {
+	return fmt.Sprintf("DB Handle: %p", db.db)
+}
+
+// Open the bolt DB in the agent's DBPath, creating the directory and file if necessary.
+func (db *AgentBoltDB) Initialize(cfg *config.HorizonConfig) error {
+
+	if err := os.MkdirAll(cfg.Edge.DBPath, 0700); err != nil {
+		return errors.New(fmt.Sprintf("unable to create directory %v for bolt DB configuration, error: %v", cfg.Edge.DBPath, err))
+	}
+
+	return db.open(path.Join(cfg.Edge.DBPath, BOLTDB_DATABASE_NAME))
+}
+
+// Open a bolt DB file as an agent database. This is used by tools and tests that work on a specific DB file.
+func OpenBoltDatabase(dbFile string) (*AgentBoltDB, error) {
+	db := new(AgentBoltDB)
+	if err := db.open(dbFile); err != nil {
+		return nil, err
+	}
+	return db, nil
+}
+
+func (db *AgentBoltDB) open(dbFile string) error {
+	if edgeDB, err := bolt.Open(dbFile, 0600, &bolt.Options{Timeout: 10 * time.Second}); err != nil {
+		return errors.New(fmt.Sprintf("unable to open bolt database %v, error: %v", dbFile, err))
+	} else {
+		db.db = edgeDB
+	}
+	return nil
+}
+
+func (db *AgentBoltDB) Close() {
+	glog.V(2).Infof("Closing bolt DB %v", db.db.Path())
+	db.db.Close()
+}
+
+func (db *AgentBoltDB) Remove() error {
+	dbFile := db.db.Path()
+	db.Close()
+	glog.Infof("Removing local db file %v.", dbFile)
+	return os.Remove(dbFile)
+}
+
+func (db *AgentBoltDB) View(fn func(tx Tx) error) error {
+	return db.db.View(func(tx *bolt.Tx) error {
+		return fn(&boltTx{tx: tx})
+	})
+}
+
+func (db *AgentBoltDB) Update(fn func(tx Tx) error) error {
+	return db.db.Update(func(tx *bolt.Tx) error {
+		return fn(&boltTx{tx: tx})
+	})
+}
+
+type boltTx struct {
+	tx *bolt.Tx
+}
+
+func (t *boltTx) Bucket(name []byte) Bucket {
+	// Return an untyped nil so that callers can compare the result with nil.
+	if b := t.tx.Bucket(name); b != nil {
+		return b
+	}
+	return nil
+}
+
+func (t *boltTx) CreateBucketIfNotExists(name []byte) (Bucket, error) {
+	if b, err := t.tx.CreateBucketIfNotExists(name); err != nil {
+		return nil, err
+	} else {
+		return b, nil
+	}
+}
+
+func (t *boltTx) DeleteBucket(name []byte) error {
+	return t.tx.DeleteBucket(name)
+}
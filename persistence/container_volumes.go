@@ -4,7 +4,6 @@ import (
 	"encoding/json"
 	"fmt"
 	"github.com/golang/glog"
-	bolt "go.etcd.io/bbolt"
 	"strconv"
 	"time"
 )
@@ -40,8 +39,8 @@ func (w ContainerVolume) ShortString() string {
 }
 
 // save the ContainerVolume record into db.
-func SaveContainerVolume(db *bolt.DB, container_volume *ContainerVolume) error {
-	writeErr := db.Update(func(tx *bolt.Tx) error {
+func SaveContainerVolume(db AgentDatabase, container_volume *ContainerVolume) error {
+	writeErr := db.Update(func(tx Tx) error {
 		if bucket, err := tx.CreateBucketIfNotExists([]byte(CONTAINER_VOLUMES)); err != nil {
 			return err
 		} else {
@@ -68,18 +67,18 @@ func SaveContainerVolume(db *bolt.DB, container_volume *ContainerVolume) error {
 }
 
 // save the container volume into db.
-func SaveContainerVolumeByName(db *bolt.DB, name string) error {
+func SaveContainerVolumeByName(db AgentDatabase, name string) error {
 	pcv := NewContainerVolume(name)
 	return SaveContainerVolume(db, pcv)
 }
 
 // Find the container volumes that are not deleted yet
-func FindAllUndeletedContainerVolumes(db *bolt.DB) ([]ContainerVolume, error) {
+func FindAllUndeletedContainerVolumes(db AgentDatabase) ([]ContainerVolume, error) {
 	return FindContainerVolumes(db, []ContainerVolumeFilter{UnarchivedCVFilter()})
 }
 
 // Mark the given volume as archived.
-func ArchiveContainerVolumes(db *bolt.DB, cv *ContainerVolume) error {
+func ArchiveContainerVolumes(db AgentDatabase, cv *ContainerVolume) error {
 	if cv == nil {
 		return nil
 	}
@@ -104,11 +103,11 @@ func NameCVFilter(name string) ContainerVolumeFilter {
 }
 
 // find container volumes from the db for the given filters
-func FindContainerVolumes(db *bolt.DB, filters []ContainerVolumeFilter) ([]ContainerVolume, error) {
+func FindContainerVolumes(db AgentDatabase, filters []ContainerVolumeFilter) ([]ContainerVolume, error) {
 	cvs := make([]ContainerVolume, 0)
 
 	// fetch container volumes
-	readErr := db.View(func(tx *bolt.Tx) error {
+	readErr := db.View(func(tx Tx) error {
 
 		if b := tx.Bucket([]byte(CONTAINER_VOLUMES)); b != nil {
 			b.ForEach(func(k, v []byte) error {
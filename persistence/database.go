@@ -0,0 +1,52 @@
+package persistence
+
+import (
+	"github.com/open-horizon/anax/config"
+)
+
+// The agent can be configured to run with different databases. By default, the agent's state is kept in a bolt DB
+// file in the configured DBPath. When the agent runs in a kubernetes cluster, it can instead keep its state in a
+// postgresql database so that the state survives replacement of the agent's pod. This file contains the abstract
+// interface representing the database handle used by the runtime to access the real database.
+//
+// The agent stores all of its objects as serialized values in named buckets, so the interface is modelled on the
+// transactional bucket API that the agent originally used with bolt.
+type AgentDatabase interface {
+
+	// Database related functions
+	Initialize(cfg *config.HorizonConfig) error
+	Close()
+
+	// Close the database and remove all the data in it. This is used when the node is unregistered and the agent
+	// is configured to remove its database on exit.
+	Remove() error
+
+	// Run a read-only transaction. The transaction is rolled back when fn returns.
+	View(fn func(tx Tx) error) error
+
+	// Run a read-write transaction. The transaction is committed if fn returns nil, otherwise it is rolled back.
+	Update(fn func(tx Tx) error) error
+}
+
+// A database transaction. The buckets returned by a transaction can only be used within that transaction.
+type Tx interface {
+	// Returns nil if the bucket does not exist.
+	Bucket(name []byte) Bucket
+	CreateBucketIfNotExists(name []byte) (Bucket, error)
+	DeleteBucket(name []byte) error
+}
+
+// A named collection of key/value pairs. Keys are kept in byte-sorted order.
+type Bucket interface {
+	// Returns nil if the key does not exist.
+	Get(key []byte) []byte
+	Put(key []byte, value []byte) error
+	Delete(key []byte) error
+
+	// Call fn for each key/value pair in key order. Iteration stops when fn returns an error. The bucket must not
+	// be modified by fn.
+	ForEach(fn func(k, v []byte) error) error
+
+	// Returns an auto-incrementing integer for the bucket.
+	NextSequence() (uint64, error)
+}
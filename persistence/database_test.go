@@ -0,0 +1,90 @@
+//go:build unit
+// +build unit
+
+package persistence
+
+import (
+	"github.com/open-horizon/anax/config"
+	"os"
+	"path"
+	"testing"
+)
+
+// The bolt DB is used when only the DBPath is configured, and the DB file is removed by Remove.
+func Test_InitDatabase_bolt(t *testing.T) {
+	dir, err := os.MkdirTemp("", "utdb-")
+	if err != nil {
+		t.Errorf("Error creating temp dir: %v", err)
+	}
+	defer cleanTestDir(dir)
+
+	if db, err := InitDatabase(&config.HorizonConfig{}); err != nil {
+		t.Errorf("Error initializing database: %v", err)
+	} else if db != nil {
+		t.Errorf("No database should be initialized when the agent is not configured, was %v", db)
+	}
+
+	cfg := &config.HorizonConfig{Edge: config.Config{DBPath: dir}}
+	db, err := InitDatabase(cfg)
+	if err != nil {
+		t.Errorf("Error initializing database: %v", err)
+	} else if _, ok := db.(*AgentBoltDB); !ok {
+		t.Errorf("Database should be a bolt DB, was %T", db)
+	}
+
+	if err := db.Remove(); err != nil {
+		t.Errorf("Error removing database: %v", err)
+	} else if _, err := os.Stat(path.Join(dir, BOLTDB_DATABASE_NAME)); !os.IsNotExist(err) {
+		t.Errorf("Database file should have been removed, error: %v", err)
+	}
+}
+
+// Buckets that do not exist are nil, keys are iterated in order and errors roll back the transaction.
+func Test_AgentBoltDB_buckets(t *testing.T) {
+	dir, db, err := utsetup()
+	if err != nil {
+		t.Errorf("Error setting up UT DB: %v", err)
+	}
+	defer cleanTestDir(dir)
+
+	if err := db.View(func(tx Tx) error {
+		if b := tx.Bucket([]byte("test")); b != nil {
+			t.Errorf("Bucket should not exist, was %v", b)
+		}
+		return nil
+	}); err != nil {
+		t.Errorf("Error reading database: %v", err)
+	}
+
+	if err := db.Update(func(tx Tx) error {
+		b, err := tx.CreateBucketIfNotExists([]byte("test"))
+		if err != nil {
+			return err
+		}
+		for _, k := range []string{"b", "c", "a"} {
+			if err := b.Put([]byte(k), []byte("value-"+k)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}); err != nil {
+		t.Errorf("Error writing database: %v", err)
+	}
+
+	db.Update(func(tx Tx) error {
+		tx.Bucket([]byte("test")).Delete([]byte("a"))
+		return os.ErrInvalid
+	})
+
+	keys := ""
+	if err := db.View(func(tx Tx) error {
+		return tx.Bucket([]byte("test")).ForEach(func(k, v []byte) error {
+			keys += string(k)
+			return nil
+		})
+	}); err != nil {
+		t.Errorf("Error reading database: %v", err)
+	} else if keys != "abc" {
+		t.Errorf("Keys should be abc, were %v", keys)
+	}
+}
@@ -5,7 +5,6 @@ import (
 	"errors"
 	"fmt"
 	"github.com/golang/glog"
-	bolt "go.etcd.io/bbolt"
 	"reflect"
 	"strings"
 	"time"
@@ -132,7 +131,7 @@ func (e *ExchangeDevice) IsEdgeCluster() bool {
 }
 
 // a convenience function b/c we know there is really only one device
-func (e *ExchangeDevice) InvalidateExchangeToken(db *bolt.DB) (*ExchangeDevice, error) {
+func (e *ExchangeDevice) InvalidateExchangeToken(db AgentDatabase) (*ExchangeDevice, error) {
 	exchDev, err := FindExchangeDevice(db)
 	if err != nil {
 		return nil, err
@@ -144,7 +143,7 @@ func (e *ExchangeDevice) InvalidateExchangeToken(db *bolt.DB) (*ExchangeDevice,
 	})
 }
 
-func (e *ExchangeDevice) SetExchangeDeviceToken(db *bolt.DB, deviceId string, token string) (*ExchangeDevice, error) {
+func (e *ExchangeDevice) SetExchangeDeviceToken(db AgentDatabase, deviceId string, token string) (*ExchangeDevice, error) {
 	if deviceId == "" || token == "" {
 		return nil, errors.New("Argument null and mustn't be")
 	}
@@ -155,7 +154,7 @@ func (e *ExchangeDevice) SetExchangeDeviceToken(db *bolt.DB, deviceId string, to
 	})
 }
 
-func (e *ExchangeDevice) SetConfigstate(db *bolt.DB, deviceId string, state string) (*ExchangeDevice, error) {
+func (e *ExchangeDevice) SetConfigstate(db AgentDatabase, deviceId string, state string) (*ExchangeDevice, error) {
 	if deviceId == "" || state == "" {
 		return nil, errors.New("Argument null and mustn't be")
 	}
@@ -167,7 +166,7 @@ func (e *ExchangeDevice) SetConfigstate(db *bolt.DB, deviceId string, state stri
 	})
 }
 
-func (e *ExchangeDevice) SetNodeType(db *bolt.DB, deviceId string, nodeType string) (*ExchangeDevice, error) {
+func (e *ExchangeDevice) SetNodeType(db AgentDatabase, deviceId string, nodeType string) (*ExchangeDevice, error) {
 	if deviceId == "" || nodeType == "" {
 		return nil, errors.New("The argument deviceId or nodeType cannot be empty.")
 	}
@@ -178,7 +177,7 @@ func (e *ExchangeDevice) SetNodeType(db *bolt.DB, deviceId string, nodeType stri
 	})
 }
 
-func (e *ExchangeDevice) SetPattern(db *bolt.DB, deviceId string, pattern string) (*ExchangeDevice, error) {
+func (e *ExchangeDevice) SetPattern(db AgentDatabase, deviceId string, pattern string) (*ExchangeDevice, error) {
 	if deviceId == "" {
 		return nil, errors.New("Argument null and mustn't be")
 	}
@@ -189,7 +188,7 @@ func (e *ExchangeDevice) SetPattern(db *bolt.DB, deviceId string, pattern string
 	})
 }
 
-func (e *ExchangeDevice) SetAgentVersion(db *bolt.DB, deviceId string, agentSoftwareVersion string) (*ExchangeDevice, error) {
+func (e *ExchangeDevice) SetAgentVersion(db AgentDatabase, deviceId string, agentSoftwareVersion string) (*ExchangeDevice, error) {
 	if deviceId == "" {
 		return nil, errors.New("Device id cannot be empty.")
 	}
@@ -204,7 +203,7 @@ func (e *ExchangeDevice) SetAgentVersion(db *bolt.DB, deviceId string, agentSoft
 	})
 }
 
-func (e *ExchangeDevice) SetConfigVersion(db *bolt.DB, deviceId string, configVersion string) (*ExchangeDevice, error) {
+func (e *ExchangeDevice) SetConfigVersion(db AgentDatabase, deviceId string, configVersion string) (*ExchangeDevice, error) {
 	if deviceId == "" {
 		return nil, errors.New("Device id cannot be empty.")
 	}
@@ -218,7 +217,7 @@ func (e *ExchangeDevice) SetConfigVersion(db *bolt.DB, deviceId string, configVe
 	})
 }
 
-func (e *ExchangeDevice) SetCertVersion(db *bolt.DB, deviceId string, certVersion string) (*ExchangeDevice, error) {
+func (e *ExchangeDevice) SetCertVersion(db AgentDatabase, deviceId string, certVersion string) (*ExchangeDevice, error) {
 	if deviceId == "" {
 		return nil, errors.New("Device id cannot be empty.")
 	}
@@ -236,7 +235,7 @@ func (e *ExchangeDevice) IsState(state string) bool {
 	return e.Config.State == state
 }
 
-func updateExchangeDevice(db *bolt.DB, self *ExchangeDevice, deviceId string, invalidateToken bool, fn func(d ExchangeDevice) *ExchangeDevice) (*ExchangeDevice, error) {
+func updateExchangeDevice(db AgentDatabase, self *ExchangeDevice, deviceId string, invalidateToken bool, fn func(d ExchangeDevice) *ExchangeDevice) (*ExchangeDevice, error) {
 	if deviceId == "" {
 		return nil, fmt.Errorf("Illegal arguments specified.")
 	}
@@ -245,7 +244,7 @@ func updateExchangeDevice(db *bolt.DB, self *ExchangeDevice, deviceId string, in
 
 	var mod ExchangeDevice
 
-	return &mod, db.Update(func(tx *bolt.Tx) error {
+	return &mod, db.Update(func(tx Tx) error {
 		b, err := tx.CreateBucketIfNotExists([]byte(DEVICES))
 		if err != nil {
 			return err
@@ -313,7 +312,7 @@ func updateExchangeDevice(db *bolt.DB, self *ExchangeDevice, deviceId string, in
 }
 
 // always assumed the given token is valid at the time of call
-func SaveNewExchangeDevice(db *bolt.DB, id string, token string, name string, nodeType string, organization string, pattern string, configstate string, softwareVersions SoftwareVersion) (*ExchangeDevice, error) {
+func SaveNewExchangeDevice(db AgentDatabase, id string, token string, name string, nodeType string, organization string, pattern string, configstate string, softwareVersions SoftwareVersion) (*ExchangeDevice, error) {
 
 	if id == "" || token == "" || name == "" || organization == "" || configstate == "" {
 		return nil, errors.New("Argument null and must not be")
@@ -321,7 +320,7 @@ func SaveNewExchangeDevice(db *bolt.DB, id string, token string, name string, no
 
 	duplicate := false
 
-	dErr := db.View(func(tx *bolt.Tx) error {
+	dErr := db.View(func(tx Tx) error {
 		bd := tx.Bucket([]byte(DEVICES))
 		if bd != nil {
 			duplicate = (bd.Get([]byte(name)) != nil)
@@ -343,7 +342,7 @@ func SaveNewExchangeDevice(db *bolt.DB, id string, token string, name string, no
 		return nil, err
 	}
 
-	writeErr := db.Update(func(tx *bolt.Tx) error {
+	writeErr := db.Update(func(tx Tx) error {
 		b, err := tx.CreateBucketIfNotExists([]byte(DEVICES))
 		if err != nil {
 			return err
@@ -361,11 +360,11 @@ func SaveNewExchangeDevice(db *bolt.DB, id string, token string, name string, no
 	return exDevice, writeErr
 }
 
-func FindExchangeDevice(db *bolt.DB) (*ExchangeDevice, error) {
+func FindExchangeDevice(db AgentDatabase) (*ExchangeDevice, error) {
 
 	devices := make([]ExchangeDevice, 0)
 
-	readErr := db.View(func(tx *bolt.Tx) error {
+	readErr := db.View(func(tx Tx) error {
 		if b := tx.Bucket([]byte(DEVICES)); b != nil {
 			return b.ForEach(func(k, v []byte) error {
 				var dev ExchangeDevice
@@ -410,7 +409,7 @@ func FindExchangeDevice(db *bolt.DB) (*ExchangeDevice, error) {
 	}
 }
 
-func DeleteExchangeDevice(db *bolt.DB) error {
+func DeleteExchangeDevice(db AgentDatabase) error {
 
 	if dev, err := FindExchangeDevice(db); err != nil {
 		return err
@@ -418,7 +417,7 @@ func DeleteExchangeDevice(db *bolt.DB) error {
 		return fmt.Errorf("could not find record for device")
 	} else {
 
-		return db.Update(func(tx *bolt.Tx) error {
+		return db.Update(func(tx Tx) error {
 
 			if b, err := tx.CreateBucketIfNotExists([]byte(DEVICES)); err != nil {
 				return err
@@ -432,7 +431,7 @@ func DeleteExchangeDevice(db *bolt.DB) error {
 }
 
 // Migrate a device object if it is restarted ona newer level of code.
-func MigrateExchangeDevice(db *bolt.DB) (bool, error) {
+func MigrateExchangeDevice(db AgentDatabase) (bool, error) {
 	usingPattern := false
 	// If the device object already exists, make sure its service or workload mode is set correctly. If not, set it.
 	// This code handles devices that upgrade to an anax runtime that supports service mode but the device is still
@@ -115,6 +115,10 @@ const (
 	EC_ERROR_NODE_USERINPUT_UPDATE = "error_userinput_update"
 	EC_ERROR_NODE_USERINPUT_PATCH  = "error_userinput_patch"
 
+	EC_NODE_BACKUP_EXPORTED      = "node_backup_exported"
+	EC_NODE_BACKUP_RESTORED      = "node_backup_restored"
+	EC_ERROR_NODE_BACKUP_RESTORE = "error_node_backup_restore"
+
 	EC_AGREEMENT_REACHED                  = "agreement_reached"
 	EC_CANCEL_AGREEMENT                   = "cancel_agreement"
 	EC_AGREEMENT_CANCELED                 = "agreement_canceled"
@@ -128,9 +132,11 @@ const (
 	EC_CONTAINER_STOPPED          = "container_stopped"
 	EC_ERROR_IN_DEPLOYMENT_CONFIG = "error_in_deployment_configuration"
 	EC_ERROR_START_CONTAINER      = "error_start_container"
+	EC_CONTAINER_UNHEALTHY        = "container_unhealthy"
 
 	EC_IMAGE_LOADED                       = "image_loaded"
 	EC_ERROR_IMAGE_LOADE                  = "error_image_load"
+	EC_ERROR_IMAGE_UNTRUSTED              = "error_image_untrusted"
 	EC_ERROR_AGREEMENT_VERIFICATION       = "error_in_agreement_verification"
 	EC_ERROR_DELETE_AGREEMENT_IN_EXCHANGE = "error_delete_agreement_in_exchange"
 
@@ -144,6 +150,9 @@ const (
 	EC_DEPENDENT_SERVICE_FAILED            = "dependent_service_failed"
 	EC_COMPLETE_DEPENDENT_SERVICE          = "complete_dependent_service"
 	EC_REMOVE_OLD_DEPENDENT_SERVICE_FAILED = "remove_old_dependent_service_failed"
+	EC_WAIT_DEPENDENT_SERVICE_READY        = "wait_dependent_service_ready"
+	EC_DEPENDENT_SERVICE_READY             = "dependent_service_ready"
+	EC_DEPENDENT_SERVICE_NOT_READY         = "dependent_service_not_ready"
 
 	EC_START_RETRY_DEPENDENT_SERVICE       = "start_retry_dependent_service"
 	EC_ERROR_START_RETRY_DEPENDENT_SERVICE = "error_start_retry_dependent_service"
@@ -5,7 +5,6 @@ import (
 	"fmt"
 	"github.com/golang/glog"
 	"github.com/open-horizon/anax/i18n"
-	bolt "go.etcd.io/bbolt"
 	"golang.org/x/text/message"
 	"reflect"
 	"strconv"
@@ -196,8 +195,8 @@ func NewMessageMeta(msg_key string, message_args ...interface{}) *MessageMeta {
 }
 
 // save the timestamp for the last unregistration into db.
-func SaveLastUnregistrationTime(db *bolt.DB, last_unreg_time uint64) error {
-	writeErr := db.Update(func(tx *bolt.Tx) error {
+func SaveLastUnregistrationTime(db AgentDatabase, last_unreg_time uint64) error {
+	writeErr := db.Update(func(tx Tx) error {
 		if bucket, err := tx.CreateBucketIfNotExists([]byte(LAST_UNREG)); err != nil {
 			return err
 		} else {
@@ -209,12 +208,12 @@ func SaveLastUnregistrationTime(db *bolt.DB, last_unreg_time uint64) error {
 }
 
 // Find the event log from the db
-func GetLastUnregistrationTime(db *bolt.DB) (uint64, error) {
+func GetLastUnregistrationTime(db AgentDatabase) (uint64, error) {
 	var last_unreg uint64
 	last_unreg = 0
 
 	// fetch event logs
-	readErr := db.View(func(tx *bolt.Tx) error {
+	readErr := db.View(func(tx Tx) error {
 
 		if b := tx.Bucket([]byte(LAST_UNREG)); b != nil {
 			v := b.Get([]byte("lastunreg"))
@@ -236,8 +235,8 @@ func GetLastUnregistrationTime(db *bolt.DB) (uint64, error) {
 }
 
 // save the event log record into db.
-func SaveEventLog(db *bolt.DB, event_log *EventLog) error {
-	writeErr := db.Update(func(tx *bolt.Tx) error {
+func SaveEventLog(db AgentDatabase, event_log *EventLog) error {
+	writeErr := db.Update(func(tx Tx) error {
 		if bucket, err := tx.CreateBucketIfNotExists([]byte(EVENT_LOGS)); err != nil {
 			return err
 		} else if nextKey, err := bucket.NextSequence(); err != nil {
@@ -308,12 +307,12 @@ func GetRealEventSource(source_type string, src *json.RawMessage) (*EventSourceI
 }
 
 // Find the event log from the db
-func FindEventLogWithKey(db *bolt.DB, key string) (*EventLog, error) {
+func FindEventLogWithKey(db AgentDatabase, key string) (*EventLog, error) {
 	var pel *EventLog
 	pel = nil
 
 	// fetch event logs
-	readErr := db.View(func(tx *bolt.Tx) error {
+	readErr := db.View(func(tx Tx) error {
 
 		if b := tx.Bucket([]byte(EVENT_LOGS)); b != nil {
 			v := b.Get([]byte(key))
@@ -365,11 +364,11 @@ func SelectorFilter(selectors map[string][]Selector) EventLogFilter {
 }
 
 // find event logs from the db for the given filters
-func FindEventLogs(db *bolt.DB, filters []EventLogFilter) ([]EventLog, error) {
+func FindEventLogs(db AgentDatabase, filters []EventLogFilter) ([]EventLog, error) {
 	evlogs := make([]EventLog, 0)
 
 	// fetch logs
-	readErr := db.View(func(tx *bolt.Tx) error {
+	readErr := db.View(func(tx Tx) error {
 
 		if b := tx.Bucket([]byte(EVENT_LOGS)); b != nil {
 			b.ForEach(func(k, v []byte) error {
@@ -413,7 +412,7 @@ func FindEventLogs(db *bolt.DB, filters []EventLogFilter) ([]EventLog, error) {
 
 // delete event logs from the db that match the given selectors
 // returns the number of logs deleted
-func DeleteEventLogsWithSelectors(db *bolt.DB, selectors map[string][]Selector, msgPrinter *message.Printer) (int, error) {
+func DeleteEventLogsWithSelectors(db AgentDatabase, selectors map[string][]Selector, msgPrinter *message.Printer) (int, error) {
 	// separate base selectors from the source selectors
 	base_selectors, source_selectors := GroupSelectors(selectors)
 
@@ -423,7 +422,7 @@ func DeleteEventLogsWithSelectors(db *bolt.DB, selectors map[string][]Selector,
 
 	count := 0
 
-	dbErr := db.Update(func(tx *bolt.Tx) error {
+	dbErr := db.Update(func(tx Tx) error {
 		if b := tx.Bucket([]byte(EVENT_LOGS)); b != nil {
 			b.ForEach(func(k, v []byte) error {
 				var el EventLogRaw
@@ -451,7 +450,7 @@ func DeleteEventLogsWithSelectors(db *bolt.DB, selectors map[string][]Selector,
 
 // find event logs from the db for the given given selectors.
 // If all_logs is false, only the event logs for the current registration is returned.
-func FindEventLogsWithSelectors(db *bolt.DB, all_logs bool, selectors map[string][]Selector, msgPrinter *message.Printer) ([]EventLog, error) {
+func FindEventLogsWithSelectors(db AgentDatabase, all_logs bool, selectors map[string][]Selector, msgPrinter *message.Printer) ([]EventLog, error) {
 	// separate base selectors from the source selectors
 	base_selectors, source_selectors := GroupSelectors(selectors)
 
@@ -471,7 +470,7 @@ func FindEventLogsWithSelectors(db *bolt.DB, all_logs bool, selectors map[string
 	}
 
 	// fetch logs
-	readErr := db.View(func(tx *bolt.Tx) error {
+	readErr := db.View(func(tx Tx) error {
 
 		if b := tx.Bucket([]byte(EVENT_LOGS)); b != nil {
 			b.ForEach(func(k, v []byte) error {
@@ -514,11 +513,11 @@ func FindEventLogsWithSelectors(db *bolt.DB, all_logs bool, selectors map[string
 }
 
 // find all event logs from the db
-func FindAllEventLogs(db *bolt.DB) ([]EventLog, error) {
+func FindAllEventLogs(db AgentDatabase) ([]EventLog, error) {
 	evlogs := make([]EventLog, 0)
 
 	// fetch logs
-	readErr := db.View(func(tx *bolt.Tx) error {
+	readErr := db.View(func(tx Tx) error {
 
 		if b := tx.Bucket([]byte(EVENT_LOGS)); b != nil {
 			b.ForEach(func(k, v []byte) error {
@@ -746,7 +745,7 @@ func MatchAttributeValue(attr interface{}, selectors []Selector) (bool, bool, er
 }
 
 // GetEventLogObject returns the full eventlog object associated with a given record id
-func GetEventLogObject(db *bolt.DB, msgPrinter *message.Printer, recordID string) EventLog {
+func GetEventLogObject(db AgentDatabase, msgPrinter *message.Printer, recordID string) EventLog {
 	if msgPrinter == nil {
 		msgPrinter = i18n.GetMessagePrinter()
 	}
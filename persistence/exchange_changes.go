@@ -5,7 +5,6 @@ import (
 	"fmt"
 	"github.com/golang/glog"
 	"github.com/open-horizon/anax/cutil"
-	bolt "go.etcd.io/bbolt"
 	"time"
 )
 
@@ -24,11 +23,11 @@ func (c ChangeState) String() string {
 
 // Retrieve the change state object from the database. The bolt APIs assume there is more than 1 object in a bucket,
 // so this function has to be prepared for that case, even though there should only ever be 1.
-func FindExchangeChangeState(db *bolt.DB) (*ChangeState, error) {
+func FindExchangeChangeState(db AgentDatabase) (*ChangeState, error) {
 
 	chg := make([]ChangeState, 0)
 
-	readErr := db.View(func(tx *bolt.Tx) error {
+	readErr := db.View(func(tx Tx) error {
 		if b := tx.Bucket([]byte(EXCHANGE_CHANGES)); b != nil {
 			return b.ForEach(func(k, v []byte) error {
 				var c ChangeState
@@ -61,9 +60,9 @@ func FindExchangeChangeState(db *bolt.DB) (*ChangeState, error) {
 }
 
 // There is only 1 object in the bucket so we can use the bucket name as the object key.
-func SaveExchangeChangeState(db *bolt.DB, changeID uint64) error {
+func SaveExchangeChangeState(db AgentDatabase, changeID uint64) error {
 
-	writeErr := db.Update(func(tx *bolt.Tx) error {
+	writeErr := db.Update(func(tx Tx) error {
 		b, err := tx.CreateBucketIfNotExists([]byte(EXCHANGE_CHANGES))
 		if err != nil {
 			return err
@@ -88,7 +87,7 @@ func SaveExchangeChangeState(db *bolt.DB, changeID uint64) error {
 }
 
 // Remove the change state object from the local database.
-func DeleteExchangeChangeState(db *bolt.DB) error {
+func DeleteExchangeChangeState(db AgentDatabase) error {
 
 	if chg, err := FindExchangeChangeState(db); err != nil {
 		return err
@@ -96,7 +95,7 @@ func DeleteExchangeChangeState(db *bolt.DB) error {
 		return nil
 	} else {
 
-		return db.Update(func(tx *bolt.Tx) error {
+		return db.Update(func(tx Tx) error {
 
 			if b, err := tx.CreateBucketIfNotExists([]byte(EXCHANGE_CHANGES)); err != nil {
 				return err
@@ -72,3 +72,41 @@ func Test_PersistToFrom(t *testing.T) {
 	}
 
 }
+
+func Test_HelmReleases(t *testing.T) {
+
+	dir, db, err := utsetup()
+	if err != nil {
+		t.Fatalf("unable to set up the database, error: %v", err)
+	}
+	defer cleanTestDir(dir)
+
+	if rel, err := FindHelmRelease(db, "test"); err != nil || rel != nil {
+		t.Errorf("expected no release, got %v %v", rel, err)
+	}
+
+	owned := &HelmRelease{ReleaseName: "test", AgreementId: "ag1"}
+	pending := &HelmRelease{ReleaseName: "other", UninstallDeadline: 1000}
+	if err := SaveHelmRelease(db, owned); err != nil {
+		t.Errorf("unable to save %v, error: %v", owned, err)
+	} else if err := SaveHelmRelease(db, pending); err != nil {
+		t.Errorf("unable to save %v, error: %v", pending, err)
+	}
+
+	if rel, err := FindHelmRelease(db, "test"); err != nil || rel == nil || *rel != *owned {
+		t.Errorf("expected %v, got %v %v", owned, rel, err)
+	} else if rel.IsPendingUninstall() {
+		t.Errorf("release %v should not be pending uninstall", rel)
+	}
+	if rels, err := FindHelmReleases(db); err != nil || len(rels) != 2 {
+		t.Errorf("expected 2 releases, got %v %v", rels, err)
+	} else if !rels[0].IsPendingUninstall() || rels[0].ReleaseName != "other" {
+		t.Errorf("release %v should be pending uninstall", rels[0])
+	}
+
+	if err := DeleteHelmRelease(db, "other"); err != nil {
+		t.Errorf("unable to delete release, error: %v", err)
+	} else if rels, err := FindHelmReleases(db); err != nil || len(rels) != 1 {
+		t.Errorf("expected 1 release, got %v %v", rels, err)
+	}
+}
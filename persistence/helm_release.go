@@ -0,0 +1,102 @@
+package persistence
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// helm release table name
+const HELM_RELEASES = "helm_releases"
+
+// A Helm release installed by the agent. The owner is the agreement that installed or last upgraded the release. When
+// the agreement of the owner ends, the release is kept until the uninstall deadline, so that the agreement of an
+// upgraded version of the service can upgrade it in place. The release is keyed by its name.
+type HelmRelease struct {
+	ReleaseName       string `json:"release_name"`
+	AgreementId       string `json:"agreement_id"`       // the owner, empty while the release waits to be uninstalled
+	UninstallDeadline int64  `json:"uninstall_deadline"` // unix time after which the release is uninstalled, 0 while it is owned
+}
+
+func (h HelmRelease) String() string {
+	return fmt.Sprintf("ReleaseName: %v, "+
+		"AgreementId: %v, "+
+		"UninstallDeadline: %v",
+		h.ReleaseName, h.AgreementId, h.UninstallDeadline)
+}
+
+func (h HelmRelease) ShortString() string {
+	return h.String()
+}
+
+// Returns true when the release is waiting to be uninstalled.
+func (h HelmRelease) IsPendingUninstall() bool {
+	return h.AgreementId == "" && h.UninstallDeadline != 0
+}
+
+// save or update the given helm release
+func SaveHelmRelease(db AgentDatabase, release *HelmRelease) error {
+	writeErr := db.Update(func(tx Tx) error {
+		if bucket, err := tx.CreateBucketIfNotExists([]byte(HELM_RELEASES)); err != nil {
+			return err
+		} else if serial, err := json.Marshal(release); err != nil {
+			return fmt.Errorf("Failed to serialize helm release: %v", err)
+		} else {
+			return bucket.Put([]byte(release.ReleaseName), serial)
+		}
+	})
+
+	return writeErr
+}
+
+func DeleteHelmRelease(db AgentDatabase, releaseName string) error {
+	return db.Update(func(tx Tx) error {
+		if bucket, err := tx.CreateBucketIfNotExists([]byte(HELM_RELEASES)); err != nil {
+			return err
+		} else if err := bucket.Delete([]byte(releaseName)); err != nil {
+			return fmt.Errorf("Unable to delete helm release record for %v: %v.", releaseName, err)
+		}
+		return nil
+	})
+}
+
+// Returns nil if the release is not in the db.
+func FindHelmRelease(db AgentDatabase, releaseName string) (*HelmRelease, error) {
+	var release *HelmRelease
+
+	readErr := db.View(func(tx Tx) error {
+		if bucket := tx.Bucket([]byte(HELM_RELEASES)); bucket != nil {
+			if v := bucket.Get([]byte(releaseName)); v != nil {
+				release = new(HelmRelease)
+				if err := json.Unmarshal(v, release); err != nil {
+					return fmt.Errorf("Unable to deserialize helm release record %v: %v", releaseName, err)
+				}
+			}
+		}
+		return nil
+	})
+
+	if readErr != nil {
+		return nil, readErr
+	}
+	return release, nil
+}
+
+func FindHelmReleases(db AgentDatabase) ([]HelmRelease, error) {
+	releases := make([]HelmRelease, 0)
+
+	readErr := db.View(func(tx Tx) error {
+		if bucket := tx.Bucket([]byte(HELM_RELEASES)); bucket != nil {
+			return bucket.ForEach(func(k, v []byte) error {
+				var release HelmRelease
+				if err := json.Unmarshal(v, &release); err != nil {
+					return fmt.Errorf("Unable to deserialize helm release record %v: %v", string(k), err)
+				}
+				releases = append(releases, release)
+				return nil
+			})
+		}
+		return nil
+	})
+
+	return releases, readErr
+}
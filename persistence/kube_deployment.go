@@ -6,27 +6,39 @@ import (
 	"github.com/open-horizon/anax/cutil"
 )
 
+// A cluster deployment has either an operator yaml archive, or a manifest archive that holds plain k8s manifests or
+// a Kustomize directory.
 type KubeDeploymentConfig struct {
 	Metadata            map[string]interface{} `json:"metadata,omitempty"`
-	OperatorYamlArchive string                 `json:"operatorYamlArchive"`
+	OperatorYamlArchive string                 `json:"operatorYamlArchive,omitempty"`
+	ManifestArchive     string                 `json:"manifestArchive,omitempty"`
 	Secrets             map[string]interface{} `json:"secrets,omitempty"`
 	MMSPVC              map[string]interface{} `json:"mmspvc,omitempty"`
 }
 
 func (k *KubeDeploymentConfig) ToString() string {
-	if k != nil {
+	if k != nil && k.IsManifest() {
+		return fmt.Sprintf("ManifestArchive: %v, Metadata: %v, Secrets: %v", cutil.TruncateDisplayString(k.ManifestArchive, 20), k.Metadata, k.Secrets)
+	} else if k != nil {
 		return fmt.Sprintf("OperatorYamlArchive: %v, Metadata: %v, Secrets: %v", cutil.TruncateDisplayString(k.OperatorYamlArchive, 20), k.Metadata, k.Secrets)
 	}
 	return ""
 }
 
+// Returns true if the deployment is a bundle of manifests that is applied to the cluster, rather than an operator.
+func (k *KubeDeploymentConfig) IsManifest() bool {
+	return k.ManifestArchive != ""
+}
+
 func GetKubeDeployment(deployStr string) (*KubeDeploymentConfig, error) {
 	kd := new(KubeDeploymentConfig)
 	err := json.Unmarshal([]byte(deployStr), kd)
 	if err != nil {
 		return nil, fmt.Errorf("error unmarshaling deployment config as KubeDeployment: %v", err)
-	} else if kd.OperatorYamlArchive == "" {
-		return nil, fmt.Errorf("required field 'operatorYamlArchive' is missing in the deployment string.")
+	} else if kd.OperatorYamlArchive == "" && kd.ManifestArchive == "" {
+		return nil, fmt.Errorf("required field 'operatorYamlArchive' or 'manifestArchive' is missing in the deployment string.")
+	} else if kd.OperatorYamlArchive != "" && kd.ManifestArchive != "" {
+		return nil, fmt.Errorf("only one of 'operatorYamlArchive' and 'manifestArchive' can be specified in the deployment string.")
 	}
 	return kd, nil
 }
@@ -62,6 +74,8 @@ func (k *KubeDeploymentConfig) IsNative() bool {
 func IsKube(dep map[string]interface{}) bool {
 	if _, ok := dep["operatorYamlArchive"]; ok {
 		return true
+	} else if _, ok := dep["manifestArchive"]; ok {
+		return true
 	}
 	return false
 }
@@ -4,7 +4,6 @@ import (
 	"fmt"
 	"github.com/open-horizon/anax/exchangecommon"
 	"github.com/open-horizon/anax/policy"
-	bolt "go.etcd.io/bbolt"
 )
 
 // ==========================================================================================================
@@ -31,7 +30,7 @@ type MicroserviceDefInterface interface {
 	GetUpgradeUngradeFailureReason() uint64
 	GetUngradeFailureDescription() string
 
-	Archive(db *bolt.DB) error
+	Archive(db AgentDatabase) error
 
 	/*
 		GetUpgradeNewDefId() string
@@ -87,7 +86,7 @@ type MicroserviceInstInterface interface {
 	GetCurrentRetryCount() uint
 	GetRetryStartTime() uint64
 
-	Archive(db *bolt.DB) error
+	Archive(db AgentDatabase) error
 
 	/*
 			HasDeployment() bool
@@ -127,7 +126,7 @@ type MicroserviceInstInterface interface {
 // Get all the MicroserviceInstInterface objects including the archived ones if includeArchived is true.
 // It gets both MicroserviceInstance objects and EstablishedAgreement objects.
 // If convertToMI is true, it will be EstablishedAgreement objects to MicroserviceIntance objects.
-func GetAllMicroserviceInstances(db *bolt.DB, includeArchived bool, convertToMI bool) ([]MicroserviceInstInterface, error) {
+func GetAllMicroserviceInstances(db AgentDatabase, includeArchived bool, convertToMI bool) ([]MicroserviceInstInterface, error) {
 	// get microservice definitions
 	filter := []MIFilter{}
 	if !includeArchived {
@@ -169,7 +168,7 @@ func GetAllMicroserviceInstances(db *bolt.DB, includeArchived bool, convertToMI
 // It gets both MicroserviceInstance objects and EstablishedAgreement objects.
 // If includeArchived is true, it will return both archived and unarchived.
 // If convertToMI is true, it will be EstablishedAgreement objects to MicroserviceIntance objects.
-func GetAllMicroserviceInstancesWithDefId(db *bolt.DB, msdefId string, includeArchived bool, convertToMI bool) ([]MicroserviceInstInterface, error) {
+func GetAllMicroserviceInstancesWithDefId(db AgentDatabase, msdefId string, includeArchived bool, convertToMI bool) ([]MicroserviceInstInterface, error) {
 
 	ret := []MicroserviceInstInterface{}
 
@@ -232,7 +231,7 @@ func AgreementToMicroserviceInstance(ag EstablishedAgreement) *MicroserviceInsta
 
 // This function returns an object with MicroserviceInstInterface.
 // It could be *MicroserviceInstance or *EstablishedAgreement.
-func GetMicroserviceInstIWithKey(db *bolt.DB, msinst_key string) (MicroserviceInstInterface, error) {
+func GetMicroserviceInstIWithKey(db AgentDatabase, msinst_key string) (MicroserviceInstInterface, error) {
 	if inst, err := FindMicroserviceInstanceWithKey(db, msinst_key); err != nil {
 		return nil, fmt.Errorf("Error getting service instance %v from db. %v", msinst_key, err)
 	} else if inst != nil {
@@ -248,7 +247,7 @@ func GetMicroserviceInstIWithKey(db *bolt.DB, msinst_key string) (MicroserviceIn
 // This function archives the microservice instance with the given key.
 // The key could be a key for the MicroseviceInstance or EstablishedAgreement
 // It will archive the related microservice defintion if no more instances referencing it.
-func ArchiveMicroserviceInstAndDef(db *bolt.DB, msinst_key string, archiveDef bool) error {
+func ArchiveMicroserviceInstAndDef(db AgentDatabase, msinst_key string, archiveDef bool) error {
 	// find the microservice instance
 	msi, err := GetMicroserviceInstIWithKey(db, msinst_key)
 	if err != nil {
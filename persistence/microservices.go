@@ -8,7 +8,6 @@ import (
 	"github.com/open-horizon/anax/cutil"
 	"github.com/open-horizon/anax/exchangecommon"
 	"github.com/satori/go.uuid"
-	bolt "go.etcd.io/bbolt"
 	"strconv"
 	"time"
 )
@@ -232,7 +231,7 @@ func (w *MicroserviceDefinition) GetUngradeFailureDescription() string {
 	return w.UngradeFailureDescription
 }
 
-func (w *MicroserviceDefinition) Archive(db *bolt.DB) error {
+func (w *MicroserviceDefinition) Archive(db AgentDatabase) error {
 	_, err := MsDefArchived(db, w.GetKey())
 	return err
 }
@@ -280,8 +279,8 @@ func (m *MicroserviceDefinition) HasRequiredServices() bool {
 }
 
 // save the microservice record. update if it already exists in the db
-func SaveOrUpdateMicroserviceDef(db *bolt.DB, msdef *MicroserviceDefinition) error {
-	writeErr := db.Update(func(tx *bolt.Tx) error {
+func SaveOrUpdateMicroserviceDef(db AgentDatabase, msdef *MicroserviceDefinition) error {
+	writeErr := db.Update(func(tx Tx) error {
 		if bucket, err := tx.CreateBucketIfNotExists([]byte(MICROSERVICE_DEFINITIONS)); err != nil {
 			return err
 		} else if nextKey, err := bucket.NextSequence(); err != nil {
@@ -304,17 +303,17 @@ func SaveOrUpdateMicroserviceDef(db *bolt.DB, msdef *MicroserviceDefinition) err
 }
 
 // find the unarchived microservice definitions for the given url and org
-func FindUnarchivedMicroserviceDefs(db *bolt.DB, url string, org string) ([]MicroserviceDefinition, error) {
+func FindUnarchivedMicroserviceDefs(db AgentDatabase, url string, org string) ([]MicroserviceDefinition, error) {
 	return FindMicroserviceDefs(db, []MSFilter{UnarchivedMSFilter(), UrlOrgMSFilter(url, org)})
 }
 
 // find the microservice definition from the db
-func FindMicroserviceDefWithKey(db *bolt.DB, key string) (*MicroserviceDefinition, error) {
+func FindMicroserviceDefWithKey(db AgentDatabase, key string) (*MicroserviceDefinition, error) {
 	var pms *MicroserviceDefinition
 	pms = nil
 
 	// fetch microservice definitions
-	readErr := db.View(func(tx *bolt.Tx) error {
+	readErr := db.View(func(tx Tx) error {
 
 		if b := tx.Bucket([]byte(MICROSERVICE_DEFINITIONS)); b != nil {
 			v := b.Get([]byte(key))
@@ -378,11 +377,11 @@ func UrlMSFilter(spec_url string) MSFilter {
 }
 
 // find the microservice instance from the db
-func FindMicroserviceDefs(db *bolt.DB, filters []MSFilter) ([]MicroserviceDefinition, error) {
+func FindMicroserviceDefs(db AgentDatabase, filters []MSFilter) ([]MicroserviceDefinition, error) {
 	ms_defs := make([]MicroserviceDefinition, 0)
 
 	// fetch contracts
-	readErr := db.View(func(tx *bolt.Tx) error {
+	readErr := db.View(func(tx Tx) error {
 
 		if b := tx.Bucket([]byte(MICROSERVICE_DEFINITIONS)); b != nil {
 			b.ForEach(func(k, v []byte) error {
@@ -418,7 +417,7 @@ func FindMicroserviceDefs(db *bolt.DB, filters []MSFilter) ([]MicroserviceDefini
 }
 
 // set the msdef to archived
-func MsDefArchived(db *bolt.DB, key string) (*MicroserviceDefinition, error) {
+func MsDefArchived(db AgentDatabase, key string) (*MicroserviceDefinition, error) {
 	return microserviceDefStateUpdate(db, key, func(c MicroserviceDefinition) *MicroserviceDefinition {
 		c.Archived = true
 		return &c
@@ -426,49 +425,49 @@ func MsDefArchived(db *bolt.DB, key string) (*MicroserviceDefinition, error) {
 }
 
 // set the msdef to un-archived
-func MsDefUnarchived(db *bolt.DB, key string) (*MicroserviceDefinition, error) {
+func MsDefUnarchived(db AgentDatabase, key string) (*MicroserviceDefinition, error) {
 	return microserviceDefStateUpdate(db, key, func(c MicroserviceDefinition) *MicroserviceDefinition {
 		c.Archived = false
 		return &c
 	})
 }
 
-func MSDefUpgradeStarted(db *bolt.DB, key string) (*MicroserviceDefinition, error) {
+func MSDefUpgradeStarted(db AgentDatabase, key string) (*MicroserviceDefinition, error) {
 	return microserviceDefStateUpdate(db, key, func(c MicroserviceDefinition) *MicroserviceDefinition {
 		c.UpgradeStartTime = uint64(time.Now().Unix())
 		return &c
 	})
 }
 
-func MSDefUpgradeMsUnregistered(db *bolt.DB, key string) (*MicroserviceDefinition, error) {
+func MSDefUpgradeMsUnregistered(db AgentDatabase, key string) (*MicroserviceDefinition, error) {
 	return microserviceDefStateUpdate(db, key, func(c MicroserviceDefinition) *MicroserviceDefinition {
 		c.UpgradeMsUnregisteredTime = uint64(time.Now().Unix())
 		return &c
 	})
 }
 
-func MsDefUpgradeAgreementsCleared(db *bolt.DB, key string) (*MicroserviceDefinition, error) {
+func MsDefUpgradeAgreementsCleared(db AgentDatabase, key string) (*MicroserviceDefinition, error) {
 	return microserviceDefStateUpdate(db, key, func(c MicroserviceDefinition) *MicroserviceDefinition {
 		c.UpgradeAgreementsClearedTime = uint64(time.Now().Unix())
 		return &c
 	})
 }
 
-func MSDefUpgradeExecutionStarted(db *bolt.DB, key string) (*MicroserviceDefinition, error) {
+func MSDefUpgradeExecutionStarted(db AgentDatabase, key string) (*MicroserviceDefinition, error) {
 	return microserviceDefStateUpdate(db, key, func(c MicroserviceDefinition) *MicroserviceDefinition {
 		c.UpgradeExecutionStartTime = uint64(time.Now().Unix())
 		return &c
 	})
 }
 
-func MSDefUpgradeMsReregistered(db *bolt.DB, key string) (*MicroserviceDefinition, error) {
+func MSDefUpgradeMsReregistered(db AgentDatabase, key string) (*MicroserviceDefinition, error) {
 	return microserviceDefStateUpdate(db, key, func(c MicroserviceDefinition) *MicroserviceDefinition {
 		c.UpgradeMsReregisteredTime = uint64(time.Now().Unix())
 		return &c
 	})
 }
 
-func MSDefUpgradeFailed(db *bolt.DB, key string, reason uint64, reasonString string) (*MicroserviceDefinition, error) {
+func MSDefUpgradeFailed(db AgentDatabase, key string, reason uint64, reasonString string) (*MicroserviceDefinition, error) {
 	return microserviceDefStateUpdate(db, key, func(c MicroserviceDefinition) *MicroserviceDefinition {
 		c.UpgradeFailedTime = uint64(time.Now().Unix())
 		c.UngradeFailureReason = reason
@@ -477,14 +476,14 @@ func MSDefUpgradeFailed(db *bolt.DB, key string, reason uint64, reasonString str
 	})
 }
 
-func MSDefUpgradeNewMsId(db *bolt.DB, key string, new_id string) (*MicroserviceDefinition, error) {
+func MSDefUpgradeNewMsId(db AgentDatabase, key string, new_id string) (*MicroserviceDefinition, error) {
 	return microserviceDefStateUpdate(db, key, func(c MicroserviceDefinition) *MicroserviceDefinition {
 		c.UpgradeNewMsId = new_id
 		return &c
 	})
 }
 
-func MSDefNewUpgradeVersionRange(db *bolt.DB, key string, version_range string) (*MicroserviceDefinition, error) {
+func MSDefNewUpgradeVersionRange(db AgentDatabase, key string, version_range string) (*MicroserviceDefinition, error) {
 	return microserviceDefStateUpdate(db, key, func(c MicroserviceDefinition) *MicroserviceDefinition {
 		c.UpgradeVersionRange = version_range
 		return &c
@@ -492,7 +491,7 @@ func MSDefNewUpgradeVersionRange(db *bolt.DB, key string, version_range string)
 }
 
 // update the micorserive definition
-func microserviceDefStateUpdate(db *bolt.DB, key string, fn func(MicroserviceDefinition) *MicroserviceDefinition) (*MicroserviceDefinition, error) {
+func microserviceDefStateUpdate(db AgentDatabase, key string, fn func(MicroserviceDefinition) *MicroserviceDefinition) (*MicroserviceDefinition, error) {
 
 	if ms, err := FindMicroserviceDefWithKey(db, key); err != nil {
 		return nil, err
@@ -506,8 +505,8 @@ func microserviceDefStateUpdate(db *bolt.DB, key string, fn func(MicroserviceDef
 }
 
 // does whole-member replacements of values that are legal to change
-func persistUpdatedMicroserviceDef(db *bolt.DB, key string, update *MicroserviceDefinition) error {
-	return db.Update(func(tx *bolt.Tx) error {
+func persistUpdatedMicroserviceDef(db AgentDatabase, key string, update *MicroserviceDefinition) error {
+	return db.Update(func(tx Tx) error {
 		if b, err := tx.CreateBucketIfNotExists([]byte(MICROSERVICE_DEFINITIONS)); err != nil {
 			return err
 		} else {
@@ -753,7 +752,7 @@ func (w *MicroserviceInstance) GetRetryStartTime() uint64 {
 	return w.RetryStartTime
 }
 
-func (w *MicroserviceInstance) Archive(db *bolt.DB) error {
+func (w *MicroserviceInstance) Archive(db AgentDatabase) error {
 	_, err := ArchiveMicroserviceInstance(db, w.GetKey())
 	return err
 }
@@ -772,7 +771,7 @@ func (m *MicroserviceInstance) HasDirectParent(parent *ServiceInstancePathElemen
 
 // Check if this microservice instance has a container dpeloyment.
 // If it does not, then there is no nothing to execute.
-func (m MicroserviceInstance) HasWorkload(db *bolt.DB) (bool, error) {
+func (m MicroserviceInstance) HasWorkload(db AgentDatabase) (bool, error) {
 	if msdef, err := FindMicroserviceDefWithKey(db, m.MicroserviceDefId); err != nil {
 		return false, err
 	} else if msdef.HasDeployment() {
@@ -808,7 +807,7 @@ func (m *MicroserviceInstance) GetDirectParents() []ServiceInstancePathElement {
 }
 
 // create a new microservice instance and save it to db.
-func NewMicroserviceInstance(db *bolt.DB, ref_url string, org string, version string, msdef_id string, dependencyPath []ServiceInstancePathElement, topLevel bool) (*MicroserviceInstance, error) {
+func NewMicroserviceInstance(db AgentDatabase, ref_url string, org string, version string, msdef_id string, dependencyPath []ServiceInstancePathElement, topLevel bool) (*MicroserviceInstance, error) {
 
 	if ref_url == "" || org == "" || version == "" {
 		return nil, errors.New("Microservice ref url id, org or version is empty, cannot persist")
@@ -848,12 +847,12 @@ func NewMicroserviceInstance(db *bolt.DB, ref_url string, org string, version st
 }
 
 // find the microservice instance from the db
-func FindMicroserviceInstance(db *bolt.DB, url string, org string, version string, instance_id string) (*MicroserviceInstance, error) {
+func FindMicroserviceInstance(db AgentDatabase, url string, org string, version string, instance_id string) (*MicroserviceInstance, error) {
 	var pms *MicroserviceInstance
 	pms = nil
 
 	// fetch microservice instances
-	readErr := db.View(func(tx *bolt.Tx) error {
+	readErr := db.View(func(tx Tx) error {
 
 		if b := tx.Bucket([]byte(MICROSERVICE_INSTANCES)); b != nil {
 			b.ForEach(func(k, v []byte) error {
@@ -884,12 +883,12 @@ func FindMicroserviceInstance(db *bolt.DB, url string, org string, version strin
 }
 
 // find the microservice instance from the db
-func FindMicroserviceInstanceWithKey(db *bolt.DB, key string) (*MicroserviceInstance, error) {
+func FindMicroserviceInstanceWithKey(db AgentDatabase, key string) (*MicroserviceInstance, error) {
 	var pms *MicroserviceInstance
 	pms = nil
 
 	// fetch microservice instances
-	readErr := db.View(func(tx *bolt.Tx) error {
+	readErr := db.View(func(tx Tx) error {
 
 		if b := tx.Bucket([]byte(MICROSERVICE_INSTANCES)); b != nil {
 			v := b.Get([]byte(key))
@@ -952,11 +951,11 @@ func ServiceDefMIFilter(msdefId string) MIFilter {
 }
 
 // find the microservice instance from the db
-func FindMicroserviceInstances(db *bolt.DB, filters []MIFilter) ([]MicroserviceInstance, error) {
+func FindMicroserviceInstances(db AgentDatabase, filters []MIFilter) ([]MicroserviceInstance, error) {
 	ms_instances := make([]MicroserviceInstance, 0)
 
 	// fetch contracts
-	readErr := db.View(func(tx *bolt.Tx) error {
+	readErr := db.View(func(tx Tx) error {
 
 		if b := tx.Bucket([]byte(MICROSERVICE_INSTANCES)); b != nil {
 			b.ForEach(func(k, v []byte) error {
@@ -992,7 +991,7 @@ func FindMicroserviceInstances(db *bolt.DB, filters []MIFilter) ([]MicroserviceI
 }
 
 // set microservice instance state to execution started or failed
-func UpdateMSInstanceExecutionState(db *bolt.DB, key string, started bool, failure_code uint, failure_desc string) (*MicroserviceInstance, error) {
+func UpdateMSInstanceExecutionState(db AgentDatabase, key string, started bool, failure_code uint, failure_desc string) (*MicroserviceInstance, error) {
 	if started {
 		return microserviceInstanceStateUpdate(db, key, func(c MicroserviceInstance) *MicroserviceInstance {
 			c.ExecutionStartTime = uint64(time.Now().Unix())
@@ -1011,7 +1010,7 @@ func UpdateMSInstanceExecutionState(db *bolt.DB, key string, started bool, failu
 }
 
 // add or delete an associated agreement id to/from the microservice instance in the db
-func UpdateMSInstanceAssociatedAgreements(db *bolt.DB, key string, add bool, agreement_id string) (*MicroserviceInstance, error) {
+func UpdateMSInstanceAssociatedAgreements(db AgentDatabase, key string, add bool, agreement_id string) (*MicroserviceInstance, error) {
 	return microserviceInstanceStateUpdate(db, key, func(c MicroserviceInstance) *MicroserviceInstance {
 		if c.AssociatedAgreements == nil {
 			c.AssociatedAgreements = make([]string, 0)
@@ -1035,21 +1034,21 @@ func UpdateMSInstanceAssociatedAgreements(db *bolt.DB, key string, add bool, agr
 	})
 }
 
-func ArchiveMicroserviceInstance(db *bolt.DB, key string) (*MicroserviceInstance, error) {
+func ArchiveMicroserviceInstance(db AgentDatabase, key string) (*MicroserviceInstance, error) {
 	return microserviceInstanceStateUpdate(db, key, func(c MicroserviceInstance) *MicroserviceInstance {
 		c.Archived = true
 		return &c
 	})
 }
 
-func UpdateMSInstanceEnvVars(db *bolt.DB, key string, env_vars map[string]string) (*MicroserviceInstance, error) {
+func UpdateMSInstanceEnvVars(db AgentDatabase, key string, env_vars map[string]string) (*MicroserviceInstance, error) {
 	return microserviceInstanceStateUpdate(db, key, func(c MicroserviceInstance) *MicroserviceInstance {
 		c.EnvVars = env_vars
 		return &c
 	})
 }
 
-func MicroserviceInstanceCleanupStarted(db *bolt.DB, key string) (*MicroserviceInstance, error) {
+func MicroserviceInstanceCleanupStarted(db AgentDatabase, key string) (*MicroserviceInstance, error) {
 	return microserviceInstanceStateUpdate(db, key, func(c MicroserviceInstance) *MicroserviceInstance {
 		c.CleanupStartTime = uint64(time.Now().Unix())
 		return &c
@@ -1057,7 +1056,7 @@ func MicroserviceInstanceCleanupStarted(db *bolt.DB, key string) (*MicroserviceI
 }
 
 // Add the given path to the ParentPath. It will not be added if there is duplicate path.
-func UpdateMSInstanceAddDependencyPath(db *bolt.DB, key string, dp *[]ServiceInstancePathElement) (*MicroserviceInstance, error) {
+func UpdateMSInstanceAddDependencyPath(db AgentDatabase, key string, dp *[]ServiceInstancePathElement) (*MicroserviceInstance, error) {
 	return microserviceInstanceStateUpdate(db, key, func(c MicroserviceInstance) *MicroserviceInstance {
 		if dp != nil && len(*dp) != 0 {
 			found := false
@@ -1077,7 +1076,7 @@ func UpdateMSInstanceAddDependencyPath(db *bolt.DB, key string, dp *[]ServiceIns
 }
 
 // remove the given path to the ParentPath.
-func UpdateMSInstanceRemoveDependencyPath(db *bolt.DB, key string, dp *[]ServiceInstancePathElement) (*MicroserviceInstance, error) {
+func UpdateMSInstanceRemoveDependencyPath(db AgentDatabase, key string, dp *[]ServiceInstancePathElement) (*MicroserviceInstance, error) {
 	return microserviceInstanceStateUpdate(db, key, func(c MicroserviceInstance) *MicroserviceInstance {
 		if dp != nil && len(*dp) != 0 {
 			new_pp := make([][]ServiceInstancePathElement, 0)
@@ -1094,7 +1093,7 @@ func UpdateMSInstanceRemoveDependencyPath(db *bolt.DB, key string, dp *[]Service
 }
 
 // Remove all the paths with the given top parent from the ParentPath
-func UpdateMSInstanceRemoveDependencyPath2(db *bolt.DB, key string, top_parent *ServiceInstancePathElement) (*MicroserviceInstance, error) {
+func UpdateMSInstanceRemoveDependencyPath2(db AgentDatabase, key string, top_parent *ServiceInstancePathElement) (*MicroserviceInstance, error) {
 	return microserviceInstanceStateUpdate(db, key, func(c MicroserviceInstance) *MicroserviceInstance {
 		if top_parent != nil {
 			new_pp := make([][]ServiceInstancePathElement, 0)
@@ -1109,7 +1108,7 @@ func UpdateMSInstanceRemoveDependencyPath2(db *bolt.DB, key string, top_parent *
 	})
 }
 
-func UpdateMSInstanceAgreementLess(db *bolt.DB, key string) (*MicroserviceInstance, error) {
+func UpdateMSInstanceAgreementLess(db AgentDatabase, key string) (*MicroserviceInstance, error) {
 	return microserviceInstanceStateUpdate(db, key, func(c MicroserviceInstance) *MicroserviceInstance {
 		c.AgreementLess = true
 		return &c
@@ -1117,7 +1116,7 @@ func UpdateMSInstanceAgreementLess(db *bolt.DB, key string) (*MicroserviceInstan
 }
 
 // This function is call when the retry starts or retry is done. When it is done, this function resets the retry counts
-func UpdateMSInstanceRetryState(db *bolt.DB, key string, started bool, max_retries uint, max_retry_duration uint) (*MicroserviceInstance, error) {
+func UpdateMSInstanceRetryState(db AgentDatabase, key string, started bool, max_retries uint, max_retry_duration uint) (*MicroserviceInstance, error) {
 	return microserviceInstanceStateUpdate(db, key, func(c MicroserviceInstance) *MicroserviceInstance {
 		if started {
 			c.RetryStartTime = uint64(time.Now().Unix())
@@ -1134,14 +1133,14 @@ func UpdateMSInstanceRetryState(db *bolt.DB, key string, started bool, max_retri
 	})
 }
 
-func UpdateMSInstanceCurrentRetryCount(db *bolt.DB, key string, current_retry uint) (*MicroserviceInstance, error) {
+func UpdateMSInstanceCurrentRetryCount(db AgentDatabase, key string, current_retry uint) (*MicroserviceInstance, error) {
 	return microserviceInstanceStateUpdate(db, key, func(c MicroserviceInstance) *MicroserviceInstance {
 		c.CurrentRetryCount = current_retry
 		return &c
 	})
 }
 
-func ResetMsInstanceExecutionStatus(db *bolt.DB, key string) (*MicroserviceInstance, error) {
+func ResetMsInstanceExecutionStatus(db AgentDatabase, key string) (*MicroserviceInstance, error) {
 	return microserviceInstanceStateUpdate(db, key, func(c MicroserviceInstance) *MicroserviceInstance {
 		c.ExecutionStartTime = 0
 		c.ExecutionFailureCode = 0
@@ -1152,7 +1151,7 @@ func ResetMsInstanceExecutionStatus(db *bolt.DB, key string) (*MicroserviceInsta
 }
 
 // update the micorserive instance
-func microserviceInstanceStateUpdate(db *bolt.DB, key string, fn func(MicroserviceInstance) *MicroserviceInstance) (*MicroserviceInstance, error) {
+func microserviceInstanceStateUpdate(db AgentDatabase, key string, fn func(MicroserviceInstance) *MicroserviceInstance) (*MicroserviceInstance, error) {
 
 	if ms, err := FindMicroserviceInstanceWithKey(db, key); err != nil {
 		return nil, err
@@ -1166,8 +1165,8 @@ func microserviceInstanceStateUpdate(db *bolt.DB, key string, fn func(Microservi
 }
 
 // does whole-member replacements of values that are legal to change
-func persistUpdatedMicroserviceInstance(db *bolt.DB, key string, update *MicroserviceInstance) error {
-	return db.Update(func(tx *bolt.Tx) error {
+func persistUpdatedMicroserviceInstance(db AgentDatabase, key string, update *MicroserviceInstance) error {
+	return db.Update(func(tx Tx) error {
 		if b, err := tx.CreateBucketIfNotExists([]byte(MICROSERVICE_INSTANCES)); err != nil {
 			return err
 		} else {
@@ -1222,7 +1221,7 @@ func persistUpdatedMicroserviceInstance(db *bolt.DB, key string, update *Microse
 }
 
 // delete associated agreement id from all the microservice instances
-func DeleteAsscAgmtsFromMSInstances(db *bolt.DB, agreement_id string) error {
+func DeleteAsscAgmtsFromMSInstances(db AgentDatabase, agreement_id string) error {
 	if ms_instances, err := FindMicroserviceInstances(db, []MIFilter{UnarchivedMIFilter()}); err != nil {
 		return fmt.Errorf("Error retrieving all service instances from database, error: %v", err)
 	} else if ms_instances != nil {
@@ -1243,7 +1242,7 @@ func DeleteAsscAgmtsFromMSInstances(db *bolt.DB, agreement_id string) error {
 }
 
 // delete a microservice instance from db. It will NOT return error if it does not exist in the db
-func DeleteMicroserviceInstance(db *bolt.DB, key string) (*MicroserviceInstance, error) {
+func DeleteMicroserviceInstance(db AgentDatabase, key string) (*MicroserviceInstance, error) {
 
 	if key == "" {
 		return nil, errors.New("key is empty, cannot remove")
@@ -1253,7 +1252,7 @@ func DeleteMicroserviceInstance(db *bolt.DB, key string) (*MicroserviceInstance,
 		} else if ms == nil {
 			return nil, nil
 		} else {
-			return ms, db.Update(func(tx *bolt.Tx) error {
+			return ms, db.Update(func(tx Tx) error {
 
 				if b, err := tx.CreateBucketIfNotExists([]byte(MICROSERVICE_INSTANCES)); err != nil {
 					return err
@@ -1309,8 +1308,8 @@ func createInstanceId() string {
 }
 
 // save the given microservice instance into the db
-func saveMicroserviceInstance(db *bolt.DB, new_inst *MicroserviceInstance) (*MicroserviceInstance, error) {
-	return new_inst, db.Update(func(tx *bolt.Tx) error {
+func saveMicroserviceInstance(db AgentDatabase, new_inst *MicroserviceInstance) (*MicroserviceInstance, error) {
+	return new_inst, db.Update(func(tx Tx) error {
 		if b, err := tx.CreateBucketIfNotExists([]byte(MICROSERVICE_INSTANCES)); err != nil {
 			return err
 		} else if bytes, err := json.Marshal(new_inst); err != nil {
@@ -7,9 +7,6 @@ import (
 	"os"
 	"path"
 	"testing"
-	"time"
-
-	bolt "go.etcd.io/bbolt"
 )
 
 // Parent and child, simple case.
@@ -373,13 +370,13 @@ func Test_UpdateMSInstanceRemoveDependencyPath2(t *testing.T) {
 }
 
 // Utility functions needed by tests
-func utsetup() (string, *bolt.DB, error) {
+func utsetup() (string, AgentDatabase, error) {
 	dir, err := os.MkdirTemp("", "utdb-")
 	if err != nil {
 		return "", nil, err
 	}
 
-	db, err := bolt.Open(path.Join(dir, "anax-ut.db"), 0600, &bolt.Options{Timeout: 10 * time.Second})
+	db, err := OpenBoltDatabase(path.Join(dir, "anax-ut.db"))
 	if err != nil {
 		return dir, nil, err
 	}
@@ -4,13 +4,12 @@ import (
 	"encoding/json"
 	"fmt"
 	"github.com/open-horizon/anax/exchangecommon"
-	bolt "go.etcd.io/bbolt"
 )
 
 const NODE_MANAGEMENT_POLICY = "nodemanagementpolicy"
 
-func SaveOrUpdateNodeManagementPolicy(db *bolt.DB, policyKey string, policy exchangecommon.ExchangeNodeManagementPolicy) error {
-	writeErr := db.Update(func(tx *bolt.Tx) error {
+func SaveOrUpdateNodeManagementPolicy(db AgentDatabase, policyKey string, policy exchangecommon.ExchangeNodeManagementPolicy) error {
+	writeErr := db.Update(func(tx Tx) error {
 		if bucket, err := tx.CreateBucketIfNotExists([]byte(NODE_MANAGEMENT_POLICY)); err != nil {
 			return err
 		} else if serial, err := json.Marshal(policy); err != nil {
@@ -23,9 +22,9 @@ func SaveOrUpdateNodeManagementPolicy(db *bolt.DB, policyKey string, policy exch
 	return writeErr
 }
 
-func FindNodeManagementPolicy(db *bolt.DB, policyKey string) (*exchangecommon.ExchangeNodeManagementPolicy, error) {
+func FindNodeManagementPolicy(db AgentDatabase, policyKey string) (*exchangecommon.ExchangeNodeManagementPolicy, error) {
 	var nmpRecord *exchangecommon.ExchangeNodeManagementPolicy
-	readErr := db.View(func(tx *bolt.Tx) error {
+	readErr := db.View(func(tx Tx) error {
 		if b := tx.Bucket([]byte(NODE_MANAGEMENT_POLICY)); b != nil {
 			nmpRecSerial := b.Get([]byte(policyKey))
 			if nmpRecSerial != nil {
@@ -46,14 +45,14 @@ func FindNodeManagementPolicy(db *bolt.DB, policyKey string) (*exchangecommon.Ex
 	return nmpRecord, nil
 }
 
-func DeleteNodeManagementPolicy(db *bolt.DB, policyKey string) (*exchangecommon.ExchangeNodeManagementPolicy, error) {
+func DeleteNodeManagementPolicy(db AgentDatabase, policyKey string) (*exchangecommon.ExchangeNodeManagementPolicy, error) {
 	nmpRecord, err := FindNodeManagementPolicy(db, policyKey)
 	if err != nil {
 		return nil, err
 	} else if nmpRecord == nil {
 		return nil, nil
 	}
-	return nmpRecord, db.Update(func(tx *bolt.Tx) error {
+	return nmpRecord, db.Update(func(tx Tx) error {
 		if b, err := tx.CreateBucketIfNotExists([]byte(NODE_MANAGEMENT_POLICY)); err != nil {
 			return err
 		} else if err := b.Delete([]byte(policyKey)); err != nil {
@@ -63,8 +62,8 @@ func DeleteNodeManagementPolicy(db *bolt.DB, policyKey string) (*exchangecommon.
 	})
 }
 
-func DeleteAllNodeManagementPolicies(db *bolt.DB) error {
-	return db.Update(func(tx *bolt.Tx) error {
+func DeleteAllNodeManagementPolicies(db AgentDatabase) error {
+	return db.Update(func(tx Tx) error {
 		if b := tx.Bucket([]byte(NODE_MANAGEMENT_POLICY)); b != nil {
 			return tx.DeleteBucket([]byte(NODE_MANAGEMENT_POLICY))
 		}
@@ -5,15 +5,14 @@ import (
 	"fmt"
 	"github.com/golang/glog"
 	"github.com/open-horizon/anax/exchangecommon"
-	bolt "go.etcd.io/bbolt"
 	"time"
 )
 
 const NODE_MANAGEMENT_STATUS = "nodemanagementstatus"
 
-func SaveOrUpdateNMPStatus(db *bolt.DB, nmpKey string, status exchangecommon.NodeManagementPolicyStatus) error {
+func SaveOrUpdateNMPStatus(db AgentDatabase, nmpKey string, status exchangecommon.NodeManagementPolicyStatus) error {
 	glog.V(5).Infof(fmt.Sprintf("Saving nmp status %v", status))
-	writeErr := db.Update(func(tx *bolt.Tx) error {
+	writeErr := db.Update(func(tx Tx) error {
 		if bucket, err := tx.CreateBucketIfNotExists([]byte(NODE_MANAGEMENT_STATUS)); err != nil {
 			return err
 		} else if serial, err := json.Marshal(status); err != nil {
@@ -26,11 +25,11 @@ func SaveOrUpdateNMPStatus(db *bolt.DB, nmpKey string, status exchangecommon.Nod
 	return writeErr
 }
 
-func DeleteNMPStatus(db *bolt.DB, nmpKey string) (*exchangecommon.NodeManagementPolicyStatus, error) {
+func DeleteNMPStatus(db AgentDatabase, nmpKey string) (*exchangecommon.NodeManagementPolicyStatus, error) {
 	if pol, err := FindNMPStatus(db, nmpKey); err != nil {
 		return nil, err
 	} else if pol != nil {
-		return pol, db.Update(func(tx *bolt.Tx) error {
+		return pol, db.Update(func(tx Tx) error {
 			if b, err := tx.CreateBucketIfNotExists([]byte(NODE_MANAGEMENT_STATUS)); err != nil {
 				return err
 			} else if err = b.Delete([]byte(nmpKey)); err != nil {
@@ -43,9 +42,9 @@ func DeleteNMPStatus(db *bolt.DB, nmpKey string) (*exchangecommon.NodeManagement
 	}
 }
 
-func FindNMPStatus(db *bolt.DB, nmpKey string) (*exchangecommon.NodeManagementPolicyStatus, error) {
+func FindNMPStatus(db AgentDatabase, nmpKey string) (*exchangecommon.NodeManagementPolicyStatus, error) {
 	var nmStatusRecord *exchangecommon.NodeManagementPolicyStatus
-	readErr := db.View(func(tx *bolt.Tx) error {
+	readErr := db.View(func(tx Tx) error {
 		if b := tx.Bucket([]byte(NODE_MANAGEMENT_STATUS)); b != nil {
 			nmsSerialRec := b.Get([]byte(nmpKey))
 			if nmsSerialRec != nil {
@@ -67,10 +66,10 @@ func FindNMPStatus(db *bolt.DB, nmpKey string) (*exchangecommon.NodeManagementPo
 	return nmStatusRecord, nil
 }
 
-func FindAllNMPStatus(db *bolt.DB) (map[string]*exchangecommon.NodeManagementPolicyStatus, error) {
+func FindAllNMPStatus(db AgentDatabase) (map[string]*exchangecommon.NodeManagementPolicyStatus, error) {
 	statuses := make(map[string]*exchangecommon.NodeManagementPolicyStatus, 0)
 
-	readErr := db.View(func(tx *bolt.Tx) error {
+	readErr := db.View(func(tx Tx) error {
 		if b := tx.Bucket([]byte(NODE_MANAGEMENT_STATUS)); b != nil {
 			b.ForEach(func(k, v []byte) error {
 				var s exchangecommon.NodeManagementPolicyStatus
@@ -129,10 +128,10 @@ func LatestKeywordNMSFilter() NMStatusFilter {
 	}
 }
 
-func FindNMPStatusWithFilters(db *bolt.DB, filters []NMStatusFilter) (map[string]*exchangecommon.NodeManagementPolicyStatus, error) {
+func FindNMPStatusWithFilters(db AgentDatabase, filters []NMStatusFilter) (map[string]*exchangecommon.NodeManagementPolicyStatus, error) {
 	statuses := make(map[string]*exchangecommon.NodeManagementPolicyStatus, 0)
 
-	readErr := db.View(func(tx *bolt.Tx) error {
+	readErr := db.View(func(tx Tx) error {
 		if b := tx.Bucket([]byte(NODE_MANAGEMENT_STATUS)); b != nil {
 			b.ForEach(func(k, v []byte) error {
 				var s exchangecommon.NodeManagementPolicyStatus
@@ -163,27 +162,27 @@ func FindNMPStatusWithFilters(db *bolt.DB, filters []NMStatusFilter) (map[string
 	return statuses, nil
 }
 
-func FindWaitingNMPStatuses(db *bolt.DB) (map[string]*exchangecommon.NodeManagementPolicyStatus, error) {
+func FindWaitingNMPStatuses(db AgentDatabase) (map[string]*exchangecommon.NodeManagementPolicyStatus, error) {
 	return FindNMPStatusWithFilters(db, []NMStatusFilter{StatusNMSFilter(exchangecommon.STATUS_NEW)})
 }
 
-func FindHAWaitingNMPStatuses(db *bolt.DB) (map[string]*exchangecommon.NodeManagementPolicyStatus, error) {
+func FindHAWaitingNMPStatuses(db AgentDatabase) (map[string]*exchangecommon.NodeManagementPolicyStatus, error) {
 	return FindNMPStatusWithFilters(db, []NMStatusFilter{StatusNMSFilter(exchangecommon.STATUS_HA_WAITING)})
 }
 
-func FindInitiatedNMPStatuses(db *bolt.DB) (map[string]*exchangecommon.NodeManagementPolicyStatus, error) {
+func FindInitiatedNMPStatuses(db AgentDatabase) (map[string]*exchangecommon.NodeManagementPolicyStatus, error) {
 	return FindNMPStatusWithFilters(db, []NMStatusFilter{StatusNMSFilter(exchangecommon.STATUS_INITIATED)})
 }
 
-func FindDownloadStartedNMPStatuses(db *bolt.DB) (map[string]*exchangecommon.NodeManagementPolicyStatus, error) {
+func FindDownloadStartedNMPStatuses(db AgentDatabase) (map[string]*exchangecommon.NodeManagementPolicyStatus, error) {
 	return FindNMPStatusWithFilters(db, []NMStatusFilter{StatusNMSFilter(exchangecommon.STATUS_DOWNLOAD_STARTED)})
 }
 
-func FindNMPWithLatestKeywordVersion(db *bolt.DB) (map[string]*exchangecommon.NodeManagementPolicyStatus, error) {
+func FindNMPWithLatestKeywordVersion(db AgentDatabase) (map[string]*exchangecommon.NodeManagementPolicyStatus, error) {
 	return FindNMPStatusWithFilters(db, []NMStatusFilter{LatestKeywordNMSFilter()})
 }
 
-func FindNMPSWithStatuses(db *bolt.DB, statuses []string) (map[string]*exchangecommon.NodeManagementPolicyStatus, error) {
+func FindNMPSWithStatuses(db AgentDatabase, statuses []string) (map[string]*exchangecommon.NodeManagementPolicyStatus, error) {
 	NMPStatuses := map[string]*exchangecommon.NodeManagementPolicyStatus{}
 	for _, status := range statuses {
 		matchingStatuses, err := FindNMPStatusWithFilters(db, []NMStatusFilter{StatusNMSFilter(status)})
@@ -198,7 +197,7 @@ func FindNMPSWithStatuses(db *bolt.DB, statuses []string) (map[string]*exchangec
 	return NMPStatuses, nil
 }
 
-func FindNodeUpgradeStatusesWithTypeAfterTime(db *bolt.DB, t time.Time, upgradeType string) (map[string]*exchangecommon.NodeManagementPolicyStatus, error) {
+func FindNodeUpgradeStatusesWithTypeAfterTime(db AgentDatabase, t time.Time, upgradeType string) (map[string]*exchangecommon.NodeManagementPolicyStatus, error) {
 	if upgradeType == "software" {
 		return FindNMPStatusWithFilters(db, []NMStatusFilter{SoftwareUpdateNMSFilter(), TimeScheduledNMSFilter(t)})
 	}
@@ -211,8 +210,8 @@ func FindNodeUpgradeStatusesWithTypeAfterTime(db *bolt.DB, t time.Time, upgradeT
 	return nil, fmt.Errorf("Unrecognized upgrade type: \"%v\".", upgradeType)
 }
 
-func DeleteAllNMPStatuses(db *bolt.DB) error {
-	return db.Update(func(tx *bolt.Tx) error {
+func DeleteAllNMPStatuses(db AgentDatabase) error {
+	return db.Update(func(tx Tx) error {
 		if b := tx.Bucket([]byte(NODE_MANAGEMENT_STATUS)); b != nil {
 			return tx.DeleteBucket([]byte(NODE_MANAGEMENT_STATUS))
 		}
@@ -2,7 +2,6 @@ package persistence
 
 import (
 	"fmt"
-	bolt "go.etcd.io/bbolt"
 )
 
 // Constants used throughout the code.
@@ -12,11 +11,11 @@ const NODE_EXCH_PATTERN = "nodeexchpattern" // The bucket name in the bolt DB.
 // from the local registered node pattern. It will be cleared once the device pattern get changed.
 // The bolt APIs assume there is more than 1 object in a bucket,
 // so this function has to be prepared for that case, even though there should only ever be 1.
-func FindSavedNodeExchPattern(db *bolt.DB) (string, error) {
+func FindSavedNodeExchPattern(db AgentDatabase) (string, error) {
 
 	pattern_name := ""
 
-	readErr := db.View(func(tx *bolt.Tx) error {
+	readErr := db.View(func(tx Tx) error {
 		if b := tx.Bucket([]byte(NODE_EXCH_PATTERN)); b != nil {
 			return b.ForEach(func(k, v []byte) error {
 				pattern_name = string(v)
@@ -35,9 +34,9 @@ func FindSavedNodeExchPattern(db *bolt.DB) (string, error) {
 }
 
 // There is only 1 object in the bucket so we can use the bucket name as the object key.
-func SaveNodeExchPattern(db *bolt.DB, nodePatternName string) error {
+func SaveNodeExchPattern(db AgentDatabase, nodePatternName string) error {
 
-	writeErr := db.Update(func(tx *bolt.Tx) error {
+	writeErr := db.Update(func(tx Tx) error {
 		b, err := tx.CreateBucketIfNotExists([]byte(NODE_EXCH_PATTERN))
 		if err != nil {
 			return err
@@ -51,7 +50,7 @@ func SaveNodeExchPattern(db *bolt.DB, nodePatternName string) error {
 }
 
 // Remove the node exchange pattern name from the local database.
-func DeleteNodeExchPattern(db *bolt.DB) error {
+func DeleteNodeExchPattern(db AgentDatabase) error {
 
 	if pattern_name, err := FindSavedNodeExchPattern(db); err != nil {
 		return err
@@ -59,7 +58,7 @@ func DeleteNodeExchPattern(db *bolt.DB) error {
 		return nil
 	} else {
 
-		return db.Update(func(tx *bolt.Tx) error {
+		return db.Update(func(tx Tx) error {
 
 			if b, err := tx.CreateBucketIfNotExists([]byte(NODE_EXCH_PATTERN)); err != nil {
 				return err
@@ -4,7 +4,6 @@ import (
 	"encoding/json"
 	"fmt"
 	"github.com/open-horizon/anax/exchangecommon"
-	bolt "go.etcd.io/bbolt"
 )
 
 // Constants used throughout the code.
@@ -25,11 +24,11 @@ func (e PersistenceNodePolicy) String() string {
 
 // Retrieve the node policy object from the database. The bolt APIs assume there is more than 1 object in a bucket,
 // so this function has to be prepared for that case, even though there should only ever be 1.
-func FindNodePolicy(db *bolt.DB) (*exchangecommon.NodePolicy, error) {
+func FindNodePolicy(db AgentDatabase) (*exchangecommon.NodePolicy, error) {
 
 	policy := make([]exchangecommon.NodePolicy, 0)
 
-	readErr := db.View(func(tx *bolt.Tx) error {
+	readErr := db.View(func(tx Tx) error {
 		if b := tx.Bucket([]byte(NODE_POLICY)); b != nil {
 			return b.ForEach(func(k, v []byte) error {
 				var pol PersistenceNodePolicy
@@ -70,9 +69,9 @@ func FindNodePolicy(db *bolt.DB) (*exchangecommon.NodePolicy, error) {
 }
 
 // There is only 1 object in the bucket so we can use the bucket name as the object key.
-func SaveNodePolicy(db *bolt.DB, nodePolicy *exchangecommon.NodePolicy) error {
+func SaveNodePolicy(db AgentDatabase, nodePolicy *exchangecommon.NodePolicy) error {
 
-	writeErr := db.Update(func(tx *bolt.Tx) error {
+	writeErr := db.Update(func(tx Tx) error {
 		b, err := tx.CreateBucketIfNotExists([]byte(NODE_POLICY))
 		if err != nil {
 			return err
@@ -90,7 +89,7 @@ func SaveNodePolicy(db *bolt.DB, nodePolicy *exchangecommon.NodePolicy) error {
 }
 
 // Remove the node policy object from the local database.
-func DeleteNodePolicy(db *bolt.DB) error {
+func DeleteNodePolicy(db AgentDatabase) error {
 
 	if pol, err := FindNodePolicy(db); err != nil {
 		return err
@@ -98,7 +97,7 @@ func DeleteNodePolicy(db *bolt.DB) error {
 		return nil
 	} else {
 
-		return db.Update(func(tx *bolt.Tx) error {
+		return db.Update(func(tx Tx) error {
 
 			if b, err := tx.CreateBucketIfNotExists([]byte(NODE_POLICY)); err != nil {
 				return err
@@ -112,11 +111,11 @@ func DeleteNodePolicy(db *bolt.DB) error {
 }
 
 // Retrieve the exchange node policy lastUpdated string from the database.
-func GetNodePolicyLastUpdated_Exch(db *bolt.DB) (string, error) {
+func GetNodePolicyLastUpdated_Exch(db AgentDatabase) (string, error) {
 
 	lastUpdated := ""
 
-	readErr := db.View(func(tx *bolt.Tx) error {
+	readErr := db.View(func(tx Tx) error {
 		if b := tx.Bucket([]byte(EXCHANGE_NP_LAST_UPDATED)); b != nil {
 			return b.ForEach(func(k, v []byte) error {
 				lastUpdated = string(v)
@@ -135,9 +134,9 @@ func GetNodePolicyLastUpdated_Exch(db *bolt.DB) (string, error) {
 }
 
 // save the exchange node policy lastUpdated string.
-func SaveNodePolicyLastUpdated_Exch(db *bolt.DB, lastUpdated string) error {
+func SaveNodePolicyLastUpdated_Exch(db AgentDatabase, lastUpdated string) error {
 
-	writeErr := db.Update(func(tx *bolt.Tx) error {
+	writeErr := db.Update(func(tx Tx) error {
 		b, err := tx.CreateBucketIfNotExists([]byte(EXCHANGE_NP_LAST_UPDATED))
 		if err != nil {
 			return err
@@ -151,14 +150,14 @@ func SaveNodePolicyLastUpdated_Exch(db *bolt.DB, lastUpdated string) error {
 }
 
 // Remove the exchange node policy lastUpdated string from the local database.
-func DeleteNodePolicyLastUpdated_Exch(db *bolt.DB) error {
+func DeleteNodePolicyLastUpdated_Exch(db AgentDatabase) error {
 
 	if lastUpdated, err := GetNodePolicyLastUpdated_Exch(db); err != nil {
 		return err
 	} else if lastUpdated == "" {
 		return nil
 	} else {
-		return db.Update(func(tx *bolt.Tx) error {
+		return db.Update(func(tx Tx) error {
 
 			if b, err := tx.CreateBucketIfNotExists([]byte(EXCHANGE_NP_LAST_UPDATED)); err != nil {
 				return err
@@ -3,7 +3,6 @@ package persistence
 import (
 	"encoding/json"
 	"fmt"
-	bolt "go.etcd.io/bbolt"
 )
 
 const NODE_STATUS = "node_status"
@@ -27,10 +26,10 @@ type ContainerStatus struct {
 }
 
 // FindNodeStatus returns the node status currently in the local db
-func FindNodeStatus(db *bolt.DB) ([]WorkloadStatus, error) {
+func FindNodeStatus(db AgentDatabase) ([]WorkloadStatus, error) {
 	var nodeStatus []WorkloadStatus
 
-	readErr := db.View(func(tx *bolt.Tx) error {
+	readErr := db.View(func(tx Tx) error {
 		if b := tx.Bucket([]byte(NODE_STATUS)); b != nil {
 			return b.ForEach(func(k, v []byte) error {
 
@@ -52,8 +51,8 @@ func FindNodeStatus(db *bolt.DB) ([]WorkloadStatus, error) {
 }
 
 // SaveNodeStatus saves the provided node status to the local db
-func SaveNodeStatus(db *bolt.DB, status []WorkloadStatus) error {
-	writeErr := db.Update(func(tx *bolt.Tx) error {
+func SaveNodeStatus(db AgentDatabase, status []WorkloadStatus) error {
+	writeErr := db.Update(func(tx Tx) error {
 		b, err := tx.CreateBucketIfNotExists([]byte(NODE_STATUS))
 		if err != nil {
 			return err
@@ -70,13 +69,13 @@ func SaveNodeStatus(db *bolt.DB, status []WorkloadStatus) error {
 }
 
 // DeleteSurfaceErrors delete node status from the local database
-func DeleteNodeStatus(db *bolt.DB) error {
+func DeleteNodeStatus(db AgentDatabase) error {
 	if seList, err := FindNodeStatus(db); err != nil {
 		return err
 	} else if len(seList) == 0 {
 		return nil
 	} else {
-		return db.Update(func(tx *bolt.Tx) error {
+		return db.Update(func(tx Tx) error {
 
 			if b, err := tx.CreateBucketIfNotExists([]byte(NODE_STATUS)); err != nil {
 				return err
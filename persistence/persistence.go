@@ -6,7 +6,6 @@ import (
 	"fmt"
 	"github.com/golang/glog"
 	"github.com/open-horizon/anax/cutil"
-	bolt "go.etcd.io/bbolt"
 	"time"
 )
 
@@ -135,7 +134,7 @@ func (c EstablishedAgreement) String() string {
 
 }
 
-func NewEstablishedAgreement(db *bolt.DB, name string, agreementId string, consumerId string, proposal string, protocol string, protocolVersion int, dependentSvcs ServiceSpecs, signature string, address string, bcType string, bcName string, bcOrg string, wi *WorkloadInfo, agreementTimeout uint64) (*EstablishedAgreement, error) {
+func NewEstablishedAgreement(db AgentDatabase, name string, agreementId string, consumerId string, proposal string, protocol string, protocolVersion int, dependentSvcs ServiceSpecs, signature string, address string, bcType string, bcName string, bcOrg string, wi *WorkloadInfo, agreementTimeout uint64) (*EstablishedAgreement, error) {
 
 	if name == "" || agreementId == "" || consumerId == "" || proposal == "" || protocol == "" || protocolVersion == 0 {
 		return nil, errors.New("Agreement id, consumer id, proposal, protocol, or protocol version are empty, cannot persist")
@@ -185,7 +184,7 @@ func NewEstablishedAgreement(db *bolt.DB, name string, agreementId string, consu
 		FailedVerAttempts:               0,
 	}
 
-	return newAg, db.Update(func(tx *bolt.Tx) error {
+	return newAg, db.Update(func(tx Tx) error {
 
 		if b, err := tx.CreateBucketIfNotExists([]byte(E_AGREEMENTS + "-" + protocol)); err != nil {
 			return err
@@ -322,7 +321,7 @@ func (a *EstablishedAgreement) GetRetryStartTime() uint64 {
 	return 0
 }
 
-func (a *EstablishedAgreement) Archive(db *bolt.DB) error {
+func (a *EstablishedAgreement) Archive(db AgentDatabase) error {
 	_, err := ArchiveEstablishedAgreement(db, a.CurrentAgreementId, a.AgreementProtocol)
 	return err
 }
@@ -337,7 +336,7 @@ func (a *EstablishedAgreement) GetDeploymentConfig() DeploymentConfig {
 		nd.Services = a.CurrentDeployment
 		return nd
 
-		// The extended deployment config must be in use, so return it. It could be kube or helm.
+		// The extended deployment config must be in use, so return it. It could be kube, helm or systemd.
 	} else if IsKube(a.ExtendedDeployment) {
 		cd := new(KubeDeploymentConfig)
 		if err := cd.FromPersistentForm(a.ExtendedDeployment); err != nil {
@@ -350,12 +349,18 @@ func (a *EstablishedAgreement) GetDeploymentConfig() DeploymentConfig {
 			glog.Errorf("Unable to convert helm deployment %v to persistent form, error %v", a.ExtendedDeployment, err)
 		}
 		return hd
+	} else if IsSystemd(a.ExtendedDeployment) {
+		sd := new(SystemdDeploymentConfig)
+		if err := sd.FromPersistentForm(a.ExtendedDeployment); err != nil {
+			glog.Errorf("Unable to convert systemd deployment %v to persistent form, error %v", a.ExtendedDeployment, err)
+		}
+		return sd
 	}
 
 	return nil
 }
 
-func ArchiveEstablishedAgreement(db *bolt.DB, agreementId string, protocol string) (*EstablishedAgreement, error) {
+func ArchiveEstablishedAgreement(db AgentDatabase, agreementId string, protocol string) (*EstablishedAgreement, error) {
 	return agreementStateUpdate(db, agreementId, protocol, func(c EstablishedAgreement) *EstablishedAgreement {
 		c.Archived = true
 		c.CurrentDeployment = map[string]ServiceConfig{}
@@ -364,7 +369,7 @@ func ArchiveEstablishedAgreement(db *bolt.DB, agreementId string, protocol strin
 }
 
 // set agreement state to execution started
-func AgreementStateExecutionStarted(db *bolt.DB, dbAgreementId string, protocol string) (*EstablishedAgreement, error) {
+func AgreementStateExecutionStarted(db AgentDatabase, dbAgreementId string, protocol string) (*EstablishedAgreement, error) {
 	return agreementStateUpdate(db, dbAgreementId, protocol, func(c EstablishedAgreement) *EstablishedAgreement {
 		c.AgreementExecutionStartTime = uint64(time.Now().Unix())
 		return &c
@@ -372,7 +377,7 @@ func AgreementStateExecutionStarted(db *bolt.DB, dbAgreementId string, protocol
 }
 
 // set agreement state to accepted, a positive reply is being sent
-func AgreementStateAccepted(db *bolt.DB, dbAgreementId string, protocol string) (*EstablishedAgreement, error) {
+func AgreementStateAccepted(db AgentDatabase, dbAgreementId string, protocol string) (*EstablishedAgreement, error) {
 	return agreementStateUpdate(db, dbAgreementId, protocol, func(c EstablishedAgreement) *EstablishedAgreement {
 		c.AgreementAcceptedTime = uint64(time.Now().Unix())
 		return &c
@@ -380,7 +385,7 @@ func AgreementStateAccepted(db *bolt.DB, dbAgreementId string, protocol string)
 }
 
 // set the eth signature of the proposal
-func AgreementStateProposalSigned(db *bolt.DB, dbAgreementId string, protocol string, sig string) (*EstablishedAgreement, error) {
+func AgreementStateProposalSigned(db AgentDatabase, dbAgreementId string, protocol string, sig string) (*EstablishedAgreement, error) {
 	return agreementStateUpdate(db, dbAgreementId, protocol, func(c EstablishedAgreement) *EstablishedAgreement {
 		c.ProposalSig = sig
 		return &c
@@ -388,7 +393,7 @@ func AgreementStateProposalSigned(db *bolt.DB, dbAgreementId string, protocol st
 }
 
 // set the eth counterparty address when it is received from the consumer
-func AgreementStateBCDataReceived(db *bolt.DB, dbAgreementId string, protocol string, address string) (*EstablishedAgreement, error) {
+func AgreementStateBCDataReceived(db AgentDatabase, dbAgreementId string, protocol string, address string) (*EstablishedAgreement, error) {
 	return agreementStateUpdate(db, dbAgreementId, protocol, func(c EstablishedAgreement) *EstablishedAgreement {
 		c.CounterPartyAddress = address
 		return &c
@@ -396,7 +401,7 @@ func AgreementStateBCDataReceived(db *bolt.DB, dbAgreementId string, protocol st
 }
 
 // set the time when out agreement blockchain update message was Ack'd.
-func AgreementStateBCUpdateAcked(db *bolt.DB, dbAgreementId string, protocol string) (*EstablishedAgreement, error) {
+func AgreementStateBCUpdateAcked(db AgentDatabase, dbAgreementId string, protocol string) (*EstablishedAgreement, error) {
 	return agreementStateUpdate(db, dbAgreementId, protocol, func(c EstablishedAgreement) *EstablishedAgreement {
 		c.AgreementBCUpdateAckTime = uint64(time.Now().Unix())
 		return &c
@@ -404,7 +409,7 @@ func AgreementStateBCUpdateAcked(db *bolt.DB, dbAgreementId string, protocol str
 }
 
 // set agreement state to finalized
-func AgreementStateFinalized(db *bolt.DB, dbAgreementId string, protocol string) (*EstablishedAgreement, error) {
+func AgreementStateFinalized(db AgentDatabase, dbAgreementId string, protocol string) (*EstablishedAgreement, error) {
 	return agreementStateUpdate(db, dbAgreementId, protocol, func(c EstablishedAgreement) *EstablishedAgreement {
 		c.AgreementFinalizedTime = uint64(time.Now().Unix())
 		return &c
@@ -412,7 +417,7 @@ func AgreementStateFinalized(db *bolt.DB, dbAgreementId string, protocol string)
 }
 
 // set deployment config because execution is about to begin
-func AgreementDeploymentStarted(db *bolt.DB, dbAgreementId string, protocol string, deployment DeploymentConfig) (*EstablishedAgreement, error) {
+func AgreementDeploymentStarted(db AgentDatabase, dbAgreementId string, protocol string, deployment DeploymentConfig) (*EstablishedAgreement, error) {
 	return agreementStateUpdate(db, dbAgreementId, protocol, func(c EstablishedAgreement) *EstablishedAgreement {
 		if pf, err := deployment.ToPersistentForm(); err != nil {
 			glog.Errorf("Unable to persist deployment config: (%T) %v", deployment, deployment)
@@ -428,7 +433,7 @@ func AgreementDeploymentStarted(db *bolt.DB, dbAgreementId string, protocol stri
 }
 
 // set agreement state to terminated
-func AgreementStateTerminated(db *bolt.DB, dbAgreementId string, reason uint64, reasonString string, protocol string) (*EstablishedAgreement, error) {
+func AgreementStateTerminated(db AgentDatabase, dbAgreementId string, reason uint64, reasonString string, protocol string) (*EstablishedAgreement, error) {
 	return agreementStateUpdate(db, dbAgreementId, protocol, func(c EstablishedAgreement) *EstablishedAgreement {
 		c.AgreementTerminatedTime = uint64(time.Now().Unix())
 		c.TerminatedReason = reason
@@ -438,7 +443,7 @@ func AgreementStateTerminated(db *bolt.DB, dbAgreementId string, reason uint64,
 }
 
 // reset agreement state to not-terminated so that we can retry the termination
-func AgreementStateForceTerminated(db *bolt.DB, dbAgreementId string, protocol string) (*EstablishedAgreement, error) {
+func AgreementStateForceTerminated(db AgentDatabase, dbAgreementId string, protocol string) (*EstablishedAgreement, error) {
 	return agreementStateUpdate(db, dbAgreementId, protocol, func(c EstablishedAgreement) *EstablishedAgreement {
 		c.AgreementForceTerminatedTime = uint64(time.Now().Unix())
 		return &c
@@ -446,7 +451,7 @@ func AgreementStateForceTerminated(db *bolt.DB, dbAgreementId string, protocol s
 }
 
 // set agreement state to data received
-func AgreementStateDataReceived(db *bolt.DB, dbAgreementId string, protocol string) (*EstablishedAgreement, error) {
+func AgreementStateDataReceived(db AgentDatabase, dbAgreementId string, protocol string) (*EstablishedAgreement, error) {
 	return agreementStateUpdate(db, dbAgreementId, protocol, func(c EstablishedAgreement) *EstablishedAgreement {
 		c.AgreementDataReceivedTime = uint64(time.Now().Unix())
 		return &c
@@ -454,7 +459,7 @@ func AgreementStateDataReceived(db *bolt.DB, dbAgreementId string, protocol stri
 }
 
 // set agreement state to agreement protocol terminated
-func AgreementStateAgreementProtocolTerminated(db *bolt.DB, dbAgreementId string, protocol string) (*EstablishedAgreement, error) {
+func AgreementStateAgreementProtocolTerminated(db AgentDatabase, dbAgreementId string, protocol string) (*EstablishedAgreement, error) {
 	return agreementStateUpdate(db, dbAgreementId, protocol, func(c EstablishedAgreement) *EstablishedAgreement {
 		c.AgreementProtocolTerminatedTime = uint64(time.Now().Unix())
 		return &c
@@ -462,7 +467,7 @@ func AgreementStateAgreementProtocolTerminated(db *bolt.DB, dbAgreementId string
 }
 
 // set agreement state to workload terminated
-func AgreementStateWorkloadTerminated(db *bolt.DB, dbAgreementId string, protocol string) (*EstablishedAgreement, error) {
+func AgreementStateWorkloadTerminated(db AgentDatabase, dbAgreementId string, protocol string) (*EstablishedAgreement, error) {
 	return agreementStateUpdate(db, dbAgreementId, protocol, func(c EstablishedAgreement) *EstablishedAgreement {
 		c.WorkloadTerminatedTime = uint64(time.Now().Unix())
 		return &c
@@ -470,14 +475,14 @@ func AgreementStateWorkloadTerminated(db *bolt.DB, dbAgreementId string, protoco
 }
 
 // set agreement state to workload terminated
-func MeteringNotificationReceived(db *bolt.DB, dbAgreementId string, mn MeteringNotification, protocol string) (*EstablishedAgreement, error) {
+func MeteringNotificationReceived(db AgentDatabase, dbAgreementId string, mn MeteringNotification, protocol string) (*EstablishedAgreement, error) {
 	return agreementStateUpdate(db, dbAgreementId, protocol, func(c EstablishedAgreement) *EstablishedAgreement {
 		c.MeteringNotificationMsg = mn
 		return &c
 	})
 }
 
-func SetFailedVerAttempts(db *bolt.DB, dbAgreementId string, protocol string, failedVerAttempts uint64) (*EstablishedAgreement, error) {
+func SetFailedVerAttempts(db AgentDatabase, dbAgreementId string, protocol string, failedVerAttempts uint64) (*EstablishedAgreement, error) {
 	return agreementStateUpdate(db, dbAgreementId, protocol, func(c EstablishedAgreement) *EstablishedAgreement {
 		c.LastVerAttemptUpdateTime = uint64(time.Now().Unix())
 		c.FailedVerAttempts = failedVerAttempts
@@ -485,21 +490,21 @@ func SetFailedVerAttempts(db *bolt.DB, dbAgreementId string, protocol string, fa
 	})
 }
 
-func SetAgreementTimeout(db *bolt.DB, dbAgreementId string, protocol string, agTimeoutS uint64) (*EstablishedAgreement, error) {
+func SetAgreementTimeout(db AgentDatabase, dbAgreementId string, protocol string, agTimeoutS uint64) (*EstablishedAgreement, error) {
 	return agreementStateUpdate(db, dbAgreementId, protocol, func(c EstablishedAgreement) *EstablishedAgreement {
 		c.AgreementTimeout = agTimeoutS
 		return &c
 	})
 }
 
-func SetAgreementServiceDefId(db *bolt.DB, dbAgreementId string, protocol string, svcDefId string) (*EstablishedAgreement, error) {
+func SetAgreementServiceDefId(db AgentDatabase, dbAgreementId string, protocol string, svcDefId string) (*EstablishedAgreement, error) {
 	return agreementStateUpdate(db, dbAgreementId, protocol, func(c EstablishedAgreement) *EstablishedAgreement {
 		c.ServiceDefId = svcDefId
 		return &c
 	})
 }
 
-func SetAgreementProposal(db *bolt.DB, dbAgreementId string, protocols []string, newProposal string) (*EstablishedAgreement, error) {
+func SetAgreementProposal(db AgentDatabase, dbAgreementId string, protocols []string, newProposal string) (*EstablishedAgreement, error) {
 	if existingAgreements, err := FindEstablishedAgreementsAllProtocols(db, protocols, []EAFilter{IdEAFilter(dbAgreementId)}); err != nil {
 		return nil, fmt.Errorf("Error finding agreement %v for update: %v", dbAgreementId, err)
 	} else if len(existingAgreements) != 1 {
@@ -511,7 +516,7 @@ func SetAgreementProposal(db *bolt.DB, dbAgreementId string, protocols []string,
 	}
 }
 
-func DeleteEstablishedAgreement(db *bolt.DB, agreementId string, protocol string) error {
+func DeleteEstablishedAgreement(db AgentDatabase, agreementId string, protocol string) error {
 
 	if agreementId == "" {
 		return errors.New("Agreement id empty, cannot remove")
@@ -527,7 +532,7 @@ func DeleteEstablishedAgreement(db *bolt.DB, agreementId string, protocol string
 			return fmt.Errorf("Expecting 1 records with id: %v, found %v", agreementId, agreements)
 		} else {
 
-			return db.Update(func(tx *bolt.Tx) error {
+			return db.Update(func(tx Tx) error {
 
 				if b, err := tx.CreateBucketIfNotExists([]byte(E_AGREEMENTS + "-" + protocol)); err != nil {
 					return err
@@ -541,7 +546,7 @@ func DeleteEstablishedAgreement(db *bolt.DB, agreementId string, protocol string
 	}
 }
 
-func agreementStateUpdate(db *bolt.DB, dbAgreementId string, protocol string, fn func(EstablishedAgreement) *EstablishedAgreement) (*EstablishedAgreement, error) {
+func agreementStateUpdate(db AgentDatabase, dbAgreementId string, protocol string, fn func(EstablishedAgreement) *EstablishedAgreement) (*EstablishedAgreement, error) {
 	filters := make([]EAFilter, 0)
 	filters = append(filters, UnarchivedEAFilter())
 	filters = append(filters, IdEAFilter(dbAgreementId))
@@ -560,8 +565,8 @@ func agreementStateUpdate(db *bolt.DB, dbAgreementId string, protocol string, fn
 }
 
 // does whole-member replacements of values that are legal to change during the course of a contract's life
-func persistUpdatedAgreement(db *bolt.DB, dbAgreementId string, protocol string, update *EstablishedAgreement) error {
-	return db.Update(func(tx *bolt.Tx) error {
+func persistUpdatedAgreement(db AgentDatabase, dbAgreementId string, protocol string, update *EstablishedAgreement) error {
+	return db.Update(func(tx Tx) error {
 		if b, err := tx.CreateBucketIfNotExists([]byte(E_AGREEMENTS + "-" + protocol)); err != nil {
 			return err
 		} else {
@@ -678,11 +683,11 @@ type SensorUrls struct {
 	SensorUrl []string `json:"sensor_url"`
 }
 
-func FindEstablishedAgreements(db *bolt.DB, protocol string, filters []EAFilter) ([]EstablishedAgreement, error) {
+func FindEstablishedAgreements(db AgentDatabase, protocol string, filters []EAFilter) ([]EstablishedAgreement, error) {
 	agreements := make([]EstablishedAgreement, 0)
 
 	// fetch contracts
-	readErr := db.View(func(tx *bolt.Tx) error {
+	readErr := db.View(func(tx Tx) error {
 
 		if b := tx.Bucket([]byte(E_AGREEMENTS + "-" + protocol)); b != nil {
 			b.ForEach(func(k, v []byte) error {
@@ -735,7 +740,7 @@ func FindEstablishedAgreements(db *bolt.DB, protocol string, filters []EAFilter)
 	}
 }
 
-func FindEstablishedAgreementsAllProtocols(db *bolt.DB, protocols []string, filters []EAFilter) ([]EstablishedAgreement, error) {
+func FindEstablishedAgreementsAllProtocols(db AgentDatabase, protocols []string, filters []EAFilter) ([]EstablishedAgreement, error) {
 	agreements := make([]EstablishedAgreement, 0)
 	for _, protocol := range protocols {
 		if ags, err := FindEstablishedAgreements(db, protocol, filters); err != nil {
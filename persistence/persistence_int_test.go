@@ -8,12 +8,9 @@ import (
 	"os"
 	"reflect"
 	"testing"
-	"time"
-
-	bolt "go.etcd.io/bbolt"
 )
 
-var testDb *bolt.DB
+var testDb AgentDatabase
 
 func TestMain(m *testing.M) {
 	testDbFile, err := os.CreateTemp("", "anax_persistence_int_test.db")
@@ -23,7 +20,7 @@ func TestMain(m *testing.M) {
 	defer os.Remove(testDbFile.Name())
 
 	var dbErr error
-	testDb, dbErr = bolt.Open(testDbFile.Name(), 0600, &bolt.Options{Timeout: 10 * time.Second})
+	testDb, dbErr = OpenBoltDatabase(testDbFile.Name())
 	if dbErr != nil {
 		panic(err)
 	}
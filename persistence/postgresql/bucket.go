@@ -0,0 +1,201 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/golang/glog"
+	"github.com/open-horizon/anax/persistence"
+)
+
+// Constants for the SQL statements that are used to work with the agent's buckets. Each bucket is a row in the buckets
+// table, and each key/value pair in a bucket is a row in the bucket entries table. Keys are byte arrays, which
+// postgresql orders byte by byte, in the same way as the bolt DB.
+
+// buckets schema:
+// name:     The name of the bucket.
+// sequence: The last value returned by NextSequence for the bucket.
+const BUCKETS_CREATE_TABLE = `CREATE TABLE IF NOT EXISTS agent_buckets (
+	name bytea PRIMARY KEY,
+	sequence bigint NOT NULL DEFAULT 0
+);`
+
+// bucket entries schema:
+// bucket: The name of the bucket containing the entry.
+// key:    The key of the entry.
+// value:  The serialized object.
+const BUCKET_ENTRIES_CREATE_TABLE = `CREATE TABLE IF NOT EXISTS agent_bucket_entries (
+	bucket bytea NOT NULL REFERENCES agent_buckets(name) ON DELETE CASCADE,
+	key bytea NOT NULL,
+	value bytea NOT NULL,
+	PRIMARY KEY (bucket, key)
+);`
+
+const BUCKET_QUERY = `SELECT 1 FROM agent_buckets WHERE name = $1;`
+const BUCKET_INSERT = `INSERT INTO agent_buckets (name) VALUES ($1) ON CONFLICT (name) DO NOTHING;`
+const BUCKET_DELETE = `DELETE FROM agent_buckets WHERE name = $1;`
+const BUCKET_NEXT_SEQUENCE = `UPDATE agent_buckets SET sequence = sequence + 1 WHERE name = $1 RETURNING sequence;`
+const BUCKETS_DELETE_ALL = `DELETE FROM agent_buckets;`
+
+const ENTRY_QUERY = `SELECT value FROM agent_bucket_entries WHERE bucket = $1 AND key = $2;`
+const ENTRY_QUERY_ALL = `SELECT key, value FROM agent_bucket_entries WHERE bucket = $1 ORDER BY key;`
+const ENTRY_UPSERT = `INSERT INTO agent_bucket_entries (bucket, key, value) VALUES ($1, $2, $3) ON CONFLICT (bucket, key) DO UPDATE SET value = EXCLUDED.value;`
+const ENTRY_DELETE = `DELETE FROM agent_bucket_entries WHERE bucket = $1 AND key = $2;`
+
+// Update transactions are serialized with a transaction level advisory lock, so that there is only one writer at a
+// time, as with the bolt DB. The lock id is an arbitrary constant that identifies the agent's database.
+const UPDATE_LOCK = `SELECT pg_advisory_xact_lock(4713);`
+
+// Read-only transactions see a consistent snapshot of the database.
+func (db *AgentPostgresqlDB) View(fn func(tx persistence.Tx) error) error {
+	return db.wrapTransaction(&sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true}, fn)
+}
+
+func (db *AgentPostgresqlDB) Update(fn func(tx persistence.Tx) error) error {
+	return db.wrapTransaction(nil, func(tx persistence.Tx) error {
+		if _, err := tx.(*pgTx).tx.Exec(UPDATE_LOCK); err != nil {
+			return errors.New(fmt.Sprintf("unable to obtain update lock, error: %v", err))
+		}
+		return fn(tx)
+	})
+}
+
+// Run the input function in a transaction, committing the transaction if the function and all of the database
+// operations it performed succeeded, otherwise rolling it back.
+func (db *AgentPostgresqlDB) wrapTransaction(opts *sql.TxOptions, fn func(tx persistence.Tx) error) error {
+
+	sqlTx, err := db.db.BeginTx(context.Background(), opts)
+	if err != nil {
+		return errors.New(fmt.Sprintf("unable to start transaction, error: %v", err))
+	}
+
+	tx := &pgTx{tx: sqlTx}
+	if err := fn(tx); err != nil {
+		rollback(sqlTx)
+		return err
+	} else if tx.err != nil {
+		// A bucket operation that cannot return an error failed.
+		rollback(sqlTx)
+		return tx.err
+	} else if err := sqlTx.Commit(); err != nil {
+		return errors.New(fmt.Sprintf("unable to commit transaction, error: %v", err))
+	}
+	return nil
+}
+
+func rollback(tx *sql.Tx) {
+	if err := tx.Rollback(); err != nil {
+		glog.Errorf("Unable to rollback transaction, error: %v", err)
+	}
+}
+
+// A transaction in the agent's postgresql database. Bucket and Get do not return errors, so the first database error
+// they encounter is saved and returned when the transaction ends.
+type pgTx struct {
+	tx  *sql.Tx
+	err error
+}
+
+func (t *pgTx) setError(err error) {
+	if t.err == nil {
+		t.err = err
+	}
+}
+
+func (t *pgTx) Bucket(name []byte) persistence.Bucket {
+	var exists int
+	if err := t.tx.QueryRow(BUCKET_QUERY, name).Scan(&exists); err == sql.ErrNoRows {
+		return nil
+	} else if err != nil {
+		t.setError(errors.New(fmt.Sprintf("unable to query bucket %v, error: %v", string(name), err)))
+		return nil
+	}
+	return &pgBucket{tx: t, name: name}
+}
+
+func (t *pgTx) CreateBucketIfNotExists(name []byte) (persistence.Bucket, error) {
+	if _, err := t.tx.Exec(BUCKET_INSERT, name); err != nil {
+		return nil, errors.New(fmt.Sprintf("unable to create bucket %v, error: %v", string(name), err))
+	}
+	return &pgBucket{tx: t, name: name}, nil
+}
+
+func (t *pgTx) DeleteBucket(name []byte) error {
+	if res, err := t.tx.Exec(BUCKET_DELETE, name); err != nil {
+		return errors.New(fmt.Sprintf("unable to delete bucket %v, error: %v", string(name), err))
+	} else if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return errors.New(fmt.Sprintf("bucket %v not found", string(name)))
+	}
+	return nil
+}
+
+type pgBucket struct {
+	tx   *pgTx
+	name []byte
+}
+
+func (b *pgBucket) Get(key []byte) []byte {
+	var value []byte
+	if err := b.tx.tx.QueryRow(ENTRY_QUERY, b.name, key).Scan(&value); err == sql.ErrNoRows {
+		return nil
+	} else if err != nil {
+		b.tx.setError(errors.New(fmt.Sprintf("unable to read key %v in bucket %v, error: %v", string(key), string(b.name), err)))
+		return nil
+	}
+	return value
+}
+
+func (b *pgBucket) Put(key []byte, value []byte) error {
+	if _, err := b.tx.tx.Exec(ENTRY_UPSERT, b.name, key, value); err != nil {
+		return errors.New(fmt.Sprintf("unable to write key %v in bucket %v, error: %v", string(key), string(b.name), err))
+	}
+	return nil
+}
+
+func (b *pgBucket) Delete(key []byte) error {
+	if _, err := b.tx.tx.Exec(ENTRY_DELETE, b.name, key); err != nil {
+		return errors.New(fmt.Sprintf("unable to delete key %v in bucket %v, error: %v", string(key), string(b.name), err))
+	}
+	return nil
+}
+
+// The entries are read before fn is called, because the connection cannot run other statements for fn while the
+// query results are open.
+func (b *pgBucket) ForEach(fn func(k, v []byte) error) error {
+	rows, err := b.tx.tx.Query(ENTRY_QUERY_ALL, b.name)
+	if err != nil {
+		return errors.New(fmt.Sprintf("unable to read bucket %v, error: %v", string(b.name), err))
+	}
+
+	keys := make([][]byte, 0)
+	values := make([][]byte, 0)
+	for rows.Next() {
+		var k, v []byte
+		if err := rows.Scan(&k, &v); err != nil {
+			rows.Close()
+			return errors.New(fmt.Sprintf("unable to scan bucket %v entry, error: %v", string(b.name), err))
+		}
+		keys = append(keys, k)
+		values = append(values, v)
+	}
+	if err := rows.Err(); err != nil {
+		return errors.New(fmt.Sprintf("unable to read bucket %v, error: %v", string(b.name), err))
+	}
+	rows.Close()
+
+	for i := range keys {
+		if err := fn(keys[i], values[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (b *pgBucket) NextSequence() (uint64, error) {
+	var seq uint64
+	if err := b.tx.tx.QueryRow(BUCKET_NEXT_SEQUENCE, b.name).Scan(&seq); err != nil {
+		return 0, errors.New(fmt.Sprintf("unable to get next sequence for bucket %v, error: %v", string(b.name), err))
+	}
+	return seq, nil
+}
@@ -0,0 +1,77 @@
+package postgresql
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/golang/glog"
+	_ "github.com/lib/pq"
+	"github.com/open-horizon/anax/config"
+	"github.com/open-horizon/anax/persistence"
+)
+
+func init() {
+	persistence.Register("postgresql", new(AgentPostgresqlDB))
+}
+
+// The postgresql implementation of the agent database. The agent's buckets are kept in tables of the configured
+// database, so the agent's state survives the loss of the node (or pod) that the agent is running in. Each agent
+// must be configured with its own database (DBName), the tables are not shared between agents.
+type AgentPostgresqlDB struct {
+	db *sql.DB // A handle to the underlying database.
+}
+
+func (db *AgentPostgresqlDB) String() string {
+	return fmt.Sprintf("DB Handle: %v", db.db)
+}
+
+// This function is called by the anax main to allow the configured database a chance to initialize itself.
+// This function is called every time the agent starts, so the tables are only created if they dont already exist.
+func (db *AgentPostgresqlDB) Initialize(cfg *config.HorizonConfig) error {
+
+	connectInfo, trace := cfg.Edge.Postgresql.MakeConnectionString()
+
+	glog.V(1).Infof("Connecting to Postgresql database: %v", trace)
+
+	if pgdb, err := sql.Open("postgres", connectInfo); err != nil {
+		return errors.New(fmt.Sprintf("unable to open Postgresql database, error: %v", err))
+	} else if err := pgdb.Ping(); err != nil {
+		return errors.New(fmt.Sprintf("unable to ping Postgresql database, error: %v", err))
+	} else {
+		db.db = pgdb
+
+		// Set the max open connections
+		if cfg.Edge.Postgresql.MaxOpenConnections != 0 {
+			db.db.SetMaxOpenConns(cfg.Edge.Postgresql.MaxOpenConnections)
+		}
+
+		// Now create the tables as necessary.
+		glog.V(3).Infof("Postgresql database tables initializing.")
+
+		if _, err := db.db.Exec(BUCKETS_CREATE_TABLE); err != nil {
+			return errors.New(fmt.Sprintf("unable to create buckets table, error: %v", err))
+		} else if _, err := db.db.Exec(BUCKET_ENTRIES_CREATE_TABLE); err != nil {
+			return errors.New(fmt.Sprintf("unable to create bucket entries table, error: %v", err))
+		}
+
+		glog.V(3).Infof("Postgresql database tables initialized.")
+	}
+
+	return nil
+}
+
+func (db *AgentPostgresqlDB) Close() {
+	glog.V(2).Infof("Closing Postgresql database")
+	db.db.Close()
+	glog.V(2).Infof("Closed Postgresql database")
+}
+
+// The tables are left in place, only the agent's data is removed.
+func (db *AgentPostgresqlDB) Remove() error {
+	defer db.Close()
+	glog.Infof("Removing agent data from Postgresql database.")
+	if _, err := db.db.Exec(BUCKETS_DELETE_ALL); err != nil {
+		return errors.New(fmt.Sprintf("unable to remove agent data, error: %v", err))
+	}
+	return nil
+}
@@ -0,0 +1,39 @@
+package persistence
+
+import (
+	"errors"
+	"fmt"
+	"github.com/open-horizon/anax/config"
+)
+
+// The registry enables optional agent database implementations to be plugged into the runtime. The implementation
+// registers itself with this registry when the implementation's package init() method is driven, so that the
+// persistence package does not need to import each of the optional DB specific packages. As with the agbot
+// registry, the name of each DB implementation is hard coded here and in the implementation's call to Register().
+type DatabaseProviderRegistry map[string]AgentDatabase
+
+var DatabaseProviders = DatabaseProviderRegistry{}
+
+func Register(name string, db AgentDatabase) {
+	DatabaseProviders[name] = db
+}
+
+// Initialize the underlying agent database depending on what is configured. If the postgresql DB is configured for
+// the agent, it is used, otherwise the bolt DB in the configured DBPath is used. If the node is not configured as an
+// agent, nil is returned.
+func InitDatabase(cfg *config.HorizonConfig) (AgentDatabase, error) {
+
+	if cfg.IsEdgePostgresqlConfigured() {
+		if dbObj, ok := DatabaseProviders["postgresql"]; !ok {
+			return nil, errors.New(fmt.Sprintf("the postgresql DB is configured for the agent but the postgresql DB provider is not available."))
+		} else {
+			return dbObj, dbObj.Initialize(cfg)
+		}
+
+	} else if cfg.IsEdgeBoltDBConfigured() {
+		dbObj := DatabaseProviders["bolt"]
+		return dbObj, dbObj.Initialize(cfg)
+
+	}
+	return nil, nil
+}
@@ -5,7 +5,6 @@ import (
 	"errors"
 	"fmt"
 	"github.com/golang/glog"
-	bolt "go.etcd.io/bbolt"
 )
 
 const SECRET_STATUS = "secret_status"
@@ -35,7 +34,7 @@ func (s SecretStatus) String() string {
 		s.SecretName, s.UpdateTime)
 }
 
-func NewMSSInst(db *bolt.DB, msInstKey string, essToken string) (*MicroserviceSecretStatusInst, error) {
+func NewMSSInst(db AgentDatabase, msInstKey string, essToken string) (*MicroserviceSecretStatusInst, error) {
 	if msInstKey == "" || essToken == "" {
 		return nil, errors.New("microserviceInstanceKey or essToken is empty, cannot persist")
 	}
@@ -60,8 +59,8 @@ func (w MicroserviceSecretStatusInst) GetKey() string {
 }
 
 // save the given microserviceSecretStatus instance into the db. Key: MsInstKey, Value: MicroserviceSecretStatus Object
-func saveMSSInst(db *bolt.DB, new_secret_status_inst *MicroserviceSecretStatusInst) (*MicroserviceSecretStatusInst, error) {
-	return new_secret_status_inst, db.Update(func(tx *bolt.Tx) error {
+func saveMSSInst(db AgentDatabase, new_secret_status_inst *MicroserviceSecretStatusInst) (*MicroserviceSecretStatusInst, error) {
+	return new_secret_status_inst, db.Update(func(tx Tx) error {
 		if b, err := tx.CreateBucketIfNotExists([]byte(SECRET_STATUS)); err != nil {
 			return err
 		} else if bytes, err := json.Marshal(new_secret_status_inst); err != nil {
@@ -74,12 +73,12 @@ func saveMSSInst(db *bolt.DB, new_secret_status_inst *MicroserviceSecretStatusIn
 	})
 }
 
-func FindMSSInstWithKey(db *bolt.DB, ms_inst_key string) (*MicroserviceSecretStatusInst, error) {
+func FindMSSInstWithKey(db AgentDatabase, ms_inst_key string) (*MicroserviceSecretStatusInst, error) {
 	var pmsSecretStatusInst *MicroserviceSecretStatusInst
 	pmsSecretStatusInst = nil
 
 	// fetch microserviceSecretStatus instances
-	readErr := db.View(func(tx *bolt.Tx) error {
+	readErr := db.View(func(tx Tx) error {
 		if b := tx.Bucket([]byte(SECRET_STATUS)); b != nil {
 			v := b.Get([]byte(ms_inst_key))
 
@@ -104,26 +103,27 @@ func FindMSSInstWithKey(db *bolt.DB, ms_inst_key string) (*MicroserviceSecretSta
 	}
 }
 
-func FindMSSInstWithESSToken(db *bolt.DB, ess_token string) (*MicroserviceSecretStatusInst, error) {
+func FindMSSInstWithESSToken(db AgentDatabase, ess_token string) (*MicroserviceSecretStatusInst, error) {
 	var pms *MicroserviceSecretStatusInst
 	pms = nil
 
 	// fetch microserviceSecretStatus instances
-	readErr := db.View(func(tx *bolt.Tx) error {
+	readErr := db.View(func(tx Tx) error {
 
 		if b := tx.Bucket([]byte(SECRET_STATUS)); b != nil {
-			cursor := b.Cursor()
-			for key, value := cursor.First(); key != nil; key, value = cursor.Next() {
+			return b.ForEach(func(key, value []byte) error {
 				var msSecretStatusInstance MicroserviceSecretStatusInst
-				if err := json.Unmarshal(value, &msSecretStatusInstance); err != nil {
+				if pms != nil {
+					return nil
+				} else if err := json.Unmarshal(value, &msSecretStatusInstance); err != nil {
 					return err
 				}
 
 				if msSecretStatusInstance.ESSToken == ess_token {
 					pms = &msSecretStatusInstance
-					return nil
 				}
-			}
+				return nil
+			})
 		}
 
 		return nil // end the transaction
@@ -137,7 +137,7 @@ func FindMSSInstWithESSToken(db *bolt.DB, ess_token string) (*MicroserviceSecret
 }
 
 // delete a microserviceSecretStatus instance from db. It will NOT return error if it does not exist in the db
-func DeleteMSSInstWithKey(db *bolt.DB, ms_inst_key string) (*MicroserviceSecretStatusInst, error) {
+func DeleteMSSInstWithKey(db AgentDatabase, ms_inst_key string) (*MicroserviceSecretStatusInst, error) {
 	if ms_inst_key == "" {
 		return nil, errors.New("microserviceInstantKey (key) is empty, cannot remove")
 	} else {
@@ -146,7 +146,7 @@ func DeleteMSSInstWithKey(db *bolt.DB, ms_inst_key string) (*MicroserviceSecretS
 		} else if ms == nil {
 			return nil, nil
 		} else {
-			return ms, db.Update(func(tx *bolt.Tx) error {
+			return ms, db.Update(func(tx Tx) error {
 				if b, err := tx.CreateBucketIfNotExists([]byte(SECRET_STATUS)); err != nil {
 					return err
 				} else if err := b.Delete([]byte(ms_inst_key)); err != nil {
@@ -159,7 +159,7 @@ func DeleteMSSInstWithKey(db *bolt.DB, ms_inst_key string) (*MicroserviceSecretS
 	}
 }
 
-func DeleteMSSInstWithESSToken(db *bolt.DB, ess_token string) (*MicroserviceSecretStatusInst, error) {
+func DeleteMSSInstWithESSToken(db AgentDatabase, ess_token string) (*MicroserviceSecretStatusInst, error) {
 	if ess_token == "" {
 		return nil, errors.New("ess_token(key) is empty, cannot remove")
 	} else {
@@ -168,7 +168,7 @@ func DeleteMSSInstWithESSToken(db *bolt.DB, ess_token string) (*MicroserviceSecr
 		} else if ms == nil {
 			return nil, nil
 		} else {
-			return ms, db.Update(func(tx *bolt.Tx) error {
+			return ms, db.Update(func(tx Tx) error {
 				if b, err := tx.CreateBucketIfNotExists([]byte(SECRET_STATUS)); err != nil {
 					return err
 				} else if err := b.Delete([]byte(ms.MsInstKey)); err != nil {
@@ -181,14 +181,14 @@ func DeleteMSSInstWithESSToken(db *bolt.DB, ess_token string) (*MicroserviceSecr
 	}
 }
 
-func SaveSecretStatus(db *bolt.DB, ms_inst_key string, secret_status *SecretStatus) (*MicroserviceSecretStatusInst, error) {
+func SaveSecretStatus(db AgentDatabase, ms_inst_key string, secret_status *SecretStatus) (*MicroserviceSecretStatusInst, error) {
 	return mssInstStateUpdate(db, ms_inst_key, func(c MicroserviceSecretStatusInst) *MicroserviceSecretStatusInst {
 		c.SecretsStatus[secret_status.SecretName] = secret_status
 		return &c
 	})
 }
 
-func FindSecretStatus(db *bolt.DB, ms_inst_key string, secret_name string) (*SecretStatus, error) {
+func FindSecretStatus(db AgentDatabase, ms_inst_key string, secret_name string) (*SecretStatus, error) {
 	secStatus := &SecretStatus{}
 	mssinst, err := FindMSSInstWithKey(db, ms_inst_key)
 	if err != nil {
@@ -198,7 +198,7 @@ func FindSecretStatus(db *bolt.DB, ms_inst_key string, secret_name string) (*Sec
 	return mssinst.SecretsStatus[secret_name], nil
 }
 
-func FindUpdatedSecretsForMSSInstance(db *bolt.DB, ms_inst_key string) ([]string, error) {
+func FindUpdatedSecretsForMSSInstance(db AgentDatabase, ms_inst_key string) ([]string, error) {
 	updatedSecretNames := make([]string, 0)
 	if mssInst, err := FindMSSInstWithKey(db, ms_inst_key); err != nil {
 		return updatedSecretNames, err
@@ -238,7 +238,7 @@ func FindUpdatedSecretsForMSSInstance(db *bolt.DB, ms_inst_key string) ([]string
 }
 
 // update the microserviceSecretStatus instance
-func mssInstStateUpdate(db *bolt.DB, ms_inst_key string, fn func(MicroserviceSecretStatusInst) *MicroserviceSecretStatusInst) (*MicroserviceSecretStatusInst, error) {
+func mssInstStateUpdate(db AgentDatabase, ms_inst_key string, fn func(MicroserviceSecretStatusInst) *MicroserviceSecretStatusInst) (*MicroserviceSecretStatusInst, error) {
 
 	if mss, err := FindMSSInstWithKey(db, ms_inst_key); err != nil {
 		return nil, err
@@ -253,8 +253,8 @@ func mssInstStateUpdate(db *bolt.DB, ms_inst_key string, fn func(MicroserviceSec
 	}
 }
 
-func persistUpdatedMSSInst(db *bolt.DB, ms_inst_key string, update *MicroserviceSecretStatusInst) error {
-	return db.Update(func(tx *bolt.Tx) error {
+func persistUpdatedMSSInst(db AgentDatabase, ms_inst_key string, update *MicroserviceSecretStatusInst) error {
+	return db.Update(func(tx Tx) error {
 		if b, err := tx.CreateBucketIfNotExists([]byte(SECRET_STATUS)); err != nil {
 			return err
 		} else {
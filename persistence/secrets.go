@@ -7,7 +7,6 @@ import (
 	"github.com/open-horizon/anax/cutil"
 	"github.com/open-horizon/anax/exchangecommon"
 	"github.com/open-horizon/anax/semanticversion"
-	bolt "go.etcd.io/bbolt"
 	"time"
 )
 
@@ -18,16 +17,18 @@ const SECRETS = "secrets"
 const AGREEMENT_SECRETS = "agreement_secrets"
 
 type PersistedServiceSecret struct {
-	SvcOrgid        string
-	SvcUrl          string
-	SvcArch         string
-	SvcVersionRange string
-	SvcSecretName   string
-	SvcSecretValue  string
-	AgreementIds    []string
-	ContainerIds    []string
-	TimeCreated     uint64
-	TimeLastUpdated uint64
+	SvcOrgid               string
+	SvcUrl                 string
+	SvcArch                string
+	SvcVersionRange        string
+	SvcSecretName          string
+	SvcSecretValue         string
+	SvcSecretVersion       uint64 // Incremented each time the value of the secret changes
+	SvcSecretPreviousValue string // The value before the last change, kept so that a failed secret rollout can be reverted
+	AgreementIds           []string
+	ContainerIds           []string
+	TimeCreated            uint64
+	TimeLastUpdated        uint64
 }
 
 type PersistedServiceSecrets struct {
@@ -54,12 +55,12 @@ func PersistedSecretFromPolicySecret(inputSecretBindings []exchangecommon.Secret
 // Save the secret bindings from an agreement
 // This bucket is used to keep the secret information until such time that the microservice instance id is created
 // After that id exists, the secrets will be saved in the SECRETS bucket keyed by ms instance id
-func SaveAgreementSecrets(db *bolt.DB, agId string, secretsList *[]PersistedServiceSecret) error {
+func SaveAgreementSecrets(db AgentDatabase, agId string, secretsList *[]PersistedServiceSecret) error {
 	if secretsList == nil {
 		return nil
 	}
 
-	writeErr := db.Update(func(tx *bolt.Tx) error {
+	writeErr := db.Update(func(tx Tx) error {
 		bucket, err := tx.CreateBucketIfNotExists([]byte(AGREEMENT_SECRETS))
 		if err != nil {
 			return err
@@ -75,13 +76,13 @@ func SaveAgreementSecrets(db *bolt.DB, agId string, secretsList *[]PersistedServ
 	return writeErr
 }
 
-func FindAgreementSecrets(db *bolt.DB, agId string) (*[]PersistedServiceSecret, error) {
+func FindAgreementSecrets(db AgentDatabase, agId string) (*[]PersistedServiceSecret, error) {
 	if db == nil {
 		return nil, nil
 	}
 
 	var psecretRec *[]PersistedServiceSecret
-	readErr := db.View(func(tx *bolt.Tx) error {
+	readErr := db.View(func(tx Tx) error {
 		if b := tx.Bucket([]byte(AGREEMENT_SECRETS)); b != nil {
 			s := b.Get([]byte(agId))
 			if s != nil {
@@ -99,7 +100,7 @@ func FindAgreementSecrets(db *bolt.DB, agId string) (*[]PersistedServiceSecret,
 	return psecretRec, readErr
 }
 
-func DeleteAgreementSecrets(db *bolt.DB, agId string) error {
+func DeleteAgreementSecrets(db AgentDatabase, agId string) error {
 	if db == nil {
 		return nil
 	}
@@ -109,7 +110,7 @@ func DeleteAgreementSecrets(db *bolt.DB, agId string) error {
 	} else if agSecrets == nil {
 		return nil
 	} else {
-		return db.Update(func(tx *bolt.Tx) error {
+		return db.Update(func(tx Tx) error {
 
 			if b, err := tx.CreateBucketIfNotExists([]byte(AGREEMENT_SECRETS)); err != nil {
 				return err
@@ -123,7 +124,7 @@ func DeleteAgreementSecrets(db *bolt.DB, agId string) error {
 }
 
 // Saves the given secret to the agent db
-func SaveSecret(db *bolt.DB, secretName string, msInstKey string, msInstVers string, secretToSave *PersistedServiceSecret) error {
+func SaveSecret(db AgentDatabase, secretName string, msInstKey string, msInstVers string, secretToSave *PersistedServiceSecret) error {
 	if secretToSave == nil {
 		return nil
 	}
@@ -145,22 +146,54 @@ func SaveSecret(db *bolt.DB, secretName string, msInstKey string, msInstVers str
 		mergedSec.ContainerIds = cutil.MergeSlices(mergedSec.ContainerIds, secretToSave.ContainerIds)
 		if mergedSec.SvcSecretValue != secretToSave.SvcSecretValue {
 			mergedSec.TimeLastUpdated = timestamp
+			mergedSec.SvcSecretPreviousValue = mergedSec.SvcSecretValue
 			mergedSec.SvcSecretValue = secretToSave.SvcSecretValue
+			mergedSec.SvcSecretVersion++
 		}
 		secretToSaveAll.SecretsMap[secretName] = mergedSec
 	} else {
 		secretToSave.TimeLastUpdated = uint64(time.Now().Unix())
+		if secretToSave.SvcSecretVersion == 0 {
+			secretToSave.SvcSecretVersion = 1
+		}
 		secretToSaveAll.SecretsMap[secretName] = secretToSave
 	}
 
 	return SaveAllSecretsForService(db, msInstKey, secretToSaveAll)
 }
 
-func SaveAllSecretsForService(db *bolt.DB, msInstId string, secretToSaveAll *PersistedServiceSecrets) error {
+// Restores the previous value of the given secret. The restored value is a new version of the secret, and the value
+// it replaces is not kept, so a secret can only be reverted once per change. Returns nil if there is no previous
+// value to restore.
+func RevertSecret(db AgentDatabase, secretName string, msInstKey string) (*PersistedServiceSecret, error) {
+	allSecs, err := FindAllSecretsForMS(db, msInstKey)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to get all secrets for microservice %v. Error was: %v", msInstKey, err)
+	} else if allSecs == nil {
+		return nil, nil
+	}
+
+	sec, ok := allSecs.SecretsMap[secretName]
+	if !ok || sec.SvcSecretPreviousValue == "" {
+		return nil, nil
+	}
+
+	sec.SvcSecretValue = sec.SvcSecretPreviousValue
+	sec.SvcSecretPreviousValue = ""
+	sec.SvcSecretVersion++
+	sec.TimeLastUpdated = uint64(time.Now().Unix())
+
+	if err := SaveAllSecretsForService(db, msInstKey, allSecs); err != nil {
+		return nil, err
+	}
+	return sec, nil
+}
+
+func SaveAllSecretsForService(db AgentDatabase, msInstId string, secretToSaveAll *PersistedServiceSecrets) error {
 	if db == nil {
 		return nil
 	}
-	writeErr := db.Update(func(tx *bolt.Tx) error {
+	writeErr := db.Update(func(tx Tx) error {
 		bucket, err := tx.CreateBucketIfNotExists([]byte(SECRETS))
 		if err != nil {
 			return err
@@ -176,7 +209,7 @@ func SaveAllSecretsForService(db *bolt.DB, msInstId string, secretToSaveAll *Per
 	return writeErr
 }
 
-func AddAgreementForMSInstSecrets(db *bolt.DB, msInstId string, agId string) error {
+func AddAgreementForMSInstSecrets(db AgentDatabase, msInstId string, agId string) error {
 	if db == nil {
 		return nil
 	}
@@ -196,12 +229,12 @@ func AddAgreementForMSInstSecrets(db *bolt.DB, msInstId string, agId string) err
 }
 
 // Gets the secret from the database, no error returned if none is found in the db
-func FindAllSecretsForMS(db *bolt.DB, msInstId string) (*PersistedServiceSecrets, error) {
+func FindAllSecretsForMS(db AgentDatabase, msInstId string) (*PersistedServiceSecrets, error) {
 	if db == nil {
 		return nil, nil
 	}
 	var psecretRec *PersistedServiceSecrets
-	readErr := db.View(func(tx *bolt.Tx) error {
+	readErr := db.View(func(tx Tx) error {
 
 		if b := tx.Bucket([]byte(SECRETS)); b != nil {
 			s := b.Get([]byte(msInstId))
@@ -226,7 +259,7 @@ func FindAllSecretsForMS(db *bolt.DB, msInstId string) (*PersistedServiceSecrets
 }
 
 // Find a particular secret, if a version range is provided it must match the exact range on the secret, if a specific version is given return the first matching secret range
-func FindSingleSecretForService(db *bolt.DB, secName string, msInstId string) (*PersistedServiceSecret, error) {
+func FindSingleSecretForService(db AgentDatabase, secName string, msInstId string) (*PersistedServiceSecret, error) {
 	allSec, err := FindAllSecretsForMS(db, msInstId)
 	if err != nil {
 		return nil, err
@@ -240,7 +273,7 @@ func FindSingleSecretForService(db *bolt.DB, secName string, msInstId string) (*
 	return nil, nil
 }
 
-func AddContainerIdToSecret(db *bolt.DB, secName string, msInstId string, msDefVers string, containerId string) error {
+func AddContainerIdToSecret(db AgentDatabase, secName string, msInstId string, msDefVers string, containerId string) error {
 	sec, err := FindSingleSecretForService(db, secName, msInstId)
 	if err != nil {
 		return err
@@ -280,13 +313,13 @@ func VersRangeSecFilter(versRange string) SecFilter {
 	}
 }
 
-func FindAllServiceSecretsWithFilters(db *bolt.DB, filters []SecFilter) ([]PersistedServiceSecrets, error) {
+func FindAllServiceSecretsWithFilters(db AgentDatabase, filters []SecFilter) ([]PersistedServiceSecrets, error) {
 	matchingSecrets := []PersistedServiceSecrets{}
 	if db == nil {
 		return matchingSecrets, nil
 	}
 
-	readErr := db.View(func(tx *bolt.Tx) error {
+	readErr := db.View(func(tx Tx) error {
 		if b := tx.Bucket([]byte(SECRETS)); b != nil {
 			b.ForEach(func(k, v []byte) error {
 				var s PersistedServiceSecrets
@@ -320,14 +353,14 @@ func FindAllServiceSecretsWithFilters(db *bolt.DB, filters []SecFilter) ([]Persi
 	}
 }
 
-func FindAllServiceSecretsWithSpecs(db *bolt.DB, svcUrl string, svcOrg string) ([]PersistedServiceSecrets, error) {
+func FindAllServiceSecretsWithSpecs(db AgentDatabase, svcUrl string, svcOrg string) ([]PersistedServiceSecrets, error) {
 	filters := []SecFilter{UrlSecFilter(svcUrl), OrgSecFilter(svcOrg)}
 
 	return FindAllServiceSecretsWithFilters(db, filters)
 }
 
 // Returns the secret from the db if it was there. No error returned if it is not in the db
-func DeleteSecrets(db *bolt.DB, secName string, msInstId string) (*PersistedServiceSecret, error) {
+func DeleteSecrets(db AgentDatabase, secName string, msInstId string) (*PersistedServiceSecret, error) {
 	if db == nil {
 		return nil, nil
 	}
@@ -340,7 +373,7 @@ func DeleteSecrets(db *bolt.DB, secName string, msInstId string) (*PersistedServ
 		}
 		if len(allSec.SecretsMap) == 0 {
 			retSec := allSec.SecretsMap[secName]
-			return retSec, db.Update(func(tx *bolt.Tx) error {
+			return retSec, db.Update(func(tx Tx) error {
 				if b, err := tx.CreateBucketIfNotExists([]byte(SECRETS)); err != nil {
 					return err
 				} else if err := b.Delete([]byte(msInstId)); err != nil {
@@ -356,7 +389,7 @@ func DeleteSecrets(db *bolt.DB, secName string, msInstId string) (*PersistedServ
 	return nil, nil
 }
 
-func DeleteSecretsSpec(db *bolt.DB, secName string, svcName string, svcOrg string, svcVersionRange string) error {
+func DeleteSecretsSpec(db AgentDatabase, secName string, svcName string, svcOrg string, svcVersionRange string) error {
 	if allServiceSecrets, err := FindAllServiceSecretsWithSpecs(db, svcName, svcOrg); err != nil {
 		return err
 	} else {
@@ -0,0 +1,68 @@
+//go:build unit
+// +build unit
+
+package persistence
+
+import (
+	"testing"
+)
+
+// Verify that a secret keeps its previous value when it changes, and that it can be reverted once.
+func Test_SecretVersions(t *testing.T) {
+
+	dir, db, err := utsetup()
+	if err != nil {
+		t.Error(err)
+	}
+	defer cleanTestDir(dir)
+
+	msKey := "mskey1"
+	sec := &PersistedServiceSecret{SvcOrgid: "myorg", SvcUrl: "svc1", SvcSecretName: "pw", SvcSecretValue: "v1", AgreementIds: []string{"ag1"}}
+	if err := SaveSecret(db, "pw", msKey, "1.0.0", sec); err != nil {
+		t.Errorf("failed to save secret, error %v", err)
+	}
+
+	// Nothing to revert to before the secret changes.
+	if reverted, err := RevertSecret(db, "pw", msKey); err != nil {
+		t.Errorf("failed to revert secret, error %v", err)
+	} else if reverted != nil {
+		t.Errorf("secret should not have been reverted: %v", reverted)
+	}
+
+	// The same value is not a new version.
+	same := &PersistedServiceSecret{SvcOrgid: "myorg", SvcUrl: "svc1", SvcSecretName: "pw", SvcSecretValue: "v1", AgreementIds: []string{"ag1"}}
+	if err := SaveSecret(db, "pw", msKey, "1.0.0", same); err != nil {
+		t.Errorf("failed to save secret, error %v", err)
+	}
+
+	updated := &PersistedServiceSecret{SvcOrgid: "myorg", SvcUrl: "svc1", SvcSecretName: "pw", SvcSecretValue: "v2", AgreementIds: []string{"ag1"}}
+	if err := SaveSecret(db, "pw", msKey, "1.0.0", updated); err != nil {
+		t.Errorf("failed to save secret, error %v", err)
+	}
+
+	if s, err := FindSingleSecretForService(db, "pw", msKey); err != nil {
+		t.Errorf("failed to find secret, error %v", err)
+	} else if s.SvcSecretValue != "v2" || s.SvcSecretPreviousValue != "v1" || s.SvcSecretVersion != 2 {
+		t.Errorf("incorrect secret after update: %v", s)
+	}
+
+	// Revert to the previous value, as a new version.
+	if reverted, err := RevertSecret(db, "pw", msKey); err != nil {
+		t.Errorf("failed to revert secret, error %v", err)
+	} else if reverted == nil || reverted.SvcSecretValue != "v1" || reverted.SvcSecretVersion != 3 {
+		t.Errorf("incorrect reverted secret: %v", reverted)
+	}
+
+	if s, err := FindSingleSecretForService(db, "pw", msKey); err != nil {
+		t.Errorf("failed to find secret, error %v", err)
+	} else if s.SvcSecretValue != "v1" || s.SvcSecretPreviousValue != "" {
+		t.Errorf("incorrect secret after revert: %v", s)
+	}
+
+	// The value that was reverted is not kept.
+	if reverted, err := RevertSecret(db, "pw", msKey); err != nil {
+		t.Errorf("failed to revert secret, error %v", err)
+	} else if reverted != nil {
+		t.Errorf("secret should not have been reverted twice: %v", reverted)
+	}
+}
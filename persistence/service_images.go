@@ -3,7 +3,6 @@ package persistence
 import (
 	"encoding/json"
 	"fmt"
-	bolt "go.etcd.io/bbolt"
 	"regexp"
 	"time"
 )
@@ -32,8 +31,8 @@ func (s ServiceImageUsage) ShortString() string {
 
 // save or update the given image info
 // image use info is keyed with ImageName:ImageTag
-func SaveOrUpdateServiceImage(db *bolt.DB, serviceImage *ServiceImageUsage) error {
-	writeErr := db.Update(func(tx *bolt.Tx) error {
+func SaveOrUpdateServiceImage(db AgentDatabase, serviceImage *ServiceImageUsage) error {
+	writeErr := db.Update(func(tx Tx) error {
 		if bucket, err := tx.CreateBucketIfNotExists([]byte(SERVICE_IMAGES)); err != nil {
 			return err
 		} else if serial, err := json.Marshal(serviceImage); err != nil {
@@ -46,8 +45,8 @@ func SaveOrUpdateServiceImage(db *bolt.DB, serviceImage *ServiceImageUsage) erro
 	return writeErr
 }
 
-func DeleteServiceImage(db *bolt.DB, imageId string) error {
-	return db.Update(func(tx *bolt.Tx) error {
+func DeleteServiceImage(db AgentDatabase, imageId string) error {
+	return db.Update(func(tx Tx) error {
 		if bucket, err := tx.CreateBucketIfNotExists([]byte(SERVICE_IMAGES)); err != nil {
 			return err
 		} else if err := bucket.Delete([]byte(imageId)); err != nil {
@@ -57,10 +56,10 @@ func DeleteServiceImage(db *bolt.DB, imageId string) error {
 	})
 }
 
-func FindServiceImageUsageWithFilters(db *bolt.DB, filters []IUFilter) ([]ServiceImageUsage, error) {
+func FindServiceImageUsageWithFilters(db AgentDatabase, filters []IUFilter) ([]ServiceImageUsage, error) {
 	imgUsages := make([]ServiceImageUsage, 0)
 
-	readErr := db.View(func(tx *bolt.Tx) error {
+	readErr := db.View(func(tx Tx) error {
 		if bucket := tx.Bucket([]byte(SERVICE_IMAGES)); bucket != nil {
 			bucket.ForEach(func(k, v []byte) error {
 				imgRec := ServiceImageUsage{}
@@ -5,7 +5,6 @@ import (
 	"fmt"
 	"github.com/golang/glog"
 	"github.com/open-horizon/anax/i18n"
-	bolt "go.etcd.io/bbolt"
 	"time"
 )
 
@@ -22,10 +21,10 @@ type SurfaceError struct {
 }
 
 // FindSurfaceErrors returns the surface errors currently in the local db
-func FindSurfaceErrors(db *bolt.DB) ([]SurfaceError, error) {
+func FindSurfaceErrors(db AgentDatabase) ([]SurfaceError, error) {
 	var surfaceErrors []SurfaceError
 
-	readErr := db.View(func(tx *bolt.Tx) error {
+	readErr := db.View(func(tx Tx) error {
 		if b := tx.Bucket([]byte(NODE_SURFACEERR)); b != nil {
 			return b.ForEach(func(k, v []byte) error {
 
@@ -47,8 +46,8 @@ func FindSurfaceErrors(db *bolt.DB) ([]SurfaceError, error) {
 }
 
 // SaveSurfaceErrors saves the provided list of surface errors to the local db
-func SaveSurfaceErrors(db *bolt.DB, surfaceErrors []SurfaceError) error {
-	writeErr := db.Update(func(tx *bolt.Tx) error {
+func SaveSurfaceErrors(db AgentDatabase, surfaceErrors []SurfaceError) error {
+	writeErr := db.Update(func(tx Tx) error {
 		b, err := tx.CreateBucketIfNotExists([]byte(NODE_SURFACEERR))
 		if err != nil {
 			return err
@@ -65,13 +64,13 @@ func SaveSurfaceErrors(db *bolt.DB, surfaceErrors []SurfaceError) error {
 }
 
 // DeleteSurfaceErrors delete node surface errors from the local database
-func DeleteSurfaceErrors(db *bolt.DB) error {
+func DeleteSurfaceErrors(db AgentDatabase) error {
 	if seList, err := FindSurfaceErrors(db); err != nil {
 		return err
 	} else if len(seList) == 0 {
 		return nil
 	} else {
-		return db.Update(func(tx *bolt.Tx) error {
+		return db.Update(func(tx Tx) error {
 
 			if b, err := tx.CreateBucketIfNotExists([]byte(NODE_SURFACEERR)); err != nil {
 				return err
@@ -85,7 +84,7 @@ func DeleteSurfaceErrors(db *bolt.DB) error {
 }
 
 // NewErrorLog takes an eventLog object and puts it in the local db and exchange if it should be surfaced
-func NewErrorLog(db *bolt.DB, eventLog EventLog) bool {
+func NewErrorLog(db AgentDatabase, eventLog EventLog) bool {
 	if !IsSurfaceType(eventLog.EventCode) || !(eventLog.SourceType == SRC_TYPE_AG || eventLog.SourceType == SRC_TYPE_SVC) {
 		return false
 	}
@@ -119,14 +118,17 @@ func NewErrorLog(db *bolt.DB, eventLog EventLog) bool {
 func getErrorTypeList() []string {
 	return []string{
 		EC_ERROR_IMAGE_LOADE,
+		EC_ERROR_IMAGE_UNTRUSTED,
 		EC_ERROR_IN_DEPLOYMENT_CONFIG,
 		EC_ERROR_START_CONTAINER,
+		EC_CONTAINER_UNHEALTHY,
 		EC_CANCEL_AGREEMENT_EXECUTION_TIMEOUT,
 		EC_CANCEL_AGREEMENT_SERVICE_SUSPENDED,
 		EC_ERROR_SERVICE_CONFIG,
 		EC_ERROR_START_SERVICE,
 		EC_ERROR_START_DEPENDENT_SERVICE,
 		EC_DEPENDENT_SERVICE_FAILED,
+		EC_DEPENDENT_SERVICE_NOT_READY,
 	}
 
 }
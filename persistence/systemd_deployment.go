@@ -0,0 +1,113 @@
+package persistence
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// The structure of the json string in the deployment field of a service definition when the
+// service is a native Linux service run by systemd on the host. The package (a binary or a tarball)
+// and the unit file are MMS objects, both objects must be signed.
+
+type SystemdDeploymentConfig struct {
+	UnitName      string `json:"systemd_unit"`   // the name of the unit, without the .service suffix
+	ObjectOrg     string `json:"object_org"`     // the org of the MMS objects, defaults to the org of the service
+	ObjectType    string `json:"object_type"`    // the MMS object type of the package and unit file objects
+	PackageObject string `json:"package_object"` // the MMS object id of the binary or tarball, a tarball must end with .tar.gz or .tgz
+	UnitObject    string `json:"unit_object"`    // the MMS object id of the unit file
+}
+
+func NewSystemdDeployment(unitName string, objectType string, packageObject string, unitObject string) *SystemdDeploymentConfig {
+	sd := new(SystemdDeploymentConfig)
+	sd.UnitName = unitName
+	sd.ObjectType = objectType
+	sd.PackageObject = packageObject
+	sd.UnitObject = unitObject
+	return sd
+}
+
+func (s SystemdDeploymentConfig) String() string {
+	return fmt.Sprintf("Unit %v, Package %v/%v/%v, Unit File %v/%v/%v", s.UnitName, s.ObjectOrg, s.ObjectType, s.PackageObject, s.ObjectOrg, s.ObjectType, s.UnitObject)
+}
+
+// A plain unit name, without a template instance or escapes. The unit name is used in the paths of the unit file and
+// its drop-in directory, so it cannot contain a path separator or a parent directory reference.
+var unitNameRE = regexp.MustCompile(`^[A-Za-z0-9:_.-]+$`)
+
+// The longest unit name, systemd limits the name of a unit file, including its .service suffix, to 255 characters.
+const maxUnitNameLength = 255 - len(".service")
+
+func IsValidUnitName(unitName string) bool {
+	return len(unitName) <= maxUnitNameLength && unitNameRE.MatchString(unitName) && !strings.Contains(unitName, "..") && !strings.HasPrefix(unitName, ".")
+}
+
+func IsSystemd(dep map[string]interface{}) bool {
+	if _, ok := dep["systemd_unit"]; ok {
+		return true
+	}
+	return false
+}
+
+// Functions that allow SystemdDeploymentConfig to support the DeploymentConfig interface.
+
+func (s *SystemdDeploymentConfig) IsNative() bool {
+	return false
+}
+
+func (s *SystemdDeploymentConfig) ToPersistentForm() (map[string]interface{}, error) {
+	ret := make(map[string]interface{})
+
+	// Marshal to JSON form so that we can unmarshal as a map[string]interface{}.
+	if jBytes, err := json.Marshal(s); err != nil {
+		return ret, errors.New(fmt.Sprintf("error marshalling systemd deployment: %v, error: %v", s, err))
+	} else if err := json.Unmarshal(jBytes, &ret); err != nil {
+		return ret, errors.New(fmt.Sprintf("error unmarshalling systemd deployment: %v, error: %v", string(jBytes), err))
+	}
+
+	return ret, nil
+}
+
+func (s *SystemdDeploymentConfig) FromPersistentForm(pf map[string]interface{}) error {
+
+	// Marshal to JSON form so that we can unmarshal as a SystemdDeploymentConfig.
+	if jBytes, err := json.Marshal(pf); err != nil {
+		return errors.New(fmt.Sprintf("error marshalling systemd persistent deployment: %v, error: %v", s, err))
+	} else if err := json.Unmarshal(jBytes, s); err != nil {
+		return errors.New(fmt.Sprintf("error unmarshalling systemd persistent deployment: %v, error: %v", string(jBytes), err))
+	}
+
+	return nil
+}
+
+func (s *SystemdDeploymentConfig) ToString() string {
+	if s != nil {
+		return s.String()
+	} else {
+		return ""
+	}
+}
+
+// Given a deployment string, unmarshal it as a SystemdDeployment object. It might not be a SystemdDeployment, so
+// we have to verify what was just unmarshalled.
+func GetSystemdDeployment(depStr string) (*SystemdDeploymentConfig, error) {
+
+	sd := new(SystemdDeploymentConfig)
+	err := json.Unmarshal([]byte(depStr), sd)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("error unmarshalling deployment config as SystemdDeployment: %v", err))
+	}
+
+	if len(sd.UnitName) == 0 {
+		return nil, errors.New(fmt.Sprintf("deployment config is not a SystemdDeployment"))
+	} else if !IsValidUnitName(sd.UnitName) {
+		return nil, errors.New(fmt.Sprintf("systemd deployment config %v has an invalid systemd_unit, it must be a plain unit name made of letters, digits, ':', '_', '.' and '-'", sd))
+	} else if len(sd.ObjectType) == 0 || len(sd.PackageObject) == 0 || len(sd.UnitObject) == 0 {
+		return nil, errors.New(fmt.Sprintf("systemd deployment config %v must have an object_type, package_object and unit_object", sd))
+	}
+
+	return sd, nil
+
+}
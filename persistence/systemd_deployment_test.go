@@ -0,0 +1,82 @@
+//go:build unit
+// +build unit
+
+package persistence
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func Test_DecodeSystemdDeployment(t *testing.T) {
+
+	sd := NewSystemdDeployment("hello", "systemd-package", "hello-1.0.0.tar.gz", "hello.service")
+
+	sdBytes, err := json.Marshal(sd)
+	if err != nil {
+		t.Errorf("Error marshalling %v, error: %v", sd, err)
+	}
+
+	newSD, gsdErr := GetSystemdDeployment(string(sdBytes))
+	if gsdErr != nil {
+		t.Errorf("Error extracting systemd deployment %v, error: %v", string(sdBytes), gsdErr)
+	} else if *newSD != *sd {
+		t.Errorf("Extracted systemd deployment %v does not match original %v", newSD, sd)
+	}
+
+	// A helm or native deployment is not a systemd deployment.
+	for _, dep := range []string{`{"chart_archive":"1234","release_name":"test"}`, `{"services":{"hello":{"image":"hello:1.0"}}}`} {
+		if newSD, gsdErr := GetSystemdDeployment(dep); gsdErr == nil || newSD != nil {
+			t.Errorf("Should be an error returned for %v", dep)
+		}
+	}
+
+	// The objects are required.
+	incomplete := `{"systemd_unit":"hello","object_type":"systemd-package"}`
+	if _, gsdErr := GetSystemdDeployment(incomplete); gsdErr == nil {
+		t.Errorf("Should be an error returned for %v", incomplete)
+	}
+
+	// The unit name is used in the paths of the unit files, it must be a plain unit name.
+	for _, name := range []string{"../../../etc/cron.d/evil", "hello/world", "..", ".hidden", "a..b", "hello@1", "hello world", strings.Repeat("a", 250)} {
+		sd.UnitName = name
+		sdBytes, _ := json.Marshal(sd)
+		if _, gsdErr := GetSystemdDeployment(string(sdBytes)); gsdErr == nil {
+			t.Errorf("Should be an error returned for unit name %v", name)
+		}
+	}
+	for _, name := range []string{"hello", "hello-world_2.0", "my:unit"} {
+		if !IsValidUnitName(name) {
+			t.Errorf("Unit name %v should be valid", name)
+		}
+	}
+
+}
+
+func Test_SystemdPersistToFrom(t *testing.T) {
+
+	sd := NewSystemdDeployment("hello", "systemd-package", "hello", "hello.service")
+	sd.ObjectOrg = "myorg"
+
+	if pf, err := sd.ToPersistentForm(); err != nil {
+		t.Errorf("unexpected error changing to persistent form: %v", err)
+	} else if !IsSystemd(pf) || IsHelm(pf) || IsKube(pf) {
+		t.Errorf("persistent form should only be recognized as systemd, is: %v", pf)
+	} else {
+		nsd := SystemdDeploymentConfig{}
+		if err := nsd.FromPersistentForm(pf); err != nil {
+			t.Errorf("unexpected error changing from persistent form: %v", err)
+		} else if nsd != *sd {
+			t.Errorf("object from persistent form: %v doesnt match original: %v", nsd, sd)
+		}
+
+		ag := EstablishedAgreement{ExtendedDeployment: pf}
+		if dc, ok := ag.GetDeploymentConfig().(*SystemdDeploymentConfig); !ok {
+			t.Errorf("agreement deployment config should be a systemd deployment, is %T", ag.GetDeploymentConfig())
+		} else if *dc != *sd {
+			t.Errorf("agreement deployment config %v doesnt match original: %v", dc, sd)
+		}
+	}
+
+}
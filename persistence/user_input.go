@@ -4,7 +4,6 @@ import (
 	"encoding/json"
 	"fmt"
 	"github.com/open-horizon/anax/policy"
-	bolt "go.etcd.io/bbolt"
 )
 
 const NODE_USERINPUT = "nodeuserinput"                              // The bucket name in the bolt DB.
@@ -12,11 +11,11 @@ const EXCHANGE_NODE_USERINPUT_HASH = "exchange_node_userinput_hash" // The buuck
 
 // Retrieve the node user input object from the database. The bolt APIs assume there is more than 1 object in a bucket,
 // so this function has to be prepared for that case, even though there should only ever be 1.
-func FindNodeUserInput(db *bolt.DB) ([]policy.UserInput, error) {
+func FindNodeUserInput(db AgentDatabase) ([]policy.UserInput, error) {
 
 	var userInput []policy.UserInput
 
-	readErr := db.View(func(tx *bolt.Tx) error {
+	readErr := db.View(func(tx Tx) error {
 		if b := tx.Bucket([]byte(NODE_USERINPUT)); b != nil {
 			return b.ForEach(func(k, v []byte) error {
 
@@ -39,9 +38,9 @@ func FindNodeUserInput(db *bolt.DB) ([]policy.UserInput, error) {
 }
 
 // There is only 1 object in the bucket so we can use the bucket name as the object key.
-func SaveNodeUserInput(db *bolt.DB, userInput []policy.UserInput) error {
+func SaveNodeUserInput(db AgentDatabase, userInput []policy.UserInput) error {
 
-	writeErr := db.Update(func(tx *bolt.Tx) error {
+	writeErr := db.Update(func(tx Tx) error {
 		b, err := tx.CreateBucketIfNotExists([]byte(NODE_USERINPUT))
 		if err != nil {
 			return err
@@ -58,7 +57,7 @@ func SaveNodeUserInput(db *bolt.DB, userInput []policy.UserInput) error {
 }
 
 // Remove the node user input object from the local database.
-func DeleteNodeUserInput(db *bolt.DB) error {
+func DeleteNodeUserInput(db AgentDatabase) error {
 
 	if ui, err := FindNodeUserInput(db); err != nil {
 		return err
@@ -66,7 +65,7 @@ func DeleteNodeUserInput(db *bolt.DB) error {
 		return nil
 	} else {
 
-		return db.Update(func(tx *bolt.Tx) error {
+		return db.Update(func(tx Tx) error {
 
 			if b, err := tx.CreateBucketIfNotExists([]byte(NODE_USERINPUT)); err != nil {
 				return err
@@ -80,7 +79,7 @@ func DeleteNodeUserInput(db *bolt.DB) error {
 }
 
 // Delete all UserInputAttributes from the local db.
-func DeleteAllUserInputAttributes(db *bolt.DB) error {
+func DeleteAllUserInputAttributes(db AgentDatabase) error {
 	if attributes, err := FindApplicableAttributes(db, "", ""); err != nil {
 		return fmt.Errorf("Failed to get all the UserInputAttributes. %v", err)
 	} else {
@@ -98,7 +97,7 @@ func DeleteAllUserInputAttributes(db *bolt.DB) error {
 }
 
 // Get all UerInputAttributes from db
-func GetAllUserInputAttributes(db *bolt.DB) ([]UserInputAttributes, error) {
+func GetAllUserInputAttributes(db AgentDatabase) ([]UserInputAttributes, error) {
 	allUI := []UserInputAttributes{}
 	if attributes, err := FindApplicableAttributes(db, "", ""); err != nil {
 		return nil, fmt.Errorf("Failed to get all the UserInputAttributes. %v", err)
@@ -115,11 +114,11 @@ func GetAllUserInputAttributes(db *bolt.DB) ([]UserInputAttributes, error) {
 }
 
 // Retrieve the exchange node user input hash from the database.
-func GetNodeUserInputHash_Exch(db *bolt.DB) ([]byte, error) {
+func GetNodeUserInputHash_Exch(db AgentDatabase) ([]byte, error) {
 
 	userInputHash := []byte{}
 
-	readErr := db.View(func(tx *bolt.Tx) error {
+	readErr := db.View(func(tx Tx) error {
 		if b := tx.Bucket([]byte(EXCHANGE_NODE_USERINPUT_HASH)); b != nil {
 			return b.ForEach(func(k, v []byte) error {
 				userInputHash = v
@@ -138,9 +137,9 @@ func GetNodeUserInputHash_Exch(db *bolt.DB) ([]byte, error) {
 }
 
 // save the exchange node user input hash.
-func SaveNodeUserInputHash_Exch(db *bolt.DB, userInputHash []byte) error {
+func SaveNodeUserInputHash_Exch(db AgentDatabase, userInputHash []byte) error {
 
-	writeErr := db.Update(func(tx *bolt.Tx) error {
+	writeErr := db.Update(func(tx Tx) error {
 		b, err := tx.CreateBucketIfNotExists([]byte(EXCHANGE_NODE_USERINPUT_HASH))
 		if err != nil {
 			return err
@@ -154,14 +153,14 @@ func SaveNodeUserInputHash_Exch(db *bolt.DB, userInputHash []byte) error {
 }
 
 // Remove the exchange node user input hash from the local database.
-func DeleteNodeUserInputHash_Exch(db *bolt.DB) error {
+func DeleteNodeUserInputHash_Exch(db AgentDatabase) error {
 
 	if userInputHash, err := GetNodeUserInputHash_Exch(db); err != nil {
 		return err
 	} else if userInputHash == nil || len(userInputHash) == 0 {
 		return nil
 	} else {
-		return db.Update(func(tx *bolt.Tx) error {
+		return db.Update(func(tx Tx) error {
 
 			if b, err := tx.CreateBucketIfNotExists([]byte(EXCHANGE_NODE_USERINPUT_HASH)); err != nil {
 				return err
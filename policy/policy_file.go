@@ -73,6 +73,7 @@ type Policy struct {
 	SecretBinding      []exchangecommon.SecretBinding      `json:"secretBinding,omitempty"`    // This structure has the servive secret name to secret provider name mappings
 	SecretDetails      []exchangecommon.SecretBinding      `json:"secretDetails,omitempty"`    // This structure has the service secret name to secret details mappings
 	ClusterNamespace   string                              `json:"clusterNamespace,omitempty"` // the namespace for the service to be deployed
+	Rollout            *RolloutPolicy                      `json:"rollout,omitempty"`          // how a new service version is rolled out to the nodes with agreements
 }
 
 // These functions are used to create Policy objects. You can create the base object
@@ -134,6 +135,11 @@ func (self *Policy) DeepCopy() *Policy {
 
 	newPolicy.ClusterNamespace = self.ClusterNamespace
 
+	if self.Rollout != nil {
+		ro := *self.Rollout
+		newPolicy.Rollout = &ro
+	}
+
 	return newPolicy
 }
 
@@ -513,6 +519,9 @@ func (self *Policy) String() string {
 	res += fmt.Sprintf("SecretBinding: %v\n", self.SecretBinding)
 
 	res += fmt.Sprintf("ClusterNamespace: %v\n", self.ClusterNamespace)
+	if self.Rollout != nil {
+		res += fmt.Sprintf("Rollout: %v\n", self.Rollout)
+	}
 
 	return res
 }
@@ -0,0 +1,50 @@
+package policy
+
+import (
+	"fmt"
+)
+
+// The default number of seconds a node in a rollout wave has to get a healthy agreement for the new service version.
+const RolloutHealthTimeoutS_DEFAULT = 1800
+
+// How a new service version is rolled out to the nodes that already have an agreement for the policy. The nodes are
+// moved to the new version in waves, and the next wave starts after the current wave has been healthy for the bake time.
+type RolloutPolicy struct {
+	BatchSize      int `json:"batch_size,omitempty"`     // The number of nodes moved to the new version in each wave
+	BatchPercent   int `json:"batch_percent,omitempty"`  // The percentage of the nodes moved to the new version in each wave, used when batch_size is not set
+	BakeTimeS      int `json:"bake_time,omitempty"`      // The number of seconds a wave has to stay healthy before the next wave starts
+	MaxFailures    int `json:"max_failures,omitempty"`   // The number of nodes in a wave that can fail before the rollout is halted
+	HealthTimeoutS int `json:"health_timeout,omitempty"` // The number of seconds a node in a wave has to get a healthy agreement for the new version
+}
+
+func (r RolloutPolicy) String() string {
+	return fmt.Sprintf("BatchSize: %v, BatchPercent: %v, BakeTimeS: %v, MaxFailures: %v, HealthTimeoutS: %v",
+		r.BatchSize, r.BatchPercent, r.BakeTimeS, r.MaxFailures, r.HealthTimeoutS)
+}
+
+func (r RolloutPolicy) IsSame(compare RolloutPolicy) bool {
+	return r == compare
+}
+
+// Returns the number of nodes in each wave of a rollout to the given number of nodes.
+func (r RolloutPolicy) WaveSize(nodes int) int {
+	size := r.BatchSize
+	if size <= 0 {
+		percent := r.BatchPercent
+		if percent <= 0 || percent > 100 {
+			percent = 100
+		}
+		size = (nodes*percent + 99) / 100
+	}
+	if size <= 0 {
+		size = 1
+	}
+	return size
+}
+
+func (r RolloutPolicy) GetHealthTimeoutS() int {
+	if r.HealthTimeoutS <= 0 {
+		return RolloutHealthTimeoutS_DEFAULT
+	}
+	return r.HealthTimeoutS
+}
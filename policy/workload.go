@@ -5,7 +5,6 @@ import (
 	"fmt"
 	"github.com/golang/glog"
 	"github.com/open-horizon/anax/cutil"
-	"github.com/open-horizon/rsapss-tool/verify"
 	"golang.org/x/crypto/bcrypt"
 )
 
@@ -187,31 +186,31 @@ func (w Workload) HasValidSignature(keyFileNames []string) error {
 	glog.V(3).Infof("Verifying workload signature with keys (bare or wrapped in x509 cert): %v", keyFileNames)
 
 	if w.Deployment != "" {
-		if verified, fn_success, failed_map := verify.InputVerifiedByAnyKey(keyFileNames, w.DeploymentSignature, []byte(w.Deployment)); !verified {
+		if verified, fn_success, failed_map := cutil.InputVerifiedByAnyKey(keyFileNames, w.DeploymentSignature, []byte(w.Deployment)); !verified {
 			glog.Errorf("Unable to verify deployment signature: %v", failed_map)
 			return fmt.Errorf("There is no public key available to verify the deployment signature. Ensure that valid deployment signing keys are published with the service. Deployment signature: %v for deployment: %v.", w.DeploymentSignature, w.Deployment)
 		} else {
-			glog.Infof("Deployment verification successful with RSA pubkey in file: %v", fn_success)
+			glog.Infof("Deployment verification successful with pubkey in file: %v", fn_success)
 		}
 	}
 
 	if w.ClusterDeployment != "" {
-		if verified, fn_success, failed_map := verify.InputVerifiedByAnyKey(keyFileNames, w.ClusterDeploymentSignature, []byte(w.ClusterDeployment)); !verified {
+		if verified, fn_success, failed_map := cutil.InputVerifiedByAnyKey(keyFileNames, w.ClusterDeploymentSignature, []byte(w.ClusterDeployment)); !verified {
 			glog.Errorf("Unable to verify cluster deployment signature: %v", failed_map)
 			return fmt.Errorf("There is no public key available to verify the deployment signature. Ensure that deployment signing keys are published with the service. Deployment signature: %v for deployment: %v.", w.ClusterDeploymentSignature, cutil.TruncateDisplayString(w.ClusterDeployment, 100))
 		} else {
-			glog.Infof("Cluster deployment verification successful with RSA pubkey in file: %v", fn_success)
+			glog.Infof("Cluster deployment verification successful with pubkey in file: %v", fn_success)
 		}
 	}
 
 	if w.DeploymentOverrides == "" {
 		return nil
 	} else {
-		if verified, fn_success, failed_map := verify.InputVerifiedByAnyKey(keyFileNames, w.DeploymentOverridesSignature, []byte(w.DeploymentOverrides)); !verified {
+		if verified, fn_success, failed_map := cutil.InputVerifiedByAnyKey(keyFileNames, w.DeploymentOverridesSignature, []byte(w.DeploymentOverrides)); !verified {
 			glog.Errorf("Unable to verify override deployment signature: %v", failed_map)
 			return fmt.Errorf("There is no public key available to verify the deployment signature. Ensure that deployment signing keys are published with the service. Deployment signature: %v for deployment: %v.", w.DeploymentOverridesSignature, w.DeploymentOverrides)
 		} else {
-			glog.Infof("Deployment overrides verification successful with RSA pubkey in file: %v", fn_success)
+			glog.Infof("Deployment overrides verification successful with pubkey in file: %v", fn_success)
 		}
 		return nil
 	}
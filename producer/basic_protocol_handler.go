@@ -14,8 +14,8 @@ import (
 	"github.com/open-horizon/anax/persistence"
 	"github.com/open-horizon/anax/policy"
 	"github.com/open-horizon/anax/resource"
+	"github.com/open-horizon/anax/tracing"
 	"github.com/open-horizon/anax/worker"
-	bolt "go.etcd.io/bbolt"
 )
 
 type BasicProtocolHandler struct {
@@ -23,7 +23,7 @@ type BasicProtocolHandler struct {
 	agreementPH *basicprotocol.ProtocolHandler
 }
 
-func NewBasicProtocolHandler(name string, cfg *config.HorizonConfig, db *bolt.DB, pm *policy.PolicyManager, ec exchange.ExchangeContext) *BasicProtocolHandler {
+func NewBasicProtocolHandler(name string, cfg *config.HorizonConfig, db persistence.AgentDatabase, pm *policy.PolicyManager, ec exchange.ExchangeContext) *BasicProtocolHandler {
 	if name == basicprotocol.PROTOCOL_NAME {
 		return &BasicProtocolHandler{
 			BaseProducerProtocolHandler: &BaseProducerProtocolHandler{
@@ -65,9 +65,23 @@ func (c *BasicProtocolHandler) AcceptCommand(cmd worker.Command) bool {
 
 func (c *BasicProtocolHandler) HandleProposalMessage(proposal abstractprotocol.Proposal, protocolMsg string, exchangeMsg *exchange.DeviceMessage) (bool, bool) {
 
+	// Start tracing the agreement on the node, as part of the agbot's trace when the proposal carries its trace context.
+	// The trace ends when the workload is running or the agreement is terminated, or right here if the proposal is not
+	// accepted.
+	tracing.StartAgreement(proposal.AgreementId(), "agent.Agreement", proposal.TraceContext())
+	span := tracing.StartSpan(proposal.AgreementId(), "agent.HandleProposal", nil)
+	accepted := false
+	defer func() {
+		span.End()
+		if !accepted {
+			tracing.EndAgreement(proposal.AgreementId(), errors.New("proposal was not accepted by the node"))
+		}
+	}()
+
 	if handled, reply, tcPolicy := c.HandleProposal(c.agreementPH, proposal, protocolMsg, []map[string]string{}, exchangeMsg); handled {
 		if reply != nil {
 			c.PersistProposal(proposal, reply, tcPolicy, protocolMsg)
+			accepted = reply.ProposalAccepted()
 			return handled, reply.ProposalAccepted()
 		} else {
 			return handled, false
@@ -175,6 +189,32 @@ func (c *BasicProtocolHandler) HandleExtensionMessages(msg *events.ExchangeDevic
 
 			}
 
+		} else if update.IsSecretRevert() {
+
+			glog.V(3).Infof(BPHlogString(fmt.Sprintf("handling secret revert for %v: %v", update.AgreementId(), update.Metadata)))
+
+			// The metadata names the secrets to revert, in the same form as a secret update but without the secret values.
+			var revertSecrets []exchangecommon.SecretBinding
+			if bytes, err := json.Marshal(update.Metadata); err != nil {
+				glog.Errorf(BPHlogString(fmt.Sprintf("agreement %v, unable to marshal revert, error: %v", update.AgreementId(), err)))
+				acceptedUpdate = false
+			} else if err := json.Unmarshal(bytes, &revertSecrets); err != nil {
+				glog.Errorf(BPHlogString(fmt.Sprintf("agreement %v, unable to unmarshal revert, error: %v", update.AgreementId(), err)))
+				acceptedUpdate = false
+			} else {
+
+				secManager := resource.NewSecretsManager(c.BaseProducerProtocolHandler.config, c.db)
+
+				// restore the previous secret values, if nodeType is device, also updating the secret file in agent
+				revertedSecs, err := secManager.RevertServiceSecretUpdates(update.AgreementId(), persistence.PersistedSecretFromPolicySecret(revertSecrets, update.AgreementId()))
+				if err != nil {
+					glog.Errorf(BPHlogString(fmt.Sprintf("agreement %v, unable to revert service secrets, error: %v", update.AgreementId(), err)))
+					acceptedUpdate = false
+				}
+				updatedSecs = revertedSecs
+
+			}
+
 		} else if update.IsPolicyChangeUpdate() {
 			// a policy that was used to form an agreement with this node has changed
 			// the agbot has sent an updated merged policy
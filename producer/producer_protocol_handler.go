@@ -18,7 +18,6 @@ import (
 	"github.com/open-horizon/anax/persistence"
 	"github.com/open-horizon/anax/policy"
 	"github.com/open-horizon/anax/worker"
-	bolt "go.etcd.io/bbolt"
 	"strconv"
 	"strings"
 	"time"
@@ -48,7 +47,7 @@ func MarkI18nMessages() {
 	msgPrinter.Sprintf(EL_PROD_ERR_HANDLE_PROPOSAL)
 }
 
-func CreateProducerPH(name string, cfg *config.HorizonConfig, db *bolt.DB, pm *policy.PolicyManager, ec exchange.ExchangeContext) ProducerProtocolHandler {
+func CreateProducerPH(name string, cfg *config.HorizonConfig, db persistence.AgentDatabase, pm *policy.PolicyManager, ec exchange.ExchangeContext) ProducerProtocolHandler {
 	if handler := NewBasicProtocolHandler(name, cfg, db, pm, ec); handler != nil {
 		return handler
 	} // Add new producer side protocol handlers here
@@ -82,7 +81,7 @@ type ProducerProtocolHandler interface {
 type BaseProducerProtocolHandler struct {
 	name   string
 	pm     *policy.PolicyManager
-	db     *bolt.DB
+	db     persistence.AgentDatabase
 	config *config.HorizonConfig
 	ec     exchange.ExchangeContext
 }
@@ -0,0 +1,229 @@
+package resource
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/open-horizon/anax/cutil"
+	"github.com/open-horizon/edge-sync-service/common"
+	"github.com/open-horizon/edge-sync-service/core/security"
+)
+
+// The prefix of the CSS object API used by the ESS. The ESS polls it for the object updates of the node.
+const cssObjectsURL = "/spi/v1/objects/"
+
+// The ObjectDeltaProxy is the CSS of the embedded ESS. It passes the requests of the ESS to the CSS, except for the
+// requests for the data of the objects published with a delta. When the version of the data that the delta was made
+// from is retained on the node, and the ESS has received the companion object with the delta, the data is
+// reconstructed on the node and the ESS receives it from the proxy, so that the full data is not downloaded. Otherwise
+// the request is passed to the CSS, and the ESS receives the full data. The ESS verifies the data it receives either
+// way.
+//
+// The messages of the companion objects are moved ahead of the other messages of each poll, so that the ESS receives
+// the delta before it requests the data of the object.
+type ObjectDeltaProxy struct {
+	upstream    *httputil.ReverseProxy
+	objectData  func(org string, objType string, objID string, dataID int64) (*ObjectVersion, error)
+	credentials func() (string, string)
+	server      *http.Server
+	url         string
+}
+
+func NewObjectDeltaProxy(cssURL string, cssCACert string, ov *ObjectVersionManager) (*ObjectDeltaProxy, error) {
+	target, err := url.Parse(cssURL)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("unable to parse CSS URL %v, error %v", cssURL, err))
+	}
+
+	transport := &http.Transport{}
+	if cssCACert != "" {
+		if tlsConfig, err := cssTLSConfig(cssCACert); err != nil {
+			return nil, err
+		} else {
+			transport.TLSClientConfig = tlsConfig
+		}
+	}
+
+	p := &ObjectDeltaProxy{
+		objectData: ov.deltaObjectData,
+		credentials: func() (string, string) {
+			return security.KeyandSecretForURL(common.HTTPCSSURL)
+		},
+	}
+	p.upstream = &httputil.ReverseProxy{
+		Rewrite: func(r *httputil.ProxyRequest) {
+			r.SetURL(target)
+		},
+		Transport:      transport,
+		ModifyResponse: p.modifyResponse,
+		ErrorHandler: func(writer http.ResponseWriter, request *http.Request, err error) {
+			glog.Errorf(dpLogString(fmt.Sprintf("unable to pass %v %v to the CSS, error %v", request.Method, request.URL.Path, err)))
+			writer.WriteHeader(http.StatusBadGateway)
+		},
+	}
+	return p, nil
+}
+
+// The CSS CA certificate is a file name or the certificate itself, as in the ESS.
+func cssTLSConfig(cssCACert string) (*tls.Config, error) {
+	certificate, err := os.ReadFile(cssCACert)
+	if err != nil {
+		if _, ok := err.(*os.PathError); !ok {
+			return nil, errors.New(fmt.Sprintf("unable to read CSS CA certificate %v, error %v", cssCACert, err))
+		}
+		certificate = []byte(cssCACert)
+	}
+	caCertPool := x509.NewCertPool()
+	caCertPool.AppendCertsFromPEM(certificate)
+	return &tls.Config{RootCAs: caCertPool}, nil
+}
+
+// Start listening on the loopback interface. It returns the URL of the proxy, which is the CSS URL of the ESS.
+func (p *ObjectDeltaProxy) Start() (string, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return "", errors.New(fmt.Sprintf("unable to listen for the ESS, error %v", err))
+	}
+	p.server = &http.Server{Handler: p, ReadHeaderTimeout: 30 * time.Second}
+	go func() {
+		if err := p.server.Serve(listener); err != nil && err != http.ErrServerClosed {
+			glog.Errorf(dpLogString(fmt.Sprintf("stopped serving the ESS, error %v", err)))
+		}
+	}()
+	p.url = "http://" + listener.Addr().String()
+	return p.url, nil
+}
+
+func (p *ObjectDeltaProxy) Stop() {
+	if p.server != nil {
+		p.server.Close()
+	}
+}
+
+func (p *ObjectDeltaProxy) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	if v := p.reconstructedData(request); v != nil {
+		if f, err := os.Open(v.dataFile); err != nil {
+			glog.Errorf(dpLogString(fmt.Sprintf("unable to open the data of %v, the data is received from the CSS, error %v", v, err)))
+		} else {
+			defer f.Close()
+			glog.V(5).Infof(dpLogString(fmt.Sprintf("serving the reconstructed data of %v to the ESS", v)))
+			writer.Header().Set("Content-Type", "application/octet-stream")
+			http.ServeContent(writer, request, "", time.Time{}, f)
+			return
+		}
+	}
+	p.upstream.ServeHTTP(writer, request)
+}
+
+// Returns the version with the reconstructed data of the object, when the request is the ESS requesting the data of
+// an object published with a delta that is reconstructed on the node. The request must have the node's credentials so
+// that other processes on the node cannot read the data of the node's objects.
+func (p *ObjectDeltaProxy) reconstructedData(request *http.Request) *ObjectVersion {
+	if request.Method != http.MethodGet || !strings.HasPrefix(request.URL.Path, cssObjectsURL) {
+		return nil
+	}
+	// {org}/{type}/{id}/{instanceID}/{dataID}/data
+	parts := strings.Split(strings.TrimPrefix(request.URL.Path, cssObjectsURL), "/")
+	if len(parts) != 6 || parts[5] != "data" {
+		return nil
+	}
+	dataID, err := strconv.ParseInt(parts[4], 10, 64)
+	if err != nil {
+		return nil
+	}
+
+	user, password, ok := request.BasicAuth()
+	key, secret := p.credentials()
+	if !ok || key == "" || subtle.ConstantTimeCompare([]byte(user), []byte(key)) != 1 || subtle.ConstantTimeCompare([]byte(password), []byte(secret)) != 1 {
+		return nil
+	}
+
+	v, err := p.objectData(parts[0], parts[1], parts[2], dataID)
+	if err != nil {
+		glog.Errorf(dpLogString(fmt.Sprintf("unable to reconstruct object %v/%v from its delta, the data is received from the CSS, error %v", parts[1], parts[2], err)))
+		return nil
+	}
+	return v
+}
+
+// Move the messages of the companion objects ahead of the other messages of a poll.
+func (p *ObjectDeltaProxy) modifyResponse(response *http.Response) error {
+	if response.Request.Method != http.MethodGet || !strings.HasSuffix(response.Request.URL.Path, cssObjectsURL) || response.StatusCode != http.StatusOK {
+		return nil
+	}
+	body, err := io.ReadAll(response.Body)
+	response.Body.Close()
+	if err != nil {
+		return err
+	}
+	body = deltaUpdatesFirst(body)
+	response.Body = io.NopCloser(bytes.NewReader(body))
+	response.ContentLength = int64(len(body))
+	response.Header.Set("Content-Length", strconv.Itoa(len(body)))
+	return nil
+}
+
+// Returns the messages of a poll with the messages of the companion objects first. The order of the messages of each
+// object is kept. The messages are returned unchanged if they cannot be parsed, the ESS handles them.
+func deltaUpdatesFirst(body []byte) []byte {
+	var messages []json.RawMessage
+	if err := json.Unmarshal(body, &messages); err != nil {
+		return body
+	}
+
+	type objectMessage struct {
+		MetaData common.MetaData
+	}
+	keys := make([]string, len(messages))
+	deltas := make(map[string]bool)
+	for i, raw := range messages {
+		var message objectMessage
+		if err := json.Unmarshal(raw, &message); err != nil {
+			return body
+		}
+		keys[i] = message.MetaData.ObjectType + "/" + message.MetaData.ObjectID
+		if cutil.IsDeltaObject(&message.MetaData) {
+			deltas[keys[i]] = true
+		}
+	}
+	if len(deltas) == 0 {
+		return body
+	}
+
+	order := make([]int, len(messages))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return deltas[keys[order[i]]] && !deltas[keys[order[j]]]
+	})
+	sorted := make([]json.RawMessage, len(messages))
+	for i, index := range order {
+		sorted[i] = messages[index]
+	}
+	if b, err := json.Marshal(sorted); err == nil {
+		return b
+	}
+	return body
+}
+
+// Logging function
+var dpLogString = func(v interface{}) string {
+	return fmt.Sprintf("Object Delta Proxy: %v", v)
+}
@@ -0,0 +1,141 @@
+//go:build unit
+// +build unit
+
+package resource
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/open-horizon/anax/cutil"
+	"github.com/open-horizon/edge-sync-service/common"
+	"github.com/stretchr/testify/assert"
+)
+
+type testPollMessage struct {
+	Type     string
+	MetaData common.MetaData
+}
+
+func testPollBody(t *testing.T, messages ...testPollMessage) []byte {
+	b, err := json.Marshal(messages)
+	assert.Nil(t, err)
+	return b
+}
+
+func testPollOrder(t *testing.T, body []byte) []string {
+	var messages []testPollMessage
+	assert.Nil(t, json.Unmarshal(body, &messages))
+	order := make([]string, 0, len(messages))
+	for _, m := range messages {
+		order = append(order, m.Type+" "+m.MetaData.ObjectID)
+	}
+	return order
+}
+
+func testDeltaMeta(objectID string) common.MetaData {
+	meta := common.MetaData{ObjectType: "model", ObjectID: objectID, DestinationPolicy: &common.Policy{}}
+	if strings.HasSuffix(objectID, cutil.MMS_DELTA_OBJECT_SUFFIX) {
+		cutil.SetObjectPolicyProperty(meta.DestinationPolicy, cutil.MMS_PROP_DELTA_BASE, "abc", "string")
+	}
+	return meta
+}
+
+func Test_deltaUpdatesFirst(t *testing.T) {
+	body := testPollBody(t,
+		testPollMessage{Type: common.Update, MetaData: testDeltaMeta("detector")},
+		testPollMessage{Type: common.Delete, MetaData: common.MetaData{ObjectType: "model", ObjectID: "detector.delta"}},
+		testPollMessage{Type: common.Update, MetaData: testDeltaMeta("other")},
+		testPollMessage{Type: common.Update, MetaData: testDeltaMeta("detector.delta")},
+	)
+
+	// the messages of the companion keep their order, ahead of the other messages
+	assert.Equal(t, []string{"delete detector.delta", "update detector.delta", "update detector", "update other"}, testPollOrder(t, deltaUpdatesFirst(body)))
+
+	// polls without companions and bodies that cannot be parsed are not changed
+	body = testPollBody(t, testPollMessage{Type: common.Update, MetaData: testDeltaMeta("detector")})
+	assert.Equal(t, body, deltaUpdatesFirst(body))
+	assert.Equal(t, []byte("not json"), deltaUpdatesFirst([]byte("not json")))
+}
+
+func Test_ObjectDeltaProxy(t *testing.T) {
+	poll := testPollBody(t,
+		testPollMessage{Type: common.Update, MetaData: testDeltaMeta("detector")},
+		testPollMessage{Type: common.Update, MetaData: testDeltaMeta("detector.delta")},
+	)
+	upstreamPaths := []string{}
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		upstreamPaths = append(upstreamPaths, r.URL.Path)
+		if r.URL.Path == "/css"+cssObjectsURL {
+			w.Write(poll)
+		} else {
+			w.Write([]byte("full data from the CSS"))
+		}
+	}))
+	defer upstream.Close()
+
+	p, err := NewObjectDeltaProxy(upstream.URL+"/css", "", &ObjectVersionManager{})
+	assert.Nil(t, err)
+	p.credentials = func() (string, string) { return "myorg/openhorizon.edgenode/node1", "token" }
+
+	dir := t.TempDir()
+	reconstructed := &ObjectVersion{Meta: testObjectMeta("2.0", 2, ""), dataFile: path.Join(dir, "2.data")}
+	assert.Nil(t, os.WriteFile(reconstructed.dataFile, []byte("reconstructed data"), 0600))
+	p.objectData = func(org string, objType string, objID string, dataID int64) (*ObjectVersion, error) {
+		if org == "myorg" && objType == "model" && objID == "detector" && dataID == 2 {
+			return reconstructed, nil
+		}
+		return nil, nil
+	}
+
+	proxyURL, err := p.Start()
+	assert.Nil(t, err)
+	defer p.Stop()
+
+	get := func(urlPath string, user string, password string, rangeHeader string) (*http.Response, string) {
+		request, err := http.NewRequest(http.MethodGet, proxyURL+urlPath, nil)
+		assert.Nil(t, err)
+		request.SetBasicAuth(user, password)
+		if rangeHeader != "" {
+			request.Header.Set("Range", rangeHeader)
+		}
+		response, err := http.DefaultClient.Do(request)
+		assert.Nil(t, err)
+		defer response.Body.Close()
+		b, err := io.ReadAll(response.Body)
+		assert.Nil(t, err)
+		return response, string(b)
+	}
+
+	// the companion is polled ahead of the object
+	response, body := get(cssObjectsURL, "myorg/openhorizon.edgenode/node1", "token", "")
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.Equal(t, []string{"update detector.delta", "update detector"}, testPollOrder(t, []byte(body)))
+
+	// the ESS receives the reconstructed data, in full or by chunks, without downloading it from the CSS
+	upstreamPaths = upstreamPaths[:0]
+	response, body = get(cssObjectsURL+"myorg/model/detector/5/2/data", "myorg/openhorizon.edgenode/node1", "token", "")
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.Equal(t, "reconstructed data", body)
+	response, body = get(cssObjectsURL+"myorg/model/detector/5/2/data", "myorg/openhorizon.edgenode/node1", "token", "bytes=14-17")
+	assert.Equal(t, http.StatusPartialContent, response.StatusCode)
+	assert.Equal(t, "4", response.Header.Get("Content-Length"))
+	assert.Equal(t, "data", body)
+	assert.Empty(t, upstreamPaths)
+
+	// other data, and requests without the node's credentials, are received from the CSS
+	_, body = get(cssObjectsURL+"myorg/model/detector/5/2/data", "myorg/openhorizon.edgenode/node2", "token", "")
+	assert.Equal(t, "full data from the CSS", body)
+	_, body = get(cssObjectsURL+"myorg/model/detector/5/2/data", "myorg/openhorizon.edgenode/node1", "wrong", "")
+	assert.Equal(t, "full data from the CSS", body)
+	_, body = get(cssObjectsURL+"myorg/model/detector/5/3/data", "myorg/openhorizon.edgenode/node1", "token", "")
+	assert.Equal(t, "full data from the CSS", body)
+	dataPath := "/css" + cssObjectsURL + "myorg/model/detector/5/"
+	assert.Equal(t, []string{dataPath + "2/data", dataPath + "2/data", dataPath + "3/data"}, upstreamPaths)
+}
@@ -0,0 +1,734 @@
+package resource
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/open-horizon/anax/config"
+	"github.com/open-horizon/anax/cutil"
+	"github.com/open-horizon/edge-sync-service/common"
+	"github.com/open-horizon/edge-sync-service/core/base"
+	"github.com/open-horizon/edge-sync-service/core/security"
+)
+
+// The prefix of the ESS object API.
+const essObjectsURL = "/api/v1/objects/"
+
+// A version of an object's data that is kept on the node after it is replaced in the ESS, so that services can be
+// rolled back to it. The metadata is the latest metadata of the object while the version was current. The data of an
+// object published with a delta may be reconstructed from the delta before the ESS has received the data.
+type ObjectVersion struct {
+	Meta     common.MetaData `json:"meta"`
+	Retained time.Time       `json:"retained"`           // When the version was copied from the ESS.
+	DataHash string          `json:"dataHash,omitempty"` // The SHA256 hash of the data, used to find the base of a delta.
+	DataSize int64           `json:"dataSize,omitempty"`
+	dataFile string
+}
+
+func (v ObjectVersion) String() string {
+	return fmt.Sprintf("Object %v/%v, Version: %v, DataID: %v, Retained: %v", v.Meta.ObjectType, v.Meta.ObjectID, v.Meta.Version, v.Meta.DataID, v.Retained)
+}
+
+// Returns the SHA256 hash of the data, computing it for versions retained before the hash was kept.
+func (v *ObjectVersion) dataHash() (string, error) {
+	if v.DataHash != "" {
+		return v.DataHash, nil
+	}
+	f, err := os.Open(v.dataFile)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// The ObjectVersionManager implements the lifecycle of the objects in the embedded ESS. The ESS only keeps the current
+// version of an object and it never removes the objects it has received, so the agent copies each version of the data
+// that arrives to its own store, and serves the ESS object API in front of the ESS:
+//   - Expired objects are not served to the services.
+//   - An object pinned to a previous version (openhorizon.mms.pinnedVersion) is served from that version.
+//   - A service whose version is not in the destination policy of the current version is served the newest previous
+//     version that it can use, so object versions follow the service versions that use them.
+//   - An object published with a delta (openhorizon.mms.dataHash) is reconstructed from the delta in its companion
+//     object when the version the delta was made from is retained, and the ESS receives the reconstructed data from
+//     the ObjectDeltaProxy in place of the data in the CSS.
+//   - The companion objects that have the deltas are not listed to the services.
+//
+// Everything else is passed to the ESS.
+type ObjectVersionManager struct {
+	root          string
+	org           string
+	defaultRetain int
+	essHandler    http.Handler
+	lock          sync.RWMutex
+	deltaLock     sync.Mutex      // Held while an object is reconstructed from its delta.
+	failedDeltas  map[string]bool // The versions that cannot be reconstructed, so that they are not tried again.
+}
+
+func NewObjectVersionManager(cfg *config.HorizonConfig, org string) *ObjectVersionManager {
+	return &ObjectVersionManager{
+		root:          cfg.GetFileSyncServiceVersionsPath(),
+		org:           org,
+		defaultRetain: cfg.GetFSSRetainedObjectVersions(),
+	}
+}
+
+func (m *ObjectVersionManager) objectDir(objType string, objID string) string {
+	return path.Join(m.root, url.PathEscape(m.org), url.PathEscape(objType), url.PathEscape(objID))
+}
+
+// Returns the versions of an object on the node, newest first. The current version of the object is included once it
+// has been copied.
+func (m *ObjectVersionManager) versions(objType string, objID string) ([]ObjectVersion, error) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	return readObjectVersions(m.objectDir(objType, objID))
+}
+
+func readObjectVersions(dir string) ([]ObjectVersion, error) {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	versions := make([]ObjectVersion, 0, len(entries))
+	for _, entry := range entries {
+		if !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+		var v ObjectVersion
+		if b, err := os.ReadFile(path.Join(dir, entry.Name())); err != nil {
+			return nil, err
+		} else if err := json.Unmarshal(b, &v); err != nil {
+			return nil, errors.New(fmt.Sprintf("unable to unmarshal object version %v, error %v", path.Join(dir, entry.Name()), err))
+		}
+		v.dataFile = path.Join(dir, strings.TrimSuffix(entry.Name(), ".json")+".data")
+		versions = append(versions, v)
+	}
+	sort.Slice(versions, func(i, j int) bool { return versions[i].Retained.After(versions[j].Retained) })
+	return versions, nil
+}
+
+// Returns true if the version has the data of the object's metadata.
+func sameObjectData(v *ObjectVersion, meta *common.MetaData) bool {
+	return v.Meta.DataID == meta.DataID && v.Meta.ObjectSize == meta.ObjectSize && v.Meta.Signature == meta.Signature
+}
+
+// Select the version of an object that is served to a service. It returns nil and true to serve the current version
+// from the ESS, and nil and false if the object is not served at all because it has expired. The service is the <org>/<version>/<name> identity of the service, or empty when the caller
+// is not a service.
+func selectObjectVersion(current *common.MetaData, versions []ObjectVersion, service string, now time.Time) (*ObjectVersion, bool) {
+	if cutil.ObjectExpired(current, now) {
+		return nil, false
+	}
+
+	// The data of an object published with a delta may be reconstructed before the ESS has received it, so it is served
+	// from its version when there is one.
+	serveCurrent := func() (*ObjectVersion, bool) {
+		if cutil.GetObjectDataHash(current) != "" {
+			for i := range versions {
+				if sameObjectData(&versions[i], current) {
+					return &versions[i], true
+				}
+			}
+		}
+		return nil, true
+	}
+
+	// The previous versions, newest first.
+	previous := make([]*ObjectVersion, 0, len(versions))
+	for i := range versions {
+		if !sameObjectData(&versions[i], current) && !cutil.ObjectExpired(&versions[i].Meta, now) {
+			previous = append(previous, &versions[i])
+		}
+	}
+
+	if pinned := cutil.GetObjectPinnedVersion(current); pinned != "" && pinned != current.Version {
+		for _, v := range previous {
+			if pinned == cutil.MMS_PINNED_PREVIOUS || v.Meta.Version == pinned {
+				if cutil.ServiceCanUseObject(&v.Meta, service) {
+					return v, true
+				}
+				break
+			}
+		}
+		glog.V(3).Infof(ovLogString(fmt.Sprintf("object %v/%v is pinned to version %v which is not retained on this node, serving the current version", current.ObjectType, current.ObjectID, pinned)))
+	}
+
+	if cutil.ServiceCanUseObject(current, service) {
+		return serveCurrent()
+	}
+	for _, v := range previous {
+		if cutil.ServiceCanUseObject(&v.Meta, service) {
+			return v, true
+		}
+	}
+	// The service cannot use any version, the ESS decides whether it can access the object.
+	return nil, true
+}
+
+// Called periodically to copy the new versions of the objects in the ESS, and to remove the versions that are no
+// longer retained.
+func (m *ObjectVersionManager) CheckObjects() int {
+	if !common.Running {
+		return 0
+	}
+
+	objects, err := base.ListObjectsWithFilters(m.org, nil, "", "", "", 0, "", "", "", "", nil, "", nil)
+	if err != nil {
+		glog.Errorf(ovLogString(fmt.Sprintf("unable to list the objects in the ESS, error %v", err)))
+		return 0
+	}
+
+	now := time.Now()
+	retained := make(map[string]bool)
+	for i := range objects {
+		meta := &objects[i]
+		if meta.Deleted || meta.NoData || meta.Link != "" || cutil.IsDeltaObject(meta) || cutil.ObjectExpired(meta, now) {
+			continue
+		}
+		retain, err := cutil.GetObjectRetainVersions(meta, m.defaultRetain)
+		if err != nil {
+			glog.Warningf(ovLogString(fmt.Sprintf("object %v/%v has an invalid policy, error %v", meta.ObjectType, meta.ObjectID, err)))
+		}
+		// The current version of an object published with a delta is kept, so that the next delta can be applied to it.
+		if retain == 0 && cutil.GetObjectDataHash(meta) == "" {
+			continue
+		}
+		retained[m.objectDir(meta.ObjectType, meta.ObjectID)] = true
+
+		if status, err := base.GetObjectStatus(m.org, meta.ObjectType, meta.ObjectID); err != nil {
+			glog.Errorf(ovLogString(fmt.Sprintf("unable to get the status of object %v/%v, error %v", meta.ObjectType, meta.ObjectID, err)))
+			continue
+		} else if status != common.CompletelyReceived && status != common.ObjReceived && status != common.ObjConsumed {
+			continue
+		}
+
+		if err := m.retainObject(meta, now); err != nil {
+			glog.Errorf(ovLogString(fmt.Sprintf("unable to retain object %v/%v version %v, error %v", meta.ObjectType, meta.ObjectID, meta.Version, err)))
+		} else if err := m.pruneObject(meta, retain); err != nil {
+			glog.Errorf(ovLogString(fmt.Sprintf("unable to remove previous versions of object %v/%v, error %v", meta.ObjectType, meta.ObjectID, err)))
+		}
+	}
+
+	// Remove the versions of the objects that are gone from the ESS, have expired or are no longer retained.
+	if err := m.removeObjects(retained); err != nil {
+		glog.Errorf(ovLogString(fmt.Sprintf("unable to remove object versions, error %v", err)))
+	}
+	return 0
+}
+
+// Copy the current data of the object if it is not on the node yet, otherwise update the metadata of its version.
+func (m *ObjectVersionManager) retainObject(meta *common.MetaData, now time.Time) error {
+	dir := m.objectDir(meta.ObjectType, meta.ObjectID)
+	name := path.Join(dir, fmt.Sprintf("%d", meta.DataID))
+
+	versions, err := m.versions(meta.ObjectType, meta.ObjectID)
+	if err != nil {
+		return err
+	}
+	if v := currentObjectVersion(versions, name, meta); v != nil {
+		if v.Meta.InstanceID == meta.InstanceID {
+			return nil
+		}
+		v.Meta = *meta
+		return m.writeObjectVersion(name, v, nil, false)
+	}
+
+	reader, err := base.GetObjectData(m.org, meta.ObjectType, meta.ObjectID)
+	if err != nil {
+		return err
+	} else if reader == nil {
+		return nil
+	}
+	defer closeObjectData(reader)
+
+	v := &ObjectVersion{Meta: *meta, Retained: now}
+	if err := m.writeObjectVersion(name, v, reader, false); err != nil {
+		return err
+	}
+	glog.V(3).Infof(ovLogString(fmt.Sprintf("retained %v", v)))
+	return nil
+}
+
+// Returns the version of the data of an object published with a delta, that the ESS receives in place of the data in
+// the CSS. The data is reconstructed from the delta in the companion object when it is not on the node yet. It returns
+// nil when the object is not published with a delta, or when its data cannot be reconstructed on the node, so that the
+// ESS receives the data from the CSS.
+func (m *ObjectVersionManager) deltaObjectData(org string, objType string, objID string, dataID int64) (*ObjectVersion, error) {
+	if org != m.org {
+		return nil, nil
+	}
+	meta, err := base.GetObject(org, objType, objID)
+	if err != nil {
+		return nil, err
+	} else if meta == nil || meta.Deleted || meta.DataID != dataID || cutil.GetObjectDataHash(meta) == "" {
+		return nil, nil
+	}
+
+	m.deltaLock.Lock()
+	defer m.deltaLock.Unlock()
+	if err := m.reconstructObject(meta, time.Now()); err != nil {
+		return nil, err
+	}
+	versions, err := m.versions(objType, objID)
+	if err != nil {
+		return nil, err
+	}
+	return currentObjectVersion(versions, path.Join(m.objectDir(objType, objID), fmt.Sprintf("%d", dataID)), meta), nil
+}
+
+// Reconstruct the current data of an object published with a delta, that the ESS has not received yet, from the delta
+// in its companion object. The delta is only applied when the companion is the delta to the object's data and the
+// version it was made from is retained on the node. Otherwise the ESS receives the data from the CSS. The caller must
+// hold the delta lock.
+func (m *ObjectVersionManager) reconstructObject(meta *common.MetaData, now time.Time) error {
+	dir := m.objectDir(meta.ObjectType, meta.ObjectID)
+	name := path.Join(dir, fmt.Sprintf("%d", meta.DataID))
+	if m.failedDeltas[name] {
+		return nil
+	}
+
+	versions, err := m.versions(meta.ObjectType, meta.ObjectID)
+	if err != nil {
+		return err
+	} else if currentObjectVersion(versions, name, meta) != nil {
+		return nil
+	}
+
+	// The companion may not have been received yet, or be the delta of another version of the data.
+	deltaID := cutil.DeltaObjectID(meta.ObjectID)
+	deltaMeta, err := base.GetObject(m.org, meta.ObjectType, deltaID)
+	if err != nil {
+		return err
+	} else if deltaMeta == nil || deltaMeta.Deleted {
+		return nil
+	}
+	baseHash, dataHash := cutil.GetObjectDelta(deltaMeta)
+	if dataHash != cutil.GetObjectDataHash(meta) {
+		return nil
+	} else if status, err := base.GetObjectStatus(m.org, meta.ObjectType, deltaID); err != nil {
+		return err
+	} else if status != common.CompletelyReceived && status != common.ObjReceived && status != common.ObjConsumed {
+		return nil
+	}
+
+	baseVersion := findObjectVersion(versions, baseHash)
+	if baseVersion == nil {
+		glog.V(3).Infof(ovLogString(fmt.Sprintf("the base of the delta of object %v/%v version %v is not retained on this node, the data is received from the CSS", meta.ObjectType, meta.ObjectID, meta.Version)))
+		m.markFailedDelta(name)
+		return nil
+	}
+
+	reader, err := base.GetObjectData(m.org, meta.ObjectType, deltaID)
+	if err != nil {
+		return err
+	} else if reader == nil {
+		return nil
+	}
+	defer closeObjectData(reader)
+
+	v := &ObjectVersion{Meta: *meta, Retained: now}
+	if err := m.writeObjectVersionFromDelta(name, v, baseVersion, reader); err != nil {
+		// The delta is not applied again, the ESS receives the data from the CSS.
+		m.markFailedDelta(name)
+		return err
+	}
+	glog.V(3).Infof(ovLogString(fmt.Sprintf("reconstructed %v from its delta", v)))
+	return nil
+}
+
+func (m *ObjectVersionManager) markFailedDelta(name string) {
+	if m.failedDeltas == nil {
+		m.failedDeltas = make(map[string]bool)
+	}
+	m.failedDeltas[name] = true
+}
+
+// Returns the version in the file name that has the data of the object's metadata, or nil if it is not on the node.
+func currentObjectVersion(versions []ObjectVersion, name string, meta *common.MetaData) *ObjectVersion {
+	for i := range versions {
+		if v := &versions[i]; v.dataFile == name+".data" && sameObjectData(v, meta) {
+			return v
+		}
+	}
+	return nil
+}
+
+// Returns the version whose data has the SHA256 hash, or nil if it is not retained.
+func findObjectVersion(versions []ObjectVersion, dataHash string) *ObjectVersion {
+	for i := range versions {
+		if hash, err := versions[i].dataHash(); err != nil {
+			glog.Warningf(ovLogString(fmt.Sprintf("unable to hash the data of %v, error %v", versions[i], err)))
+		} else if hash == dataHash {
+			return &versions[i]
+		}
+	}
+	return nil
+}
+
+func closeObjectData(reader io.Reader) {
+	if closer, ok := reader.(io.Closer); ok {
+		closer.Close()
+	}
+}
+
+// Write a version whose data is reconstructed by applying the delta to the base version of the delta. The data is
+// verified against the object's data hash and signature before the version is written.
+func (m *ObjectVersionManager) writeObjectVersionFromDelta(name string, v *ObjectVersion, base *ObjectVersion, delta io.Reader) error {
+	m.lock.RLock()
+	f, err := os.Open(base.dataFile)
+	m.lock.RUnlock()
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	reader, writer := io.Pipe()
+	defer reader.Close()
+	go func() {
+		writer.CloseWithError(cutil.ApplyDelta(f, delta, writer))
+	}()
+	return m.writeObjectVersion(name, v, reader, true)
+}
+
+// Write the metadata, and the data if there is a reader, of a version. Both are written to temporary files first so
+// that a version is never read partially written. Data reconstructed from a delta is verified before it is kept.
+func (m *ObjectVersionManager) writeObjectVersion(name string, v *ObjectVersion, data io.Reader, reconstructed bool) error {
+	if err := os.MkdirAll(path.Dir(name), 0700); err != nil {
+		return err
+	}
+
+	if data != nil {
+		tmp := name + ".data.tmp"
+		f, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+		if err != nil {
+			return err
+		}
+		h := sha256.New()
+		v.DataSize, err = io.Copy(io.MultiWriter(f, h), data)
+		v.DataHash = hex.EncodeToString(h.Sum(nil))
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		if err == nil && reconstructed {
+			err = verifyObjectVersionData(v, tmp)
+		}
+		if err != nil {
+			os.Remove(tmp)
+			return err
+		}
+		m.lock.Lock()
+		err = os.Rename(tmp, name+".data")
+		m.lock.Unlock()
+		if err != nil {
+			return err
+		}
+	}
+
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	tmp := name + ".json.tmp"
+	if err := os.WriteFile(tmp, b, 0600); err != nil {
+		return err
+	}
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return os.Rename(tmp, name+".json")
+}
+
+// Verify the data reconstructed from a delta before it is served.
+func verifyObjectVersionData(v *ObjectVersion, dataFile string) error {
+	f, err := os.Open(dataFile)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return cutil.VerifyObjectDeltaData(&v.Meta, f)
+}
+
+// Remove the previous versions of an object beyond the number to retain. The current version is always kept.
+func (m *ObjectVersionManager) pruneObject(meta *common.MetaData, retain int) error {
+	versions, err := m.versions(meta.ObjectType, meta.ObjectID)
+	if err != nil {
+		return err
+	}
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	kept := 0
+	for i := range versions {
+		v := &versions[i]
+		if sameObjectData(v, meta) {
+			continue
+		} else if kept < retain {
+			kept++
+			continue
+		}
+		glog.V(3).Infof(ovLogString(fmt.Sprintf("removing %v", v)))
+		if err := os.Remove(strings.TrimSuffix(v.dataFile, ".data") + ".json"); err != nil && !os.IsNotExist(err) {
+			return err
+		} else if err := os.Remove(v.dataFile); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// Remove the versions of all the objects that are not in the retained set of object directories.
+func (m *ObjectVersionManager) removeObjects(retained map[string]bool) error {
+	orgDir := path.Join(m.root, url.PathEscape(m.org))
+	types, err := os.ReadDir(orgDir)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	for _, t := range types {
+		ids, err := os.ReadDir(path.Join(orgDir, t.Name()))
+		if err != nil {
+			return err
+		}
+		for _, id := range ids {
+			if dir := path.Join(orgDir, t.Name(), id.Name()); !retained[dir] {
+				glog.V(3).Infof(ovLogString(fmt.Sprintf("removing the versions in %v", dir)))
+				if err := os.RemoveAll(dir); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// Put the object version handlers in front of the ESS object API. The ESS must already be started so that its handler
+// can be found in the mux. The handlers are more specific than the ESS pattern so the mux calls them first.
+func (m *ObjectVersionManager) SetupHttpHandler(mux *http.ServeMux) error {
+	probe, err := http.NewRequest(http.MethodGet, essObjectsURL+"type", nil)
+	if err != nil {
+		return err
+	}
+	handler, pattern := mux.Handler(probe)
+	if pattern == "" {
+		return errors.New("the ESS object API is not registered")
+	}
+	m.essHandler = handler
+
+	// curl -X GET https://localhost/api/v1/objects/{type}/{id}/data --cacert /ess-cert/cert.pem --unix-socket /var/run/horizon/essapi.sock
+	mux.HandleFunc(http.MethodGet+" "+essObjectsURL+"{type}", m.handleListObjects)
+	mux.HandleFunc(http.MethodGet+" "+essObjectsURL+"{type}/{id}", m.handleGetObject)
+	mux.HandleFunc(http.MethodGet+" "+essObjectsURL+"{type}/{id}/data", m.handleGetObjectData)
+	return nil
+}
+
+// Returns the service identity of the caller, and false if the request is not authenticated, in which case the ESS
+// handles it.
+func authenticatedService(request *http.Request) (string, bool) {
+	code, _, id := security.Authenticate(request)
+	if code == security.AuthFailed {
+		return "", false
+	} else if code != security.AuthService {
+		return "", true
+	}
+	return id, true
+}
+
+// Select the version of an object to serve to the caller. It returns nil and true when the ESS handles the request.
+func (m *ObjectVersionManager) resolve(request *http.Request, objType string, objID string) (*ObjectVersion, bool) {
+	if !common.Running {
+		return nil, true
+	}
+	service, ok := authenticatedService(request)
+	if !ok {
+		return nil, true
+	}
+	current, err := base.GetObject(m.org, objType, objID)
+	if err != nil || current == nil || current.Deleted {
+		return nil, true
+	}
+	versions, err := m.versions(objType, objID)
+	if err != nil {
+		glog.Errorf(ovLogString(fmt.Sprintf("unable to read the versions of object %v/%v, error %v", objType, objID, err)))
+	}
+	v, served := selectObjectVersion(current, versions, service, time.Now())
+	if v != nil {
+		// The version is served as the current instance of the object.
+		v.Meta.InstanceID = current.InstanceID
+		glog.V(5).Infof(ovLogString(fmt.Sprintf("serving %v to %v", v, service)))
+	}
+	return v, served
+}
+
+// GET /api/v1/objects/{objectType} returns the updated objects of a type. The expired objects and the companion objects
+// that have deltas are removed, and the objects that are served from a previous version have the metadata of that
+// version.
+func (m *ObjectVersionManager) handleListObjects(writer http.ResponseWriter, request *http.Request) {
+	service, ok := authenticatedService(request)
+	if !common.Running || !ok {
+		m.essHandler.ServeHTTP(writer, request)
+		return
+	}
+
+	resp := newBufferedResponse()
+	m.essHandler.ServeHTTP(resp, request)
+
+	var objects []common.MetaData
+	if resp.code != http.StatusOK || json.Unmarshal(resp.body.Bytes(), &objects) != nil {
+		resp.writeTo(writer)
+		return
+	}
+
+	now := time.Now()
+	result := make([]common.MetaData, 0, len(objects))
+	for i := range objects {
+		obj := &objects[i]
+		if cutil.IsDeltaObject(obj) {
+			continue
+		} else if obj.Deleted {
+			result = append(result, *obj)
+			continue
+		}
+		versions, err := m.versions(obj.ObjectType, obj.ObjectID)
+		if err != nil {
+			glog.Errorf(ovLogString(fmt.Sprintf("unable to read the versions of object %v/%v, error %v", obj.ObjectType, obj.ObjectID, err)))
+		}
+		if v, served := selectObjectVersion(obj, versions, service, now); !served {
+			continue
+		} else if v != nil {
+			meta := v.Meta
+			meta.InstanceID = obj.InstanceID
+			result = append(result, meta)
+		} else {
+			result = append(result, *obj)
+		}
+	}
+
+	for k, v := range resp.header {
+		writer.Header()[k] = v
+	}
+	if len(result) == 0 {
+		writer.Header().Del("Content-Type")
+		writer.WriteHeader(http.StatusNotFound)
+		return
+	}
+	body, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		writer.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	writer.Header().Del("Content-Length")
+	writer.WriteHeader(http.StatusOK)
+	writer.Write(body)
+}
+
+// GET /api/v1/objects/{objectType}/{objectID} returns the metadata of the version of an object that is served to the
+// caller.
+func (m *ObjectVersionManager) handleGetObject(writer http.ResponseWriter, request *http.Request) {
+	v, served := m.resolve(request, request.PathValue("type"), request.PathValue("id"))
+	if !served {
+		writer.WriteHeader(http.StatusNotFound)
+		return
+	} else if v == nil {
+		m.essHandler.ServeHTTP(writer, request)
+		return
+	}
+
+	body, err := json.MarshalIndent(v.Meta, "", "  ")
+	if err != nil {
+		writer.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(http.StatusOK)
+	writer.Write(body)
+}
+
+// GET /api/v1/objects/{objectType}/{objectID}/data returns the data of the version of an object that is served to the
+// caller. Range requests are supported like they are by the ESS.
+func (m *ObjectVersionManager) handleGetObjectData(writer http.ResponseWriter, request *http.Request) {
+	v, served := m.resolve(request, request.PathValue("type"), request.PathValue("id"))
+	if !served {
+		writer.WriteHeader(http.StatusNotFound)
+		return
+	} else if v == nil {
+		m.essHandler.ServeHTTP(writer, request)
+		return
+	}
+
+	// The file stays readable if the version is removed while it is served.
+	m.lock.RLock()
+	f, err := os.Open(v.dataFile)
+	m.lock.RUnlock()
+	if err != nil {
+		glog.Errorf(ovLogString(fmt.Sprintf("unable to open the data of %v, error %v", v, err)))
+		m.essHandler.ServeHTTP(writer, request)
+		return
+	}
+	defer f.Close()
+
+	writer.Header().Set("Content-Type", "application/octet-stream")
+	http.ServeContent(writer, request, "", v.Retained, f)
+}
+
+// A response writer that keeps the response of the ESS so that it can be changed before it is sent.
+type bufferedResponse struct {
+	header http.Header
+	code   int
+	body   bytes.Buffer
+}
+
+func newBufferedResponse() *bufferedResponse {
+	return &bufferedResponse{header: make(http.Header), code: http.StatusOK}
+}
+
+func (b *bufferedResponse) Header() http.Header {
+	return b.header
+}
+
+func (b *bufferedResponse) Write(data []byte) (int, error) {
+	return b.body.Write(data)
+}
+
+func (b *bufferedResponse) WriteHeader(code int) {
+	b.code = code
+}
+
+func (b *bufferedResponse) writeTo(writer http.ResponseWriter) {
+	for k, v := range b.header {
+		writer.Header()[k] = v
+	}
+	writer.WriteHeader(b.code)
+	writer.Write(b.body.Bytes())
+}
+
+// Logging function
+var ovLogString = func(v interface{}) string {
+	return fmt.Sprintf("Object Versions: %v", v)
+}
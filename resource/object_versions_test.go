@@ -0,0 +1,233 @@
+//go:build unit
+// +build unit
+
+package resource
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/open-horizon/anax/cutil"
+	"github.com/open-horizon/edge-sync-service/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func testObjectMeta(version string, dataID int64, serviceVersion string) common.MetaData {
+	return common.MetaData{
+		ObjectType: "model",
+		ObjectID:   "detector",
+		Version:    version,
+		DataID:     dataID,
+		ObjectSize: dataID * 100,
+		DestinationPolicy: &common.Policy{
+			Services: []common.ServiceID{{OrgID: "myorg", ServiceName: "detect", Arch: "*", Version: serviceVersion}},
+		},
+	}
+}
+
+func Test_selectObjectVersion(t *testing.T) {
+	now := time.Now()
+	v1 := ObjectVersion{Meta: testObjectMeta("1.0", 1, "[1.0.0,2.0.0)"), Retained: now.Add(-2 * time.Hour)}
+	v2 := ObjectVersion{Meta: testObjectMeta("2.0", 2, "[1.0.0,2.0.0)"), Retained: now.Add(-time.Hour)}
+	current := testObjectMeta("3.0", 3, "[2.0.0,INFINITY)")
+	v3 := ObjectVersion{Meta: current, Retained: now}
+	versions := []ObjectVersion{v3, v2, v1}
+
+	// the current version is served to the services it is for
+	v, served := selectObjectVersion(&current, versions, "myorg/2.1.0/detect", now)
+	assert.True(t, served)
+	assert.Nil(t, v)
+
+	// a service that is not upgraded yet gets the newest version for it
+	v, served = selectObjectVersion(&current, versions, "myorg/1.5.0/detect", now)
+	assert.True(t, served)
+	assert.Equal(t, "2.0", v.Meta.Version)
+
+	// callers that are not services get the current version
+	v, served = selectObjectVersion(&current, versions, "", now)
+	assert.True(t, served)
+	assert.Nil(t, v)
+
+	// a pinned object is served from the pinned version, if the service can use it
+	current.DestinationPolicy.Services[0].Version = "[1.0.0,INFINITY)"
+	current.DestinationPolicy.Properties = []common.PolicyProperty{{Name: cutil.MMS_PROP_PINNED_VERSION, Value: "1.0"}}
+	v, served = selectObjectVersion(&current, versions, "myorg/1.5.0/detect", now)
+	assert.True(t, served)
+	assert.Equal(t, "1.0", v.Meta.Version)
+
+	v, served = selectObjectVersion(&current, versions, "myorg/2.1.0/detect", now)
+	assert.True(t, served)
+	assert.Nil(t, v)
+
+	current.DestinationPolicy.Properties[0].Value = cutil.MMS_PINNED_PREVIOUS
+	v, served = selectObjectVersion(&current, versions, "myorg/1.5.0/detect", now)
+	assert.True(t, served)
+	assert.Equal(t, "2.0", v.Meta.Version)
+
+	// a version that is not retained is not served
+	current.DestinationPolicy.Properties[0].Value = "0.9"
+	v, served = selectObjectVersion(&current, versions, "myorg/1.5.0/detect", now)
+	assert.True(t, served)
+	assert.Nil(t, v)
+
+	// expired objects are not served, and expired versions are skipped
+	current.DestinationPolicy.Properties[0].Value = cutil.MMS_PINNED_PREVIOUS
+	versions[1].Meta.Expiration = now.Add(-time.Minute).Format(time.RFC3339)
+	v, served = selectObjectVersion(&current, versions, "myorg/1.5.0/detect", now)
+	assert.True(t, served)
+	assert.Equal(t, "1.0", v.Meta.Version)
+
+	current.Expiration = now.Add(-time.Minute).Format(time.RFC3339)
+	_, served = selectObjectVersion(&current, versions, "myorg/1.5.0/detect", now)
+	assert.False(t, served)
+}
+
+func Test_selectObjectVersion_serviceOutsidePolicies(t *testing.T) {
+	now := time.Now()
+	v1 := ObjectVersion{Meta: testObjectMeta("1.0", 1, "[1.0.0,2.0.0)"), Retained: now.Add(-time.Hour)}
+	current := testObjectMeta("2.0", 2, "[2.0.0,3.0.0)")
+	cutil.SetObjectPolicyProperty(current.DestinationPolicy, cutil.MMS_PROP_DATA_HASH, "abc", "string")
+	versions := []ObjectVersion{{Meta: current, Retained: now}, v1}
+
+	// the retained copy of the current version is served to the services it is for
+	v, served := selectObjectVersion(&current, versions, "myorg/2.1.0/detect", now)
+	assert.True(t, served)
+	assert.Equal(t, "2.0", v.Meta.Version)
+
+	// a service that is in no policy is never served a retained copy, the ESS checks its access
+	for _, service := range []string{"myorg/3.0.0/detect", "myorg/2.1.0/other", "otherorg/2.1.0/detect"} {
+		v, served = selectObjectVersion(&current, versions, service, now)
+		assert.True(t, served, service)
+		assert.Nil(t, v, service)
+	}
+}
+
+func Test_ObjectVersionManager_SetupHttpHandler(t *testing.T) {
+	mux := http.NewServeMux()
+	ess := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	mux.Handle(essObjectsURL, http.StripPrefix(essObjectsURL, ess))
+
+	m := &ObjectVersionManager{root: t.TempDir(), org: "myorg"}
+	assert.Nil(t, m.SetupHttpHandler(mux))
+
+	// the object reads are handled in front of the ESS, everything else goes to the ESS
+	for _, tc := range []struct {
+		method  string
+		url     string
+		pattern string
+	}{
+		{http.MethodGet, "/api/v1/objects/model", "GET /api/v1/objects/{type}"},
+		{http.MethodGet, "/api/v1/objects/model?received=true", "GET /api/v1/objects/{type}"},
+		{http.MethodGet, "/api/v1/objects/model/detector", "GET /api/v1/objects/{type}/{id}"},
+		{http.MethodGet, "/api/v1/objects/model/detector/data", "GET /api/v1/objects/{type}/{id}/data"},
+		{http.MethodGet, "/api/v1/objects/model/detector/status", essObjectsURL},
+		{http.MethodPut, "/api/v1/objects/model/detector/consumed", essObjectsURL},
+		{http.MethodPut, "/api/v1/objects/model", essObjectsURL},
+	} {
+		req, _ := http.NewRequest(tc.method, tc.url, nil)
+		_, pattern := mux.Handler(req)
+		assert.Equal(t, tc.pattern, pattern, tc.method+" "+tc.url)
+	}
+
+	assert.NotNil(t, (&ObjectVersionManager{}).SetupHttpHandler(http.NewServeMux()))
+}
+
+func Test_ObjectVersionManager_pruneObject(t *testing.T) {
+	m := &ObjectVersionManager{root: t.TempDir(), org: "myorg"}
+	now := time.Now()
+
+	for i := int64(1); i <= 4; i++ {
+		meta := testObjectMeta(fmt.Sprintf("1.%d", i), i, "")
+		v := &ObjectVersion{Meta: meta, Retained: now.Add(time.Duration(i) * time.Minute)}
+		name := path.Join(m.objectDir(meta.ObjectType, meta.ObjectID), fmt.Sprintf("%d", i))
+		assert.Nil(t, m.writeObjectVersion(name, v, strings.NewReader("data "+meta.Version), false))
+	}
+
+	versions, err := m.versions("model", "detector")
+	assert.Nil(t, err)
+	assert.Equal(t, 4, len(versions))
+	assert.Equal(t, "1.4", versions[0].Meta.Version)
+
+	// the current version and the newest previous version are kept
+	current := testObjectMeta("1.4", 4, "")
+	assert.Nil(t, m.pruneObject(&current, 1))
+	versions, err = m.versions("model", "detector")
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(versions))
+	assert.Equal(t, "1.4", versions[0].Meta.Version)
+	assert.Equal(t, "1.3", versions[1].Meta.Version)
+
+	// the versions of objects that are not retained are removed
+	assert.Nil(t, m.removeObjects(map[string]bool{}))
+	versions, err = m.versions("model", "detector")
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(versions))
+}
+
+func Test_ObjectVersionManager_writeObjectVersionFromDelta(t *testing.T) {
+	m := &ObjectVersionManager{root: t.TempDir(), org: "myorg"}
+	now := time.Now()
+	dir := m.objectDir("model", "detector")
+
+	baseData := bytes.Repeat([]byte("version 1 of the model data "), 1000)
+	newData := append(append([]byte{}, baseData[:10000]...), []byte("version 2")...)
+	newData = append(newData, baseData[10000:]...)
+	var delta bytes.Buffer
+	assert.Nil(t, cutil.WriteDelta(bytes.NewReader(baseData), bytes.NewReader(newData), &delta))
+
+	baseHash, newHash := sha256.Sum256(baseData), sha256.Sum256(newData)
+	baseMeta := testObjectMeta("1.0", 1, "")
+	cutil.SetObjectPolicyProperty(baseMeta.DestinationPolicy, cutil.MMS_PROP_DATA_HASH, hex.EncodeToString(baseHash[:]), "string")
+	assert.Nil(t, m.writeObjectVersion(path.Join(dir, "1"), &ObjectVersion{Meta: baseMeta, Retained: now}, bytes.NewReader(baseData), false))
+	versions, err := m.versions("model", "detector")
+	assert.Nil(t, err)
+	assert.Equal(t, hex.EncodeToString(baseHash[:]), versions[0].DataHash)
+
+	// the current version in the ESS has the full data, which is served by the ESS until it is reconstructed
+	current := testObjectMeta("2.0", 2, "")
+	current.ObjectSize = int64(len(newData))
+	cutil.SetObjectPolicyProperty(current.DestinationPolicy, cutil.MMS_PROP_DATA_HASH, hex.EncodeToString(newHash[:]), "string")
+	servedVersion, served := selectObjectVersion(&current, versions, "", now)
+	assert.True(t, served)
+	assert.Nil(t, servedVersion)
+
+	baseVersion := findObjectVersion(versions, hex.EncodeToString(baseHash[:]))
+	assert.NotNil(t, baseVersion)
+	assert.Nil(t, findObjectVersion(versions, hex.EncodeToString(newHash[:])))
+
+	v := &ObjectVersion{Meta: current, Retained: now.Add(time.Second)}
+	assert.Nil(t, m.writeObjectVersionFromDelta(path.Join(dir, "2"), v, baseVersion, bytes.NewReader(delta.Bytes())))
+	versions, err = m.versions("model", "detector")
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(versions))
+
+	servedVersion, served = selectObjectVersion(&current, versions, "", now)
+	assert.True(t, served)
+	assert.Equal(t, "2.0", servedVersion.Meta.Version)
+	assert.Equal(t, int64(len(newData)), servedVersion.DataSize)
+	data, err := os.ReadFile(servedVersion.dataFile)
+	assert.Nil(t, err)
+	assert.Equal(t, newData, data)
+
+	// the reconstructed data must have the hash of the object
+	cutil.SetObjectPolicyProperty(current.DestinationPolicy, cutil.MMS_PROP_DATA_HASH, hex.EncodeToString(baseHash[:]), "string")
+	current.DataID = 3
+	v = &ObjectVersion{Meta: current, Retained: now.Add(2 * time.Second)}
+	assert.NotNil(t, m.writeObjectVersionFromDelta(path.Join(dir, "3"), v, baseVersion, bytes.NewReader(delta.Bytes())))
+
+	// the delta must be made from the base
+	cutil.SetObjectPolicyProperty(current.DestinationPolicy, cutil.MMS_PROP_DATA_HASH, hex.EncodeToString(newHash[:]), "string")
+	v = &ObjectVersion{Meta: current, Retained: now.Add(2 * time.Second)}
+	assert.NotNil(t, m.writeObjectVersionFromDelta(path.Join(dir, "3"), v, &versions[0], bytes.NewReader(delta.Bytes())))
+	versions, err = m.versions("model", "detector")
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(versions))
+}
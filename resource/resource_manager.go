@@ -18,7 +18,6 @@ import (
 	"github.com/open-horizon/edge-utilities/logger"
 	"github.com/open-horizon/edge-utilities/logger/log"
 	"github.com/open-horizon/edge-utilities/logger/trace"
-	bolt "go.etcd.io/bbolt"
 )
 
 type ResourceManager struct {
@@ -28,6 +27,7 @@ type ResourceManager struct {
 	id       string
 	token    string
 	nodeType string
+	proxy    *ObjectDeltaProxy
 }
 
 func NewResourceManager(cfg *config.HorizonConfig, org string, pattern string, id string, token string, nodeType string) *ResourceManager {
@@ -65,7 +65,7 @@ func (r ResourceManager) String() string {
 		r.org, r.pattern, r.id, r.token, r.nodeType)
 }
 
-func (r ResourceManager) setupFileSyncService(am *AuthenticationManager) error {
+func (r *ResourceManager) setupFileSyncService(am *AuthenticationManager, ov *ObjectVersionManager) error {
 
 	// Generate a self signed certificate to be used for TLS between a service and the embedded ESS API.
 	// The SSL private key is stored in a different location from the certificate so that the services
@@ -139,8 +139,21 @@ func (r ResourceManager) setupFileSyncService(am *AuthenticationManager) error {
 	// The embedded ESS will use a local bolt DB.
 	common.Configuration.StorageProvider = "bolt"
 
-	// Set the fully formed CSS API URL in the global configuration object.
+	// Set the fully formed CSS API URL in the global configuration object. The ESS reaches the CSS through the object
+	// delta proxy, so that the objects published with a delta are reconstructed on the node when possible.
 	common.HTTPCSSURL = r.config.GetCSSURL()
+	if ov != nil && r.proxy == nil {
+		if proxy, err := NewObjectDeltaProxy(r.config.GetCSSURL(), r.config.GetCSSSSLCert(), ov); err != nil {
+			glog.Errorf(rmLogString(fmt.Sprintf("unable to create the object delta proxy, objects published with a delta are received in full, error: %v", err)))
+		} else if proxyURL, err := proxy.Start(); err != nil {
+			glog.Errorf(rmLogString(fmt.Sprintf("unable to start the object delta proxy, objects published with a delta are received in full, error: %v", err)))
+		} else {
+			r.proxy = proxy
+			common.HTTPCSSURL = proxyURL
+		}
+	} else if r.proxy != nil {
+		common.HTTPCSSURL = r.proxy.url
+	}
 
 	// Init the sync service log and trace.
 	parameters := logger.Parameters{
@@ -171,15 +184,16 @@ func (r ResourceManager) setupFileSyncService(am *AuthenticationManager) error {
 	return nil
 }
 
-func (r ResourceManager) setupSecretsAPI(am *AuthenticationManager, db *bolt.DB) {
+func (r ResourceManager) setupSecretsAPI(am *AuthenticationManager, db persistence.AgentDatabase) {
 	glog.V(5).Infof(rmLogString(fmt.Sprintf("Setup secret API")))
 	secretAPIs := NewSecretAPI(db, am)
 	secretAPIs.SetupHttpHandler()
 }
 
-// StartFileSyncServiceAndSecretAPI will start embeded ESS and agent secrets API server
-func (r ResourceManager) StartFileSyncServiceAndSecretsAPI(am *AuthenticationManager, db *bolt.DB) error {
-	if err := r.setupFileSyncService(am); err != nil {
+// StartFileSyncServiceAndSecretAPI will start embeded ESS and agent secrets API server. The ESS receives the objects
+// published with a delta from the object version manager when it can reconstruct them.
+func (r *ResourceManager) StartFileSyncServiceAndSecretsAPI(am *AuthenticationManager, db persistence.AgentDatabase, ov *ObjectVersionManager) error {
+	if err := r.setupFileSyncService(am, ov); err != nil {
 		glog.Errorf(rmLogString(fmt.Sprintf("ESS Setup error: %v", err)))
 		os.Exit(98)
 	}
@@ -248,6 +262,10 @@ func (r ResourceManager) StopFileSyncService() {
 			}
 		}
 
+		if r.proxy != nil {
+			r.proxy.Stop()
+		}
+
 		// Complete the final steps of cleanup.
 		r.RemovePersistencePath()
 		glog.Infof(rmLogString(fmt.Sprintf("ESS Stopped")))
@@ -260,6 +278,10 @@ func (r *ResourceManager) RemovePersistencePath() {
 	if err := os.RemoveAll(syncPath); err != nil {
 		glog.Errorf(rmLogString(fmt.Sprintf("unable to remove file sync service persistence path %v, error: %v", syncPath, err)))
 	}
+	versionsPath := r.config.GetFileSyncServiceVersionsPath()
+	if err := os.RemoveAll(versionsPath); err != nil {
+		glog.Errorf(rmLogString(fmt.Sprintf("unable to remove object versions path %v, error: %v", versionsPath, err)))
+	}
 }
 
 // Logging function
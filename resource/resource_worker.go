@@ -8,17 +8,21 @@ import (
 	"github.com/open-horizon/anax/events"
 	"github.com/open-horizon/anax/persistence"
 	"github.com/open-horizon/anax/worker"
-	bolt "go.etcd.io/bbolt"
+	"net/http"
 )
 
+const OBJECT_VERSIONS = "ObjectVersions"
+
 type ResourceWorker struct {
 	worker.BaseWorker // embedded field
-	db                *bolt.DB
+	db                persistence.AgentDatabase
 	rm                *ResourceManager
 	am                *AuthenticationManager
+	ov                *ObjectVersionManager
+	ovStarted         bool
 }
 
-func NewResourceWorker(name string, config *config.HorizonConfig, db *bolt.DB, am *AuthenticationManager) *ResourceWorker {
+func NewResourceWorker(name string, config *config.HorizonConfig, db persistence.AgentDatabase, am *AuthenticationManager) *ResourceWorker {
 
 	var ec *worker.BaseExchangeContext
 	var rm *ResourceManager
@@ -58,14 +62,37 @@ func (w *ResourceWorker) Messages() chan events.Message {
 
 func (w *ResourceWorker) Initialize() bool {
 	if w.rm.Configured() {
-		if err := w.rm.StartFileSyncServiceAndSecretsAPI(w.am, w.db); err != nil {
+		if err := w.rm.StartFileSyncServiceAndSecretsAPI(w.am, w.db, w.objectVersions()); err != nil {
 			glog.Errorf(reslog(fmt.Sprintf("Error starting ESS and Secrets API: %v", err)))
 			return false
 		}
+		w.startObjectVersions()
 	}
 	return true
 }
 
+// Returns the object version manager of the node, which is created before the embedded ESS is started so that the ESS
+// can receive the objects reconstructed from their delta.
+func (w *ResourceWorker) objectVersions() *ObjectVersionManager {
+	if w.ov == nil {
+		w.ov = NewObjectVersionManager(w.Config, w.rm.org)
+	}
+	return w.ov
+}
+
+// Start the management of object versions in front of the embedded ESS, which must already be started.
+func (w *ResourceWorker) startObjectVersions() {
+	if w.ovStarted {
+		return
+	}
+	if err := w.ov.SetupHttpHandler(http.DefaultServeMux); err != nil {
+		glog.Errorf(reslog(fmt.Sprintf("Error setting up object versions, previous object versions will not be served: %v", err)))
+		return
+	}
+	w.ovStarted = true
+	w.DispatchSubworker(OBJECT_VERSIONS, w.ov.CheckObjects, int(w.Config.GetFSSObjectVersionCheckRate()), true)
+}
+
 // Handle events that are propogated to this worker from the internal event bus.
 func (w *ResourceWorker) NewEvent(incoming events.Message) {
 
@@ -152,7 +179,11 @@ func (w *ResourceWorker) handleNodeConfigCommand(cmd *NodeConfigCommand) error {
 		destinationType = "openhorizon/openhorizon.edgenode"
 	}
 	w.rm.NodeConfigUpdate(cmd.msg.Org(), destinationType, cmd.msg.DeviceId(), cmd.msg.Token(), cmd.msg.DeviceType())
-	return w.rm.StartFileSyncServiceAndSecretsAPI(w.am, w.db)
+	if err := w.rm.StartFileSyncServiceAndSecretsAPI(w.am, w.db, w.objectVersions()); err != nil {
+		return err
+	}
+	w.startObjectVersions()
+	return nil
 }
 
 // The node has just been unconfigured so we can stop the file sync service.
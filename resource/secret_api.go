@@ -25,7 +25,6 @@ import (
 	"fmt"
 	"github.com/golang/glog"
 	"github.com/open-horizon/anax/persistence"
-	bolt "go.etcd.io/bbolt"
 	"net/http"
 	"strconv"
 	"strings"
@@ -41,7 +40,7 @@ const (
 var unauthorizedBytes = []byte("Unauthorized")
 
 type SecretAPI struct {
-	db            *bolt.DB
+	db            persistence.AgentDatabase
 	authenticator *SecretsAPIAuthenticate
 }
 
@@ -52,7 +51,7 @@ type secretObject struct {
 	Value string `json:"value"`
 }
 
-func NewSecretAPI(db *bolt.DB, am *AuthenticationManager) *SecretAPI {
+func NewSecretAPI(db persistence.AgentDatabase, am *AuthenticationManager) *SecretAPI {
 	auth := &SecretsAPIAuthenticate{
 		AuthMgr: am,
 	}
@@ -12,7 +12,6 @@ import (
 	"github.com/open-horizon/anax/cutil"
 	"github.com/open-horizon/anax/persistence"
 	"github.com/open-horizon/anax/semanticversion"
-	bolt "go.etcd.io/bbolt"
 	"os"
 	"os/exec"
 	"os/user"
@@ -24,7 +23,7 @@ const VALUE_ONLY_FORMAT = "value_only"
 
 type SecretsManager struct {
 	config   *config.HorizonConfig
-	db       *bolt.DB
+	db       persistence.AgentDatabase
 	NodeType string
 }
 
@@ -33,7 +32,7 @@ type SecretObj struct {
 	Value string `json:"value"`
 }
 
-func NewSecretsManager(cfg *config.HorizonConfig, database *bolt.DB) *SecretsManager {
+func NewSecretsManager(cfg *config.HorizonConfig, database persistence.AgentDatabase) *SecretsManager {
 	nodeType := persistence.DEVICE_TYPE_DEVICE
 	if cfg.Edge.DockerEndpoint == "" {
 		nodeType = persistence.DEVICE_TYPE_CLUSTER
@@ -167,6 +166,37 @@ func (s SecretsManager) ProcessServiceSecretUpdates(agId string, updatedSecList
 	return nil
 }
 
+// Restore the previous version of the given secrets for the services in an agreement. This is used when the agbot halts
+// a staged secret rollout. Only the service and secret names are used from the input list. The restored secrets are
+// returned so that they can be pushed to the service on an edge cluster.
+func (s SecretsManager) RevertServiceSecretUpdates(agId string, revertSecList []persistence.PersistedServiceSecret) ([]persistence.PersistedServiceSecret, error) {
+	revertedSecs := make([]persistence.PersistedServiceSecret, 0)
+	for _, revertSec := range revertSecList {
+		existingSvcSecList, err := persistence.FindAllServiceSecretsWithSpecs(s.db, revertSec.SvcUrl, revertSec.SvcOrgid)
+		if err != nil {
+			return revertedSecs, err
+		}
+		for _, existingSvcSec := range existingSvcSecList {
+			if existingSec, ok := existingSvcSec.SecretsMap[revertSec.SvcSecretName]; !ok || !cutil.SliceContains(existingSec.AgreementIds, agId) {
+				continue
+			} else if revertedSec, err := persistence.RevertSecret(s.db, revertSec.SvcSecretName, existingSvcSec.MsInstKey); err != nil {
+				return revertedSecs, err
+			} else if revertedSec == nil {
+				glog.Warningf(secLogString(fmt.Sprintf("No previous version of secret %v for service instance %v to revert to.", revertSec.SvcSecretName, existingSvcSec.MsInstKey)))
+			} else {
+				glog.V(3).Infof(secLogString(fmt.Sprintf("Reverted secret %v for service instance %v to version %v.", revertSec.SvcSecretName, existingSvcSec.MsInstKey, revertedSec.SvcSecretVersion)))
+				if s.NodeType == persistence.DEVICE_TYPE_DEVICE {
+					if err := s.WriteExistingServiceSecretsToFile(existingSvcSec.MsInstKey, *revertedSec); err != nil {
+						return revertedSecs, err
+					}
+				}
+				revertedSecs = append(revertedSecs, *revertedSec)
+			}
+		}
+	}
+	return revertedSecs, nil
+}
+
 func (s SecretsManager) FindSecretsMatchingMsInst(allSecrets *[]persistence.PersistedServiceSecret, msInst persistence.MicroserviceInstInterface) (*[]persistence.PersistedServiceSecret, error) {
 	if allSecrets == nil {
 		return nil, nil
@@ -239,7 +269,7 @@ func (s SecretsManager) WriteExistingServiceSecretsToFile(msInstKey string, upda
 }
 
 // Remove this agId from all the secrets it is in. If it is the only agId for that secret, remove the secret
-func (s SecretsManager) DeleteAllSecForAgreement(db *bolt.DB, agreementId string) error {
+func (s SecretsManager) DeleteAllSecForAgreement(db persistence.AgentDatabase, agreementId string) error {
 	if allSec, err := persistence.FindAllServiceSecretsWithFilters(db, []persistence.SecFilter{}); err != nil {
 		return err
 	} else {
@@ -0,0 +1,71 @@
+package semanticversion
+
+import (
+	"errors"
+	"fmt"
+	"github.com/open-horizon/anax/i18n"
+	"strconv"
+	"strings"
+)
+
+// A numeric range uses the same interval syntax as a version range, but its bounds are numbers,
+// e.g. [1024,4096) means 1024 <= a < 4096 and (0.5,INFINITY) means 0.5 < a.
+type Numeric_Range struct {
+	full_expression string
+	start           float64
+	start_inclusive bool
+	end             float64
+	end_inclusive   bool
+	end_infinite    bool
+}
+
+func (nr Numeric_Range) String() string {
+	return fmt.Sprintf("Numeric Range: %v", nr.full_expression)
+}
+
+func Numeric_Range_Factory(range_string string) (*Numeric_Range, error) {
+
+	// get message printer
+	msgPrinter := i18n.GetMessagePrinter()
+
+	expr := range_string
+	if len(expr) < 2 {
+		return nil, errors.New(msgPrinter.Sprintf("Numeric_Range: %v is not a valid numeric range.", expr))
+	} else if strings.Contains(expr, " ") {
+		return nil, errors.New(msgPrinter.Sprintf("Numeric_Range: Whitespace is not permitted in %v.", expr))
+	} else if !(leftIncluded(expr) || leftExcluded(expr)) || !(rightIncluded(expr) || rightExcluded(expr)) {
+		return nil, errors.New(msgPrinter.Sprintf("Numeric_Range: %v must start with %v or %v and end with %v or %v.", expr, leftInc, leftEx, rightInc, rightEx))
+	}
+
+	bounds := strings.Split(expr[1:len(expr)-1], versionSeperator)
+	if len(bounds) != 2 {
+		return nil, errors.New(msgPrinter.Sprintf("Numeric_Range: %v must have a start and an end separated by %v.", expr, versionSeperator))
+	}
+
+	nr := &Numeric_Range{full_expression: expr, start_inclusive: leftIncluded(expr), end_inclusive: rightIncluded(expr)}
+
+	var err error
+	if nr.start, err = strconv.ParseFloat(bounds[0], 64); err != nil {
+		return nil, errors.New(msgPrinter.Sprintf("Numeric_Range: %v is not a valid number in %v.", bounds[0], expr))
+	}
+
+	if bounds[1] == INF {
+		nr.end_infinite = true
+	} else if nr.end, err = strconv.ParseFloat(bounds[1], 64); err != nil {
+		return nil, errors.New(msgPrinter.Sprintf("Numeric_Range: %v is not a valid number in %v.", bounds[1], expr))
+	} else if nr.end < nr.start {
+		return nil, errors.New(msgPrinter.Sprintf("Numeric_Range: the start of %v is greater than the end.", expr))
+	}
+
+	return nr, nil
+}
+
+// Return true if the input number is within the range.
+func (self *Numeric_Range) Contains(value float64) bool {
+	if value < self.start || (value == self.start && !self.start_inclusive) {
+		return false
+	} else if self.end_infinite {
+		return true
+	}
+	return value < self.end || (value == self.end && self.end_inclusive)
+}
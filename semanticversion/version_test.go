@@ -749,3 +749,22 @@ func TestCompareVersionsWithPrerelease(t *testing.T) {
 	assert.Nil(t, err, fmt.Sprintf("Error should be nil, but got:%v \n", err))
 	assert.Equal(t, 1, c, fmt.Sprintf("%v should be higher than %v.", v2, v1))
 }
+
+func Test_Numeric_Range(t *testing.T) {
+	nr, err := Numeric_Range_Factory("[1024,4096)")
+	assert.Nil(t, err, fmt.Sprintf("Error should be nil, but got:%v \n", err))
+	assert.True(t, nr.Contains(1024), "1024 should be in [1024,4096)")
+	assert.True(t, nr.Contains(2048.5), "2048.5 should be in [1024,4096)")
+	assert.False(t, nr.Contains(4096), "4096 should not be in [1024,4096)")
+	assert.False(t, nr.Contains(1023), "1023 should not be in [1024,4096)")
+
+	nr, err = Numeric_Range_Factory("(-1.5,INFINITY)")
+	assert.Nil(t, err, fmt.Sprintf("Error should be nil, but got:%v \n", err))
+	assert.False(t, nr.Contains(-1.5), "-1.5 should not be in (-1.5,INFINITY)")
+	assert.True(t, nr.Contains(1000000), "1000000 should be in (-1.5,INFINITY)")
+
+	for _, r := range []string{"", "[1024, 4096)", "1024,4096", "[4096,1024]", "[abc,4096)", "[1024]"} {
+		_, err = Numeric_Range_Factory(r)
+		assert.NotNil(t, err, fmt.Sprintf("%v should not be a valid numeric range", r))
+	}
+}
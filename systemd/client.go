@@ -0,0 +1,135 @@
+package systemd
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/golang/glog"
+)
+
+// Status object returned by our systemd client.
+type UnitStatus struct {
+	Name         string // Unit name
+	ActiveState  string // active, inactive, failed, activating, ...
+	SubState     string // running, dead, exited, ...
+	StartTime    uint64 // Unix time at which the unit entered the active state, 0 if it never did
+	LoadState    string // loaded, not-found, masked, ...
+	FragmentPath string // The unit file that the unit was loaded from
+}
+
+// The systemd client interface that we use, regardless of how its implemented under the covers.
+type SystemdClient interface {
+	DaemonReload() error
+	Start(unitName string) error
+	Stop(unitName string) error
+	Status(unitName string) (*UnitStatus, error)
+	CreateUser(userName string, homeDir string, groups []string) error
+	DeleteUser(userName string) error
+}
+
+func NewSystemdClient() SystemdClient {
+	return NewCliClient()
+}
+
+// This client implements our abstract systemd client interface, using the systemctl, useradd and userdel commands.
+type CliClient struct {
+}
+
+const ACTIVE = "active"
+
+// The load state of a unit that has no unit file.
+const NOT_FOUND = "not-found"
+
+// The format of the timestamps shown by systemctl. Golang requires the format string to be in reference to the specific time as shown.
+const SystemdTimestampFormat = "Mon 2006-01-02 15:04:05 MST"
+
+func NewCliClient() *CliClient {
+	return new(CliClient)
+}
+
+func (c *CliClient) DaemonReload() error {
+	_, err := run("systemctl", "daemon-reload")
+	return err
+}
+
+// Enable the unit so that it is restarted when the host reboots, and start it now.
+func (c *CliClient) Start(unitName string) error {
+	_, err := run("systemctl", "enable", "--now", unitFileName(unitName))
+	return err
+}
+
+// Stop the unit and disable it.
+func (c *CliClient) Stop(unitName string) error {
+	_, err := run("systemctl", "disable", "--now", unitFileName(unitName))
+	return err
+}
+
+func (c *CliClient) Status(unitName string) (*UnitStatus, error) {
+	out, err := run("systemctl", "show", "--property=ActiveState,SubState,ActiveEnterTimestamp,LoadState,FragmentPath", unitFileName(unitName))
+	if err != nil {
+		return nil, err
+	}
+	return parseUnitStatus(unitName, out), nil
+}
+
+// Create a system user without a login shell to run the unit.
+func (c *CliClient) CreateUser(userName string, homeDir string, groups []string) error {
+	args := []string{"--system", "--no-create-home", "--home-dir", homeDir, "--shell", "/usr/sbin/nologin", "--user-group"}
+	if len(groups) != 0 {
+		args = append(args, "--groups", strings.Join(groups, ","))
+	}
+	_, err := run("useradd", append(args, userName)...)
+	return err
+}
+
+func (c *CliClient) DeleteUser(userName string) error {
+	_, err := run("userdel", userName)
+	return err
+}
+
+// Parse the output of systemctl show, which is one property=value per line.
+func parseUnitStatus(unitName string, out string) *UnitStatus {
+	status := &UnitStatus{Name: unitName}
+	for _, line := range strings.Split(out, "\n") {
+		prop := strings.SplitN(strings.TrimSpace(line), "=", 2)
+		if len(prop) != 2 {
+			continue
+		}
+		switch prop[0] {
+		case "ActiveState":
+			status.ActiveState = prop[1]
+		case "SubState":
+			status.SubState = prop[1]
+		case "LoadState":
+			status.LoadState = prop[1]
+		case "FragmentPath":
+			status.FragmentPath = prop[1]
+		case "ActiveEnterTimestamp":
+			if t, err := time.Parse(SystemdTimestampFormat, prop[1]); err == nil {
+				status.StartTime = uint64(t.Unix())
+			}
+		}
+	}
+	return status
+}
+
+func run(name string, args ...string) (string, error) {
+	glog.V(5).Infof(clilogString(fmt.Sprintf("Running %v %v", name, strings.Join(args, " "))))
+	if out, err := exec.Command(name, args...).Output(); err != nil {
+		errMsg := ""
+		if exErr, ok := err.(*exec.ExitError); ok {
+			errMsg = string(exErr.Stderr)
+		}
+		return "", errors.New(fmt.Sprintf("error running %v %v: (%T) %v error message: %v", name, strings.Join(args, " "), err, err, errMsg))
+	} else {
+		glog.V(5).Infof(clilogString(fmt.Sprintf("Output from %v: %s", name, string(out))))
+		return string(out), nil
+	}
+}
+
+var clilogString = func(v interface{}) string {
+	return fmt.Sprintf("Systemd CliClient: %v", v)
+}
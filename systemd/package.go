@@ -0,0 +1,435 @@
+package systemd
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/user"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/golang/glog"
+	"github.com/open-horizon/anax/cutil"
+	"github.com/open-horizon/anax/persistence"
+)
+
+// The directory in which the unit files of services are installed.
+const UNIT_FILE_PATH = "/etc/systemd/system"
+
+// The name of the drop-in file that the agent adds to the unit of a service, to run it as the service user in
+// the install directory of the package.
+const DROP_IN_FILE = "horizon.conf"
+
+// The name of the environment file written to the install directory of the package. It holds the same HZN_*
+// variables that are given to service containers.
+const ENV_FILE = "horizon.env"
+
+// The prefix of the system user created for each agreement. The rest of the user name is derived from the agreement id.
+const SERVICE_USER_PREFIX = "hzn-"
+
+// Installs the package and unit file of a systemd service for an agreement, and removes them when the agreement ends.
+type Installer struct {
+	client      SystemdClient
+	unitPath    string // where the unit files are installed
+	servicePath string // the install directories of the packages are created under this path, one per agreement
+}
+
+func NewInstaller(client SystemdClient, unitPath string, servicePath string) *Installer {
+	return &Installer{
+		client:      client,
+		unitPath:    unitPath,
+		servicePath: servicePath,
+	}
+}
+
+// The settings that a unit file can use, by section. The agent runs the unit as the service user of the agreement,
+// the other settings could run the service, or some of its commands, as another user or with more privileges, give it
+// access to other files of the host, or make systemd start other units.
+var allowedUnitSettings = map[string][]string{
+	"Unit": {
+		"Description",
+		"Documentation",
+		"After",
+		"Before",
+		"StartLimitIntervalSec",
+		"StartLimitBurst",
+	},
+	"Service": {
+		"Type",
+		"ExecCondition",
+		"ExecStartPre",
+		"ExecStart",
+		"ExecStartPost",
+		"ExecReload",
+		"ExecStop",
+		"ExecStopPost",
+		"Restart",
+		"RestartSec",
+		"RestartPreventExitStatus",
+		"SuccessExitStatus",
+		"RemainAfterExit",
+		"TimeoutSec",
+		"TimeoutStartSec",
+		"TimeoutStopSec",
+		"RuntimeMaxSec",
+		"WatchdogSec",
+		"NotifyAccess",
+		"KillMode",
+		"KillSignal",
+		"Environment",
+		"StandardOutput",
+		"StandardError",
+		"SyslogIdentifier",
+		"UMask",
+		"NoNewPrivileges",
+		"PrivateTmp",
+		"ProtectSystem",
+		"ProtectHome",
+		"RuntimeDirectory",
+		"StateDirectory",
+		"CacheDirectory",
+		"LogsDirectory",
+	},
+	"Install": {
+		"WantedBy",
+	},
+}
+
+// The prefixes of the settings of the [Unit] section that only check a condition before the unit is started.
+var allowedUnitConditionPrefixes = []string{"Condition", "Assert"}
+
+// The values that the output settings can have. The other values make systemd open a file or a socket for the service.
+var allowedOutputValues = []string{"journal", "null", "inherit"}
+
+func unitSettingAllowed(section string, key string) bool {
+	if section == "Unit" {
+		for _, prefix := range allowedUnitConditionPrefixes {
+			if strings.HasPrefix(key, prefix) {
+				return true
+			}
+		}
+	}
+	for _, allowed := range allowedUnitSettings[section] {
+		if key == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+// The prefixes of the command of an Exec setting that make systemd run the command with full privileges, instead of
+// as the service user.
+const privilegedExecPrefixes = "+!"
+
+// All the prefixes that can precede the command of an Exec setting.
+const execPrefixes = "@-:+!|"
+
+// Check that the unit file runs the service with the privileges given to it by the agent's drop-in file.
+func validateUnitFile(unitFile string) error {
+	content, err := os.ReadFile(unitFile)
+	if err != nil {
+		return err
+	}
+
+	// Lines that end with a backslash are continued on the next line.
+	lines := strings.Split(strings.ReplaceAll(strings.ReplaceAll(string(content), "\r\n", "\n"), "\\\n", " "), "\n")
+	section := ""
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+		if strings.HasPrefix(line, ".include") {
+			return errors.New(fmt.Sprintf("the unit file cannot include other files: %v", line))
+		}
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			section = strings.TrimSpace(line[1 : len(line)-1])
+			if _, ok := allowedUnitSettings[section]; !ok {
+				return errors.New(fmt.Sprintf("the unit file cannot have a %v section, only the [Unit], [Service] and [Install] sections are allowed", line))
+			}
+			continue
+		}
+
+		key, value, found := strings.Cut(line, "=")
+		if !found {
+			return errors.New(fmt.Sprintf("the unit file has an invalid line: %v", line))
+		}
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+
+		if section == "" {
+			return errors.New(fmt.Sprintf("the %v setting of the unit file is not in a section", key))
+		} else if !unitSettingAllowed(section, key) {
+			return errors.New(fmt.Sprintf("the unit file cannot use the %v setting in the [%v] section, the agent sets the user and the privileges of the service", key, section))
+		}
+		if key == "StandardOutput" || key == "StandardError" {
+			if !cutil.SliceContains(allowedOutputValues, value) {
+				return errors.New(fmt.Sprintf("the %v setting of the unit file must be one of %v: %v", key, strings.Join(allowedOutputValues, ", "), value))
+			}
+		}
+		if strings.HasPrefix(key, "Exec") {
+			prefix := value[:len(value)-len(strings.TrimLeft(value, execPrefixes))]
+			if strings.ContainsAny(prefix, privilegedExecPrefixes) {
+				return errors.New(fmt.Sprintf("the command of the %v setting cannot use the '+' or '!' prefix, it would run with full privileges: %v", key, value))
+			}
+		}
+	}
+	return nil
+}
+
+func unitFileName(unitName string) string {
+	return unitName + ".service"
+}
+
+// The user names of Linux are at most 32 characters, a prefix of the agreement id hash keeps the name short and unique.
+func ServiceUserName(agreementId string) string {
+	return SERVICE_USER_PREFIX + cutil.GetHashFromString(agreementId)[:12]
+}
+
+func (i *Installer) GetInstallDir(agreementId string) string {
+	return path.Join(i.servicePath, agreementId)
+}
+
+func (i *Installer) unitFilePath(unitName string) string {
+	return path.Join(i.unitPath, unitFileName(unitName))
+}
+
+func (i *Installer) dropInPath(unitName string) string {
+	return path.Join(i.unitPath, unitFileName(unitName)+".d", DROP_IN_FILE)
+}
+
+// Returns true if the unit file was installed for the given agreement. The agreement id is recorded in the drop-in file.
+func (i *Installer) ownsUnit(agreementId string, unitName string) bool {
+	if content, err := os.ReadFile(i.dropInPath(unitName)); err != nil {
+		return false
+	} else {
+		return strings.Contains(string(content), dropInHeader(agreementId))
+	}
+}
+
+// Install the package and the unit file downloaded for the agreement, create the service user and start the unit. The
+// groups are supplementary groups of the service user, they give the service access to its ESS credentials and secrets.
+func (i *Installer) Install(agreementId string, sd *persistence.SystemdDeploymentConfig, packageFile string, unitFile string, env map[string]string, groups []string) error {
+
+	if err := validateUnitFile(unitFile); err != nil {
+		return errors.New(fmt.Sprintf("unit file %v cannot be installed, error: %v", sd.UnitObject, err))
+	}
+
+	// Don't replace or shadow a unit that was not installed for this agreement. The unit can be loaded from another
+	// directory than the one the agent installs units in, e.g. /usr/lib/systemd/system.
+	if !i.ownsUnit(agreementId, sd.UnitName) {
+		if _, err := os.Stat(i.unitFilePath(sd.UnitName)); err == nil {
+			return errors.New(fmt.Sprintf("unit %v is already installed on the host", unitFileName(sd.UnitName)))
+		} else if status, err := i.client.Status(sd.UnitName); err != nil {
+			return errors.New(fmt.Sprintf("unable to check if unit %v is installed on the host, error: %v", unitFileName(sd.UnitName), err))
+		} else if status.LoadState != "" && status.LoadState != NOT_FOUND {
+			return errors.New(fmt.Sprintf("unit %v is already %v on the host from %v", unitFileName(sd.UnitName), status.LoadState, status.FragmentPath))
+		}
+	}
+
+	installDir := i.GetInstallDir(agreementId)
+	if err := os.RemoveAll(installDir); err != nil {
+		return errors.New(fmt.Sprintf("unable to clean install directory %v, error: %v", installDir, err))
+	} else if err := os.MkdirAll(installDir, 0755); err != nil {
+		return errors.New(fmt.Sprintf("unable to create install directory %v, error: %v", installDir, err))
+	}
+
+	if isTarball(sd.PackageObject) {
+		if err := extractTarball(packageFile, installDir); err != nil {
+			return errors.New(fmt.Sprintf("unable to extract package %v, error: %v", sd.PackageObject, err))
+		}
+	} else if err := copyFile(packageFile, path.Join(installDir, path.Base(sd.PackageObject)), 0755); err != nil {
+		return errors.New(fmt.Sprintf("unable to install package %v, error: %v", sd.PackageObject, err))
+	}
+
+	// The environment file is read by systemd, not by the service user.
+	if err := os.WriteFile(path.Join(installDir, ENV_FILE), []byte(envFileContent(env)), 0600); err != nil {
+		return errors.New(fmt.Sprintf("unable to write environment file for unit %v, error: %v", sd.UnitName, err))
+	}
+
+	// The user is left over from a previous attempt when the install is retried.
+	userName := ServiceUserName(agreementId)
+	if !userExists(userName) {
+		if err := i.client.CreateUser(userName, installDir, groups); err != nil {
+			return errors.New(fmt.Sprintf("unable to create user %v for unit %v, error: %v", userName, sd.UnitName, err))
+		}
+	}
+
+	// The drop-in is written first, it marks the unit as installed for this agreement.
+	if err := os.MkdirAll(path.Dir(i.dropInPath(sd.UnitName)), 0755); err != nil {
+		return errors.New(fmt.Sprintf("unable to create drop-in directory for unit %v, error: %v", sd.UnitName, err))
+	} else if err := os.WriteFile(i.dropInPath(sd.UnitName), []byte(dropInContent(agreementId, userName, installDir)), 0644); err != nil {
+		return errors.New(fmt.Sprintf("unable to write drop-in file for unit %v, error: %v", sd.UnitName, err))
+	} else if err := copyFile(unitFile, i.unitFilePath(sd.UnitName), 0644); err != nil {
+		return errors.New(fmt.Sprintf("unable to install unit file %v, error: %v", unitFileName(sd.UnitName), err))
+	}
+
+	if err := i.client.DaemonReload(); err != nil {
+		return err
+	} else if err := i.client.Start(sd.UnitName); err != nil {
+		return err
+	}
+
+	glog.V(3).Infof(sdlog(fmt.Sprintf("installed unit %v for agreement %v in %v", sd.UnitName, agreementId, installDir)))
+	return nil
+}
+
+// Stop the unit and remove everything that was installed for the agreement. All the steps are attempted, the first
+// error is returned.
+func (i *Installer) UnInstall(agreementId string, sd *persistence.SystemdDeploymentConfig) error {
+
+	var firstErr error
+	record := func(err error) {
+		if err != nil {
+			glog.Errorf(sdlog(err))
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+
+	if i.ownsUnit(agreementId, sd.UnitName) {
+		if err := i.client.Stop(sd.UnitName); err != nil {
+			record(err)
+		}
+		if err := os.Remove(i.unitFilePath(sd.UnitName)); err != nil && !os.IsNotExist(err) {
+			record(errors.New(fmt.Sprintf("unable to remove unit file %v, error: %v", unitFileName(sd.UnitName), err)))
+		}
+		if err := os.RemoveAll(path.Dir(i.dropInPath(sd.UnitName))); err != nil {
+			record(errors.New(fmt.Sprintf("unable to remove drop-in directory of unit %v, error: %v", sd.UnitName, err)))
+		}
+		record(i.client.DaemonReload())
+	} else {
+		glog.V(3).Infof(sdlog(fmt.Sprintf("unit %v is not installed for agreement %v, leaving it in place", sd.UnitName, agreementId)))
+	}
+
+	if userName := ServiceUserName(agreementId); userExists(userName) {
+		record(i.client.DeleteUser(userName))
+	}
+
+	if err := os.RemoveAll(i.GetInstallDir(agreementId)); err != nil {
+		record(errors.New(fmt.Sprintf("unable to remove install directory %v, error: %v", i.GetInstallDir(agreementId), err)))
+	}
+
+	return firstErr
+}
+
+// The first line of the drop-in file identifies the agreement that installed the unit.
+func dropInHeader(agreementId string) string {
+	return fmt.Sprintf("# Installed by the Horizon agent for agreement %v", agreementId)
+}
+
+func dropInContent(agreementId string, userName string, installDir string) string {
+	lines := []string{
+		dropInHeader(agreementId),
+		"[Service]",
+		"User=" + userName,
+		"WorkingDirectory=" + installDir,
+		"EnvironmentFile=" + path.Join(installDir, ENV_FILE),
+		"",
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Write the variables in the format of a systemd environment file, sorted so that the file is stable.
+func envFileContent(env map[string]string) string {
+	names := make([]string, 0, len(env))
+	for name := range env {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	for _, name := range names {
+		b.WriteString(fmt.Sprintf("%v=%v\n", name, strconv.Quote(env[name])))
+	}
+	return b.String()
+}
+
+func isTarball(objectId string) bool {
+	return strings.HasSuffix(objectId, ".tar.gz") || strings.HasSuffix(objectId, ".tgz")
+}
+
+// Extract a gzipped tarball into the install directory. Entries that would land outside of the directory are rejected.
+func extractTarball(tarFile string, installDir string) error {
+	f, err := os.Open(tarFile)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return err
+	}
+	defer gz.Close()
+
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		target := filepath.Join(installDir, hdr.Name)
+		if target != filepath.Clean(installDir) && !strings.HasPrefix(target, filepath.Clean(installDir)+string(os.PathSeparator)) {
+			return errors.New(fmt.Sprintf("tarball entry %v is outside of the install directory", hdr.Name))
+		}
+
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return err
+			}
+			out, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, os.FileMode(hdr.Mode).Perm()&0755)
+			if err != nil {
+				return err
+			}
+			_, err = io.Copy(out, tr)
+			out.Close()
+			if err != nil {
+				return err
+			}
+		default:
+			glog.Warningf(sdlog(fmt.Sprintf("skipping tarball entry %v of type %v", hdr.Name, string(hdr.Typeflag))))
+		}
+	}
+}
+
+func userExists(userName string) bool {
+	_, err := user.Lookup(userName)
+	return err == nil
+}
+
+func copyFile(src string, dest string, mode os.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dest, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+var sdlog = func(v interface{}) string {
+	return fmt.Sprintf("Systemd Installer: %v", v)
+}
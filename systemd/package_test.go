@@ -0,0 +1,313 @@
+//go:build unit
+// +build unit
+
+package systemd
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"os"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/open-horizon/anax/persistence"
+)
+
+type fakeClient struct {
+	started   []string
+	stopped   []string
+	reloads   int
+	users     []string
+	userGroup []string
+	loaded    map[string]string // the units of the host, and the unit files they are loaded from
+}
+
+func (f *fakeClient) DaemonReload() error {
+	f.reloads++
+	return nil
+}
+
+func (f *fakeClient) Start(unitName string) error {
+	f.started = append(f.started, unitName)
+	return nil
+}
+
+func (f *fakeClient) Stop(unitName string) error {
+	f.stopped = append(f.stopped, unitName)
+	return nil
+}
+
+func (f *fakeClient) Status(unitName string) (*UnitStatus, error) {
+	if fragmentPath, ok := f.loaded[unitName]; ok {
+		return &UnitStatus{Name: unitName, ActiveState: ACTIVE, SubState: "running", LoadState: "loaded", FragmentPath: fragmentPath}, nil
+	}
+	for _, started := range f.started {
+		if started == unitName {
+			return &UnitStatus{Name: unitName, ActiveState: ACTIVE, SubState: "running", LoadState: "loaded"}, nil
+		}
+	}
+	return &UnitStatus{Name: unitName, ActiveState: "inactive", SubState: "dead", LoadState: NOT_FOUND}, nil
+}
+
+func (f *fakeClient) CreateUser(userName string, homeDir string, groups []string) error {
+	f.users = append(f.users, userName)
+	f.userGroup = groups
+	return nil
+}
+
+func (f *fakeClient) DeleteUser(userName string) error {
+	return nil
+}
+
+func writeTarball(t *testing.T, fileName string, files map[string]string) {
+	f, err := os.Create(fileName)
+	if err != nil {
+		t.Fatalf("unable to create tarball %v, error: %v", fileName, err)
+	}
+	defer f.Close()
+
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+	for name, content := range files {
+		hdr := &tar.Header{Name: name, Mode: 0755, Size: int64(len(content)), Typeflag: tar.TypeReg}
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatalf("unable to write tarball header, error: %v", err)
+		} else if _, err := tw.Write([]byte(content)); err != nil {
+			t.Fatalf("unable to write tarball entry, error: %v", err)
+		}
+	}
+	tw.Close()
+	gz.Close()
+}
+
+func setupInstaller(t *testing.T) (*Installer, *fakeClient, string) {
+	dir := t.TempDir()
+	unitPath := path.Join(dir, "units")
+	if err := os.MkdirAll(unitPath, 0755); err != nil {
+		t.Fatalf("unable to create unit directory, error: %v", err)
+	}
+
+	unitFile := path.Join(dir, "hello.service")
+	if err := os.WriteFile(unitFile, []byte("[Service]\nExecStart=hello\n"), 0644); err != nil {
+		t.Fatalf("unable to write unit file, error: %v", err)
+	}
+
+	client := new(fakeClient)
+	return NewInstaller(client, unitPath, path.Join(dir, "services")), client, dir
+}
+
+func Test_InstallTarball(t *testing.T) {
+
+	installer, client, dir := setupInstaller(t)
+
+	packageFile := path.Join(dir, "hello-1.0.0.tar.gz")
+	writeTarball(t, packageFile, map[string]string{"bin/hello": "#!/bin/sh\n"})
+
+	sd := persistence.NewSystemdDeployment("hello", "systemd-package", "hello-1.0.0.tar.gz", "hello.service")
+	env := map[string]string{"HZN_AGREEMENTID": "ag1", "HZN_ORGANIZATION": "myorg"}
+
+	if err := installer.Install("ag1", sd, packageFile, path.Join(dir, "hello.service"), env, []string{"group1"}); err != nil {
+		t.Fatalf("unexpected error installing %v, error: %v", sd, err)
+	}
+
+	installDir := installer.GetInstallDir("ag1")
+	if _, err := os.Stat(path.Join(installDir, "bin", "hello")); err != nil {
+		t.Errorf("package was not extracted, error: %v", err)
+	}
+	if content, err := os.ReadFile(path.Join(installDir, ENV_FILE)); err != nil {
+		t.Errorf("environment file was not written, error: %v", err)
+	} else if !strings.Contains(string(content), "HZN_ORGANIZATION=\"myorg\"") {
+		t.Errorf("environment file is missing a variable: %v", string(content))
+	}
+	if content, err := os.ReadFile(installer.dropInPath("hello")); err != nil {
+		t.Errorf("drop-in file was not written, error: %v", err)
+	} else if !strings.Contains(string(content), "User="+ServiceUserName("ag1")) {
+		t.Errorf("drop-in file does not set the user: %v", string(content))
+	}
+	if len(client.started) != 1 || client.started[0] != "hello" {
+		t.Errorf("unit was not started: %v", client.started)
+	} else if len(client.users) != 1 || len(client.userGroup) != 1 {
+		t.Errorf("service user was not created with its groups: %v %v", client.users, client.userGroup)
+	}
+
+	// Remove the unit and the package.
+	if err := installer.UnInstall("ag1", sd); err != nil {
+		t.Errorf("unexpected error uninstalling %v, error: %v", sd, err)
+	}
+	if _, err := os.Stat(installer.unitFilePath("hello")); !os.IsNotExist(err) {
+		t.Errorf("unit file was not removed")
+	} else if _, err := os.Stat(path.Dir(installer.dropInPath("hello"))); !os.IsNotExist(err) {
+		t.Errorf("drop-in directory was not removed")
+	} else if _, err := os.Stat(installDir); !os.IsNotExist(err) {
+		t.Errorf("install directory was not removed")
+	} else if len(client.stopped) != 1 {
+		t.Errorf("unit was not stopped: %v", client.stopped)
+	}
+}
+
+func Test_InstallBinary(t *testing.T) {
+
+	installer, _, dir := setupInstaller(t)
+
+	packageFile := path.Join(dir, "hello")
+	if err := os.WriteFile(packageFile, []byte("binary"), 0644); err != nil {
+		t.Fatalf("unable to write package file, error: %v", err)
+	}
+
+	sd := persistence.NewSystemdDeployment("hello", "systemd-package", "hello", "hello.service")
+	if err := installer.Install("ag1", sd, packageFile, path.Join(dir, "hello.service"), nil, nil); err != nil {
+		t.Fatalf("unexpected error installing %v, error: %v", sd, err)
+	}
+
+	if fi, err := os.Stat(path.Join(installer.GetInstallDir("ag1"), "hello")); err != nil {
+		t.Errorf("binary was not installed, error: %v", err)
+	} else if fi.Mode().Perm()&0100 == 0 {
+		t.Errorf("binary is not executable, mode %v", fi.Mode())
+	}
+}
+
+func Test_InstallConflictingUnit(t *testing.T) {
+
+	installer, client, dir := setupInstaller(t)
+
+	// A unit of the same name that was not installed by the agent.
+	if err := os.WriteFile(installer.unitFilePath("hello"), []byte("[Service]\n"), 0644); err != nil {
+		t.Fatalf("unable to write unit file, error: %v", err)
+	}
+
+	sd := persistence.NewSystemdDeployment("hello", "systemd-package", "hello", "hello.service")
+	if err := installer.Install("ag1", sd, path.Join(dir, "hello.service"), path.Join(dir, "hello.service"), nil, nil); err == nil {
+		t.Errorf("expected an error installing over an existing unit")
+	}
+
+	// The unit of the host is left in place.
+	if err := installer.UnInstall("ag1", sd); err != nil {
+		t.Errorf("unexpected error uninstalling %v, error: %v", sd, err)
+	} else if _, err := os.Stat(installer.unitFilePath("hello")); err != nil {
+		t.Errorf("unit file of the host was removed")
+	} else if len(client.stopped) != 0 {
+		t.Errorf("unit of the host was stopped: %v", client.stopped)
+	}
+}
+
+func Test_InstallLoadedUnit(t *testing.T) {
+
+	installer, client, dir := setupInstaller(t)
+
+	// A unit of the same name that is loaded from another directory of the host.
+	client.loaded = map[string]string{"hello": "/usr/lib/systemd/system/hello.service"}
+
+	sd := persistence.NewSystemdDeployment("hello", "systemd-package", "hello", "hello.service")
+	if err := installer.Install("ag1", sd, path.Join(dir, "hello.service"), path.Join(dir, "hello.service"), nil, nil); err == nil {
+		t.Errorf("expected an error installing over a loaded unit")
+	} else if !strings.Contains(err.Error(), "/usr/lib/systemd/system/hello.service") {
+		t.Errorf("the error should name the unit file of the host: %v", err)
+	} else if _, err := os.Stat(installer.unitFilePath("hello")); !os.IsNotExist(err) {
+		t.Errorf("unit file was installed over a loaded unit")
+	} else if len(client.started) != 0 {
+		t.Errorf("unit was started: %v", client.started)
+	}
+}
+
+func Test_ExtractTarballOutsideDir(t *testing.T) {
+
+	dir := t.TempDir()
+	packageFile := path.Join(dir, "evil.tar.gz")
+	writeTarball(t, packageFile, map[string]string{"../evil": "evil"})
+
+	if err := extractTarball(packageFile, path.Join(dir, "install")); err == nil {
+		t.Errorf("expected an error extracting an entry outside of the install directory")
+	} else if _, err := os.Stat(path.Join(dir, "evil")); !os.IsNotExist(err) {
+		t.Errorf("entry outside of the install directory was written")
+	}
+}
+
+func Test_envFileContent(t *testing.T) {
+
+	env := map[string]string{"B": "two words", "A": "say \"hi\""}
+	expected := "A=\"say \\\"hi\\\"\"\nB=\"two words\"\n"
+	if content := envFileContent(env); content != expected {
+		t.Errorf("expected %v, got %v", expected, content)
+	}
+}
+
+func Test_parseUnitStatus(t *testing.T) {
+
+	out := "ActiveState=active\nSubState=running\nActiveEnterTimestamp=Mon 2023-01-02 15:04:05 UTC\n"
+	status := parseUnitStatus("hello", out)
+	if status.ActiveState != ACTIVE || status.SubState != "running" {
+		t.Errorf("unexpected status %v", status)
+	} else if status.StartTime != 1672671845 {
+		t.Errorf("unexpected start time %v", status.StartTime)
+	} else if IsUnitFailed(status) {
+		t.Errorf("active unit should not be failed")
+	}
+
+	status = parseUnitStatus("hello", "ActiveState=failed\nSubState=failed\nActiveEnterTimestamp=\n")
+	if status.StartTime != 0 {
+		t.Errorf("unexpected start time %v", status.StartTime)
+	} else if !IsUnitFailed(status) {
+		t.Errorf("failed unit should be failed")
+	}
+}
+
+func Test_ServiceUserName(t *testing.T) {
+
+	agId := strings.Repeat("a", 64)
+	if name := ServiceUserName(agId); len(name) > 32 || !strings.HasPrefix(name, SERVICE_USER_PREFIX) {
+		t.Errorf("invalid user name %v", name)
+	} else if name == ServiceUserName(strings.Repeat("b", 64)) {
+		t.Errorf("user names of different agreements should be different")
+	}
+}
+
+func Test_InstallPrivilegedUnit(t *testing.T) {
+
+	installer, client, dir := setupInstaller(t)
+
+	packageFile := path.Join(dir, "hello")
+	if err := os.WriteFile(packageFile, []byte("binary"), 0644); err != nil {
+		t.Fatalf("unable to write package file, error: %v", err)
+	}
+	sd := persistence.NewSystemdDeployment("hello", "systemd-package", "hello", "hello.service")
+
+	units := []string{
+		"[Service]\nExecStart=hello\nUser=root\n",
+		"[Service]\nExecStart=hello\nGroup = docker\n",
+		"[Service]\nExecStart=+/bin/sh -c 'id'\n",
+		"[Service]\nExecStartPre=-!/bin/chown root /tmp/x\nExecStart=hello\n",
+		"[Service]\nExecStart=hello\nExecStopPost=@+/bin/sh \\\n  sh -c 'id'\n",
+		"[Service]\nPermissionsStartOnly=true\nExecStartPre=/bin/chown root /tmp/x\nExecStart=hello\n",
+		"[Service]\nExecStart=hello\nAmbientCapabilities=CAP_SYS_ADMIN\n",
+		"[Service]\nExecStart=hello\nSupplementaryGroups=\\\n  docker\n",
+		"[Service]\nExecStart=hello\nEnvironmentFile=/etc/shadow\n",
+		"[Service]\nExecStart=hello\nStandardOutput=file:/etc/motd\n",
+		"[Service]\nExecStart=hello\nCapabilityBoundingSet=CAP_NET_ADMIN\n",
+		"[Unit]\nOnFailure=reboot.target\n[Service]\nExecStart=hello\n",
+		"[Service]\nExecStart=hello\n[Socket]\nListenStream=80\n",
+		"ExecStart=hello\n",
+	}
+	for _, unit := range units {
+		unitFile := path.Join(dir, "hello.service")
+		if err := os.WriteFile(unitFile, []byte(unit), 0644); err != nil {
+			t.Fatalf("unable to write unit file, error: %v", err)
+		}
+		if err := installer.Install("ag1", sd, packageFile, unitFile, nil, nil); err == nil {
+			t.Errorf("expected an error installing unit %v", unit)
+		} else if _, err := os.Stat(installer.unitFilePath("hello")); !os.IsNotExist(err) {
+			t.Errorf("unit %v was installed", unit)
+		}
+	}
+	if len(client.started) != 0 || len(client.users) != 0 {
+		t.Errorf("a privileged unit was started: %v %v", client.started, client.users)
+	}
+
+	// The allowed settings, the other prefixes and a comment that mentions a forbidden setting are fine.
+	unit := "[Unit]\nDescription=hello\nConditionPathExists=/dev/ttyUSB0\n[Service]\n# User=root is set by the agent\nExecStartPre=-/bin/true\nExecStart=:@hello hello\nStandardOutput=journal\n[Install]\nWantedBy=multi-user.target\n"
+	if err := os.WriteFile(path.Join(dir, "hello.service"), []byte(unit), 0644); err != nil {
+		t.Fatalf("unable to write unit file, error: %v", err)
+	} else if err := installer.Install("ag1", sd, packageFile, path.Join(dir, "hello.service"), nil, nil); err != nil {
+		t.Errorf("unexpected error installing unit %v, error: %v", unit, err)
+	}
+}
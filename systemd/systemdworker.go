@@ -0,0 +1,341 @@
+package systemd
+
+import (
+	"crypto"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+
+	"github.com/golang/glog"
+	"github.com/open-horizon/anax/config"
+	"github.com/open-horizon/anax/cutil"
+	"github.com/open-horizon/anax/events"
+	"github.com/open-horizon/anax/exchange"
+	"github.com/open-horizon/anax/persistence"
+	"github.com/open-horizon/anax/resource"
+	"github.com/open-horizon/anax/worker"
+)
+
+// The directory under the systemd service path into which the MMS objects of a service are downloaded.
+const DOWNLOAD_DIR = ".download"
+
+type SystemdWorker struct {
+	worker.BaseWorker // embedded field
+	db                persistence.AgentDatabase
+	secretMgr         *resource.SecretsManager
+	installer         *Installer
+}
+
+func NewSystemdWorker(name string, cfg *config.HorizonConfig, db persistence.AgentDatabase, sm *resource.SecretsManager) *SystemdWorker {
+
+	// do not start this worker if the the node is registered and the type is cluster
+	dev, _ := persistence.FindExchangeDevice(db)
+	if dev != nil && dev.GetNodeType() == persistence.DEVICE_TYPE_CLUSTER {
+		return nil
+	}
+
+	worker := &SystemdWorker{
+		BaseWorker: worker.NewBaseWorker(name, cfg, getEC(cfg, db)),
+		db:         db,
+		secretMgr:  sm,
+		installer:  NewInstaller(NewSystemdClient(), UNIT_FILE_PATH, cfg.GetSystemdServicePath()),
+	}
+
+	glog.Info(sdwlog(fmt.Sprintf("Starting Systemd worker")))
+	worker.Start(worker, 0)
+	return worker
+}
+
+func (w *SystemdWorker) Messages() chan events.Message {
+	return w.BaseWorker.Manager.Messages
+}
+
+func (w *SystemdWorker) NewEvent(incoming events.Message) {
+
+	switch incoming.(type) {
+	case *events.AgreementReachedMessage:
+		msg, _ := incoming.(*events.AgreementReachedMessage)
+
+		fCmd := NewInstallCommand(msg.LaunchContext())
+		w.Commands <- fCmd
+
+	case *events.GovernanceWorkloadCancelationMessage:
+		msg, _ := incoming.(*events.GovernanceWorkloadCancelationMessage)
+
+		switch msg.Event().Id {
+		case events.AGREEMENT_ENDED:
+			cmd := NewUnInstallCommand(msg.AgreementProtocol, msg.AgreementId, msg.Deployment)
+			w.Commands <- cmd
+		}
+
+	case *events.GovernanceMaintenanceMessage:
+		msg, _ := incoming.(*events.GovernanceMaintenanceMessage)
+
+		switch msg.Event().Id {
+		case events.CONTAINER_MAINTAIN:
+			cmd := NewMaintenanceCommand(msg.AgreementProtocol, msg.AgreementId, msg.Deployment)
+			w.Commands <- cmd
+		}
+
+	case *events.EdgeRegisteredExchangeMessage:
+		msg, _ := incoming.(*events.EdgeRegisteredExchangeMessage)
+
+		switch msg.Event().Id {
+		case events.NEW_DEVICE_REG:
+			cmd := NewNodeRegisteredCommand(msg)
+			w.Commands <- cmd
+		}
+
+	case *events.NodeShutdownCompleteMessage:
+		msg, _ := incoming.(*events.NodeShutdownCompleteMessage)
+		switch msg.Event().Id {
+		case events.UNCONFIGURE_COMPLETE:
+			w.Commands <- worker.NewTerminateCommand("shutdown")
+		}
+
+	default: //nothing
+
+	}
+
+	return
+}
+
+func (w *SystemdWorker) CommandHandler(command worker.Command) bool {
+
+	switch command.(type) {
+	case *InstallCommand:
+
+		cmd := command.(*InstallCommand)
+		if lc := w.getLaunchContext(cmd.LaunchContext); lc == nil {
+			glog.Errorf(sdwlog(fmt.Sprintf("incoming event was not a known launch context: %T", cmd.LaunchContext)))
+		} else {
+			glog.V(5).Infof(sdwlog(fmt.Sprintf("LaunchContext(%T) for agreement: %v", lc, lc.AgreementId)))
+
+			// Check the deployment string to see if it's a systemd deployment.
+			deploymentConfig := lc.ContainerConfig().Deployment
+			if sd, err := persistence.GetSystemdDeployment(deploymentConfig); err != nil {
+				glog.V(5).Infof(sdwlog(fmt.Sprintf("ignoring non-systemd deployment: %v", err)))
+				return true
+			} else if _, err := persistence.AgreementDeploymentStarted(w.db, lc.AgreementId, lc.AgreementProtocol, sd); err != nil {
+				glog.Errorf(sdwlog(fmt.Sprintf("received error updating database deployment state, %v", err)))
+				w.Messages() <- events.NewWorkloadMessage(events.EXECUTION_FAILED, lc.AgreementProtocol, lc.AgreementId, sd)
+				return true
+			} else if err := w.processSystemdPackage(lc, sd); err != nil {
+				glog.Errorf(sdwlog(fmt.Sprintf("failed to install systemd service after agreement negotiation: %v", err)))
+				w.Messages() <- events.NewWorkloadMessage(events.EXECUTION_FAILED, lc.AgreementProtocol, lc.AgreementId, sd)
+				return true
+			} else {
+				w.Messages() <- events.NewWorkloadMessage(events.EXECUTION_BEGUN, lc.AgreementProtocol, lc.AgreementId, sd)
+			}
+
+		}
+
+	case *UnInstallCommand:
+
+		cmd := command.(*UnInstallCommand)
+
+		// Make sure it's a systemd deployment.
+		sdc, ok := cmd.Deployment.(*persistence.SystemdDeploymentConfig)
+		if !ok {
+			glog.V(5).Infof(sdwlog(fmt.Sprintf("ignoring non-systemd deployment: %v", cmd.Deployment)))
+			return true
+		}
+
+		glog.V(3).Infof(sdwlog(fmt.Sprintf("uninstalling %v for agreement %v", sdc, cmd.CurrentAgreementId)))
+		if err := w.installer.UnInstall(cmd.CurrentAgreementId, sdc); err != nil {
+			glog.Errorf(sdwlog(fmt.Sprintf("failed to uninstall systemd service after agreement cancellation: %v", err)))
+		}
+
+		w.Messages() <- events.NewWorkloadMessage(events.WORKLOAD_DESTROYED, cmd.AgreementProtocol, cmd.CurrentAgreementId, sdc)
+
+	case *MaintenanceCommand:
+		cmd := command.(*MaintenanceCommand)
+
+		sdc, ok := cmd.Deployment.(*persistence.SystemdDeploymentConfig)
+		if !ok {
+			return true
+		}
+
+		glog.V(3).Infof(sdwlog(fmt.Sprintf("received maintenance command: %v", cmd)))
+		if status, err := NewSystemdClient().Status(sdc.UnitName); err != nil {
+			glog.Errorf(sdwlog(fmt.Sprintf("unable to get the status of unit %v, error: %v", sdc.UnitName, err)))
+		} else if IsUnitFailed(status) {
+			glog.Errorf(sdwlog(fmt.Sprintf("unit %v for agreement %v is not running, state %v/%v", sdc.UnitName, cmd.AgreementId, status.ActiveState, status.SubState)))
+			// Ask governer to cancel the agreement.
+			w.Messages() <- events.NewWorkloadMessage(events.EXECUTION_FAILED, cmd.AgreementProtocol, cmd.AgreementId, sdc)
+		}
+
+	case *NodeRegisteredCommand:
+		w.EC = getEC(w.Config, w.db)
+
+	default:
+		return false
+	}
+	return true
+
+}
+
+func (w *SystemdWorker) getLaunchContext(launchContext interface{}) *events.AgreementLaunchContext {
+	switch launchContext.(type) {
+	case *events.AgreementLaunchContext:
+		lc := launchContext.(*events.AgreementLaunchContext)
+		return lc
+	}
+	return nil
+}
+
+// Download the package and unit file of the service, then install and start the unit.
+func (w *SystemdWorker) processSystemdPackage(lc *events.AgreementLaunchContext, sd *persistence.SystemdDeploymentConfig) error {
+
+	glog.V(5).Infof(sdwlog(fmt.Sprintf("begin install of systemd unit %v", sd.UnitName)))
+
+	// The objects default to the org of the service.
+	org := sd.ObjectOrg
+	if org == "" {
+		if ags, err := persistence.FindEstablishedAgreements(w.db, lc.AgreementProtocol, []persistence.EAFilter{persistence.UnarchivedEAFilter(), persistence.IdEAFilter(lc.AgreementId)}); err != nil {
+			return errors.New(fmt.Sprintf("unable to retrieve agreement %v from database, error %v", lc.AgreementId, err))
+		} else if len(ags) != 1 {
+			return errors.New(fmt.Sprintf("unable to find agreement %v in the database", lc.AgreementId))
+		} else {
+			org = ags[0].RunningWorkload.Org
+		}
+	}
+
+	downloadDir := path.Join(w.Config.GetSystemdServicePath(), DOWNLOAD_DIR, lc.AgreementId)
+	defer os.RemoveAll(downloadDir)
+
+	packageFile, err := w.downloadObject(org, sd.ObjectType, sd.PackageObject, downloadDir)
+	if err != nil {
+		return err
+	}
+	unitFile, err := w.downloadObject(org, sd.ObjectType, sd.UnitObject, downloadDir)
+	if err != nil {
+		return err
+	}
+
+	// Save service secrets from the agreement into the microservice instance, they are written to the secrets
+	// directory of the agreement.
+	if err := w.secretMgr.ProcessServiceSecretsWithInstanceId(lc.AgreementId, lc.AgreementId); err != nil {
+		return errors.New(fmt.Sprintf("unable to save the secrets of agreement %v, error: %v", lc.AgreementId, err))
+	}
+
+	// The group that owns the secrets of the agreement lets the service user read them.
+	groups := []string{}
+	if _, err := os.Stat(w.secretMgr.GetSecretsPath(lc.AgreementId)); err == nil {
+		groups = append(groups, cutil.GetHashFromString(lc.AgreementId))
+	}
+
+	env := make(map[string]string)
+	if lc.EnvironmentAdditions != nil {
+		for k, v := range *lc.EnvironmentAdditions {
+			env[k] = v
+		}
+	}
+
+	if err := w.installer.Install(lc.AgreementId, sd, packageFile, unitFile, env, groups); err != nil {
+		// Don't leave a partial install behind.
+		w.installer.UnInstall(lc.AgreementId, sd)
+		return errors.New(fmt.Sprintf("unable to install systemd unit %v, error: %v", sd, err))
+	}
+
+	glog.V(5).Infof(sdwlog(fmt.Sprintf("completed install of systemd unit %v", sd.UnitName)))
+
+	return nil
+}
+
+// Download an MMS object from the CSS into the given directory and return the path of the file. The object must be
+// signed, the data is verified against the signature.
+func (w *SystemdWorker) downloadObject(org string, objType string, objId string, filePath string) (string, error) {
+
+	glog.V(3).Infof(sdwlog(fmt.Sprintf("downloading css object %v/%v/%v to %v", org, objType, objId, filePath)))
+
+	objMeta, err := exchange.GetObject(w, org, objId, objType)
+	if err != nil {
+		return "", errors.New(fmt.Sprintf("failed to get metadata for css object %v/%v/%v, error: %v", org, objType, objId, err))
+	} else if objMeta == nil {
+		return "", errors.New(fmt.Sprintf("css object %v/%v/%v not found", org, objType, objId))
+	}
+
+	if objMeta.HashAlgorithm == "" || objMeta.PublicKey == "" || objMeta.Signature == "" {
+		return "", errors.New(fmt.Sprintf("css object %v/%v/%v is not signed", org, objType, objId))
+	}
+
+	// The object is signed by whoever published it, the key must be trusted by the node like the keys that sign the
+	// deployments of services.
+	if keyFileNames, err := w.Config.Collaborators.KeyFileNamesFetcher.GetKeyFileNames(w.Config.Edge.PublicKeyPath, w.Config.UserPublicKeyPath()); err != nil {
+		return "", errors.New(fmt.Sprintf("unable to get the trusted public keys, error: %v", err))
+	} else if keyFile, err := trustedKeyFile(keyFileNames, objMeta.PublicKey); err != nil {
+		return "", errors.New(fmt.Sprintf("css object %v/%v/%v is not signed by a trusted key, error: %v", org, objType, objId, err))
+	} else {
+		glog.V(5).Infof(sdwlog(fmt.Sprintf("css object %v/%v/%v is signed by the key in %v", org, objType, objId, keyFile)))
+	}
+
+	// The object id can look like a path, the file is named after its last element.
+	fileName := path.Base(objId)
+	if err := exchange.GetObjectData(w, org, objType, objId, filePath, fileName, objMeta, true); err != nil {
+		return "", errors.New(fmt.Sprintf("failed to get data for css object %v/%v/%v, error: %v", org, objType, objId, err))
+	}
+
+	fullName := path.Join(filePath, fileName)
+	tmpFileName := fmt.Sprintf("%v.tmp", fullName)
+	if verified, err := cutil.VerifyDataSigInFile(tmpFileName, objMeta.PublicKey, objMeta.Signature, objMeta.HashAlgorithm, fullName); !verified {
+		os.Remove(fullName)
+		os.Remove(tmpFileName)
+		return "", errors.New(fmt.Sprintf("failed to verify data signature for css object %v/%v/%v, error: %v", org, objType, objId, err))
+	}
+
+	return fullName, nil
+}
+
+// Returns the trusted key or cert file that holds the public key of an MMS object signature. The public key is base64
+// encoded DER, as it is in the metadata of the object.
+func trustedKeyFile(keyOrCertFiles []string, publicKey string) (string, error) {
+	type comparableKey interface {
+		Equal(x crypto.PublicKey) bool
+	}
+
+	keyBytes, err := base64.StdEncoding.DecodeString(publicKey)
+	if err != nil {
+		return "", errors.New(fmt.Sprintf("unable to base64 decode public key, error: %v", err))
+	}
+	pubKey, err := x509.ParsePKIXPublicKey(keyBytes)
+	if err != nil {
+		return "", errors.New(fmt.Sprintf("unable to parse public key, error: %v", err))
+	}
+	key, ok := pubKey.(comparableKey)
+	if !ok {
+		return "", errors.New(fmt.Sprintf("unsupported public key type %T", pubKey))
+	}
+
+	for _, keyOrCertFile := range keyOrCertFiles {
+		if content, err := os.ReadFile(keyOrCertFile); err != nil {
+			glog.Warningf(sdwlog(fmt.Sprintf("unable to read key file %v, error: %v", keyOrCertFile, err)))
+		} else if trusted, err := cutil.ValidPublicKeyOrCert(content); err != nil {
+			glog.Warningf(sdwlog(fmt.Sprintf("unable to use key file %v, error: %v", keyOrCertFile, err)))
+		} else if key.Equal(trusted) {
+			return keyOrCertFile, nil
+		}
+	}
+	return "", errors.New(fmt.Sprintf("the public key is not in any of the %v trusted key files", len(keyOrCertFiles)))
+}
+
+// Returns true when the unit has stopped. A unit that is starting or restarting is not considered failed.
+func IsUnitFailed(status *UnitStatus) bool {
+	return status.ActiveState == "failed" || status.ActiveState == "inactive"
+}
+
+func getEC(cfg *config.HorizonConfig, db persistence.AgentDatabase) *worker.BaseExchangeContext {
+	var ec *worker.BaseExchangeContext
+	if dev, _ := persistence.FindExchangeDevice(db); dev != nil {
+		ec = worker.NewExchangeContext(fmt.Sprintf("%v/%v", dev.Org, dev.Id), dev.Token, cfg.Edge.ExchangeURL, cfg.GetCSSURL(), cfg.Edge.AgbotURL, cfg.Collaborators.HTTPClientFactory)
+	}
+
+	return ec
+}
+
+var sdwlog = func(v interface{}) string {
+	return fmt.Sprintf("Systemd Worker: %v", v)
+}
@@ -0,0 +1,58 @@
+//go:build unit
+// +build unit
+
+package systemd
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/open-horizon/anax/cutil"
+)
+
+func Test_trustedKeyFile(t *testing.T) {
+
+	dir := t.TempDir()
+	writeKey := func(name string) string {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			t.Fatalf("unable to generate key, error: %v", err)
+		}
+		pemBytes, err := cutil.MarshalPublicKeyPEM(key.Public())
+		if err != nil {
+			t.Fatalf("unable to marshal key, error: %v", err)
+		} else if err := os.WriteFile(path.Join(dir, name), pemBytes, 0644); err != nil {
+			t.Fatalf("unable to write key file, error: %v", err)
+		}
+		derBytes, err := x509.MarshalPKIXPublicKey(key.Public())
+		if err != nil {
+			t.Fatalf("unable to marshal key, error: %v", err)
+		}
+		return base64.StdEncoding.EncodeToString(derBytes)
+	}
+
+	trusted := writeKey("trusted.pem")
+	other := writeKey("other.pem")
+	keyFiles := []string{path.Join(dir, "missing.pem"), path.Join(dir, "trusted.pem")}
+
+	if keyFile, err := trustedKeyFile(keyFiles, trusted); err != nil {
+		t.Errorf("unexpected error finding the trusted key, error: %v", err)
+	} else if keyFile != path.Join(dir, "trusted.pem") {
+		t.Errorf("unexpected key file %v", keyFile)
+	}
+
+	// The key of the object signature is not in the trust store.
+	if _, err := trustedKeyFile(keyFiles, other); err == nil {
+		t.Errorf("expected an error for a key that is not trusted")
+	} else if _, err := trustedKeyFile(keyFiles, "not a key"); err == nil {
+		t.Errorf("expected an error for an invalid key")
+	} else if _, err := trustedKeyFile(nil, trusted); err == nil {
+		t.Errorf("expected an error without trusted keys")
+	}
+}
@@ -386,6 +386,24 @@ else
 fi
 unset HZN_PRIVATE_KEY_FILE
 
+# Test object publish with an ECDSA key, which the sync service cannot verify
+echo "Testing object publish with an ECDSA key is rejected"
+openssl ecparam -name prime256v1 -genkey -noout -out /tmp/mms.ecdsa.private.key
+PUBLIC_KEY_BEFORE=$(hzn mms object list --objectType=test --objectId=test1 -l | awk '{if(NR>1)print}' | jq -r '.[0].publicKey')
+hzn mms object publish -m /tmp/meta.json -f /tmp/data-small.txt -k /tmp/mms.ecdsa.private.key >/dev/null 2>&1
+RC=$?
+rm /tmp/mms.ecdsa.private.key
+if [ $RC -eq 0 ]
+then
+  echo -e "Object publish with an ECDSA key should fail"
+  exit 1
+elif [ "$(hzn mms object list --objectType=test --objectId=test1 -l | awk '{if(NR>1)print}' | jq -r '.[0].publicKey')" != "${PUBLIC_KEY_BEFORE}" ]; then
+  echo -e "Object should not be updated when publish with an ECDSA key fails"
+  exit 1
+else
+  echo "Completed"
+fi
+
 # Test object publish with default keyfile path set (~/.hzn/keys/service.private.key)
 echo "Testing object publish with default path set (~/.hzn/keys/service.private.key)"
 hzn mms object publish -m /tmp/meta.json -f /tmp/data-small.txt >/dev/null
@@ -0,0 +1,213 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/open-horizon/anax/config"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.41.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// OpenTelemetry tracing of the agreement negotiation lifecycle. An agreement is traced with a root span that starts when
+// the agbot begins to consider a node and a root span on the node that starts when the proposal is received. The steps
+// of the negotiation (proposal creation, message delivery, the node's decision, governance, image fetch, container
+// start, finalization) are child spans of these root spans, which are found by agreement id because the steps run on
+// different workers. The trace context travels between the agbot and the node in the protocol message envelope, so the
+// spans on both sides end up in the same trace.
+//
+// When tracing is not configured, the global tracer provider is a no-op and none of the functions in this package
+// record anything.
+
+const TRACER_NAME = "github.com/open-horizon/anax"
+
+const SERVICE_NAME = "anax"
+
+// Span attributes.
+const ATTR_AGREEMENT_ID = "horizon.agreement.id"
+const ATTR_NODE_ID = "horizon.node.id"
+const ATTR_POLICY_NAME = "horizon.policy.name"
+const ATTR_MESSAGE_TYPE = "horizon.protocol.message_type"
+const ATTR_PROPOSAL_ACCEPTED = "horizon.proposal.accepted"
+
+// The propagator that reads and writes the W3C trace context in the protocol messages.
+var propagator = propagation.TraceContext{}
+
+var tracerProvider *sdktrace.TracerProvider
+
+// The root span of each agreement that is being traced by this process, by agreement id.
+var agreementSpans = make(map[string]trace.Span)
+var agreementSpansLock sync.Mutex
+
+// Initialize the tracer provider with the configured exporter. This is a no-op when tracing is not configured.
+func Init(cfg *config.TracingConfig, serviceVersion string) error {
+
+	if !cfg.IsEnabled() {
+		return nil
+	}
+
+	var exporter sdktrace.SpanExporter
+	switch cfg.Exporter {
+	case config.TRACING_EXPORTER_OTLP:
+		opts := []otlptracehttp.Option{otlptracehttp.WithEndpoint(cfg.OTLPEndpoint)}
+		if cfg.OTLPInsecure {
+			opts = append(opts, otlptracehttp.WithInsecure())
+		}
+		if e, err := otlptracehttp.New(context.Background(), opts...); err != nil {
+			return errors.New(fmt.Sprintf("unable to create OTLP trace exporter for %v, error: %v", cfg.OTLPEndpoint, err))
+		} else {
+			exporter = e
+		}
+	case config.TRACING_EXPORTER_FILE:
+		if err := os.MkdirAll(filepath.Dir(cfg.FilePath), 0750); err != nil {
+			return errors.New(fmt.Sprintf("unable to create directory for trace file %v, error: %v", cfg.FilePath, err))
+		} else if f, err := os.OpenFile(filepath.Clean(cfg.FilePath), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600); err != nil {
+			return errors.New(fmt.Sprintf("unable to open trace file %v, error: %v", cfg.FilePath, err))
+		} else if e, err := stdouttrace.New(stdouttrace.WithWriter(f)); err != nil {
+			return errors.New(fmt.Sprintf("unable to create file trace exporter for %v, error: %v", cfg.FilePath, err))
+		} else {
+			exporter = e
+		}
+	default:
+		return errors.New(fmt.Sprintf("tracing exporter %v is not supported", cfg.Exporter))
+	}
+
+	res := resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceName(SERVICE_NAME), semconv.ServiceVersion(serviceVersion))
+	tracerProvider = sdktrace.NewTracerProvider(sdktrace.WithBatcher(exporter), sdktrace.WithResource(res))
+	otel.SetTracerProvider(tracerProvider)
+
+	glog.V(3).Infof(tracingLogString(fmt.Sprintf("exporting agreement traces with %v", cfg.String())))
+	return nil
+}
+
+// Flush the spans that have not been exported yet and stop the exporter.
+func Shutdown() {
+	if tracerProvider == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := tracerProvider.Shutdown(ctx); err != nil {
+		glog.Errorf(tracingLogString(fmt.Sprintf("error flushing traces, error: %v", err)))
+	}
+}
+
+func tracer() trace.Tracer {
+	return otel.Tracer(TRACER_NAME)
+}
+
+// Start the root span of an agreement in this process. The carrier is the trace context from a protocol message, when
+// the other party started the trace, otherwise it is nil and a new trace is started. The span is only remembered when it
+// is recording, so that nothing accumulates when tracing is disabled.
+func StartAgreement(agreementId string, name string, carrier map[string]string, opts ...trace.SpanStartOption) {
+
+	ctx := propagator.Extract(context.Background(), propagation.MapCarrier(carrier))
+	opts = append(opts, trace.WithAttributes(attribute.String(ATTR_AGREEMENT_ID, agreementId)))
+	_, span := tracer().Start(ctx, name, opts...)
+	if !span.IsRecording() {
+		return
+	}
+
+	agreementSpansLock.Lock()
+	defer agreementSpansLock.Unlock()
+	if old, ok := agreementSpans[agreementId]; ok {
+		old.End()
+	}
+	agreementSpans[agreementId] = span
+}
+
+// End the root span of an agreement. A non-nil error marks the agreement as failed. It is safe to call this for an
+// agreement that is not traced, or more than once for the same agreement.
+func EndAgreement(agreementId string, err error) {
+
+	agreementSpansLock.Lock()
+	span, ok := agreementSpans[agreementId]
+	delete(agreementSpans, agreementId)
+	agreementSpansLock.Unlock()
+
+	if ok {
+		EndSpan(span, err)
+	}
+}
+
+// Add attributes to the root span of an agreement.
+func SetAgreementAttributes(agreementId string, attrs ...attribute.KeyValue) {
+	if span := agreementSpan(agreementId); span != nil {
+		span.SetAttributes(attrs...)
+	}
+}
+
+func agreementSpan(agreementId string) trace.Span {
+	agreementSpansLock.Lock()
+	defer agreementSpansLock.Unlock()
+	return agreementSpans[agreementId]
+}
+
+// Start a span for a step of an agreement. The span is a child of the agreement's root span. If the agreement is not
+// traced by this process, the span is a child of the trace context in the carrier, if there is one, otherwise the span
+// does not record anything.
+func StartSpan(agreementId string, name string, carrier map[string]string, attrs ...attribute.KeyValue) trace.Span {
+
+	var ctx context.Context
+	if root := agreementSpan(agreementId); root != nil {
+		ctx = trace.ContextWithSpan(context.Background(), root)
+	} else if carrier != nil {
+		ctx = propagator.Extract(context.Background(), propagation.MapCarrier(carrier))
+	}
+
+	if ctx == nil || !trace.SpanContextFromContext(ctx).IsValid() {
+		return trace.SpanFromContext(context.Background())
+	}
+
+	attrs = append(attrs, attribute.String(ATTR_AGREEMENT_ID, agreementId))
+	_, span := tracer().Start(ctx, name, trace.WithAttributes(attrs...))
+	return span
+}
+
+// Record a step of an agreement that has already happened, as a child of the agreement's root span.
+func RecordSpan(agreementId string, name string, start time.Time, end time.Time, attrs ...attribute.KeyValue) {
+	if root := agreementSpan(agreementId); root != nil {
+		attrs = append(attrs, attribute.String(ATTR_AGREEMENT_ID, agreementId))
+		_, span := tracer().Start(trace.ContextWithSpan(context.Background(), root), name, trace.WithTimestamp(start), trace.WithAttributes(attrs...))
+		span.End(trace.WithTimestamp(end))
+	}
+}
+
+// End a span, marking it as failed when there is an error.
+func EndSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// Return the trace context of a span, to be sent to the other party in a protocol message so that its spans are
+// children of this span. The result is nil when the span is not recording.
+func TraceContext(span trace.Span) map[string]string {
+	if !span.IsRecording() {
+		return nil
+	}
+
+	carrier := propagation.MapCarrier{}
+	propagator.Inject(trace.ContextWithSpan(context.Background(), span), carrier)
+	return carrier
+}
+
+var tracingLogString = func(v interface{}) string {
+	return fmt.Sprintf("Tracing: %v", v)
+}
@@ -0,0 +1,71 @@
+//go:build unit
+// +build unit
+
+package tracing
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func Test_AgreementTrace(t *testing.T) {
+
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+
+	// The agbot side of the agreement.
+	StartAgreement("ag1", "agbot.Agreement", nil)
+	RecordSpan("ag1", "agbot.Search", time.Now().Add(-time.Second), time.Now())
+	send := StartSpan("ag1", "abstractprotocol.SendProtocolMessage", nil)
+	carrier := TraceContext(send)
+	send.End()
+	assert.NotEmpty(t, carrier["traceparent"], "The trace context should be in the carrier.")
+
+	// The node side of the agreement continues the trace from the message.
+	StartAgreement("ag2", "agent.Agreement", carrier)
+	step := StartSpan("ag2", "governance.RecordReply", nil)
+	EndSpan(step, nil)
+	EndAgreement("ag2", errors.New("agreement cancelled"))
+	EndAgreement("ag1", nil)
+
+	// Ending an agreement that is no longer traced does nothing.
+	EndAgreement("ag1", nil)
+
+	spans := recorder.Ended()
+	assert.Equal(t, 5, len(spans), "All the spans should have ended.")
+
+	byName := make(map[string]sdktrace.ReadOnlySpan)
+	for _, s := range spans {
+		byName[s.Name()] = s
+	}
+
+	root := byName["agbot.Agreement"]
+	for _, name := range []string{"agbot.Search", "abstractprotocol.SendProtocolMessage", "agent.Agreement", "governance.RecordReply"} {
+		assert.Equal(t, root.SpanContext().TraceID(), byName[name].SpanContext().TraceID(), name+" should be in the agreement trace.")
+	}
+	assert.Equal(t, byName["abstractprotocol.SendProtocolMessage"].SpanContext().SpanID(), byName["agent.Agreement"].Parent().SpanID(), "The node's root span should be a child of the message span.")
+	assert.Equal(t, codes.Error, byName["agent.Agreement"].Status().Code, "The cancelled agreement should be marked as failed.")
+	assert.Equal(t, codes.Unset, root.Status().Code, "The finalized agreement should not be marked as failed.")
+	assert.Equal(t, 0, len(agreementSpans), "There should be no agreements left.")
+}
+
+func Test_UntracedAgreement(t *testing.T) {
+
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+
+	// A step of an agreement that is not traced by this process and has no trace context does not record anything.
+	span := StartSpan("ag3", "imagefetch.FetchImages", nil)
+	assert.False(t, span.IsRecording(), "The span should not be recording.")
+	assert.Nil(t, TraceContext(span), "There should be no trace context.")
+	EndSpan(span, nil)
+
+	assert.Equal(t, 0, len(recorder.Ended()), "There should be no spans.")
+}
@@ -0,0 +1,52 @@
+package worker
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Collects the status of the workers and subworkers from the worker status manager on each scrape. The status metrics
+// are set to 1 for the current status of each worker, so that they can be matched on the status label. The last run
+// time of the subworkers shows whether they are still alive.
+type workerStatusCollector struct {
+	workerStatus     *prometheus.Desc
+	subworkerStatus  *prometheus.Desc
+	subworkerLastRun *prometheus.Desc
+}
+
+func NewWorkerStatusCollector() prometheus.Collector {
+	return &workerStatusCollector{
+		workerStatus: prometheus.NewDesc("horizon_worker_status",
+			"Set to 1 for the current status of each worker.",
+			[]string{"worker", "status"}, nil),
+		subworkerStatus: prometheus.NewDesc("horizon_subworker_status",
+			"Set to 1 for the current status of each subworker.",
+			[]string{"worker", "subworker", "status"}, nil),
+		subworkerLastRun: prometheus.NewDesc("horizon_subworker_last_run_timestamp_seconds",
+			"Time each subworker last finished a run, in seconds since the epoch.",
+			[]string{"worker", "subworker"}, nil),
+	}
+}
+
+func (c *workerStatusCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.workerStatus
+	ch <- c.subworkerStatus
+	ch <- c.subworkerLastRun
+}
+
+func (c *workerStatusCollector) Collect(ch chan<- prometheus.Metric) {
+	wsm := GetWorkerStatusManager()
+	wsm.ManagerLock.Lock()
+	defer wsm.ManagerLock.Unlock()
+
+	for name, ws := range wsm.Workers {
+		ws.StatusLock.Lock()
+		ch <- prometheus.MustNewConstMetric(c.workerStatus, prometheus.GaugeValue, 1, name, ws.Status)
+		for subname, status := range ws.SubworkerStatus {
+			ch <- prometheus.MustNewConstMetric(c.subworkerStatus, prometheus.GaugeValue, 1, name, subname, status)
+		}
+		for subname, t := range ws.SubworkerLastRun {
+			ch <- prometheus.MustNewConstMetric(c.subworkerLastRun, prometheus.GaugeValue, float64(t), name, subname)
+		}
+		ws.StatusLock.Unlock()
+	}
+}
@@ -480,6 +480,7 @@ func (w *BaseWorker) DispatchSubworker(name string, runSubWorker func() int, int
 					glog.V(3).Infof(cdLogString(fmt.Sprintf("Running subworker %v", name)))
 				}
 				returnedWait := runSubWorker()
+				workerStatusManager.SetSubworkerLastRun(w.GetName(), name)
 				if !logOptOut {
 					glog.V(3).Infof(cdLogString(fmt.Sprintf("Finished run of subworker %v", name)))
 				}
@@ -29,10 +29,11 @@ func resetWorkerStatusManager() {
 
 // status for a worker
 type WorkerStatus struct {
-	Name            string            `json:"name"`
-	Status          string            `json:"status"`
-	SubworkerStatus map[string]string `json:"subworker_status"`
-	StatusLock      sync.Mutex        `json:"-"` // The lock that protects modification from different threads at the same time
+	Name             string            `json:"name"`
+	Status           string            `json:"status"`
+	SubworkerStatus  map[string]string `json:"subworker_status"`
+	SubworkerLastRun map[string]int64  `json:"-"` // The time each subworker last finished a run, in seconds since the epoch
+	StatusLock       sync.Mutex        `json:"-"` // The lock that protects modification from different threads at the same time
 }
 
 func (w *WorkerStatus) SetWorkerStatus(status string) {
@@ -49,6 +50,13 @@ func (w *WorkerStatus) SetSubworkerStatus(name string, status string) {
 	w.SubworkerStatus[name] = status
 }
 
+func (w *WorkerStatus) SetSubworkerLastRun(name string, t int64) {
+	w.StatusLock.Lock()
+	defer w.StatusLock.Unlock()
+
+	w.SubworkerLastRun[name] = t
+}
+
 type WorkerStatusManager struct {
 	Workers     map[string]*WorkerStatus `json:"workers"`
 	StatusLog   []string                 `json:"worker_status_log"`
@@ -68,9 +76,10 @@ func (w *WorkerStatusManager) SetWorkerStatus(name string, status string) {
 
 	if _, ok := w.Workers[name]; !ok {
 		w.Workers[name] = &WorkerStatus{
-			Name:            name,
-			Status:          status,
-			SubworkerStatus: make(map[string]string),
+			Name:             name,
+			Status:           status,
+			SubworkerStatus:  make(map[string]string),
+			SubworkerLastRun: make(map[string]int64),
 		}
 	} else {
 		w.Workers[name].SetWorkerStatus(status)
@@ -86,9 +95,10 @@ func (w *WorkerStatusManager) SetSubworkerStatus(name string, subname string, st
 
 	if _, ok := w.Workers[name]; !ok {
 		w.Workers[name] = &WorkerStatus{
-			Name:            name,
-			Status:          STATUS_NONE,
-			SubworkerStatus: make(map[string]string),
+			Name:             name,
+			Status:           STATUS_NONE,
+			SubworkerStatus:  make(map[string]string),
+			SubworkerLastRun: make(map[string]int64),
 		}
 	}
 	w.Workers[name].SetSubworkerStatus(subname, status)
@@ -97,6 +107,16 @@ func (w *WorkerStatusManager) SetSubworkerStatus(name string, subname string, st
 	w.StatusLog = append(w.StatusLog, fmt.Sprintf("%v Worker %v: subworker %v %v.", time_s, name, subname, status))
 }
 
+// Record that a subworker finished a run. This is not added to the status log because subworkers run all the time.
+func (w *WorkerStatusManager) SetSubworkerLastRun(name string, subname string) {
+	w.ManagerLock.Lock()
+	defer w.ManagerLock.Unlock()
+
+	if ws, ok := w.Workers[name]; ok {
+		ws.SetSubworkerLastRun(subname, time.Now().Unix())
+	}
+}
+
 // Get the status string for the given worker. It returns an empty string if the worker does not exist.
 func (w *WorkerStatusManager) GetWorkerStatus(name string) string {
 	if ws, ok := w.Workers[name]; ok {
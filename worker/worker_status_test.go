@@ -4,6 +4,7 @@
 package worker
 
 import (
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/stretchr/testify/assert"
 	"testing"
 )
@@ -54,3 +55,24 @@ func Test_SubworkerStatus(t *testing.T) {
 	assert.Equal(t, STATUS_ADDED, workerStatusManager.GetSubworkerStatus("worker2", "sub2"), "The status for worker2 subworker sub2 should be "+STATUS_ADDED)
 	assert.Equal(t, STATUS_ADDED, workerStatusManager.GetSubworkerStatus("worker3", "sub1"), "The status for worker3 subworker sub2 should be "+STATUS_ADDED)
 }
+
+func Test_SubworkerLastRun(t *testing.T) {
+
+	// reset the workerStatusManager for testing
+	workerStatusManager = NewWorkerStatusManager()
+
+	workerStatusManager.SetWorkerStatus("worker1", STATUS_STARTED)
+	workerStatusManager.SetSubworkerStatus("worker1", "sub1", STATUS_STARTED)
+	workerStatusManager.SetSubworkerLastRun("worker1", "sub1")
+	workerStatusManager.SetSubworkerLastRun("worker2", "sub1")
+
+	assert.Equal(t, 2, len(workerStatusManager.StatusLog), "Subworker runs should not be logged.")
+	assert.NotEqual(t, int64(0), workerStatusManager.Workers["worker1"].SubworkerLastRun["sub1"], "The last run of worker1 subworker sub1 should be set.")
+	assert.Equal(t, 1, len(workerStatusManager.Workers), "A subworker run should not add a worker.")
+
+	c := NewWorkerStatusCollector()
+	ch := make(chan prometheus.Metric, 10)
+	c.Collect(ch)
+	close(ch)
+	assert.Equal(t, 3, len(ch), "There should be a worker status, a subworker status and a subworker last run metric.")
+}